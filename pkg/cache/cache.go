@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"encoding/json"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// Cache a cache shared between processes such as the replicas of the service so that they do not look up the same
+// users and issues or render the same changelogs via the git provider API. Entries expire after the time to live of
+// the cache
+type Cache interface {
+	// Get returns the value of the key or nil if it is not cached or has expired
+	Get(key string) ([]byte, error)
+
+	// Set caches the value of the key
+	Set(key string, value []byte) error
+}
+
+// GetJSON unmarshals the cached value of the key returning false if the cache is nil or the key is not cached.
+// Failures are logged rather than returned as the value can be looked up again
+func GetJSON(c Cache, key string, value interface{}) bool {
+	if c == nil {
+		return false
+	}
+	data, err := c.Get(key)
+	if err != nil {
+		log.Logger().Warnf("failed to get %s from the shared cache: %s", key, err.Error())
+		return false
+	}
+	if len(data) == 0 {
+		return false
+	}
+	err = json.Unmarshal(data, value)
+	if err != nil {
+		log.Logger().Warnf("failed to parse %s from the shared cache: %s", key, err.Error())
+		return false
+	}
+	return true
+}
+
+// SetJSON caches the value of the key as JSON if the cache is not nil logging any failure
+func SetJSON(c Cache, key string, value interface{}) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = c.Set(key, data)
+	}
+	if err != nil {
+		log.Logger().Warnf("failed to add %s to the shared cache: %s", key, err.Error())
+	}
+}
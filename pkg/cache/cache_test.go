@@ -0,0 +1,181 @@
+// +build unit
+
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type user struct {
+	Login string
+}
+
+func TestKubeCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset()
+	for _, newCache := range []func(ttl time.Duration) *cache.KubeCache{
+		func(ttl time.Duration) *cache.KubeCache {
+			return cache.NewConfigMapCache(ctx, kubeClient, "jx", "jx-changelog-cache", ttl)
+		},
+		func(ttl time.Duration) *cache.KubeCache {
+			return cache.NewSecretCache(ctx, kubeClient, "jx", "jx-changelog-cache", ttl)
+		},
+	} {
+		c := newCache(time.Hour)
+		value := &user{}
+		assert.False(t, cache.GetJSON(c, "users/github.com/login/jstrachan", value))
+
+		cache.SetJSON(c, "users/github.com/login/jstrachan", &user{Login: "jstrachan"})
+		cache.SetJSON(c, "users/github.com/login/rawlingsj", &user{Login: "rawlingsj"})
+		kubeClient.ClearActions()
+		require.True(t, cache.GetJSON(c, "users/github.com/login/jstrachan", value))
+		assert.Equal(t, "jstrachan", value.Login)
+		assert.True(t, cache.GetJSON(c, "users/github.com/login/rawlingsj", value))
+		assert.Empty(t, kubeClient.Actions(), "the entries should be read from the local copy of the resource")
+
+		cache.SetJSON(newCache(time.Millisecond), "users/github.com/login/jstrachan", &user{Login: "expired"})
+		time.Sleep(2 * time.Millisecond)
+		other := newCache(time.Hour)
+		assert.False(t, cache.GetJSON(other, "users/github.com/login/jstrachan", value), "the entry should have expired")
+		assert.True(t, cache.GetJSON(other, "users/github.com/login/rawlingsj", value))
+		cache.SetJSON(c, "users/github.com/login/jenkins-x-bot", &user{Login: "jenkins-x-bot"})
+		assert.False(t, cache.GetJSON(c, "users/github.com/login/jstrachan", value), "the update should refresh the local copy")
+	}
+	cm, err := kubeClient.CoreV1().ConfigMaps("jx").Get(ctx, "jx-changelog-cache", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, cm.Data, 2, "the expired entry should have been removed")
+	secret, err := kubeClient.CoreV1().Secrets("jx").Get(ctx, "jx-changelog-cache", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, secret.Data, 2)
+
+	assert.False(t, cache.GetJSON(nil, "users/github.com/login/jstrachan", &user{}), "a nil cache caches nothing")
+	cache.SetJSON(nil, "users/github.com/login/jstrachan", &user{})
+}
+
+func TestKubeCacheConcurrentSets(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset()
+	c := cache.NewConfigMapCache(ctx, kubeClient, "jx", "jx-changelog-cache", time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.SetJSON(c, "users/github.com/login/user"+strconv.Itoa(i), &user{Login: "user" + strconv.Itoa(i)})
+		}(i)
+	}
+	wg.Wait()
+
+	other := cache.NewConfigMapCache(ctx, kubeClient, "jx", "jx-changelog-cache", time.Hour)
+	for i := 0; i < 20; i++ {
+		value := &user{}
+		require.True(t, cache.GetJSON(other, "users/github.com/login/user"+strconv.Itoa(i), value), "the entry %d should have been added", i)
+		assert.Equal(t, "user"+strconv.Itoa(i), value.Login)
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	lock := sync.Mutex{}
+	values := map[string]string{}
+	var commands []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveRedis(conn, func(args []string) string {
+				lock.Lock()
+				defer lock.Unlock()
+				commands = append(commands, strings.Join(args, " "))
+				switch args[0] {
+				case "AUTH":
+					if args[1] != "secret" {
+						return "-ERR invalid password\r\n"
+					}
+					return "+OK\r\n"
+				case "SET":
+					values[args[1]] = args[2]
+					return "+OK\r\n"
+				case "GET":
+					value, ok := values[args[1]]
+					if !ok {
+						return "$-1\r\n"
+					}
+					return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+				default:
+					return "-ERR unknown command\r\n"
+				}
+			})
+		}
+	}()
+
+	c := cache.NewRedisCache(listener.Addr().String(), "secret", 0, time.Minute)
+	defer c.Close()
+	data, err := c.Get("issues/123")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	require.NoError(t, c.Set("issues/123", []byte("{\"title\":\"a\r\nbug\"}")))
+	data, err = c.Get("issues/123")
+	require.NoError(t, err)
+	assert.Equal(t, "{\"title\":\"a\r\nbug\"}", string(data))
+	assert.Equal(t, []string{"AUTH secret", "GET jx-changelog:issues/123", "SET jx-changelog:issues/123 {\"title\":\"a\r\nbug\"} PX 60000", "GET jx-changelog:issues/123"}, commands)
+
+	wrong := cache.NewRedisCache(listener.Addr().String(), "wrong", 0, time.Minute)
+	defer wrong.Close()
+	_, err = wrong.Get("issues/123")
+	assert.Error(t, err)
+}
+
+// serveRedis replies to the commands of the connection which are arrays of bulk strings
+func serveRedis(conn net.Conn, reply func(args []string) string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		var args []string
+		for i := 0; i < count; i++ {
+			line, err = r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			arg := make([]byte, size+2)
+			_, err = io.ReadFull(r, arg)
+			if err != nil {
+				return
+			}
+			args = append(args, string(arg[:size]))
+		}
+		_, err = io.WriteString(conn, reply(args))
+		if err != nil {
+			return
+		}
+	}
+}
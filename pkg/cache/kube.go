@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// kubeCacheMaxSize the maximum size of the entries of the ConfigMap or Secret which are limited to 1MiB
+	kubeCacheMaxSize = 900 * 1024
+
+	// DefaultKubeCacheRefresh the default duration the local copy of the entries is used before it is loaded again
+	DefaultKubeCacheRefresh = 10 * time.Second
+)
+
+// KubeCache caches the entries in a ConfigMap or a Secret which is created if it does not exist. Expired entries are
+// removed when adding entries and the entries expiring soonest are removed to keep the resource below its size limit.
+// The entries are read from a local copy of the resource which is loaded again after Refresh and the entries set while
+// the resource is being updated are added together in the next update
+type KubeCache struct {
+	Ctx        context.Context
+	KubeClient kubernetes.Interface
+	Namespace  string
+	Name       string
+	Secret     bool
+	TTL        time.Duration
+	Refresh    time.Duration
+
+	lock     sync.Mutex
+	data     map[string][]byte
+	loaded   time.Time
+	pending  map[string][]byte
+	updating bool
+}
+
+// kubeEntry a cached value along with its key as the keys of the resource are hashes
+type kubeEntry struct {
+	Key     string    `json:"key"`
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// NewConfigMapCache creates a cache of the ConfigMap
+func NewConfigMapCache(ctx context.Context, kubeClient kubernetes.Interface, ns, name string, ttl time.Duration) *KubeCache {
+	return &KubeCache{Ctx: ctx, KubeClient: kubeClient, Namespace: ns, Name: name, TTL: ttl, Refresh: DefaultKubeCacheRefresh}
+}
+
+// NewSecretCache creates a cache of the Secret which is preferable to a ConfigMap if the issues are private
+func NewSecretCache(ctx context.Context, kubeClient kubernetes.Interface, ns, name string, ttl time.Duration) *KubeCache {
+	return &KubeCache{Ctx: ctx, KubeClient: kubeClient, Namespace: ns, Name: name, Secret: true, TTL: ttl, Refresh: DefaultKubeCacheRefresh}
+}
+
+// Get returns the value of the key or nil if it is not cached or has expired
+func (c *KubeCache) Get(key string) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil || time.Since(c.loaded) >= c.Refresh {
+		data, _, err := c.load()
+		if err != nil {
+			return nil, err
+		}
+		c.refreshed(data)
+	}
+	e := decodeEntry(c.data[entryName(key)])
+	if e == nil || e.Key != key || e.expired(time.Now()) {
+		return nil, nil
+	}
+	return e.Value, nil
+}
+
+// Set caches the value of the key. If the resource is being updated the entry is added by the next update of the
+// resource rather than updating it once per entry
+func (c *KubeCache) Set(key string, value []byte) error {
+	e := &kubeEntry{Key: key, Value: value}
+	if c.TTL > 0 {
+		e.Expires = time.Now().Add(c.TTL)
+	}
+	entry, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the cache entry %s", key)
+	}
+	name := entryName(key)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.pending == nil {
+		c.pending = map[string][]byte{}
+	}
+	c.pending[name] = entry
+	if c.data != nil {
+		c.data[name] = entry
+	}
+	if c.updating {
+		return nil
+	}
+	c.updating = true
+	defer func() {
+		c.updating = false
+	}()
+	for len(c.pending) > 0 {
+		entries := c.pending
+		c.pending = nil
+		c.lock.Unlock()
+		data, err := c.update(entries)
+		c.lock.Lock()
+		if err != nil {
+			return err
+		}
+		c.refreshed(data)
+	}
+	return nil
+}
+
+// update adds the entries to the resource retrying if the resource is updated concurrently by another replica and
+// returns the entries of the updated resource
+func (c *KubeCache) update(entries map[string][]byte) (map[string][]byte, error) {
+	var answer map[string][]byte
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		data, existing, err := c.load()
+		if err != nil {
+			return err
+		}
+		for name, entry := range entries {
+			data[name] = entry
+		}
+		prune(data, time.Now())
+		answer = data
+		return c.save(data, existing)
+	})
+	return answer, err
+}
+
+// refreshed replaces the local copy of the entries keeping the entries which are not added to the resource yet
+func (c *KubeCache) refreshed(data map[string][]byte) {
+	for name, entry := range c.pending {
+		data[name] = entry
+	}
+	c.data = data
+	c.loaded = time.Now()
+}
+
+// prune removes the expired entries then the entries expiring soonest until the entries fit in the resource
+func prune(data map[string][]byte, now time.Time) {
+	size := 0
+	var entries []*kubeEntry
+	names := map[*kubeEntry]string{}
+	for name, value := range data {
+		e := decodeEntry(value)
+		if e == nil || e.expired(now) {
+			delete(data, name)
+			continue
+		}
+		size += len(name) + len(value)
+		entries = append(entries, e)
+		names[e] = name
+	}
+	if size <= kubeCacheMaxSize {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Expires.Before(entries[j].Expires)
+	})
+	for _, e := range entries {
+		if size <= kubeCacheMaxSize {
+			return
+		}
+		name := names[e]
+		size -= len(name) + len(data[name])
+		delete(data, name)
+	}
+}
+
+// load returns the entries of the resource and its metadata which is nil if it does not exist
+func (c *KubeCache) load() (map[string][]byte, *metav1.ObjectMeta, error) {
+	data := map[string][]byte{}
+	if c.Secret {
+		secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(c.Ctx, c.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return data, nil, nil
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get Secret %s in namespace %s", c.Name, c.Namespace)
+		}
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+		return data, &secret.ObjectMeta, nil
+	}
+	cm, err := c.KubeClient.CoreV1().ConfigMaps(c.Namespace).Get(c.Ctx, c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return data, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", c.Name, c.Namespace)
+	}
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+	return data, &cm.ObjectMeta, nil
+}
+
+// save creates the resource with the entries if it does not exist or updates the existing resource version so that
+// concurrent updates conflict
+func (c *KubeCache) save(data map[string][]byte, existing *metav1.ObjectMeta) error {
+	meta := metav1.ObjectMeta{Name: c.Name, Namespace: c.Namespace}
+	if existing != nil {
+		meta = *existing
+	}
+	if c.Secret {
+		secrets := c.KubeClient.CoreV1().Secrets(c.Namespace)
+		secret := &corev1.Secret{ObjectMeta: meta, Data: data}
+		if existing == nil {
+			_, err := secrets.Create(c.Ctx, secret, metav1.CreateOptions{})
+			return c.wrap(err, "create", "Secret")
+		}
+		_, err := secrets.Update(c.Ctx, secret, metav1.UpdateOptions{})
+		return c.wrap(err, "update", "Secret")
+	}
+	configMaps := c.KubeClient.CoreV1().ConfigMaps(c.Namespace)
+	cm := &corev1.ConfigMap{ObjectMeta: meta, Data: map[string]string{}}
+	for k, v := range data {
+		cm.Data[k] = string(v)
+	}
+	if existing == nil {
+		_, err := configMaps.Create(c.Ctx, cm, metav1.CreateOptions{})
+		return c.wrap(err, "create", "ConfigMap")
+	}
+	_, err := configMaps.Update(c.Ctx, cm, metav1.UpdateOptions{})
+	return c.wrap(err, "update", "ConfigMap")
+}
+
+// wrap wraps the error keeping conflicts and already exists errors retryable
+func (c *KubeCache) wrap(err error, verb, kind string) error {
+	if err == nil {
+		return nil
+	}
+	if apierrors.IsAlreadyExists(err) {
+		// lets retry as another replica created the resource first
+		return apierrors.NewConflict(corev1.Resource(kind), c.Name, err)
+	}
+	if apierrors.IsConflict(err) {
+		return err
+	}
+	return errors.Wrapf(err, "failed to %s %s %s in namespace %s", verb, kind, c.Name, c.Namespace)
+}
+
+// entryName returns the name of the entry of the key in the resource which only allows alphanumeric names
+func entryName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:20])
+}
+
+func decodeEntry(data []byte) *kubeEntry {
+	if len(data) == 0 {
+		return nil
+	}
+	e := &kubeEntry{}
+	if json.Unmarshal(data, e) != nil {
+		return nil
+	}
+	return e
+}
+
+func (e *kubeEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
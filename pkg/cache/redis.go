@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// redisKeyPrefix the prefix of the keys of the cache in redis
+	redisKeyPrefix = "jx-changelog:"
+
+	// redisTimeout the maximum time to connect to redis or wait for the reply of a command
+	redisTimeout = 5 * time.Second
+)
+
+// RedisCache caches the entries in redis using the RESP protocol over a single connection which is reopened after
+// any failure
+type RedisCache struct {
+	Address  string
+	Password string
+	DB       int
+	TTL      time.Duration
+
+	lock   sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisCache creates a cache of the redis server at the address such as 'redis:6379'
+func NewRedisCache(address, password string, db int, ttl time.Duration) *RedisCache {
+	return &RedisCache{Address: address, Password: password, DB: db, TTL: ttl}
+}
+
+// Get returns the value of the key or nil if it is not cached
+func (c *RedisCache) Get(key string) ([]byte, error) {
+	reply, err := c.do("GET", redisKeyPrefix+key)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, errors.Errorf("unexpected reply %v to GET %s", reply, key)
+	}
+	return data, nil
+}
+
+// Set caches the value of the key expiring it after the time to live
+func (c *RedisCache) Set(key string, value []byte) error {
+	args := []string{"SET", redisKeyPrefix + key, string(value)}
+	if c.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(c.TTL.Milliseconds(), 10))
+	}
+	_, err := c.do(args...)
+	return err
+}
+
+// Close closes the connection to redis
+func (c *RedisCache) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.close()
+}
+
+// do runs the command returning its reply
+func (c *RedisCache) do(args ...string) (interface{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn == nil {
+		err := c.connect()
+		if err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.command(args...)
+	if err != nil {
+		if _, ok := err.(redisError); !ok {
+			// lets reconnect on the next command as the connection may be broken
+			c.close() //nolint:errcheck
+		}
+		return nil, errors.Wrapf(err, "failed to run redis command %s", args[0])
+	}
+	return reply, nil
+}
+
+func (c *RedisCache) connect() error {
+	conn, err := net.DialTimeout("tcp", c.Address, redisTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "failed to connect to redis at %s", c.Address)
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+	if c.Password != "" {
+		_, err = c.command("AUTH", c.Password)
+		if err != nil {
+			c.close() //nolint:errcheck
+			return errors.Wrapf(err, "failed to authenticate with redis at %s", c.Address)
+		}
+	}
+	if c.DB != 0 {
+		_, err = c.command("SELECT", strconv.Itoa(c.DB))
+		if err != nil {
+			c.close() //nolint:errcheck
+			return errors.Wrapf(err, "failed to select redis database %d", c.DB)
+		}
+	}
+	return nil
+}
+
+func (c *RedisCache) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	return err
+}
+
+// command writes the command as an array of bulk strings and reads the reply
+func (c *RedisCache) command(args ...string) (interface{}, error) {
+	err := c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if err != nil {
+		return nil, err
+	}
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err = io.WriteString(c.conn, buf.String())
+	if err != nil {
+		return nil, err
+	}
+	return readReply(c.reader)
+}
+
+// redisError an error reply of redis which leaves the connection usable
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// readReply reads a simple string, error, integer or bulk string reply. A nil bulk string is returned as nil
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid redis bulk string size %s", line[1:])
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	default:
+		return nil, errors.Errorf("unsupported redis reply %q", line)
+	}
+}
@@ -0,0 +1,178 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultApprovalDir the directory of the repository the release notes awaiting approval are committed to
+	DefaultApprovalDir = "release-notes"
+
+	// approvalBranchPrefix the prefix of the branches of the pull requests approving release notes
+	approvalBranchPrefix = "changelog/"
+)
+
+// ApprovalFile returns the path of the release notes of the tag in the approval directory
+func ApprovalFile(dir, tag string) string {
+	if dir == "" {
+		dir = DefaultApprovalDir
+	}
+	return filepath.Join(dir, tag+".md")
+}
+
+// TagOfApprovalFile returns the tag of the release notes file in the approval directory such as 'v1.2.3' for
+// 'release-notes/v1.2.3.md'
+func TagOfApprovalFile(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// approvalPublisher commits the release notes to a branch and opens a pull request on the default branch so that
+// they are reviewed before 'jx-changelog publish --from-file' creates the release once the pull request is merged
+type approvalPublisher struct {
+	g *Generator
+}
+
+func (p *approvalPublisher) Name() string {
+	return "approval-pr"
+}
+
+func (p *approvalPublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	tag, err := g.releaseTag(result.Release.Spec.Version)
+	if err != nil {
+		return err
+	}
+	result.Tag = tag
+	path := ApprovalFile(g.ApprovalDir, tag)
+	branch := approvalBranchPrefix + tag
+	base := g.State.DefaultBranch
+	if base == "" {
+		base = g.defaultBranch()
+	}
+
+	git := g.Git()
+	dir := g.ScmFactory.Dir
+	tmpDir, err := ioutil.TempDir("", "jx-changelog-approval-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	_, err = git.Command(dir, "fetch", "origin", base)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch the branch %s", base)
+	}
+	_, err = git.Command(dir, "worktree", "add", "-B", branch, tmpDir, "FETCH_HEAD")
+	if err != nil {
+		return errors.Wrapf(err, "failed to check out the branch %s", branch)
+	}
+	defer git.Command(dir, "worktree", "remove", "--force", tmpDir) //nolint:errcheck
+
+	err = writeSiteFile(filepath.Join(tmpDir, path), []byte(result.Markdown))
+	if err != nil {
+		return err
+	}
+	err = gitclient.Add(git, tmpDir, path)
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("chore: release notes of %s", tag)
+	_, err = git.Command(tmpDir, "commit", "-m", title)
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit %s to the branch %s", path, branch)
+	}
+	// lets replace the notes of any previous run which has not been merged yet
+	err = gitclient.Push(git, tmpDir, "origin", true, "HEAD:"+branch)
+	if err != nil {
+		return err
+	}
+
+	pr, err := p.findOrCreatePullRequest(ctx, &scm.PullRequestInput{
+		Title: title,
+		Head:  branch,
+		Base:  base,
+		Body:  fmt.Sprintf("The release notes of %s are published once this pull request is merged via:\n\n```\njx-changelog publish --from-file %s\n```\n\n---\n\n%s", tag, filepath.ToSlash(path), result.Markdown),
+	})
+	if err != nil {
+		return err
+	}
+	if pr.Link != "" {
+		result.Release.Spec.ReleaseNotesURL = pr.Link
+	}
+	log.Logger().Infof("the release notes of %s await approval in pull request %s", info(tag), info(pr.Link))
+	return nil
+}
+
+// findOrCreatePullRequest returns the open pull request of the branch of the input creating it if there is none
+func (p *approvalPublisher) findOrCreatePullRequest(ctx context.Context, input *scm.PullRequestInput) (*scm.PullRequest, error) {
+	g := p.g
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient == nil {
+		return nil, errors.New("no git provider client to open the pull request of the release notes")
+	}
+	fullName := scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository)
+	prs, _, err := scmClient.PullRequests.List(ctx, fullName, scm.PullRequestListOptions{Page: 1, Size: 100, Open: true})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the pull requests of %s", fullName)
+	}
+	for _, pr := range prs {
+		if pr.Head.Ref == input.Head && !pr.Closed && !pr.Merged {
+			return pr, nil
+		}
+	}
+	pr, res, err := scmClient.PullRequests.Create(ctx, fullName, input)
+	if err != nil {
+		return nil, errors.Wrapf(scmError(res, err), "failed to create the pull request of the release notes on %s", fullName)
+	}
+	return pr, nil
+}
+
+// PublishNotes creates or updates the release of the tag on the git provider with the pre-generated markdown such
+// as the release notes approved via a pull request
+func (g *Generator) PublishNotes(ctx context.Context, tag, markdown string) (*Result, error) {
+	var err error
+	gitInfo := g.ScmFactory.GitURL
+	if gitInfo == nil {
+		gitInfo, err = giturl.ParseGitURL(g.ScmFactory.SourceURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse git URL %s", g.ScmFactory.SourceURL)
+		}
+	}
+	g.State.GitInfo = gitInfo
+	g.State.Channel, err = ParseChannel(g.Channel, g.ChannelTagPattern)
+	if err != nil {
+		return nil, err
+	}
+	g.UpdateRelease = true
+	g.ApprovalPR = false
+	release := &v1.Release{
+		Spec: v1.ReleaseSpec{
+			Name:          gitInfo.Name,
+			Version:       strings.TrimPrefix(tag, "v"),
+			GitOwner:      gitInfo.Organisation,
+			GitRepository: gitInfo.Name,
+			GitHTTPURL:    gitInfo.HttpsURL(),
+			GitCloneURL:   gitInfo.CloneURL,
+		},
+	}
+	result := &Result{
+		Range:    &Range{CurrentName: tag},
+		Release:  release,
+		Markdown: markdown,
+		Output:   markdown,
+		Tag:      tag,
+	}
+	return result, g.Publish(ctx, result)
+}
@@ -0,0 +1,75 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalPR(t *testing.T) {
+	t.Parallel()
+	origin := initRepo(t)
+	dir := initRepo(t)
+	git := func(dir string, args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git(origin, "config", "receive.denyCurrentBranch", "ignore")
+	git(dir, "config", "user.email", "jane@foo.com")
+	git(dir, "config", "user.name", "Jane Doe")
+	git(dir, "checkout", "-q", "-b", "main")
+	git(dir, "commit", "-q", "--allow-empty", "-m", "feat: initial")
+	git(dir, "tag", "v1.2.3")
+	git(dir, "remote", "add", "origin", origin)
+	git(dir, "push", "-q", "origin", "main")
+
+	scmClient, data := scmfake.NewDefault()
+	g := newCollectGenerator(t, dir, nil, nil)
+	g.ApprovalPR = true
+	g.ScmFactory.ScmClient = scmClient
+	g.ScmFactory.Owner = "myorg"
+	g.ScmFactory.Repository = "myrepo"
+	g.State.DefaultBranch = "main"
+
+	ctx := context.Background()
+	publish := func(markdown string) *changelog.Result {
+		result := &changelog.Result{
+			Range:    &changelog.Range{CurrentName: "v1.2.3"},
+			Release:  &v1.Release{Spec: v1.ReleaseSpec{Version: "1.2.3"}},
+			Markdown: markdown,
+			Output:   markdown,
+		}
+		require.NoError(t, g.Publish(ctx, result))
+		return result
+	}
+	result := publish("## Changes\n\n* fix: a bug\n")
+	assert.Equal(t, "v1.2.3", result.Tag)
+	require.Len(t, data.PullRequests, 1)
+	var pr *scm.PullRequest
+	for _, p := range data.PullRequests {
+		pr = p
+	}
+	assert.Equal(t, "changelog/v1.2.3", pr.Head.Ref)
+	assert.Equal(t, "main", pr.Base.Ref)
+	assert.Contains(t, pr.Body, "jx-changelog publish --from-file release-notes/v1.2.3.md")
+	assert.Empty(t, data.Releases, "the release should not be created until the notes are approved")
+	assert.Equal(t, "## Changes\n\n* fix: a bug", git(origin, "show", "changelog/v1.2.3:release-notes/v1.2.3.md"))
+
+	// lets regenerate the notes which should update the branch of the open pull request
+	publish("## Changes\n\n* fix: a nasty bug\n")
+	assert.Len(t, data.PullRequests, 1, "the open pull request should be reused")
+	assert.Equal(t, "## Changes\n\n* fix: a nasty bug", git(origin, "show", "changelog/v1.2.3:release-notes/v1.2.3.md"))
+	assert.Equal(t, "chore: release notes of v1.2.3", git(origin, "log", "-1", "--format=%s", "changelog/v1.2.3"))
+	assert.Equal(t, "feat: initial", git(origin, "log", "-1", "--format=%s", "changelog/v1.2.3~1"), "the branch should be based on the default branch")
+}
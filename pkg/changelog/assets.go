@@ -0,0 +1,188 @@
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// assetFiles the file names and content types of the release assets of the formats
+var assetFiles = map[string][2]string{
+	RendererMarkdown: {"changelog.md", "text/markdown"},
+	RendererJSON:     {"changelog.json", "application/json"},
+	RendererHTML:     {"changelog.html", "text/html"},
+	RendererSlack:    {"changelog-slack.json", "application/json"},
+	RendererPDF:      {"changelog.pdf", "application/pdf"},
+}
+
+// ReleaseAsset a rendering of the changelog uploaded as an asset of the release on the git provider
+type ReleaseAsset struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// AssetUploader uploads the assets of a release on the git provider
+type AssetUploader interface {
+	// UploadAsset uploads the asset to the release replacing any existing asset of the same name
+	UploadAsset(ctx context.Context, fullName string, release *scm.Release, asset *ReleaseAsset) error
+}
+
+// validateReleaseAssets creates the renderers of the formats uploaded as release assets
+func (g *Generator) validateReleaseAssets() error {
+	g.State.AssetRenderers = map[string]Renderer{}
+	for _, format := range g.ReleaseAssets {
+		if format == RendererMarkdown {
+			continue
+		}
+		r, err := NewRenderer(format, nil)
+		if err != nil {
+			return options.InvalidOptionf("release-asset", format, "%s", err.Error())
+		}
+		g.State.AssetRenderers[format] = r
+	}
+	return nil
+}
+
+// renderReleaseAssets renders the changelog in each of the release asset formats
+func (g *Generator) renderReleaseAssets(result *Result, input *RenderInput) error {
+	result.Assets = nil
+	for _, format := range g.ReleaseAssets {
+		text := result.Markdown
+		if r := g.State.AssetRenderers[format]; r != nil {
+			var err error
+			text, err = r.Render(input)
+			if err != nil {
+				return errors.Wrapf(err, "failed to render the %s release asset", format)
+			}
+		}
+		file, ok := assetFiles[format]
+		if !ok {
+			file = [2]string{"changelog." + format, "application/octet-stream"}
+		}
+		result.Assets = append(result.Assets, ReleaseAsset{Name: file[0], ContentType: file[1], Data: []byte(text)})
+	}
+	if asset := g.checksumsAsset(); asset != nil {
+		result.Assets = append(result.Assets, *asset)
+	}
+	return nil
+}
+
+// uploadReleaseAssets uploads the rendered assets to the release using the AssetUploader or the GitHub API
+func (g *Generator) uploadReleaseAssets(ctx context.Context, fullName string, release *scm.Release, assets []ReleaseAsset) error {
+	if len(assets) == 0 {
+		return nil
+	}
+	if release == nil || release.ID == 0 {
+		return errors.Errorf("cannot upload the release assets of %s as the release has no ID", fullName)
+	}
+	uploader := g.AssetUploader
+	if uploader == nil {
+		kind := g.ScmFactory.GitKind
+		if kind != "" && kind != "github" {
+			return errors.Errorf("uploading release assets is not supported for git kind %s", kind)
+		}
+		uploader = &GitHubAssetUploader{ServerURL: g.ScmFactory.GitServerURL, Token: g.ScmFactory.GitToken, Client: g.HTTPClient}
+	}
+	for i := range assets {
+		asset := &assets[i]
+		err := uploader.UploadAsset(ctx, fullName, release, asset)
+		if err != nil {
+			return errors.Wrapf(err, "failed to upload the release asset %s", asset.Name)
+		}
+		log.Logger().Infof("uploaded the release asset %s", info(asset.Name))
+	}
+	return nil
+}
+
+// GitHubAssetUploader uploads release assets via the GitHub REST API. Defaults to http.DefaultClient if the client
+// is nil
+type GitHubAssetUploader struct {
+	ServerURL string
+	Token     string
+	Client    *http.Client
+}
+
+// UploadAsset deletes any existing asset of the same name then uploads the asset
+func (u *GitHubAssetUploader) UploadAsset(ctx context.Context, fullName string, release *scm.Release, asset *ReleaseAsset) error {
+	apiURL, uploadURL := u.urls()
+	assetsPath := fmt.Sprintf("repos/%s/releases/%d/assets", fullName, release.ID)
+
+	resp, err := u.do(ctx, http.MethodGet, apiURL+assetsPath, "", nil)
+	if err != nil {
+		return err
+	}
+	var existing []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&existing)
+	resp.Body.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the assets of release %d", release.ID)
+	}
+	for _, a := range existing {
+		if a.Name == asset.Name {
+			resp, err = u.do(ctx, http.MethodDelete, fmt.Sprintf("%srepos/%s/releases/assets/%d", apiURL, fullName, a.ID), "", nil)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+		}
+	}
+
+	resp, err = u.do(ctx, http.MethodPost, uploadURL+assetsPath+"?name="+url.QueryEscape(asset.Name), asset.ContentType, asset.Data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// urls returns the base URLs of the API and uploads of the server
+func (u *GitHubAssetUploader) urls() (string, string) {
+	server := strings.TrimSuffix(u.ServerURL, "/")
+	if server == "" || server == "https://github.com" {
+		return "https://api.github.com/", "https://uploads.github.com/"
+	}
+	return server + "/api/v3/", server + "/api/uploads/"
+}
+
+func (u *GitHubAssetUploader) do(ctx context.Context, method, requestURL, contentType string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the request to %s", requestURL)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if u.Token != "" {
+		req.Header.Set("Authorization", "token "+u.Token)
+	}
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to %s %s", method, requestURL)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return nil, newError(ErrAuth, "%s %s returned status %s", method, requestURL, resp.Status)
+		}
+		return nil, errors.Errorf("%s %s returned status %s", method, requestURL, resp.Status)
+	}
+	return resp, nil
+}
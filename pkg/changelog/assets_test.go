@@ -0,0 +1,83 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubAssetUploader(t *testing.T) {
+	t.Parallel()
+	var requests []string
+	uploaded := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Header.Get("Authorization") != "token mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"id": 5, "name": "changelog.json"}, {"id": 6, "name": "other.zip"}]`))
+		case http.MethodPost:
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			data, _ := ioutil.ReadAll(r.Body)
+			uploaded = string(data)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	u := &changelog.GitHubAssetUploader{ServerURL: server.URL, Token: "mytoken"}
+	asset := &changelog.ReleaseAsset{Name: "changelog.json", ContentType: "application/json", Data: []byte(`{"version": "1.2.3"}`)}
+	require.NoError(t, u.UploadAsset(context.Background(), "myorg/myrepo", &scm.Release{ID: 7}, asset))
+	assert.Equal(t, []string{
+		"GET /api/v3/repos/myorg/myrepo/releases/7/assets",
+		"DELETE /api/v3/repos/myorg/myrepo/releases/assets/5",
+		"POST /api/uploads/repos/myorg/myrepo/releases/7/assets?name=changelog.json",
+	}, requests)
+	assert.Equal(t, `{"version": "1.2.3"}`, uploaded)
+
+	u.Token = ""
+	err := u.UploadAsset(context.Background(), "myorg/myrepo", &scm.Release{ID: 7}, asset)
+	require.Error(t, err)
+	assert.Equal(t, changelog.ExitCodeAuth, changelog.ExitCode(err))
+}
+
+func TestRenderReleaseAssets(t *testing.T) {
+	t.Parallel()
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+	g := &changelog.Generator{ReleaseAssets: []string{changelog.RendererMarkdown, changelog.RendererJSON}}
+	require.NoError(t, g.Validate())
+	g.State.GitInfo = gitInfo
+	result := &changelog.Result{
+		Range:           &changelog.Range{},
+		Release:         &v1.Release{Spec: v1.ReleaseSpec{Version: "1.2.3", Commits: []v1.CommitSummary{{SHA: "1111111aaaa", Message: "fix: a bug"}}}},
+		Changelog:       &changelog.Changelog{Commits: []*changelog.Commit{changelog.NewCommit("1111111aaaa", "fix: a bug")}},
+		MarkdownOptions: &gits.MarkdownOptions{},
+	}
+	require.NoError(t, g.Render(context.Background(), result))
+	require.Len(t, result.Assets, 2)
+	assert.Equal(t, "changelog.md", result.Assets[0].Name)
+	assert.Equal(t, result.Markdown, string(result.Assets[0].Data))
+	assert.Equal(t, "changelog.json", result.Assets[1].Name)
+	assert.Equal(t, "application/json", result.Assets[1].ContentType)
+	assert.Contains(t, string(result.Assets[1].Data), `"version"`)
+
+	assert.Error(t, (&changelog.Generator{ReleaseAssets: []string{"docx"}}).Validate(), "there is no docx renderer registered")
+}
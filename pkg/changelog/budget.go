@@ -0,0 +1,109 @@
+package changelog
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// rateLimitRemainingHeaders the headers of the remaining rate limit of GitHub, GitLab and Gitea
+var rateLimitRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+
+// APIBudget limits the git provider API calls spent enriching the changelog so that a large backfill does not use up
+// the rate limit of a token shared with other jobs. Once the budget is exhausted issues are no longer looked up and
+// users are resolved from their git signatures only. Publishing is not limited. A nil budget is never exhausted
+type APIBudget struct {
+	// MaxCalls the maximum number of API calls. Zero means no limit
+	MaxCalls int
+
+	// MinRemaining the remaining rate limit of the git provider below which the budget is exhausted. Zero means the
+	// rate limit is not checked
+	MinRemaining int
+
+	lock      sync.Mutex
+	calls     int
+	remaining int
+	logged    bool
+}
+
+// NewAPIBudget creates the budget returning nil if there are no limits
+func NewAPIBudget(maxCalls, minRemaining int) *APIBudget {
+	if maxCalls <= 0 && minRemaining <= 0 {
+		return nil
+	}
+	return &APIBudget{MaxCalls: maxCalls, MinRemaining: minRemaining, remaining: -1}
+}
+
+// Guard wraps the HTTP client of the git provider to count the API calls and track the remaining rate limit
+func (b *APIBudget) Guard(client *scm.Client) {
+	if b == nil || client == nil {
+		return
+	}
+	httpClient := http.Client{}
+	if client.Client != nil {
+		httpClient = *client.Client
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	httpClient.Transport = &budgetTransport{budget: b, next: next}
+	client.Client = &httpClient
+}
+
+// Exhausted returns true if the maximum number of API calls have been made or the remaining rate limit is too low.
+// The first time the budget is exhausted a warning is logged
+func (b *APIBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	exhausted := (b.MaxCalls > 0 && b.calls >= b.MaxCalls) || (b.MinRemaining > 0 && b.remaining >= 0 && b.remaining < b.MinRemaining)
+	if exhausted && !b.logged {
+		b.logged = true
+		log.Logger().Warnf("the git provider API budget is exhausted after %d calls with %d calls of the rate limit remaining so issues are no longer looked up and users are resolved from git only", b.calls, b.remaining)
+	}
+	return exhausted
+}
+
+// Calls returns the number of API calls made
+func (b *APIBudget) Calls() int {
+	if b == nil {
+		return 0
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.calls
+}
+
+type budgetTransport struct {
+	budget *APIBudget
+	next   http.RoundTripper
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	b := t.budget
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.calls++
+	if err != nil {
+		return resp, err
+	}
+	for _, name := range rateLimitRemainingHeaders {
+		value := resp.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		remaining, err := strconv.Atoi(value)
+		if err == nil {
+			b.remaining = remaining
+		}
+		break
+	}
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIBudget(t *testing.T) {
+	t.Parallel()
+	remaining := atomic.Value{}
+	remaining.Store("5000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", remaining.Load().(string))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"login": "bob"}`))
+	}))
+	defer server.Close()
+
+	assert.Nil(t, changelog.NewAPIBudget(0, 0), "should not create a budget without limits")
+	assert.False(t, changelog.NewAPIBudget(0, 0).Exhausted())
+
+	client, err := factory.NewClient("github", server.URL, "mytoken")
+	require.NoError(t, err)
+	budget := changelog.NewAPIBudget(3, 100)
+	budget.Guard(client)
+	ctx := context.Background()
+
+	_, _, err = client.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.False(t, budget.Exhausted())
+	_, _, err = client.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.False(t, budget.Exhausted())
+	_, _, err = client.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, budget.Calls())
+	assert.True(t, budget.Exhausted(), "should be exhausted after the maximum number of calls")
+
+	budget = changelog.NewAPIBudget(0, 100)
+	budget.Guard(client)
+	_, _, err = client.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.False(t, budget.Exhausted())
+	remaining.Store("99")
+	_, _, err = client.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.True(t, budget.Exhausted(), "should be exhausted when the rate limit is nearly used up")
+}
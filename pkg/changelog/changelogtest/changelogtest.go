@@ -0,0 +1,295 @@
+// Package changelogtest provides a fixed clock, fixture builders and fakes for deterministic unit tests of code
+// using the changelog library
+package changelogtest
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DefaultTime the time of the FixedClock and fixtures unless specified
+var DefaultTime = time.Date(2020, time.September, 13, 12, 26, 40, 0, time.UTC)
+
+// FixedClock a changelog.Clock which always returns the same time
+type FixedClock struct {
+	Time time.Time
+}
+
+// NewClock creates a clock fixed at the given time
+func NewClock(t time.Time) *FixedClock {
+	return &FixedClock{Time: t}
+}
+
+// Now returns the fixed time
+func (c *FixedClock) Now() time.Time {
+	return c.Time
+}
+
+// CommitBuilder builds commit fixtures. The SHA is derived from the message unless specified
+type CommitBuilder struct {
+	commit   object.Commit
+	issueIDs []string
+}
+
+// NewCommit creates a builder of the commit with the given message
+func NewCommit(message string) *CommitBuilder {
+	sum := sha1.Sum([]byte(message)) //nolint:gosec
+	signature := object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: DefaultTime}
+	return &CommitBuilder{
+		commit: object.Commit{
+			Hash:      plumbing.NewHash(hex.EncodeToString(sum[:])),
+			Author:    signature,
+			Committer: signature,
+			Message:   message,
+		},
+	}
+}
+
+// WithSHA sets the SHA of the commit
+func (b *CommitBuilder) WithSHA(sha string) *CommitBuilder {
+	b.commit.Hash = plumbing.NewHash(sha)
+	return b
+}
+
+// WithAuthor sets the author and committer of the commit
+func (b *CommitBuilder) WithAuthor(name, email string) *CommitBuilder {
+	b.commit.Author.Name = name
+	b.commit.Author.Email = email
+	b.commit.Committer = b.commit.Author
+	return b
+}
+
+// WithTime sets the author and commit time of the commit
+func (b *CommitBuilder) WithTime(t time.Time) *CommitBuilder {
+	b.commit.Author.When = t
+	b.commit.Committer.When = t
+	return b
+}
+
+// WithParents sets the parents of the commit. Use two or more parents for merge commits
+func (b *CommitBuilder) WithParents(shas ...string) *CommitBuilder {
+	b.commit.ParentHashes = nil
+	for _, sha := range shas {
+		b.commit.ParentHashes = append(b.commit.ParentHashes, plumbing.NewHash(sha))
+	}
+	return b
+}
+
+// WithIssueIDs sets the IDs of the issues referenced by the changelog commit
+func (b *CommitBuilder) WithIssueIDs(ids ...string) *CommitBuilder {
+	b.issueIDs = ids
+	return b
+}
+
+// GitCommit returns the git commit
+func (b *CommitBuilder) GitCommit() *object.Commit {
+	c := b.commit
+	return &c
+}
+
+// Build returns the commit of the changelog model
+func (b *CommitBuilder) Build() *changelog.Commit {
+	sha := b.commit.Hash.String()
+	c := changelog.NewCommit(sha, b.commit.Message)
+	c.AuthorEmail = b.commit.Author.Email
+	c.Author = &v1.UserDetails{Name: b.commit.Author.Name, Email: b.commit.Author.Email}
+	c.Committer = &v1.UserDetails{Name: b.commit.Committer.Name, Email: b.commit.Committer.Email}
+	c.IssueIDs = b.issueIDs
+	return c
+}
+
+// IssueBuilder builds issue and pull request fixtures
+type IssueBuilder struct {
+	issue scm.Issue
+	id    string
+}
+
+// NewIssue creates a builder of the open issue with the given ID and title
+func NewIssue(id, title string) *IssueBuilder {
+	number, _ := strconv.Atoi(id)
+	return &IssueBuilder{
+		id: id,
+		issue: scm.Issue{
+			Number:  number,
+			Title:   title,
+			State:   "open",
+			Link:    "https://github.com/myorg/myrepo/issues/" + id,
+			Author:  scm.User{Login: "jdoe", Name: "Jane Doe"},
+			Created: DefaultTime,
+		},
+	}
+}
+
+// AsPullRequest marks the issue as a pull request
+func (b *IssueBuilder) AsPullRequest() *IssueBuilder {
+	b.issue.PullRequest = true
+	b.issue.Link = "https://github.com/myorg/myrepo/pull/" + b.id
+	return b
+}
+
+// WithLabels sets the labels of the issue
+func (b *IssueBuilder) WithLabels(labels ...string) *IssueBuilder {
+	b.issue.Labels = labels
+	return b
+}
+
+// WithState sets the state of the issue such as 'closed'
+func (b *IssueBuilder) WithState(state string) *IssueBuilder {
+	b.issue.State = state
+	return b
+}
+
+// WithAuthor sets the login and name of the author of the issue
+func (b *IssueBuilder) WithAuthor(login, name string) *IssueBuilder {
+	b.issue.Author = scm.User{Login: login, Name: name}
+	return b
+}
+
+// ScmIssue returns the issue of the git provider
+func (b *IssueBuilder) ScmIssue() *scm.Issue {
+	i := b.issue
+	return &i
+}
+
+// Build returns the issue of the changelog model
+func (b *IssueBuilder) Build() *changelog.Issue {
+	return &changelog.Issue{
+		ID:          b.id,
+		URL:         b.issue.Link,
+		Title:       b.issue.Title,
+		Body:        b.issue.Body,
+		State:       b.issue.State,
+		User:        &v1.UserDetails{Login: b.issue.Author.Login, Name: b.issue.Author.Name},
+		Labels:      b.issue.Labels,
+		Created:     b.issue.Created,
+		PullRequest: b.issue.PullRequest,
+	}
+}
+
+// IssueTracker a fake issues.IssueProvider which records the issues looked up
+type IssueTracker struct {
+	Issues  map[string]*scm.Issue
+	Lookups []string
+
+	lock sync.Mutex
+}
+
+// NewIssueTracker creates a fake issue tracker containing the issues
+func NewIssueTracker(issues ...*IssueBuilder) *IssueTracker {
+	t := &IssueTracker{Issues: map[string]*scm.Issue{}}
+	for _, b := range issues {
+		t.Issues[b.id] = b.ScmIssue()
+	}
+	return t
+}
+
+// GetIssue returns the issue or nil if it does not exist
+func (t *IssueTracker) GetIssue(key string) (*scm.Issue, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.Lookups = append(t.Lookups, key)
+	return t.Issues[key], nil
+}
+
+// SearchIssues returns the open issues
+func (t *IssueTracker) SearchIssues(query string) ([]*scm.Issue, error) {
+	var answer []*scm.Issue
+	for _, issue := range t.Issues {
+		if issue.State == "open" {
+			answer = append(answer, issue)
+		}
+	}
+	return answer, nil
+}
+
+// SearchIssuesClosedSince returns no issues
+func (t *IssueTracker) SearchIssuesClosedSince(since time.Time) ([]*scm.Issue, error) {
+	return nil, nil
+}
+
+// CreateIssue adds the issue to the tracker
+func (t *IssueTracker) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	issue.Number = len(t.Issues) + 1
+	t.Issues[strconv.Itoa(issue.Number)] = issue
+	return issue, nil
+}
+
+// CreateIssueComment fails if the issue does not exist
+func (t *IssueTracker) CreateIssueComment(key string, comment string) error {
+	if t.Issues[key] == nil {
+		return errors.Errorf("issue %s does not exist", key)
+	}
+	return nil
+}
+
+// IssueURL returns the URL of the issue
+func (t *IssueTracker) IssueURL(key string) string {
+	return t.HomeURL() + "/issues/" + key
+}
+
+// HomeURL returns the URL of the fake repository
+func (t *IssueTracker) HomeURL() string {
+	return "https://github.com/myorg/myrepo"
+}
+
+// CommitFetcher a fake gits.CommitFetcher which returns the same commits for any range of revisions
+type CommitFetcher struct {
+	Commits []*object.Commit
+}
+
+// NewCommitFetcher creates a fake commit fetcher of the commits in reverse chronological order
+func NewCommitFetcher(commits ...*CommitBuilder) *CommitFetcher {
+	f := &CommitFetcher{}
+	for _, b := range commits {
+		f.Commits = append(f.Commits, b.GitCommit())
+	}
+	return f
+}
+
+// FetchCommits iterates over all the commits
+func (f *CommitFetcher) FetchCommits(gitDir, fromRev, toRev string) (gits.CommitIterator, error) {
+	return NewCommitIterator(f.Commits...), nil
+}
+
+// FetchHistory iterates over the latest commits
+func (f *CommitFetcher) FetchHistory(gitDir, rev string, maxCommits int) (gits.CommitIterator, error) {
+	commits := f.Commits
+	if maxCommits > 0 && maxCommits < len(commits) {
+		commits = commits[:maxCommits]
+	}
+	return NewCommitIterator(commits...), nil
+}
+
+// NewCommitIterator iterates over the commits
+func NewCommitIterator(commits ...*object.Commit) gits.CommitIterator {
+	return &commitIterator{commits: commits}
+}
+
+type commitIterator struct {
+	commits []*object.Commit
+}
+
+func (i *commitIterator) Next() (*object.Commit, error) {
+	if len(i.commits) == 0 {
+		return nil, io.EOF
+	}
+	c := i.commits[0]
+	i.commits = i.commits[1:]
+	return c, nil
+}
+
+func (i *commitIterator) Close() {
+	i.commits = nil
+}
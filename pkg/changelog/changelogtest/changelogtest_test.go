@@ -0,0 +1,48 @@
+// +build unit
+
+package changelogtest_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	t.Parallel()
+	clock := changelogtest.NewClock(changelogtest.DefaultTime)
+	g, err := changelog.New(changelog.WithClock(clock))
+	require.NoError(t, err)
+	assert.Equal(t, clock, g.Clock)
+
+	feat := changelogtest.NewCommit("feat: something (#12)").WithAuthor("James Strachan", "james@foo.com").WithIssueIDs("12")
+	assert.Equal(t, changelogtest.NewCommit("feat: something (#12)").GitCommit().Hash, feat.GitCommit().Hash)
+	c := feat.Build()
+	assert.Equal(t, "feat", c.Type)
+	assert.Equal(t, "james@foo.com", c.AuthorEmail)
+	assert.Equal(t, []string{"12"}, c.IssueIDs)
+
+	fetcher := changelogtest.NewCommitFetcher(feat, changelogtest.NewCommit("fix: a bug").WithTime(time.Unix(0, 0)))
+	iter, err := fetcher.FetchHistory("", "", 1)
+	require.NoError(t, err)
+	first, err := iter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "James Strachan", first.Author.Name)
+	_, err = iter.Next()
+	assert.Equal(t, io.EOF, err)
+
+	tracker := changelogtest.NewIssueTracker(changelogtest.NewIssue("12", "a feature").AsPullRequest().WithLabels("enhancement"))
+	r := &refs.Resolver{Tracker: tracker}
+	found, err := r.Resolve(refs.Scan(first.Message))
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.True(t, found[0].PullRequest)
+	assert.Equal(t, "https://github.com/myorg/myrepo/pull/12", found[0].URL)
+	assert.Equal(t, []string{"12"}, tracker.Lookups)
+}
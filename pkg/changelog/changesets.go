@@ -0,0 +1,280 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// BumpMajor the changeset makes breaking changes
+	BumpMajor = "major"
+
+	// BumpMinor the changeset adds features
+	BumpMinor = "minor"
+
+	// BumpPatch the changeset fixes bugs
+	BumpPatch = "patch"
+)
+
+// bumps the version bumps in the order of their severity
+var bumps = []string{BumpMajor, BumpMinor, BumpPatch}
+
+// Changeset a markdown fragment of the '.changeset' directory describing one change along with the version bump of
+// each of the packages it changes. The bumps are in the YAML front matter of the fragment such as '"@myorg/api": minor'
+// followed by the markdown summary of the change
+type Changeset struct {
+	// File the path of the fragment
+	File string
+
+	// Bumps the version bump of each package
+	Bumps map[string]string
+
+	// Summary the markdown description of the change
+	Summary string
+}
+
+// ParseChangeset parses the changeset fragment of the file
+func ParseChangeset(file, text string) (*Changeset, error) {
+	answer := &Changeset{File: file, Bumps: map[string]string{}}
+	text = strings.TrimLeft(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if strings.HasPrefix(text, "---\n") {
+		text = "\n" + text[4:]
+		end := strings.Index(text, "\n---")
+		if end < 0 {
+			return nil, errors.Errorf("the front matter of the changeset %s is not terminated by ---", file)
+		}
+		err := yaml.Unmarshal([]byte(text[:end]), &answer.Bumps)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the front matter of the changeset %s", file)
+		}
+		text = text[end+4:]
+	}
+	for pkg, bump := range answer.Bumps {
+		if bumpIndex(bump) < 0 {
+			return nil, errors.Errorf("invalid bump %s of package %s in the changeset %s: should be %s", bump, pkg, file, strings.Join(bumps, ", "))
+		}
+	}
+	answer.Summary = strings.TrimSpace(text)
+	return answer, nil
+}
+
+// LoadChangesets loads the changeset fragments of the directory sorted by file name. The README.md of the directory
+// is ignored. Returns nil if the directory does not exist
+func LoadChangesets(dir string) ([]*Changeset, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the changesets in %s", dir)
+	}
+	sort.Strings(matches)
+	var answer []*Changeset
+	for _, f := range matches {
+		if strings.EqualFold(filepath.Base(f), "README.md") {
+			continue
+		}
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the changeset %s", f)
+		}
+		c, err := ParseChangeset(f, string(data))
+		if err != nil {
+			return nil, err
+		}
+		if c.Summary == "" && len(c.Bumps) == 0 {
+			continue
+		}
+		answer = append(answer, c)
+	}
+	return answer, nil
+}
+
+// Bump returns the most severe bump of the packages of the changeset. Empty if it bumps no packages
+func (c *Changeset) Bump() string {
+	answer := ""
+	for _, bump := range c.Bumps {
+		if answer == "" || bumpIndex(bump) < bumpIndex(answer) {
+			answer = bump
+		}
+	}
+	return answer
+}
+
+// Message returns the changeset as a Conventional Commits message so that it is rendered in the section of its bump
+// scoped by its packages
+func (c *Changeset) Message() string {
+	kind := "feat"
+	if c.Bump() == BumpPatch {
+		kind = "fix"
+	}
+	var packages []string
+	for pkg := range c.Bumps {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+	scope := ""
+	if len(packages) > 0 {
+		scope = "(" + strings.Join(packages, ", ") + ")"
+	}
+	summary := c.Summary
+	if summary == "" {
+		summary = strings.TrimSuffix(filepath.Base(c.File), ".md")
+	}
+	return kind + scope + ": " + summary
+}
+
+// ChangesetsBump returns the most severe bump of the changesets. Empty if there are none
+func ChangesetsBump(changesets []*Changeset) string {
+	answer := ""
+	for _, c := range changesets {
+		bump := c.Bump()
+		if bump != "" && (answer == "" || bumpIndex(bump) < bumpIndex(answer)) {
+			answer = bump
+		}
+	}
+	return answer
+}
+
+// BumpVersion returns the semantic version after the bump keeping any 'v' prefix. An empty version such as that of the
+// initial release is bumped from 0.0.0. Returns an error if the version is not a semantic version
+func BumpVersion(version, bump string) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+	}
+	var parts [3]int
+	core := strings.TrimPrefix(version, "v")
+	if idx := strings.IndexAny(core, "-+"); idx >= 0 {
+		core = core[:idx]
+	}
+	if core != "" {
+		for i, p := range strings.SplitN(core, ".", 3) {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return "", errors.Errorf("cannot bump the version %s as it is not a semantic version", version)
+			}
+			parts[i] = n
+		}
+	}
+	switch bump {
+	case BumpMajor:
+		parts = [3]int{parts[0] + 1, 0, 0}
+	case BumpMinor:
+		parts = [3]int{parts[0], parts[1] + 1, 0}
+	default:
+		parts[2]++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, parts[0], parts[1], parts[2]), nil
+}
+
+func bumpIndex(bump string) int {
+	for i, b := range bumps {
+		if b == bump {
+			return i
+		}
+	}
+	return -1
+}
+
+// addChangesets adds the changesets of the ChangesetDir to the release as changes. If no version is specified the
+// version of the previous release is bumped by the most severe bump of the changesets
+func (g *Generator) addChangesets(dir string, rng *Range, release *v1.Release) error {
+	if g.ChangesetDir == "" {
+		return nil
+	}
+	changesetDir := g.ChangesetDir
+	if !filepath.IsAbs(changesetDir) {
+		changesetDir = filepath.Join(dir, changesetDir)
+	}
+	changesets, err := LoadChangesets(changesetDir)
+	if err != nil {
+		return err
+	}
+	g.State.Changesets = changesets
+	if len(changesets) == 0 {
+		log.Logger().Infof("no changesets found in %s", info(changesetDir))
+		return nil
+	}
+	for _, c := range changesets {
+		release.Spec.Commits = append(release.Spec.Commits, v1.CommitSummary{Message: c.Message()})
+	}
+	bump := ChangesetsBump(changesets)
+	if g.Version == "" && bump != "" {
+		release.Spec.Version, err = BumpVersion(rng.PreviousName, bump)
+		if err != nil {
+			return errors.Wrap(err, "failed to bump the version from the changesets, use --version to specify it")
+		}
+		log.Logger().Infof("bumped the %s version of %s to %s from %d changesets", bump, rng.PreviousName, info(release.Spec.Version), len(changesets))
+	}
+	return nil
+}
+
+// changesetPublisher deletes the changesets consumed by the release and commits the deletion as the release commit
+// along with the generated changelog file if ChangesetCommit is enabled. The release commit is pushed to the release
+// branch as the tag of the release has already been created
+type changesetPublisher struct {
+	g *Generator
+}
+
+func (p *changesetPublisher) Name() string {
+	return "changesets"
+}
+
+func (p *changesetPublisher) Publish(_ context.Context, result *Result) error {
+	g := p.g
+	changesets := g.State.Changesets
+	if len(changesets) == 0 {
+		return nil
+	}
+	git := g.Git()
+	dir := g.ScmFactory.Dir
+	args := []string{"rm", "-q", "--ignore-unmatch", "--"}
+	for _, c := range changesets {
+		args = append(args, c.File)
+	}
+	_, err := git.Command(dir, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove the changesets")
+	}
+	for _, c := range changesets {
+		// lets remove any changesets which were not committed
+		err = os.Remove(c.File)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to remove the changeset %s", c.File)
+		}
+	}
+	log.Logger().Infof("removed %d changesets", len(changesets))
+	if !g.ChangesetCommit {
+		return nil
+	}
+	if g.OutputMarkdownFile != "" {
+		_, err = git.Command(dir, "add", "--", g.OutputMarkdownFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the changelog %s", g.OutputMarkdownFile)
+		}
+	}
+	_, err = git.Command(dir, "commit", "-m", fmt.Sprintf("chore: release %s", result.Tag))
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit the release %s", result.Tag)
+	}
+	branch := g.State.Branch
+	if branch == "" {
+		return errors.Errorf("cannot push the release commit of %s as the release branch is unknown", result.Tag)
+	}
+	err = gitclient.Push(git, dir, "origin", false, "HEAD:"+branch)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push the release commit of %s", result.Tag)
+	}
+	log.Logger().Infof("pushed the release commit of %s to the %s branch", info(result.Tag), info(branch))
+	return nil
+}
@@ -0,0 +1,110 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChangeset(t *testing.T) {
+	t.Parallel()
+	c, err := changelog.ParseChangeset("brave-dogs-run.md", "---\n\"@myorg/api\": minor\n\"@myorg/cli\": patch\n---\n\nAdds the search endpoint\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"@myorg/api": "minor", "@myorg/cli": "patch"}, c.Bumps)
+	assert.Equal(t, "Adds the search endpoint", c.Summary)
+	assert.Equal(t, changelog.BumpMinor, c.Bump())
+	assert.Equal(t, "feat(@myorg/api, @myorg/cli): Adds the search endpoint", c.Message())
+
+	c, err = changelog.ParseChangeset("empty.md", "---\n---\n")
+	require.NoError(t, err)
+	assert.Empty(t, c.Bumps)
+	assert.Equal(t, "", c.Bump())
+
+	_, err = changelog.ParseChangeset("invalid.md", "---\n\"@myorg/api\": huge\n---\n\nBig change\n")
+	assert.Error(t, err)
+	_, err = changelog.ParseChangeset("unterminated.md", "---\n\"@myorg/api\": major\n")
+	assert.Error(t, err)
+}
+
+func TestBumpVersion(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		version, bump, expected string
+	}{
+		{"v1.2.3", changelog.BumpMajor, "v2.0.0"},
+		{"1.2.3", changelog.BumpMinor, "1.3.0"},
+		{"1.2.3-rc.1", changelog.BumpPatch, "1.2.4"},
+		{"1.2", changelog.BumpPatch, "1.2.1"},
+		{"", changelog.BumpMinor, "0.1.0"},
+	}
+	for _, tc := range testCases {
+		version, err := changelog.BumpVersion(tc.version, tc.bump)
+		require.NoError(t, err, "bump %s of %s", tc.bump, tc.version)
+		assert.Equal(t, tc.expected, version, "bump %s of %s", tc.bump, tc.version)
+	}
+
+	_, err := changelog.BumpVersion("main", changelog.BumpPatch)
+	assert.Error(t, err, "should not bump a version which is not a semantic version")
+}
+
+func TestChangesets(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	origin := initRepo(t)
+	out, err := exec.Command("git", "-C", origin, "config", "receive.denyCurrentBranch", "ignore").CombinedOutput()
+	require.NoError(t, err, "git config: %s", out)
+	git("remote", "add", "origin", origin)
+	changesetDir := filepath.Join(dir, ".changeset")
+	require.NoError(t, os.MkdirAll(changesetDir, 0755))
+	for name, text := range map[string]string{
+		"README.md":          "# Changesets\n",
+		"brave-dogs-run.md":  "---\n\"@myorg/api\": minor\n---\n\nAdds the search endpoint\n",
+		"quiet-cats-nap.md":  "---\n\"@myorg/cli\": patch\n---\n\nFixes the help of the search command\n",
+		"silver-owls-fly.md": "---\n\"@myorg/cli\": patch\n---\n\nFixes the colours of the output\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(changesetDir, name), []byte(text), 0600))
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "chore: add changesets")
+
+	g := newCollectGenerator(t, dir, []*changelogtest.CommitBuilder{changelogtest.NewCommit("chore: add changesets")}, changelogtest.NewIssueTracker())
+	g.ChangesetDir = ".changeset"
+	g.ChangesetCommit = true
+	g.OutputMarkdownFile = filepath.Join(dir, "CHANGELOG.md")
+
+	ctx := context.Background()
+	result, err := g.Collect(ctx, &changelog.Range{PreviousRev: "v1.2.3", PreviousName: "v1.2.3", CurrentRev: "HEAD"})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, "v1.3.0", result.Release.Spec.Version, "the minor bump should be applied")
+	require.NoError(t, g.Curate(result))
+	require.NoError(t, g.Render(ctx, result))
+	assert.Contains(t, result.Markdown, "* @myorg/api: Adds the search endpoint")
+	assert.Contains(t, result.Markdown, "* @myorg/cli: Fixes the help of the search command")
+
+	require.NoError(t, g.Publish(ctx, result))
+	matches, err := filepath.Glob(filepath.Join(changesetDir, "*.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(changesetDir, "README.md")}, matches, "the consumed changesets should be removed")
+	assert.Equal(t, "chore: release v1.3.0", git("log", "-1", "--format=%s"))
+	assert.Equal(t, "", git("status", "--porcelain"), "the release commit should include the changelog")
+	assert.Contains(t, git("ls-remote", "origin", "refs/heads/"+g.State.Branch), git("rev-parse", "HEAD"), "the release commit should be pushed")
+}
@@ -0,0 +1,181 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChannelStable the channel of the final releases such as 'v1.2.3'
+	ChannelStable = "stable"
+
+	// ChannelBeta the channel of the pre-releases such as 'v1.2.3-beta.1' or 'v1.2.3-rc.1'
+	ChannelBeta = "beta"
+
+	// ChannelNightly the channel of the scheduled builds such as 'v1.2.3-nightly.20200913' or 'nightly-20200913'
+	ChannelNightly = "nightly"
+)
+
+// Channel the treatment of the releases of a release channel
+type Channel struct {
+	Name string
+
+	// TagPattern matches the tags of the releases of the channel
+	TagPattern *regexp.Regexp
+
+	// Prerelease marks the releases on the git provider as pre-releases
+	Prerelease bool
+
+	// Notify posts the releases to the Notify webhooks as well as the ChannelNotify webhook of the channel
+	Notify bool
+
+	// Section the heading of the section of the changelog file the releases of the channel are written to
+	Section string
+
+	// stability orders the channels so that the changelog of a channel starts from the previous release of the
+	// channel or of a more stable channel
+	stability int
+}
+
+var channels = []*Channel{
+	{
+		Name:       ChannelStable,
+		TagPattern: regexp.MustCompile(`^v?\d+\.\d+\.\d+$`),
+		Notify:     true,
+		Section:    "Stable",
+		stability:  2,
+	},
+	{
+		Name:       ChannelBeta,
+		TagPattern: regexp.MustCompile(`^v?\d+\.\d+\.\d+-(alpha|beta|rc)([.-]?\d+)*$`),
+		Prerelease: true,
+		Notify:     true,
+		Section:    "Beta",
+		stability:  1,
+	},
+	{
+		Name:       ChannelNightly,
+		TagPattern: regexp.MustCompile(`^(v?\d+\.\d+\.\d+-)?nightly([.-].*)?$`),
+		Prerelease: true,
+		Section:    "Nightly",
+	},
+}
+
+// ParseChannel returns the channel of the name or nil if there is none. The optional tag pattern replaces the
+// pattern matching the tags of the channel
+func ParseChannel(name, tagPattern string) (*Channel, error) {
+	if name == "" {
+		if tagPattern != "" {
+			return nil, options.MissingOption("channel")
+		}
+		return nil, nil
+	}
+	for _, c := range channels {
+		if c.Name != name {
+			continue
+		}
+		answer := *c
+		if tagPattern != "" {
+			var err error
+			answer.TagPattern, err = regexp.Compile(tagPattern)
+			if err != nil {
+				return nil, options.InvalidOptionf("channel-tag-pattern", tagPattern, "should be a regular expression: %s", err.Error())
+			}
+		}
+		return &answer, nil
+	}
+	return nil, options.InvalidOptionf("channel", name, "should be one of %s, %s or %s", ChannelStable, ChannelBeta, ChannelNightly)
+}
+
+// Includes returns true if the tag is a release of the channel or of a more stable channel
+func (c *Channel) Includes(tag string) bool {
+	if c.TagPattern.MatchString(tag) {
+		return true
+	}
+	for _, other := range channels {
+		if other.stability > c.stability && other.TagPattern.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// channelRange returns the latest tag of the channel, or the current revision if it is a tag, along with the tag of
+// the release before it of the channel or of a more stable channel. Missing tags are returned as nil
+func (g *Generator) channelRange(c *Channel) (*gits.Tag, *gits.Tag, error) {
+	dir := g.ScmFactory.Dir
+	tags, err := gits.ListTags(g.Git(), dir, 0)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to list the tags of the %s channel in %s", c.Name, dir)
+	}
+	var current *gits.Tag
+	for i := range tags {
+		tag := &tags[i]
+		if current == nil {
+			if tag.Name == g.CurrentRevision || (g.CurrentRevision == "" && c.TagPattern.MatchString(tag.Name)) {
+				current = tag
+			}
+			continue
+		}
+		if c.Includes(tag.Name) {
+			return current, tag, nil
+		}
+	}
+	return current, nil, nil
+}
+
+// channelNotify returns the webhooks notified of the releases of the channel
+func (g *Generator) channelNotify() []string {
+	c := g.State.Channel
+	if c == nil {
+		return g.Notify
+	}
+	var answer []string
+	if c.Notify {
+		answer = append(answer, g.Notify...)
+	}
+	if u := g.ChannelNotify[c.Name]; u != "" {
+		answer = append(answer, u)
+	}
+	return answer
+}
+
+// channelStart marks the start of the section of the channel in the changelog file
+func channelStart(c *Channel) string {
+	return "<!-- jx-changelog:channel:" + c.Name + ":start -->"
+}
+
+// channelEnd marks the end of the section of the channel in the changelog file
+func channelEnd(c *Channel) string {
+	return "<!-- jx-changelog:channel:" + c.Name + ":end -->"
+}
+
+// UpdateChannelSection returns the changelog file with the markdown replacing the section of the channel so that
+// the latest release of each channel is kept in the same file. Missing sections are added in order of stability
+func UpdateChannelSection(content string, c *Channel, markdown string) string {
+	section := channelStart(c) + "\n## " + c.Section + "\n\n" + strings.TrimSpace(markdown) + "\n" + channelEnd(c)
+	start := strings.Index(content, channelStart(c))
+	end := strings.Index(content, channelEnd(c))
+	if start >= 0 && end > start {
+		return content[:start] + section + content[end+len(channelEnd(c)):]
+	}
+	// lets add the section before that of the first less stable channel
+	for _, other := range channels {
+		if other.stability >= c.stability {
+			continue
+		}
+		i := strings.Index(content, channelStart(other))
+		if i >= 0 {
+			return content[:i] + section + "\n\n" + content[i:]
+		}
+	}
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return section + "\n"
+	}
+	return content + "\n\n" + section + "\n"
+}
@@ -0,0 +1,200 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChannel(t *testing.T) {
+	t.Parallel()
+	c, err := changelog.ParseChannel("", "")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = changelog.ParseChannel(changelog.ChannelBeta, "")
+	require.NoError(t, err)
+	assert.True(t, c.Prerelease)
+	assert.True(t, c.Includes("v1.2.0-rc.1"))
+	assert.True(t, c.Includes("v1.1.0"), "the beta channel should start from stable releases")
+	assert.False(t, c.Includes("nightly-20200913"))
+
+	c, err = changelog.ParseChannel(changelog.ChannelStable, "")
+	require.NoError(t, err)
+	assert.False(t, c.Prerelease)
+	assert.False(t, c.Includes("v1.2.0-beta.1"))
+
+	c, err = changelog.ParseChannel(changelog.ChannelNightly, `^edge-\d+$`)
+	require.NoError(t, err)
+	assert.True(t, c.Includes("edge-20200913"))
+	assert.True(t, c.Includes("v1.2.0-beta.1"))
+
+	_, err = changelog.ParseChannel("weekly", "")
+	assert.Error(t, err)
+	_, err = changelog.ParseChannel(changelog.ChannelBeta, "beta-(")
+	assert.Error(t, err)
+	_, err = changelog.ParseChannel("", "^edge")
+	assert.Error(t, err, "the tag pattern should require a channel")
+}
+
+func TestChannelRange(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	git := func(date string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git("", "config", "user.email", "jane@foo.com")
+	git("", "config", "user.name", "Jane Doe")
+	git("2019-12-31T10:00:00Z", "commit", "-q", "--allow-empty", "-m", "chore: initial")
+	first := git("", "rev-parse", "HEAD")
+	shas := map[string]string{}
+	for i, tag := range []string{"v1.0.0", "v1.1.0-beta.1", "nightly-20200103", "v1.1.0-beta.2", "nightly-20200105"} {
+		date := fmt.Sprintf("2020-01-0%dT10:00:00Z", i+1)
+		git(date, "commit", "-q", "--allow-empty", "-m", "fix: change "+tag)
+		git(date, "tag", tag)
+		shas[tag] = git("", "rev-parse", "HEAD")
+	}
+	git("2020-01-06T10:00:00Z", "commit", "-q", "--allow-empty", "-m", "feat: unreleased")
+
+	testCases := []struct {
+		channel, current, previous string
+	}{
+		{changelog.ChannelStable, "v1.0.0", ""},
+		{changelog.ChannelBeta, "v1.1.0-beta.2", "v1.1.0-beta.1"},
+		{changelog.ChannelNightly, "nightly-20200105", "v1.1.0-beta.2"},
+	}
+	for _, tc := range testCases {
+		g := &changelog.Generator{Channel: tc.channel}
+		g.ScmFactory.Dir = dir
+		require.NoError(t, g.Validate())
+		rng, err := g.ResolveRange(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, rng)
+		assert.Equal(t, tc.current, rng.CurrentName, "current release of the %s channel", tc.channel)
+		assert.Equal(t, shas[tc.current], rng.CurrentRev, "current revision of the %s channel", tc.channel)
+		if tc.previous != "" {
+			assert.Equal(t, tc.previous, rng.PreviousName, "previous release of the %s channel", tc.channel)
+			assert.Equal(t, shas[tc.previous], rng.PreviousRev, "previous revision of the %s channel", tc.channel)
+		} else {
+			assert.Equal(t, first, rng.PreviousRev, "the first release of the %s channel should start from the first commit", tc.channel)
+		}
+	}
+
+	// lets release a tag of the channel which is not the latest
+	g := &changelog.Generator{Channel: changelog.ChannelBeta, CurrentRevision: "v1.1.0-beta.1"}
+	g.ScmFactory.Dir = dir
+	require.NoError(t, g.Validate())
+	rng, err := g.ResolveRange(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0-beta.1", rng.CurrentRev)
+	assert.Equal(t, "v1.0.0", rng.PreviousName)
+}
+
+func TestUpdateChannelSection(t *testing.T) {
+	t.Parallel()
+	stable, err := changelog.ParseChannel(changelog.ChannelStable, "")
+	require.NoError(t, err)
+	beta, err := changelog.ParseChannel(changelog.ChannelBeta, "")
+	require.NoError(t, err)
+	nightly, err := changelog.ParseChannel(changelog.ChannelNightly, "")
+	require.NoError(t, err)
+
+	content := changelog.UpdateChannelSection("# Changelog\n", nightly, "### Nightly 1\n")
+	content = changelog.UpdateChannelSection(content, stable, "### Stable 1\n")
+	content = changelog.UpdateChannelSection(content, beta, "### Beta 1\n")
+	content = changelog.UpdateChannelSection(content, nightly, "### Nightly 2\n")
+	assert.Equal(t, `# Changelog
+
+<!-- jx-changelog:channel:stable:start -->
+## Stable
+
+### Stable 1
+<!-- jx-changelog:channel:stable:end -->
+
+<!-- jx-changelog:channel:beta:start -->
+## Beta
+
+### Beta 1
+<!-- jx-changelog:channel:beta:end -->
+
+<!-- jx-changelog:channel:nightly:start -->
+## Nightly
+
+### Nightly 2
+<!-- jx-changelog:channel:nightly:end -->
+`, content)
+}
+
+func TestChannelPublish(t *testing.T) {
+	t.Parallel()
+	var notified []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := changelog.SlackMessage{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		notified = append(notified, r.URL.Path)
+	}))
+	defer server.Close()
+
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+	dir := initRepo(t)
+	for _, tc := range []struct {
+		channel    string
+		prerelease bool
+		notified   []string
+	}{
+		{changelog.ChannelStable, false, []string{"/all", "/stable"}},
+		{changelog.ChannelNightly, true, []string{"/nightly"}},
+	} {
+		notified = nil
+		scmClient, data := scmfake.NewDefault()
+		g := &changelog.Generator{
+			Channel:       tc.channel,
+			UpdateRelease: true,
+			Notify:        []string{server.URL + "/all"},
+			ChannelNotify: map[string]string{
+				changelog.ChannelStable:  server.URL + "/stable",
+				changelog.ChannelNightly: server.URL + "/nightly",
+			},
+		}
+		g.ScmFactory.Dir = dir
+		g.ScmFactory.GitURL = gitInfo
+		g.ScmFactory.ScmClient = scmClient
+		g.ScmFactory.Owner = "myorg"
+		g.ScmFactory.Repository = "myrepo"
+		g.ScmFactory.GitKind = "fake"
+		require.NoError(t, g.Validate())
+		g.State.GitInfo = gitInfo
+		result := &changelog.Result{
+			Range:    &changelog.Range{},
+			Release:  &v1.Release{Spec: v1.ReleaseSpec{Version: "1.2.3"}},
+			Markdown: "## Changes\n",
+			Output:   "## Changes\n",
+		}
+		require.NoError(t, g.Publish(context.Background(), result))
+		require.Len(t, data.Releases["myorg/myrepo"], 1)
+		for _, r := range data.Releases["myorg/myrepo"] {
+			assert.Equal(t, tc.prerelease, r.Prerelease, "prerelease of the %s channel", tc.channel)
+		}
+		assert.Equal(t, tc.notified, notified, "notifications of the %s channel", tc.channel)
+	}
+}
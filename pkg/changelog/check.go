@@ -0,0 +1,196 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PolicyNoBreakingChanges fails if any commit is a breaking change
+	PolicyNoBreakingChanges = "no-breaking-changes"
+
+	// PolicyAllIssuesClosed fails if any referenced issue is not closed
+	PolicyAllIssuesClosed = "all-issues-closed"
+
+	// PolicyConventionalCommits fails if any commit does not use Conventional Commits
+	PolicyConventionalCommits = "conventional-commits"
+
+	// PolicyLinkedIssues fails if any commit does not reference an issue or pull request
+	PolicyLinkedIssues = "linked-issues"
+
+	// PolicyMaxCommits fails if the release has more commits than the argument such as 'max-commits=50'
+	PolicyMaxCommits = "max-commits"
+)
+
+// closedIssueStates the states of issues and pull requests which are considered closed
+var closedIssueStates = []string{"closed", "merged", "done", "resolved", "fixed"}
+
+// PolicyViolation a violation of a policy required of the changelog
+type PolicyViolation struct {
+	Policy  string `json:"policy"`
+	Message string `json:"message"`
+}
+
+// policy evaluates a policy against the model returning the messages of its violations
+type policy struct {
+	parseArg func(arg string) error
+	check    func(model *ExportModel, arg string) []string
+}
+
+var policies = map[string]policy{
+	PolicyNoBreakingChanges:   {check: checkNoBreakingChanges},
+	PolicyAllIssuesClosed:     {check: checkAllIssuesClosed},
+	PolicyConventionalCommits: {check: checkConventionalCommits},
+	PolicyLinkedIssues:        {check: checkLinkedIssues},
+	PolicyMaxCommits:          {parseArg: parseMaxCommits, check: checkMaxCommits},
+}
+
+// PolicyNames returns the sorted names of the supported policies
+func PolicyNames() []string {
+	var answer []string
+	for name := range policies {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// ValidatePolicy validates a policy expression of the form 'name' or 'name=argument'
+func ValidatePolicy(expression string) error {
+	name, arg := splitPolicy(expression)
+	p, ok := policies[name]
+	if !ok {
+		return errors.Errorf("unknown policy %s. Supported policies: %s", name, strings.Join(PolicyNames(), ", "))
+	}
+	if p.parseArg == nil {
+		if arg != "" {
+			return errors.Errorf("policy %s does not take an argument", name)
+		}
+		return nil
+	}
+	if arg == "" {
+		return errors.Errorf("policy %s requires an argument such as '%s=10'", name, name)
+	}
+	return p.parseArg(arg)
+}
+
+// CheckPolicies evaluates the policy expressions against the model returning the violations in the order of the
+// expressions
+func CheckPolicies(model *ExportModel, expressions []string) ([]PolicyViolation, error) {
+	var answer []PolicyViolation
+	for _, expression := range expressions {
+		err := ValidatePolicy(expression)
+		if err != nil {
+			return nil, err
+		}
+		name, arg := splitPolicy(expression)
+		for _, m := range policies[name].check(model, arg) {
+			answer = append(answer, PolicyViolation{Policy: expression, Message: m})
+		}
+	}
+	return answer, nil
+}
+
+func splitPolicy(expression string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(expression), "=", 2)
+	name := strings.TrimSpace(parts[0])
+	if len(parts) == 1 {
+		return name, ""
+	}
+	return name, strings.TrimSpace(parts[1])
+}
+
+func checkNoBreakingChanges(model *ExportModel, _ string) []string {
+	var answer []string
+	for _, c := range model.Changelog.Commits {
+		if c.Breaking {
+			answer = append(answer, fmt.Sprintf("commit %s is a breaking change: %s", shortSHA(c.SHA), c.Subject))
+		}
+	}
+	return answer
+}
+
+func checkAllIssuesClosed(model *ExportModel, _ string) []string {
+	var answer []string
+	for _, issue := range model.Changelog.Issues {
+		state := strings.ToLower(issue.State)
+		closed := false
+		for _, s := range closedIssueStates {
+			if state == s {
+				closed = true
+				break
+			}
+		}
+		if !closed {
+			if state == "" {
+				state = "unknown"
+			}
+			answer = append(answer, fmt.Sprintf("issue #%s is %s: %s", issue.ID, state, issue.Title))
+		}
+	}
+	return answer
+}
+
+func checkConventionalCommits(model *ExportModel, _ string) []string {
+	var answer []string
+	for _, c := range model.Changelog.Commits {
+		if c.Type == "" {
+			answer = append(answer, fmt.Sprintf("commit %s does not use Conventional Commits: %s", shortSHA(c.SHA), c.Subject))
+		}
+	}
+	return answer
+}
+
+func checkLinkedIssues(model *ExportModel, _ string) []string {
+	var answer []string
+	for _, c := range model.Changelog.Commits {
+		if len(c.IssueIDs) == 0 {
+			answer = append(answer, fmt.Sprintf("commit %s does not reference an issue or pull request: %s", shortSHA(c.SHA), c.Subject))
+		}
+	}
+	return answer
+}
+
+func parseMaxCommits(arg string) error {
+	limit, err := strconv.Atoi(arg)
+	if err != nil || limit < 0 {
+		return errors.Errorf("the maximum number of commits %s should be a non negative number", arg)
+	}
+	return nil
+}
+
+func checkMaxCommits(model *ExportModel, arg string) []string {
+	limit, _ := strconv.Atoi(arg)
+	count := len(model.Changelog.Commits)
+	if count > limit {
+		return []string{fmt.Sprintf("the release has %d commits which is more than %d", count, limit)}
+	}
+	return nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// PolicyViolationsError returns an ErrPolicyViolation error summarising the violations or nil if there are none
+func PolicyViolationsError(violations []PolicyViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	failed := map[string]bool{}
+	var names []string
+	for _, v := range violations {
+		if !failed[v.Policy] {
+			failed[v.Policy] = true
+			names = append(names, v.Policy)
+		}
+	}
+	return newError(ErrPolicyViolation, "the changelog has %d violations of the policies: %s", len(violations), strings.Join(names, ", "))
+}
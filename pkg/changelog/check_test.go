@@ -0,0 +1,52 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPolicies(t *testing.T) {
+	t.Parallel()
+	feat := changelog.NewCommit("1111111aaaa", "feat(cli)!: something new\n\nFixes #12")
+	feat.IssueIDs = []string{"12"}
+	model := &changelog.ExportModel{
+		Changelog: &changelog.Changelog{
+			Commits: []*changelog.Commit{feat, changelog.NewCommit("2222222bbbb", "wip")},
+			Issues: []*changelog.Issue{
+				{ID: "12", Title: "done", State: "Closed"},
+				{ID: "13", Title: "still open", State: "open"},
+				{ID: "14", Title: "no state"},
+			},
+		},
+	}
+
+	violations, err := changelog.CheckPolicies(model, []string{changelog.PolicyNoBreakingChanges, changelog.PolicyAllIssuesClosed, "max-commits=2"})
+	require.NoError(t, err)
+	assert.Equal(t, []changelog.PolicyViolation{
+		{Policy: "no-breaking-changes", Message: "commit 1111111 is a breaking change: something new"},
+		{Policy: "all-issues-closed", Message: "issue #13 is open: still open"},
+		{Policy: "all-issues-closed", Message: "issue #14 is unknown: no state"},
+	}, violations)
+
+	err = changelog.PolicyViolationsError(violations)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, changelog.ErrPolicyViolation)
+	assert.Equal(t, changelog.ExitCodePolicyViolation, changelog.ExitCode(err))
+	assert.Equal(t, "the changelog has 3 violations of the policies: no-breaking-changes, all-issues-closed", err.Error())
+	assert.NoError(t, changelog.PolicyViolationsError(nil))
+
+	violations, err = changelog.CheckPolicies(model, []string{"conventional-commits", "linked-issues", "max-commits=1"})
+	require.NoError(t, err)
+	require.Len(t, violations, 3)
+	assert.Equal(t, "commit 2222222 does not use Conventional Commits: wip", violations[0].Message)
+	assert.Equal(t, "the release has 2 commits which is more than 1", violations[2].Message)
+
+	for _, expression := range []string{"unknown", "max-commits", "max-commits=-1", "no-breaking-changes=true"} {
+		assert.Error(t, changelog.ValidatePolicy(expression), "expression %s", expression)
+	}
+}
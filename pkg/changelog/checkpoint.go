@@ -0,0 +1,131 @@
+package changelog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// checkpointInterval the number of issues looked up between saving the checkpoint
+const checkpointInterval = 25
+
+// Checkpoint the progress of enriching the changelog saved to the checkpoint file so that a run which fails part of
+// the way through such as hitting the rate limit resumes where it left off
+type Checkpoint struct {
+	// Range the range being collected. The checkpoint is ignored if it was saved for a different range
+	Range string `json:"range"`
+
+	// Issues the issues and pull requests resolved in the issue tracker
+	Issues []refs.IssueSummary `json:"issues,omitempty"`
+
+	// Users the users resolved via the git provider
+	Users *users.Checkpoint `json:"users,omitempty"`
+}
+
+// checkpointKey returns the key of the range the checkpoint is saved for
+func checkpointKey(rng *Range) string {
+	switch {
+	case rng.FromIssueTracker():
+		return rng.Description()
+	case rng.FirstRelease:
+		return "initial release up to " + rng.CurrentRev
+	default:
+		return rng.PreviousRev + ".." + rng.CurrentRev
+	}
+}
+
+// loadCheckpoint restores the issues and users of the checkpoint file if it was saved for the same range and records
+// those resolved from now on so that the checkpoint can be saved
+func (g *Generator) loadCheckpoint(rng *Range, resolver *users.GitUserResolver) error {
+	path := g.CheckpointFile
+	if path == "" {
+		return nil
+	}
+	checkpoint := &Checkpoint{Range: checkpointKey(rng)}
+	g.State.Checkpoint = checkpoint
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the checkpoint file %s", path)
+	}
+	previous := &Checkpoint{}
+	err = json.Unmarshal(data, previous)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the checkpoint file %s", path)
+	}
+	if previous.Range != checkpoint.Range {
+		log.Logger().Infof("ignoring the checkpoint file %s of %s as it was saved for a different range", path, previous.Range)
+		return nil
+	}
+	checkpoint.Issues = previous.Issues
+	g.State.Refs.Known = map[string]*refs.IssueSummary{}
+	for i := range checkpoint.Issues {
+		g.State.Refs.Known[checkpoint.Issues[i].ID] = &checkpoint.Issues[i]
+	}
+	resolver.Restore(previous.Users)
+	log.Logger().Infof("resuming from the checkpoint file %s with %d issues already resolved", info(path), len(checkpoint.Issues))
+	return nil
+}
+
+// recordCheckpoint adds the resolved issue to the checkpoint saving it every checkpointInterval issues
+func (g *Generator) recordCheckpoint(issue *refs.IssueSummary, resolver *users.GitUserResolver) {
+	checkpoint := g.State.Checkpoint
+	if checkpoint == nil {
+		return
+	}
+	checkpoint.Issues = append(checkpoint.Issues, *issue)
+	if len(checkpoint.Issues)%checkpointInterval == 0 {
+		err := g.saveCheckpoint(resolver)
+		if err != nil {
+			log.Logger().Warnf("%s", err.Error())
+		}
+	}
+}
+
+// saveCheckpoint saves the issues and users resolved so far to the checkpoint file
+func (g *Generator) saveCheckpoint(resolver *users.GitUserResolver) error {
+	checkpoint := g.State.Checkpoint
+	if checkpoint == nil {
+		return nil
+	}
+	checkpoint.Users = resolver.Checkpoint()
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the checkpoint")
+	}
+	path := g.CheckpointFile
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the checkpoint file %s", path)
+	}
+	return nil
+}
+
+// finishCheckpoint removes the checkpoint file once the changelog has been fully enriched. If the enrichment was cut
+// short by the API budget the checkpoint is saved instead so that a retry can complete it
+func (g *Generator) finishCheckpoint(resolver *users.GitUserResolver, failed bool) error {
+	if g.State.Checkpoint == nil {
+		return nil
+	}
+	if failed || g.State.Budget.Exhausted() {
+		err := g.saveCheckpoint(resolver)
+		if err == nil {
+			log.Logger().Infof("saved the progress to the checkpoint file %s so that the next run resumes from it", info(g.CheckpointFile))
+		}
+		return err
+	}
+	err := os.Remove(g.CheckpointFile)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove the checkpoint file %s", g.CheckpointFile)
+	}
+	return nil
+}
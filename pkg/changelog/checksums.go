@@ -0,0 +1,108 @@
+package changelog
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+var bsdChecksumRegex = regexp.MustCompile(`^([A-Z0-9-]+) \((.+)\) = ([0-9a-fA-F]+)$`)
+
+// checksumAlgorithms the names of the algorithms of the checksums indexed by the length of their hex digest
+var checksumAlgorithms = map[int]string{32: "MD5", 40: "SHA1", 64: "SHA256", 128: "SHA512"}
+
+// Checksum the checksum of an artifact of the release
+type Checksum struct {
+	File      string
+	Algorithm string
+	Digest    string
+}
+
+// ParseChecksums parses the checksums in the format of the sha256sum command such as 'f2ca1b...  foo.tar.gz' or the
+// BSD format such as 'SHA256 (foo.tar.gz) = f2ca1b...'
+func ParseChecksums(text string) ([]Checksum, error) {
+	var answer []Checksum
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c := Checksum{}
+		if m := bsdChecksumRegex.FindStringSubmatch(line); m != nil {
+			c = Checksum{Algorithm: m[1], File: m[2], Digest: m[3]}
+		} else {
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 {
+				return nil, errors.Errorf("line %d is not a checksum and file name: %s", i+1, line)
+			}
+			c.Digest = fields[0]
+			c.File = strings.TrimPrefix(strings.TrimLeft(fields[1], " "), "*")
+			c.Algorithm = checksumAlgorithms[len(c.Digest)]
+		}
+		if _, err := hex.DecodeString(c.Digest); err != nil || c.File == "" {
+			return nil, errors.Errorf("line %d is not a checksum and file name: %s", i+1, line)
+		}
+		if c.Algorithm == "" {
+			c.Algorithm = "Checksum"
+		}
+		c.Digest = strings.ToLower(c.Digest)
+		answer = append(answer, c)
+	}
+	return answer, nil
+}
+
+// ChecksumsMarkdown renders the checksums as a markdown table
+func ChecksumsMarkdown(checksums []Checksum) string {
+	if len(checksums) == 0 {
+		return ""
+	}
+	algorithm := checksums[0].Algorithm
+	for _, c := range checksums {
+		if c.Algorithm != algorithm {
+			algorithm = "Checksum"
+		}
+	}
+	var buf strings.Builder
+	buf.WriteString("### Checksums\n\n| File | " + algorithm + " |\n| --- | --- |\n")
+	for _, c := range checksums {
+		digest := c.Digest
+		if algorithm == "Checksum" {
+			digest = c.Algorithm + ": " + digest
+		}
+		buf.WriteString("| `" + strings.ReplaceAll(c.File, "|", "\\|") + "` | `" + digest + "` |\n")
+	}
+	return buf.String()
+}
+
+// loadChecksums loads the checksums file if specified
+func (g *Generator) loadChecksums() error {
+	g.State.Checksums = nil
+	g.State.ChecksumsData = nil
+	if g.ChecksumsFile == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(g.ChecksumsFile)
+	if err != nil {
+		return options.InvalidOptionf("checksums-file", g.ChecksumsFile, "failed to read the file: %s", err.Error())
+	}
+	checksums, err := ParseChecksums(string(data))
+	if err != nil {
+		return options.InvalidOptionf("checksums-file", g.ChecksumsFile, "%s", err.Error())
+	}
+	g.State.Checksums = checksums
+	g.State.ChecksumsData = data
+	return nil
+}
+
+// checksumsAsset returns the checksums file uploaded as an asset of the release
+func (g *Generator) checksumsAsset() *ReleaseAsset {
+	if g.State.ChecksumsData == nil {
+		return nil
+	}
+	return &ReleaseAsset{Name: filepath.Base(g.ChecksumsFile), ContentType: "text/plain", Data: g.State.ChecksumsData}
+}
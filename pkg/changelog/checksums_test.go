@@ -0,0 +1,80 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sha256a = strings.Repeat("a", 64)
+	sha256b = strings.Repeat("B", 64)
+)
+
+func TestParseChecksums(t *testing.T) {
+	t.Parallel()
+	checksums, err := changelog.ParseChecksums(sha256a + "  foo-linux-amd64.tar.gz\n" + sha256b + " *foo-windows-amd64.zip\n\nSHA512 (foo.txt) = " + strings.Repeat("c", 128) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, []changelog.Checksum{
+		{File: "foo-linux-amd64.tar.gz", Algorithm: "SHA256", Digest: sha256a},
+		{File: "foo-windows-amd64.zip", Algorithm: "SHA256", Digest: strings.ToLower(sha256b)},
+		{File: "foo.txt", Algorithm: "SHA512", Digest: strings.Repeat("c", 128)},
+	}, checksums)
+
+	_, err = changelog.ParseChecksums("not a checksum")
+	assert.Error(t, err)
+	_, err = changelog.ParseChecksums("xyz  foo.tar.gz")
+	assert.Error(t, err)
+}
+
+func TestChecksumsMarkdown(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, changelog.ChecksumsMarkdown(nil))
+	assert.Equal(t, "### Checksums\n\n| File | SHA256 |\n| --- | --- |\n| `foo.tar.gz` | `"+sha256a+"` |\n",
+		changelog.ChecksumsMarkdown([]changelog.Checksum{{File: "foo.tar.gz", Algorithm: "SHA256", Digest: sha256a}}))
+	assert.Contains(t, changelog.ChecksumsMarkdown([]changelog.Checksum{
+		{File: "foo.tar.gz", Algorithm: "SHA256", Digest: sha256a},
+		{File: "foo.txt", Algorithm: "MD5", Digest: "d41d8cd98f00b204e9800998ecf8427e"},
+	}), "| `foo.txt` | `MD5: d41d8cd98f00b204e9800998ecf8427e` |")
+}
+
+func TestChecksumsFile(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	checksumsFile := filepath.Join(tmpDir, "sha256sums.txt")
+	require.NoError(t, ioutil.WriteFile(checksumsFile, []byte(sha256a+"  foo.tar.gz\n"), 0600))
+
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+	g := &changelog.Generator{ChecksumsFile: checksumsFile, Footer: "the footer\n"}
+	require.NoError(t, g.Validate())
+	g.State.GitInfo = gitInfo
+	result := &changelog.Result{
+		Range:           &changelog.Range{},
+		Release:         &v1.Release{Spec: v1.ReleaseSpec{Version: "1.2.3", Commits: []v1.CommitSummary{{SHA: "1111111aaaa", Message: "fix: a bug"}}}},
+		Changelog:       &changelog.Changelog{Commits: []*changelog.Commit{changelog.NewCommit("1111111aaaa", "fix: a bug")}},
+		MarkdownOptions: &gits.MarkdownOptions{},
+	}
+	require.NoError(t, g.Render(context.Background(), result))
+	assert.Contains(t, result.Markdown, "\n### Checksums\n\n| File | SHA256 |\n| --- | --- |\n| `foo.tar.gz` | `"+sha256a+"` |\nthe footer\n")
+	require.Len(t, result.Assets, 1)
+	assert.Equal(t, "sha256sums.txt", result.Assets[0].Name)
+	assert.Equal(t, sha256a+"  foo.tar.gz\n", string(result.Assets[0].Data))
+
+	g.ChecksumsFile = filepath.Join(tmpDir, "missing.txt")
+	assert.Error(t, g.Validate())
+}
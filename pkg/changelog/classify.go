@@ -0,0 +1,78 @@
+package changelog
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// classifyDependencies classifies the dependency updates by the change of their semantic version indexed by
+// gits.DependencyKey. If advisories are enabled updates of the dependency sections which fix security advisories
+// are classified as security updates
+func (g *Generator) classifyDependencies(updates []v1.DependencyUpdate, sections []deps.Section) map[string]deps.Classification {
+	lookup := g.AdvisoryLookup
+	if lookup == nil && g.DependencyAdvisories {
+		lookup = &deps.OSVLookup{Client: g.HTTPClient}
+	}
+	sectionUpdates := map[string]deps.Update{}
+	for _, section := range sections {
+		for i := range section.Updates {
+			du := deps.ToDependencyUpdate(&section.Updates[i])
+			sectionUpdates[gits.DependencyKey(&du)] = section.Updates[i]
+		}
+	}
+	answer := map[string]deps.Classification{}
+	for i := range updates {
+		du := &updates[i]
+		key := gits.DependencyKey(du)
+		c := deps.Classification{Class: deps.ClassifyVersions(du.FromVersion, du.ToVersion)}
+		if u, ok := sectionUpdates[key]; ok && lookup != nil {
+			// the versions of the collapsed update span all the updates of the dependency
+			u.From = du.FromVersion
+			u.To = du.ToVersion
+			advisories, err := lookup.Fixed(&u)
+			if err != nil {
+				log.Logger().Warnf("failed to look up the security advisories of dependency %s: %s", u.Name, err.Error())
+			} else if len(advisories) > 0 {
+				c = deps.Classification{Class: deps.ClassSecurity, Advisories: advisories}
+			}
+		}
+		if c.Class != "" {
+			answer[key] = c
+		}
+	}
+	return answer
+}
+
+// addDependencyClassesAnnotations records the classification of the dependency updates on the Release along with
+// the classes found in the release
+func addDependencyClassesAnnotations(release *v1.Release, classifications map[string]deps.Classification) error {
+	if len(classifications) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(classifications)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the dependency classes")
+	}
+	found := map[string]bool{}
+	for _, c := range classifications {
+		found[c.Class] = true
+	}
+	var classes []string
+	for _, class := range deps.Classes {
+		if found[class] {
+			classes = append(classes, class)
+		}
+	}
+	if release.Annotations == nil {
+		release.Annotations = map[string]string{}
+	}
+	release.Annotations[DependencyClassesAnnotation] = string(data)
+	release.Annotations[DependencyUpdatesAnnotation] = strings.Join(classes, ",")
+	return nil
+}
@@ -0,0 +1,22 @@
+package changelog
+
+import "time"
+
+// Clock provides the current time so that tests can generate changelogs with deterministic timestamps
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// now returns the current time of the Clock defaulting to the system clock
+func (g *Generator) now() time.Time {
+	if g.Clock == nil {
+		return systemClock{}.Now()
+	}
+	return g.Clock.Now()
+}
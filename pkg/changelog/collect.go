@@ -0,0 +1,710 @@
+package changelog
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/helmhelpers"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	jenkinsio "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// enrichWindow the number of commits per worker which are enriched ahead of the commits being added to the changelog
+const enrichWindow = 4
+
+// Collect finds the git commits in the range along with their issues, pull requests and users and creates the Release.
+// If there is no git repository a nil result is returned
+func (g *Generator) Collect(ctx context.Context, rng *Range) (*Result, error) {
+	g.State.Context = ctx
+	g.State.FirstRelease = rng.FirstRelease
+	if g.State.Budget == nil {
+		g.State.Budget = NewAPIBudget(g.MaxAPICalls, g.MinRateLimitRemaining)
+		g.State.Budget.Guard(g.ScmFactory.ScmClient)
+	}
+	previousRev := rng.PreviousRev
+	currentRev := rng.CurrentRev
+
+	dir := g.ScmFactory.Dir
+	templatesDir, err := g.templatesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.Logger().WithFields(logrus.Fields{
+		LogKeyPreviousRevision: previousRev,
+		LogKeyCurrentRevision:  currentRev,
+	})
+	switch {
+	case rng.FromIssueTracker():
+		logger.Infof("Generating change log of %s", info(rng.Description()))
+	case rng.FirstRelease:
+		logger.Infof("Generating change log of the initial release up to git ref %s", info(currentRev))
+	default:
+		logger.Infof("Generating change log from git ref %s => %s", info(previousRev), info(currentRev))
+	}
+
+	gitDir, gitConfDir, err := gitclient.FindGitConfigDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if (gitDir == "" || gitConfDir == "") && !rng.FromIssueTracker() {
+		log.Logger().Warnf("No git directory could be found from dir %s", dir)
+		return nil, nil
+	}
+
+	gitInfo := g.ScmFactory.GitURL
+	if gitInfo == nil {
+		gitInfo, err = giturl.ParseGitURL(g.ScmFactory.SourceURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse git URL %s", g.ScmFactory.SourceURL)
+		}
+	}
+
+	g.State.GitInfo = gitInfo
+	g.State.Branch = g.releaseBranch()
+
+	tracker, err := g.CreateIssueProvider()
+	if err != nil {
+		return nil, err
+	}
+	g.State.Tracker = tracker
+
+	version := g.Version
+	if version == "" {
+		version = SpecVersion
+	}
+
+	release := &v1.Release{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Release",
+			APIVersion: jenkinsio.GroupAndVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ReleaseName,
+			CreationTimestamp: metav1.Time{
+				Time: g.now(),
+			},
+			//ResourceVersion:   "1",
+			DeletionTimestamp: &metav1.Time{},
+		},
+		Spec: v1.ReleaseSpec{
+			Name:          SpecName,
+			Version:       version,
+			GitOwner:      gitInfo.Organisation,
+			GitRepository: gitInfo.Name,
+			GitHTTPURL:    gitInfo.HttpsURL(),
+			GitCloneURL:   gitInfo.CloneURL,
+			Commits:       []v1.CommitSummary{},
+			Issues:        []v1.IssueSummary{},
+			PullRequests:  []v1.IssueSummary{},
+		},
+	}
+
+	resolver, err := g.createUserResolver(dir)
+	if err != nil {
+		return nil, err
+	}
+	g.State.Refs = &refs.Resolver{
+		Tracker:     tracker,
+		Users:       resolver,
+		SharedCache: g.SharedCache,
+		OnLookupError: func(err error) error {
+			return HandleError(g.OnIssueLookupError, err)
+		},
+		StartLookup: func() func() {
+			return g.StartPhase(PhaseIssueLookup)
+		},
+		StartUserResolution: func() func() {
+			return g.StartPhase(PhaseUserResolution)
+		},
+		OnResolved: func(issue *refs.IssueSummary) {
+			g.recordCheckpoint(issue, resolver)
+		},
+	}
+	err = g.loadCheckpoint(rng, resolver)
+	if err != nil {
+		return nil, err
+	}
+	model := &Changelog{}
+	if rng.FromIssueTracker() {
+		err = g.collectQuery(model, rng)
+	} else {
+		err = g.collectCommits(ctx, rng, gitDir, model, resolver)
+	}
+	if err != nil {
+		if cerr := g.finishCheckpoint(resolver, true); cerr != nil {
+			log.Logger().Warnf("%s", cerr.Error())
+		}
+		return nil, err
+	}
+	err = g.finishCheckpoint(resolver, false)
+	if err != nil {
+		return nil, err
+	}
+	var reworked []string
+	if g.CollapseReverts {
+		reworked = model.CollapseReverts()
+	}
+	model.CategorizeByLabels(g.State.LabelTypes)
+	model.ProjectInto(&release.Spec)
+	err = g.addChangesets(dir, rng, release)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencySections, err := g.analyzeDependencies(rng)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range dependencySections {
+		for i := range section.Updates {
+			release.Spec.DependencyUpdates = append(release.Spec.DependencyUpdates, deps.ToDependencyUpdate(&section.Updates[i]))
+		}
+	}
+	err = addDependencyKindsAnnotation(release, dependencySections)
+	if err != nil {
+		return nil, err
+	}
+	release.Spec.DependencyUpdates = CollapseDependencyUpdates(release.Spec.DependencyUpdates)
+
+	commitCount := len(release.Spec.Commits)
+	switch {
+	case rng.FromIssueTracker():
+		err = g.checkQuery(&release.Spec, rng)
+		if err != nil {
+			return nil, err
+		}
+	case g.FailIfFindCommits && commitCount == 0:
+		return nil, newError(ErrNoCommits, "no commits found between revision %s and %s", previousRev, currentRev)
+	case g.MinCommits > 0 && commitCount < g.MinCommits:
+		return nil, newError(ErrNoCommits, "found %d commits between revision %s and %s but at least %d are required", commitCount, previousRev, currentRev, g.MinCommits)
+	}
+
+	markdownOptions := &gits.MarkdownOptions{
+		ContributorAvatars: g.ContributorAvatars,
+		CompareURL:         gits.CompareURL(gitInfo, g.ScmFactory.GitKind, rng.PreviousName, rng.CurrentName),
+		CompareLink:        g.CompareLink,
+		CommitLinks:        g.CommitLinks,
+		InitialRelease:     rng.FirstRelease,
+		Mentions:           g.createMentions(),
+		DependencySections: dependencySections,
+		LabelBadges:        g.LabelBadges,
+		LabelFilter:        g.State.LabelFilter,
+	}
+	for _, sha := range reworked {
+		if markdownOptions.Reworked == nil {
+			markdownOptions.Reworked = map[string]bool{}
+		}
+		markdownOptions.Reworked[sha] = true
+	}
+	if g.ClassifyDependencies || g.DependencyAdvisories {
+		markdownOptions.DependencyClassifications = g.classifyDependencies(release.Spec.DependencyUpdates, dependencySections)
+		err = addDependencyClassesAnnotations(release, markdownOptions.DependencyClassifications)
+		if err != nil {
+			return nil, err
+		}
+	}
+	offline := g.State.Budget.Exhausted()
+	if g.DependencyUpdatePaths && !offline {
+		markdownOptions.DependencyChanges = g.aggregateUpstreamReleases(release.Spec.DependencyUpdates)
+	}
+	if g.DependencyReleaseNotes && !offline {
+		markdownOptions.DependencyReleaseNotes = g.findUpstreamReleaseNotes(release.Spec.DependencyUpdates)
+	}
+	if rng.FirstRelease {
+		if release.Annotations == nil {
+			release.Annotations = map[string]string{}
+		}
+		release.Annotations[InitialReleaseAnnotation] = "true"
+	}
+	if g.Reviewers && !offline {
+		stopUserResolution := g.StartPhase(PhaseUserResolution)
+		markdownOptions.Reviewers = g.findReviewers(&release.Spec, resolver)
+		stopUserResolution()
+		err = addReviewersAnnotation(release, markdownOptions.Reviewers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.Deployments && !offline {
+		markdownOptions.Deployments = g.findDeployments(rng)
+		err = addDeploymentsAnnotation(release, markdownOptions.Deployments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	g.State.Release = release
+	return &Result{
+		Range:           rng,
+		Changelog:       model,
+		Release:         release,
+		MarkdownOptions: markdownOptions,
+		TemplatesDir:    templatesDir,
+	}, nil
+}
+
+// templatesDir returns the templates directory of the helm chart the Release YAML is generated into creating it if
+// the Release YAML or CRD is generated
+func (g *Generator) templatesDir() (string, error) {
+	templatesDir := g.TemplatesDir
+	if templatesDir == "" {
+		chartFile, err := helmhelpers.FindChart(g.ScmFactory.Dir)
+		if err != nil {
+			return "", errors.Wrap(err, "could not find helm chart")
+		}
+		path, _ := filepath.Split(chartFile)
+		templatesDir = filepath.Join(path, "templates")
+	}
+	if g.GenerateReleaseYaml || g.GenerateCRD {
+		err := os.MkdirAll(templatesDir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to create the templates directory %s", templatesDir)
+		}
+	}
+	return templatesDir, nil
+}
+
+// collectCommits streams the commits of the range adding them to the model one at a time so that the git commits of
+// large ranges are not held in memory. If there are several EnrichWorkers the commits are enriched concurrently
+func (g *Generator) collectCommits(ctx context.Context, rng *Range, gitDir string, model *Changelog, resolver *users.GitUserResolver) error {
+	walk := func(fn func(commit *object.Commit) error) error {
+		return g.walkCommits(ctx, rng, gitDir, fn)
+	}
+	if commits, ok := g.prefetchIssues(ctx, rng, gitDir); ok {
+		walk = func(fn func(commit *object.Commit) error) error {
+			for _, commit := range commits {
+				if ctx.Err() != nil {
+					return errors.Wrap(ctx.Err(), "aborted generating the changelog")
+				}
+				err := fn(commit)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	log.Logger().Debugf("Found commits:")
+	if g.EnrichWorkers > 1 {
+		return g.collectCommitsConcurrently(ctx, walk, model, resolver)
+	}
+	return walk(func(commit *object.Commit) error {
+		logCommit(commit)
+		return g.addCommit(model, g.enrichCommit(commit, resolver))
+	})
+}
+
+// collectCommitsConcurrently enriches the commits of the range on a pool of EnrichWorkers goroutines then adds them
+// to the model in the order of the git history. At most enrichWindow commits per worker are in flight at once
+func (g *Generator) collectCommitsConcurrently(ctx context.Context, walk func(fn func(commit *object.Commit) error) error, model *Changelog, resolver *users.GitUserResolver) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	workers := g.EnrichWorkers
+	type job struct {
+		index  int
+		commit *object.Commit
+	}
+	jobs := make(chan job, workers)
+	results := make(chan *enrichedCommit, workers)
+	window := make(chan struct{}, workers*enrichWindow)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				e := g.enrichCommit(j.commit, resolver)
+				e.index = j.index
+				results <- e
+			}
+		}()
+	}
+	walked := make(chan error, 1)
+	go func() {
+		index := 0
+		err := walk(func(commit *object.Commit) error {
+			logCommit(commit)
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return errors.Wrap(ctx.Err(), "aborted generating the changelog")
+			}
+			jobs <- job{index: index, commit: commit}
+			index++
+			return nil
+		})
+		close(jobs)
+		wg.Wait()
+		close(results)
+		walked <- err
+	}()
+
+	// lets add the commits in order as the first commit referencing an issue is the one it is listed with
+	var err error
+	pending := map[int]*enrichedCommit{}
+	next := 0
+	for e := range results {
+		pending[e.index] = e
+		for p := pending[next]; p != nil; p = pending[next] {
+			delete(pending, next)
+			next++
+			<-window
+			if err == nil {
+				err = g.addCommit(model, p)
+				if err != nil {
+					cancel()
+				}
+			}
+		}
+	}
+	walkErr := <-walked
+	if err != nil {
+		return err
+	}
+	return walkErr
+}
+
+func logCommit(commit *object.Commit) {
+	log.Logger().Debugf("  commit %s", commit.Hash)
+	log.Logger().Debugf("  Author: %s <%s>", commit.Author.Name, commit.Author.Email)
+	log.Logger().Debugf("  Date: %s", commit.Committer.When.Format(time.ANSIC))
+	log.Logger().Debugf("      %s\n\n\n", commit.Message)
+}
+
+// prefetchIssues looks up the issues and pull requests referenced by the commits of the range in batches if the issue
+// tracker supports it. Any issues which fail to be prefetched are looked up one at a time. The walked commits are
+// returned so that the range is only walked once. Returns false if the issues are not prefetched in which case the
+// commits are streamed rather than held in memory
+func (g *Generator) prefetchIssues(ctx context.Context, rng *Range, gitDir string) ([]*object.Commit, bool) {
+	tracker, ok := g.State.Tracker.(issues.BatchIssueProvider)
+	if !ok || g.State.Budget.Exhausted() {
+		return nil, false
+	}
+	var commits []*object.Commit
+	var keys []string
+	found := map[string]bool{}
+	err := g.walkCommits(ctx, rng, gitDir, func(commit *object.Commit) error {
+		commits = append(commits, commit)
+		for _, ref := range g.scanRefs(commit) {
+			if !found[ref.ID] {
+				found[ref.ID] = true
+				keys = append(keys, ref.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false
+	}
+	if len(keys) > 0 {
+		stopLookup := g.StartPhase(PhaseIssueLookup)
+		err = tracker.PrefetchIssues(keys)
+		stopLookup()
+		if err != nil {
+			log.Logger().Warnf("failed to look up the issues of the commits in batches so looking them up one at a time: %s", err.Error())
+		}
+	}
+	return commits, true
+}
+
+// walkCommits calls the function with each commit of the range which is included in the changelog
+func (g *Generator) walkCommits(ctx context.Context, rng *Range, gitDir string, fn func(commit *object.Commit) error) error {
+	previousRev := rng.PreviousRev
+	currentRev := rng.CurrentRev
+	fetcher := g.State.CommitFetcher
+	if fetcher == nil {
+		fetcher = &gits.GoGitCommitFetcher{}
+	}
+
+	stopGitLog := g.StartPhase(PhaseGitLog)
+	var iter gits.CommitIterator
+	var err error
+	if rng.FirstRelease {
+		iter, err = fetcher.FetchHistory(gitDir, currentRev, g.FirstReleaseMax)
+	} else {
+		iter, err = fetcher.FetchCommits(gitDir, previousRev, currentRev)
+	}
+	stopGitLog()
+	if err != nil {
+		if g.FailIfFindCommits {
+			return err
+		}
+		if rng.FirstRelease {
+			log.Logger().Warnf("failed to find the git history of revision %s due to: %s", currentRev, err.Error())
+		} else {
+			log.Logger().Warnf("failed to find git commits between revision %s and %s due to: %s", previousRev, currentRev, err.Error())
+		}
+		return nil
+	}
+	defer iter.Close()
+
+	for {
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "aborted generating the changelog")
+		}
+		stopGitLog = g.StartPhase(PhaseGitLog)
+		commit, err := iter.Next()
+		stopGitLog()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if g.FailIfFindCommits {
+				return err
+			}
+			log.Logger().Warnf("failed to find git commits between revision %s and %s due to: %s", previousRev, currentRev, err.Error())
+			return nil
+		}
+		if g.skipReleaseCommit(commit) {
+			continue
+		}
+		if g.includeCommit(commit) {
+			err = fn(commit)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// includeCommit returns true if the commit should be included in the changelog using the merge commit policy
+func (g *Generator) includeCommit(commit *object.Commit) bool {
+	if len(commit.ParentHashes) <= 1 {
+		return true
+	}
+	switch g.MergeCommitPolicy {
+	case MergeCommitsInclude:
+		return true
+	case MergeCommitsOnlyPRs:
+		return MergePullRequestRegex.MatchString(commit.Message)
+	default:
+		return false
+	}
+}
+
+// skipReleaseCommit returns true if the commit message matches the skip commit pattern
+func (g *Generator) skipReleaseCommit(commit *object.Commit) bool {
+	regex := g.State.SkipCommitRegex
+	if regex != nil && regex.MatchString(commit.Message) {
+		log.Logger().Debugf("skipping release commit %s", commit.Hash.String())
+		return true
+	}
+	return false
+}
+
+// enrichedCommit a commit along with the results of looking it up on the git provider and issue tracker
+type enrichedCommit struct {
+	index      int
+	commit     *Commit
+	refs       []refs.Ref
+	offline    bool
+	prs        []issues.CommitPullRequest
+	lookedUpPR bool
+}
+
+// enrichCommit resolves the users of the commit and looks up its issues and pull requests. It only uses the
+// concurrency safe resolvers so that commits can be enriched by several goroutines
+func (g *Generator) enrichCommit(commit *object.Commit, resolver *users.GitUserResolver) *enrichedCommit {
+	var err error
+	sha := commit.Hash.String()
+	c := NewCommit(sha, commit.Message)
+	c.URL = gits.CommitURL(g.State.GitInfo, g.ScmFactory.GitKind, sha)
+	c.Branch = g.State.Branch
+	c.AuthorEmail = commit.Author.Email
+	if g.State.Budget.Exhausted() {
+		// lets resolve the users from their git signatures only
+		resolver.DisableGitProvider()
+	}
+	stopUserResolution := g.StartPhase(PhaseUserResolution)
+	if commit.Author.Email != "" && commit.Author.Name != "" {
+		c.Author, err = resolver.CommitAuthorAsUser(sha, &commit.Author)
+		if err != nil {
+			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git author %s: %v", commit.Author, err)
+		}
+	}
+	if commit.Committer.Email != "" && commit.Committer.Name != "" {
+		c.Committer, err = resolver.GitSignatureAsUser(&commit.Committer)
+		if err != nil {
+			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git committer %s: %v", commit.Committer, err)
+		}
+	}
+	stopUserResolution()
+
+	answer := &enrichedCommit{commit: c, refs: g.scanRefs(commit)}
+	if len(answer.refs) > 0 && g.State.Budget.Exhausted() {
+		answer.offline = true
+	} else {
+		g.State.Refs.Prefetch(answer.refs)
+	}
+
+	provider, ok := g.State.Tracker.(issues.CommitPullRequestProvider)
+	if g.CommitPullRequests && ok && !g.State.Budget.Exhausted() {
+		stopLookup := g.StartPhase(PhaseIssueLookup)
+		answer.prs, err = provider.CommitPullRequests(sha)
+		stopLookup()
+		if err != nil {
+			log.Logger().Warnf("failed to find the pull requests of commit %s: %s", sha, err.Error())
+		} else {
+			answer.lookedUpPR = true
+		}
+	}
+	return answer
+}
+
+// addCommit adds the enriched commit along with the issues and pull requests it is the first to reference
+func (g *Generator) addCommit(model *Changelog, e *enrichedCommit) error {
+	c := e.commit
+	err := g.addIssuesAndPullRequests(model, e)
+	if err != nil {
+		// lets only fail if the issue lookup errors have been opted into failing
+		err = HandleError(g.OnIssueLookupError, errors.Wrapf(err, "failed to enrich commit %s with issues", c.SHA))
+		if err != nil {
+			return err
+		}
+	}
+	if e.lookedUpPR {
+		g.addCommitPullRequest(model, c, e.prs)
+	}
+	model.Commits = append(model.Commits, c)
+	g.addTrailers(c.SHA, c.Trailers)
+	return nil
+}
+
+func (g *Generator) addIssuesAndPullRequests(model *Changelog, e *enrichedCommit) error {
+	issueKind := issues.GetIssueProvider(g.State.Tracker)
+	if !g.State.LoggedIssueKind {
+		g.State.LoggedIssueKind = true
+		log.Logger().Infof("Finding issues in commit messages using %s format", issueKind)
+	}
+	if e.offline {
+		return nil
+	}
+	found, err := g.State.Refs.Resolve(e.refs)
+	for i := range found {
+		e.commit.IssueIDs = append(e.commit.IssueIDs, found[i].ID)
+		model.addIssue(&found[i])
+	}
+	return err
+}
+
+// addIssue adds the resolved issue or pull request to the changelog
+func (c *Changelog) addIssue(issue *refs.IssueSummary) {
+	i := &Issue{
+		ID:          issue.ID,
+		URL:         issue.URL,
+		Title:       issue.Title,
+		Body:        issue.Body,
+		State:       issue.State,
+		User:        issue.User,
+		Created:     issue.Created,
+		ClosedBy:    issue.ClosedBy,
+		Assignees:   issue.Assignees,
+		Labels:      issue.Labels,
+		Milestone:   issue.Milestone,
+		PullRequest: issue.PullRequest,
+	}
+	if issue.PullRequest {
+		c.PullRequests = append(c.PullRequests, i)
+	} else {
+		c.Issues = append(c.Issues, i)
+	}
+}
+
+// addCommitPullRequest attaches the pull request which merged the commit out of the pull requests found by
+// enrichCommit. The pull request is added to the changelog unless it is already referenced
+func (g *Generator) addCommitPullRequest(model *Changelog, commit *Commit, prs []issues.CommitPullRequest) {
+	if len(prs) == 0 {
+		return
+	}
+	// lets prefer the pull request which merged the commit over others containing it
+	pr := prs[0]
+	for _, p := range prs {
+		if p.Merged {
+			pr = p
+			break
+		}
+	}
+	commit.PullRequest = &CommitPullRequest{
+		ID:     pr.Key,
+		Number: pr.Number,
+		Title:  pr.Title,
+		URL:    pr.URL,
+		Labels: pr.Labels,
+	}
+	if stringhelpers.StringArrayIndex(commit.IssueIDs, pr.Key) < 0 {
+		commit.IssueIDs = append(commit.IssueIDs, pr.Key)
+	}
+	if g.State.Refs.MarkResolved(pr.Key) {
+		model.PullRequests = append(model.PullRequests, &Issue{
+			ID:          pr.Key,
+			URL:         pr.URL,
+			Title:       pr.Title,
+			State:       pr.State,
+			Labels:      pr.Labels,
+			PullRequest: true,
+		})
+	}
+}
+
+// scanRefs returns the references to the issues and pull requests of the issue tracker in the commit message
+func (g *Generator) scanRefs(rawCommit *object.Commit) []refs.Ref {
+	text := fullCommitMessageText(rawCommit)
+	var scanned []refs.Ref
+	if issues.SeparatePullRequests(g.State.Tracker) {
+		scanned, text = refs.ScanPullRequests(text)
+	}
+	return append(scanned, refs.ScanKind(issues.GetIssueProvider(g.State.Tracker), text)...)
+}
+
+// toV1Labels converts git labels to IssueLabel
+func toV1Labels(labels []string) []v1.IssueLabel {
+	var answer []v1.IssueLabel
+	for _, label := range labels {
+		answer = append(answer, v1.IssueLabel{
+			Name: label,
+		})
+	}
+	return answer
+}
+
+// fullCommitMessageText returns the commit message
+func fullCommitMessageText(commit *object.Commit) string {
+	answer := commit.Message
+	fn := func(parent *object.Commit) error {
+		text := parent.Message
+		if text != "" {
+			sep := "\n"
+			if strings.HasSuffix(answer, "\n") {
+				sep = ""
+			}
+			answer += sep + text
+		}
+		return nil
+	}
+	err := fn(commit) //nolint:errcheck
+	if err != nil {
+		log.Logger().Warnf("failed to create commit message %s", err.Error())
+	}
+	return answer
+
+}
@@ -0,0 +1,285 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectEnrichWorkers(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	commits, issues := syntheticRelease(40, 10)
+
+	collect := func(workers int) *changelog.Changelog {
+		tracker := changelogtest.NewIssueTracker(issues...)
+		g := newCollectGenerator(t, dir, commits, tracker)
+		g.EnrichWorkers = workers
+
+		result, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"})
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Len(t, tracker.Lookups, 10, "each issue should be looked up once")
+		return result.Changelog
+	}
+	expected := collect(1)
+	require.Len(t, expected.Commits, 40)
+	require.Len(t, expected.Issues, 10)
+	assert.Equal(t, []string{"1"}, expected.Commits[0].IssueIDs)
+	assert.Empty(t, expected.Commits[10].IssueIDs)
+
+	actual := collect(8)
+	require.Len(t, actual.Commits, 40)
+	for i := range expected.Commits {
+		assert.Equal(t, expected.Commits[i].SHA, actual.Commits[i].SHA)
+		assert.Equal(t, expected.Commits[i].IssueIDs, actual.Commits[i].IssueIDs)
+	}
+	for i := range expected.Issues {
+		assert.Equal(t, expected.Issues[i].ID, actual.Issues[i].ID)
+	}
+}
+
+// batchIssueTracker records the keys of the issues prefetched in batches
+type batchIssueTracker struct {
+	*changelogtest.IssueTracker
+	prefetched []string
+}
+
+func (t *batchIssueTracker) PrefetchIssues(keys []string) error {
+	t.prefetched = append(t.prefetched, keys...)
+	return nil
+}
+
+// countingCommitFetcher counts the walks of the commits of the range
+type countingCommitFetcher struct {
+	*changelogtest.CommitFetcher
+	walks int
+}
+
+func (f *countingCommitFetcher) FetchCommits(gitDir, fromRev, toRev string) (gits.CommitIterator, error) {
+	f.walks++
+	return f.CommitFetcher.FetchCommits(gitDir, fromRev, toRev)
+}
+
+func TestCollectPrefetchIssuesWalksOnce(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	commits, issues := syntheticRelease(6, 3)
+	for _, workers := range []int{1, 4} {
+		tracker := &batchIssueTracker{IssueTracker: changelogtest.NewIssueTracker(issues...)}
+		g := newCollectGenerator(t, dir, commits, tracker.IssueTracker)
+		g.IssueTracker = tracker
+		g.EnrichWorkers = workers
+		fetcher := &countingCommitFetcher{CommitFetcher: changelogtest.NewCommitFetcher(commits...)}
+		g.State.CommitFetcher = fetcher
+
+		result, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"})
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, []string{"1", "2", "3"}, tracker.prefetched, "the issues should be prefetched in one batch")
+		assert.Equal(t, 1, fetcher.walks, "the commits of the range should only be walked once")
+		assert.Len(t, result.Changelog.Commits, 6)
+	}
+}
+
+func TestCollectMinCommits(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	rng := &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "v1.1.0"}
+	commits := []*changelogtest.CommitBuilder{
+		changelogtest.NewCommit("chore(release): 1.1.0"),
+		changelogtest.NewCommit("fix: something"),
+	}
+	collect := func(commits []*changelogtest.CommitBuilder, fn func(g *changelog.Generator)) (*changelog.Result, error) {
+		g := newCollectGenerator(t, dir, commits, changelogtest.NewIssueTracker())
+		g.State.SkipCommitRegex = regexp.MustCompile(changelog.DefaultSkipCommitPattern)
+		fn(g)
+		return g.Collect(context.Background(), rng)
+	}
+
+	result, err := collect(nil, func(g *changelog.Generator) {})
+	require.NoError(t, err, "no commits should not fail by default")
+	assert.Empty(t, result.Release.Spec.Commits)
+
+	_, err = collect(commits[:1], func(g *changelog.Generator) {
+		g.FailIfFindCommits = true
+	})
+	require.Error(t, err, "only a release commit should count as no commits")
+	assert.True(t, errors.Is(err, changelog.ErrNoCommits))
+	assert.Equal(t, changelog.ExitCodeNoCommits, changelog.ExitCode(err))
+	assert.Contains(t, err.Error(), "no commits found between revision v1.0.0 and v1.1.0")
+
+	_, err = collect(commits, func(g *changelog.Generator) {
+		g.MinCommits = 2
+	})
+	require.Error(t, err)
+	assert.Equal(t, changelog.ExitCodeNoCommits, changelog.ExitCode(err))
+	assert.Contains(t, err.Error(), "found 1 commits between revision v1.0.0 and v1.1.0 but at least 2 are required")
+
+	result, err = collect(commits, func(g *changelog.Generator) {
+		g.FailIfFindCommits = true
+		g.MinCommits = 1
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Release.Spec.Commits, 1)
+}
+
+func TestCollectSkipReleaseCommits(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	commits := []*changelogtest.CommitBuilder{
+		changelogtest.NewCommit("release 1.1.0"),
+		changelogtest.NewCommit("chore(release): 1.1.0"),
+		changelogtest.NewCommit("fix: release the lock"),
+		changelogtest.NewCommit("chore: bump version to 1.1.0 [skip ci]"),
+	}
+	collect := func(pattern string) []string {
+		g := newCollectGenerator(t, dir, commits, changelogtest.NewIssueTracker())
+		g.SkipCommitPattern = pattern
+		require.NoError(t, g.Validate())
+		g.State.CommitFetcher = changelogtest.NewCommitFetcher(commits...)
+
+		result, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "v1.1.0"})
+		require.NoError(t, err)
+		var messages []string
+		for _, c := range result.Changelog.Commits {
+			messages = append(messages, c.Message)
+		}
+		return messages
+	}
+	assert.Equal(t, []string{"fix: release the lock", "chore: bump version to 1.1.0 [skip ci]"}, collect(changelog.DefaultSkipCommitPattern))
+	assert.Equal(t, []string{"release 1.1.0", "chore(release): 1.1.0", "fix: release the lock"}, collect(`\[skip ci\]`))
+	assert.Len(t, collect(""), 4, "an empty pattern should include all commits")
+
+	g := &changelog.Generator{SkipCommitPattern: "("}
+	err := g.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "skip-commit-pattern")
+}
+
+func TestCollectMergeCommitPolicy(t *testing.T) {
+	t.Parallel()
+	dir := initRepo(t)
+	parents := []string{"1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222"}
+	commits := []*changelogtest.CommitBuilder{
+		changelogtest.NewCommit("Merge pull request #12 from jstrachan/fix").WithParents(parents...),
+		changelogtest.NewCommit("Merge branch 'main' into feature").WithParents(parents...),
+		changelogtest.NewCommit("Merge branch 'fix' into 'main'\n\nSee merge request myorg/myrepo!34").WithParents(parents...),
+		changelogtest.NewCommit("fix: something").WithParents(parents[0]),
+	}
+	collect := func(g *changelog.Generator) int {
+		require.NoError(t, g.Validate())
+		g.State.CommitFetcher = changelogtest.NewCommitFetcher(commits...)
+		result, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "v1.1.0"})
+		require.NoError(t, err)
+		return len(result.Changelog.Commits)
+	}
+	policy := func(policy string) int {
+		g := newCollectGenerator(t, dir, commits, changelogtest.NewIssueTracker())
+		g.MergeCommitPolicy = policy
+		return collect(g)
+	}
+	assert.Equal(t, 1, policy(""), "merge commits should be excluded by default")
+	assert.Equal(t, 1, policy(changelog.MergeCommitsExclude))
+	assert.Equal(t, 4, policy(changelog.MergeCommitsInclude))
+	assert.Equal(t, 3, policy(changelog.MergeCommitsOnlyPRs), "only the merges of pull requests and merge requests should be included")
+
+	g := newCollectGenerator(t, dir, commits, changelogtest.NewIssueTracker())
+	g.MergeCommitPolicy = ""
+	g.IncludeMergeCommits = true
+	assert.Equal(t, 4, collect(g), "--include-merge-commits should default the policy to include")
+}
+
+// benchmarkSizes the number of commits of the synthetic releases benchmarked
+var benchmarkSizes = []int{1000, 10000, 100000}
+
+func BenchmarkCollect(b *testing.B) {
+	dir := initRepo(b)
+	for _, size := range benchmarkSizes {
+		commits, issues := syntheticRelease(size, size/10)
+		b.Run(fmt.Sprintf("commits-%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				g := newCollectGenerator(b, dir, commits, changelogtest.NewIssueTracker(issues...))
+				b.StartTimer()
+				_, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"})
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	dir := initRepo(b)
+	for _, size := range benchmarkSizes {
+		commits, issues := syntheticRelease(size, size/10)
+		g := newCollectGenerator(b, dir, commits, changelogtest.NewIssueTracker(issues...))
+		result, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"})
+		require.NoError(b, err)
+		require.NoError(b, g.Curate(result))
+		b.Run(fmt.Sprintf("commits-%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				require.NoError(b, g.Render(context.Background(), result))
+			}
+		})
+	}
+}
+
+// initRepo creates an empty git repository in a temporary directory
+func initRepo(tb testing.TB) string {
+	dir, err := ioutil.TempDir("", "jx-changelog-")
+	require.NoError(tb, err)
+	tb.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	out, err := exec.Command("git", "init", "-q", dir).CombinedOutput()
+	require.NoError(tb, err, "git init: %s", out)
+	return dir
+}
+
+// syntheticRelease returns the commits of a release referencing the issues round robin so that each issue is
+// referenced by several commits
+func syntheticRelease(commitCount, issueCount int) ([]*changelogtest.CommitBuilder, []*changelogtest.IssueBuilder) {
+	var commits []*changelogtest.CommitBuilder
+	var issues []*changelogtest.IssueBuilder
+	for i := 0; i < commitCount; i++ {
+		id := fmt.Sprintf("%d", i%issueCount+1)
+		commits = append(commits, changelogtest.NewCommit(fmt.Sprintf("fix: change %d (#%s)", i, id)).WithAuthor("Jane Doe", "jane@foo.com"))
+		if i < issueCount {
+			issues = append(issues, changelogtest.NewIssue(id, "issue "+id))
+		}
+	}
+	return commits, issues
+}
+
+// newCollectGenerator creates a validated generator which collects the commits from the repository in the directory
+func newCollectGenerator(tb testing.TB, dir string, commits []*changelogtest.CommitBuilder, tracker *changelogtest.IssueTracker) *changelog.Generator {
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(tb, err)
+	g := &changelog.Generator{
+		TemplatesDir: dir,
+		IssueTracker: tracker,
+		Clock:        changelogtest.NewClock(changelogtest.DefaultTime),
+	}
+	g.ScmFactory.Dir = dir
+	g.ScmFactory.GitURL = gitInfo
+	require.NoError(tb, g.Validate())
+	g.State.CommitFetcher = changelogtest.NewCommitFetcher(commits...)
+	return g
+}
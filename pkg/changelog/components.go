@@ -0,0 +1,328 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/pkg/errors"
+)
+
+// Component a component of the changelog whose changes are collected and rendered separately then merged into one
+// section per component. Either the paths of a monorepo component or the git clone of an aggregated repository
+type Component struct {
+	// Name the name of the section of the component
+	Name string
+
+	// Paths the paths of the monorepo component whose commits are included
+	Paths []string
+
+	// Dir the directory of the git clone of an aggregated repository. Empty for the components of the repository
+	Dir string
+}
+
+// ParseComponent parses a monorepo component such as 'api=services/api,libs/client'. The name defaults to the last
+// element of the first path
+func ParseComponent(text string) (Component, error) {
+	name, value := splitComponent(text)
+	var paths []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return Component{}, options.InvalidOptionf("component", text, "should be the paths of the component such as 'api=services/api'")
+	}
+	if name == "" {
+		name = filepath.Base(paths[0])
+	}
+	return Component{Name: name, Paths: paths}, nil
+}
+
+// ParseComponentRepository parses an aggregated repository such as 'web=../web'. The name defaults to the last
+// element of the directory
+func ParseComponentRepository(text string) (Component, error) {
+	name, dir := splitComponent(text)
+	if dir == "" {
+		return Component{}, options.InvalidOptionf("component-repository", text, "should be the directory of a git clone such as 'web=../web'")
+	}
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	return Component{Name: name, Dir: dir}, nil
+}
+
+// validatePathsBackend checks the git backend can read the commits of paths which are only read via 'git log -- <paths>'
+func validatePathsBackend(backend, option string) error {
+	if backend != "" && backend != gits.GitBackendCLI {
+		return options.InvalidOptionf("git-backend", backend, "should be %s as the commits of the --%s paths are read via 'git log -- <paths>'", gits.GitBackendCLI, option)
+	}
+	return nil
+}
+
+func splitComponent(text string) (string, string) {
+	idx := strings.Index(text, "=")
+	if idx < 0 {
+		return "", strings.TrimSpace(text)
+	}
+	return strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx+1:])
+}
+
+// validateComponents parses the components and checks the options which cannot be merged across them
+func (g *Generator) validateComponents() error {
+	g.State.Components = nil
+	for _, text := range g.Components {
+		c, err := ParseComponent(text)
+		if err != nil {
+			return err
+		}
+		g.State.Components = append(g.State.Components, c)
+	}
+	if len(g.State.Components) > 0 {
+		err := validatePathsBackend(g.GitBackend, "component")
+		if err != nil {
+			return err
+		}
+	}
+	for _, text := range g.ComponentRepositories {
+		c, err := ParseComponentRepository(text)
+		if err != nil {
+			return err
+		}
+		g.State.Components = append(g.State.Components, c)
+	}
+	if len(g.State.Components) == 0 {
+		return nil
+	}
+	if g.Format != "" && g.Format != RendererMarkdown {
+		return options.InvalidOptionf("format", g.Format, "should be %s when generating the changelog of components", RendererMarkdown)
+	}
+	if g.ChangesetDir != "" {
+		return options.InvalidOptionf("changesets", g.ChangesetDir, "is not supported when generating the changelog of components")
+	}
+	if len(g.TranslateLanguages) > 0 {
+		return options.InvalidOptionf("translate", strings.Join(g.TranslateLanguages, ","), "is not supported when generating the changelog of components")
+	}
+	if len(g.ReleaseAssets) > 0 {
+		return options.InvalidOptionf("release-asset", strings.Join(g.ReleaseAssets, ","), "is not supported when generating the changelog of components")
+	}
+	return nil
+}
+
+// generateComponents collects and renders each component on a pool of ComponentWorkers sharing the rate limit of the
+// git provider then publishes the changelog merging the components in the order they were specified
+func (g *Generator) generateComponents(ctx context.Context) (*Result, error) {
+	g.State.Context = ctx
+	components := g.State.Components
+
+	// lets wrap the shared git provider client once before the components use it concurrently
+	NewRateLimiter(g.ComponentMinRateLimit).Limit(g.ScmFactory.ScmClient)
+	if g.State.Budget == nil {
+		g.State.Budget = NewAPIBudget(g.MaxAPICalls, g.MinRateLimitRemaining)
+		g.State.Budget.Guard(g.ScmFactory.ScmClient)
+	}
+	generators := make([]*Generator, len(components))
+	for i := range components {
+		var err error
+		generators[i], err = g.component(&components[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*Result, len(components))
+	err := RunConcurrently(g.ComponentWorkers, len(components), func(i int) error {
+		cg := generators[i]
+		rng, err := cg.ResolveRange(ctx)
+		if err != nil || rng == nil {
+			return errors.Wrapf(err, "failed to resolve the revisions of component %s", components[i].Name)
+		}
+		results[i], err = cg.Collect(ctx, rng)
+		return errors.Wrapf(err, "failed to collect the changes of component %s", components[i].Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// lets curate the components one at a time as they share the input
+	for i, result := range results {
+		if result != nil {
+			err = generators[i].Curate(result)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = RunConcurrently(g.ComponentWorkers, len(components), func(i int) error {
+		if results[i] == nil {
+			return nil
+		}
+		return errors.Wrapf(generators[i].Render(ctx, results[i]), "failed to render the changelog of component %s", components[i].Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := g.mergeComponents(components, results)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	err = g.Publish(ctx, result)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// component creates the generator of the component which only collects and renders its changes so that the merged
+// changelog is published once
+func (g *Generator) component(c *Component) (*Generator, error) {
+	cg := *g
+	cg.Components = nil
+	cg.ComponentRepositories = nil
+	cg.State.Components = nil
+	cg.Paths = c.Paths
+	cg.FailIfFindCommits = false
+	cg.MinCommits = 0
+	cg.CheckpointFile = ""
+	cg.GenerateReleaseYaml = false
+	cg.GenerateCRD = false
+	cg.Header, cg.HeaderFile = "", ""
+	cg.Footer, cg.FooterFile = "", ""
+	cg.State.Checksums = nil
+
+	var err error
+	cg.State.CommitFetcher, err = gits.NewCommitFetcher(g.GitBackend)
+	if err != nil {
+		return nil, options.InvalidOptionf("git-backend", g.GitBackend, "%s", err.Error())
+	}
+	if len(c.Paths) > 0 {
+		cg.State.CommitFetcher = &gits.CLICommitFetcher{Paths: c.Paths}
+	}
+	if c.Dir != "" {
+		// lets use the client of the git provider of the changelog so that the repositories share its rate limit
+		f := g.ScmFactory
+		cg.ScmFactory = scmhelpers.Options{
+			Dir:             c.Dir,
+			DiscoverFromGit: true,
+			ScmClient:       f.ScmClient,
+			GitServerURL:    f.GitServerURL,
+			GitKind:         f.GitKind,
+			GitToken:        f.GitToken,
+			Namespace:       f.Namespace,
+			JXClient:        f.JXClient,
+			GitClient:       f.GitClient,
+			CommandRunner:   f.CommandRunner,
+		}
+		err = cg.ScmFactory.Validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to discover the git repository of component %s in %s", c.Name, c.Dir)
+		}
+	}
+	return &cg, nil
+}
+
+// mergeComponents merges the results of the components into the result of the changelog in the order of the
+// components. Commits, issues and pull requests shared by several components are only included once in the Release
+func (g *Generator) mergeComponents(components []Component, results []*Result) (*Result, error) {
+	var answer *Result
+	model := &Changelog{}
+	commits := map[string]bool{}
+	found := map[string]bool{}
+	var sections []string
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		if answer == nil {
+			release := result.Release.DeepCopy()
+			answer = &Result{
+				Range:   result.Range,
+				Release: release,
+			}
+			if gitInfo := g.ScmFactory.GitURL; gitInfo != nil {
+				release.Spec.GitOwner = gitInfo.Organisation
+				release.Spec.GitRepository = gitInfo.Name
+				release.Spec.GitHTTPURL = gitInfo.HttpsURL()
+				release.Spec.GitCloneURL = gitInfo.CloneURL
+			}
+			release.Spec.DependencyUpdates = nil
+		}
+		for _, c := range result.Changelog.Commits {
+			if !commits[c.SHA] {
+				commits[c.SHA] = true
+				model.Commits = append(model.Commits, c)
+			}
+		}
+		model.Issues = appendIssues(model.Issues, result.Changelog.Issues, found)
+		model.PullRequests = appendIssues(model.PullRequests, result.Changelog.PullRequests, found)
+		answer.Release.Spec.DependencyUpdates = append(answer.Release.Spec.DependencyUpdates, result.Release.Spec.DependencyUpdates...)
+		sections = append(sections, fmt.Sprintf("## %s\n\n%s", components[i].Name, strings.TrimSpace(result.Markdown)))
+	}
+	if answer == nil {
+		return nil, nil
+	}
+	spec := &answer.Release.Spec
+	model.ProjectInto(spec)
+	spec.DependencyUpdates = CollapseDependencyUpdates(spec.DependencyUpdates)
+	answer.Changelog = model
+
+	// lets render the header and footer once around the sections of the components
+	templateData := &TemplateData{
+		ReleaseSpec:  spec,
+		Contributors: gits.Contributors(spec),
+		Trailers:     g.State.Trailers,
+		Groups:       model.Groups(),
+	}
+	header, err := g.getTemplateResult(templateData, "header", g.Header, g.HeaderFile)
+	if err != nil {
+		return nil, err
+	}
+	footer, err := g.getTemplateResult(templateData, "footer", g.Footer, g.FooterFile)
+	if err != nil {
+		return nil, err
+	}
+	if checksums := ChecksumsMarkdown(g.State.Checksums); checksums != "" {
+		footer = "\n" + checksums + footer
+	}
+	answer.TemplateData = templateData
+	answer.Markdown = header + strings.Join(sections, "\n\n") + "\n" + footer
+	answer.Output = answer.Markdown
+
+	commitCount := len(answer.Release.Spec.Commits)
+	rng := answer.Range
+	switch {
+	case g.FailIfFindCommits && commitCount == 0:
+		return nil, newError(ErrNoCommits, "no commits found in the components between revision %s and %s", rng.PreviousRev, rng.CurrentRev)
+	case g.MinCommits > 0 && commitCount < g.MinCommits:
+		return nil, newError(ErrNoCommits, "found %d commits in the components between revision %s and %s but at least %d are required", commitCount, rng.PreviousRev, rng.CurrentRev, g.MinCommits)
+	}
+
+	answer.TemplatesDir, err = g.templatesDir()
+	if err != nil {
+		return nil, err
+	}
+	g.State.Release = answer.Release
+	return answer, nil
+}
+
+func appendIssues(answer, issues []*Issue, found map[string]bool) []*Issue {
+	for _, issue := range issues {
+		key := issue.URL
+		if key == "" {
+			key = issue.ID
+		}
+		if !found[key] {
+			found[key] = true
+			answer = append(answer, issue)
+		}
+	}
+	return answer
+}
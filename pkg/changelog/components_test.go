@@ -0,0 +1,125 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseComponent(t *testing.T) {
+	t.Parallel()
+	c, err := changelog.ParseComponent("api=services/api, libs/client")
+	require.NoError(t, err)
+	assert.Equal(t, changelog.Component{Name: "api", Paths: []string{"services/api", "libs/client"}}, c)
+
+	c, err = changelog.ParseComponent("services/web")
+	require.NoError(t, err)
+	assert.Equal(t, changelog.Component{Name: "web", Paths: []string{"services/web"}}, c)
+
+	_, err = changelog.ParseComponent("api=")
+	assert.Error(t, err)
+
+	c, err = changelog.ParseComponentRepository("../frontend")
+	require.NoError(t, err)
+	assert.Equal(t, changelog.Component{Name: "frontend", Dir: "../frontend"}, c)
+}
+
+func TestGenerateComponents(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	commit := func(path, message string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, path), []byte(message), 0600))
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	commit("README.md", "initial import")
+	git("tag", "v1.0.0")
+	commit("services/api/main.go", "feat: api endpoint")
+	commit("services/web/index.html", "feat: web page")
+	commit("services/api/handler.go", "fix: api handler")
+	commit("libs/shared.go", "fix: shared library")
+
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+	generate := func(workers int) *changelog.Result {
+		g := &changelog.Generator{
+			PreviousRevision:   "v1.0.0",
+			CurrentRevision:    "HEAD",
+			TemplatesDir:       dir,
+			OutputMarkdownFile: filepath.Join(t.TempDir(), "CHANGELOG.md"),
+			Header:             "# Release {{ .Version }}\n\n",
+			Version:            "1.1.0",
+			Components:         []string{"web=services/web,libs", "api=services/api,libs"},
+			ComponentWorkers:   workers,
+			IssueTracker:       changelogtest.NewIssueTracker(),
+			Clock:              changelogtest.NewClock(changelogtest.DefaultTime),
+		}
+		g.ScmFactory.Dir = dir
+		g.ScmFactory.GitURL = gitInfo
+		require.NoError(t, g.Validate())
+
+		result, err := g.Generate(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		return result
+	}
+	expected := generate(1)
+	markdown := expected.Markdown
+	assert.True(t, strings.HasPrefix(markdown, "# Release 1.1.0\n\n## web\n\n"), "should start with the header and the first component:\n%s", markdown)
+	web := strings.Index(markdown, "## web")
+	api := strings.Index(markdown, "## api")
+	require.True(t, web >= 0 && api > web, "the components should be in the order of the flags:\n%s", markdown)
+	assert.Contains(t, markdown[web:api], "web page")
+	assert.NotContains(t, markdown[web:api], "api endpoint")
+	assert.Contains(t, markdown[api:], "api handler")
+	assert.Contains(t, markdown[api:], "shared library")
+	assert.Equal(t, "myorg", expected.Release.Spec.GitOwner)
+	assert.Len(t, expected.Release.Spec.Commits, 4, "the commit of the paths shared by the components should be included once")
+
+	actual := generate(2)
+	assert.Equal(t, markdown, actual.Markdown)
+	assert.Equal(t, expected.Release.Spec.Commits, actual.Release.Spec.Commits)
+}
+
+func TestPathsRequireCLIBackend(t *testing.T) {
+	t.Parallel()
+	for _, backend := range []string{"", gits.GitBackendCLI} {
+		g := &changelog.Generator{Paths: []string{"services/api"}, GitBackend: backend}
+		require.NoError(t, g.Validate(), "the %q backend should support paths", backend)
+		assert.Equal(t, &gits.CLICommitFetcher{Paths: []string{"services/api"}}, g.State.CommitFetcher)
+	}
+
+	g := &changelog.Generator{Paths: []string{"services/api"}, GitBackend: gits.GitBackendGoGit}
+	err := g.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git-backend")
+
+	g = &changelog.Generator{Components: []string{"api=services/api"}, GitBackend: gits.GitBackendGoGit}
+	assert.Error(t, g.Validate(), "the paths of the components should require the cli backend")
+
+	g = &changelog.Generator{ComponentRepositories: []string{"web=../web"}, GitBackend: gits.GitBackendGoGit}
+	assert.NoError(t, g.Validate(), "aggregated repositories should support any backend")
+}
@@ -0,0 +1,121 @@
+package changelog
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// contributorKey returns the key used to identify a contributor across commits
+func contributorKey(user *v1.UserDetails) string {
+	if user == nil {
+		return ""
+	}
+	if user.Login != "" {
+		return strings.ToLower(user.Login)
+	}
+	return strings.ToLower(user.Name)
+}
+
+// findNewContributors finds the authors of commits in this release who have no commits before the previous revision
+func (g *Generator) findNewContributors(spec *v1.ReleaseSpec, commits []*Commit, previousRev string) []gits.NewContributor {
+	summaries := map[string]*v1.CommitSummary{}
+	for i := range spec.Commits {
+		summaries[spec.Commits[i].SHA] = &spec.Commits[i]
+	}
+	pullRequests := map[string]*v1.IssueSummary{}
+	for i := range spec.PullRequests {
+		pullRequests[spec.PullRequests[i].ID] = &spec.PullRequests[i]
+	}
+
+	var keys []string
+	emails := map[string][]string{}
+	firstCommits := map[string]*v1.CommitSummary{}
+
+	// commits are in reverse chronological order so lets walk from the oldest
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		cs := summaries[commit.SHA]
+		if cs == nil || commit.AuthorEmail == "" {
+			continue
+		}
+		key := contributorKey(cs.Author)
+		if key == "" || users.IsServiceAccount(cs.Author) {
+			continue
+		}
+		if firstCommits[key] == nil {
+			keys = append(keys, key)
+			firstCommits[key] = cs
+		}
+		if !stringSliceContains(emails[key], commit.AuthorEmail) {
+			emails[key] = append(emails[key], commit.AuthorEmail)
+		}
+	}
+
+	var answer []gits.NewContributor
+	for _, key := range keys {
+		existing := false
+		for _, email := range emails[key] {
+			if previousRev == "" {
+				// this is the initial release so every contributor is new
+				break
+			}
+			found, err := gits.HasCommitsByAuthor(g.Git(), g.ScmFactory.Dir, previousRev, email)
+			if err != nil {
+				log.Logger().Warnf("failed to check for previous commits by %s: %s", email, err.Error())
+				existing = true
+				break
+			}
+			if found {
+				existing = true
+				break
+			}
+		}
+		if existing {
+			continue
+		}
+		cs := firstCommits[key]
+		nc := gits.NewContributor{
+			User:        cs.Author,
+			FirstCommit: cs,
+		}
+		for _, id := range cs.IssueIDs {
+			if pr := pullRequests[id]; pr != nil {
+				nc.FirstPullRequest = pr
+				break
+			}
+		}
+		answer = append(answer, nc)
+	}
+	return answer
+}
+
+func stringSliceContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// addContributorsAnnotation records the contributors on the Release as the ReleaseSpec has no field for them
+func addContributorsAnnotation(release *v1.Release, contributors []gits.Contributor) error {
+	if len(contributors) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(contributors)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal contributors")
+	}
+	if release.Annotations == nil {
+		release.Annotations = map[string]string{}
+	}
+	release.Annotations[ContributorsAnnotation] = string(data)
+	return nil
+}
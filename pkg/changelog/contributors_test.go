@@ -0,0 +1,72 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContributors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	git("init", "-q")
+	git("-c", "user.name=Alice", "-c", "user.email=alice@foo.com", "commit", "-q", "--allow-empty", "-m", "initial import")
+	git("tag", "v1.0.0")
+
+	alice := &v1.UserDetails{Login: "alice", Name: "Alice", Email: "alice@foo.com"}
+	bob := &v1.UserDetails{Login: "bob", Name: "Bob", Email: "bob@foo.com"}
+	bot := &v1.UserDetails{Login: "renovate[bot]", Name: "renovate[bot]", Email: "bot@renovateapp.com", ServiceAccount: users.IdentityKindBot}
+	pr := v1.IssueSummary{ID: "7", Title: "fix something"}
+
+	// the commits are in reverse chronological order
+	commits := []struct {
+		sha    string
+		author *v1.UserDetails
+		email  string
+		issues []string
+	}{
+		{"ccc", bob, "bob@users.noreply.github.com", nil},
+		{"bbb", bot, "bot@renovateapp.com", nil},
+		{"aaa", alice, "alice@foo.com", nil},
+		{"000", bob, "bob@foo.com", []string{"7"}},
+	}
+	result := &changelog.Result{
+		Range:     &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"},
+		Changelog: &changelog.Changelog{},
+		Release: &v1.Release{
+			Spec: v1.ReleaseSpec{PullRequests: []v1.IssueSummary{pr}},
+		},
+	}
+	for _, c := range commits {
+		result.Changelog.Commits = append(result.Changelog.Commits, &changelog.Commit{SHA: c.sha, AuthorEmail: c.email})
+		result.Release.Spec.Commits = append(result.Release.Spec.Commits, v1.CommitSummary{SHA: c.sha, Author: c.author, IssueIDs: c.issues})
+	}
+
+	g := &changelog.Generator{NewContributors: true}
+	g.ScmFactory.Dir = dir
+	contributors := g.Export(result).NewContributors
+	require.Len(t, contributors, 1, "alice has commits before the previous release and bots are not contributors")
+	assert.Equal(t, "bob", contributors[0].User.Login)
+	assert.Equal(t, "000", contributors[0].FirstCommit.SHA, "the oldest commit should be the first commit")
+	require.NotNil(t, contributors[0].FirstPullRequest)
+	assert.Equal(t, "7", contributors[0].FirstPullRequest.ID)
+
+	result.Range.PreviousRev = ""
+	contributors = g.Export(result).NewContributors
+	require.Len(t, contributors, 2, "every contributor of the initial release is new")
+	assert.Equal(t, "bob", contributors[0].User.Login)
+	assert.Equal(t, "alice", contributors[1].User.Login)
+}
@@ -0,0 +1,138 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/pkg/errors"
+)
+
+// curateNoType the name picked for commits which should not have a Conventional Commits type
+const curateNoType = "none"
+
+// Curate lets the user pick the commits of the changelog along with their titles and Conventional Commits types
+// using the Input of the generator. Issues and pull requests which are only referenced by excluded commits are removed
+func (g *Generator) Curate(result *Result) error {
+	in := g.Input
+	model := result.Changelog
+	if in == nil || model == nil || len(model.Commits) == 0 {
+		return nil
+	}
+	var names []string
+	commits := map[string]*Commit{}
+	for _, c := range model.Commits {
+		name := curateName(c)
+		names = append(names, name)
+		commits[name] = c
+	}
+	selected, err := in.SelectNames(names, "Select the commits to include in the changelog:", true, "Deselect the commits which should not appear in the changelog")
+	if err != nil {
+		return errors.Wrap(err, "failed to select the commits")
+	}
+	included := map[*Commit]bool{}
+	for _, name := range selected {
+		if c := commits[name]; c != nil {
+			included[c] = true
+		}
+	}
+
+	edit, err := in.Confirm("Edit the titles and types of the included commits?", false, "Lets you recategorize the commits and polish their titles")
+	if err != nil {
+		return errors.Wrap(err, "failed to confirm editing the commits")
+	}
+	var kept []*Commit
+	for _, c := range model.Commits {
+		if !included[c] {
+			continue
+		}
+		if edit {
+			err = curateCommit(g, c)
+			if err != nil {
+				return err
+			}
+		}
+		kept = append(kept, c)
+	}
+	model.Commits = kept
+	model.Issues = referencedIssues(model.Issues, kept)
+	model.PullRequests = referencedIssues(model.PullRequests, kept)
+	model.ProjectInto(&result.Release.Spec)
+	return nil
+}
+
+// curateCommit picks the type and title of the commit and rewrites the first line of its message to match
+func curateCommit(g *Generator, c *Commit) error {
+	types := []string{curateNoType}
+	for kind := range gits.ConventionalCommitTitles {
+		if kind != "" {
+			types = append(types, kind)
+		}
+	}
+	sort.Strings(types[1:])
+	name := curateName(c)
+	kind := c.Type
+	if kind == "" {
+		kind = curateNoType
+	}
+	kind, err := g.Input.PickNameWithDefault(types, "Type of "+name+":", kind, "The Conventional Commits type decides the section of the changelog")
+	if err != nil {
+		return errors.Wrapf(err, "failed to pick the type of %s", c.SHA)
+	}
+	title, err := g.Input.PickValue("Title of "+name+":", c.Subject, true, "The title of the commit in the changelog")
+	if err != nil {
+		return errors.Wrapf(err, "failed to pick the title of %s", c.SHA)
+	}
+	if kind == curateNoType {
+		kind = ""
+	}
+	c.Type = kind
+	c.Subject = strings.TrimSpace(title)
+	rewriteCommitHeader(c)
+	return nil
+}
+
+// rewriteCommitHeader rewrites the first line of the message of the commit to match its type, scope and subject
+func rewriteCommitHeader(c *Commit) {
+	header := c.Subject
+	if c.Type != "" {
+		prefix := c.Type
+		if c.Scope != "" {
+			prefix += "(" + c.Scope + ")"
+		}
+		if c.Breaking && !breakingChangeRegex.MatchString(c.Message) {
+			prefix += "!"
+		}
+		header = prefix + ": " + c.Subject
+	}
+	lines := strings.SplitN(c.Message, "\n", 2)
+	lines[0] = header
+	c.Message = strings.Join(lines, "\n")
+}
+
+// curateName returns the name of the commit shown to the user
+func curateName(c *Commit) string {
+	sha := c.SHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return fmt.Sprintf("%s %s", sha, strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0]))
+}
+
+// referencedIssues returns the issues referenced by the commits
+func referencedIssues(issues []*Issue, commits []*Commit) []*Issue {
+	ids := map[string]bool{}
+	for _, c := range commits {
+		for _, id := range c.IssueIDs {
+			ids[id] = true
+		}
+	}
+	var answer []*Issue
+	for _, issue := range issues {
+		if ids[issue.ID] {
+			answer = append(answer, issue)
+		}
+	}
+	return answer
+}
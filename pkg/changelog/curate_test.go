@@ -0,0 +1,53 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurate(t *testing.T) {
+	t.Parallel()
+	feat := changelog.NewCommit("1111111aaaa", "feat(cli)!: something new\n\nFixes #12")
+	feat.IssueIDs = []string{"12"}
+	chore := changelog.NewCommit("2222222bbbb", "wip\n\nFixes #13")
+	chore.IssueIDs = []string{"13"}
+	result := &changelog.Result{
+		Changelog: &changelog.Changelog{
+			Commits: []*changelog.Commit{feat, chore},
+			Issues:  []*changelog.Issue{{ID: "12", Title: "kept"}, {ID: "13", Title: "removed"}},
+		},
+		Release: &v1.Release{},
+	}
+
+	g := &changelog.Generator{
+		Input: &fake.FakeInput{
+			Values: map[string]string{
+				"Select the commits to include in the changelog:":    "1111111 feat(cli)!: something new",
+				"Edit the titles and types of the included commits?": "y",
+				"Type of 1111111 feat(cli)!: something new:":         "fix",
+				"Title of 1111111 feat(cli)!: something new:":        "a polished title",
+			},
+		},
+	}
+	require.NoError(t, g.Curate(result))
+
+	spec := &result.Release.Spec
+	require.Len(t, spec.Commits, 1)
+	assert.Equal(t, "fix(cli)!: a polished title\n\nFixes #12", spec.Commits[0].Message)
+	require.Len(t, spec.Issues, 1)
+	assert.Equal(t, "kept", spec.Issues[0].Title)
+	assert.Equal(t, "fix", result.Changelog.Commits[0].Type)
+	assert.True(t, result.Changelog.Commits[0].Breaking)
+
+	// lets check curation is skipped without an input
+	result.Changelog.Commits = []*changelog.Commit{feat, chore}
+	require.NoError(t, (&changelog.Generator{}).Curate(result))
+	assert.Len(t, result.Changelog.Commits, 2)
+}
@@ -0,0 +1,185 @@
+package changelog
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// CollapseDependencyUpdates takes a raw set of dependencyUpdates, removes duplicates and collapses multiple updates to
+// the same org/repo:components into a sungle update
+func CollapseDependencyUpdates(dependencyUpdates []v1.DependencyUpdate) []v1.DependencyUpdate {
+	// Sort the dependency updates. This makes the outputs more readable, and it also allows us to more easily do duplicate removal and collapsing
+
+	sort.Slice(dependencyUpdates, func(i, j int) bool {
+		if dependencyUpdates[i].Owner == dependencyUpdates[j].Owner {
+			if dependencyUpdates[i].Repo == dependencyUpdates[j].Repo {
+				if dependencyUpdates[i].Component == dependencyUpdates[j].Component {
+					if dependencyUpdates[i].FromVersion == dependencyUpdates[j].FromVersion {
+						return dependencyUpdates[i].ToVersion < dependencyUpdates[j].ToVersion
+					}
+					return dependencyUpdates[i].FromVersion < dependencyUpdates[j].FromVersion
+				}
+				return dependencyUpdates[i].Component < dependencyUpdates[j].Component
+			}
+			return dependencyUpdates[i].Repo < dependencyUpdates[j].Repo
+		}
+		return dependencyUpdates[i].Owner < dependencyUpdates[j].Owner
+	})
+
+	// Collapse  entries
+	collapsed := make([]v1.DependencyUpdate, 0)
+
+	if len(dependencyUpdates) > 0 {
+		start := 0
+		for i := 1; i <= len(dependencyUpdates); i++ {
+			if i == len(dependencyUpdates) || dependencyUpdates[i-1].Owner != dependencyUpdates[i].Owner || dependencyUpdates[i-1].Repo != dependencyUpdates[i].Repo || dependencyUpdates[i-1].Component != dependencyUpdates[i].Component {
+				end := i - 1
+				var paths []v1.DependencyUpdatePath
+				for _, du := range dependencyUpdates[start:i] {
+					paths = append(paths, du.Paths...)
+				}
+				collapsed = append(collapsed, v1.DependencyUpdate{
+					Paths: paths,
+					DependencyUpdateDetails: v1.DependencyUpdateDetails{
+						Owner:              dependencyUpdates[start].Owner,
+						Repo:               dependencyUpdates[start].Repo,
+						Component:          dependencyUpdates[start].Component,
+						URL:                dependencyUpdates[start].URL,
+						Host:               dependencyUpdates[start].Host,
+						FromVersion:        dependencyUpdates[start].FromVersion,
+						FromReleaseHTMLURL: dependencyUpdates[start].FromReleaseHTMLURL,
+						FromReleaseName:    dependencyUpdates[start].FromReleaseName,
+						ToVersion:          dependencyUpdates[end].ToVersion,
+						ToReleaseName:      dependencyUpdates[end].ToReleaseName,
+						ToReleaseHTMLURL:   dependencyUpdates[end].ToReleaseHTMLURL,
+					},
+				})
+				start = i
+			}
+		}
+	}
+	return collapsed
+}
+
+// analyzeDependencies diffs the manifest files of the enabled analyzers between the revisions of the range
+// The version stream analyzer is enabled automatically for version stream and cluster repositories
+func (g *Generator) analyzeDependencies(rng *Range) ([]deps.Section, error) {
+	dir := g.ScmFactory.Dir
+	analyzers := g.State.Analyzers
+	if !hasVersionStreamAnalyzer(analyzers) && deps.IsVersionStream(dir) {
+		analyzers = append(analyzers, &deps.VersionStreamAnalyzer{})
+	}
+	if len(analyzers) == 0 {
+		return nil, nil
+	}
+	if rng.FirstRelease {
+		log.Logger().Debugf("not analyzing the dependencies of the initial release")
+		return nil, nil
+	}
+	if rng.FromIssueTracker() {
+		log.Logger().Debugf("not analyzing the dependencies of %s", rng.Description())
+		return nil, nil
+	}
+	var answer []deps.Section
+	for _, a := range analyzers {
+		section := deps.Section{Title: a.Title()}
+		paths, err := analyzerFiles(dir, a)
+		if err != nil {
+			return nil, err
+		}
+		found := map[deps.Update]bool{}
+		for _, path := range paths {
+			previous, _, err := gits.GetFileAtRevision(g.Git(), dir, rng.PreviousRev, path)
+			if err != nil {
+				return nil, err
+			}
+			current, _, err := gits.GetFileAtRevision(g.Git(), dir, rng.CurrentRev, path)
+			if err != nil {
+				return nil, err
+			}
+			if previous == current {
+				continue
+			}
+			updates, err := a.Diff(path, previous, current)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to diff the dependencies of %s", path)
+			}
+			for _, u := range updates {
+				// the same update may be found in several files such as a Chart.yaml and its Chart.lock
+				if !found[u] {
+					found[u] = true
+					section.Updates = append(section.Updates, u)
+				}
+			}
+		}
+		if len(section.Updates) > 0 {
+			answer = append(answer, section)
+		}
+	}
+	return answer, nil
+}
+
+func hasVersionStreamAnalyzer(analyzers []deps.Analyzer) bool {
+	for _, a := range analyzers {
+		if _, ok := a.(*deps.VersionStreamAnalyzer); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzerFiles returns the paths of the files of the analyzer relative to the directory expanding any glob patterns
+func analyzerFiles(dir string, a deps.Analyzer) ([]string, error) {
+	var answer []string
+	for _, pattern := range a.Files() {
+		if !strings.ContainsAny(pattern, "*?[") {
+			answer = append(answer, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid file pattern %s", pattern)
+		}
+		for _, m := range matches {
+			rel, err := filepath.Rel(dir, m)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to find the relative path of %s", m)
+			}
+			answer = append(answer, filepath.ToSlash(rel))
+		}
+	}
+	return answer, nil
+}
+
+// addDependencyKindsAnnotation records the kind of each dependency update of the sections on the Release as the
+// DependencyUpdate has no field for it
+func addDependencyKindsAnnotation(release *v1.Release, sections []deps.Section) error {
+	kinds := map[string]string{}
+	for _, section := range sections {
+		for i := range section.Updates {
+			u := &section.Updates[i]
+			du := deps.ToDependencyUpdate(u)
+			kinds[gits.DependencyKey(&du)] = u.Ecosystem
+		}
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(kinds)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the dependency kinds")
+	}
+	if release.Annotations == nil {
+		release.Annotations = map[string]string{}
+	}
+	release.Annotations[DependencyKindsAnnotation] = string(data)
+	return nil
+}
@@ -0,0 +1,104 @@
+package changelog
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// deploymentPages the maximum number of pages of the most recent deployments searched for those of the release
+const deploymentPages = 3
+
+// DeploymentsAnnotation the annotation on the Release containing the JSON encoded environments the release is
+// deployed to
+const DeploymentsAnnotation = "changelog.jenkins-x.io/deployments"
+
+// findDeployments finds the environments the current revision of the range was successfully deployed to via the
+// deployment statuses of the git provider. Only the latest deployment of each environment is returned
+func (g *Generator) findDeployments(rng *Range) []gits.Deployment {
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient == nil {
+		return nil
+	}
+	ctx := g.State.Context
+	fullName := scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository)
+	sha, err := g.Git().Command(g.ScmFactory.Dir, "rev-list", "-n", "1", rng.CurrentRev)
+	if err != nil {
+		log.Logger().Debugf("failed to find the SHA of revision %s: %s", rng.CurrentRev, err.Error())
+	}
+	sha = strings.TrimSpace(sha)
+	matches := func(d *scm.Deployment) bool {
+		return (sha != "" && d.Sha == sha) || d.Ref == rng.CurrentRev || (rng.CurrentName != "" && d.Ref == rng.CurrentName)
+	}
+
+	found := map[string]bool{}
+	var answer []gits.Deployment
+	for page := 1; page <= deploymentPages; page++ {
+		deployments, _, err := scmClient.Deployments.List(ctx, fullName, scm.ListOptions{Page: page, Size: 100})
+		if err != nil {
+			log.Logger().Warnf("failed to list the deployments of repository %s: %s", fullName, err.Error())
+			break
+		}
+		for _, d := range deployments {
+			if d == nil || !matches(d) {
+				continue
+			}
+			deployment := g.deploymentStatus(fullName, d)
+			if deployment != nil && !found[deployment.Environment] {
+				found[deployment.Environment] = true
+				answer = append(answer, *deployment)
+			}
+		}
+		if len(deployments) < 100 {
+			break
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Deployed.Before(answer[j].Deployed)
+	})
+	return answer
+}
+
+// deploymentStatus returns the deployment if its latest status is successful
+func (g *Generator) deploymentStatus(fullName string, d *scm.Deployment) *gits.Deployment {
+	statuses, _, err := g.ScmFactory.ScmClient.Deployments.ListStatus(g.State.Context, fullName, d.ID, scm.ListOptions{Page: 1, Size: 1})
+	if err != nil {
+		log.Logger().Warnf("failed to list the statuses of deployment %s of repository %s: %s", d.ID, fullName, err.Error())
+		return nil
+	}
+	if len(statuses) == 0 || statuses[0] == nil || !strings.EqualFold(statuses[0].State, "success") {
+		return nil
+	}
+	status := statuses[0]
+	environment := status.Environment
+	if environment == "" {
+		environment = d.Environment
+	}
+	return &gits.Deployment{
+		Environment: environment,
+		URL:         status.EnvironmentLink,
+		Deployed:    status.Created,
+	}
+}
+
+// addDeploymentsAnnotation records the environments the release is deployed to on the Release
+func addDeploymentsAnnotation(release *v1.Release, deployments []gits.Deployment) error {
+	if len(deployments) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(deployments)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal deployments")
+	}
+	if release.Annotations == nil {
+		release.Annotations = map[string]string{}
+	}
+	release.Annotations[DeploymentsAnnotation] = string(data)
+	return nil
+}
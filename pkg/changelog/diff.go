@@ -0,0 +1,179 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+const (
+	// ReleaseItemCommit a commit of a release
+	ReleaseItemCommit = "commit"
+
+	// ReleaseItemIssue an issue fixed by a release
+	ReleaseItemIssue = "issue"
+
+	// ReleaseItemPullRequest a pull request merged by a release
+	ReleaseItemPullRequest = "pull-request"
+)
+
+var (
+	markdownLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	commitURLRegex    = regexp.MustCompile(`/commits?/([0-9a-fA-F]{7,40})$`)
+	pullRequestRegex  = regexp.MustCompile(`/(?:pull|pulls|merge_requests|pull-requests)/(\d+)$`)
+	issueURLRegex     = regexp.MustCompile(`/issues/([^/]+)$`)
+)
+
+// ReleaseItem a commit, issue or pull request of a release
+type ReleaseItem struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// ReleaseDiff the commits, issues and pull requests added or removed between two releases
+type ReleaseDiff struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Added   []ReleaseItem `json:"added,omitempty"`
+	Removed []ReleaseItem `json:"removed,omitempty"`
+}
+
+// Empty returns true if the releases contain the same items
+func (d *ReleaseDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// ReleaseSpecItems returns the commits, issues and pull requests of the Release
+func ReleaseSpecItems(spec *v1.ReleaseSpec) []ReleaseItem {
+	var answer []ReleaseItem
+	for i := range spec.Commits {
+		c := &spec.Commits[i]
+		answer = append(answer, ReleaseItem{Kind: ReleaseItemCommit, ID: c.SHA, Title: strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0])})
+	}
+	for i := range spec.Issues {
+		answer = append(answer, ReleaseItem{Kind: ReleaseItemIssue, ID: spec.Issues[i].ID, Title: spec.Issues[i].Title})
+	}
+	for i := range spec.PullRequests {
+		answer = append(answer, ReleaseItem{Kind: ReleaseItemPullRequest, ID: spec.PullRequests[i].ID, Title: spec.PullRequests[i].Title})
+	}
+	return answer
+}
+
+// MarkdownReleaseItems returns the commits, issues and pull requests linked from the markdown release notes published on
+// the git provider. Only links into the repository URL are returned so that upstream dependency releases are ignored
+func MarkdownReleaseItems(markdown, repoURL string) []ReleaseItem {
+	prefix := strings.TrimSuffix(repoURL, "/") + "/"
+	var answer []ReleaseItem
+	for _, line := range strings.Split(markdown, "\n") {
+		links := markdownLinkRegex.FindAllStringSubmatch(line, -1)
+		if len(links) == 0 {
+			continue
+		}
+		title := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(markdownLinkRegex.ReplaceAllString(line, "$1")), "*-"))
+		for _, link := range links {
+			url := link[2]
+			if repoURL != "" && !strings.HasPrefix(url, prefix) {
+				continue
+			}
+			if m := commitURLRegex.FindStringSubmatch(url); m != nil {
+				answer = append(answer, ReleaseItem{Kind: ReleaseItemCommit, ID: m[1], Title: title})
+			} else if m := pullRequestRegex.FindStringSubmatch(url); m != nil {
+				answer = append(answer, ReleaseItem{Kind: ReleaseItemPullRequest, ID: m[1], Title: title})
+			} else if m := issueURLRegex.FindStringSubmatch(url); m != nil {
+				answer = append(answer, ReleaseItem{Kind: ReleaseItemIssue, ID: m[1], Title: title})
+			}
+		}
+	}
+	return answer
+}
+
+// DiffReleases returns the items of the 'to' release which are not in the 'from' release and vice versa. Commits are
+// matched on their abbreviated SHA so that release notes linking to abbreviated commits can be compared
+func DiffReleases(fromName string, from []ReleaseItem, toName string, to []ReleaseItem) *ReleaseDiff {
+	fromItems := indexReleaseItems(from)
+	toItems := indexReleaseItems(to)
+	answer := &ReleaseDiff{From: fromName, To: toName}
+	for key, item := range toItems {
+		if _, ok := fromItems[key]; !ok {
+			answer.Added = append(answer.Added, item)
+		}
+	}
+	for key, item := range fromItems {
+		if _, ok := toItems[key]; !ok {
+			answer.Removed = append(answer.Removed, item)
+		}
+	}
+	sortReleaseItems(answer.Added)
+	sortReleaseItems(answer.Removed)
+	return answer
+}
+
+func indexReleaseItems(items []ReleaseItem) map[string]ReleaseItem {
+	answer := map[string]ReleaseItem{}
+	for _, item := range items {
+		id := item.ID
+		if item.Kind == ReleaseItemCommit {
+			id = strings.ToLower(id)
+			if len(id) > 7 {
+				id = id[:7]
+			}
+		}
+		key := item.Kind + "/" + id
+		if _, ok := answer[key]; !ok {
+			answer[key] = item
+		}
+	}
+	return answer
+}
+
+func sortReleaseItems(items []ReleaseItem) {
+	order := map[string]int{ReleaseItemPullRequest: 1, ReleaseItemIssue: 2, ReleaseItemCommit: 3}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Kind != b.Kind {
+			return order[a.Kind] < order[b.Kind]
+		}
+		return a.ID < b.ID
+	})
+}
+
+// ReleaseDiffMarkdown returns the markdown report of the differences between the releases
+func ReleaseDiffMarkdown(d *ReleaseDiff) string {
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("## Changes from %s to %s\n", d.From, d.To))
+	if d.Empty() {
+		buf.WriteString("\nThe releases contain the same commits, issues and pull requests\n")
+		return buf.String()
+	}
+	write := func(title string, items []ReleaseItem) {
+		if len(items) == 0 {
+			return
+		}
+		buf.WriteString(fmt.Sprintf("\n### %s\n\n", title))
+		for _, item := range items {
+			buf.WriteString(strings.TrimSpace(fmt.Sprintf("* %s %s %s", item.Kind, describeReleaseItemID(item), item.Title)) + "\n")
+		}
+	}
+	write("Added", d.Added)
+	write("Removed", d.Removed)
+	return buf.String()
+}
+
+func describeReleaseItemID(item ReleaseItem) string {
+	if item.Kind == ReleaseItemCommit {
+		if len(item.ID) > 7 {
+			return item.ID[:7]
+		}
+		return item.ID
+	}
+	_, err := strconv.Atoi(item.ID)
+	if err != nil {
+		return item.ID
+	}
+	return "#" + item.ID
+}
@@ -0,0 +1,51 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffReleases(t *testing.T) {
+	t.Parallel()
+	repoURL := "https://github.com/myorg/myrepo"
+	gitInfo, err := giturl.ParseGitURL(repoURL)
+	require.NoError(t, err)
+	spec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{SHA: "1111111aaaaaaa", URL: repoURL + "/commit/1111111aaaaaaa", Message: "feat: something new", IssueIDs: []string{"12"}},
+			{SHA: "2222222bbbbbbb", URL: repoURL + "/commit/2222222bbbbbbb", Message: "fix: a bug"},
+		},
+		Issues:       []v1.IssueSummary{{ID: "12", URL: repoURL + "/issues/12", Title: "the issue"}},
+		PullRequests: []v1.IssueSummary{{ID: "13", URL: repoURL + "/pull/13", Title: "the pull request"}},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(spec, gitInfo, &gits.MarkdownOptions{CommitLinks: true})
+	require.NoError(t, err)
+	markdown += "\n* upstream [3333333](https://github.com/other/repo/commit/3333333cccccc)\n"
+
+	published := changelog.MarkdownReleaseItems(markdown, repoURL)
+	d := changelog.DiffReleases("published", published, "regenerated", changelog.ReleaseSpecItems(spec))
+	assert.True(t, d.Empty(), "diff %#v of markdown:\n%s", d, markdown)
+
+	regenerated := *spec
+	regenerated.Commits = append([]v1.CommitSummary{{SHA: "4444444ddddddd", Message: "feat: another"}}, spec.Commits[1:]...)
+	regenerated.Issues = nil
+	d = changelog.DiffReleases("published", published, "regenerated", changelog.ReleaseSpecItems(&regenerated))
+	assert.Equal(t, []changelog.ReleaseItem{{Kind: changelog.ReleaseItemCommit, ID: "4444444ddddddd", Title: "feat: another"}}, d.Added)
+	require.Len(t, d.Removed, 2)
+	assert.Equal(t, changelog.ReleaseItemIssue, d.Removed[0].Kind)
+	assert.Equal(t, "12", d.Removed[0].ID)
+	assert.Equal(t, "1111111", d.Removed[1].ID[:7])
+
+	report := changelog.ReleaseDiffMarkdown(d)
+	assert.Contains(t, report, "## Changes from published to regenerated\n")
+	assert.Contains(t, report, "### Added\n\n* commit 4444444 feat: another\n")
+	assert.Contains(t, report, "* issue #12 ")
+}
@@ -0,0 +1,201 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DigestPeriodDaily a digest of the last day
+	DigestPeriodDaily = "daily"
+
+	// DigestPeriodWeekly a digest of the last week
+	DigestPeriodWeekly = "weekly"
+
+	// DigestPeriodMonthly a digest of the last month
+	DigestPeriodMonthly = "monthly"
+
+	// digestDateFormat the format of the dates of a digest
+	digestDateFormat = "2 January 2006"
+)
+
+// DigestRepository the commits of a repository in the period of a digest
+type DigestRepository struct {
+	// Name the 'owner/name' of the repository
+	Name string
+
+	// URL the HTTP URL of the repository used to link to the commits
+	URL string
+
+	// Commits the commits of the period latest first
+	Commits []*Commit
+}
+
+// DigestPeriodStart returns the start of the period ending at until
+func DigestPeriodStart(period string, until time.Time) (time.Time, error) {
+	switch period {
+	case DigestPeriodDaily:
+		return until.AddDate(0, 0, -1), nil
+	case DigestPeriodWeekly:
+		return until.AddDate(0, 0, -7), nil
+	case DigestPeriodMonthly:
+		return until.AddDate(0, -1, 0), nil
+	default:
+		return time.Time{}, errors.Errorf("unknown period %s. Should be one of %s, %s or %s", period, DigestPeriodDaily, DigestPeriodWeekly, DigestPeriodMonthly)
+	}
+}
+
+// CollectDigestCommits returns the commits of the history of the repository committed in the period latest first.
+// Merge commits are excluded
+func CollectDigestCommits(fetcher gits.CommitFetcher, dir string, since, until time.Time) ([]*Commit, error) {
+	iter, err := fetcher.FetchHistory(dir, "HEAD", 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk the history of %s", dir)
+	}
+	defer iter.Close()
+	var answer []*Commit
+	for {
+		c, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the history of %s", dir)
+		}
+		when := c.Committer.When
+		if !until.IsZero() && !when.Before(until) {
+			continue
+		}
+		if when.Before(since) {
+			break
+		}
+		if len(c.ParentHashes) > 1 {
+			continue
+		}
+		commit := NewCommit(c.Hash.String(), c.Message)
+		commit.Author = &v1.UserDetails{Name: c.Author.Name, Email: c.Author.Email}
+		answer = append(answer, commit)
+	}
+	return answer, nil
+}
+
+// ReleasesDigestMarkdown returns the digest document of the releases of the period grouped by repository
+func ReleasesDigestMarkdown(title string, since, until time.Time, releases []v1.Release) (string, error) {
+	var buf strings.Builder
+	writeDigestTitle(&buf, title, since, until)
+	if len(releases) == 0 {
+		buf.WriteString("\nNo releases in this period\n")
+		return buf.String(), nil
+	}
+	var names []string
+	groups := map[string][]*v1.Release{}
+	for i := range releases {
+		r := &releases[i]
+		name := releaseName(r)
+		if groups[name] == nil {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], r)
+	}
+	sort.Strings(names)
+	buf.WriteString(fmt.Sprintf("\n%s across %s\n", plural(len(releases), "release"), plural(len(names), "repository")))
+	for _, name := range names {
+		buf.WriteString(fmt.Sprintf("\n## %s\n", name))
+		for _, r := range groups[name] {
+			gitInfo := &giturl.GitRepository{}
+			if r.Spec.GitHTTPURL != "" {
+				info, err := giturl.ParseGitURL(r.Spec.GitHTTPURL)
+				if err == nil {
+					gitInfo = info
+				}
+			}
+			markdown, err := gits.GenerateMarkdown(&r.Spec, gitInfo)
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to generate the changelog of Release %s", r.Name)
+			}
+			buf.WriteString(fmt.Sprintf("\n### %s\n\n_Released %s_\n", releaseVersion(r), r.CreationTimestamp.Time.UTC().Format(digestDateFormat)))
+			if strings.TrimSpace(markdown) != "" {
+				buf.WriteString("\n" + demoteHeadings(demoteHeadings(markdown)))
+			}
+		}
+	}
+	return buf.String(), nil
+}
+
+// CommitsDigestMarkdown returns the digest document of the commits of the period grouped by repository and
+// Conventional Commits type
+func CommitsDigestMarkdown(title string, since, until time.Time, repositories []DigestRepository) string {
+	var buf strings.Builder
+	writeDigestTitle(&buf, title, since, until)
+	count := 0
+	for i := range repositories {
+		count += len(repositories[i].Commits)
+	}
+	if count == 0 {
+		buf.WriteString("\nNo commits in this period\n")
+		return buf.String()
+	}
+	buf.WriteString(fmt.Sprintf("\n%s across %s\n", plural(count, "commit"), plural(len(repositories), "repository")))
+	for i := range repositories {
+		repo := &repositories[i]
+		if len(repo.Commits) == 0 {
+			continue
+		}
+		name := repo.Name
+		if repo.URL != "" {
+			name = "[" + repo.Name + "](" + repo.URL + ")"
+		}
+		buf.WriteString(fmt.Sprintf("\n## %s\n", name))
+		cl := &Changelog{Commits: repo.Commits}
+		for _, group := range cl.Groups() {
+			groupTitle := group.Title
+			if groupTitle == "" {
+				groupTitle = "Other Changes"
+			}
+			buf.WriteString(fmt.Sprintf("\n### %s\n\n", groupTitle))
+			for _, c := range group.Commits {
+				subject := c.Subject
+				if c.Scope != "" {
+					subject = "**" + c.Scope + ":** " + subject
+				}
+				sha := c.SHA
+				if len(sha) > 7 {
+					sha = sha[:7]
+				}
+				if repo.URL != "" {
+					sha = "[" + sha + "](" + strings.TrimSuffix(repo.URL, "/") + "/commit/" + c.SHA + ")"
+				}
+				author := ""
+				if c.Author != nil && c.Author.Name != "" {
+					author = " by " + c.Author.Name
+				}
+				buf.WriteString(fmt.Sprintf("* %s (%s%s)\n", subject, sha, author))
+			}
+		}
+	}
+	return buf.String()
+}
+
+func writeDigestTitle(buf *strings.Builder, title string, since, until time.Time) {
+	buf.WriteString("# " + title + "\n\n")
+	buf.WriteString(fmt.Sprintf("_%s to %s_\n", since.UTC().Format(digestDateFormat), until.UTC().Format(digestDateFormat)))
+}
+
+// plural returns the count and the noun in the plural if the count is not one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", count, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
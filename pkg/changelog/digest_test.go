@@ -0,0 +1,61 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestPeriodStart(t *testing.T) {
+	t.Parallel()
+	until := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	since, err := changelog.DigestPeriodStart(changelog.DigestPeriodWeekly, until)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 3, 12, 0, 0, 0, time.UTC), since)
+
+	since, err = changelog.DigestPeriodStart(changelog.DigestPeriodMonthly, until)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 2, 10, 12, 0, 0, 0, time.UTC), since)
+
+	_, err = changelog.DigestPeriodStart("yearly", until)
+	assert.Error(t, err)
+}
+
+func TestCommitsDigestMarkdown(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2021, 3, 3, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	commit := func(sha, message string) *changelog.Commit {
+		c := changelog.NewCommit(sha, message)
+		c.Author = &v1.UserDetails{Name: "Jane Doe"}
+		return c
+	}
+	markdown := changelog.CommitsDigestMarkdown("Weekly Digest", since, until, []changelog.DigestRepository{
+		{
+			Name: "myorg/app1",
+			URL:  "https://github.com/myorg/app1",
+			Commits: []*changelog.Commit{
+				commit("1111111111111111111111111111111111111111", "fix(cli): a bug"),
+				commit("2222222222222222222222222222222222222222", "tidy up"),
+				commit("3333333333333333333333333333333333333333", "feat: something new"),
+			},
+		},
+		{Name: "myorg/app2"},
+	})
+	assert.Contains(t, markdown, "# Weekly Digest\n\n_3 March 2021 to 10 March 2021_\n\n3 commits across 2 repositories\n")
+	assert.Contains(t, markdown, "\n## [myorg/app1](https://github.com/myorg/app1)\n")
+	assert.Contains(t, markdown, "* **cli:** a bug ([1111111](https://github.com/myorg/app1/commit/1111111111111111111111111111111111111111) by Jane Doe)\n")
+	assert.Contains(t, markdown, "\n### Other Changes\n\n* tidy up")
+	assert.NotContains(t, markdown, "myorg/app2")
+	assert.Less(t, strings.Index(markdown, "something new"), strings.Index(markdown, "a bug"))
+
+	markdown = changelog.CommitsDigestMarkdown("Weekly Digest", since, until, nil)
+	assert.Contains(t, markdown, "No commits in this period")
+}
@@ -0,0 +1,342 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// environmentFiles the files of an environment GitOps repository containing the versions of the applications. The
+// helmfiles of Jenkins X 3 and the requirements.yaml of Jenkins X 2 environments are supported
+var environmentFiles = []string{"helmfile.yaml", "helmfiles/*/helmfile.yaml", "env/requirements.yaml"}
+
+// releaseKindRegex matches the kind of Release resources
+var releaseKindRegex = regexp.MustCompile(`(?m)^kind:\s*Release\s*$`)
+
+// EnvironmentApp an application deployed by an environment GitOps repository
+type EnvironmentApp struct {
+	Name      string
+	Namespace string
+	Chart     string
+	Version   string
+}
+
+// Key returns the namespace and name of the application
+func (a *EnvironmentApp) Key() string {
+	if a.Namespace == "" {
+		return a.Name
+	}
+	return a.Namespace + "/" + a.Name
+}
+
+// AppChange an application whose version changed between the revisions of an environment
+type AppChange struct {
+	EnvironmentApp
+
+	// FromVersion the previous version. Empty if the application was added
+	FromVersion string
+
+	// GitURL the URL of the source repository of the application if known
+	GitURL string
+
+	// ReleaseNotes the markdown release notes of the upstream releases of the application
+	ReleaseNotes string
+}
+
+// EnvironmentDiff finds the applications promoted between two revisions of an environment GitOps repository along
+// with their release notes
+type EnvironmentDiff struct {
+	// Dir the directory of the git clone of the environment repository
+	Dir string
+
+	// FromRevision the previous revision of the environment
+	FromRevision string
+
+	// ToRevision the current revision of the environment. Defaults to HEAD
+	ToRevision string
+
+	// GitClient runs the git commands
+	GitClient gitclient.Interface
+
+	// ScmClient if specified the release notes of the applications are fetched from the releases of the git provider
+	ScmClient *scm.Client
+
+	// Context the context of the requests to the git provider
+	Context context.Context
+
+	// Workers the number of applications whose release notes are found concurrently. Defaults to one
+	Workers int
+}
+
+type helmfile struct {
+	Releases []struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Chart     string `json:"chart"`
+		Version   string `json:"version"`
+	} `json:"releases"`
+}
+
+// ParseEnvironmentApps parses the applications of the helmfile.yaml or requirements.yaml file at the path indexed by
+// EnvironmentApp.Key. Releases of helmfiles in the 'helmfiles/<namespace>' directories default to the namespace
+func ParseEnvironmentApps(filePath, text string) (map[string]EnvironmentApp, error) {
+	answer := map[string]EnvironmentApp{}
+	if strings.TrimSpace(text) == "" {
+		return answer, nil
+	}
+	if path.Base(filePath) == "requirements.yaml" {
+		dependencies, err := deps.ParseHelmDependencies(text, false)
+		if err != nil {
+			return nil, err
+		}
+		for name, d := range dependencies {
+			app := EnvironmentApp{Name: name, Chart: name, Version: d.Version}
+			answer[app.Key()] = app
+		}
+		return answer, nil
+	}
+	namespace := ""
+	if dir := path.Dir(filePath); path.Dir(dir) == "helmfiles" {
+		namespace = path.Base(dir)
+	}
+	hf := &helmfile{}
+	err := yaml.Unmarshal([]byte(text), hf)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range hf.Releases {
+		if r.Version == "" {
+			continue
+		}
+		app := EnvironmentApp{Name: r.Name, Namespace: r.Namespace, Chart: r.Chart, Version: r.Version}
+		if app.Name == "" {
+			app.Name = path.Base(r.Chart)
+		}
+		if app.Namespace == "" {
+			app.Namespace = namespace
+		}
+		answer[app.Key()] = app
+	}
+	return answer, nil
+}
+
+// DiffEnvironmentApps returns the applications whose versions changed sorted by key. Removed applications are ignored
+func DiffEnvironmentApps(previous, current map[string]EnvironmentApp) []AppChange {
+	var answer []AppChange
+	for key, app := range current {
+		p := previous[key]
+		if p.Version == app.Version {
+			continue
+		}
+		answer = append(answer, AppChange{EnvironmentApp: app, FromVersion: p.Version})
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Key() < answer[j].Key()
+	})
+	return answer
+}
+
+// Diff returns the applications whose versions changed between the revisions along with their release notes
+func (e *EnvironmentDiff) Diff() ([]AppChange, error) {
+	var answer []AppChange
+	for _, pattern := range environmentFiles {
+		matches, err := filepath.Glob(filepath.Join(e.Dir, pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid file pattern %s", pattern)
+		}
+		for _, m := range matches {
+			rel, err := filepath.Rel(e.Dir, m)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to find the relative path of %s", m)
+			}
+			changes, err := e.diffFile(filepath.ToSlash(rel))
+			if err != nil {
+				return nil, err
+			}
+			answer = append(answer, changes...)
+		}
+	}
+	err := RunConcurrently(e.Workers, len(answer), func(i int) error {
+		return e.addReleaseNotes(&answer[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+func (e *EnvironmentDiff) diffFile(filePath string) ([]AppChange, error) {
+	previousText, _, err := gits.GetFileAtRevision(e.GitClient, e.Dir, e.FromRevision, filePath)
+	if err != nil {
+		return nil, err
+	}
+	currentText, _, err := gits.GetFileAtRevision(e.GitClient, e.Dir, e.ToRevision, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if previousText == currentText {
+		return nil, nil
+	}
+	previous, err := ParseEnvironmentApps(filePath, previousText)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the previous %s", filePath)
+	}
+	current, err := ParseEnvironmentApps(filePath, currentText)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the current %s", filePath)
+	}
+	return DiffEnvironmentApps(previous, current), nil
+}
+
+// addReleaseNotes adds the release notes of the releases of the git provider between the versions falling back to the
+// changes of the Release CR of the application in the environment
+func (e *EnvironmentDiff) addReleaseNotes(change *AppChange) error {
+	release, err := e.findRelease(change)
+	if err != nil {
+		return err
+	}
+	if release != nil {
+		change.GitURL = release.Spec.GitHTTPURL
+	}
+	if change.GitURL == "" {
+		change.GitURL, err = e.findGitURL(change)
+		if err != nil {
+			return err
+		}
+	}
+	change.ReleaseNotes = providerReleaseNotes(e.Context, e.ScmClient, change)
+	if change.ReleaseNotes == "" && release != nil {
+		gitInfo, err := giturl.ParseGitURL(release.Spec.GitHTTPURL)
+		if err != nil {
+			log.Logger().Debugf("failed to parse the git URL %s of the release of %s: %s", release.Spec.GitHTTPURL, change.Key(), err.Error())
+			return nil
+		}
+		change.ReleaseNotes, err = gits.GenerateMarkdown(&release.Spec, gitInfo)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate the release notes of %s", change.Key())
+		}
+	}
+	return nil
+}
+
+// findGitURL returns the source repository of the chart of the application in the version stream of the environment
+func (e *EnvironmentDiff) findGitURL(change *AppChange) (string, error) {
+	if change.Chart == "" {
+		return "", nil
+	}
+	for _, filePath := range []string{"versionStream/charts/" + change.Chart + "/defaults.yaml", "versionStream/charts/" + change.Chart + ".yml"} {
+		text, found, err := gits.GetFileAtRevision(e.GitClient, e.Dir, e.ToRevision, filePath)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			continue
+		}
+		f, err := deps.ParseVersionStreamFile(text)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+		return strings.TrimSuffix(f.GitURL, ".git"), nil
+	}
+	return "", nil
+}
+
+// findRelease returns the Release CR of the application rendered into the config-root directory of the environment
+func (e *EnvironmentDiff) findRelease(change *AppChange) (*v1.Release, error) {
+	toRev := e.ToRevision
+	if toRev == "" {
+		toRev = "HEAD"
+	}
+	dir := "config-root/namespaces/" + change.Namespace + "/" + change.Name
+	text, err := e.GitClient.Command(e.Dir, "ls-tree", "-r", "--name-only", toRev, "--", dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the files of %s at %s", dir, toRev)
+	}
+	for _, filePath := range strings.Split(text, "\n") {
+		filePath = strings.TrimSpace(filePath)
+		if !strings.HasSuffix(filePath, ".yaml") {
+			continue
+		}
+		data, _, err := gits.GetFileAtRevision(e.GitClient, e.Dir, toRev, filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !releaseKindRegex.MatchString(data) {
+			continue
+		}
+		release := &v1.Release{}
+		err = yaml.Unmarshal([]byte(data), release)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the Release %s", filePath)
+		}
+		if release.Kind == "Release" && strings.TrimPrefix(release.Spec.Version, "v") == strings.TrimPrefix(change.Version, "v") {
+			return release, nil
+		}
+	}
+	return nil, nil
+}
+
+// providerReleaseNotes returns the descriptions of the releases of the git provider between the versions
+func providerReleaseNotes(ctx context.Context, scmClient *scm.Client, change *AppChange) string {
+	if scmClient == nil || change.GitURL == "" {
+		return ""
+	}
+	gitInfo, err := giturl.ParseGitURL(change.GitURL)
+	if err != nil {
+		log.Logger().Debugf("failed to parse the git URL %s of %s: %s", change.GitURL, change.Key(), err.Error())
+		return ""
+	}
+	fullName := scm.Join(gitInfo.Organisation, gitInfo.Name)
+	releases, _, err := scmClient.Releases.List(ctx, fullName, scm.ReleaseListOptions{Page: 1, Size: upstreamReleasePageSize})
+	if err != nil {
+		log.Logger().Warnf("failed to list the releases of %s: %s", fullName, err.Error())
+		return ""
+	}
+	return upstreamReleaseNotes(releases, change.FromVersion, change.Version)
+}
+
+// EnvironmentMarkdown returns the aggregated markdown document of the application changes of the environment
+func EnvironmentMarkdown(changes []AppChange, fromRev, toRev string) string {
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("## Environment Changes from %s to %s\n", fromRev, toRev))
+	if len(changes) == 0 {
+		buf.WriteString("\nNo application versions changed\n")
+		return buf.String()
+	}
+	buf.WriteString("\n| Application | Namespace | Old Version | New Version |\n")
+	buf.WriteString("| ----------- | --------- | ----------- | ----------- |\n")
+	for i := range changes {
+		c := &changes[i]
+		name := c.Name
+		if c.GitURL != "" {
+			name = "[" + c.Name + "](" + c.GitURL + ")"
+		}
+		from := c.FromVersion
+		if from == "" {
+			from = "-"
+		}
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", name, c.Namespace, from, c.Version))
+	}
+	for i := range changes {
+		c := &changes[i]
+		if strings.TrimSpace(c.ReleaseNotes) == "" {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\n### %s %s\n\n%s\n", c.Name, c.Version, strings.TrimSpace(c.ReleaseNotes)))
+	}
+	return buf.String()
+}
@@ -0,0 +1,98 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvironmentApps(t *testing.T) {
+	t.Parallel()
+	apps, err := changelog.ParseEnvironmentApps("helmfiles/jx/helmfile.yaml", `releases:
+- chart: jx3/jx-pipelines-visualizer
+  version: 1.7.2
+- chart: jx3/lighthouse
+  name: lighthouse
+  namespace: lighthouse
+  version: 1.1.0
+- chart: dev/local
+`)
+	require.NoError(t, err)
+	require.Len(t, apps, 2)
+	assert.Equal(t, changelog.EnvironmentApp{Name: "jx-pipelines-visualizer", Namespace: "jx", Chart: "jx3/jx-pipelines-visualizer", Version: "1.7.2"}, apps["jx/jx-pipelines-visualizer"])
+	assert.Equal(t, "1.1.0", apps["lighthouse/lighthouse"].Version)
+
+	apps, err = changelog.ParseEnvironmentApps("env/requirements.yaml", `dependencies:
+- name: jxui
+  version: 0.0.10
+  repository: https://charts.jenkins-x.io
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.10", apps["jxui"].Version)
+}
+
+func TestEnvironmentDiff(t *testing.T) {
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	git := func(args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return out
+	}
+	write := func(path, text string) {
+		f := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0755))
+		require.NoError(t, ioutil.WriteFile(f, []byte(text), 0600))
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	write("helmfiles/jx/helmfile.yaml", "releases:\n- chart: jx3/jx-preview\n  version: 0.1.0\n- chart: jx3/jx-kh-check\n  version: 0.0.70\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	from := git("rev-parse", "HEAD")
+
+	write("helmfiles/jx/helmfile.yaml", "releases:\n- chart: jx3/jx-preview\n  version: 0.2.0\n- chart: jx3/jx-kh-check\n  version: 0.0.70\n")
+	write("config-root/namespaces/jx/jx-preview/release.yaml", `apiVersion: jenkins.io/v1
+kind: Release
+metadata:
+  name: jx-preview-0.2.0
+spec:
+  version: 0.2.0
+  gitHttpUrl: https://github.com/jenkins-x/jx-preview
+  commits:
+  - message: "fix: preview URLs"
+    sha: "1234567890"
+`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "promote jx-preview")
+
+	diff := &changelog.EnvironmentDiff{
+		Dir:          dir,
+		FromRevision: from,
+		GitClient:    gitclient.Interface(g),
+	}
+	changes, err := diff.Diff()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	c := changes[0]
+	assert.Equal(t, "jx-preview", c.Name)
+	assert.Equal(t, "0.1.0", c.FromVersion)
+	assert.Equal(t, "0.2.0", c.Version)
+	assert.Equal(t, "https://github.com/jenkins-x/jx-preview", c.GitURL)
+	assert.Contains(t, c.ReleaseNotes, "preview URLs")
+
+	markdown := changelog.EnvironmentMarkdown(changes, "v1", "v2")
+	assert.Contains(t, markdown, "## Environment Changes from v1 to v2\n")
+	assert.Contains(t, markdown, "| [jx-preview](https://github.com/jenkins-x/jx-preview) | jx | 0.1.0 | 0.2.0 |\n")
+	assert.Contains(t, markdown, "\n### jx-preview 0.2.0\n\n")
+}
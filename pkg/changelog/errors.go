@@ -0,0 +1,129 @@
+package changelog
+
+import (
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoPreviousTag there is no previous tag or commit to generate the changelog from
+	ErrNoPreviousTag = errors.New("no previous tag")
+
+	// ErrNoCommits there are no commits, or fewer than the minimum number of commits, in the changelog
+	ErrNoCommits = errors.New("no commits")
+
+	// ErrReleaseConflict the release on the git provider conflicts with an existing release or tag
+	ErrReleaseConflict = errors.New("release conflict")
+
+	// ErrAuth the git provider rejected the credentials
+	ErrAuth = errors.New("not authorized")
+
+	// ErrPolicyViolation the changelog violates the required policies
+	ErrPolicyViolation = errors.New("policy violation")
+)
+
+// errorKinds the kinds of errors in the order they are reported
+var errorKinds = []error{ErrAuth, ErrReleaseConflict, ErrPolicyViolation, ErrNoCommits, ErrNoPreviousTag}
+
+// Error an error of a Kind such as ErrNoCommits which can be checked via errors.Is while keeping the message of the cause
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error returns the message of the cause
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is the Kind of the error
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// ErrorKind returns the kind of the error such as ErrNoCommits or nil if it is not one of the kinds of errors
+func ErrorKind(err error) error {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+func newError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Err: errors.Errorf(format, args...)}
+}
+
+// withKind returns the error with the kind of the first error which has one
+func withKind(err error, causes ...error) error {
+	for _, cause := range causes {
+		kind := ErrorKind(cause)
+		if kind != nil {
+			return &Error{Kind: kind, Err: err}
+		}
+	}
+	return err
+}
+
+// scmError classifies the error of a request to the git provider as ErrAuth or ErrReleaseConflict from the status code
+func scmError(res *scm.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+	status := 0
+	if res != nil {
+		status = res.Status
+	}
+	switch {
+	case status == http.StatusUnauthorized || errors.Is(err, scm.ErrNotAuthorized):
+		return &Error{Kind: ErrAuth, Err: err}
+	case status == http.StatusConflict || status == http.StatusUnprocessableEntity:
+		return &Error{Kind: ErrReleaseConflict, Err: err}
+	}
+	return err
+}
+
+const (
+	// ExitCodeFailure the exit code of errors which are not one of the kinds of errors
+	ExitCodeFailure = 1
+
+	// ExitCodeNoPreviousTag the exit code of ErrNoPreviousTag
+	ExitCodeNoPreviousTag = 3
+
+	// ExitCodeNoCommits the exit code of ErrNoCommits
+	ExitCodeNoCommits = 4
+
+	// ExitCodeReleaseConflict the exit code of ErrReleaseConflict
+	ExitCodeReleaseConflict = 5
+
+	// ExitCodeAuth the exit code of ErrAuth
+	ExitCodeAuth = 6
+
+	// ExitCodePolicyViolation the exit code of ErrPolicyViolation
+	ExitCodePolicyViolation = 7
+)
+
+// ExitCode returns the exit code of the command line for the kind of the error
+func ExitCode(err error) int {
+	switch ErrorKind(err) {
+	case ErrNoPreviousTag:
+		return ExitCodeNoPreviousTag
+	case ErrNoCommits:
+		return ExitCodeNoCommits
+	case ErrReleaseConflict:
+		return ExitCodeReleaseConflict
+	case ErrAuth:
+		return ExitCodeAuth
+	case ErrPolicyViolation:
+		return ExitCodePolicyViolation
+	}
+	return ExitCodeFailure
+}
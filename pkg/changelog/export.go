@@ -0,0 +1,129 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+var plainEnvValueRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@+,=-]*$`)
+
+// exportEnvFile writes the details of the changelog as environment variables in dotenv format so that later
+// steps in the pipeline can consume them
+func (g *Generator) exportEnvFile(spec *v1.ReleaseSpec, tag, previousRev, currentRev string) error {
+	if g.ExportEnvFile == "" {
+		return nil
+	}
+	values := map[string]string{
+		"CHANGELOG_VERSION":       strings.TrimPrefix(spec.Version, "v"),
+		"CHANGELOG_TAG":           tag,
+		"CHANGELOG_RELEASE_URL":   spec.ReleaseNotesURL,
+		"CHANGELOG_PREVIOUS_REV":  previousRev,
+		"CHANGELOG_REV":           currentRev,
+		"CHANGELOG_COMMITS":       strconv.Itoa(len(spec.Commits)),
+		"CHANGELOG_ISSUES":        strconv.Itoa(len(spec.Issues)),
+		"CHANGELOG_PULL_REQUESTS": strconv.Itoa(len(spec.PullRequests)),
+		"CHANGELOG_MARKDOWN_FILE": g.OutputMarkdownFile,
+		"CHANGELOG_GIT_OWNER":     spec.GitOwner,
+		"CHANGELOG_GIT_REPO":      spec.GitRepository,
+	}
+	err := ioutil.WriteFile(g.ExportEnvFile, []byte(toDotenv(values)), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the export env file %s", g.ExportEnvFile)
+	}
+	log.Logger().Infof("generated: %s", info(g.ExportEnvFile))
+	return nil
+}
+
+// toDotenv formats the values as sorted KEY=value lines quoting any values which are not plain text
+func toDotenv(values map[string]string) string {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		v := values[k]
+		if !plainEnvValueRegex.MatchString(v) {
+			v = quoteEnvValue(v)
+		}
+		sb.WriteString(fmt.Sprintf("%s=%s\n", k, v))
+	}
+	return sb.String()
+}
+
+func quoteEnvValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// ExportModel the full structured model of the changelog collected from the git history, issue tracker and git
+// provider before it is rendered so that it can be consumed by custom renderers and audits
+type ExportModel struct {
+	Repository                string                         `json:"repository"`
+	GitURL                    string                         `json:"gitUrl,omitempty"`
+	Version                   string                         `json:"version,omitempty"`
+	Range                     *Range                         `json:"range"`
+	Changelog                 *Changelog                     `json:"changelog"`
+	DependencyUpdates         []v1.DependencyUpdate          `json:"dependencyUpdates,omitempty"`
+	DependencyClassifications map[string]deps.Classification `json:"dependencyClassifications,omitempty"`
+	Contributors              []gits.Contributor             `json:"contributors,omitempty"`
+	NewContributors           []gits.NewContributor          `json:"newContributors,omitempty"`
+	Reviewers                 []gits.Reviewer                `json:"reviewers,omitempty"`
+	Annotations               map[string]string              `json:"annotations,omitempty"`
+	Stats                     *Stats                         `json:"stats"`
+}
+
+// Export returns the structured model of the collected changelog along with the contributors, new contributors and
+// reviewers enabled on the generator without rendering or publishing it
+func (g *Generator) Export(result *Result) *ExportModel {
+	release := result.Release
+	spec := &release.Spec
+	answer := &ExportModel{
+		Repository:        scm.Join(spec.GitOwner, spec.GitRepository),
+		GitURL:            spec.GitHTTPURL,
+		Version:           g.Version,
+		Range:             result.Range,
+		Changelog:         result.Changelog,
+		DependencyUpdates: spec.DependencyUpdates,
+		Contributors:      gits.Contributors(spec),
+		Annotations:       release.Annotations,
+		Stats:             result.Changelog.Stats(),
+	}
+	if result.MarkdownOptions != nil {
+		answer.DependencyClassifications = result.MarkdownOptions.DependencyClassifications
+		answer.Reviewers = aggregateReviewers(result.MarkdownOptions.Reviewers)
+	}
+	if g.NewContributors {
+		answer.NewContributors = g.findNewContributors(spec, result.Changelog.Commits, result.Range.PreviousRev)
+	}
+	return answer
+}
+
+// CollectModel resolves the range of the changelog and collects its structured model without rendering or publishing
+// it. Returns nil if there is no changelog to collect such as when there is no previous tag
+func (g *Generator) CollectModel(ctx context.Context) (*ExportModel, error) {
+	rng, err := g.ResolveRange(ctx)
+	if err != nil || rng == nil {
+		return nil, err
+	}
+	result, err := g.Collect(ctx, rng)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return g.Export(result), nil
+}
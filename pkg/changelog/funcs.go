@@ -0,0 +1,126 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// textFuncs returns the string, list and date functions of the templates using the function of the current time. They
+// are a subset of the sprig functions named and taking their arguments in the same order so that templates written for
+// helm charts using them can be reused
+func textFuncs(now func() time.Time) template.FuncMap {
+	return template.FuncMap{
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      strings.Title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"quote":      func(s interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(s)) },
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"trunc":      trunc,
+		"join":       join,
+		"default":    defaultValue,
+		"empty":      empty,
+		"sub":        func(a, b int) int { return a - b },
+		"now":        now,
+		"date":       date,
+		"toJson":     toJSON,
+	}
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// trunc truncates the string to the length or removes the length from the start if it is negative
+func trunc(length int, s string) string {
+	if length < 0 && len(s)+length > 0 {
+		return s[len(s)+length:]
+	}
+	if length >= 0 && len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+// join joins the elements of the list such as a slice of strings or issue labels with the separator
+func join(sep string, list interface{}) string {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+	values := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		values = append(values, fmt.Sprint(v.Index(i).Interface()))
+	}
+	return strings.Join(values, sep)
+}
+
+// defaultValue returns the value unless it is empty in which case the default is returned
+func defaultValue(d, value interface{}) interface{} {
+	if empty(value) {
+		return d
+	}
+	return value
+}
+
+// empty returns true if the value is nil, zero or has no elements
+func empty(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// date formats the time such as the CreationTimestamp of the release with the layout such as '2006-01-02'
+func date(layout string, value interface{}) string {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v != nil {
+			t = *v
+		}
+	case metav1.Time:
+		t = v.Time
+	case *metav1.Time:
+		if v != nil {
+			t = v.Time
+		}
+	default:
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
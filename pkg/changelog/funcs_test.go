@@ -0,0 +1,66 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyTemplate(t *testing.T) {
+	t.Parallel()
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+	g := &changelog.Generator{
+		Clock:  changelogtest.NewClock(changelogtest.DefaultTime),
+		Header: "# {{ .Name | upper }}\n",
+		Footer: "\n_{{ default \"no comparison\" .CompareURL }}_\n",
+		Template: `Released {{ date "2006-01-02" now }} with {{ len .Commits }} commits
+{{ range .Groups }}
+### {{ default "Other Changes" .Title }}
+{{ range .Commits }}- {{ with .Scope }}**{{ . }}** {{ end }}{{ .Subject | trimSuffix "." | title }} ({{ trunc 7 .SHA }})
+{{ end }}{{ end }}
+Labels: {{ join ", " (splitList "," "a,b") | quote }}
+`,
+	}
+	require.NoError(t, g.Validate())
+	g.State.GitInfo = gitInfo
+	commits := []*changelog.Commit{
+		changelog.NewCommit("1111111aaaa", "fix(cli): the help."),
+		changelog.NewCommit("2222222bbbb", "feat: search"),
+		changelog.NewCommit("3333333cccc", "tidy up"),
+	}
+	spec := v1.ReleaseSpec{Name: "myapp", Version: "1.2.0"}
+	(&changelog.Changelog{Commits: commits}).ProjectInto(&spec)
+	result := &changelog.Result{
+		Range:           &changelog.Range{},
+		Release:         &v1.Release{Spec: spec},
+		Changelog:       &changelog.Changelog{Commits: commits},
+		MarkdownOptions: &gits.MarkdownOptions{},
+	}
+	require.NoError(t, g.Render(context.Background(), result))
+	assert.Equal(t, `# MYAPP
+Released `+changelogtest.DefaultTime.Format("2006-01-02")+` with 3 commits
+
+### New Features
+- Search (2222222)
+
+### Bug Fixes
+- **cli** The Help (1111111)
+
+### Other Changes
+- Tidy Up (3333333)
+
+Labels: "a, b"
+
+_no comparison_
+`, result.Markdown)
+}
@@ -0,0 +1,684 @@
+package changelog
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Generator generates the changelog of a git repository between two revisions. The generation is split into the
+// ResolveRange, Collect, Curate, Render and Publish phases which can be invoked individually or all together via Generate
+type Generator struct {
+	ScmFactory    scmhelpers.Options
+	GitClient     gitclient.Interface
+	CommandRunner cmdrunner.CommandRunner
+	// HTTPClient the client of the issue trackers, webhooks and other HTTP APIs so that they share connections.
+	// Defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	PreviousRevision        string
+	PreviousDate            string
+	CurrentRevision         string
+	Milestone               string
+	PullRequestsMergedSince string
+	PullRequestsMergedUntil string
+	BaseBranch              string
+	TemplatesDir            string
+	ReleaseYamlFile         string
+	CrdYamlFile             string
+	Version                 string
+	Header                  string
+	HeaderFile              string
+	Footer                  string
+	FooterFile              string
+	Template                string
+	TemplateFile            string
+	OutputMarkdownFile      string
+	ExportEnvFile           string
+	CalendarFile            string
+	ChecksumsFile           string
+	CosignBinary            string
+	SiteDir                 string
+	SiteFormat              string
+	SiteBranch              string
+	PublishWiki             bool
+	WikiURL                 string
+	WikiUsername            string
+	TranslateCommand        string
+	TranslateURL            string
+	TranslationOutput       string
+	TranslationsDir         string
+	MailmapFile             string
+	AliasFile               string
+	CheckpointFile          string
+	OverwriteCRD            bool
+	GenerateCRD             bool
+	GenerateReleaseYaml     bool
+	UpdateRelease           bool
+	ApprovalPR              bool
+	ApprovalDir             string
+	IncludeMergeCommits     bool
+	FailIfFindCommits       bool
+	NewContributors         bool
+	Contributors            bool
+	ContributorAvatars      bool
+	Reviewers               bool
+	Deployments             bool
+	DependencyReleaseNotes  bool
+	DependencyAnalyzers     []string
+	ClassifyDependencies    bool
+	DependencyAdvisories    bool
+	DependencyUpdatePaths   bool
+	Mentions                string
+	TranslateLanguages      []string
+	HighlightLabels         []string
+	ReleaseAssets           []string
+	LinkIssues              bool
+	IssueURLTemplates       map[string]string
+	LabelSections           map[string]string
+	ExcludeLabels           []string
+	LabelBadges             bool
+	CommitLinks             bool
+	CompareLink             bool
+	LabelBadgePatterns      []string
+	SkipCommitPattern       string
+	MinCommits              int
+	Highlights              int
+	CollapseReverts         bool
+	CommitPullRequests      bool
+	MaxAPICalls             int
+	MinRateLimitRemaining   int
+	EnrichWorkers           int
+	PerfBudget              map[string]string
+	FirstRelease            bool
+	FirstReleaseMax         int
+	Reproducible            bool
+	ReleaseMetadata         bool
+	Sign                    bool
+	JiraVersion             bool
+	JiraVersionReleased     bool
+	JiraVersionName         string
+	JiraServer              string
+	JiraProject             string
+	JiraUsername            string
+	JiraAPIToken            string
+	ProjectBoard            string
+	ProjectField            string
+	ProjectOption           string
+	GeneratorVersion        string
+	OnReleaseError          string
+	OnIssueLookupError      string
+	MergeCommitPolicy       string
+	GitBackend              string
+	Paths                   []string
+	Components              []string
+	ComponentRepositories   []string
+	ComponentWorkers        int
+	ComponentMinRateLimit   int
+	ChangesetDir            string
+	ChangesetCommit         bool
+	Notify                  []string
+	Channel                 string
+	ChannelTagPattern       string
+	ChannelNotify           map[string]string
+	Format                  string
+	FormatOptions           map[string]string
+	Publishers              []Publisher
+	IssueTracker            issues.IssueProvider
+	Clock                   Clock
+	AdvisoryLookup          deps.AdvisoryLookup
+
+	// Input if specified the collected commits are curated interactively before the changelog is rendered
+	Input input.Interface
+
+	// AssetUploader if specified uploads the ReleaseAssets instead of the GitHub API
+	AssetUploader AssetUploader
+
+	// Translator if specified translates the changelog into the TranslateLanguages instead of the translate command
+	// or URL
+	Translator Translator
+
+	// SharedCache if specified shares the users and issues looked up via the git provider between processes such as
+	// the replicas of the service
+	SharedCache cache.Cache
+
+	State State
+}
+
+// State the state of the generator while generating the changelog
+type State struct {
+	Context          context.Context
+	GitInfo          *giturl.GitRepository
+	Branch           string
+	DefaultBranch    string
+	FirstRelease     bool
+	SkipCommitRegex  *regexp.Regexp
+	Trailers         map[string]map[string]string
+	Profile          *Profile
+	PerfBudget       map[string]time.Duration
+	Budget           *APIBudget
+	LabelTypes       map[string]string
+	LabelFilter      func(label string) bool
+	MergedSince      time.Time
+	MergedUntil      time.Time
+	Renderer         Renderer
+	CommitFetcher    gits.CommitFetcher
+	Analyzers        []deps.Analyzer
+	Tracker          issues.IssueProvider
+	Refs             *refs.Resolver
+	LoggedIssueKind  bool
+	Release          *v1.Release
+	Translator       Translator
+	AssetRenderers   map[string]Renderer
+	Checksums        []Checksum
+	PreviousReleases []*TemplateRelease
+	ChecksumsData    []byte
+	Checkpoint       *Checkpoint
+	Changesets       []*Changeset
+	Channel          *Channel
+	Components       []Component
+}
+
+// Range the git revisions of the changelog
+type Range struct {
+	// PreviousRev the revision of the previous release which is excluded from the changelog. Empty for the initial release
+	PreviousRev string `json:"previousRev,omitempty"`
+
+	// PreviousName the tag or name of the previous revision used in links
+	PreviousName string `json:"previousName,omitempty"`
+
+	// CurrentRev the revision being released
+	CurrentRev string `json:"currentRev"`
+
+	// CurrentName the tag or branch name of the current revision used in links
+	CurrentName string `json:"currentName,omitempty"`
+
+	// FirstRelease true if there is no previous release so the changelog contains the history up to the current revision
+	FirstRelease bool `json:"firstRelease,omitempty"`
+
+	// Milestone if specified the changelog contains the issues and pull requests of the milestone rather than the
+	// commits between the revisions
+	Milestone string `json:"milestone,omitempty"`
+
+	// PullRequestsMerged if true the changelog contains the pull requests merged into the base branch between the
+	// times rather than the commits between the revisions
+	PullRequestsMerged bool       `json:"pullRequestsMerged,omitempty"`
+	BaseBranch         string     `json:"baseBranch,omitempty"`
+	MergedSince        *time.Time `json:"mergedSince,omitempty"`
+	MergedUntil        *time.Time `json:"mergedUntil,omitempty"`
+}
+
+// Result the results of the phases of generating the changelog
+type Result struct {
+	// Range the git revisions of the changelog
+	Range *Range
+
+	// Changelog the collected model of the changes which is projected into the Release
+	Changelog *Changelog
+
+	// Release the generated Release resource
+	Release *v1.Release
+
+	// MarkdownOptions the options used to render the markdown
+	MarkdownOptions *gits.MarkdownOptions
+
+	// TemplateData the data used to render the header and footer templates
+	TemplateData *TemplateData
+
+	// Markdown the changelog rendered as markdown for the release on the git provider
+	Markdown string
+
+	// Output the changelog rendered in the chosen format
+	Output string
+
+	// Tag the git tag of the release on the git provider
+	Tag string
+
+	// Published the outcome of publishing the changelog to each target
+	Published []PublishReport
+
+	// TemplatesDir the directory the Release YAML is generated into
+	TemplatesDir string
+
+	// Translations the translations of the markdown indexed by language
+	Translations map[string]string
+
+	// Assets the renderings of the changelog uploaded as assets of the release on the git provider
+	Assets []ReleaseAsset
+}
+
+const (
+	ReleaseName = `{{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}`
+
+	SpecName    = `{{ .Chart.Name }}`
+	SpecVersion = `{{ .Chart.Version }}`
+
+	// ContributorsAnnotation the annotation on the Release containing the JSON encoded contributors
+	ContributorsAnnotation = "changelog.jenkins-x.io/contributors"
+
+	// DefaultSkipCommitPattern the default pattern of the release commits removed from the changelog
+	DefaultSkipCommitPattern = `^(release |chore\(release\):)`
+
+	// MergeCommitsInclude includes all merge commits in the changelog
+	MergeCommitsInclude = "include"
+
+	// MergeCommitsExclude excludes all merge commits from the changelog
+	MergeCommitsExclude = "exclude"
+
+	// MergeCommitsOnlyPRs only includes the merge commits of pull requests in the changelog
+	MergeCommitsOnlyPRs = "only-prs"
+
+	// MentionsNone users are rendered as links to their profiles
+	MentionsNone = "none"
+
+	// MentionsAll users are rendered as @login mentions
+	MentionsAll = "all"
+
+	// MentionsOrgMembers only members of the organisation of the repository are @mentioned and others are rendered as plain names
+	MentionsOrgMembers = "org-members"
+
+	// TrailersAnnotation the annotation on the Release containing the JSON encoded commit trailers indexed by commit SHA
+	TrailersAnnotation = "changelog.jenkins-x.io/trailers"
+
+	// InitialReleaseAnnotation the annotation on the Release marking it as the initial release of the repository
+	InitialReleaseAnnotation = "changelog.jenkins-x.io/initial-release"
+
+	// ReviewersAnnotation the annotation on the Release containing the JSON encoded approving reviewers of each pull request
+	ReviewersAnnotation = "changelog.jenkins-x.io/reviewers"
+
+	// DependencyKindsAnnotation the annotation on the Release containing the JSON encoded kind of each dependency
+	// update found by the dependency analyzers such as 'go' or 'image' indexed by owner/repo:component
+	DependencyKindsAnnotation = "changelog.jenkins-x.io/dependency-kinds"
+
+	// DependencyClassesAnnotation the annotation on the Release containing the JSON encoded classification of each
+	// dependency update such as 'major' or 'security' indexed by owner/repo:component
+	DependencyClassesAnnotation = "changelog.jenkins-x.io/dependency-classes"
+
+	// DependencyUpdatesAnnotation the annotation on the Release containing the comma separated classes of the
+	// dependency updates of the release such as 'major,patch' so that promotions can be gated on them
+	DependencyUpdatesAnnotation = "changelog.jenkins-x.io/dependency-updates"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	// MergePullRequestRegex matches the messages of the merge commits of GitHub and Bitbucket pull requests and GitLab merge requests
+	MergePullRequestRegex = regexp.MustCompile(`(?i)(pull request #\d+|merge request \S*!\d+)`)
+)
+
+// Validate validates the configuration of the generator. The git repository should already have been discovered
+// via the ScmFactory
+func (g *Generator) Validate() error {
+	switch g.Mentions {
+	case "", MentionsNone, MentionsAll, MentionsOrgMembers:
+	default:
+		return options.InvalidOptionf("mentions", g.Mentions, "should be one of %s, %s or %s", MentionsNone, MentionsAll, MentionsOrgMembers)
+	}
+
+	switch g.MergeCommitPolicy {
+	case "":
+		g.MergeCommitPolicy = MergeCommitsExclude
+		if g.IncludeMergeCommits {
+			g.MergeCommitPolicy = MergeCommitsInclude
+		}
+	case MergeCommitsInclude, MergeCommitsExclude, MergeCommitsOnlyPRs:
+	default:
+		return options.InvalidOptionf("merge-commit-policy", g.MergeCommitPolicy, "should be one of %s, %s or %s", MergeCommitsInclude, MergeCommitsExclude, MergeCommitsOnlyPRs)
+	}
+
+	err := ValidateErrorPolicy("on-release-error", g.OnReleaseError)
+	if err != nil {
+		return err
+	}
+	g.State.Channel, err = ParseChannel(g.Channel, g.ChannelTagPattern)
+	if err != nil {
+		return err
+	}
+	err = ValidateIssueURLTemplates(g.IssueURLTemplates)
+	if err != nil {
+		return err
+	}
+	g.State.PerfBudget, err = ParsePerfBudget(g.PerfBudget)
+	if err != nil {
+		return err
+	}
+	g.State.LabelTypes, err = LabelTypes(g.LabelSections)
+	if err != nil {
+		return err
+	}
+	g.State.LabelFilter, err = LabelFilter(g.LabelBadgePatterns)
+	if err != nil {
+		return err
+	}
+	if g.ProjectBoard != "" {
+		_, _, err = parseProjectBoard(g.ProjectBoard)
+		if err != nil {
+			return err
+		}
+	}
+	err = g.validateQuery()
+	if err != nil {
+		return err
+	}
+	err = g.validateTranslation()
+	if err != nil {
+		return err
+	}
+	err = g.validateSigning()
+	if err != nil {
+		return err
+	}
+	err = g.validateReleaseAssets()
+	if err != nil {
+		return err
+	}
+	err = g.loadChecksums()
+	if err != nil {
+		return err
+	}
+	err = g.validateWiki()
+	if err != nil {
+		return err
+	}
+	switch g.SiteFormat {
+	case "", SiteFormatHugo, SiteFormatDocusaurus:
+	default:
+		return options.InvalidOptionf("site-format", g.SiteFormat, "should be %s or %s", SiteFormatHugo, SiteFormatDocusaurus)
+	}
+	err = ValidateErrorPolicy("on-issue-lookup-error", g.OnIssueLookupError)
+	if err != nil {
+		return err
+	}
+
+	g.State.CommitFetcher, err = gits.NewCommitFetcher(g.GitBackend)
+	if err != nil {
+		return options.InvalidOptionf("git-backend", g.GitBackend, "%s", err.Error())
+	}
+	if len(g.Paths) > 0 {
+		err = validatePathsBackend(g.GitBackend, "path")
+		if err != nil {
+			return err
+		}
+		// lets let git skip the commits of other paths via pathspecs rather than walking and diffing every commit
+		g.State.CommitFetcher = &gits.CLICommitFetcher{Paths: g.Paths}
+	}
+
+	err = g.validateComponents()
+	if err != nil {
+		return err
+	}
+
+	g.State.Analyzers = nil
+	for _, name := range g.DependencyAnalyzers {
+		a, err := deps.NewAnalyzer(name)
+		if err != nil {
+			return options.InvalidOptionf("dependency-analyzer", name, "%s", err.Error())
+		}
+		g.State.Analyzers = append(g.State.Analyzers, a)
+	}
+
+	g.State.Renderer, err = NewRenderer(g.Format, g.FormatOptions)
+	if err != nil {
+		return options.InvalidOptionf("format", g.Format, "%s", err.Error())
+	}
+	if g.Format == RendererPDF && !g.UpdateRelease && g.OutputMarkdownFile == "" {
+		return options.InvalidOptionf("format", g.Format, "requires the output file to be specified via --output-markdown")
+	}
+
+	if g.SkipCommitPattern != "" {
+		g.State.SkipCommitRegex, err = regexp.Compile(g.SkipCommitPattern)
+		if err != nil {
+			return options.InvalidOptionf("skip-commit-pattern", g.SkipCommitPattern, "should be a valid regular expression: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// Generate runs all the phases of generating the changelog. If there is no range of revisions to generate the
+// changelog for a nil result is returned. If there are Components they are generated concurrently and merged
+func (g *Generator) Generate(ctx context.Context) (*Result, error) {
+	if len(g.State.Components) > 0 {
+		return g.generateComponents(ctx)
+	}
+	rng, err := g.ResolveRange(ctx)
+	if err != nil || rng == nil {
+		return nil, err
+	}
+	result, err := g.Collect(ctx, rng)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	err = g.Curate(result)
+	if err != nil {
+		return result, err
+	}
+	err = g.Render(ctx, result)
+	if err != nil {
+		return result, err
+	}
+	err = g.Publish(ctx, result)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ResolveRange resolves the previous and current git revisions of the changelog. If there is no previous revision
+// to compare against a nil range is returned or ErrNoPreviousTag if FailIfFindCommits is enabled
+func (g *Generator) ResolveRange(ctx context.Context) (*Range, error) {
+	g.State.Context = ctx
+	dir := g.ScmFactory.Dir
+	if g.Milestone != "" || g.PullRequestsMergedSince != "" || g.PullRequestsMergedUntil != "" {
+		return g.queryRange(), nil
+	}
+
+	var err error
+	rng := &Range{
+		PreviousRev: g.PreviousRevision,
+	}
+	rng.PreviousName = rng.PreviousRev
+	channel := g.State.Channel
+	if channel != nil && rng.PreviousRev == "" && g.PreviousDate == "" {
+		current, previous, err := g.channelRange(channel)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			rng.PreviousRev, rng.PreviousName = previous.SHA, previous.Name
+		}
+		if current != nil && g.CurrentRevision == "" {
+			rng.CurrentRev, rng.CurrentName = current.SHA, current.Name
+		}
+		if previous == nil && !g.FirstRelease {
+			log.Logger().Infof("no previous release of the %s channel found", info(channel.Name))
+		}
+	}
+	if rng.PreviousRev == "" {
+		previousDate := g.PreviousDate
+		if previousDate != "" {
+			rng.PreviousRev, err = gits.GetRevisionBeforeDateText(g.Git(), dir, previousDate)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to find commits before date %s", previousDate)
+			}
+		}
+	}
+	if rng.PreviousRev == "" {
+		if channel == nil {
+			rng.PreviousRev, rng.PreviousName, err = gits.GetCommitPointedToByPreviousTag(g.Git(), dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if rng.PreviousRev == "" && g.FirstRelease {
+			log.Logger().Info("no previous tag found so generating the changelog of the initial release")
+			rng.FirstRelease = true
+		} else if rng.PreviousRev == "" {
+			// lets assume we are the first release
+			rng.PreviousRev, err = gits.GetFirstCommitSha(g.Git(), dir)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to find first commit after we found no previous releaes")
+			}
+			if rng.PreviousRev == "" {
+				if g.FailIfFindCommits {
+					return nil, newError(ErrNoPreviousTag, "no previous tag or commit found in %s", dir)
+				}
+				log.Logger().Info("no previous commit version found so change diff unavailable")
+				return nil, nil
+			}
+		}
+	}
+	if rng.CurrentRev == "" {
+		rng.CurrentRev = g.CurrentRevision
+		rng.CurrentName = rng.CurrentRev
+	}
+	if rng.CurrentRev == "" && channel != nil {
+		// lets release the head of the branch if the channel has no tag yet rather than the tag of another channel
+		rng.CurrentRev = "HEAD"
+	}
+	if rng.CurrentRev == "" {
+		rng.CurrentRev, rng.CurrentName, err = gits.GetCommitPointedToByLatestTag(g.Git(), dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	g.State.DefaultBranch = g.defaultBranch()
+	if rng.CurrentName == "" {
+		rng.CurrentName = g.State.DefaultBranch
+	}
+	return rng, nil
+}
+
+// CreateIssueProvider returns the IssueTracker if specified, the Jira issue provider if there is a JiraServer
+// otherwise creates the issue provider of the git provider
+func (g *Generator) CreateIssueProvider() (issues.IssueProvider, error) {
+	if g.IssueTracker != nil {
+		return g.IssueTracker, nil
+	}
+	if g.JiraServer != "" {
+		username := g.JiraUsername
+		if username == "" {
+			username = os.Getenv(issues.JiraUsernameEnv)
+		}
+		apiToken := g.JiraAPIToken
+		if apiToken == "" {
+			apiToken = os.Getenv(issues.JiraAPITokenEnv)
+		}
+		tracker, err := issues.CreateJiraIssueProvider(g.HTTPClient, g.JiraServer, username, apiToken, g.JiraProject, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the Jira issue provider of %s", g.JiraServer)
+		}
+		return tracker, nil
+	}
+	return issues.CreateGitIssueProvider(g.State.Context, g.ScmFactory.ScmClient, g.ScmFactory.Owner, g.ScmFactory.Repository)
+}
+
+func (g *Generator) Git() gitclient.Interface {
+	if g.GitClient == nil {
+		g.GitClient = cli.NewCLIClient("", g.CommandRunner)
+	}
+	return g.GitClient
+}
+
+// releaseBranch returns the name of the branch being released
+func (g *Generator) releaseBranch() string {
+	branch, err := g.ScmFactory.GetBranch()
+	if err != nil {
+		log.Logger().Warnf("failed to find the current branch: %s", err.Error())
+	}
+	if branch == "" || branch == "HEAD" {
+		branch = g.State.DefaultBranch
+	}
+	return branch
+}
+
+// defaultBranch returns the default branch of the repository from the git provider or the origin remote
+func (g *Generator) defaultBranch() string {
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient != nil && g.ScmFactory.Owner != "" && g.ScmFactory.Repository != "" {
+		fullName := scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository)
+		repo, _, err := scmClient.Repositories.Find(g.State.Context, fullName)
+		if err != nil {
+			log.Logger().Debugf("failed to find repository %s: %s", fullName, err.Error())
+		} else if repo != nil && repo.Branch != "" {
+			return repo.Branch
+		}
+	}
+	branch, err := gits.GetRemoteDefaultBranch(g.Git(), g.ScmFactory.Dir)
+	if err != nil {
+		log.Logger().Debugf("failed to find the default branch: %s", err.Error())
+	}
+	if branch == "" {
+		branch = "master"
+	}
+	return branch
+}
+
+// createMentions returns the function to decide which users are @mentioned or nil if users should be linked
+func (g *Generator) createMentions() func(user *v1.UserDetails) bool {
+	switch g.Mentions {
+	case MentionsAll:
+		return func(user *v1.UserDetails) bool {
+			return !users.IsServiceAccount(user)
+		}
+	case MentionsOrgMembers:
+		members := &users.OrgMembers{
+			GitProvider: g.ScmFactory.ScmClient,
+			Org:         g.ScmFactory.Owner,
+			Ctx:         g.State.Context,
+		}
+		return func(user *v1.UserDetails) bool {
+			return !users.IsServiceAccount(user) && members.IsMember(user.Login)
+		}
+	default:
+		return nil
+	}
+}
+
+// createUserResolver creates the user resolver using the mailmap and alias configuration
+func (g *Generator) createUserResolver(dir string) (*users.GitUserResolver, error) {
+	mailmapFile := g.MailmapFile
+	if mailmapFile == "" {
+		mailmapFile = filepath.Join(dir, users.MailmapFileName)
+	}
+	mailmap, err := users.LoadMailmap(mailmapFile)
+	if err != nil {
+		return nil, err
+	}
+	var aliases *users.Aliases
+	if g.AliasFile != "" {
+		aliases, err = users.LoadAliases(g.AliasFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &users.GitUserResolver{
+		GitProvider: g.ScmFactory.ScmClient,
+		Mailmap:     mailmap,
+		Aliases:     aliases,
+		Repository:  scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository),
+		Ctx:         g.State.Context,
+		SharedCache: g.SharedCache,
+	}, nil
+}
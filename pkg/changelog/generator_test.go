@@ -0,0 +1,131 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorValidate(t *testing.T) {
+	t.Parallel()
+	g := &changelog.Generator{
+		IncludeMergeCommits: true,
+		SkipCommitPattern:   changelog.DefaultSkipCommitPattern,
+	}
+	require.NoError(t, g.Validate())
+	assert.Equal(t, changelog.MergeCommitsInclude, g.MergeCommitPolicy)
+	require.NotNil(t, g.State.SkipCommitRegex)
+	assert.True(t, g.State.SkipCommitRegex.MatchString("chore(release): 1.2.3"))
+
+	assert.Error(t, (&changelog.Generator{Mentions: "some"}).Validate())
+	assert.Error(t, (&changelog.Generator{OnReleaseError: "ignore"}).Validate())
+	assert.Error(t, (&changelog.Generator{SkipCommitPattern: "["}).Validate())
+	assert.Error(t, (&changelog.Generator{Milestone: "v1.4.0", PreviousRevision: "v1.3.0"}).Validate())
+	assert.NoError(t, (&changelog.Generator{Milestone: "v1.4.0"}).Validate())
+	assert.Error(t, (&changelog.Generator{Milestone: "v1.4.0", PullRequestsMergedSince: "2020-07-01"}).Validate())
+	assert.Error(t, (&changelog.Generator{PullRequestsMergedSince: "July"}).Validate())
+	assert.Error(t, (&changelog.Generator{BaseBranch: "main"}).Validate())
+	assert.Error(t, (&changelog.Generator{ProjectBoard: "myorg"}).Validate())
+	assert.NoError(t, (&changelog.Generator{ProjectBoard: "myorg/5"}).Validate())
+	g = &changelog.Generator{PullRequestsMergedSince: "2020-07-01", PullRequestsMergedUntil: "2020-07-31T12:00:00Z"}
+	require.NoError(t, g.Validate())
+	assert.Equal(t, time.Date(2020, time.July, 31, 12, 0, 0, 0, time.UTC), g.State.MergedUntil)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	p := &fakePublisher{name: "custom"}
+	g, err := changelog.New(changelog.WithRange("v1.0.0", "v1.1.0"), changelog.WithTemplates("charts/foo/templates"), changelog.WithPublisher(p))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", g.PreviousRevision)
+	assert.Equal(t, "v1.1.0", g.CurrentRevision)
+	assert.Equal(t, "charts/foo/templates", g.TemplatesDir)
+	assert.Equal(t, []changelog.Publisher{p}, g.Publishers)
+	assert.Equal(t, changelog.MergeCommitsExclude, g.MergeCommitPolicy)
+	assert.True(t, g.UpdateRelease)
+	require.NotNil(t, g.State.Renderer)
+
+	_, err = changelog.New(func(g *changelog.Generator) {
+		g.Format = "docx"
+	})
+	assert.Error(t, err)
+}
+
+func TestCollapseDependencyUpdates(t *testing.T) {
+	t.Parallel()
+	update := func(component, from, to string) v1.DependencyUpdate {
+		return v1.DependencyUpdate{
+			DependencyUpdateDetails: v1.DependencyUpdateDetails{
+				Owner:       "jenkins-x",
+				Repo:        "jx",
+				Component:   component,
+				FromVersion: from,
+				ToVersion:   to,
+			},
+		}
+	}
+	collapsed := changelog.CollapseDependencyUpdates([]v1.DependencyUpdate{
+		update("", "1.0.1", "1.0.2"),
+		update("", "1.0.0", "1.0.1"),
+		update("chart", "2.0.0", "2.1.0"),
+	})
+	require.Len(t, collapsed, 2)
+	assert.Equal(t, "1.0.0", collapsed[0].FromVersion)
+	assert.Equal(t, "1.0.2", collapsed[0].ToVersion)
+	assert.Equal(t, "chart", collapsed[1].Component)
+}
+
+func TestMergeUpstreamReleases(t *testing.T) {
+	t.Parallel()
+	details := func(repo, from, to string) v1.DependencyUpdateDetails {
+		return v1.DependencyUpdateDetails{Host: "github.com", Owner: "jenkins-x", Repo: repo, FromVersion: from, ToVersion: to}
+	}
+	release := func(commit string, issueIDs ...string) *v1.Release {
+		r := &v1.Release{}
+		r.Spec.Commits = []v1.CommitSummary{{Message: commit, SHA: "1234567890"}}
+		for _, id := range issueIDs {
+			r.Spec.Issues = append(r.Spec.Issues, v1.IssueSummary{ID: id, Title: "issue " + id})
+		}
+		return r
+	}
+	latest := release("fix: something", "12")
+	latest.Spec.DependencyUpdates = []v1.DependencyUpdate{{
+		DependencyUpdateDetails: details("jx-api", "1.0.0", "1.1.0"),
+		Paths:                   []v1.DependencyUpdatePath{{details("jx-kube-client", "0.1.0", "0.2.0")}},
+	}}
+	previous := release("feat: another", "12", "13")
+
+	du := &v1.DependencyUpdate{DependencyUpdateDetails: details("jx-helpers", "3.0.0", "3.0.2")}
+	changes := changelog.MergeUpstreamReleases(du, []*v1.Release{latest, previous})
+	require.NotNil(t, changes)
+	require.Len(t, changes.Commits, 2)
+	assert.Equal(t, "fix: something", changes.Commits[0].Message)
+	require.Len(t, changes.Issues, 2)
+	assert.Equal(t, "13", changes.Issues[1].ID)
+	require.Len(t, du.Paths, 2)
+	assert.Equal(t, v1.DependencyUpdatePath{details("jx-api", "1.0.0", "1.1.0")}, du.Paths[0])
+	assert.Equal(t, v1.DependencyUpdatePath{details("jx-api", "1.0.0", "1.1.0"), details("jx-kube-client", "0.1.0", "0.2.0")}, du.Paths[1])
+
+	collapsed := changelog.CollapseDependencyUpdates([]v1.DependencyUpdate{*du})
+	require.Len(t, collapsed, 1)
+	assert.Len(t, collapsed[0].Paths, 2)
+
+	assert.Nil(t, changelog.MergeUpstreamReleases(du, nil))
+}
+
+func TestErrorKinds(t *testing.T) {
+	t.Parallel()
+	err := errors.Wrap(&changelog.Error{Kind: changelog.ErrNoCommits, Err: errors.New("no commits found between revision v1.0.0 and v1.1.0")}, "failed to collect")
+	assert.True(t, errors.Is(err, changelog.ErrNoCommits))
+	assert.False(t, errors.Is(err, changelog.ErrAuth))
+	assert.Equal(t, "failed to collect: no commits found between revision v1.0.0 and v1.1.0", err.Error())
+	assert.Equal(t, changelog.ExitCodeNoCommits, changelog.ExitCode(err))
+	assert.Equal(t, changelog.ExitCodeFailure, changelog.ExitCode(errors.New("something else")))
+}
@@ -0,0 +1,130 @@
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// helmChartVersionRegex splits the chart of 'helm list' output such as 'jx-preview-0.2.0' into its name and version
+var helmChartVersionRegex = regexp.MustCompile(`^(.+)-(v?\d+(\.\d+)*([-+].*)?)$`)
+
+// HelmDiff generates the changelog of the applications whose chart versions changed between two states of the
+// deployed helm releases
+type HelmDiff struct {
+	// Previous the previously deployed applications indexed by EnvironmentApp.Key
+	Previous map[string]EnvironmentApp
+
+	// Current the currently deployed applications indexed by EnvironmentApp.Key
+	Current map[string]EnvironmentApp
+
+	// Sources the URLs of the source repositories of charts indexed by chart name overriding the version stream
+	Sources map[string]string
+
+	// VersionStreamDir if specified the source repositories of the charts are found in the version stream
+	VersionStreamDir string
+
+	// ScmClient if specified the release notes of the applications are fetched from the releases of the git provider
+	ScmClient *scm.Client
+
+	// Context the context of the requests to the git provider
+	Context context.Context
+}
+
+type helmListRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Chart     string `json:"chart"`
+}
+
+// ParseHelmList parses the JSON output of 'helm list --output json' indexed by EnvironmentApp.Key
+func ParseHelmList(text string) (map[string]EnvironmentApp, error) {
+	var releases []helmListRelease
+	err := json.Unmarshal([]byte(text), &releases)
+	if err != nil {
+		return nil, err
+	}
+	answer := map[string]EnvironmentApp{}
+	for _, r := range releases {
+		app := EnvironmentApp{Name: r.Name, Namespace: r.Namespace, Chart: r.Chart}
+		if m := helmChartVersionRegex.FindStringSubmatch(r.Chart); m != nil {
+			app.Chart = m[1]
+			app.Version = m[2]
+		}
+		answer[app.Key()] = app
+	}
+	return answer, nil
+}
+
+// ParseHelmState parses the applications of a helmfile or the JSON output of 'helm list'
+func ParseHelmState(filePath, text string) (map[string]EnvironmentApp, error) {
+	if strings.HasPrefix(strings.TrimSpace(text), "[") {
+		return ParseHelmList(text)
+	}
+	return ParseEnvironmentApps(filePath, text)
+}
+
+// Diff returns the applications whose versions changed along with their source repositories and release notes
+func (h *HelmDiff) Diff() ([]AppChange, error) {
+	changes := DiffEnvironmentApps(h.Previous, h.Current)
+	for i := range changes {
+		c := &changes[i]
+		url, err := h.findGitURL(c.Chart)
+		if err != nil {
+			return nil, err
+		}
+		c.GitURL = url
+		c.ReleaseNotes = providerReleaseNotes(h.Context, h.ScmClient, c)
+	}
+	return changes, nil
+}
+
+// findGitURL returns the source repository of the chart from the sources or the version stream
+func (h *HelmDiff) findGitURL(chart string) (string, error) {
+	name := path.Base(chart)
+	if url := h.Sources[chart]; url != "" {
+		return url, nil
+	}
+	if url := h.Sources[name]; url != "" {
+		return url, nil
+	}
+	if h.VersionStreamDir == "" || name == "" {
+		return "", nil
+	}
+	patterns := []string{filepath.Join("charts", "*", name, "defaults.yaml"), filepath.Join("charts", "*", name+".yml")}
+	if strings.Contains(chart, "/") {
+		patterns = append([]string{filepath.Join("charts", chart, "defaults.yaml"), filepath.Join("charts", chart+".yml")}, patterns...)
+	}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(h.VersionStreamDir, pattern))
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid file pattern %s", pattern)
+		}
+		for _, m := range matches {
+			data, err := ioutil.ReadFile(m)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return "", errors.Wrapf(err, "failed to read %s", m)
+			}
+			f, err := deps.ParseVersionStreamFile(string(data))
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to parse %s", m)
+			}
+			if f.GitURL != "" {
+				return strings.TrimSuffix(f.GitURL, ".git"), nil
+			}
+		}
+	}
+	return "", nil
+}
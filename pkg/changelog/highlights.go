@@ -0,0 +1,83 @@
+package changelog
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultHighlightLabel the label of the issues and pull requests whose commits are highlighted
+const DefaultHighlightLabel = "highlight"
+
+// Highlights returns the SHAs of up to max commits to highlight at the top of the changelog. Commits referencing an
+// issue or pull request with one of the labels come first followed by breaking changes and then the commits of the
+// pull requests with the most commits. Returns nil if the changelog has no more than max commits
+func (c *Changelog) Highlights(max int, labels []string) []string {
+	if max <= 0 || len(c.Commits) <= max {
+		return nil
+	}
+	labelled := map[string]bool{}
+	for _, issue := range append(append([]*Issue{}, c.Issues...), c.PullRequests...) {
+		for _, l := range issue.Labels {
+			for _, label := range labels {
+				if strings.EqualFold(l, label) {
+					labelled[issue.ID] = true
+				}
+			}
+		}
+	}
+	pullRequests := map[string]bool{}
+	for _, pr := range c.PullRequests {
+		pullRequests[pr.ID] = true
+	}
+	prCommits := map[string]int{}
+	for _, commit := range c.Commits {
+		for _, id := range commit.IssueIDs {
+			if pullRequests[id] {
+				prCommits[id]++
+			}
+		}
+	}
+
+	type candidate struct {
+		commit   *Commit
+		labelled bool
+		size     int
+	}
+	var candidates []candidate
+	sized := map[string]bool{}
+	for _, commit := range c.Commits {
+		cand := candidate{commit: commit}
+		for _, id := range commit.IssueIDs {
+			if labelled[id] {
+				cand.labelled = true
+			}
+			// lets only highlight the first commit of each pull request for its size
+			if prCommits[id] > cand.size && !sized[id] {
+				sized[id] = true
+				cand.size = prCommits[id]
+			}
+		}
+		// lets only highlight pull requests made of several commits
+		if cand.size < 2 {
+			cand.size = 0
+		}
+		if cand.labelled || commit.Breaking || cand.size > 0 {
+			candidates = append(candidates, cand)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.labelled != b.labelled {
+			return a.labelled
+		}
+		if a.commit.Breaking != b.commit.Breaking {
+			return a.commit.Breaking
+		}
+		return a.size > b.size
+	})
+	var answer []string
+	for i := 0; i < len(candidates) && i < max; i++ {
+		answer = append(answer, candidates[i].commit.SHA)
+	}
+	return answer
+}
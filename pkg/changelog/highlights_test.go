@@ -0,0 +1,36 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlights(t *testing.T) {
+	t.Parallel()
+	commit := func(sha, message string, issueIDs ...string) *changelog.Commit {
+		c := changelog.NewCommit(sha, message)
+		c.IssueIDs = issueIDs
+		return c
+	}
+	model := &changelog.Changelog{
+		Commits: []*changelog.Commit{
+			commit("111", "fix: a bug"),
+			commit("222", "feat: part one", "20"),
+			commit("333", "feat: part two", "20"),
+			commit("444", "feat!: a breaking change"),
+			commit("555", "feat: a highlight", "12"),
+			commit("666", "chore: tidy", "21"),
+		},
+		Issues:       []*changelog.Issue{{ID: "12", Labels: []string{"Highlight"}}},
+		PullRequests: []*changelog.Issue{{ID: "20", PullRequest: true}, {ID: "21", PullRequest: true}},
+	}
+	assert.Equal(t, []string{"555", "444", "222"}, model.Highlights(5, []string{changelog.DefaultHighlightLabel}))
+	assert.Equal(t, []string{"555", "444"}, model.Highlights(2, []string{changelog.DefaultHighlightLabel}))
+	assert.Equal(t, []string{"444", "222"}, model.Highlights(5, nil))
+	assert.Nil(t, model.Highlights(6, nil), "should not highlight short changelogs")
+	assert.Nil(t, model.Highlights(0, nil))
+}
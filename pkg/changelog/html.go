@@ -0,0 +1,58 @@
+package changelog
+
+import (
+	"github.com/russross/blackfriday"
+)
+
+// HTMLRenderer renders the markdown of the changelog as HTML
+type HTMLRenderer struct {
+	// Page renders a complete HTML page rather than a fragment
+	Page bool
+
+	// Title the title of the page
+	Title string
+
+	// CSS the URL of the stylesheet of the page
+	CSS string
+}
+
+// NewHTMLRenderer creates the HTML renderer. The 'page' option renders a complete page using the 'title' and 'css' options
+func NewHTMLRenderer(options RendererOptions) (Renderer, error) {
+	err := options.Validate("page", "title", "css")
+	if err != nil {
+		return nil, err
+	}
+	page, err := options.Bool("page", false)
+	if err != nil {
+		return nil, err
+	}
+	return &HTMLRenderer{
+		Page:  page,
+		Title: options["title"],
+		CSS:   options["css"],
+	}, nil
+}
+
+// Render renders the changelog as HTML
+func (r *HTMLRenderer) Render(input *RenderInput) (string, error) {
+	markdown, err := (&MarkdownRenderer{}).Render(input)
+	if err != nil {
+		return "", err
+	}
+	title := r.Title
+	if title == "" && input.ReleaseSpec != nil {
+		title = input.ReleaseSpec.Name + " " + input.ReleaseSpec.Version
+	}
+	return MarkdownToHTML(markdown, title, r.CSS, r.Page), nil
+}
+
+// MarkdownToHTML converts the markdown to HTML. If page is true a complete page using the title and stylesheet is returned
+func MarkdownToHTML(markdown, title, css string, page bool) string {
+	flags := blackfriday.HTML_USE_XHTML | blackfriday.HTML_USE_SMARTYPANTS | blackfriday.HTML_SMARTYPANTS_FRACTIONS | blackfriday.HTML_SMARTYPANTS_DASHES | blackfriday.HTML_SMARTYPANTS_LATEX_DASHES
+	if page {
+		flags |= blackfriday.HTML_COMPLETE_PAGE
+	}
+	renderer := blackfriday.HtmlRenderer(flags, title, css)
+	extensions := blackfriday.EXTENSION_NO_INTRA_EMPHASIS | blackfriday.EXTENSION_TABLES | blackfriday.EXTENSION_FENCED_CODE | blackfriday.EXTENSION_AUTOLINK | blackfriday.EXTENSION_STRIKETHROUGH
+	return string(blackfriday.Markdown([]byte(markdown), renderer, extensions))
+}
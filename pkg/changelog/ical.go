@@ -0,0 +1,181 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// CalendarEvent the all day event of a release in an iCalendar file
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Date        time.Time
+	Stamp       time.Time
+}
+
+// UpdateCalendar returns the iCalendar with the event added or replacing the existing event of the same UID. The
+// calendar is created if empty
+func UpdateCalendar(calendar string, event *CalendarEvent) string {
+	var events [][]string
+	var current []string
+	for _, line := range unfoldCalendar(calendar) {
+		switch {
+		case line == "BEGIN:VEVENT":
+			current = []string{line}
+		case line == "END:VEVENT" && current != nil:
+			current = append(current, line)
+			if calendarEventUID(current) != event.UID {
+				events = append(events, current)
+			}
+			current = nil
+		case current != nil:
+			current = append(current, line)
+		}
+	}
+	events = append(events, event.lines())
+
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//jx-changelog//Release Calendar//EN", "CALSCALE:GREGORIAN"}
+	for _, e := range events {
+		lines = append(lines, e...)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var buf strings.Builder
+	for _, line := range lines {
+		buf.WriteString(foldCalendarLine(line))
+		buf.WriteString("\r\n")
+	}
+	return buf.String()
+}
+
+func (e *CalendarEvent) lines() []string {
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + escapeCalendarText(e.UID),
+		"DTSTAMP:" + e.Stamp.UTC().Format("20060102T150405Z"),
+		"DTSTART;VALUE=DATE:" + e.Date.Format("20060102"),
+		"DTEND;VALUE=DATE:" + e.Date.AddDate(0, 0, 1).Format("20060102"),
+		"SUMMARY:" + escapeCalendarText(e.Summary),
+	}
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeCalendarText(e.Description))
+	}
+	if e.URL != "" {
+		lines = append(lines, "URL:"+e.URL)
+	}
+	return append(lines, "TRANSP:TRANSPARENT", "END:VEVENT")
+}
+
+// unfoldCalendar splits the calendar into its logical lines joining the folded continuation lines
+func unfoldCalendar(calendar string) []string {
+	var answer []string
+	for _, line := range strings.Split(strings.ReplaceAll(calendar, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(answer) > 0 {
+			answer[len(answer)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			answer = append(answer, line)
+		}
+	}
+	return answer
+}
+
+func calendarEventUID(lines []string) string {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "UID:") {
+			return strings.TrimPrefix(line, "UID:")
+		}
+	}
+	return ""
+}
+
+// foldCalendarLine folds the line into lines of at most 75 octets without splitting UTF-8 characters
+func foldCalendarLine(line string) string {
+	var buf strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			buf.WriteString("\r\n ")
+			width = 1
+		}
+		buf.WriteRune(r)
+		width += size
+	}
+	return buf.String()
+}
+
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// calendarPublisher adds or updates the event of the release in the calendar file
+type calendarPublisher struct {
+	g *Generator
+}
+
+func (p *calendarPublisher) Name() string {
+	return "calendar"
+}
+
+func (p *calendarPublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	path := g.CalendarFile
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to read the calendar %s", path)
+	}
+	err = ioutil.WriteFile(path, []byte(UpdateCalendar(string(data), g.calendarEvent(result))), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the calendar %s", path)
+	}
+	log.Logger().Infof("generated: %s", info(path))
+	return nil
+}
+
+// calendarEvent creates the event of the release identified by the repository and version
+func (g *Generator) calendarEvent(result *Result) *CalendarEvent {
+	spec := &result.Release.Spec
+	version := strings.TrimPrefix(spec.Version, "v")
+	name := spec.Name
+	if g.State.GitInfo != nil {
+		name = g.State.GitInfo.Name
+	}
+	repository := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(spec.GitHTTPURL, "https://"), "http://"), ".git")
+	if repository == "" {
+		repository = name
+	}
+	now := g.now()
+	event := &CalendarEvent{
+		UID:     fmt.Sprintf("%s@%s", version, repository),
+		Summary: strings.TrimSpace(fmt.Sprintf("%s %s released", name, version)),
+		URL:     spec.ReleaseNotesURL,
+		Date:    now,
+		Stamp:   now,
+	}
+	if result.Changelog != nil {
+		stats := result.Changelog.Stats()
+		event.Description = fmt.Sprintf("%d commits, %d issues and %d pull requests by %d contributors", stats.Commits, stats.Issues, stats.PullRequests, stats.Contributors)
+		if stats.BreakingChanges > 0 {
+			event.Description += fmt.Sprintf(" including %d breaking changes", stats.BreakingChanges)
+		}
+	}
+	if spec.ReleaseNotesURL != "" {
+		if event.Description != "" {
+			event.Description += "\n"
+		}
+		event.Description += "Release notes: " + spec.ReleaseNotesURL
+	}
+	return event
+}
@@ -0,0 +1,91 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateCalendar(t *testing.T) {
+	t.Parallel()
+	event := &changelog.CalendarEvent{
+		UID:         "1.0.0@github.com/acme/foo",
+		Summary:     "foo 1.0.0 released",
+		Description: "3 commits, 1 issues; see the notes",
+		Date:        time.Date(2020, time.September, 13, 0, 0, 0, 0, time.UTC),
+		Stamp:       changelogtest.DefaultTime,
+	}
+	calendar := changelog.UpdateCalendar("", event)
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.Contains(t, calendar, "DTSTART;VALUE=DATE:20200913\r\nDTEND;VALUE=DATE:20200914\r\n")
+	assert.Contains(t, calendar, "DTSTAMP:20200913T122640Z\r\n")
+	assert.Contains(t, calendar, `DESCRIPTION:3 commits\, 1 issues\; see the notes`)
+	assert.True(t, strings.HasSuffix(calendar, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+
+	next := *event
+	next.UID = "1.1.0@github.com/acme/foo"
+	next.Summary = "foo 1.1.0 released with a summary long enough to need folding over several lines of the file"
+	calendar = changelog.UpdateCalendar(calendar, &next)
+	assert.Equal(t, 2, strings.Count(calendar, "BEGIN:VEVENT"))
+	for _, line := range strings.Split(calendar, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	updated := *event
+	updated.Summary = "foo 1.0.0 re-released"
+	calendar = changelog.UpdateCalendar(calendar, &updated)
+	assert.Equal(t, 2, strings.Count(calendar, "BEGIN:VEVENT"))
+	assert.Contains(t, calendar, "SUMMARY:foo 1.0.0 re-released\r\n")
+	assert.NotContains(t, calendar, "SUMMARY:foo 1.0.0 released\r\n")
+	assert.Contains(t, calendar, "several lines")
+}
+
+func TestCalendarFile(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	calendarFile := filepath.Join(tmpDir, "releases.ics")
+	g := &changelog.Generator{
+		CalendarFile: calendarFile,
+		Clock:        changelogtest.NewClock(changelogtest.DefaultTime),
+	}
+	result := &changelog.Result{
+		Range: &changelog.Range{},
+		Changelog: &changelog.Changelog{
+			Commits: []*changelog.Commit{changelogtest.NewCommit("fix: a bug").Build()},
+		},
+		Release: &v1.Release{
+			Spec: v1.ReleaseSpec{
+				Name:            "foo",
+				Version:         "v1.2.3",
+				GitHTTPURL:      "https://github.com/acme/foo",
+				ReleaseNotesURL: "https://github.com/acme/foo/releases/tag/v1.2.3",
+			},
+		},
+		Output: "## Changes\n",
+	}
+	err = g.Publish(context.TODO(), result)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(calendarFile)
+	require.NoError(t, err)
+	calendar := string(data)
+	assert.Contains(t, calendar, "UID:1.2.3@github.com/acme/foo\r\n")
+	assert.Contains(t, calendar, "SUMMARY:foo 1.2.3 released\r\n")
+	assert.Contains(t, calendar, "URL:https://github.com/acme/foo/releases/tag/v1.2.3\r\n")
+	assert.Contains(t, calendar, `DESCRIPTION:1 commits\, 0 issues and 0 pull requests`)
+}
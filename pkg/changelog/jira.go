@@ -0,0 +1,71 @@
+package changelog
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// jiraVersionPublisher creates or updates the version of the release in the Jira project and adds it to the fix
+// versions of the referenced issues so that the Jira releases mirror the git releases
+type jiraVersionPublisher struct {
+	g *Generator
+}
+
+func (p *jiraVersionPublisher) Name() string {
+	return "jira-version"
+}
+
+func (p *jiraVersionPublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	tracker := g.State.Tracker
+	if tracker == nil {
+		tracker = g.IssueTracker
+	}
+	versions, ok := tracker.(issues.VersionProvider)
+	if !ok {
+		return errors.Errorf("the issue tracker does not support project versions")
+	}
+	version := &issues.ProjectVersion{
+		Name:        g.jiraVersionName(result),
+		ReleaseDate: g.now().Format("2006-01-02"),
+		Released:    g.JiraVersionReleased,
+	}
+	if result.Release != nil && result.Release.Spec.ReleaseNotesURL != "" {
+		version.Description = "Release notes: " + result.Release.Spec.ReleaseNotesURL
+	}
+	_, err := versions.EnsureVersion(version)
+	if err != nil {
+		return err
+	}
+	count := 0
+	if result.Changelog != nil {
+		for _, issue := range result.Changelog.Issues {
+			if issue.PullRequest || !refs.JiraIssueRegex.MatchString(issue.ID) {
+				continue
+			}
+			err = versions.AddIssueVersion(issue.ID, version.Name)
+			if err != nil {
+				return err
+			}
+			count++
+		}
+	}
+	log.Logger().Infof("updated the Jira version %s with %d issues", info(version.Name), count)
+	return nil
+}
+
+// jiraVersionName returns the name of the Jira version of the release defaulting to the version without any 'v' prefix
+func (g *Generator) jiraVersionName(result *Result) string {
+	if g.JiraVersionName != "" {
+		return g.JiraVersionName
+	}
+	if result.Release == nil {
+		return strings.TrimPrefix(result.Tag, "v")
+	}
+	return strings.TrimPrefix(result.Release.Spec.Version, "v")
+}
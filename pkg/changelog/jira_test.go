@@ -0,0 +1,117 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVersionTracker struct {
+	*changelogtest.IssueTracker
+	versions    []issues.ProjectVersion
+	fixVersions map[string]string
+}
+
+func (t *fakeVersionTracker) EnsureVersion(version *issues.ProjectVersion) (string, error) {
+	t.versions = append(t.versions, *version)
+	return "10001", nil
+}
+
+func (t *fakeVersionTracker) AddIssueVersion(key, version string) error {
+	t.fixVersions[key] = version
+	return nil
+}
+
+func TestJiraVersion(t *testing.T) {
+	t.Parallel()
+	tracker := &fakeVersionTracker{IssueTracker: changelogtest.NewIssueTracker(), fixVersions: map[string]string{}}
+	g := &changelog.Generator{Clock: changelogtest.NewClock(changelogtest.DefaultTime)}
+	changelog.WithJiraVersion(true)(g)
+	g.State.Tracker = tracker
+
+	result := &changelog.Result{
+		Range: &changelog.Range{},
+		Changelog: &changelog.Changelog{
+			Issues: []*changelog.Issue{
+				changelogtest.NewIssue("PROJ-1", "a bug").Build(),
+				changelogtest.NewIssue("PROJ-2", "a feature").Build(),
+			},
+			PullRequests: []*changelog.Issue{
+				changelogtest.NewIssue("12", "a pull request").AsPullRequest().Build(),
+			},
+		},
+		Release: &v1.Release{
+			Spec: v1.ReleaseSpec{Version: "v1.2.3", ReleaseNotesURL: "https://github.com/acme/foo/releases/tag/v1.2.3"},
+		},
+		Output: "## Changes\n",
+	}
+	err := g.Publish(context.TODO(), result)
+	require.NoError(t, err)
+
+	require.Len(t, tracker.versions, 1)
+	assert.Equal(t, issues.ProjectVersion{
+		Name:        "1.2.3",
+		Description: "Release notes: https://github.com/acme/foo/releases/tag/v1.2.3",
+		ReleaseDate: "2020-09-13",
+		Released:    true,
+	}, tracker.versions[0])
+	assert.Equal(t, map[string]string{"PROJ-1": "1.2.3", "PROJ-2": "1.2.3"}, tracker.fixVersions)
+}
+
+func TestJiraVersionRequiresJira(t *testing.T) {
+	t.Parallel()
+	g := &changelog.Generator{JiraVersion: true}
+	g.State.Tracker = changelogtest.NewIssueTracker()
+	result := &changelog.Result{
+		Range:   &changelog.Range{},
+		Release: &v1.Release{Spec: v1.ReleaseSpec{Version: "v1.2.3"}},
+		Output:  "## Changes\n",
+	}
+	err := g.Publish(context.TODO(), result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support project versions")
+}
+
+func TestJiraIssueTracker(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/search" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issues": [{"key": "PROJ-7", "fields": {"summary": "the login fails", "status": {"name": "Done"},
+			"assignee": {"accountId": "5b10b", "displayName": "John Roe"}}}]}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	dir := initRepo(t)
+	g := newCollectGenerator(t, dir, []*changelogtest.CommitBuilder{changelogtest.NewCommit("fix: the login PROJ-7")}, nil)
+	g.IssueTracker = nil
+	g.JiraServer = server.URL
+	g.JiraProject = "PROJ"
+	g.JiraAPIToken = "my-token"
+
+	ctx := context.Background()
+	result, err := g.Collect(ctx, &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.Len(t, result.Release.Spec.Issues, 1)
+	issue := result.Release.Spec.Issues[0]
+	assert.Equal(t, "PROJ-7", issue.ID)
+	assert.Equal(t, "the login fails", issue.Title)
+	assert.Equal(t, "Done", issue.State)
+	assert.Equal(t, server.URL+"/browse/PROJ-7", issue.URL)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "John Roe", issue.Assignees[0].Name)
+}
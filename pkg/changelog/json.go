@@ -0,0 +1,63 @@
+package changelog
+
+import (
+	"encoding/json"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+)
+
+// JSONRenderer renders the release along with its contributors, reviewers and trailers as a JSON document
+type JSONRenderer struct {
+	// Compact disables the indentation of the document
+	Compact bool
+}
+
+// JSONChangelog the JSON document of the changelog
+type JSONChangelog struct {
+	Release         *v1.ReleaseSpec              `json:"release"`
+	Contributors    []gits.Contributor           `json:"contributors,omitempty"`
+	NewContributors []gits.NewContributor        `json:"newContributors,omitempty"`
+	Reviewers       []gits.Reviewer              `json:"reviewers,omitempty"`
+	Trailers        map[string]map[string]string `json:"trailers,omitempty"`
+	Stats           *Stats                       `json:"stats,omitempty"`
+}
+
+// NewJSONRenderer creates the JSON renderer. The 'compact' option disables indentation
+func NewJSONRenderer(options RendererOptions) (Renderer, error) {
+	err := options.Validate("compact")
+	if err != nil {
+		return nil, err
+	}
+	compact, err := options.Bool("compact", false)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONRenderer{Compact: compact}, nil
+}
+
+// Render renders the changelog as JSON
+func (r *JSONRenderer) Render(input *RenderInput) (string, error) {
+	doc := &JSONChangelog{
+		Release:         input.ReleaseSpec,
+		Contributors:    input.Contributors,
+		NewContributors: input.NewContributors,
+		Reviewers:       input.Reviewers,
+		Trailers:        input.Trailers,
+	}
+	if input.Changelog != nil {
+		doc.Stats = input.Changelog.Stats()
+	}
+	var data []byte
+	var err error
+	if r.Compact {
+		data, err = json.Marshal(doc)
+	} else {
+		data, err = json.MarshalIndent(doc, "", "  ")
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the changelog to JSON")
+	}
+	return string(data) + "\n", nil
+}
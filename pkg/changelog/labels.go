@@ -0,0 +1,150 @@
+package changelog
+
+import (
+	"path"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+)
+
+// LabelTypes resolves the sections of the labels such as 'kind/bug=Bug Fixes' or 'kind/bug=fix' to the Conventional
+// Commits types of the sections indexed by the lower case label
+func LabelTypes(sections map[string]string) (map[string]string, error) {
+	answer := map[string]string{}
+	for label, section := range sections {
+		kind, ok := sectionType(section)
+		if !ok {
+			return nil, options.InvalidOptionf("label-section", label+"="+section, "should map the label to a Conventional Commits type such as fix or the title of its section such as Bug Fixes")
+		}
+		answer[strings.ToLower(label)] = kind
+	}
+	return answer, nil
+}
+
+// sectionType returns the Conventional Commits type of the type or the title of its section
+func sectionType(section string) (string, bool) {
+	section = strings.TrimSpace(section)
+	for kind, group := range gits.ConventionalCommitTitles {
+		if kind != "" && (strings.EqualFold(kind, section) || strings.EqualFold(group.Title, section)) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// CategorizeByLabels sets the type of each commit from the labels of its pull request and issues using the types
+// indexed by the lower case label. The labels of pull requests take precedence over those of issues. The first line of
+// the message of a recategorized commit is rewritten so that it is rendered in the section of its new type
+func (c *Changelog) CategorizeByLabels(types map[string]string) {
+	if len(types) == 0 {
+		return
+	}
+	issues := map[string]*Issue{}
+	for _, issue := range c.Issues {
+		issues[issue.ID] = issue
+	}
+	for _, pr := range c.PullRequests {
+		issues[pr.ID] = pr
+	}
+	for _, commit := range c.Commits {
+		var labels []string
+		if commit.PullRequest != nil {
+			labels = append(labels, commit.PullRequest.Labels...)
+		}
+		for _, pullRequests := range []bool{true, false} {
+			for _, id := range commit.IssueIDs {
+				if issue := issues[id]; issue != nil && issue.PullRequest == pullRequests {
+					labels = append(labels, issue.Labels...)
+				}
+			}
+		}
+		for _, label := range labels {
+			kind, ok := types[strings.ToLower(label)]
+			if !ok {
+				continue
+			}
+			if kind != commit.Type {
+				commit.Type = kind
+				rewriteCommitHeader(commit)
+			}
+			break
+		}
+	}
+}
+
+// DefaultExcludeLabel the label of the pull requests and issues which are left out of the release notes
+const DefaultExcludeLabel = "release-note-none"
+
+// WithoutLabels returns a copy of the changelog without the pull requests and issues which have any of the labels nor
+// the commits referencing them. Returns the changelog itself if nothing is excluded
+func (c *Changelog) WithoutLabels(labels []string) *Changelog {
+	excluded := map[string]bool{}
+	hasLabel := func(issueLabels []string) bool {
+		for _, l := range issueLabels {
+			for _, label := range labels {
+				if strings.EqualFold(l, label) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, issue := range append(append([]*Issue{}, c.Issues...), c.PullRequests...) {
+		if hasLabel(issue.Labels) {
+			excluded[issue.ID] = true
+		}
+	}
+	var commits []*Commit
+	for _, commit := range c.Commits {
+		exclude := commit.PullRequest != nil && hasLabel(commit.PullRequest.Labels)
+		for _, id := range commit.IssueIDs {
+			if excluded[id] {
+				exclude = true
+			}
+		}
+		if !exclude {
+			commits = append(commits, commit)
+		}
+	}
+	if len(excluded) == 0 && len(commits) == len(c.Commits) {
+		return c
+	}
+	var issues, pullRequests []*Issue
+	for _, issue := range referencedIssues(c.Issues, commits) {
+		if !excluded[issue.ID] {
+			issues = append(issues, issue)
+		}
+	}
+	for _, pr := range referencedIssues(c.PullRequests, commits) {
+		if !excluded[pr.ID] {
+			pullRequests = append(pullRequests, pr)
+		}
+	}
+	return &Changelog{Commits: commits, Issues: issues, PullRequests: pullRequests}
+}
+
+// LabelFilter returns the function matching the labels against the glob patterns such as 'kind/*' ignoring case or
+// nil if there are no patterns
+func LabelFilter(patterns []string) (func(label string) bool, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	lower := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		lower[i] = strings.ToLower(pattern)
+		_, err := path.Match(lower[i], "")
+		if err != nil {
+			return nil, options.InvalidOptionf("label-badge-pattern", pattern, "%s", err.Error())
+		}
+	}
+	return func(label string) bool {
+		label = strings.ToLower(label)
+		for _, pattern := range lower {
+			if matched, _ := path.Match(pattern, label); matched {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
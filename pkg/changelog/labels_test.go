@@ -0,0 +1,95 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategorizeByLabels(t *testing.T) {
+	t.Parallel()
+	types, err := changelog.LabelTypes(map[string]string{"kind/bug": "Bug Fixes", "Kind/Feature": "feat", "kind/docs": "documentation"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kind/bug": "fix", "kind/feature": "feat", "kind/docs": "docs"}, types)
+	_, err = changelog.LabelTypes(map[string]string{"kind/bug": "Bugs"})
+	assert.Error(t, err)
+
+	commit := func(sha, message string, issueIDs ...string) *changelog.Commit {
+		c := changelog.NewCommit(sha, message)
+		c.IssueIDs = issueIDs
+		return c
+	}
+	squashed := commit("333", "Make the cache faster (#21)\n\nSome details")
+	squashed.PullRequest = &changelog.CommitPullRequest{ID: "21", Number: 21, Labels: []string{"kind/feature"}}
+	model := &changelog.Changelog{
+		Commits: []*changelog.Commit{
+			commit("111", "chore(cli)!: handle the crash", "10", "20"),
+			commit("222", "feat: something", "11"),
+			squashed,
+			commit("444", "fix: unlabelled"),
+		},
+		Issues: []*changelog.Issue{
+			{ID: "10", Labels: []string{"kind/docs"}},
+			{ID: "11", Labels: []string{"other"}},
+		},
+		PullRequests: []*changelog.Issue{{ID: "20", PullRequest: true, Labels: []string{"Kind/Bug"}}},
+	}
+	model.CategorizeByLabels(types)
+
+	assert.Equal(t, "fix", model.Commits[0].Type, "the labels of pull requests should take precedence over issues")
+	assert.Equal(t, "fix(cli)!: handle the crash", model.Commits[0].Message)
+	assert.Equal(t, "feat: something", model.Commits[1].Message)
+	assert.Equal(t, "feat: Make the cache faster (#21)\n\nSome details", model.Commits[2].Message)
+	assert.Equal(t, "fix: unlabelled", model.Commits[3].Message)
+}
+
+func TestWithoutLabels(t *testing.T) {
+	t.Parallel()
+	squashed := changelog.NewCommit("333", "docs: typo (#21)")
+	squashed.PullRequest = &changelog.CommitPullRequest{ID: "21", Number: 21, Labels: []string{"Release-Note-None"}}
+	kept := changelog.NewCommit("111", "feat: something")
+	kept.IssueIDs = []string{"10"}
+	excluded := changelog.NewCommit("222", "chore: tidy")
+	excluded.IssueIDs = []string{"11", "20"}
+	model := &changelog.Changelog{
+		Commits: []*changelog.Commit{kept, excluded, squashed},
+		Issues: []*changelog.Issue{
+			{ID: "10"},
+			{ID: "11"},
+		},
+		PullRequests: []*changelog.Issue{
+			{ID: "20", PullRequest: true, Labels: []string{changelog.DefaultExcludeLabel}},
+			{ID: "21", PullRequest: true},
+		},
+	}
+
+	notes := model.WithoutLabels([]string{changelog.DefaultExcludeLabel})
+	require.Len(t, notes.Commits, 1)
+	assert.Equal(t, "111", notes.Commits[0].SHA)
+	require.Len(t, notes.Issues, 1)
+	assert.Equal(t, "10", notes.Issues[0].ID)
+	assert.Empty(t, notes.PullRequests)
+	assert.Len(t, model.Commits, 3, "the changelog should not be modified")
+
+	assert.Same(t, model, model.WithoutLabels([]string{"other"}))
+}
+
+func TestLabelFilter(t *testing.T) {
+	t.Parallel()
+	filter, err := changelog.LabelFilter(nil)
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	filter, err = changelog.LabelFilter([]string{"kind/*", "Severity/*"})
+	require.NoError(t, err)
+	assert.True(t, filter("kind/bug"))
+	assert.True(t, filter("severity/High"))
+	assert.False(t, filter("lifecycle/stale"))
+
+	_, err = changelog.LabelFilter([]string{"kind/["})
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package changelog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+)
+
+const (
+	// IssueTemplateGit the key of the URL template of git provider issue mentions such as '#123'
+	IssueTemplateGit = "#"
+
+	// IssueTemplateID the placeholder of the issue ID in the URL templates
+	IssueTemplateID = "{id}"
+)
+
+var (
+	issueTemplateKeyRegex = regexp.MustCompile(`^[A-Z][A-Z0-9]+$`)
+
+	// mentionRegex matches the text which must not be linked such as code, links and comments before the bare
+	// '#123' and 'PROJ-456' mentions along with the character preceding them
+	mentionRegex = regexp.MustCompile("(?s)(```.*?```|`[^`\n]*`|<!--.*?-->|\\[[^\\]\n]*\\]\\([^)\n]*\\)|<[^>\n]+>|https?://[^\\s)]+)" +
+		`|(^|[^\w&/#\[-])(#(\d+)|([A-Z][A-Z0-9]+)-\d+)\b`)
+)
+
+// ValidateIssueURLTemplates validates the URL templates of the issue mentions indexed by '#' for git provider issues
+// or the project key such as 'PROJ' for 'PROJ-456'
+func ValidateIssueURLTemplates(templates map[string]string) error {
+	for k, v := range templates {
+		if k != IssueTemplateGit && !issueTemplateKeyRegex.MatchString(k) {
+			return options.InvalidOptionf("issue-url-template", k, "should be %s or an upper case project key such as PROJ", IssueTemplateGit)
+		}
+		if !strings.Contains(v, IssueTemplateID) {
+			return options.InvalidOptionf("issue-url-template", v, "should contain the %s placeholder", IssueTemplateID)
+		}
+	}
+	return nil
+}
+
+// LinkIssueMentions returns the markdown with the bare '#123' and 'PROJ-456' mentions linked via the URL function.
+// Mentions inside links, code and comments are left alone as are those the function returns no URL for
+func LinkIssueMentions(markdown string, issueURL func(key, id string) string) string {
+	var buf strings.Builder
+	last := 0
+	for _, m := range mentionRegex.FindAllStringSubmatchIndex(markdown, -1) {
+		if m[2] >= 0 {
+			// lets skip code, links and comments
+			continue
+		}
+		mention := markdown[m[6]:m[7]]
+		key, id := IssueTemplateGit, mention
+		if m[8] >= 0 {
+			id = markdown[m[8]:m[9]]
+		} else {
+			key = markdown[m[10]:m[11]]
+		}
+		url := issueURL(key, id)
+		if url == "" {
+			continue
+		}
+		buf.WriteString(markdown[last:m[6]])
+		buf.WriteString(markdownLink(mention, url))
+		last = m[7]
+	}
+	buf.WriteString(markdown[last:])
+	return buf.String()
+}
+
+// linkIssueMentions links the bare issue mentions of the markdown if enabled using the URL templates or failing
+// that the issue tracker if it is of the same kind as the mention
+func (g *Generator) linkIssueMentions(markdown string) string {
+	if !g.LinkIssues {
+		return markdown
+	}
+	tracker := g.State.Tracker
+	return LinkIssueMentions(markdown, func(key, id string) string {
+		tmpl := g.IssueURLTemplates[key]
+		if tmpl != "" {
+			return strings.ReplaceAll(tmpl, IssueTemplateID, id)
+		}
+		if tracker == nil {
+			return ""
+		}
+		kind := issues.GetIssueProvider(tracker)
+		_, err := strconv.Atoi(id)
+		numeric := err == nil
+		if (kind == issues.Git) != numeric {
+			return ""
+		}
+		return tracker.IssueURL(id)
+	})
+}
+
+func markdownLink(text, url string) string {
+	return "[" + text + "](" + url + ")"
+}
@@ -0,0 +1,36 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkIssueMentions(t *testing.T) {
+	t.Parallel()
+	issueURL := func(key, id string) string {
+		switch key {
+		case changelog.IssueTemplateGit:
+			return "https://github.com/myorg/myrepo/issues/" + id
+		case "PROJ":
+			return "https://jira.acme.com/browse/" + id
+		}
+		return ""
+	}
+	markdown := "### Bug Fixes\n\n" +
+		"* [#12](https://github.com/myorg/myrepo/issues/12) fixes #34, PROJ-456 and OTHER-1 (Jane)\n" +
+		"* see `#56` and https://github.com/myorg/myrepo/pull/78#issuecomment-9 or &#90; and a/#11\n" +
+		"\n```\n#13 PROJ-7\n```\n<!-- #14 -->\n#15"
+	expected := "### Bug Fixes\n\n" +
+		"* [#12](https://github.com/myorg/myrepo/issues/12) fixes [#34](https://github.com/myorg/myrepo/issues/34), [PROJ-456](https://jira.acme.com/browse/PROJ-456) and OTHER-1 (Jane)\n" +
+		"* see `#56` and https://github.com/myorg/myrepo/pull/78#issuecomment-9 or &#90; and a/#11\n" +
+		"\n```\n#13 PROJ-7\n```\n<!-- #14 -->\n[#15](https://github.com/myorg/myrepo/issues/15)"
+	assert.Equal(t, expected, changelog.LinkIssueMentions(markdown, issueURL))
+
+	assert.NoError(t, changelog.ValidateIssueURLTemplates(map[string]string{"#": "https://bugs.acme.com/{id}", "PROJ": "https://jira.acme.com/browse/{id}"}))
+	assert.Error(t, changelog.ValidateIssueURLTemplates(map[string]string{"proj": "https://jira.acme.com/browse/{id}"}))
+	assert.Error(t, changelog.ValidateIssueURLTemplates(map[string]string{"PROJ": "https://jira.acme.com/browse/"}))
+}
@@ -0,0 +1,126 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+const (
+	// LintRuleConventional the message must use the Conventional Commits format
+	LintRuleConventional = "conventional"
+
+	// LintRuleType the type must be one of the allowed types
+	LintRuleType = "type"
+
+	// LintRuleScope the scope must be one of the allowed scopes or is required
+	LintRuleScope = "scope"
+
+	// LintRuleSubject the subject must not be empty
+	LintRuleSubject = "subject"
+
+	// LintRuleSubjectLength the first line of the message must not be longer than the maximum length
+	LintRuleSubjectLength = "subject-length"
+
+	// LintRuleBreakingChange breaking change footers must be 'BREAKING CHANGE: <description>'
+	LintRuleBreakingChange = "breaking-change"
+)
+
+var (
+	// breakingChangeFooterRegex matches footers which look like breaking changes in any case
+	breakingChangeFooterRegex = regexp.MustCompile(`(?i)^\s*breaking[ _-]?changes?\s*:`)
+
+	// validBreakingChangeFooterRegex matches the breaking change footers of the Conventional Commits specification
+	validBreakingChangeFooterRegex = regexp.MustCompile(`^BREAKING[ -]CHANGE: \S`)
+)
+
+// LintRules the rules commit messages are validated against
+type LintRules struct {
+	// Types the allowed Conventional Commits types. Defaults to DefaultLintTypes
+	Types []string
+
+	// Scopes the allowed scopes. Any scope is allowed if empty
+	Scopes []string
+
+	// RequireScope fails commits without a scope
+	RequireScope bool
+
+	// MaxSubjectLength the maximum length of the first line of the message. Not checked if zero
+	MaxSubjectLength int
+
+	// AllowNonConventional only lints commits using the Conventional Commits format
+	AllowNonConventional bool
+}
+
+// LintViolation a rule violated by a commit
+type LintViolation struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// DefaultLintTypes returns the sorted Conventional Commits types of the changelog sections
+func DefaultLintTypes() []string {
+	var answer []string
+	for kind := range gits.ConventionalCommitTitles {
+		if kind != "" {
+			answer = append(answer, kind)
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// Lint returns the rules violated by the commit message
+func (r *LintRules) Lint(sha, message string) []LintViolation {
+	c := NewCommit(sha, message)
+	firstLine := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	var answer []LintViolation
+	add := func(rule, msg string, args ...interface{}) {
+		answer = append(answer, LintViolation{SHA: sha, Subject: firstLine, Rule: rule, Message: fmt.Sprintf(msg, args...)})
+	}
+	if r.MaxSubjectLength > 0 && len(firstLine) > r.MaxSubjectLength {
+		add(LintRuleSubjectLength, "the subject is %d characters long which is more than %d", len(firstLine), r.MaxSubjectLength)
+	}
+	if c.Type == "" {
+		if !r.AllowNonConventional {
+			add(LintRuleConventional, "the subject should be of the form 'type(scope): subject'")
+		}
+		return answer
+	}
+
+	types := r.Types
+	if len(types) == 0 {
+		types = DefaultLintTypes()
+	}
+	if stringhelpers.StringArrayIndex(types, c.Type) < 0 {
+		add(LintRuleType, "the type %s should be one of %s", c.Type, strings.Join(types, ", "))
+	}
+	if c.Scope == "" {
+		if r.RequireScope {
+			add(LintRuleScope, "a scope is required")
+		}
+	} else if len(r.Scopes) > 0 && stringhelpers.StringArrayIndex(r.Scopes, c.Scope) < 0 {
+		add(LintRuleScope, "the scope %s should be one of %s", c.Scope, strings.Join(r.Scopes, ", "))
+	}
+	if strings.TrimSpace(c.Subject) == "" {
+		add(LintRuleSubject, "the subject is empty")
+	}
+
+	lines := strings.Split(message, "\n")
+	for _, line := range lines[1:] {
+		if !breakingChangeFooterRegex.MatchString(line) {
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if !validBreakingChangeFooterRegex.MatchString(line) {
+			add(LintRuleBreakingChange, "the footer %q should be 'BREAKING CHANGE: <description>'", line)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,44 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintRules(t *testing.T) {
+	t.Parallel()
+	rules := func(r changelog.LintRules) func(message string) []string {
+		return func(message string) []string {
+			var answer []string
+			for _, v := range r.Lint("123", message) {
+				answer = append(answer, v.Rule)
+			}
+			return answer
+		}
+	}
+
+	lint := rules(changelog.LintRules{MaxSubjectLength: 30})
+	assert.Empty(t, lint("feat: something new"))
+	assert.Empty(t, lint("fix(cli)!: drop a flag\n\nBREAKING CHANGE: the --foo flag is removed"))
+	assert.Empty(t, lint("fix: something\n\nBreaking changes are described in the docs"))
+	assert.Equal(t, []string{changelog.LintRuleConventional}, lint("did some stuff"))
+	assert.Equal(t, []string{changelog.LintRuleType}, lint("feature: something"))
+	assert.Equal(t, []string{changelog.LintRuleSubject}, lint("fix: "))
+	assert.Equal(t, []string{changelog.LintRuleSubjectLength}, lint("fix: a subject which is far too long"))
+	assert.Equal(t, []string{changelog.LintRuleBreakingChange}, lint("feat: something\n\nbreaking change: lower case"))
+	assert.Equal(t, []string{changelog.LintRuleBreakingChange}, lint("feat: something\n\nBREAKING CHANGE:"))
+
+	lint = rules(changelog.LintRules{Types: []string{"feat", "fix"}, Scopes: []string{"cli", "api"}, RequireScope: true, AllowNonConventional: true})
+	assert.Empty(t, lint("did some stuff"))
+	assert.Empty(t, lint("feat(api): something"))
+	assert.Equal(t, []string{changelog.LintRuleType}, lint("chore(cli): something"))
+	assert.Equal(t, []string{changelog.LintRuleScope}, lint("feat: something"))
+	assert.Equal(t, []string{changelog.LintRuleScope}, lint("feat(ui): something"))
+
+	assert.Contains(t, changelog.DefaultLintTypes(), "feat")
+	assert.NotContains(t, changelog.DefaultLintTypes(), "")
+}
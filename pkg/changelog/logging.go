@@ -0,0 +1,42 @@
+package changelog
+
+import (
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LogKeyPreviousRevision the log field of the previous git revision of the changelog
+	LogKeyPreviousRevision = "previousRev"
+
+	// LogKeyCurrentRevision the log field of the current git revision of the changelog
+	LogKeyCurrentRevision = "currentRev"
+
+	// LogKeyVersion the log field of the version being released
+	LogKeyVersion = "version"
+
+	// LogKeyCommits the log field of the number of commits in the changelog
+	LogKeyCommits = "commits"
+
+	// LogKeyIssues the log field of the number of issues in the changelog
+	LogKeyIssues = "issues"
+
+	// LogKeyPullRequests the log field of the number of pull requests in the changelog
+	LogKeyPullRequests = "pullRequests"
+
+	// LogKeyReleaseURL the log field of the URL of the release notes
+	LogKeyReleaseURL = "releaseURL"
+)
+
+// LogFields returns the structured log fields summarising the generated changelog
+func LogFields(spec *v1.ReleaseSpec, previousRev, currentRev string) logrus.Fields {
+	return logrus.Fields{
+		LogKeyPreviousRevision: previousRev,
+		LogKeyCurrentRevision:  currentRev,
+		LogKeyVersion:          spec.Version,
+		LogKeyCommits:          len(spec.Commits),
+		LogKeyIssues:           len(spec.Issues),
+		LogKeyPullRequests:     len(spec.PullRequests),
+		LogKeyReleaseURL:       spec.ReleaseNotesURL,
+	}
+}
@@ -0,0 +1,39 @@
+package changelog
+
+import (
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+)
+
+// MarkdownRenderer renders the changelog as markdown. It is used for the release on the git provider whatever the
+// chosen output format
+type MarkdownRenderer struct{}
+
+// NewMarkdownRenderer creates the markdown renderer which has no options
+func NewMarkdownRenderer(options RendererOptions) (Renderer, error) {
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &MarkdownRenderer{}, nil
+}
+
+// Render renders the changelog as markdown
+func (r *MarkdownRenderer) Render(input *RenderInput) (string, error) {
+	if input.BodyTemplate {
+		return input.Header + input.Body + input.Footer, nil
+	}
+	gitInfo := input.GitInfo
+	markdownOptions := input.MarkdownOptions
+	markdown, err := gits.GenerateMarkdownWithOptions(input.ReleaseSpec, gitInfo, markdownOptions)
+	if err != nil {
+		return "", err
+	}
+	markdown += gits.GenerateNewContributorsMarkdown(input.NewContributors, gitInfo, markdownOptions)
+	if input.ContributorsSection {
+		markdown += gits.GenerateContributorsMarkdown(input.Contributors, gitInfo, markdownOptions)
+	}
+	if input.ReviewersSection {
+		markdown += gits.GenerateReviewersMarkdown(input.Reviewers, gitInfo, markdownOptions)
+	}
+	return input.Header + markdown + input.Footer, nil
+}
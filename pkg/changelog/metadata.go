@@ -0,0 +1,93 @@
+package changelog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MetadataMarker the prefix of the hidden comment containing the metadata of a generated release body
+const MetadataMarker = "jx-changelog:metadata"
+
+var metadataRegex = regexp.MustCompile(`(?s)\n*<!-- ` + regexp.QuoteMeta(MetadataMarker) + ` (.*?) -->\n*`)
+
+// ReleaseMetadata the machine readable metadata embedded as a hidden comment in the published release body so that
+// later runs can tell generated release notes apart from hand edited ones
+type ReleaseMetadata struct {
+	Version          string `json:"version,omitempty"`
+	PreviousRev      string `json:"previousRev,omitempty"`
+	CurrentRev       string `json:"currentRev,omitempty"`
+	GeneratorVersion string `json:"generatorVersion,omitempty"`
+
+	// Digest the SHA256 of the generated content without the metadata
+	Digest string `json:"digest"`
+}
+
+// AddMetadata returns the markdown with the metadata appended as a hidden comment replacing any existing metadata.
+// The digest of the metadata is calculated from the markdown
+func AddMetadata(markdown string, metadata ReleaseMetadata) (string, error) {
+	content := stripMetadata(markdown)
+	metadata.Digest = contentDigest(content)
+
+	// json.Marshal escapes '>' so the payload cannot terminate the comment
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the release metadata")
+	}
+	return strings.TrimRight(content, "\n") + "\n\n<!-- " + MetadataMarker + " " + string(data) + " -->\n", nil
+}
+
+// ParseMetadata returns the metadata of the release body along with the content without any metadata. The metadata
+// is nil if the body was not generated
+func ParseMetadata(body string) (*ReleaseMetadata, string, error) {
+	matches := metadataRegex.FindAllStringSubmatch(body, -1)
+	if len(matches) == 0 {
+		return nil, body, nil
+	}
+	content := stripMetadata(body)
+
+	// the metadata of the release is appended after that of any embedded release notes
+	metadata := &ReleaseMetadata{}
+	err := json.Unmarshal([]byte(matches[len(matches)-1][1]), metadata)
+	if err != nil {
+		return nil, content, errors.Wrap(err, "failed to parse the release metadata")
+	}
+	return metadata, content, nil
+}
+
+// stripMetadata removes the metadata comments from the release body
+func stripMetadata(body string) string {
+	if !strings.Contains(body, MetadataMarker) {
+		return body
+	}
+	return strings.TrimRight(metadataRegex.ReplaceAllString(body, "\n\n"), "\n") + "\n"
+}
+
+// Edited returns true if the content no longer matches the digest of the generated content
+func (m *ReleaseMetadata) Edited(content string) bool {
+	return m.Digest != contentDigest(content)
+}
+
+func contentDigest(content string) string {
+	// git providers may return the body with windows line endings
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	sum := sha256.Sum256([]byte(strings.TrimSpace(content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// releaseMetadata returns the metadata of the generated release body
+func (g *Generator) releaseMetadata(result *Result) ReleaseMetadata {
+	answer := ReleaseMetadata{
+		Version:          result.Release.Spec.Version,
+		GeneratorVersion: g.GeneratorVersion,
+	}
+	if result.Range != nil {
+		answer.PreviousRev = result.Range.PreviousRev
+		answer.CurrentRev = result.Range.CurrentRev
+	}
+	return answer
+}
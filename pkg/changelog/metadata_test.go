@@ -0,0 +1,45 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseMetadata(t *testing.T) {
+	t.Parallel()
+	markdown := "### New Features\n\n* something new <b>-->\n"
+	body, err := changelog.AddMetadata(markdown, changelog.ReleaseMetadata{Version: "1.2.0", PreviousRev: "v1.1.0", CurrentRev: "abc1234", GeneratorVersion: "0.1.0"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(body, markdown+"\n<!-- jx-changelog:metadata {"), "body %s", body)
+	assert.Equal(t, 1, strings.Count(body[len(markdown):], "-->"), "the payload should not close the comment")
+
+	metadata, content, err := changelog.ParseMetadata(body)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "1.2.0", metadata.Version)
+	assert.Equal(t, "v1.1.0", metadata.PreviousRev)
+	assert.Equal(t, "0.1.0", metadata.GeneratorVersion)
+	assert.Equal(t, markdown, content)
+	assert.False(t, metadata.Edited(content))
+	assert.False(t, metadata.Edited(strings.ReplaceAll(content, "\n", "\r\n")), "should ignore windows line endings")
+	assert.True(t, metadata.Edited(content+"\nsome hand written notes\n"))
+
+	// lets regenerate the body replacing the metadata
+	regenerated, err := changelog.AddMetadata(body, changelog.ReleaseMetadata{Version: "1.2.1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(regenerated, changelog.MetadataMarker))
+	metadata, _, err = changelog.ParseMetadata(regenerated)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.1", metadata.Version)
+
+	metadata, content, err = changelog.ParseMetadata(markdown)
+	require.NoError(t, err)
+	assert.Nil(t, metadata, "hand written release notes have no metadata")
+	assert.Equal(t, markdown, content)
+}
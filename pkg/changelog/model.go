@@ -0,0 +1,225 @@
+package changelog
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+)
+
+var (
+	conventionalCommitRegex = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.*)$`)
+	breakingChangeRegex     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+)
+
+// Changelog the model of the changes in a release collected from the git history and issue tracker. It is projected
+// into the v1.ReleaseSpec of the Release so that rendering features do not depend on extending the jx-api types
+type Changelog struct {
+	// Commits the commits of the release in the order of the git history
+	Commits []*Commit `json:"commits"`
+
+	// Issues the issues referenced by the commits
+	Issues []*Issue `json:"issues,omitempty"`
+
+	// PullRequests the pull requests referenced by the commits
+	PullRequests []*Issue `json:"pullRequests,omitempty"`
+}
+
+// Commit a git commit of the release along with its parsed Conventional Commits message
+type Commit struct {
+	SHA       string          `json:"sha"`
+	URL       string          `json:"url,omitempty"`
+	Branch    string          `json:"branch,omitempty"`
+	Message   string          `json:"message"`
+	Author    *v1.UserDetails `json:"author,omitempty"`
+	Committer *v1.UserDetails `json:"committer,omitempty"`
+
+	// AuthorEmail the email of the author in the git commit before any mailmap or alias is applied
+	AuthorEmail string `json:"authorEmail,omitempty"`
+
+	// Type the Conventional Commits type such as 'feat' or 'fix'. Empty if the message is not a conventional commit
+	Type string `json:"type,omitempty"`
+
+	// Scope the optional scope of the conventional commit such as 'cli' in 'feat(cli): something'
+	Scope string `json:"scope,omitempty"`
+
+	// Subject the message of the first line without the type and scope
+	Subject string `json:"subject"`
+
+	// Breaking true if the commit is marked as a breaking change via '!' or a 'BREAKING CHANGE:' footer
+	Breaking bool `json:"breaking,omitempty"`
+
+	// IssueIDs the IDs of the issues and pull requests referenced by the commit
+	IssueIDs []string `json:"issueIds,omitempty"`
+
+	// Trailers the trailers of the commit message such as 'Signed-off-by'
+	Trailers map[string]string `json:"trailers,omitempty"`
+
+	// PullRequest the pull request which merged the commit found via the git provider even if it was squashed or
+	// rebased. Only looked up if enabled
+	PullRequest *CommitPullRequest `json:"pullRequest,omitempty"`
+
+	// Reworks the SHAs of the commits reverting and reapplying the commit which were collapsed into it
+	Reworks []string `json:"reworks,omitempty"`
+}
+
+// CommitPullRequest the pull request associated with a commit
+type CommitPullRequest struct {
+	ID     string   `json:"id"`
+	Number int      `json:"number"`
+	Title  string   `json:"title"`
+	URL    string   `json:"url,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Issue an issue or pull request referenced by the commits of the release
+type Issue struct {
+	ID          string           `json:"id"`
+	URL         string           `json:"url,omitempty"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body,omitempty"`
+	State       string           `json:"state,omitempty"`
+	User        *v1.UserDetails  `json:"user,omitempty"`
+	ClosedBy    *v1.UserDetails  `json:"closedBy,omitempty"`
+	Assignees   []v1.UserDetails `json:"assignees,omitempty"`
+	Labels      []string         `json:"labels,omitempty"`
+	Milestone   string           `json:"milestone,omitempty"`
+	Created     time.Time        `json:"created"`
+	PullRequest bool             `json:"pullRequest,omitempty"`
+}
+
+// CommitGroup the commits of the release of a Conventional Commits type
+type CommitGroup struct {
+	// Type the Conventional Commits type of the commits. Empty for commits not using Conventional Commits
+	Type string
+
+	// Title the title of the group such as 'Bug Fixes'
+	Title string
+
+	Commits []*Commit
+}
+
+// Stats the statistics of the release
+type Stats struct {
+	Commits         int            `json:"commits"`
+	BreakingChanges int            `json:"breakingChanges"`
+	Issues          int            `json:"issues"`
+	PullRequests    int            `json:"pullRequests"`
+	Contributors    int            `json:"contributors"`
+	Types           map[string]int `json:"types,omitempty"`
+}
+
+// NewCommit creates the commit parsing the Conventional Commits type, scope and breaking change markers of the message
+func NewCommit(sha, message string) *Commit {
+	answer := &Commit{
+		SHA:      sha,
+		Message:  message,
+		Trailers: gits.ParseTrailers(message),
+	}
+	firstLine := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	answer.Subject = firstLine
+	m := conventionalCommitRegex.FindStringSubmatch(firstLine)
+	if m != nil {
+		answer.Type = strings.ToLower(m[1])
+		answer.Scope = strings.TrimSpace(m[2])
+		answer.Breaking = m[3] == "!"
+		answer.Subject = m[4]
+	}
+	if breakingChangeRegex.MatchString(message) {
+		answer.Breaking = true
+	}
+	return answer
+}
+
+// Groups returns the commits grouped by their Conventional Commits type in the order of the changelog sections
+func (c *Changelog) Groups() []CommitGroup {
+	var answer []CommitGroup
+	indexes := map[string]int{}
+	for _, commit := range c.Commits {
+		idx, ok := indexes[commit.Type]
+		if !ok {
+			idx = len(answer)
+			indexes[commit.Type] = idx
+			group := CommitGroup{Type: commit.Type}
+			if g := gits.ConventionalCommitTitles[commit.Type]; g != nil {
+				group.Title = g.Title
+			}
+			answer = append(answer, group)
+		}
+		answer[idx].Commits = append(answer[idx].Commits, commit)
+	}
+	order := func(kind string) int {
+		if g := gits.ConventionalCommitTitles[kind]; g != nil {
+			return g.Order
+		}
+		return len(gits.ConventionalCommitTitles) + 1
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return order(answer[i].Type) < order(answer[j].Type)
+	})
+	return answer
+}
+
+// Stats returns the statistics of the release
+func (c *Changelog) Stats() *Stats {
+	answer := &Stats{
+		Commits:      len(c.Commits),
+		Issues:       len(c.Issues),
+		PullRequests: len(c.PullRequests),
+		Types:        map[string]int{},
+	}
+	for _, commit := range c.Commits {
+		if commit.Breaking {
+			answer.BreakingChanges++
+		}
+		if commit.Type != "" {
+			answer.Types[commit.Type]++
+		}
+	}
+	spec := &v1.ReleaseSpec{}
+	c.ProjectInto(spec)
+	answer.Contributors = len(gits.Contributors(spec))
+	return answer
+}
+
+// ProjectInto projects the commits, issues and pull requests into the ReleaseSpec
+func (c *Changelog) ProjectInto(spec *v1.ReleaseSpec) {
+	spec.Commits = make([]v1.CommitSummary, 0, len(c.Commits))
+	for _, commit := range c.Commits {
+		spec.Commits = append(spec.Commits, v1.CommitSummary{
+			Message:   commit.Message,
+			URL:       commit.URL,
+			SHA:       commit.SHA,
+			Author:    commit.Author,
+			Branch:    commit.Branch,
+			Committer: commit.Committer,
+			IssueIDs:  commit.IssueIDs,
+		})
+	}
+	spec.Issues = toIssueSummaries(c.Issues)
+	spec.PullRequests = toIssueSummaries(c.PullRequests)
+}
+
+func toIssueSummaries(issues []*Issue) []v1.IssueSummary {
+	answer := make([]v1.IssueSummary, 0, len(issues))
+	for _, issue := range issues {
+		created := issue.Created
+		answer = append(answer, v1.IssueSummary{
+			ID:                issue.ID,
+			URL:               issue.URL,
+			Title:             issue.Title,
+			Body:              issue.Body,
+			State:             issue.State,
+			User:              issue.User,
+			CreationTimestamp: kube.ToMetaTime(&created),
+			ClosedBy:          issue.ClosedBy,
+			Assignees:         issue.Assignees,
+			Labels:            toV1Labels(issue.Labels),
+		})
+	}
+	return answer
+}
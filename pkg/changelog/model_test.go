@@ -0,0 +1,72 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCommit(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		message                 string
+		expectType, expectScope string
+		expectSubject           string
+		expectBreaking          bool
+	}{
+		{"feat(cli): add a flag", "feat", "cli", "add a flag", false},
+		{"fix!: drop support", "fix", "", "drop support", true},
+		{"Fix: something\n\nBREAKING CHANGE: the API changed", "fix", "", "something", true},
+		{"just a message", "", "", "just a message", false},
+	}
+	for _, tc := range testCases {
+		c := changelog.NewCommit("abc", tc.message)
+		assert.Equal(t, tc.expectType, c.Type, "type for %s", tc.message)
+		assert.Equal(t, tc.expectScope, c.Scope, "scope for %s", tc.message)
+		assert.Equal(t, tc.expectSubject, c.Subject, "subject for %s", tc.message)
+		assert.Equal(t, tc.expectBreaking, c.Breaking, "breaking for %s", tc.message)
+	}
+}
+
+func TestChangelogModel(t *testing.T) {
+	t.Parallel()
+	author := &v1.UserDetails{Login: "jstrachan"}
+	fix := changelog.NewCommit("1", "fix: a bug")
+	fix.Author = author
+	fix.IssueIDs = []string{"12"}
+	feat := changelog.NewCommit("2", "feat!: something new")
+	feat.Author = author
+	other := changelog.NewCommit("3", "tidy up")
+
+	model := &changelog.Changelog{
+		Commits:      []*changelog.Commit{other, fix, feat},
+		PullRequests: []*changelog.Issue{{ID: "12", Title: "a bug", Labels: []string{"bug"}, PullRequest: true}},
+	}
+
+	groups := model.Groups()
+	require.Len(t, groups, 3)
+	assert.Equal(t, "New Features", groups[0].Title)
+	assert.Equal(t, "Bug Fixes", groups[1].Title)
+	assert.Equal(t, "", groups[2].Type)
+
+	stats := model.Stats()
+	assert.Equal(t, 3, stats.Commits)
+	assert.Equal(t, 1, stats.BreakingChanges)
+	assert.Equal(t, 1, stats.PullRequests)
+	assert.Equal(t, 1, stats.Contributors)
+	assert.Equal(t, 1, stats.Types["fix"])
+
+	spec := &v1.ReleaseSpec{}
+	model.ProjectInto(spec)
+	require.Len(t, spec.Commits, 3)
+	assert.Equal(t, "fix: a bug", spec.Commits[1].Message)
+	assert.Equal(t, []string{"12"}, spec.Commits[1].IssueIDs)
+	require.Len(t, spec.PullRequests, 1)
+	assert.Equal(t, "bug", spec.PullRequests[0].Labels[0].Name)
+	assert.Empty(t, spec.Issues)
+}
@@ -0,0 +1,57 @@
+package changelog
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// notifyPublisher posts the release notes to the Slack incoming webhooks of the channel once the release is published
+type notifyPublisher struct {
+	g *Generator
+}
+
+func (p *notifyPublisher) Name() string {
+	return "notify"
+}
+
+func (p *notifyPublisher) Publish(ctx context.Context, result *Result) error {
+	msg := &SlackMessage{
+		Text:   ToSlackMarkdown(notificationHeading(result) + "\n\n" + result.Markdown),
+		Mrkdwn: true,
+	}
+	for _, u := range p.g.channelNotify() {
+		err := PostWebhook(ctx, p.g.HTTPClient, u, msg)
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("notified %s of release %s", info(webhookHost(u)), info(result.Tag))
+	}
+	return nil
+}
+
+// notificationHeading returns the markdown heading of the release linking to its release notes
+func notificationHeading(result *Result) string {
+	title := result.Tag
+	if result.Release != nil {
+		spec := &result.Release.Spec
+		if spec.GitOwner != "" && spec.GitRepository != "" {
+			title = scm.Join(spec.GitOwner, spec.GitRepository) + " " + title
+		}
+		if spec.ReleaseNotesURL != "" {
+			title = "[" + title + "](" + spec.ReleaseNotesURL + ")"
+		}
+	}
+	return "## " + title
+}
+
+// webhookHost returns the host of the webhook so that the secret path of incoming webhooks is not logged
+func webhookHost(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return "webhook"
+	}
+	return parsed.Host
+}
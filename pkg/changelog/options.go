@@ -0,0 +1,111 @@
+package changelog
+
+import (
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+)
+
+// Option configures the Generator created by New
+type Option func(g *Generator)
+
+// New creates a validated Generator using the same defaults as the 'jx changelog create' command so that library
+// consumers do not depend on the command line flags
+func New(opts ...Option) (*Generator, error) {
+	g := &Generator{
+		ReleaseYamlFile:     "release.yaml",
+		CrdYamlFile:         "release-crd.yaml",
+		GenerateReleaseYaml: true,
+		UpdateRelease:       true,
+		Format:              RendererMarkdown,
+		OnReleaseError:      ErrorPolicyWarn,
+		OnIssueLookupError:  ErrorPolicyWarn,
+		SkipCommitPattern:   DefaultSkipCommitPattern,
+		Mentions:            MentionsNone,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	err := g.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// WithScmFactory sets the git repository and git provider of the changelog
+func WithScmFactory(f scmhelpers.Options) Option {
+	return func(g *Generator) {
+		g.ScmFactory = f
+	}
+}
+
+// WithRange sets the previous and current revisions of the changelog. Empty revisions are discovered from the tags
+func WithRange(previousRev, currentRev string) Option {
+	return func(g *Generator) {
+		g.PreviousRevision = previousRev
+		g.CurrentRevision = currentRev
+	}
+}
+
+// WithTemplates sets the directory of the helm chart templates to generate the Release YAML
+func WithTemplates(dir string) Option {
+	return func(g *Generator) {
+		g.TemplatesDir = dir
+	}
+}
+
+// WithPublisher adds a publisher which is invoked after the built in publishers
+func WithPublisher(p Publisher) Option {
+	return func(g *Generator) {
+		g.Publishers = append(g.Publishers, p)
+	}
+}
+
+// WithIssueTracker sets the issue tracker used to look up the issues referenced by the commits instead of the git provider
+func WithIssueTracker(tracker issues.IssueProvider) Option {
+	return func(g *Generator) {
+		g.IssueTracker = tracker
+	}
+}
+
+// WithJiraVersion enables the creation or update of the Jira project version of the release, marked as released if
+// specified, along with the association of the referenced Jira issues. Requires a Jira issue tracker
+func WithJiraVersion(released bool) Option {
+	return func(g *Generator) {
+		g.JiraVersion = true
+		g.JiraVersionReleased = released
+	}
+}
+
+// WithClock sets the clock used for the timestamps of the changelog
+func WithClock(clock Clock) Option {
+	return func(g *Generator) {
+		g.Clock = clock
+	}
+}
+
+// WithAdvisoryLookup sets the lookup of the security advisories fixed by dependency updates and enables the
+// classification of the dependency updates
+func WithAdvisoryLookup(lookup deps.AdvisoryLookup) Option {
+	return func(g *Generator) {
+		g.AdvisoryLookup = lookup
+		g.ClassifyDependencies = true
+	}
+}
+
+// WithInput curates the collected commits interactively using the input before the changelog is rendered
+func WithInput(in input.Interface) Option {
+	return func(g *Generator) {
+		g.Input = in
+	}
+}
+
+// WithTranslator sets the translator used to translate the changelog into the languages to translate into
+func WithTranslator(t Translator, languages ...string) Option {
+	return func(g *Generator) {
+		g.Translator = t
+		g.TranslateLanguages = languages
+	}
+}
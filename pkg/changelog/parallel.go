@@ -0,0 +1,185 @@
+package changelog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// rateLimitResetHeaders the headers of the time the rate limit of GitHub, GitLab and Gitea resets in unix seconds
+var rateLimitResetHeaders = []string{"X-RateLimit-Reset", "RateLimit-Reset"}
+
+// defaultRateLimitMaxWait the maximum time to wait for the rate limit to reset
+const defaultRateLimitMaxWait = 15 * time.Minute
+
+// RunConcurrently calls the function with each index from zero to count on a pool of workers goroutines so that the
+// components or repositories of an aggregated changelog are generated concurrently. The function should store its
+// result by index so that the results are merged in the same order whatever the order they complete. The error of
+// the lowest index is returned. A single worker calls the function sequentially stopping at the first error
+func RunConcurrently(workers, count int, fn func(i int) error) error {
+	if workers <= 1 || count <= 1 {
+		for i := 0; i < count; i++ {
+			err := fn(i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if workers > count {
+		workers = count
+	}
+	errs := make([]error, count)
+	indexes := make(chan int, count)
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RateLimiter shares the rate limit of the git provider between the components generated concurrently. Once the
+// remaining rate limit reported by the git provider drops below MinRemaining every API call waits until the rate
+// limit resets rather than failing. A nil limiter does not limit
+type RateLimiter struct {
+	// MinRemaining the remaining rate limit below which the API calls wait
+	MinRemaining int
+
+	// MaxWait the maximum time to wait for the rate limit to reset. Defaults to 15 minutes
+	MaxWait time.Duration
+
+	lock      sync.Mutex
+	remaining int
+	reset     time.Time
+	logged    bool
+}
+
+// NewRateLimiter creates the limiter returning nil if there is no minimum remaining rate limit
+func NewRateLimiter(minRemaining int) *RateLimiter {
+	if minRemaining <= 0 {
+		return nil
+	}
+	return &RateLimiter{MinRemaining: minRemaining, MaxWait: defaultRateLimitMaxWait, remaining: -1}
+}
+
+// Limit wraps the HTTP client of the git provider to wait for the rate limit and track the remaining rate limit
+func (l *RateLimiter) Limit(client *scm.Client) {
+	if l == nil || client == nil {
+		return
+	}
+	httpClient := http.Client{}
+	if client.Client != nil {
+		httpClient = *client.Client
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	httpClient.Transport = &rateLimitTransport{limiter: l, next: next}
+	client.Client = &httpClient
+}
+
+// Wait waits until the rate limit resets if the remaining rate limit is below the minimum
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	var d time.Duration
+	if l.remaining >= 0 && l.remaining < l.MinRemaining {
+		d = time.Until(l.reset)
+		if l.MaxWait > 0 && d > l.MaxWait {
+			d = l.MaxWait
+		}
+		if d > 0 && !l.logged {
+			l.logged = true
+			log.Logger().Warnf("waiting %s for the git provider rate limit to reset as only %d calls remain", d.Round(time.Second).String(), l.remaining)
+		}
+	}
+	l.lock.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "aborted waiting for the git provider rate limit")
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	// lets let the next response tell us the new rate limit
+	l.remaining = -1
+	l.logged = false
+	return nil
+}
+
+// update records the remaining rate limit and when it resets from the headers of the response
+func (l *RateLimiter) update(header http.Header) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, name := range rateLimitRemainingHeaders {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		remaining, err := strconv.Atoi(value)
+		if err == nil {
+			l.remaining = remaining
+		}
+		break
+	}
+	for _, name := range rateLimitResetHeaders {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		reset, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			l.reset = time.Unix(reset, 0)
+		}
+		break
+	}
+}
+
+type rateLimitTransport struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.limiter.Wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.limiter.update(resp.Header)
+	return resp, nil
+}
@@ -0,0 +1,99 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConcurrently(t *testing.T) {
+	t.Parallel()
+	for _, workers := range []int{0, 1, 4, 100} {
+		var running, maxRunning int32
+		results := make([]string, 10)
+		err := changelog.RunConcurrently(workers, len(results), func(i int) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			// lets finish the later components first
+			time.Sleep(time.Duration(len(results)-i) * time.Millisecond)
+			results[i] = fmt.Sprintf("component-%d", i)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "component-0", results[0], "workers %d", workers)
+		assert.Equal(t, "component-9", results[9], "workers %d", workers)
+		if workers <= 1 {
+			assert.Equal(t, int32(1), maxRunning, "workers %d", workers)
+		} else {
+			assert.LessOrEqual(t, maxRunning, int32(workers), "workers %d", workers)
+		}
+	}
+
+	err := changelog.RunConcurrently(4, 10, func(i int) error {
+		if i == 3 || i == 7 {
+			return errors.Errorf("failed component %d", i)
+		}
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, "failed component 3", err.Error(), "the error of the first component should be returned")
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(10-n)))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"login":"jstrachan"}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	scmClient, err := factory.NewClient("github", server.URL, "mytoken")
+	require.NoError(t, err)
+	assert.Nil(t, changelog.NewRateLimiter(0), "no minimum remaining should not limit")
+	changelog.NewRateLimiter(0).Limit(scmClient)
+
+	limiter := changelog.NewRateLimiter(10)
+	limiter.MaxWait = 50 * time.Millisecond
+	limiter.Limit(scmClient)
+	ctx := context.Background()
+
+	start := time.Now()
+	_, _, err = scmClient.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(limiter.MaxWait), "the first call should not wait")
+
+	start = time.Now()
+	_, _, err = scmClient.Users.Find(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(limiter.MaxWait), "the call should wait for the rate limit to reset")
+
+	limiter.MaxWait = time.Hour
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = scmClient.Users.Find(cancelled)
+	assert.Error(t, err, "a cancelled wait should fail")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
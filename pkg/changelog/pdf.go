@@ -0,0 +1,308 @@
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/pkg/errors"
+)
+
+// RendererPDF renders the changelog as a PDF document
+const RendererPDF = "pdf"
+
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 56.0
+)
+
+var (
+	pdfLinkRegex     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	pdfTagRegex      = regexp.MustCompile(`<[^>]*>`)
+	pdfEmphasisRegex = regexp.MustCompile("\\*\\*|__|`")
+	pdfTableRegex    = regexp.MustCompile(`^\|[\s:|-]+\|$`)
+	pdfCommentRegex  = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+	// pdfWinAnsi the characters outside of latin-1 which have a code in the WinAnsiEncoding of the standard fonts
+	pdfWinAnsi = map[rune]byte{'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97}
+)
+
+// PDFRenderer renders the changelog as a PDF document with a cover page of the release metadata. The document is
+// laid out from the markdown using the standard Helvetica fonts unless a converter command is specified
+type PDFRenderer struct {
+	// Title the title of the document
+	Title string
+
+	// Converter if specified the shell command which converts the HTML page on its standard input to the PDF
+	// on its standard output such as 'wkhtmltopdf --quiet - -'
+	Converter string
+}
+
+// NewPDFRenderer creates the PDF renderer using the 'title' and 'converter' options
+func NewPDFRenderer(options RendererOptions) (Renderer, error) {
+	err := options.Validate("title", "converter")
+	if err != nil {
+		return nil, err
+	}
+	return &PDFRenderer{
+		Title:     options["title"],
+		Converter: options["converter"],
+	}, nil
+}
+
+// Render renders the changelog as PDF
+func (r *PDFRenderer) Render(input *RenderInput) (string, error) {
+	markdown, err := (&MarkdownRenderer{}).Render(input)
+	if err != nil {
+		return "", err
+	}
+	title := r.Title
+	if title == "" && input.ReleaseSpec != nil {
+		title = strings.TrimSpace(input.ReleaseSpec.Name + " " + input.ReleaseSpec.Version)
+	}
+	if r.Converter != "" {
+		return r.convert(MarkdownToHTML(markdown, title, "", true))
+	}
+	doc := &pdfDocument{Title: title}
+	doc.cover(title, pdfCoverMetadata(input))
+	doc.markdown(markdown)
+	return string(doc.Bytes()), nil
+}
+
+// convert converts the HTML to PDF via the converter command
+func (r *PDFRenderer) convert(html string) (string, error) {
+	out := &bytes.Buffer{}
+	c := &cmdrunner.Command{
+		Name: "sh",
+		Args: []string{"-c", r.Converter},
+		In:   strings.NewReader(html),
+		Out:  out,
+	}
+	_, err := cmdrunner.QuietCommandRunner(c)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to convert the changelog to PDF via %s", r.Converter)
+	}
+	return out.String(), nil
+}
+
+// pdfCoverMetadata returns the labels and values of the release listed on the cover page
+func pdfCoverMetadata(input *RenderInput) [][2]string {
+	spec := input.ReleaseSpec
+	if spec == nil {
+		return nil
+	}
+	var answer [][2]string
+	add := func(label, value string) {
+		if value != "" {
+			answer = append(answer, [2]string{label, value})
+		}
+	}
+	add("Version", spec.Version)
+	add("Repository", spec.GitHTTPURL)
+	add("Release notes", spec.ReleaseNotesURL)
+	add("Commits", strconv.Itoa(len(spec.Commits)))
+	add("Issues", strconv.Itoa(len(spec.Issues)))
+	add("Pull requests", strconv.Itoa(len(spec.PullRequests)))
+	add("Contributors", strconv.Itoa(len(gits.Contributors(spec))))
+	add("Generated by", "jx-changelog")
+	return answer
+}
+
+// pdfDocument a minimal PDF writer laying out lines of text over pages using the standard Helvetica fonts
+type pdfDocument struct {
+	Title string
+
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+// cover adds the title page listing the metadata of the release
+func (d *pdfDocument) cover(title string, metadata [][2]string) {
+	d.newPage()
+	d.y = pdfPageHeight / 3
+	d.text(title, 26, true, 0)
+	d.y += 24
+	for _, m := range metadata {
+		d.text(m[0]+": "+m[1], 12, false, 0)
+	}
+	d.current = nil
+}
+
+// markdown lays out the markdown as headings, bullets and paragraphs starting on a new page
+func (d *pdfDocument) markdown(markdown string) {
+	markdown = pdfCommentRegex.ReplaceAllString(markdown, "")
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case strings.TrimSpace(line) == "":
+			d.space(6)
+		case strings.HasPrefix(line, "# "):
+			d.space(10)
+			d.text(pdfPlainText(line[2:]), 20, true, 0)
+		case strings.HasPrefix(line, "## "):
+			d.space(8)
+			d.text(pdfPlainText(line[3:]), 16, true, 0)
+		case strings.HasPrefix(line, "### "), strings.HasPrefix(line, "#### "):
+			d.space(6)
+			d.text(pdfPlainText(strings.TrimLeft(line, "# ")), 13, true, 0)
+		case pdfTableRegex.MatchString(line):
+			// lets skip the separator rows of tables
+		case strings.HasPrefix(line, "|"):
+			cells := strings.Split(strings.Trim(line, "|"), "|")
+			for i := range cells {
+				cells[i] = strings.TrimSpace(cells[i])
+			}
+			d.text(pdfPlainText(strings.Join(cells, "   ")), 9, false, 0)
+		case strings.HasPrefix(strings.TrimLeft(line, " "), "* "), strings.HasPrefix(strings.TrimLeft(line, " "), "- "):
+			indent := float64(len(line)-len(strings.TrimLeft(line, " "))) * 4
+			d.text("• "+pdfPlainText(strings.TrimLeft(line, " ")[2:]), 11, false, indent+8)
+		default:
+			text := pdfPlainText(line)
+			if text != "" {
+				d.text(text, 11, false, 0)
+			}
+		}
+	}
+}
+
+// pdfPlainText removes the markdown and HTML markup of the line
+func pdfPlainText(line string) string {
+	line = pdfLinkRegex.ReplaceAllString(line, "$1")
+	line = pdfTagRegex.ReplaceAllString(line, "")
+	line = pdfEmphasisRegex.ReplaceAllString(line, "")
+	return strings.TrimSpace(line)
+}
+
+func (d *pdfDocument) newPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+	d.y = pdfMargin
+}
+
+func (d *pdfDocument) space(points float64) {
+	if d.current != nil && d.y > pdfMargin {
+		d.y += points
+	}
+}
+
+// text writes the text wrapping it at the right margin and starting new pages as required
+func (d *pdfDocument) text(text string, size float64, bold bool, indent float64) {
+	font := "F1"
+	charWidth := 0.5 * size
+	if bold {
+		font = "F2"
+		charWidth = 0.55 * size
+	}
+	width := pdfPageWidth - 2*pdfMargin - indent
+	maxChars := int(width / charWidth)
+	leading := size * 1.4
+	for i, line := range pdfWrap(text, maxChars) {
+		if d.current == nil || d.y+leading > pdfPageHeight-pdfMargin {
+			d.newPage()
+		}
+		d.y += leading
+		x := pdfMargin + indent
+		if i > 0 && strings.HasPrefix(text, "• ") {
+			x += 2 * charWidth
+		}
+		fmt.Fprintf(d.current, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pdfPageHeight-d.y, pdfEscape(line))
+	}
+}
+
+// pdfWrap splits the text into lines of at most max characters at spaces where possible
+func pdfWrap(text string, max int) []string {
+	var answer []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > max {
+			runes := []rune(word)
+			if line != "" {
+				answer = append(answer, line)
+				line = ""
+			}
+			answer = append(answer, string(runes[:max]))
+			word = string(runes[max:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= max:
+			line += " " + word
+		default:
+			answer = append(answer, line)
+			line = word
+		}
+	}
+	if line != "" || len(answer) == 0 {
+		answer = append(answer, line)
+	}
+	return answer
+}
+
+// pdfEscape encodes the text as a PDF string in WinAnsiEncoding replacing the characters it cannot encode
+func pdfEscape(text string) string {
+	var buf strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r >= 32 && r < 127:
+			buf.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&buf, "\\%03o", r)
+		case pdfWinAnsi[r] != 0:
+			fmt.Fprintf(&buf, "\\%03o", pdfWinAnsi[r])
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	return buf.String()
+}
+
+// Bytes returns the PDF document
+func (d *pdfDocument) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+	var objects []string
+	add := func(object string) int {
+		objects = append(objects, object)
+		return len(objects)
+	}
+	catalog := add("")
+	pagesID := add("")
+	regular := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	bold := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	var kids []string
+	for _, page := range d.pages {
+		content := page.String()
+		contentID := add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+		pageID := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>", pagesID, pdfPageWidth, pdfPageHeight, regular, bold, contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID)
+	objects[pagesID-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+	infoID := add(fmt.Sprintf("<< /Title (%s) /Producer (jx-changelog) >>", pdfEscape(d.Title)))
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, infoID, xref)
+	return buf.Bytes()
+}
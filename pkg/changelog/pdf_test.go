@@ -0,0 +1,73 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDFRenderer(t *testing.T) {
+	t.Parallel()
+	gitInfo, err := giturl.ParseGitURL("https://github.com/jenkins-x/jx")
+	require.NoError(t, err)
+	spec := &v1.ReleaseSpec{
+		Name:       "jx",
+		Version:    "1.2.3",
+		GitHTTPURL: "https://github.com/jenkins-x/jx",
+		Commits: []v1.CommitSummary{
+			{Message: "fix: some (bug) in café", SHA: "abcdef1234567", URL: "https://github.com/jenkins-x/jx/commit/abcdef1234567"},
+			{Message: "feat: " + strings.Repeat("a very long title ", 20), SHA: "1234567abcdef"},
+		},
+	}
+	input := &changelog.RenderInput{
+		TemplateData:    &changelog.TemplateData{ReleaseSpec: spec},
+		GitInfo:         gitInfo,
+		MarkdownOptions: &gits.MarkdownOptions{CommitLinks: true},
+	}
+
+	r, err := changelog.NewRenderer(changelog.RendererPDF, nil)
+	require.NoError(t, err)
+	text, err := r.Render(input)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(text, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(text, "%%EOF\n"))
+	assert.Contains(t, text, "/Title (jx 1.2.3)")
+	assert.Contains(t, text, "(Version: 1.2.3)")
+	assert.Contains(t, text, "(Repository: https://github.com/jenkins-x/jx)")
+	assert.Contains(t, text, "(Bug Fixes)")
+	assert.Contains(t, text, `(\225 some \(bug\) in caf\351 abcdef1)`)
+	assert.Contains(t, text, "/Count 2", "should have the cover and a page of changes")
+
+	// lets check the cross reference table points at the objects
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(text)
+	require.Len(t, startxref, 2)
+	offset, err := strconv.Atoi(startxref[1])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(text[offset:], "xref\n"))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(text, -1)
+	for i, e := range entries {
+		offset, err = strconv.Atoi(e[1])
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(text[offset:], strconv.Itoa(i+1)+" 0 obj\n"), "object %d", i+1)
+	}
+
+	r, err = changelog.NewRenderer(changelog.RendererPDF, changelog.RendererOptions{"converter": "cat"})
+	require.NoError(t, err)
+	text, err = r.Render(input)
+	require.NoError(t, err)
+	assert.Contains(t, text, "<title>jx 1.2.3</title>", "the converter should be given the HTML page")
+
+	_, err = changelog.NewRenderer(changelog.RendererPDF, changelog.RendererOptions{"page": "true"})
+	assert.Error(t, err)
+	assert.Error(t, (&changelog.Generator{Format: changelog.RendererPDF}).Validate(), "should require an output file")
+}
@@ -0,0 +1,33 @@
+package changelog
+
+import (
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+const (
+	// ErrorPolicyFail fails the command if the error occurs
+	ErrorPolicyFail = "fail"
+
+	// ErrorPolicyWarn logs a warning if the error occurs and carries on generating the changelog
+	ErrorPolicyWarn = "warn"
+)
+
+// ValidateErrorPolicy validates the value of an error policy flag. An empty policy warns
+func ValidateErrorPolicy(name, policy string) error {
+	switch policy {
+	case "", ErrorPolicyFail, ErrorPolicyWarn:
+		return nil
+	default:
+		return options.InvalidOptionf(name, policy, "should be one of %s or %s", ErrorPolicyFail, ErrorPolicyWarn)
+	}
+}
+
+// HandleError returns the error if the policy is to fail otherwise the error is logged as a warning and nil is returned
+func HandleError(policy string, err error) error {
+	if err == nil || policy == ErrorPolicyFail {
+		return err
+	}
+	log.Logger().Warn(err.Error())
+	return nil
+}
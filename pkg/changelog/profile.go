@@ -0,0 +1,237 @@
+package changelog
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/tracing"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PhaseGitLog finding the git commits of the changelog
+	PhaseGitLog = "git-log"
+
+	// PhaseIssueLookup looking up the issues and pull requests referenced by commits
+	PhaseIssueLookup = "issue-lookup"
+
+	// PhaseUserResolution resolving the authors, committers and reviewers to git provider users
+	PhaseUserResolution = "user-resolution"
+
+	// PhaseRender rendering the markdown of the changelog
+	PhaseRender = "render"
+
+	// PhasePublish publishing the changelog to the release on the git provider
+	PhasePublish = "publish"
+
+	// PhaseKubeUpdate updating the PipelineActivity
+	PhaseKubeUpdate = "kube-update"
+
+	// PerfBudgetTotal the key of the performance budget of the total time taken to generate the changelog
+	PerfBudgetTotal = "total"
+)
+
+// phases the phases of generating the changelog in the order they run
+var phases = []string{PhaseGitLog, PhaseIssueLookup, PhaseUserResolution, PhaseRender, PhasePublish, PhaseKubeUpdate}
+
+// StartPhase starts profiling and tracing the phase returning the function to call when the phase completes
+func (g *Generator) StartPhase(phase string) func() {
+	stopProfile := g.State.Profile.start(phase)
+	_, span := tracing.Start(g.State.Context, phase)
+	return func() {
+		span.End()
+		stopProfile()
+	}
+}
+
+// Profile records how long each phase of generating the changelog takes along with the git provider API calls made
+// during the phase. Durations of a phase accumulate if it is started more than once, including by concurrent
+// goroutines. The active phase is tracked per goroutine so that the API calls of concurrent workers are counted in
+// their own phases. A nil profile records nothing
+type Profile struct {
+	lock     sync.Mutex
+	phases   []string
+	times    map[string]time.Duration
+	apiCalls map[string]int
+	active   map[uint64]string
+	begin    time.Time
+}
+
+// NewProfile creates a new profile
+func NewProfile() *Profile {
+	return &Profile{
+		times:    map[string]time.Duration{},
+		apiCalls: map[string]int{},
+		active:   map[uint64]string{},
+		begin:    time.Now(),
+	}
+}
+
+// start starts timing the phase returning the function to stop timing it
+func (p *Profile) start(phase string) func() {
+	if p == nil {
+		return func() {}
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.times[phase]; !ok {
+		p.phases = append(p.phases, phase)
+		p.times[phase] = 0
+	}
+	id := goroutineID()
+	previous, nested := p.active[id]
+	p.active[id] = phase
+	begin := time.Now()
+	return func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		p.times[phase] += time.Since(begin)
+		if nested {
+			p.active[id] = previous
+		} else {
+			delete(p.active, id)
+		}
+	}
+}
+
+// goroutineID returns the ID of the current goroutine from the header of its stack trace such as 'goroutine 7 [running]:'
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = bytes.TrimPrefix(buf[:runtime.Stack(buf, false)], []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// CountAPICalls wraps the HTTP client of the git provider to count the API calls made in each phase
+func (p *Profile) CountAPICalls(client *scm.Client) {
+	if p == nil || client == nil {
+		return
+	}
+	httpClient := http.Client{}
+	if client.Client != nil {
+		httpClient = *client.Client
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	httpClient.Transport = &countingTransport{profile: p, next: next}
+	client.Client = &httpClient
+}
+
+// APICalls returns the number of git provider API calls made during the phase
+func (p *Profile) APICalls(phase string) int {
+	if p == nil {
+		return 0
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.apiCalls[phase]
+}
+
+// Report logs the time taken and API calls of each phase
+func (p *Profile) Report() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	total := 0
+	for _, phase := range p.phases {
+		calls := p.apiCalls[phase]
+		total += calls
+		log.Logger().WithFields(logrus.Fields{
+			"phase":    phase,
+			"duration": p.times[phase].String(),
+			"apiCalls": calls,
+		}).Infof("phase %s took %s with %d git provider API calls", info(phase), p.times[phase].Round(time.Millisecond).String(), calls)
+	}
+	if other := p.apiCalls[""]; other > 0 {
+		total += other
+		log.Logger().Infof("%d git provider API calls were made outside of the profiled phases", other)
+	}
+	log.Logger().WithField("apiCalls", total).Infof("made %d git provider API calls in total", total)
+}
+
+// ParsePerfBudget parses the maximum durations of the phases indexed by the phase name or total such as 'render=2s'
+func ParsePerfBudget(budget map[string]string) (map[string]time.Duration, error) {
+	if len(budget) == 0 {
+		return nil, nil
+	}
+	var keys []string
+	for k := range budget {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	answer := map[string]time.Duration{}
+	for _, phase := range keys {
+		text := budget[phase]
+		if phase != PerfBudgetTotal && stringhelpers.StringArrayIndex(phases, phase) < 0 {
+			return nil, options.InvalidOptionf("perf-budget", phase+"="+text, "the phase should be one of %s or %s", strings.Join(phases, ", "), PerfBudgetTotal)
+		}
+		d, err := time.ParseDuration(text)
+		if err != nil || d <= 0 {
+			return nil, options.InvalidOptionf("perf-budget", phase+"="+text, "should be a positive duration such as 2s")
+		}
+		answer[phase] = d
+	}
+	return answer, nil
+}
+
+// CheckBudget warns about each phase which took longer than its budget along with the total time since the profile
+// was created returning the phases over budget
+func (p *Profile) CheckBudget(budget map[string]time.Duration) []string {
+	if p == nil || len(budget) == 0 {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var answer []string
+	for _, phase := range append(append([]string{}, p.phases...), PerfBudgetTotal) {
+		limit, ok := budget[phase]
+		if !ok {
+			continue
+		}
+		d := p.times[phase]
+		if phase == PerfBudgetTotal {
+			d = time.Since(p.begin)
+		}
+		if d <= limit {
+			continue
+		}
+		log.Logger().WithFields(logrus.Fields{
+			"phase":    phase,
+			"duration": d.String(),
+			"budget":   limit.String(),
+		}).Warnf("phase %s took %s which exceeds its performance budget of %s", info(phase), d.Round(time.Millisecond).String(), limit.String())
+		answer = append(answer, phase)
+	}
+	return answer
+}
+
+type countingTransport struct {
+	profile *Profile
+	next    http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the transport is called by the goroutine making the request
+	id := goroutineID()
+	t.profile.lock.Lock()
+	t.profile.apiCalls[t.profile.active[id]]++
+	t.profile.lock.Unlock()
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,82 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerfBudget(t *testing.T) {
+	t.Parallel()
+	g := &changelog.Generator{PerfBudget: map[string]string{changelog.PhaseRender: "1ns", changelog.PhaseGitLog: "1h", changelog.PerfBudgetTotal: "1ns"}}
+	require.NoError(t, g.Validate())
+	assert.Equal(t, time.Hour, g.State.PerfBudget[changelog.PhaseGitLog])
+
+	g.State.Context = context.Background()
+	g.State.Profile = changelog.NewProfile()
+	g.StartPhase(changelog.PhaseGitLog)()
+	stop := g.StartPhase(changelog.PhaseRender)
+	time.Sleep(time.Millisecond)
+	stop()
+	assert.Equal(t, []string{changelog.PhaseRender, changelog.PerfBudgetTotal}, g.State.Profile.CheckBudget(g.State.PerfBudget))
+
+	var profile *changelog.Profile
+	assert.Empty(t, profile.CheckBudget(g.State.PerfBudget), "a nil profile records nothing")
+
+	assert.Error(t, (&changelog.Generator{PerfBudget: map[string]string{"cheese": "1s"}}).Validate(), "there is no cheese phase")
+	assert.Error(t, (&changelog.Generator{PerfBudget: map[string]string{changelog.PhaseRender: "soon"}}).Validate())
+}
+
+func TestProfileConcurrentPhases(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := &scm.Client{}
+	g := &changelog.Generator{}
+	g.State.Context = context.Background()
+	g.State.Profile = changelog.NewProfile()
+	g.State.Profile.CountAPICalls(client)
+
+	// lets interleave the phases of two workers so that a single active phase would count the calls of both in one
+	lookupStarted := make(chan struct{})
+	resolutionStarted := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stop := g.StartPhase(changelog.PhaseIssueLookup)
+		defer stop()
+		close(lookupStarted)
+		<-resolutionStarted
+		res, err := client.Client.Get(server.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		<-lookupStarted
+		stop := g.StartPhase(changelog.PhaseUserResolution)
+		defer stop()
+		close(resolutionStarted)
+		for i := 0; i < 2; i++ {
+			res, err := client.Client.Get(server.URL)
+			require.NoError(t, err)
+			res.Body.Close()
+		}
+	}()
+	wg.Wait()
+	assert.Equal(t, 1, g.State.Profile.APICalls(changelog.PhaseIssueLookup))
+	assert.Equal(t, 2, g.State.Profile.APICalls(changelog.PhaseUserResolution))
+	assert.Equal(t, 0, g.State.Profile.APICalls(""))
+	g.State.Profile.Report()
+}
@@ -0,0 +1,87 @@
+package changelog
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultProjectField the single select field of the project board whose option is set for the released issues
+	DefaultProjectField = "Status"
+
+	// DefaultProjectOption the option of the project field set for the released issues
+	DefaultProjectOption = "Released"
+)
+
+// parseProjectBoard parses the project board option of the form 'owner/number'
+func parseProjectBoard(text string) (string, int, error) {
+	i := strings.LastIndex(text, "/")
+	var number int
+	var err error
+	if i > 0 {
+		number, err = strconv.Atoi(text[i+1:])
+	}
+	if i <= 0 || err != nil || number <= 0 {
+		return "", 0, options.InvalidOptionf("project-board", text, "should be the owner and number of the project such as myorg/5")
+	}
+	return text[:i], number, nil
+}
+
+// projectBoardPublisher moves the issues and pull requests of the release to the released option of the status field
+// of a GitHub project so that delivery boards stay in sync with the releases
+type projectBoardPublisher struct {
+	g *Generator
+}
+
+func (p *projectBoardPublisher) Name() string {
+	return "project-board"
+}
+
+func (p *projectBoardPublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	tracker := g.State.Tracker
+	if tracker == nil {
+		tracker = g.IssueTracker
+	}
+	boards, ok := tracker.(issues.ProjectBoardProvider)
+	if !ok {
+		return errors.Errorf("the issue tracker does not support project boards")
+	}
+	owner, number, err := parseProjectBoard(g.ProjectBoard)
+	if err != nil {
+		return err
+	}
+	field := g.ProjectField
+	if field == "" {
+		field = DefaultProjectField
+	}
+	option := g.ProjectOption
+	if option == "" {
+		option = DefaultProjectOption
+	}
+	board, err := boards.FindProjectBoard(owner, number, field, option)
+	if err != nil {
+		return err
+	}
+	count := 0
+	if result.Changelog != nil {
+		for _, issue := range append(append([]*Issue{}, result.Changelog.Issues...), result.Changelog.PullRequests...) {
+			if _, err := strconv.Atoi(issue.ID); err != nil {
+				continue
+			}
+			err = boards.SetProjectStatus(board, issue.ID)
+			if err != nil {
+				return err
+			}
+			count++
+		}
+	}
+	log.Logger().Infof("moved %d issues and pull requests to %s on project %s", count, info(option), info(g.ProjectBoard))
+	return nil
+}
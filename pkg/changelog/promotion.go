@@ -0,0 +1,29 @@
+package changelog
+
+import (
+	"strings"
+)
+
+const (
+	// PromotionChangelogStart marks the start of the changelog in the description of a promotion pull request
+	PromotionChangelogStart = "<!-- jx-changelog:start -->"
+
+	// PromotionChangelogEnd marks the end of the changelog in the description of a promotion pull request
+	PromotionChangelogEnd = "<!-- jx-changelog:end -->"
+)
+
+// UpdatePromotionDescription returns the description of the promotion pull request with the changelog between the
+// markers replacing the changelog of any previous update so that the description can be enriched repeatedly
+func UpdatePromotionDescription(body, markdown string) string {
+	section := PromotionChangelogStart + "\n" + strings.TrimSpace(markdown) + "\n" + PromotionChangelogEnd
+	start := strings.Index(body, PromotionChangelogStart)
+	end := strings.Index(body, PromotionChangelogEnd)
+	if start >= 0 && end > start {
+		return body[:start] + section + body[end+len(PromotionChangelogEnd):]
+	}
+	body = strings.TrimRight(body, "\n")
+	if body == "" {
+		return section + "\n"
+	}
+	return body + "\n\n" + section + "\n"
+}
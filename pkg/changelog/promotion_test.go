@@ -0,0 +1,21 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePromotionDescription(t *testing.T) {
+	t.Parallel()
+	body := changelog.UpdatePromotionDescription("chore: promote jx-preview to 0.2.0\n", "## Environment Changes\n\nfirst\n")
+	assert.Equal(t, "chore: promote jx-preview to 0.2.0\n\n<!-- jx-changelog:start -->\n## Environment Changes\n\nfirst\n<!-- jx-changelog:end -->\n", body)
+
+	body = changelog.UpdatePromotionDescription(body+"\nfooter\n", "## Environment Changes\n\nsecond")
+	assert.Equal(t, "chore: promote jx-preview to 0.2.0\n\n<!-- jx-changelog:start -->\n## Environment Changes\n\nsecond\n<!-- jx-changelog:end -->\n\nfooter\n", body)
+
+	assert.Equal(t, "<!-- jx-changelog:start -->\nnotes\n<!-- jx-changelog:end -->\n", changelog.UpdatePromotionDescription("", "notes"))
+}
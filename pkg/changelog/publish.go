@@ -0,0 +1,421 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ReleaseCrdYaml the CustomResourceDefinition of the Release resource
+	ReleaseCrdYaml = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  creationTimestamp: 2018-02-24T14:56:33Z
+  name: releases.jenkins.io
+  resourceVersion: "557150"
+  selfLink: /apis/apiextensions.k8s.io/v1beta1/customresourcedefinitions/releases.jenkins.io
+  uid: e77f4e08-1972-11e8-988e-42010a8401df
+spec:
+  group: jenkins.io
+  names:
+    kind: Release
+    listKind: ReleaseList
+    plural: releases
+    shortNames:
+    - rel
+    singular: release
+    categories:
+    - all
+  scope: Namespaced
+  version: v1`
+)
+
+// Publisher publishes the generated changelog to a target such as the release on the git provider or a file
+type Publisher interface {
+	// Name returns the name of the target used when reporting the results of publishing
+	Name() string
+
+	// Publish publishes the changelog
+	Publish(ctx context.Context, result *Result) error
+}
+
+// PublishReport the outcome of publishing the changelog to a target
+type PublishReport struct {
+	// Target the name of the publisher
+	Target string
+
+	// Error the error publishing to the target or nil if it succeeded
+	Error error
+}
+
+// publishTarget a publisher along with the error policy deciding if its failure fails publishing
+type publishTarget struct {
+	Publisher
+	policy string
+}
+
+// Publish publishes the changelog to each target in turn. A failing target does not stop the remaining targets
+// from being published to. The outcome of each target is reported in the result and an error is returned if any
+// target whose error policy is to fail could not be published to
+func (g *Generator) Publish(ctx context.Context, result *Result) error {
+	g.State.Context = ctx
+	release := result.Release
+	g.State.Release = release
+	if result.Tag == "" {
+		result.Tag = release.Spec.Version
+	}
+	stopPublish := g.StartPhase(PhasePublish)
+	var failed []string
+	var errs []error
+	for _, t := range g.publishTargets() {
+		name := t.Name()
+		err := t.Publish(ctx, result)
+		result.Published = append(result.Published, PublishReport{Target: name, Error: err})
+		if err != nil {
+			err = HandleError(t.policy, errors.Wrapf(err, "failed to publish the changelog to %s", name))
+			if err != nil {
+				log.Logger().Warn(err.Error())
+				failed = append(failed, name)
+				errs = append(errs, err)
+			}
+			continue
+		}
+		log.Logger().Debugf("published the changelog to %s", name)
+	}
+	stopPublish()
+	release.Spec.Version = strings.TrimPrefix(release.Spec.Version, "v")
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	if len(errs) > 1 {
+		var messages []string
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return withKind(errors.Errorf("failed to publish the changelog to %s:\n%s", strings.Join(failed, ", "), strings.Join(messages, "\n")), errs...)
+	}
+	log.Logger().WithFields(LogFields(&release.Spec, result.Range.PreviousRev, result.Range.CurrentRev)).Info("generated the changelog")
+	return nil
+}
+
+// publishTargets returns the targets the changelog is published to in order followed by any custom publishers
+func (g *Generator) publishTargets() []publishTarget {
+	var answer []publishTarget
+	if g.ApprovalPR {
+		answer = append(answer, publishTarget{&approvalPublisher{g}, ErrorPolicyFail})
+	} else if g.UpdateRelease {
+		answer = append(answer, publishTarget{&gitReleasePublisher{g}, ErrorPolicyFail})
+	} else if g.OutputMarkdownFile != "" {
+		answer = append(answer, publishTarget{&markdownFilePublisher{g}, ErrorPolicyFail})
+	} else {
+		answer = append(answer, publishTarget{&logPublisher{}, ErrorPolicyFail})
+	}
+	if g.GenerateReleaseYaml {
+		answer = append(answer, publishTarget{&releaseYamlPublisher{g}, ErrorPolicyFail})
+	}
+	if g.GenerateCRD {
+		answer = append(answer, publishTarget{&crdPublisher{g}, ErrorPolicyFail})
+	}
+	if g.ExportEnvFile != "" {
+		answer = append(answer, publishTarget{&envFilePublisher{g}, ErrorPolicyFail})
+	}
+	if g.CalendarFile != "" {
+		answer = append(answer, publishTarget{&calendarPublisher{g}, ErrorPolicyFail})
+	}
+	if g.SiteDir != "" || g.SiteBranch != "" {
+		answer = append(answer, publishTarget{&sitePublisher{g}, ErrorPolicyFail})
+	}
+	if g.PublishWiki {
+		answer = append(answer, publishTarget{&wikiPublisher{g}, ErrorPolicyFail})
+	}
+	if g.TranslationOutput == TranslationOutputFile && len(g.TranslateLanguages) > 0 {
+		answer = append(answer, publishTarget{&translationFilePublisher{g}, ErrorPolicyFail})
+	}
+	if g.JiraVersion {
+		answer = append(answer, publishTarget{&jiraVersionPublisher{g}, ErrorPolicyFail})
+	}
+	if g.ProjectBoard != "" {
+		answer = append(answer, publishTarget{&projectBoardPublisher{g}, ErrorPolicyFail})
+	}
+	if g.ChangesetDir != "" {
+		answer = append(answer, publishTarget{&changesetPublisher{g}, ErrorPolicyFail})
+	}
+	// lets only notify once the notes awaiting approval are published
+	if len(g.channelNotify()) > 0 && !g.ApprovalPR {
+		answer = append(answer, publishTarget{&notifyPublisher{g}, ErrorPolicyWarn})
+	}
+	for _, p := range g.Publishers {
+		answer = append(answer, publishTarget{p, ErrorPolicyFail})
+	}
+	return answer
+}
+
+// gitReleasePublisher creates or updates the release of the tag on the git provider
+type gitReleasePublisher struct {
+	g *Generator
+}
+
+func (p *gitReleasePublisher) Name() string {
+	return "git-release"
+}
+
+func (p *gitReleasePublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	release := result.Release
+	markdown := result.Markdown
+	scmClient := g.ScmFactory.ScmClient
+	version := release.Spec.Version
+
+	// lets keep a tag which is not just the version such as that of notes published from a file
+	tagName := result.Tag
+	var err error
+	if tagName == "" || tagName == version {
+		tagName, err = g.releaseTag(version)
+		if err != nil {
+			return err
+		}
+	}
+	result.Tag = tagName
+	description := markdown
+	if g.ReleaseMetadata {
+		description, err = AddMetadata(markdown, g.releaseMetadata(result))
+		if err != nil {
+			return err
+		}
+	}
+	releaseInfo := &scm.ReleaseInput{
+		Title:       version,
+		Tag:         tagName,
+		Description: description,
+		Prerelease:  g.State.Channel != nil && g.State.Channel.Prerelease,
+	}
+
+	fullName := scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository)
+
+	// lets try find a release for the tag
+	rel, res, err := scmClient.Releases.FindByTag(ctx, fullName, tagName)
+
+	if isReleaseNotFound(err, g.ScmFactory.GitKind) {
+		err = nil
+		rel = nil
+	}
+	if err != nil {
+		return errors.Wrapf(scmError(res, err), "failed to query release on repo %s for tag %s", fullName, tagName)
+	}
+	if rel == nil {
+		rel, res, err = scmClient.Releases.Create(ctx, fullName, releaseInfo)
+		if err != nil {
+			return HandleError(g.OnReleaseError, errors.Wrapf(scmError(res, err), "failed to create the release for %s", fullName))
+		}
+	} else {
+		warnIfEdited(rel, fullName)
+		id := rel.ID
+		if rel.ID != 0 {
+			rel, res, err = scmClient.Releases.Update(ctx, fullName, rel.ID, releaseInfo)
+		} else {
+			rel, res, err = scmClient.Releases.UpdateByTag(ctx, fullName, rel.Tag, releaseInfo)
+		}
+		if err != nil {
+			return HandleError(g.OnReleaseError, errors.Wrapf(scmError(res, err), "failed to update the release for %s number: %d", fullName, id))
+		}
+	}
+	assets, err := g.SignAssets(result.Assets)
+	if err != nil {
+		return err
+	}
+	err = g.uploadReleaseAssets(ctx, fullName, rel, assets)
+	if err != nil {
+		return err
+	}
+	url := ""
+	if rel != nil {
+		url = rel.Link
+	}
+	if url == "" {
+		url = stringhelpers.UrlJoin(g.State.GitInfo.HttpsURL(), "releases/tag", tagName)
+	}
+	release.Spec.ReleaseNotesURL = url
+	log.Logger().Infof("updated the release information at %s", info(url))
+	log.Logger().Debugf("added description: %s", description)
+	return nil
+}
+
+// releaseTag returns the tag of the version which has a 'v' prefix if only the tag with the prefix exists
+func (g *Generator) releaseTag(version string) (string, error) {
+	dir := g.ScmFactory.Dir
+	vVersion := fmt.Sprintf("v%s", version)
+	tags, err := gits.ListTags(g.Git(), dir, 0, version, vVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing tags %s and %s in %s", version, vVersion, dir)
+	}
+	foundTag := false
+	foundVTag := false
+	for _, t := range tags {
+		switch t.Name {
+		case version:
+			foundTag = true
+		case vVersion:
+			foundVTag = true
+		}
+	}
+	if foundVTag && !foundTag {
+		return vVersion, nil
+	}
+	return version, nil
+}
+
+// warnIfEdited warns if the generated body of the existing release was edited by hand as the edits are replaced
+func warnIfEdited(rel *scm.Release, fullName string) {
+	metadata, content, err := ParseMetadata(rel.Description)
+	if err != nil {
+		log.Logger().Warnf("failed to parse the metadata of the release %s on repo %s: %s", rel.Tag, fullName, err.Error())
+		return
+	}
+	if metadata != nil && metadata.Edited(content) {
+		log.Logger().Warnf("replacing the hand edited release notes of %s on repo %s", rel.Tag, fullName)
+	}
+}
+
+// markdownFilePublisher writes the rendered changelog to the output file
+type markdownFilePublisher struct {
+	g *Generator
+}
+
+func (p *markdownFilePublisher) Name() string {
+	return "output-file"
+}
+
+func (p *markdownFilePublisher) Publish(ctx context.Context, result *Result) error {
+	path := p.g.OutputMarkdownFile
+	output := result.Output
+	if c := p.g.State.Channel; c != nil {
+		data, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to load the changelog file %s", path)
+		}
+		output = UpdateChannelSection(string(data), c, output)
+	}
+	err := ioutil.WriteFile(path, []byte(output), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the changelog file %s", path)
+	}
+	log.Logger().Infof("\nGenerated Changelog: %s", info(path))
+	return nil
+}
+
+// logPublisher logs the rendered changelog
+type logPublisher struct{}
+
+func (p *logPublisher) Name() string {
+	return "log"
+}
+
+func (p *logPublisher) Publish(ctx context.Context, result *Result) error {
+	log.Logger().Infof("\nGenerated Changelog:")
+	log.Logger().Infof("%s\n", result.Output)
+	return nil
+}
+
+// releaseYamlPublisher writes the Release YAML into the templates directory of the helm chart
+type releaseYamlPublisher struct {
+	g *Generator
+}
+
+func (p *releaseYamlPublisher) Name() string {
+	return "release-yaml"
+}
+
+func (p *releaseYamlPublisher) Publish(ctx context.Context, result *Result) error {
+	release := result.Release
+	if p.g.Reproducible {
+		// lets sort a copy so that the commits stay in the order of the git history for the other targets
+		release = release.DeepCopy()
+		makeReproducible(release)
+	}
+	data, err := yaml.Marshal(release)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal Release")
+	}
+	if data == nil {
+		return fmt.Errorf("could not marshal release to yaml")
+	}
+	releaseFile := filepath.Join(result.TemplatesDir, p.g.ReleaseYamlFile)
+	err = ioutil.WriteFile(releaseFile, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save Release YAML file %s", releaseFile)
+	}
+	log.Logger().Infof("generated: %s", info(releaseFile))
+	return nil
+}
+
+// crdPublisher writes the Release CustomResourceDefinition into the templates directory of the helm chart
+type crdPublisher struct {
+	g *Generator
+}
+
+func (p *crdPublisher) Name() string {
+	return "release-crd"
+}
+
+func (p *crdPublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	templatesDir := result.TemplatesDir
+	crdFile := filepath.Join(templatesDir, g.CrdYamlFile)
+	exists, err := files.FileExists(crdFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check for CRD YAML file %s", crdFile)
+	}
+	if !g.OverwriteCRD && exists {
+		return nil
+	}
+	err = ioutil.WriteFile(crdFile, []byte(ReleaseCrdYaml), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save Release CRD YAML file %s", crdFile)
+	}
+	log.Logger().Infof("generated: %s", info(crdFile))
+
+	err = gitclient.Add(g.Git(), templatesDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to git add in dir %s", templatesDir)
+	}
+	return nil
+}
+
+// envFilePublisher writes the details of the changelog as environment variables
+type envFilePublisher struct {
+	g *Generator
+}
+
+func (p *envFilePublisher) Name() string {
+	return "export-env-file"
+}
+
+func (p *envFilePublisher) Publish(ctx context.Context, result *Result) error {
+	return p.g.exportEnvFile(&result.Release.Spec, result.Tag, result.Range.PreviousRev, result.Range.CurrentRev)
+}
+
+func isReleaseNotFound(err error, gitKind string) bool {
+	if gitKind == "gitlab" {
+		if err == nil {
+			return false
+		}
+		return strings.Contains(err.Error(), scm.ErrForbidden.Error())
+	} else {
+		return scmhelpers.IsScmNotFound(err)
+	}
+}
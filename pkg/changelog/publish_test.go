@@ -0,0 +1,114 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakePublisher struct {
+	name string
+	err  error
+}
+
+func (p *fakePublisher) Name() string {
+	return p.name
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, result *changelog.Result) error {
+	return p.err
+}
+
+func TestPublishContinuesOnFailures(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	markdownFile := filepath.Join(tmpDir, "changelog.md")
+	g := &changelog.Generator{
+		OutputMarkdownFile:  markdownFile,
+		GenerateReleaseYaml: true,
+		ReleaseYamlFile:     "release.yaml",
+		Publishers: []changelog.Publisher{
+			&fakePublisher{name: "broken", err: &changelog.Error{Kind: changelog.ErrReleaseConflict, Err: errors.New("boom")}},
+			&fakePublisher{name: "working"},
+		},
+	}
+	result := &changelog.Result{
+		Range: &changelog.Range{},
+		Release: &v1.Release{
+			Spec: v1.ReleaseSpec{Version: "v1.2.3"},
+		},
+		Output:       "## Changes\n",
+		TemplatesDir: tmpDir,
+	}
+	err = g.Publish(context.TODO(), result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to publish the changelog to broken")
+	assert.True(t, errors.Is(err, changelog.ErrReleaseConflict))
+
+	targets := map[string]error{}
+	for _, r := range result.Published {
+		targets[r.Target] = r.Error
+	}
+	assert.Len(t, targets, 4)
+	assert.Error(t, targets["broken"])
+	assert.NoError(t, targets["working"])
+	assert.NoError(t, targets["output-file"])
+	assert.Equal(t, "1.2.3", result.Release.Spec.Version)
+	assert.FileExists(t, markdownFile)
+	assert.FileExists(t, filepath.Join(tmpDir, "release.yaml"))
+}
+
+func TestPublishReproducibleReleaseYaml(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	g := &changelog.Generator{
+		GenerateReleaseYaml: true,
+		ReleaseYamlFile:     "release.yaml",
+		Reproducible:        true,
+	}
+	publish := func(commits []v1.CommitSummary, issues []v1.IssueSummary) string {
+		result := &changelog.Result{
+			Range: &changelog.Range{},
+			Release: &v1.Release{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()},
+				Spec: v1.ReleaseSpec{
+					Version:      "v1.2.3",
+					Commits:      commits,
+					PullRequests: issues,
+				},
+			},
+			TemplatesDir: tmpDir,
+		}
+		require.NoError(t, g.Publish(context.TODO(), result))
+		assert.Equal(t, commits, result.Release.Spec.Commits, "the commits should keep the order of the git history")
+		data, err := ioutil.ReadFile(filepath.Join(tmpDir, "release.yaml"))
+		require.NoError(t, err)
+		return string(data)
+	}
+	expected := publish(
+		[]v1.CommitSummary{{SHA: "bbb", IssueIDs: []string{"2", "1"}}, {SHA: "aaa"}},
+		[]v1.IssueSummary{{ID: "10"}, {ID: "9", Labels: []v1.IssueLabel{{Name: "b"}, {Name: "a"}}}},
+	)
+	actual := publish(
+		[]v1.CommitSummary{{SHA: "aaa"}, {SHA: "bbb", IssueIDs: []string{"1", "2"}}},
+		[]v1.IssueSummary{{ID: "9", Labels: []v1.IssueLabel{{Name: "a"}, {Name: "b"}}}, {ID: "10"}},
+	)
+	assert.Equal(t, expected, actual)
+	assert.NotContains(t, actual, "deletionTimestamp")
+	assert.Less(t, strings.Index(actual, "sha: aaa"), strings.Index(actual, "sha: bbb"))
+}
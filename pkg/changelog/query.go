@@ -0,0 +1,186 @@
+package changelog
+
+import (
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+// FromIssueTracker returns true if the changelog is collected from the issue tracker rather than the git history
+func (r *Range) FromIssueTracker() bool {
+	return r.Milestone != "" || r.PullRequestsMerged
+}
+
+// Description describes the issues or pull requests of the range which are collected from the issue tracker
+func (r *Range) Description() string {
+	if r.Milestone != "" {
+		return "milestone " + r.Milestone
+	}
+	answer := "pull requests merged"
+	if r.BaseBranch != "" {
+		answer += " into " + r.BaseBranch
+	}
+	if r.MergedSince != nil {
+		answer += " since " + r.MergedSince.Format(time.RFC3339)
+	}
+	if r.MergedUntil != nil {
+		answer += " until " + r.MergedUntil.Format(time.RFC3339)
+	}
+	return answer
+}
+
+// validateQuery validates the milestone and merged pull request modes which cannot be combined with each other nor
+// the options of the git range
+func (g *Generator) validateQuery() error {
+	var err error
+	g.State.MergedSince, err = parseTime("prs-merged-since", g.PullRequestsMergedSince)
+	if err != nil {
+		return err
+	}
+	g.State.MergedUntil, err = parseTime("prs-merged-until", g.PullRequestsMergedUntil)
+	if err != nil {
+		return err
+	}
+	name, value := "milestone", g.Milestone
+	if g.PullRequestsMergedSince != "" || g.PullRequestsMergedUntil != "" {
+		if g.Milestone != "" {
+			return options.InvalidOptionf("milestone", g.Milestone, "cannot be used with --prs-merged-since or --prs-merged-until")
+		}
+		name, value = "prs-merged-since", g.PullRequestsMergedSince
+		if value == "" {
+			name, value = "prs-merged-until", g.PullRequestsMergedUntil
+		}
+	} else if g.BaseBranch != "" {
+		return options.InvalidOptionf("base-branch", g.BaseBranch, "can only be used with --prs-merged-since or --prs-merged-until")
+	}
+	switch {
+	case value == "":
+		return nil
+	case g.PreviousRevision != "":
+		return options.InvalidOptionf(name, value, "cannot be used with --previous-rev")
+	case g.PreviousDate != "":
+		return options.InvalidOptionf(name, value, "cannot be used with --previous-date")
+	case g.FirstRelease:
+		return options.InvalidOptionf(name, value, "cannot be used with --first-release")
+	}
+	return nil
+}
+
+// parseTime parses the option as a date such as '2020-07-01' or a RFC 3339 time returning the zero time if it is
+// empty
+func parseTime(name, text string) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", text)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, text)
+	}
+	if err != nil {
+		return t, options.InvalidOptionf(name, text, "should be a date such as 2020-07-01 or a RFC 3339 time")
+	}
+	return t, nil
+}
+
+// queryRange returns the range of the milestone or merged pull requests which are released from the current revision
+func (g *Generator) queryRange() *Range {
+	g.State.DefaultBranch = g.defaultBranch()
+	rng := &Range{
+		Milestone:   g.Milestone,
+		CurrentRev:  g.CurrentRevision,
+		CurrentName: g.CurrentRevision,
+	}
+	if rng.CurrentRev == "" {
+		rng.CurrentRev = "HEAD"
+		rng.CurrentName = g.State.DefaultBranch
+	}
+	if g.PullRequestsMergedSince != "" || g.PullRequestsMergedUntil != "" {
+		rng.PullRequestsMerged = true
+		rng.BaseBranch = g.BaseBranch
+		if rng.BaseBranch == "" {
+			rng.BaseBranch = g.State.DefaultBranch
+		}
+		if !g.State.MergedSince.IsZero() {
+			since := g.State.MergedSince
+			rng.MergedSince = &since
+		}
+		if !g.State.MergedUntil.IsZero() {
+			until := g.State.MergedUntil
+			rng.MergedUntil = &until
+		}
+	}
+	return rng
+}
+
+// collectQuery adds the issues and pull requests of the milestone or the pull requests merged between the times to
+// the changelog
+func (g *Generator) collectQuery(model *Changelog, rng *Range) error {
+	var keys []string
+	var err error
+	stopLookup := g.StartPhase(PhaseIssueLookup)
+	if rng.Milestone != "" {
+		keys, err = g.milestoneIssues(rng.Milestone)
+	} else {
+		keys, err = g.mergedPullRequests(rng)
+	}
+	stopLookup()
+	if err != nil {
+		return err
+	}
+	var scanned []refs.Ref
+	for _, key := range keys {
+		scanned = append(scanned, refs.Ref{ID: key})
+	}
+	found, err := g.State.Refs.Resolve(scanned)
+	for i := range found {
+		model.addIssue(&found[i])
+	}
+	return err
+}
+
+func (g *Generator) milestoneIssues(milestone string) ([]string, error) {
+	tracker, ok := g.State.Tracker.(issues.MilestoneIssuesProvider)
+	if !ok {
+		return nil, options.InvalidOptionf("milestone", milestone, "the issue tracker %s does not support milestones", g.State.Tracker.HomeURL())
+	}
+	keys, err := tracker.MilestoneIssues(milestone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the issues of milestone %s", milestone)
+	}
+	return keys, nil
+}
+
+func (g *Generator) mergedPullRequests(rng *Range) ([]string, error) {
+	tracker, ok := g.State.Tracker.(issues.MergedPullRequestsProvider)
+	if !ok {
+		return nil, errors.Errorf("the issue tracker %s does not support finding merged pull requests", g.State.Tracker.HomeURL())
+	}
+	var since, until time.Time
+	if rng.MergedSince != nil {
+		since = *rng.MergedSince
+	}
+	if rng.MergedUntil != nil {
+		until = *rng.MergedUntil
+	}
+	keys, err := tracker.MergedPullRequests(rng.BaseBranch, since, until)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find %s", rng.Description())
+	}
+	return keys, nil
+}
+
+// checkQuery checks the range has enough issues and pull requests as it has no commits
+func (g *Generator) checkQuery(spec *v1.ReleaseSpec, rng *Range) error {
+	count := len(spec.Issues) + len(spec.PullRequests)
+	if g.FailIfFindCommits && count == 0 {
+		return newError(ErrNoCommits, "no issues or pull requests found for %s", rng.Description())
+	}
+	if g.MinCommits > 0 && count < g.MinCommits {
+		return newError(ErrNoCommits, "found %d issues and pull requests for %s but at least %d are required", count, rng.Description(), g.MinCommits)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// TemplateRelease a previous release of the repository on the git provider available to the header and footer
+// templates
+type TemplateRelease struct {
+	Tag        string
+	Name       string
+	URL        string
+	Prerelease bool
+
+	// Date the date of the commit of the tag. Zero if the tag is not in the local repository
+	Date time.Time
+}
+
+// templateFuncs returns the functions available to the header and footer templates:
+//
+//	releases                  the previous releases newest first
+//	previousRelease           the latest previous release or nil
+//	releasesSince "v1.0.0"    the releases after the release of the tag or since a date such as "2020-07-01"
+//	quarterStart              the date the current quarter started such as "2020-07-01"
+//	ordinal 3                 the ordinal of the number such as "3rd"
+//	add 2 1                   the sum of the numbers
+func (g *Generator) templateFuncs(data *TemplateData) template.FuncMap {
+	current := ""
+	if data != nil && data.ReleaseSpec != nil {
+		current = strings.TrimPrefix(data.ReleaseSpec.Version, "v")
+	}
+	releases := func() ([]*TemplateRelease, error) {
+		return g.previousReleases(current)
+	}
+	funcs := template.FuncMap{
+		"releases": releases,
+		"previousRelease": func() (*TemplateRelease, error) {
+			all, err := releases()
+			if err != nil || len(all) == 0 {
+				return nil, err
+			}
+			return all[0], nil
+		},
+		"releasesSince": func(since string) ([]*TemplateRelease, error) {
+			all, err := releases()
+			if err != nil {
+				return nil, err
+			}
+			return ReleasesSince(all, since)
+		},
+		"quarterStart": func() string {
+			now := g.now()
+			return time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02")
+		},
+		"ordinal": Ordinal,
+		"add": func(a, b int) int {
+			return a + b
+		},
+	}
+	for name, fn := range textFuncs(g.now) {
+		if funcs[name] == nil {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+// ReleasesSince returns the releases which are newest first that were made after the release of the tag or on or
+// after the date if it is a date such as '2020-07-01'
+func ReleasesSince(releases []*TemplateRelease, since string) ([]*TemplateRelease, error) {
+	date, err := time.Parse("2006-01-02", since)
+	if err != nil {
+		date, err = time.Parse(time.RFC3339, since)
+	}
+	if err == nil {
+		var answer []*TemplateRelease
+		for _, r := range releases {
+			if !r.Date.Before(date) {
+				answer = append(answer, r)
+			}
+		}
+		return answer, nil
+	}
+	for i, r := range releases {
+		if r.Tag == since || strings.TrimPrefix(r.Tag, "v") == strings.TrimPrefix(since, "v") {
+			return releases[:i], nil
+		}
+	}
+	return nil, errors.Errorf("there is no release with the tag %s", since)
+}
+
+// Ordinal returns the ordinal of the number such as '1st', '2nd' or '11th'
+func Ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// previousReleases lists the published releases on the git provider other than the current version newest first.
+// The releases are only listed once
+func (g *Generator) previousReleases(current string) ([]*TemplateRelease, error) {
+	if g.State.PreviousReleases != nil {
+		return g.State.PreviousReleases, nil
+	}
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient == nil || g.ScmFactory.Owner == "" || g.ScmFactory.Repository == "" {
+		return nil, errors.Errorf("cannot list the releases without a git provider")
+	}
+	fullName := scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository)
+	answer := []*TemplateRelease{}
+	opts := scm.ReleaseListOptions{Page: 1, Size: 100}
+	for {
+		releases, res, err := scmClient.Releases.List(g.State.Context, fullName, opts)
+		if err != nil && !scmhelpers.IsScmNotFound(err) {
+			return nil, errors.Wrapf(scmError(res, err), "failed to list the releases of %s", fullName)
+		}
+		for _, r := range releases {
+			if r.Draft || r.Tag == "" || strings.TrimPrefix(r.Tag, "v") == current {
+				continue
+			}
+			answer = append(answer, &TemplateRelease{
+				Tag:        r.Tag,
+				Name:       r.Title,
+				URL:        r.Link,
+				Prerelease: r.Prerelease,
+			})
+		}
+		if len(releases) < opts.Size {
+			break
+		}
+		opts.Page++
+	}
+	if len(answer) > 0 {
+		dates := g.tagDates()
+		for _, r := range answer {
+			r.Date = dates[r.Tag]
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Date.After(answer[j].Date)
+	})
+	g.State.PreviousReleases = answer
+	return answer, nil
+}
+
+// tagDates returns the dates of the commits of the tags in the local repository indexed by tag name
+func (g *Generator) tagDates() map[string]time.Time {
+	answer := map[string]time.Time{}
+	tags, err := gits.ListTags(g.Git(), g.ScmFactory.Dir, 0)
+	if err != nil {
+		log.Logger().Debugf("failed to find the dates of the tags: %s", err.Error())
+		return answer
+	}
+	for _, t := range tags {
+		answer[t.Name] = t.Date
+	}
+	return answer
+}
@@ -0,0 +1,95 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseTemplateFunctions(t *testing.T) {
+	t.Parallel()
+	scmClient, fakeData := scmfake.NewDefault()
+	fakeData.Releases = map[string]map[int]*scm.Release{}
+	fakeData.Releases["myorg/myrepo"] = map[int]*scm.Release{
+		1: {ID: 1, Tag: "v1.0.0", Title: "v1.0.0", Link: "https://github.com/myorg/myrepo/releases/tag/v1.0.0"},
+		2: {ID: 2, Tag: "v1.1.0", Title: "v1.1.0", Link: "https://github.com/myorg/myrepo/releases/tag/v1.1.0"},
+		3: {ID: 3, Tag: "v1.1.1", Title: "v1.1.1", Link: "https://github.com/myorg/myrepo/releases/tag/v1.1.1"},
+		4: {ID: 4, Tag: "v1.2.0-rc1", Draft: true},
+		5: {ID: 5, Tag: "v1.2.0", Title: "v1.2.0"},
+	}
+	tagDates := map[string]string{
+		"v1.0.0": "2020-05-02T10:00:00Z",
+		"v1.1.0": "2020-07-14T10:00:00Z",
+		"v1.1.1": "2020-08-20T10:00:00Z",
+	}
+	runner := func(c *cmdrunner.Command) (string, error) {
+		if len(c.Args) > 0 && c.Args[0] == "for-each-ref" {
+			var lines []string
+			for tag, date := range tagDates {
+				lines = append(lines, strings.Join([]string{"refs/tags/" + tag, "1111111", "", date, ""}, "\x00"))
+			}
+			return strings.Join(lines, "\n"), nil
+		}
+		return "", errors.Errorf("unknown command")
+	}
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+
+	g := &changelog.Generator{
+		CommandRunner: runner,
+		Clock:         changelogtest.NewClock(changelogtest.DefaultTime),
+		Header: `Previous: [{{ with previousRelease }}{{ .Tag }}]({{ .URL }}){{ end }}
+This is the {{ add (len (releasesSince quarterStart)) 1 | ordinal }} release this quarter and the {{ add (len (releasesSince "v1.0.0")) 1 | ordinal }} since 1.0.0 of {{ len releases }}
+`,
+	}
+	g.ScmFactory.ScmClient = scmClient
+	g.ScmFactory.Owner = "myorg"
+	g.ScmFactory.Repository = "myrepo"
+	require.NoError(t, g.Validate())
+	g.State.GitInfo = gitInfo
+	result := &changelog.Result{
+		Range:           &changelog.Range{},
+		Release:         &v1.Release{Spec: v1.ReleaseSpec{Version: "1.2.0", Commits: []v1.CommitSummary{{SHA: "1111111aaaa", Message: "fix: a bug"}}}},
+		Changelog:       &changelog.Changelog{Commits: []*changelog.Commit{changelog.NewCommit("1111111aaaa", "fix: a bug")}},
+		MarkdownOptions: &gits.MarkdownOptions{},
+	}
+	require.NoError(t, g.Render(context.Background(), result))
+	assert.Contains(t, result.Markdown, "Previous: [v1.1.1](https://github.com/myorg/myrepo/releases/tag/v1.1.1)\n"+
+		"This is the 3rd release this quarter and the 3rd since 1.0.0 of 3\n")
+}
+
+func TestReleasesSince(t *testing.T) {
+	t.Parallel()
+	releases := []*changelog.TemplateRelease{
+		{Tag: "v1.1.0", Date: time.Date(2020, time.July, 14, 0, 0, 0, 0, time.UTC)},
+		{Tag: "v1.0.0", Date: time.Date(2020, time.May, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	found, err := changelog.ReleasesSince(releases, "2020-07-01")
+	require.NoError(t, err)
+	assert.Equal(t, releases[:1], found)
+	found, err = changelog.ReleasesSince(releases, "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, releases[:1], found)
+	_, err = changelog.ReleasesSince(releases, "v0.9.0")
+	assert.Error(t, err)
+
+	assert.Equal(t, "1st", changelog.Ordinal(1))
+	assert.Equal(t, "22nd", changelog.Ordinal(22))
+	assert.Equal(t, "11th", changelog.Ordinal(11))
+	assert.Equal(t, "113th", changelog.Ordinal(113))
+}
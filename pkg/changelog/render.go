@@ -0,0 +1,152 @@
+package changelog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"text/template"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// TemplateData the data available to the header and footer templates
+type TemplateData struct {
+	*v1.ReleaseSpec
+
+	// Contributors the authors of the commits in the release
+	Contributors []gits.Contributor
+
+	// Reviewers the approving reviewers of the pull requests in the release
+	Reviewers []gits.Reviewer
+
+	// Trailers the trailers of the commit messages such as 'Ticket: ABC-123' indexed by commit SHA then trailer key
+	Trailers map[string]map[string]string
+
+	// Groups the commits of the release grouped by their Conventional Commits type in the order of the sections
+	Groups []CommitGroup
+
+	// CompareURL the URL of the page comparing the revisions of the release
+	CompareURL string
+}
+
+// Render renders the markdown of the changelog for the git provider release along with the output in the chosen format
+func (g *Generator) Render(ctx context.Context, result *Result) error {
+	g.State.Context = ctx
+	release := result.Release
+	markdownOptions := result.MarkdownOptions
+
+	stopRender := g.StartPhase(PhaseRender)
+	defer stopRender()
+
+	templateData := &TemplateData{
+		ReleaseSpec:  &release.Spec,
+		Contributors: gits.Contributors(&release.Spec),
+		Reviewers:    aggregateReviewers(markdownOptions.Reviewers),
+		Trailers:     g.State.Trailers,
+		CompareURL:   markdownOptions.CompareURL,
+	}
+	notes := result.Changelog
+	if notes != nil && len(g.ExcludeLabels) > 0 {
+		// lets leave the excluded changes out of the release notes while keeping them in the Release
+		notes = notes.WithoutLabels(g.ExcludeLabels)
+		if notes != result.Changelog {
+			spec := release.Spec
+			notes.ProjectInto(&spec)
+			templateData.ReleaseSpec = &spec
+		}
+	}
+	if notes != nil {
+		templateData.Groups = notes.Groups()
+	}
+	if g.Highlights > 0 && notes != nil {
+		markdownOptions.Highlights = notes.Highlights(g.Highlights, g.HighlightLabels)
+	}
+	err := addTrailersAnnotation(release, templateData.Trailers)
+	if err != nil {
+		return err
+	}
+	if g.Contributors {
+		err = addContributorsAnnotation(release, templateData.Contributors)
+		if err != nil {
+			return err
+		}
+	}
+	input := &RenderInput{
+		TemplateData:        templateData,
+		Changelog:           notes,
+		GitInfo:             g.State.GitInfo,
+		MarkdownOptions:     markdownOptions,
+		ContributorsSection: g.Contributors,
+		ReviewersSection:    g.Reviewers,
+	}
+	if g.NewContributors && result.Changelog != nil {
+		input.NewContributors = g.findNewContributors(&release.Spec, result.Changelog.Commits, result.Range.PreviousRev)
+	}
+	input.Header, err = g.getTemplateResult(templateData, "header", g.Header, g.HeaderFile)
+	if err != nil {
+		return err
+	}
+	input.Footer, err = g.getTemplateResult(templateData, "footer", g.Footer, g.FooterFile)
+	if err != nil {
+		return err
+	}
+	if g.Template != "" || g.TemplateFile != "" {
+		input.BodyTemplate = true
+		input.Body, err = g.getTemplateResult(templateData, "template", g.Template, g.TemplateFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to render the changelog template")
+		}
+	}
+	if checksums := ChecksumsMarkdown(g.State.Checksums); checksums != "" {
+		input.Footer = "\n" + checksums + input.Footer
+	}
+	result.TemplateData = templateData
+	result.Markdown, err = (&MarkdownRenderer{}).Render(input)
+	if err != nil {
+		return err
+	}
+	result.Markdown = g.linkIssueMentions(result.Markdown)
+	err = g.translate(ctx, result)
+	if err != nil {
+		return err
+	}
+	log.Logger().Debugf("Generated release notes:\n\n%s\n", result.Markdown)
+
+	result.Output = result.Markdown
+	if g.State.Renderer != nil {
+		result.Output, err = g.State.Renderer.Render(input)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render the changelog as %s", g.Format)
+		}
+	}
+	return g.renderReleaseAssets(result, input)
+}
+
+func (g *Generator) getTemplateResult(templateData *TemplateData, templateName string, templateText string, templateFile string) (string, error) {
+	if templateText == "" {
+		if templateFile == "" {
+			return "", nil
+		}
+		data, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return "", err
+		}
+		templateText = string(data)
+	}
+	if templateText == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(templateName).Funcs(g.templateFuncs(templateData)).Parse(templateText)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	writer := bufio.NewWriter(&buffer)
+	err = tmpl.Execute(writer, templateData)
+	writer.Flush()
+	return buffer.String(), err
+}
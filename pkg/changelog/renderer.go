@@ -0,0 +1,139 @@
+package changelog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RendererMarkdown renders the changelog as markdown
+	RendererMarkdown = "markdown"
+
+	// RendererJSON renders the changelog as a JSON document
+	RendererJSON = "json"
+
+	// RendererHTML renders the changelog as HTML
+	RendererHTML = "html"
+
+	// RendererSlack renders the changelog as a Slack message payload
+	RendererSlack = "slack"
+)
+
+// Renderer renders the changelog of a release in an output format
+type Renderer interface {
+	// Render renders the changelog
+	Render(input *RenderInput) (string, error)
+}
+
+// RendererFactory creates a renderer from its options
+type RendererFactory func(options RendererOptions) (Renderer, error)
+
+// RendererOptions the options of a renderer such as the title of the HTML page
+type RendererOptions map[string]string
+
+// RenderInput the collected data of the changelog given to a renderer
+type RenderInput struct {
+	*TemplateData
+
+	// Changelog the collected model of the changes
+	Changelog *Changelog
+
+	// GitInfo the git repository of the release
+	GitInfo *giturl.GitRepository
+
+	// MarkdownOptions the options used to describe commits, issues and users
+	MarkdownOptions *gits.MarkdownOptions
+
+	// NewContributors the authors whose first contribution is in the release if the section is enabled
+	NewContributors []gits.NewContributor
+
+	// ContributorsSection adds the section listing the contributors
+	ContributorsSection bool
+
+	// ReviewersSection adds the section listing the reviewers
+	ReviewersSection bool
+
+	// Header the rendered header template in markdown
+	Header string
+
+	// Footer the rendered footer template in markdown
+	Footer string
+
+	// BodyTemplate the changes are rendered by the custom template of the generator rather than generated
+	BodyTemplate bool
+
+	// Body the rendered custom template in markdown used as the body of the changelog if BodyTemplate is true
+	Body string
+}
+
+var rendererFactories = map[string]RendererFactory{
+	RendererMarkdown: NewMarkdownRenderer,
+	RendererJSON:     NewJSONRenderer,
+	RendererHTML:     NewHTMLRenderer,
+	RendererSlack:    NewSlackRenderer,
+	RendererPDF:      NewPDFRenderer,
+}
+
+// RegisterRenderer registers a renderer so that it can be chosen by name. Any existing renderer of the name is
+// replaced which lets plugins and forks add or override formats
+func RegisterRenderer(name string, factory RendererFactory) {
+	rendererFactories[name] = factory
+}
+
+// RendererNames returns the sorted names of the registered renderers
+func RendererNames() []string {
+	var answer []string
+	for name := range rendererFactories {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// NewRenderer creates the renderer of the given name using its options
+func NewRenderer(name string, options RendererOptions) (Renderer, error) {
+	if name == "" {
+		name = RendererMarkdown
+	}
+	factory := rendererFactories[name]
+	if factory == nil {
+		return nil, errors.Errorf("unknown renderer %s. Should be one of: %s", name, strings.Join(RendererNames(), ", "))
+	}
+	r, err := factory(options)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid options for renderer %s", name)
+	}
+	return r, nil
+}
+
+// Validate returns an error if there are any options other than the given names
+func (o RendererOptions) Validate(names ...string) error {
+	for k := range o {
+		if stringhelpers.StringArrayIndex(names, k) < 0 {
+			if len(names) == 0 {
+				return errors.Errorf("unknown option %s. The renderer has no options", k)
+			}
+			return errors.Errorf("unknown option %s. Should be one of: %s", k, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// Bool returns the boolean value of the option or the default value if it is not specified
+func (o RendererOptions) Bool(name string, defaultValue bool) (bool, error) {
+	text := o[name]
+	if text == "" {
+		return defaultValue, nil
+	}
+	answer, err := strconv.ParseBool(text)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid boolean value %s for option %s", text, name)
+	}
+	return answer, nil
+}
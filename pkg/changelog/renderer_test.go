@@ -0,0 +1,90 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderers(t *testing.T) {
+	t.Parallel()
+	gitInfo, err := giturl.ParseGitURL("https://github.com/jenkins-x/jx")
+	require.NoError(t, err)
+	spec := &v1.ReleaseSpec{
+		Name:    "jx",
+		Version: "1.2.3",
+		Commits: []v1.CommitSummary{
+			{
+				Message: "fix: some bug",
+				SHA:     "abcdef1234567",
+				URL:     "https://github.com/jenkins-x/jx/commit/abcdef1234567",
+			},
+		},
+	}
+	input := &changelog.RenderInput{
+		TemplateData:    &changelog.TemplateData{ReleaseSpec: spec},
+		GitInfo:         gitInfo,
+		MarkdownOptions: &gits.MarkdownOptions{},
+		Header:          "# Release 1.2.3\n\n",
+	}
+
+	assert.Subset(t, changelog.RendererNames(), []string{"html", "json", "markdown", "slack"})
+
+	r, err := changelog.NewRenderer("", nil)
+	require.NoError(t, err)
+	markdown, err := r.Render(input)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(markdown, "# Release 1.2.3\n\n## Changes\n"), "markdown: %s", markdown)
+
+	r, err = changelog.NewRenderer(changelog.RendererJSON, changelog.RendererOptions{"compact": "true"})
+	require.NoError(t, err)
+	text, err := r.Render(input)
+	require.NoError(t, err)
+	doc := &changelog.JSONChangelog{}
+	require.NoError(t, json.Unmarshal([]byte(text), doc))
+	assert.Equal(t, "1.2.3", doc.Release.Version)
+
+	r, err = changelog.NewRenderer(changelog.RendererHTML, changelog.RendererOptions{"page": "true"})
+	require.NoError(t, err)
+	text, err = r.Render(input)
+	require.NoError(t, err)
+	assert.Contains(t, text, "<title>jx 1.2.3</title>")
+	assert.Contains(t, text, "<h2>Changes</h2>")
+
+	r, err = changelog.NewRenderer(changelog.RendererSlack, changelog.RendererOptions{"channel": "releases"})
+	require.NoError(t, err)
+	text, err = r.Render(input)
+	require.NoError(t, err)
+	msg := &changelog.SlackMessage{}
+	require.NoError(t, json.Unmarshal([]byte(text), msg))
+	assert.Equal(t, "releases", msg.Channel)
+	assert.Contains(t, msg.Text, "*Changes*")
+
+	_, err = changelog.NewRenderer("docx", nil)
+	assert.Error(t, err)
+	_, err = changelog.NewRenderer(changelog.RendererMarkdown, changelog.RendererOptions{"title": "foo"})
+	assert.Error(t, err)
+}
+
+func TestToSlackMarkdown(t *testing.T) {
+	t.Parallel()
+	text := changelog.ToSlackMarkdown("### Bug Fixes\n\n* fix a <b>bug</b> ([#12](https://github.com/foo/bar/issues/12)) **important**\n")
+	assert.Equal(t, "*Bug Fixes*\n\n• fix a &lt;b&gt;bug&lt;/b&gt; (<https://github.com/foo/bar/issues/12|#12>) *important*\n", text)
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	changelog.RegisterRenderer("text", func(options changelog.RendererOptions) (changelog.Renderer, error) {
+		return &changelog.MarkdownRenderer{}, nil
+	})
+	_, err := changelog.NewRenderer("text", nil)
+	assert.NoError(t, err)
+}
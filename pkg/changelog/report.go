@@ -0,0 +1,128 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/pkg/errors"
+)
+
+// ReleaseFilter selects the Release resources of an environment report
+type ReleaseFilter struct {
+	// Since if not zero only releases created at or after the time are included
+	Since time.Time
+
+	// Until if not zero only releases created before the time are included
+	Until time.Time
+
+	// AfterRelease if specified only releases created after the Release of the name are included so that a report
+	// covers the releases promoted since a previous promotion
+	AfterRelease string
+}
+
+// FilterReleases returns the releases matching the filter with the latest release first
+func FilterReleases(releases []v1.Release, filter *ReleaseFilter) ([]v1.Release, error) {
+	since := filter.Since
+	if filter.AfterRelease != "" {
+		found := false
+		for i := range releases {
+			if releases[i].Name == filter.AfterRelease {
+				found = true
+				created := releases[i].CreationTimestamp.Time.Add(time.Nanosecond)
+				if created.After(since) {
+					since = created
+				}
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("could not find the Release %s", filter.AfterRelease)
+		}
+	}
+	var answer []v1.Release
+	for i := range releases {
+		created := releases[i].CreationTimestamp.Time
+		if !since.IsZero() && created.Before(since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !created.Before(filter.Until) {
+			continue
+		}
+		answer = append(answer, releases[i])
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[j].CreationTimestamp.Time.Before(answer[i].CreationTimestamp.Time)
+	})
+	return answer, nil
+}
+
+// ReleaseReportMarkdown returns the aggregated markdown document of the changelogs of the releases
+func ReleaseReportMarkdown(title string, releases []v1.Release) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("# " + title + "\n")
+	if len(releases) == 0 {
+		buf.WriteString("\nNo releases found\n")
+		return buf.String(), nil
+	}
+	buf.WriteString("\n| Application | Version | Released |\n")
+	buf.WriteString("| ----------- | ------- | -------- |\n")
+	for i := range releases {
+		r := &releases[i]
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", releaseName(r), releaseVersion(r), r.CreationTimestamp.Time.UTC().Format(time.RFC3339)))
+	}
+	for i := range releases {
+		r := &releases[i]
+		gitInfo := &giturl.GitRepository{}
+		if r.Spec.GitHTTPURL != "" {
+			info, err := giturl.ParseGitURL(r.Spec.GitHTTPURL)
+			if err == nil {
+				gitInfo = info
+			}
+		}
+		markdown, err := gits.GenerateMarkdown(&r.Spec, gitInfo)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to generate the changelog of Release %s", r.Name)
+		}
+		buf.WriteString(fmt.Sprintf("\n## %s %s\n", releaseName(r), releaseVersion(r)))
+		if strings.TrimSpace(markdown) != "" {
+			buf.WriteString("\n" + demoteHeadings(markdown))
+		}
+	}
+	return buf.String(), nil
+}
+
+// releaseName returns the name of the application of the release linking to its git repository
+func releaseName(r *v1.Release) string {
+	name := r.Spec.Name
+	if name == "" {
+		name = r.Name
+	}
+	if r.Spec.GitHTTPURL == "" {
+		return name
+	}
+	return "[" + name + "](" + r.Spec.GitHTTPURL + ")"
+}
+
+// releaseVersion returns the version of the release linking to its release notes
+func releaseVersion(r *v1.Release) string {
+	if r.Spec.ReleaseNotesURL == "" {
+		return r.Spec.Version
+	}
+	return "[" + r.Spec.Version + "](" + r.Spec.ReleaseNotesURL + ")"
+}
+
+// demoteHeadings nests the markdown headings one level deeper so that the changelog of a release can be included
+// below its own heading
+func demoteHeadings(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			lines[i] = "#" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package changelog
+
+import (
+	"sort"
+	"strconv"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// makeReproducible sorts the commits, issues, pull requests and labels of the release and clears the volatile
+// metadata so that generating the Release YAML for the same revisions always gives the same output whatever the
+// order the git backend walked the commits or the issues were looked up in
+func makeReproducible(release *v1.Release) {
+	release.CreationTimestamp = metav1.Time{}
+	release.DeletionTimestamp = nil
+
+	spec := &release.Spec
+	for i := range spec.Commits {
+		sort.Strings(spec.Commits[i].IssueIDs)
+	}
+	sort.SliceStable(spec.Commits, func(a, b int) bool {
+		return spec.Commits[a].SHA < spec.Commits[b].SHA
+	})
+	sortIssueSummaries(spec.Issues)
+	sortIssueSummaries(spec.PullRequests)
+}
+
+func sortIssueSummaries(issues []v1.IssueSummary) {
+	for i := range issues {
+		labels := issues[i].Labels
+		sort.SliceStable(labels, func(a, b int) bool {
+			return labels[a].Name < labels[b].Name
+		})
+	}
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issueIDLess(issues[a].ID, issues[b].ID)
+	})
+}
+
+// issueIDLess compares issue IDs numerically when they are both numbers such as GitHub issues
+// otherwise lexically such as JIRA keys
+func issueIDLess(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
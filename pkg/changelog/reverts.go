@@ -0,0 +1,107 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+var (
+	revertedCommitRegex = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-fA-F]{7,40})`)
+	revertSubjectRegex  = regexp.MustCompile(`^(?:Revert|Reapply) "(.*)"$`)
+	pullRequestSuffix   = regexp.MustCompile(`\s*\(#\d+\)$`)
+)
+
+// CollapseReverts collapses each chain of commits reverting and reapplying a commit of the changelog into its final
+// state. Chains which end reverted are removed whereas chains which end reapplied are replaced by the original commit
+// marked as reworked. Returns the SHAs of the reworked commits
+func (c *Changelog) CollapseReverts() []string {
+	reverted := map[*Commit]*Commit{}
+	for i, commit := range c.Commits {
+		if target := c.revertedCommit(i); target != nil {
+			reverted[commit] = target
+		}
+	}
+	if len(reverted) == 0 {
+		return nil
+	}
+	root := func(commit *Commit) *Commit {
+		// lets guard against cycles of commits reverting each other
+		for i := 0; i < len(c.Commits); i++ {
+			target := reverted[commit]
+			if target == nil {
+				return commit
+			}
+			commit = target
+		}
+		return commit
+	}
+	chains := map[*Commit][]*Commit{}
+	for _, commit := range c.Commits {
+		if r := root(commit); r != commit {
+			chains[r] = append(chains[r], commit)
+		}
+	}
+
+	var kept []*Commit
+	var answer []string
+	for _, commit := range c.Commits {
+		if root(commit) != commit {
+			continue
+		}
+		chain := chains[commit]
+		if len(chain) > 0 {
+			if len(chain)%2 == 1 {
+				// lets drop commits whose last revert undoes them
+				continue
+			}
+			for _, r := range chain {
+				commit.Reworks = append(commit.Reworks, r.SHA)
+				for _, id := range r.IssueIDs {
+					if stringhelpers.StringArrayIndex(commit.IssueIDs, id) < 0 {
+						commit.IssueIDs = append(commit.IssueIDs, id)
+					}
+				}
+			}
+			answer = append(answer, commit.SHA)
+		}
+		kept = append(kept, commit)
+	}
+	c.Commits = kept
+	c.Issues = referencedIssues(c.Issues, kept)
+	c.PullRequests = referencedIssues(c.PullRequests, kept)
+	return answer
+}
+
+// revertedCommit returns the commit of the changelog reverted by the commit at the index via the 'This reverts commit'
+// line git adds or failing that the quoted message of a 'Revert "..."' subject of the nearest older commit
+func (c *Changelog) revertedCommit(idx int) *Commit {
+	commit := c.Commits[idx]
+	if m := revertedCommitRegex.FindStringSubmatch(commit.Message); m != nil {
+		sha := strings.ToLower(m[1])
+		for _, other := range c.Commits {
+			if other != commit && strings.HasPrefix(strings.ToLower(other.SHA), sha) {
+				return other
+			}
+		}
+		return nil
+	}
+	m := revertSubjectRegex.FindStringSubmatch(revertSubject(commit.Message))
+	if m == nil {
+		return nil
+	}
+	// the commits are in the order of the git history so the older commits come later
+	for _, other := range c.Commits[idx+1:] {
+		if revertSubject(other.Message) == m[1] {
+			return other
+		}
+	}
+	return nil
+}
+
+// revertSubject returns the first line of the message without the pull request suffix of squash merges
+func revertSubject(message string) string {
+	line := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	return pullRequestSuffix.ReplaceAllString(line, "")
+}
@@ -0,0 +1,50 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapseReverts(t *testing.T) {
+	t.Parallel()
+	commit := func(sha, message string, issueIDs ...string) *changelog.Commit {
+		c := changelog.NewCommit(sha, message)
+		c.IssueIDs = issueIDs
+		return c
+	}
+	model := &changelog.Changelog{
+		Commits: []*changelog.Commit{
+			commit("aaa5", "Reapply \"feat: caching\"\n\nThis reverts commit aaa4.", "7"),
+			commit("bbb2", "Revert \"fix: flaky retries\" (#9)\n\nReverts myorg/myrepo#8"),
+			commit("aaa4", "Revert \"feat: caching\"\n\nThis reverts commit aaa1."),
+			commit("ccc1", "Revert \"feat: from the last release\"\n\nThis reverts commit 0123456."),
+			commit("bbb1", "fix: flaky retries (#8)", "8"),
+			commit("ddd1", "chore: tidy"),
+			commit("aaa1", "feat: caching", "6"),
+		},
+		Issues: []*changelog.Issue{{ID: "6"}, {ID: "7"}},
+		PullRequests: []*changelog.Issue{
+			{ID: "8", PullRequest: true},
+		},
+	}
+	reworked := model.CollapseReverts()
+	assert.Equal(t, []string{"aaa1"}, reworked)
+
+	var shas []string
+	for _, c := range model.Commits {
+		shas = append(shas, c.SHA)
+	}
+	assert.Equal(t, []string{"ccc1", "ddd1", "aaa1"}, shas, "should keep reverts of earlier releases")
+	caching := model.Commits[2]
+	assert.Equal(t, []string{"aaa5", "aaa4"}, caching.Reworks)
+	assert.Equal(t, []string{"6", "7"}, caching.IssueIDs)
+	assert.Equal(t, "feat", caching.Type)
+	assert.Len(t, model.Issues, 2)
+	assert.Empty(t, model.PullRequests, "should remove the pull requests of reverted commits")
+
+	assert.Nil(t, (&changelog.Changelog{Commits: []*changelog.Commit{commit("111", "fix: a bug")}}).CollapseReverts())
+}
@@ -0,0 +1,133 @@
+package changelog
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// reviewPages the maximum number of pages of reviews listed for each pull request
+const reviewPages = 5
+
+// findReviewers finds the approving reviewers of each pull request in the release indexed by pull request ID
+func (g *Generator) findReviewers(spec *v1.ReleaseSpec, resolver *users.GitUserResolver) map[string][]v1.UserDetails {
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient == nil || len(spec.PullRequests) == 0 {
+		return nil
+	}
+	fullName := scm.Join(g.ScmFactory.Owner, g.ScmFactory.Repository)
+	answer := map[string][]v1.UserDetails{}
+	for i := range spec.PullRequests {
+		pr := &spec.PullRequests[i]
+		n, err := strconv.Atoi(pr.ID)
+		if err != nil {
+			continue
+		}
+		reviews, err := g.listReviews(fullName, n)
+		if err != nil {
+			log.Logger().Warnf("failed to list reviews for pull request %d on repository %s: %s", n, fullName, err.Error())
+			continue
+		}
+		found := map[string]bool{}
+		for _, review := range reviews {
+			if review == nil || !strings.EqualFold(review.State, scm.ReviewStateApproved) {
+				continue
+			}
+			author := review.Author
+			if author.Name == "" {
+				author.Name = author.Login
+			}
+			key := strings.ToLower(author.Login)
+			if key == "" || found[key] {
+				continue
+			}
+			found[key] = true
+			u, err := resolver.Resolve(&author)
+			if err != nil {
+				log.Logger().Warnf("failed to resolve reviewer %s of pull request %d: %s", author.Login, n, err.Error())
+			}
+			if u == nil || users.IsServiceAccount(u) {
+				continue
+			}
+			answer[pr.ID] = append(answer[pr.ID], *u)
+		}
+	}
+	return answer
+}
+
+// listReviews lists the reviews of the pull request a page at a time up to reviewPages pages
+func (g *Generator) listReviews(fullName string, n int) ([]*scm.Review, error) {
+	var answer []*scm.Review
+	for page := 1; page <= reviewPages; page++ {
+		reviews, _, err := g.ScmFactory.ScmClient.Reviews.List(g.State.Context, fullName, n, scm.ListOptions{Page: page, Size: 100})
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, reviews...)
+		if len(reviews) < 100 {
+			break
+		}
+	}
+	return answer, nil
+}
+
+// aggregateReviewers returns the reviewers across all the pull requests ordered by the number of approvals descending
+func aggregateReviewers(reviewers map[string][]v1.UserDetails) []gits.Reviewer {
+	var answer []gits.Reviewer
+	indexes := map[string]int{}
+	var ids []string
+	for id := range reviewers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for i := range reviewers[id] {
+			u := reviewers[id][i]
+			key := strings.ToLower(u.Login)
+			idx, ok := indexes[key]
+			if !ok {
+				idx = len(answer)
+				indexes[key] = idx
+				answer = append(answer, gits.Reviewer{User: u})
+			}
+			answer[idx].Reviews++
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		if answer[i].Reviews != answer[j].Reviews {
+			return answer[i].Reviews > answer[j].Reviews
+		}
+		return strings.ToLower(answer[i].User.Login) < strings.ToLower(answer[j].User.Login)
+	})
+	return answer
+}
+
+// addReviewersAnnotation records the logins of the approving reviewers of each pull request on the Release
+func addReviewersAnnotation(release *v1.Release, reviewers map[string][]v1.UserDetails) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	logins := map[string][]string{}
+	for id, us := range reviewers {
+		for i := range us {
+			logins[id] = append(logins[id], us[i].Login)
+		}
+	}
+	data, err := json.Marshal(logins)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal reviewers")
+	}
+	if release.Annotations == nil {
+		release.Annotations = map[string]string{}
+	}
+	release.Annotations[ReviewersAnnotation] = string(data)
+	return nil
+}
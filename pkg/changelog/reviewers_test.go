@@ -0,0 +1,58 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectReviewersPaginates(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/repos/myorg/myrepo/pulls/1/reviews") {
+			http.NotFound(w, r)
+			return
+		}
+		var reviews []string
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := 0; i < 100; i++ {
+				reviews = append(reviews, fmt.Sprintf(`{"id":%d,"state":"COMMENTED","user":{"login":"commenter%d"}}`, i, i))
+			}
+		case "2":
+			reviews = append(reviews, `{"id":100,"state":"APPROVED","user":{"login":"approver"}}`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[" + strings.Join(reviews, ",") + "]")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	scmClient, err := factory.NewClient("github", server.URL, "mytoken")
+	require.NoError(t, err)
+	dir := initRepo(t)
+	commits := []*changelogtest.CommitBuilder{changelogtest.NewCommit("fix: something (#1)")}
+	tracker := changelogtest.NewIssueTracker(changelogtest.NewIssue("1", "fix something").AsPullRequest())
+	g := newCollectGenerator(t, dir, commits, tracker)
+	g.Reviewers = true
+	g.ScmFactory.ScmClient = scmClient
+	g.ScmFactory.Owner = "myorg"
+	g.ScmFactory.Repository = "myrepo"
+
+	result, err := g.Collect(context.Background(), &changelog.Range{PreviousRev: "v1.0.0", CurrentRev: "HEAD"})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	reviewers := result.MarkdownOptions.Reviewers["1"]
+	require.Len(t, reviewers, 1, "the approval on the second page of reviews should be found")
+	assert.Equal(t, "approver", reviewers[0].Login)
+}
@@ -0,0 +1,101 @@
+package changelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// signatureFiles the suffixes and content types of the files created by signing a release asset
+var signatureFiles = [][2]string{
+	{".sig", "text/plain"},
+	{".pem", "application/x-pem-file"},
+	{".bundle", "application/json"},
+}
+
+// signedFormats the formats of the release assets which are signed
+var signedFormats = []string{RendererMarkdown, RendererJSON}
+
+// validateSigning ensures the signed formats are uploaded as release assets
+func (g *Generator) validateSigning() error {
+	if !g.Sign {
+		return nil
+	}
+	if !g.UpdateRelease {
+		return options.InvalidOptionf("sign", "true", "requires --update-release as the signatures are uploaded as assets of the release")
+	}
+	for _, format := range signedFormats {
+		if stringhelpers.StringArrayIndex(g.ReleaseAssets, format) < 0 {
+			g.ReleaseAssets = append(g.ReleaseAssets, format)
+		}
+	}
+	return nil
+}
+
+// SignAssets signs the markdown and JSON assets with cosign keyless signing returning the assets along with the
+// signature, certificate and bundle of each signed asset
+func (g *Generator) SignAssets(assets []ReleaseAsset) ([]ReleaseAsset, error) {
+	if !g.Sign {
+		return assets, nil
+	}
+	signed := map[string]bool{}
+	for _, format := range signedFormats {
+		signed[assetFiles[format][0]] = true
+	}
+	dir, err := ioutil.TempDir("", "jx-changelog-sign-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the directory to sign the release assets in")
+	}
+	defer os.RemoveAll(dir)
+
+	runner := g.CommandRunner
+	if runner == nil {
+		runner = cmdrunner.QuietCommandRunner
+	}
+	cosign := g.CosignBinary
+	if cosign == "" {
+		cosign = "cosign"
+	}
+	answer := append([]ReleaseAsset{}, assets...)
+	for i := range assets {
+		asset := &assets[i]
+		if !signed[asset.Name] {
+			continue
+		}
+		path := filepath.Join(dir, asset.Name)
+		err = ioutil.WriteFile(path, asset.Data, files.DefaultFileWritePermissions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to save %s", path)
+		}
+		c := &cmdrunner.Command{
+			Dir:  dir,
+			Name: cosign,
+			Args: []string{"sign-blob", "--yes",
+				"--output-signature", path + signatureFiles[0][0],
+				"--output-certificate", path + signatureFiles[1][0],
+				"--bundle", path + signatureFiles[2][0],
+				path},
+			Env: map[string]string{"COSIGN_EXPERIMENTAL": "1"},
+		}
+		_, err = runner(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to sign the release asset %s", asset.Name)
+		}
+		for _, f := range signatureFiles {
+			data, err := ioutil.ReadFile(path + f[0])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read the signature of %s", asset.Name)
+			}
+			answer = append(answer, ReleaseAsset{Name: asset.Name + f[0], ContentType: f[1], Data: data})
+		}
+		log.Logger().Infof("signed the release asset %s", info(asset.Name))
+	}
+	return answer, nil
+}
@@ -0,0 +1,54 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAssets(t *testing.T) {
+	t.Parallel()
+	var signed []string
+	runner := func(c *cmdrunner.Command) (string, error) {
+		assert.Equal(t, "cosign", c.Name)
+		assert.Equal(t, "sign-blob", c.Args[0])
+		args := c.Args
+		for i := 0; i+1 < len(args); i++ {
+			switch args[i] {
+			case "--output-signature", "--output-certificate", "--bundle":
+				require.NoError(t, ioutil.WriteFile(args[i+1], []byte(args[i]), 0600))
+			}
+		}
+		data, err := ioutil.ReadFile(args[len(args)-1])
+		require.NoError(t, err)
+		signed = append(signed, string(data))
+		return "", nil
+	}
+	g := &changelog.Generator{Sign: true, UpdateRelease: true, CommandRunner: runner}
+	require.NoError(t, g.Validate())
+	assert.Equal(t, []string{changelog.RendererMarkdown, changelog.RendererJSON}, g.ReleaseAssets)
+
+	assets, err := g.SignAssets([]changelog.ReleaseAsset{
+		{Name: "changelog.md", Data: []byte("## Changes\n")},
+		{Name: "changelog.html", Data: []byte("<h2>Changes</h2>")},
+		{Name: "changelog.json", Data: []byte(`{"version": "1.2.3"}`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"## Changes\n", `{"version": "1.2.3"}`}, signed)
+	var names []string
+	for _, a := range assets {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{"changelog.md", "changelog.html", "changelog.json",
+		"changelog.md.sig", "changelog.md.pem", "changelog.md.bundle",
+		"changelog.json.sig", "changelog.json.pem", "changelog.json.bundle"}, names)
+	assert.Equal(t, "--output-certificate", string(assets[4].Data))
+
+	assert.Error(t, (&changelog.Generator{Sign: true}).Validate(), "signing requires updating the release")
+}
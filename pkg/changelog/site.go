@@ -0,0 +1,176 @@
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SiteFormatHugo writes a JSON data file per release for the data directory of a Hugo site
+	SiteFormatHugo = "hugo"
+
+	// SiteFormatDocusaurus writes a blog post per release with the front matter of a Docusaurus site
+	SiteFormatDocusaurus = "docusaurus"
+)
+
+// SiteRelease the data of a release written to the data directory of a static site
+type SiteRelease struct {
+	Version  string    `json:"version"`
+	Title    string    `json:"title"`
+	Date     time.Time `json:"date"`
+	Tags     []string  `json:"tags,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Stats    *Stats    `json:"stats,omitempty"`
+	Markdown string    `json:"markdown"`
+}
+
+// SiteFile returns the name and content of the file of the release in the static site format
+func SiteFile(format string, release *SiteRelease) (string, []byte, error) {
+	switch format {
+	case SiteFormatHugo, "":
+		data, err := json.MarshalIndent(release, "", "  ")
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to marshal the release data")
+		}
+		return release.Version + ".json", append(data, '\n'), nil
+	case SiteFormatDocusaurus:
+		frontMatter := map[string]interface{}{
+			"slug":  "release-" + release.Version,
+			"title": release.Title,
+			"date":  release.Date.Format(time.RFC3339),
+		}
+		if len(release.Tags) > 0 {
+			frontMatter["tags"] = release.Tags
+		}
+		data, err := yaml.Marshal(frontMatter)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to marshal the front matter")
+		}
+		name := fmt.Sprintf("%s-release-%s.md", release.Date.Format("2006-01-02"), release.Version)
+		text := "---\n" + string(data) + "---\n\n" + strings.TrimSpace(release.Markdown) + "\n"
+		return name, []byte(text), nil
+	default:
+		return "", nil, options.InvalidOptionf("site-format", format, "should be %s or %s", SiteFormatHugo, SiteFormatDocusaurus)
+	}
+}
+
+// siteRelease creates the static site data of the release. The tags are the types of its commits along with
+// 'breaking' if it has breaking changes
+func (g *Generator) siteRelease(result *Result) *SiteRelease {
+	spec := &result.Release.Spec
+	version := strings.TrimPrefix(spec.Version, "v")
+	answer := &SiteRelease{
+		Version:  version,
+		Title:    strings.TrimSpace(spec.Name + " " + version),
+		Date:     g.now().UTC(),
+		URL:      spec.ReleaseNotesURL,
+		Markdown: result.Markdown,
+	}
+	if result.Changelog != nil {
+		answer.Stats = result.Changelog.Stats()
+		for t := range answer.Stats.Types {
+			if t != "" {
+				answer.Tags = append(answer.Tags, t)
+			}
+		}
+		sort.Strings(answer.Tags)
+		if answer.Stats.BreakingChanges > 0 {
+			answer.Tags = append(answer.Tags, "breaking")
+		}
+	}
+	return answer
+}
+
+// sitePublisher writes the data file of the release into the site directory of the repository or of the site branch
+type sitePublisher struct {
+	g *Generator
+}
+
+func (p *sitePublisher) Name() string {
+	return "site"
+}
+
+func (p *sitePublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	name, data, err := SiteFile(g.SiteFormat, g.siteRelease(result))
+	if err != nil {
+		return err
+	}
+	if g.SiteBranch == "" {
+		return writeSiteFile(filepath.Join(g.SiteDir, name), data)
+	}
+	return p.publishToBranch(filepath.Join(g.SiteDir, name), data, result.Tag)
+}
+
+// publishToBranch commits the file to the site branch via a temporary worktree and pushes it. The branch is created
+// as an orphan branch if it does not exist
+func (p *sitePublisher) publishToBranch(path string, data []byte, tag string) error {
+	g := p.g
+	git := g.Git()
+	dir := g.ScmFactory.Dir
+	branch := g.SiteBranch
+	tmpDir, err := ioutil.TempDir("", "jx-changelog-site-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	_, err = git.Command(dir, "fetch", "origin", branch)
+	if err == nil {
+		_, err = git.Command(dir, "worktree", "add", "-B", branch, tmpDir, "FETCH_HEAD")
+	} else {
+		log.Logger().Infof("creating the site branch %s", info(branch))
+		_, err = git.Command(dir, "worktree", "add", "--detach", tmpDir)
+		if err == nil {
+			_, err = git.Command(tmpDir, "checkout", "--orphan", branch)
+		}
+		if err == nil {
+			_, err = git.Command(tmpDir, "rm", "-r", "-f", "--quiet", "--ignore-unmatch", ".")
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to check out the site branch %s", branch)
+	}
+	defer git.Command(dir, "worktree", "remove", "--force", tmpDir) //nolint:errcheck
+
+	err = writeSiteFile(filepath.Join(tmpDir, path), data)
+	if err != nil {
+		return err
+	}
+	err = gitclient.Add(git, tmpDir, path)
+	if err != nil {
+		return err
+	}
+	_, err = git.Command(tmpDir, "commit", "-m", fmt.Sprintf("chore: release notes of %s", tag))
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit %s to the site branch %s", path, branch)
+	}
+	return gitclient.Push(git, tmpDir, "origin", false, "HEAD:"+branch)
+}
+
+func writeSiteFile(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the directory of %s", path)
+	}
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", path)
+	}
+	log.Logger().Infof("generated: %s", info(path))
+	return nil
+}
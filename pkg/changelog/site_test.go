@@ -0,0 +1,92 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog/changelogtest"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSiteFile(t *testing.T) {
+	t.Parallel()
+	release := &changelog.SiteRelease{
+		Version:  "1.2.3",
+		Title:    "foo 1.2.3",
+		Date:     changelogtest.DefaultTime,
+		Tags:     []string{"feat", "fix"},
+		Markdown: "## Changes\n\n* a fix\n",
+	}
+
+	name, data, err := changelog.SiteFile(changelog.SiteFormatDocusaurus, release)
+	require.NoError(t, err)
+	assert.Equal(t, "2020-09-13-release-1.2.3.md", name)
+	assert.Equal(t, `---
+date: "2020-09-13T12:26:40Z"
+slug: release-1.2.3
+tags:
+- feat
+- fix
+title: foo 1.2.3
+---
+
+## Changes
+
+* a fix
+`, string(data))
+
+	name, data, err = changelog.SiteFile(changelog.SiteFormatHugo, release)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3.json", name)
+	actual := &changelog.SiteRelease{}
+	require.NoError(t, json.Unmarshal(data, actual))
+	assert.Equal(t, release, actual)
+
+	_, _, err = changelog.SiteFile("jekyll", release)
+	assert.Error(t, err)
+}
+
+func TestSiteDir(t *testing.T) {
+	t.Parallel()
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	siteDir := filepath.Join(tmpDir, "data", "releases")
+	g := &changelog.Generator{
+		SiteDir: siteDir,
+		Clock:   changelogtest.NewClock(changelogtest.DefaultTime),
+	}
+	result := &changelog.Result{
+		Range: &changelog.Range{},
+		Changelog: &changelog.Changelog{
+			Commits: []*changelog.Commit{
+				changelogtest.NewCommit("fix: a bug").Build(),
+				changelogtest.NewCommit("feat!: a breaking feature").Build(),
+			},
+		},
+		Release:  &v1.Release{Spec: v1.ReleaseSpec{Name: "foo", Version: "v1.2.3"}},
+		Markdown: "## Changes\n",
+		Output:   "## Changes\n",
+	}
+	err = g.Publish(context.TODO(), result)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(siteDir, "1.2.3.json"))
+	require.NoError(t, err)
+	actual := &changelog.SiteRelease{}
+	require.NoError(t, json.Unmarshal(data, actual))
+	assert.Equal(t, "foo 1.2.3", actual.Title)
+	assert.Equal(t, []string{"feat", "fix", "breaking"}, actual.Tags)
+	assert.Equal(t, "## Changes\n", actual.Markdown)
+	assert.Equal(t, 2, actual.Stats.Commits)
+}
@@ -0,0 +1,75 @@
+package changelog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	slackHeadingRegex = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	slackBulletRegex  = regexp.MustCompile(`(?m)^\* `)
+	slackBoldRegex    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	slackLinkRegex    = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	slackImageRegex   = regexp.MustCompile(`&lt;img [^&]*&gt; ?`)
+)
+
+// SlackRenderer renders the changelog as the JSON payload of a Slack message using Slack's mrkdwn formatting
+type SlackRenderer struct {
+	// Channel the optional channel to post the message to
+	Channel string
+
+	// Username the optional name to post the message as
+	Username string
+}
+
+// SlackMessage the payload of a Slack message
+type SlackMessage struct {
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+	Text     string `json:"text"`
+	Mrkdwn   bool   `json:"mrkdwn"`
+}
+
+// NewSlackRenderer creates the Slack renderer using the optional 'channel' and 'username' options
+func NewSlackRenderer(options RendererOptions) (Renderer, error) {
+	err := options.Validate("channel", "username")
+	if err != nil {
+		return nil, err
+	}
+	return &SlackRenderer{
+		Channel:  options["channel"],
+		Username: options["username"],
+	}, nil
+}
+
+// Render renders the changelog as a Slack message payload
+func (r *SlackRenderer) Render(input *RenderInput) (string, error) {
+	markdown, err := (&MarkdownRenderer{}).Render(input)
+	if err != nil {
+		return "", err
+	}
+	msg := &SlackMessage{
+		Channel:  r.Channel,
+		Username: r.Username,
+		Text:     ToSlackMarkdown(markdown),
+		Mrkdwn:   true,
+	}
+	data, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the Slack message")
+	}
+	return string(data) + "\n", nil
+}
+
+// ToSlackMarkdown converts markdown into Slack's mrkdwn format
+func ToSlackMarkdown(markdown string) string {
+	text := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(markdown)
+	text = slackImageRegex.ReplaceAllString(text, "")
+	text = slackBulletRegex.ReplaceAllString(text, "• ")
+	text = slackHeadingRegex.ReplaceAllString(text, "*$1*")
+	text = slackBoldRegex.ReplaceAllString(text, "*$1*")
+	return slackLinkRegex.ReplaceAllString(text, "<$2|$1>")
+}
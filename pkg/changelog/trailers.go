@@ -0,0 +1,35 @@
+package changelog
+
+import (
+	"encoding/json"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+)
+
+// addTrailers records the trailers of the commit indexed by the commit SHA
+func (g *Generator) addTrailers(sha string, trailers map[string]string) {
+	if len(trailers) == 0 {
+		return
+	}
+	if g.State.Trailers == nil {
+		g.State.Trailers = map[string]map[string]string{}
+	}
+	g.State.Trailers[sha] = trailers
+}
+
+// addTrailersAnnotation records the trailers of the commits on the Release as a JSON map indexed by commit SHA
+func addTrailersAnnotation(release *v1.Release, trailers map[string]map[string]string) error {
+	if len(trailers) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(trailers)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal commit trailers")
+	}
+	if release.Annotations == nil {
+		release.Annotations = map[string]string{}
+	}
+	release.Annotations[TrailersAnnotation] = string(data)
+	return nil
+}
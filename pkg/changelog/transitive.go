@@ -0,0 +1,111 @@
+package changelog
+
+import (
+	"net/http"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// upstreamReleaseYamlPath returns the path of the Release YAML generated by jx-changelog in the helm chart of the
+// upstream repository
+func upstreamReleaseYamlPath(repo string) string {
+	return "charts/" + repo + "/templates/release.yaml"
+}
+
+// aggregateUpstreamReleases fetches the Release CRs of the upstream releases of each dependency update hosted on the
+// same git server. Their dependency updates are added to the Paths of the update and their commits and issues are
+// returned indexed by gits.DependencyKey
+func (g *Generator) aggregateUpstreamReleases(updates []v1.DependencyUpdate) map[string]*gits.UpstreamChanges {
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient == nil || len(updates) == 0 {
+		return nil
+	}
+	answer := map[string]*gits.UpstreamChanges{}
+	for i := range updates {
+		du := &updates[i]
+		fullName := g.upstreamRepository(du)
+		if fullName == "" {
+			continue
+		}
+		releases, err := g.fetchUpstreamReleases(fullName, du)
+		if err != nil {
+			log.Logger().Warnf("failed to find the upstream releases of dependency %s: %s", fullName, err.Error())
+			continue
+		}
+		changes := MergeUpstreamReleases(du, releases)
+		if changes != nil {
+			answer[gits.DependencyKey(du)] = changes
+		}
+	}
+	return answer
+}
+
+// fetchUpstreamReleases returns the Release CRs of the upstream releases of the dependency update with the latest
+// release first. Releases without a Release YAML in their chart are ignored
+func (g *Generator) fetchUpstreamReleases(fullName string, du *v1.DependencyUpdate) ([]*v1.Release, error) {
+	ctx := g.State.Context
+	scmClient := g.ScmFactory.ScmClient
+	releases, _, err := scmClient.Releases.List(ctx, fullName, scm.ReleaseListOptions{Page: 1, Size: upstreamReleasePageSize})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the releases of %s", fullName)
+	}
+	path := upstreamReleaseYamlPath(du.Repo)
+	var answer []*v1.Release
+	for _, tag := range upstreamReleasesBetween(releases, du.FromVersion, du.ToVersion) {
+		content, res, err := scmClient.Contents.Find(ctx, fullName, path, tag)
+		if err != nil {
+			if res != nil && res.Status == http.StatusNotFound {
+				log.Logger().Debugf("no Release YAML %s in %s at %s", path, fullName, tag)
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to find %s in %s at %s", path, fullName, tag)
+		}
+		release := &v1.Release{}
+		err = yaml.Unmarshal(content.Data, release)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s in %s at %s", path, fullName, tag)
+		}
+		answer = append(answer, release)
+	}
+	return answer, nil
+}
+
+// MergeUpstreamReleases merges the commits and issues of the Release CRs of the upstream releases of the dependency
+// update. The dependency updates of the upstream releases are added to the Paths of the update so that transitive
+// updates can be traced. Returns nil if the releases contain no changes
+func MergeUpstreamReleases(du *v1.DependencyUpdate, releases []*v1.Release) *gits.UpstreamChanges {
+	answer := &gits.UpstreamChanges{}
+	issues := map[string]bool{}
+	pullRequests := map[string]bool{}
+	for _, r := range releases {
+		answer.Commits = append(answer.Commits, r.Spec.Commits...)
+		for _, issue := range r.Spec.Issues {
+			if !issues[issue.ID] {
+				issues[issue.ID] = true
+				answer.Issues = append(answer.Issues, issue)
+			}
+		}
+		for _, pr := range r.Spec.PullRequests {
+			if !pullRequests[pr.ID] {
+				pullRequests[pr.ID] = true
+				answer.PullRequests = append(answer.PullRequests, pr)
+			}
+		}
+		for _, nested := range r.Spec.DependencyUpdates {
+			du.Paths = append(du.Paths, v1.DependencyUpdatePath{nested.DependencyUpdateDetails})
+			for _, p := range nested.Paths {
+				path := append(v1.DependencyUpdatePath{nested.DependencyUpdateDetails}, p...)
+				du.Paths = append(du.Paths, path)
+			}
+		}
+	}
+	if len(answer.Commits) == 0 && len(answer.Issues) == 0 && len(answer.PullRequests) == 0 {
+		return nil
+	}
+	return answer
+}
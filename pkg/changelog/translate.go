@@ -0,0 +1,193 @@
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TranslationOutputSection appends each translation as a section of the release notes
+	TranslationOutputSection = "section"
+
+	// TranslationOutputFile writes each translation to a file of the translations directory
+	TranslationOutputFile = "file"
+)
+
+// Translator translates the rendered markdown of the changelog into another language
+type Translator interface {
+	// Translate returns the markdown translated into the language such as 'ja'
+	Translate(ctx context.Context, markdown, language string) (string, error)
+}
+
+// TranslationRequest the JSON payload posted to a translation endpoint
+type TranslationRequest struct {
+	Language string `json:"language"`
+	Markdown string `json:"markdown"`
+}
+
+// TranslationResponse the JSON payload returned by a translation endpoint
+type TranslationResponse struct {
+	Markdown string `json:"markdown"`
+}
+
+// CommandTranslator translates the markdown via a shell command which reads the markdown on its standard input and
+// writes the translation to its standard output. The language is passed in the CHANGELOG_LANGUAGE environment variable
+type CommandTranslator struct {
+	Command       string
+	Dir           string
+	CommandRunner cmdrunner.CommandRunner
+}
+
+// Translate runs the command to translate the markdown
+func (t *CommandTranslator) Translate(ctx context.Context, markdown, language string) (string, error) {
+	runner := t.CommandRunner
+	if runner == nil {
+		runner = cmdrunner.QuietCommandRunner
+	}
+	out := &bytes.Buffer{}
+	c := &cmdrunner.Command{
+		Dir:  t.Dir,
+		Name: "sh",
+		Args: []string{"-c", t.Command},
+		In:   strings.NewReader(markdown),
+		Out:  out,
+		Err:  os.Stderr,
+		Env:  map[string]string{"CHANGELOG_LANGUAGE": language},
+	}
+	_, err := runner(c)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to run the translate command %s", t.Command)
+	}
+	return out.String(), nil
+}
+
+// HTTPTranslator translates the markdown by posting a TranslationRequest to the URL which replies with a
+// TranslationResponse. Defaults to http.DefaultClient if the client is nil
+type HTTPTranslator struct {
+	URL    string
+	Client *http.Client
+}
+
+// Translate posts the markdown to the translation endpoint
+func (t *HTTPTranslator) Translate(ctx context.Context, markdown, language string) (string, error) {
+	data, err := json.Marshal(&TranslationRequest{Language: language, Markdown: markdown})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the translation request")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(data))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the request to %s", t.URL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to post to %s", t.URL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", errors.Errorf("%s returned status %s", t.URL, resp.Status)
+	}
+	answer := &TranslationResponse{}
+	err = json.NewDecoder(resp.Body).Decode(answer)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the translation returned by %s", t.URL)
+	}
+	return answer.Markdown, nil
+}
+
+// validateTranslation validates the translation options and creates the translator if there are languages to
+// translate into
+func (g *Generator) validateTranslation() error {
+	g.State.Translator = g.Translator
+	if len(g.TranslateLanguages) == 0 {
+		return nil
+	}
+	switch g.TranslationOutput {
+	case "":
+		g.TranslationOutput = TranslationOutputSection
+	case TranslationOutputSection, TranslationOutputFile:
+	default:
+		return options.InvalidOptionf("translation-output", g.TranslationOutput, "should be %s or %s", TranslationOutputSection, TranslationOutputFile)
+	}
+	if g.State.Translator != nil {
+		return nil
+	}
+	switch {
+	case g.TranslateCommand != "" && g.TranslateURL != "":
+		return options.InvalidOptionf("translate-url", g.TranslateURL, "cannot be used with --translate-command")
+	case g.TranslateCommand != "":
+		g.State.Translator = &CommandTranslator{Command: g.TranslateCommand, Dir: g.ScmFactory.Dir, CommandRunner: g.CommandRunner}
+	case g.TranslateURL != "":
+		g.State.Translator = &HTTPTranslator{URL: g.TranslateURL, Client: g.HTTPClient}
+	default:
+		return options.MissingOption("translate-command")
+	}
+	return nil
+}
+
+// translate translates the markdown into each language appending the translations as sections of the markdown if
+// enabled. The translations are indexed by language
+func (g *Generator) translate(ctx context.Context, result *Result) error {
+	translator := g.State.Translator
+	if translator == nil || len(g.TranslateLanguages) == 0 {
+		return nil
+	}
+	markdown := result.Markdown
+	result.Translations = map[string]string{}
+	var buf strings.Builder
+	for _, language := range g.TranslateLanguages {
+		text, err := translator.Translate(ctx, markdown, language)
+		if err != nil {
+			return errors.Wrapf(err, "failed to translate the changelog into %s", language)
+		}
+		text = strings.TrimSpace(text)
+		result.Translations[language] = text
+		buf.WriteString("\n\n---\n\n## " + language + "\n\n" + text + "\n")
+	}
+	if g.TranslationOutput == TranslationOutputSection {
+		result.Markdown = strings.TrimRight(markdown, "\n") + buf.String()
+	}
+	return nil
+}
+
+// translationFilePublisher writes each translation to the translations directory
+type translationFilePublisher struct {
+	g *Generator
+}
+
+func (p *translationFilePublisher) Name() string {
+	return "translation-files"
+}
+
+func (p *translationFilePublisher) Publish(ctx context.Context, result *Result) error {
+	dir := p.g.TranslationsDir
+	err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the translations directory %s", dir)
+	}
+	for _, language := range p.g.TranslateLanguages {
+		path := filepath.Join(dir, language+".md")
+		err = ioutil.WriteFile(path, []byte(result.Translations[language]+"\n"), files.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save the translation %s", path)
+		}
+		log.Logger().Infof("generated: %s", info(path))
+	}
+	return nil
+}
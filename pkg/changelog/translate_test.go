@@ -0,0 +1,87 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTranslator struct{}
+
+func (t *fakeTranslator) Translate(ctx context.Context, markdown, language string) (string, error) {
+	return language + ": " + strings.TrimSpace(markdown) + "\n", nil
+}
+
+func TestTranslators(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	text, err := (&changelog.CommandTranslator{Command: `printf '%s: ' "$CHANGELOG_LANGUAGE" && cat`}).Translate(ctx, "### Bug Fixes\n", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "ja: ### Bug Fixes\n", text)
+
+	_, err = (&changelog.CommandTranslator{Command: "exit 1"}).Translate(ctx, "### Bug Fixes\n", "ja")
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &changelog.TranslationRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		_ = json.NewEncoder(w).Encode(&changelog.TranslationResponse{Markdown: req.Language + ": " + req.Markdown})
+	}))
+	defer server.Close()
+	text, err = (&changelog.HTTPTranslator{URL: server.URL}).Translate(ctx, "### Bug Fixes\n", "ja")
+	require.NoError(t, err)
+	assert.Equal(t, "ja: ### Bug Fixes\n", text)
+}
+
+func TestRenderTranslations(t *testing.T) {
+	t.Parallel()
+	gitInfo, err := giturl.ParseGitURL("https://github.com/myorg/myrepo")
+	require.NoError(t, err)
+	newResult := func() *changelog.Result {
+		return &changelog.Result{
+			Range: &changelog.Range{},
+			Release: &v1.Release{
+				Spec: v1.ReleaseSpec{Version: "1.2.3", Commits: []v1.CommitSummary{{SHA: "1111111aaaa", Message: "fix: a bug"}}},
+			},
+			MarkdownOptions: &gits.MarkdownOptions{},
+		}
+	}
+
+	g := &changelog.Generator{}
+	changelog.WithTranslator(&fakeTranslator{}, "ja")(g)
+	require.NoError(t, g.Validate())
+	g.State.GitInfo = gitInfo
+	result := newResult()
+	require.NoError(t, g.Render(context.Background(), result))
+	require.Contains(t, result.Translations, "ja")
+	assert.True(t, strings.HasPrefix(result.Translations["ja"], "ja: ## Changes"), "translation %s", result.Translations["ja"])
+	assert.True(t, strings.HasSuffix(result.Markdown, "\n\n---\n\n## ja\n\n"+result.Translations["ja"]+"\n"), "markdown %s", result.Markdown)
+
+	dir := t.TempDir()
+	g.TranslationOutput = changelog.TranslationOutputFile
+	g.TranslationsDir = dir
+	result = newResult()
+	require.NoError(t, g.Render(context.Background(), result))
+	assert.NotContains(t, result.Markdown, "## ja")
+	require.NoError(t, g.Publish(context.Background(), result))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "ja.md"))
+	require.NoError(t, err)
+	assert.Equal(t, result.Translations["ja"]+"\n", string(data))
+
+	assert.Error(t, (&changelog.Generator{TranslateLanguages: []string{"ja"}}).Validate(), "should require a translator")
+	assert.Error(t, (&changelog.Generator{TranslateLanguages: []string{"ja"}, TranslateCommand: "cat", TranslationOutput: "asset"}).Validate())
+}
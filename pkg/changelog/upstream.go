@@ -0,0 +1,162 @@
+package changelog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// upstreamReleasePageSize the number of releases of a dependency fetched from the git provider
+const upstreamReleasePageSize = 100
+
+// findUpstreamReleaseNotes finds the release notes of the upstream releases of each dependency update after FromVersion
+// up to and including ToVersion indexed by gits.DependencyKey. Dependencies on other git servers are ignored as
+// there are no credentials for them
+func (g *Generator) findUpstreamReleaseNotes(updates []v1.DependencyUpdate) map[string]string {
+	scmClient := g.ScmFactory.ScmClient
+	if scmClient == nil || len(updates) == 0 {
+		return nil
+	}
+	ctx := g.State.Context
+	answer := map[string]string{}
+	for i := range updates {
+		du := &updates[i]
+		fullName := g.upstreamRepository(du)
+		if fullName == "" {
+			continue
+		}
+		releases, _, err := scmClient.Releases.List(ctx, fullName, scm.ReleaseListOptions{Page: 1, Size: upstreamReleasePageSize})
+		if err != nil {
+			log.Logger().Warnf("failed to list the releases of dependency %s: %s", fullName, err.Error())
+			continue
+		}
+		notes := upstreamReleaseNotes(releases, du.FromVersion, du.ToVersion)
+		if notes != "" {
+			answer[gits.DependencyKey(du)] = notes
+		}
+	}
+	return answer
+}
+
+// upstreamRepository returns the full name of the repository of the dependency update if it is hosted on the same git
+// server as the repository of the changelog. Returns an empty string otherwise
+func (g *Generator) upstreamRepository(du *v1.DependencyUpdate) string {
+	if du.Owner == "" || du.Repo == "" || du.ToVersion == "" {
+		return ""
+	}
+	host := ""
+	if g.State.GitInfo != nil {
+		host = g.State.GitInfo.Host
+	}
+	if du.Host != "" && host != "" && !strings.EqualFold(du.Host, host) {
+		log.Logger().Debugf("ignoring the upstream releases of %s/%s on %s", du.Owner, du.Repo, du.Host)
+		return ""
+	}
+	return scm.Join(du.Owner, du.Repo)
+}
+
+// upstreamReleasesBetween returns the tags of the releases after fromVersion up to and including toVersion with the
+// latest release first
+func upstreamReleasesBetween(releases []*scm.Release, fromVersion, toVersion string) []string {
+	var answer []string
+	for _, r := range releases {
+		if r == nil || r.Draft || r.Tag == "" {
+			continue
+		}
+		if compareVersions(r.Tag, toVersion) > 0 {
+			continue
+		}
+		if fromVersion != "" && compareVersions(r.Tag, fromVersion) <= 0 {
+			continue
+		}
+		answer = append(answer, r.Tag)
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return compareVersions(answer[i], answer[j]) > 0
+	})
+	return answer
+}
+
+// upstreamReleaseNotes returns the descriptions of the releases after fromVersion up to and including toVersion with
+// the latest release first
+func upstreamReleaseNotes(releases []*scm.Release, fromVersion, toVersion string) string {
+	var matched []*scm.Release
+	for _, r := range releases {
+		if r == nil || r.Draft || strings.TrimSpace(r.Description) == "" {
+			continue
+		}
+		version := r.Tag
+		if version == "" {
+			version = r.Title
+		}
+		if compareVersions(version, toVersion) > 0 {
+			continue
+		}
+		if fromVersion != "" && compareVersions(version, fromVersion) <= 0 {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return compareVersions(matched[i].Tag, matched[j].Tag) > 0
+	})
+	var buf strings.Builder
+	for _, r := range matched {
+		title := r.Title
+		if title == "" {
+			title = r.Tag
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("#### " + title + "\n\n")
+		buf.WriteString(strings.TrimSpace(stripMetadata(r.Description)) + "\n")
+	}
+	return buf.String()
+}
+
+// compareVersions compares the dot separated numeric parts of the versions ignoring any 'v' prefix and pre-release
+// suffix. Non numeric parts are compared as strings
+func compareVersions(a, b string) int {
+	pa := versionParts(a)
+	pb := versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errx := strconv.Atoi(x)
+		ny, erry := strconv.Atoi(y)
+		switch {
+		case errx == nil && erry == nil:
+			if nx != ny {
+				if nx < ny {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	return strings.Split(version, ".")
+}
@@ -0,0 +1,36 @@
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// PostWebhook posts the payload as JSON to the webhook such as a Slack incoming webhook. Defaults to
+// http.DefaultClient if the client is nil
+func PostWebhook(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the webhook payload")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the request to %s", url)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post to %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("%s returned status %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// WikiPageName returns the name of the wiki page of the release such as 'Release-v1.2.3'
+func WikiPageName(version string) string {
+	return "Release-v" + strings.TrimPrefix(version, "v")
+}
+
+// WikiCloneURL returns the clone URL of the wiki of the repository of the HTTP URL
+func WikiCloneURL(gitHTTPURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(gitHTTPURL, "/"), ".git") + ".wiki.git"
+}
+
+// validateWiki validates the wiki is supported by the git provider
+func (g *Generator) validateWiki() error {
+	if !g.PublishWiki || g.WikiURL != "" {
+		return nil
+	}
+	switch g.ScmFactory.GitKind {
+	case "", "github", "gitea":
+		return nil
+	default:
+		return options.InvalidOptionf("wiki", "true", "wikis are only supported for github and gitea unless --wiki-url is specified not %s", g.ScmFactory.GitKind)
+	}
+}
+
+// wikiPublisher commits the release notes as a page of the wiki of the repository
+type wikiPublisher struct {
+	g *Generator
+}
+
+func (p *wikiPublisher) Name() string {
+	return "wiki"
+}
+
+func (p *wikiPublisher) Publish(ctx context.Context, result *Result) error {
+	g := p.g
+	spec := &result.Release.Spec
+	wikiURL := g.WikiURL
+	if wikiURL == "" {
+		if spec.GitHTTPURL == "" {
+			return errors.Errorf("cannot find the wiki as the release has no git URL")
+		}
+		wikiURL = WikiCloneURL(spec.GitHTTPURL)
+	}
+	cloneURL, err := gits.AuthURL(wikiURL, g.wikiUsername(ctx), g.ScmFactory.GitToken)
+	if err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir("", "jx-changelog-wiki-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the directory to clone the wiki into")
+	}
+	defer os.RemoveAll(dir)
+
+	git := g.Git()
+	_, err = gitclient.CloneToDir(git, cloneURL, dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to clone the wiki %s. The wiki must have at least one page", wikiURL)
+	}
+	page := WikiPageName(spec.Version) + ".md"
+	err = ioutil.WriteFile(filepath.Join(dir, page), []byte(strings.TrimSpace(result.Markdown)+"\n"), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the wiki page %s", page)
+	}
+	changed, err := gitclient.HasChanges(git, dir)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		log.Logger().Infof("the wiki page %s is up to date", info(page))
+		return nil
+	}
+	err = gitclient.Add(git, dir, page)
+	if err != nil {
+		return err
+	}
+	_, err = git.Command(dir, "commit", "-m", fmt.Sprintf("release notes of %s", result.Tag))
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit the wiki page %s", page)
+	}
+	err = gitclient.Push(git, dir, "origin", false, "HEAD")
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("published the wiki page %s", info(page))
+	return nil
+}
+
+// wikiUsername returns the user name to clone the wiki defaulting to the user of the git token
+func (g *Generator) wikiUsername(ctx context.Context) string {
+	if g.WikiUsername != "" || g.ScmFactory.GitToken == "" || g.ScmFactory.ScmClient == nil {
+		return g.WikiUsername
+	}
+	user, _, err := g.ScmFactory.ScmClient.Users.Find(ctx)
+	if err != nil || user == nil {
+		log.Logger().Debugf("failed to find the user of the git token: %v", err)
+		return "oauth2"
+	}
+	return user.Login
+}
@@ -0,0 +1,24 @@
+// +build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWiki(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Release-v1.2.3", changelog.WikiPageName("1.2.3"))
+	assert.Equal(t, "Release-v1.2.3", changelog.WikiPageName("v1.2.3"))
+	assert.Equal(t, "https://github.com/acme/foo.wiki.git", changelog.WikiCloneURL("https://github.com/acme/foo"))
+	assert.Equal(t, "https://gitea.acme.com/acme/foo.wiki.git", changelog.WikiCloneURL("https://gitea.acme.com/acme/foo.git"))
+
+	g := &changelog.Generator{PublishWiki: true}
+	g.ScmFactory.GitKind = "gitlab"
+	assert.Error(t, g.Validate(), "wikis are not supported for gitlab")
+	g.WikiURL = "https://gitlab.com/acme/foo.wiki.git"
+	assert.NoError(t, g.Validate())
+}
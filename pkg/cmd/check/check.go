@@ -0,0 +1,188 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/create"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// OutputText logs the violations as text
+	OutputText = "text"
+
+	// OutputJSON prints the violations as JSON
+	OutputJSON = "json"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+	changelog.Generator
+
+	Requires []string
+	Output   string
+	Timeout  time.Duration
+	Out      io.Writer
+
+	// Violations the violations found by the last run
+	Violations []changelog.PolicyViolation
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Checks the changelog against the required policies so that pipelines can gate releases on their content
+
+		The changelog is collected just like 'jx-changelog create' without rendering or publishing it. Each policy is specified via --require as 'name' or 'name=argument'. The command exits with code 7 if any policy is violated.
+
+		The supported policies are:
+
+		* no-breaking-changes fails if any commit is a breaking change
+		* all-issues-closed fails if any referenced issue is not closed
+		* conventional-commits fails if any commit does not use Conventional Commits
+		* linked-issues fails if any commit does not reference an issue or pull request
+		* max-commits=N fails if the release has more than N commits
+`)
+
+	cmdExample = templates.Examples(`
+		# fails the promotion if the release has breaking changes or open issues
+		jx-changelog check --require no-breaking-changes --require all-issues-closed
+
+		# checks the changelog between two tags reporting the violations as JSON
+		jx-changelog check --previous-rev v1.0.0 --rev v1.1.0 --require max-commits=50 -o json
+`)
+)
+
+// NewCmdCheck creates the command and options
+func NewCmdCheck() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "check",
+		Short:   "Checks the changelog against the required policies so that pipelines can gate releases on their content",
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := create.ApplySharedConfig(cmd.Flags(), o.ScmFactory.Dir)
+			create.CheckErr(err)
+			err = o.Run()
+			create.CheckErr(err)
+		},
+	}
+	o.ScmFactory.DiscoverFromGit = true
+
+	create.AddCollectFlags(cmd, &o.Generator)
+	cmd.Flags().StringArrayVarP(&o.Requires, "require", "", nil, fmt.Sprintf("The policies the changelog must satisfy. Values: %s", strings.Join(changelog.PolicyNames(), ", ")))
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputText, fmt.Sprintf("The format of the report. Values: %s or %s", OutputText, OutputJSON))
+	cmd.Flags().DurationVarP(&o.Timeout, "timeout", "", 0, "The maximum time to spend collecting the changelog such as '10m'. Defaults to no timeout")
+
+	o.ScmFactory.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and discovers the git repository
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if len(o.Requires) == 0 {
+		return options.MissingOption("require")
+	}
+	for _, r := range o.Requires {
+		err = changelog.ValidatePolicy(r)
+		if err != nil {
+			return options.InvalidOptionf("require", r, "%s", err.Error())
+		}
+	}
+	switch o.Output {
+	case OutputText, OutputJSON:
+	default:
+		return options.InvalidOptionf("output", o.Output, "should be %s or %s", OutputText, OutputJSON)
+	}
+	err = o.ScmFactory.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to discover git repository")
+	}
+
+	// lets only collect the changelog
+	o.UpdateRelease = false
+	o.GenerateReleaseYaml = false
+	o.GenerateCRD = false
+	err = o.Generator.Validate()
+	if err != nil {
+		return err
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	return nil
+}
+
+// Run collects the changelog and checks the policies
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	ctx := o.GetContext()
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	model, err := o.CollectModel(ctx)
+	if err != nil {
+		return err
+	}
+	if model == nil {
+		log.Logger().Warnf("no previous tag to check the changelog from. Use --previous-rev or --first-release")
+		return nil
+	}
+	o.Violations, err = changelog.CheckPolicies(model, o.Requires)
+	if err != nil {
+		return err
+	}
+	err = o.report()
+	if err != nil {
+		return err
+	}
+	return changelog.PolicyViolationsError(o.Violations)
+}
+
+func (o *Options) report() error {
+	if o.Output == OutputJSON {
+		violations := o.Violations
+		if violations == nil {
+			violations = []changelog.PolicyViolation{}
+		}
+		data, err := json.MarshalIndent(violations, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the violations")
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	}
+	if len(o.Violations) == 0 {
+		log.Logger().Infof("the changelog satisfies the policies %s", termcolor.ColorInfo(strings.Join(o.Requires, ", ")))
+		return nil
+	}
+	var buf strings.Builder
+	for _, v := range o.Violations {
+		buf.WriteString(fmt.Sprintf("\n  %s: %s", termcolor.ColorWarning(v.Policy), v.Message))
+	}
+	log.Logger().Infof("the changelog violates the policies:%s", buf.String())
+	return nil
+}
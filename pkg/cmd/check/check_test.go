@@ -0,0 +1,80 @@
+package check_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/check"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	git := func(args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	commit := func(message string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(message), 0600))
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	commit("feat: initial")
+	git("tag", "v1.0.0")
+	commit("feat(cli)!: something new\n\nBREAKING CHANGE: the old flag is removed")
+	commit("fix: a bug")
+	git("tag", "v1.1.0")
+
+	newOptions := func(requires ...string) (*check.Options, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		scmClient, _ := scmfake.NewDefault()
+		_, o := check.NewCmdCheck()
+		o.Ctx = context.Background()
+		o.GitClient = g
+		o.ScmFactory.Dir = dir
+		o.ScmFactory.SourceURL = "https://github.com/myorg/myrepo"
+		o.ScmFactory.GitKind = "fake"
+		o.ScmFactory.ScmClient = scmClient
+		o.ScmFactory.GitClient = g
+		o.PreviousRevision = "v1.0.0"
+		o.CurrentRevision = "v1.1.0"
+		o.Requires = requires
+		o.Out = out
+		return o, out
+	}
+
+	o, _ := newOptions("conventional-commits", "max-commits=2")
+	require.NoError(t, o.Run())
+	assert.Empty(t, o.Violations)
+
+	o, out := newOptions("no-breaking-changes", "max-commits=1")
+	o.Output = check.OutputJSON
+	err := o.Run()
+	require.Error(t, err)
+	assert.Equal(t, changelog.ExitCodePolicyViolation, changelog.ExitCode(err))
+	var violations []changelog.PolicyViolation
+	require.NoError(t, json.Unmarshal(out.Bytes(), &violations))
+	require.Len(t, violations, 2)
+	assert.Equal(t, "no-breaking-changes", violations[0].Policy)
+	assert.Equal(t, "max-commits=1", violations[1].Policy)
+
+	o, _ = newOptions()
+	assert.Error(t, o.Run(), "should require a policy")
+
+	o, _ = newOptions("no-such-policy")
+	assert.Error(t, o.Run())
+}
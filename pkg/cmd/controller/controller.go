@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/controller"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/metrics"
+	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	JXClient      jxc.Interface
+	KubeClient    kubernetes.Interface
+	GitClient     gitclient.Interface
+	Namespace     string
+	ConfigMapName string
+	ConfigFile    string
+	GitUsername   string
+	GitToken      string
+	CommitterName string
+	CommitterMail string
+	WorkDir       string
+	Resync        time.Duration
+	MetricsAddr   string
+	Config        *controller.Config
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Runs a controller which publishes the changelogs of the Release resources of a namespace
+
+		Each new version of a Release is posted to the notification webhooks such as Slack channels or environment dashboards and added to the CHANGELOG files of GitOps repositories so that publishing changelogs does not depend on the build pipelines. Published releases are annotated with 'changelog.jenkins-x.io/reconciled' so that they are only published once
+
+		The configuration is loaded from the 'config.yaml' key of a ConfigMap or a file:
+
+		  notifications:
+		  - name: releases
+		    url: https://hooks.slack.com/services/...
+		    format: slack
+		  - name: dashboard
+		    url: http://dashboard/api/releases
+		    format: json
+		    repositories: ["myorg/*"]
+		  changelogs:
+		  - url: https://github.com/myorg/environment-mycluster-dev.git
+		    path: "changelogs/{{ .Repository }}.md"
+
+		Prometheus metrics of the notifications and CHANGELOG updates are exposed on the '/metrics' path of the '--metrics-address'
+`)
+
+	cmdExample = templates.Examples(`
+		# run the controller in the current namespace
+		jx-changelog controller
+
+		# use a configuration file
+		jx-changelog controller --config controller.yaml
+`)
+)
+
+// NewCmdController creates the command and options
+func NewCmdController() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "controller",
+		Short:   "Runs a controller which publishes the changelogs of the Release resources of a namespace",
+		Aliases: []string{"operator"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "The namespace of the Release resources. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&o.ConfigMapName, "config-map", "", controller.DefaultConfigMapName, "The name of the ConfigMap containing the configuration")
+	cmd.Flags().StringVarP(&o.ConfigFile, "config", "", "", "The configuration file to use instead of the ConfigMap")
+	cmd.Flags().StringVarP(&o.GitUsername, "git-username", "", "oauth2", "The git user name used with the git token to push to the GitOps repositories")
+	cmd.Flags().StringVarP(&o.GitToken, "git-token", "", "", "The git token used to push to the GitOps repositories. Defaults to the '$GIT_TOKEN' environment variable")
+	cmd.Flags().StringVarP(&o.CommitterName, "committer-name", "", controller.DefaultCommitterName, "The name of the committer of the CHANGELOG updates")
+	cmd.Flags().StringVarP(&o.CommitterMail, "committer-email", "", controller.DefaultCommitterEmail, "The email of the committer of the CHANGELOG updates")
+	cmd.Flags().StringVarP(&o.WorkDir, "work-dir", "", "", "The directory the GitOps repositories are cloned into. Defaults to the temporary directory")
+	cmd.Flags().DurationVarP(&o.Resync, "resync", "", 10*time.Minute, "How often all the Release resources are reconciled again")
+	cmd.Flags().StringVarP(&o.MetricsAddr, "metrics-address", "", ":8080", "The address serving the Prometheus metrics and health checks. Empty disables the metrics")
+
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options, creates the clients and loads the configuration
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create jx client")
+	}
+	if o.GitToken == "" {
+		o.GitToken = os.Getenv("GIT_TOKEN")
+	}
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", nil)
+	}
+	if o.Config != nil {
+		return nil
+	}
+	var text string
+	if o.ConfigFile != "" {
+		data, err := ioutil.ReadFile(o.ConfigFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", o.ConfigFile)
+		}
+		text = string(data)
+	} else {
+		o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create kube client")
+		}
+		cm, err := o.KubeClient.CoreV1().ConfigMaps(o.Namespace).Get(o.GetContext(), o.ConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", o.ConfigMapName, o.Namespace)
+		}
+		text = cm.Data[controller.ConfigMapKey]
+	}
+	o.Config, err = controller.ParseConfig(text)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run runs the controller
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	c := &controller.Controller{
+		JXClient:       o.JXClient,
+		Namespace:      o.Namespace,
+		Config:         o.Config,
+		GitClient:      o.GitClient,
+		GitUsername:    o.GitUsername,
+		GitToken:       o.GitToken,
+		CommitterName:  o.CommitterName,
+		CommitterEmail: o.CommitterMail,
+		WorkDir:        o.WorkDir,
+	}
+	if o.MetricsAddr != "" {
+		go o.serveMetrics()
+	}
+	return c.Run(o.GetContext(), o.Resync)
+}
+
+// serveMetrics serves the Prometheus metrics and the health checks
+func (o *Options) serveMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK")) //nolint:errcheck
+	})
+	log.Logger().Infof("serving metrics on %s/metrics", termcolor.ColorInfo(o.MetricsAddr))
+	err := http.ListenAndServe(o.MetricsAddr, mux)
+	if err != nil {
+		log.Logger().Errorf("failed to serve metrics on %s: %s", o.MetricsAddr, err.Error())
+	}
+}
@@ -0,0 +1,172 @@
+package create
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// ConfigFile the path of the changelog configuration file relative to the root of the repository
+	ConfigFile = ".jx/changelog.yaml"
+
+	// UserConfigFile the path of the user configuration file relative to $XDG_CONFIG_HOME
+	UserConfigFile = "jx-changelog/config.yaml"
+
+	// EnvPrefix the prefix of the environment variables which override the options. e.g. JX_CHANGELOG_SKIP_COMMIT_PATTERN
+	EnvPrefix = "JX_CHANGELOG_"
+)
+
+// releaseFlags the options which differ for every release so they can only be specified on the command line
+var releaseFlags = map[string]bool{
+	"version":      true,
+	"rev":          true,
+	"previous-rev": true,
+}
+
+// ApplyConfig defaults any flags which were not specified on the command line from the environment variables,
+// then the repository configuration file, then the user configuration file and then the configuration file of the
+// organisation wide '--config-repo'
+func ApplyConfig(flags *pflag.FlagSet, dir string) error {
+	return applyConfig(flags, dir, true)
+}
+
+// ApplySharedConfig defaults the flags like ApplyConfig for commands which only share some of the options of the
+// create command so the other options in the configuration files are ignored
+func ApplySharedConfig(flags *pflag.FlagSet, dir string) error {
+	return applyConfig(flags, dir, false)
+}
+
+func applyConfig(flags *pflag.FlagSet, dir string, strict bool) error {
+	config := map[string]interface{}{}
+	for _, path := range configFiles(dir) {
+		exists, err := files.FileExists(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if file exists %s", path)
+		}
+		if !exists {
+			continue
+		}
+		values := map[string]interface{}{}
+		err = yamls.LoadFile(path, &values)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load changelog configuration file %s", path)
+		}
+		for k, v := range values {
+			if flags.Lookup(k) == nil || releaseFlags[k] {
+				if !strict {
+					continue
+				}
+				if releaseFlags[k] {
+					return errors.Errorf("option %s in changelog configuration file %s can only be specified on the command line", k, path)
+				}
+				return errors.Errorf("unknown option %s in changelog configuration file %s", k, path)
+			}
+			config[k] = v
+		}
+		log.Logger().Debugf("loaded changelog configuration file %s", path)
+	}
+	if repo := configRepoName(flags, config); repo != "" {
+		values, err := loadConfigRepo(flags, repo)
+		if err != nil {
+			return err
+		}
+		for k := range values {
+			if flags.Lookup(k) == nil || releaseFlags[k] {
+				if strict {
+					return errors.Errorf("unknown option %s in the changelog configuration of %s", k, repo)
+				}
+				delete(values, k)
+			}
+		}
+		mergeConfig(config, values)
+	}
+
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "help" || releaseFlags[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(EnvVarName(f.Name))
+		if ok {
+			err = setFlag(f, []string{value})
+			return
+		}
+		v, ok := config[f.Name]
+		if ok {
+			err = setFlag(f, configValues(v))
+		}
+	})
+	return err
+}
+
+// EnvVarName returns the name of the environment variable which overrides the given flag
+func EnvVarName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// configFiles returns the configuration files in order of increasing precedence
+func configFiles(dir string) []string {
+	var answer []string
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err == nil && home != "" {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		answer = append(answer, filepath.Join(configHome, UserConfigFile))
+	}
+	if dir == "" {
+		dir = "."
+	}
+	return append(answer, filepath.Join(dir, ConfigFile))
+}
+
+// configValues converts a value from the YAML configuration file to the text values of the flag
+func configValues(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var answer []string
+		for _, e := range v {
+			answer = append(answer, configValues(e)...)
+		}
+		return answer
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case map[string]interface{}:
+		var answer []string
+		for k, e := range v {
+			answer = append(answer, fmt.Sprintf("%s=%v", k, e))
+		}
+		sort.Strings(answer)
+		return answer
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
+
+func setFlag(f *pflag.Flag, values []string) error {
+	var err error
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		err = sv.Replace(values)
+	} else {
+		err = f.Value.Set(strings.Join(values, ","))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "invalid value %s for option %s", strings.Join(values, ","), f.Name)
+	}
+	return nil
+}
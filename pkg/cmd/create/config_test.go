@@ -0,0 +1,93 @@
+package create_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/create"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfigRepo(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	configRepo := filepath.Join(tmpDir, "changelog-config")
+	require.NoError(t, os.MkdirAll(filepath.Join(configRepo, "templates"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(configRepo, create.ConfigRepoFile), []byte(
+		"header-file: templates/header.md\nskip-commit-pattern: '^chore'\nissue-url-template:\n  PROJ: https://jira.example.com/browse/PROJ-{id}\n  OPS: https://jira.example.com/browse/OPS-{id}\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(configRepo, "templates", "header.md"), []byte("# Org header\n"), 0600))
+	g := cli.NewCLIClient("", nil)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		_, err = g.Command(configRepo, args...)
+		require.NoError(t, err, "failed to run git %v", args)
+	}
+
+	dir := filepath.Join(tmpDir, "app")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".jx"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, create.ConfigFile), []byte(
+		"skip-commit-pattern: '^release'\nissue-url-template:\n  OPS: https://ops.example.com/{id}\n"), 0600))
+
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	os.Setenv(create.EnvVarName("config-repo"), "file://"+configRepo)
+	defer os.Unsetenv("XDG_CACHE_HOME")
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	defer os.Unsetenv(create.EnvVarName("config-repo"))
+
+	cmd, o := create.NewCmdChangelogCreate()
+	err = create.ApplyConfig(cmd.Flags(), dir)
+	require.NoError(t, err, "failed to apply the configuration")
+
+	cacheFiles, err := filepath.Glob(filepath.Join(tmpDir, "cache", create.ConfigRepoCacheDir, "changelog-config-*", "templates", "header.md"))
+	require.NoError(t, err)
+	require.Len(t, cacheFiles, 1, "should have cached the clone of the configuration repository")
+	assert.Equal(t, cacheFiles[0], o.HeaderFile, "should resolve the templates of the configuration repository")
+	assert.Equal(t, "^release", o.SkipCommitPattern, "the repository configuration should override the configuration repository")
+	assert.Equal(t, map[string]string{
+		"PROJ": "https://jira.example.com/browse/PROJ-{id}",
+		"OPS":  "https://ops.example.com/{id}",
+	}, o.IssueURLTemplates)
+
+	// lets check the cached clone is used if the configuration repository is gone
+	require.NoError(t, os.RemoveAll(configRepo))
+	cmd, o = create.NewCmdChangelogCreate()
+	err = create.ApplyConfig(cmd.Flags(), dir)
+	require.NoError(t, err, "failed to apply the cached configuration")
+	assert.Equal(t, cacheFiles[0], o.HeaderFile)
+}
+
+func TestApplyConfigReleaseFlags(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	os.Setenv(create.EnvVarName("version"), "1.2.3")
+	os.Setenv(create.EnvVarName("skip-commit-pattern"), "^release")
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	defer os.Unsetenv(create.EnvVarName("version"))
+	defer os.Unsetenv(create.EnvVarName("skip-commit-pattern"))
+
+	cmd, o := create.NewCmdChangelogCreate()
+	err = create.ApplyConfig(cmd.Flags(), tmpDir)
+	require.NoError(t, err, "failed to apply the configuration")
+	assert.Equal(t, "", o.Version, "the version should only be specified on the command line")
+	assert.Equal(t, "^release", o.SkipCommitPattern)
+	assert.Contains(t, cmd.Long, "$JX_CHANGELOG_SKIP_COMMIT_PATTERN", "the help should keep the underscores of the environment variables")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".jx"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, create.ConfigFile), []byte("previous-rev: v1.0.0\n"), 0600))
+	cmd, _ = create.NewCmdChangelogCreate()
+	err = create.ApplyConfig(cmd.Flags(), tmpDir)
+	require.Error(t, err, "the previous revision should not be configurable")
+}
@@ -0,0 +1,190 @@
+package create
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// ConfigRepoFile the path of the changelog configuration file in the root of the '--config-repo'
+	ConfigRepoFile = "changelog.yaml"
+
+	// ConfigRepoCacheDir the directory relative to $XDG_CACHE_HOME which caches the clones of the configuration repositories
+	ConfigRepoCacheDir = "jx-changelog/config-repos"
+
+	// ConfigRepoTTL how long the cached clone of a configuration repository is used before it is fetched again
+	ConfigRepoTTL = 15 * time.Minute
+
+	configRepoFlag = "config-repo"
+)
+
+// configRepoName returns the configuration repository from the command line, environment variable or the local
+// configuration files
+func configRepoName(flags *pflag.FlagSet, config map[string]interface{}) string {
+	if f := flags.Lookup(configRepoFlag); f != nil && f.Changed {
+		return f.Value.String()
+	}
+	if value, ok := os.LookupEnv(EnvVarName(configRepoFlag)); ok {
+		return value
+	}
+	if v, ok := config[configRepoFlag]; ok {
+		return strings.Join(configValues(v), ",")
+	}
+	return ""
+}
+
+// loadConfigRepo fetches the configuration repository into the cache and loads its changelog configuration file.
+// Relative paths of the file and directory options are resolved against the clone so that the repository can share
+// templates
+func loadConfigRepo(flags *pflag.FlagSet, repo string) (map[string]interface{}, error) {
+	dir, err := fetchConfigRepo(flags, repo)
+	if err != nil {
+		return nil, err
+	}
+	configFile := filepath.Join(dir, ConfigRepoFile)
+	exists, err := files.FileExists(configFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", configFile)
+	}
+	if !exists {
+		return nil, errors.Errorf("the configuration repository %s has no %s file", repo, ConfigRepoFile)
+	}
+	values := map[string]interface{}{}
+	err = yamls.LoadFile(configFile, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the changelog configuration file %s of %s", ConfigRepoFile, repo)
+	}
+	for k, v := range values {
+		text, ok := v.(string)
+		if !ok || text == "" || filepath.IsAbs(text) || !(strings.HasSuffix(k, "-file") || strings.HasSuffix(k, "-dir")) {
+			continue
+		}
+		path := filepath.Join(dir, text)
+		exists, err = files.FileExists(path)
+		if err == nil && !exists {
+			exists, err = files.DirExists(path)
+		}
+		if err == nil && exists {
+			values[k] = path
+		}
+	}
+	log.Logger().Debugf("loaded changelog configuration file %s of %s", ConfigRepoFile, repo)
+	return values, nil
+}
+
+// fetchConfigRepo returns the directory of the clone of the configuration repository cloning or fetching it if the
+// cached clone is missing or older than ConfigRepoTTL. The cached clone is used if it cannot be fetched
+func fetchConfigRepo(flags *pflag.FlagSet, repo string) (string, error) {
+	gitURL := repo
+	if !strings.Contains(repo, "://") && !strings.HasPrefix(repo, "git@") {
+		server := flagValue(flags, "git-server")
+		if server == "" {
+			server = "https://github.com"
+		}
+		gitURL = strings.TrimSuffix(server, "/") + "/" + strings.Trim(repo, "/") + ".git"
+	}
+	token := flagValue(flags, "git-token")
+	if token == "" {
+		token = os.Getenv("GIT_TOKEN")
+	}
+	username := flagValue(flags, "git-username")
+	if username == "" {
+		username = "oauth2"
+	}
+	// lets not keep the token in the cached clone or its errors
+	credentialArgs, _, cleanup, err := gits.CredentialArgs(gitURL, username, token)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome, err = os.UserCacheDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to find the cache directory of the configuration repositories")
+		}
+	}
+	sum := sha256.Sum256([]byte(gitURL))
+	dir := filepath.Join(cacheHome, ConfigRepoCacheDir, strings.TrimSuffix(path.Base(gitURL), ".git")+"-"+hex.EncodeToString(sum[:6]))
+
+	g := cli.NewCLIClient("", nil)
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	if err == nil && info.IsDir() {
+		stamp, err := os.Stat(dir)
+		if err == nil && time.Since(stamp.ModTime()) < ConfigRepoTTL {
+			return dir, nil
+		}
+		_, err = g.Command(dir, append(credentialArgs, "fetch", "--depth", "1", gitURL, "HEAD")...)
+		if err == nil {
+			_, err = g.Command(dir, "reset", "--hard", "FETCH_HEAD")
+		}
+		if err != nil {
+			log.Logger().Warnf("failed to fetch the configuration repository %s so using the cached clone: %s", gitURL, err.Error())
+			return dir, nil
+		}
+	} else {
+		err = os.MkdirAll(filepath.Dir(dir), files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to create the cache directory %s", filepath.Dir(dir))
+		}
+		_, err = g.Command(filepath.Dir(dir), append(credentialArgs, "clone", "--depth", "1", gitURL, dir)...)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to clone the configuration repository %s", gitURL)
+		}
+	}
+	now := time.Now()
+	err = os.Chtimes(dir, now, now)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to mark the cached clone %s as fetched", dir)
+	}
+	return dir, nil
+}
+
+// mergeConfig sets the values of the configuration which are not already set merging the keys of map values
+func mergeConfig(config, values map[string]interface{}) {
+	for k, v := range values {
+		existing, ok := config[k]
+		if !ok {
+			config[k] = v
+			continue
+		}
+		em, ok1 := existing.(map[string]interface{})
+		vm, ok2 := v.(map[string]interface{})
+		if ok1 && ok2 {
+			merged := map[string]interface{}{}
+			for mk, mv := range vm {
+				merged[mk] = mv
+			}
+			for mk, mv := range em {
+				merged[mk] = mv
+			}
+			config[k] = merged
+		}
+	}
+}
+
+// flagValue returns the value of the flag or an empty string if there is no such flag
+func flagValue(flags *pflag.FlagSet, name string) string {
+	f := flags.Lookup(name)
+	if f == nil {
+		return ""
+	}
+	if value, ok := os.LookupEnv(EnvVarName(name)); ok && !f.Changed {
+		return value
+	}
+	return f.Value.String()
+}
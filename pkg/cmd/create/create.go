@@ -1,130 +1,63 @@
 package create
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"regexp"
-	"sort"
 	"strings"
-	"text/template"
 	"time"
 
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/version"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
-	"github.com/jenkins-x-plugins/jx-changelog/pkg/helmhelpers"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/httpclient"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
-	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
-	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/tracing"
 	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/builds"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/input/inputfactory"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/activities"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
 
 	"github.com/pkg/errors"
 
-	"github.com/ghodss/yaml"
-
-	jenkinsio "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io"
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/spf13/cobra"
-	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"go.opentelemetry.io/otel/attribute"
 
-	chgit "github.com/antham/chyle/chyle/git"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Options contains the command line flags
 type Options struct {
 	options.BaseOptions
-
-	ScmFactory    scmhelpers.Options
-	GitClient     gitclient.Interface
-	CommandRunner cmdrunner.CommandRunner
-	JXClient      jxc.Interface
-
-	Namespace           string
-	BuildNumber         string
-	PreviousRevision    string
-	PreviousDate        string
-	CurrentRevision     string
-	TemplatesDir        string
-	ReleaseYamlFile     string
-	CrdYamlFile         string
-	Version             string
-	Build               string
-	Header              string
-	HeaderFile          string
-	Footer              string
-	FooterFile          string
-	OutputMarkdownFile  string
-	OverwriteCRD        bool
-	GenerateCRD         bool
-	GenerateReleaseYaml bool
-	UpdateRelease       bool
-	NoReleaseInDev      bool
-	IncludeMergeCommits bool
-	FailIfFindCommits   bool
-	State               State
-}
-
-type State struct {
-	Tracker         issues.IssueProvider
-	FoundIssueNames map[string]bool
-	LoggedIssueKind bool
-	Release         *v1.Release
+	changelog.Generator
+
+	JXClient jxc.Interface
+
+	Namespace       string
+	BuildNumber     string
+	Build           string
+	NoReleaseInDev  bool
+	Timeout         time.Duration
+	OnActivityError string
+	LogFormat       string
+	Profile         bool
+	Quiet           bool
+	Interactive     bool
+	ConfigRepo      string
+	HTTP            httpclient.Options
 }
 
-const (
-	ReleaseName = `{{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}`
-
-	SpecName    = `{{ .Chart.Name }}`
-	SpecVersion = `{{ .Chart.Version }}`
-
-	ReleaseCrdYaml = `apiVersion: apiextensions.k8s.io/v1beta1
-kind: CustomResourceDefinition
-metadata:
-  creationTimestamp: 2018-02-24T14:56:33Z
-  name: releases.jenkins.io
-  resourceVersion: "557150"
-  selfLink: /apis/apiextensions.k8s.io/v1beta1/customresourcedefinitions/releases.jenkins.io
-  uid: e77f4e08-1972-11e8-988e-42010a8401df
-spec:
-  group: jenkins.io
-  names:
-    kind: Release
-    listKind: ReleaseList
-    plural: releases
-    shortNames:
-    - rel
-    singular: release
-    categories:
-    - all
-  scope: Namespaced
-  version: v1`
-)
-
 var (
-	info = termcolor.ColorInfo
-
 	GitAccessDescription = `
 
 By default jx commands look for a file '~/.jx/gitAuth.yaml' to find the API tokens for Git servers. You can use 'jx create git token' to create a Git token.
@@ -146,6 +79,18 @@ e.g. define environment variables GIT_USERNAME and GIT_API_TOKEN
 		This command also generates a Release Custom Resource Definition you can include in your helm chart to give metadata about the changelog of the application along with metadata about the release (git tag, url, commits, issues fixed etc). Including this metadata in a helm charts means we can do things like automatically comment on issues when they hit Staging or Production; or give detailed descriptions of what things have changed when using GitOps to update versions in an environment by referencing the fixed issues in the Pull Request.
 
 		You can opt out of the release YAML generation via the '--generate-yaml=false' option
+
+		Any option other than '--version', '--rev' and '--previous-rev' can also be specified in the '.jx/changelog.yaml' file in the repository or in the user configuration file, `+"`$XDG_CONFIG_HOME/jx-changelog/config.yaml`"+`, using the option names as keys. Environment variables named after the options, such as `+"`$JX_CHANGELOG_SKIP_COMMIT_PATTERN`"+`, override the configuration files and command line options override everything else
+
+		Platform teams can roll out the changelog style of an organisation via the '--config-repo' option, usually set in the user configuration file or the environment variable `+"`$JX_CHANGELOG_CONFIG_REPO`"+`. The 'changelog.yaml' file of the repository is merged under the local configuration
+
+		When run in a Jenkins X version stream or a cluster repository containing one in the 'versionStream' directory the version changes of the charts and packages are added to the Dependencies section with links to their upstream releases
+
+		The command exits with 3 if there is no previous tag and '--fail-if-no-commits' is enabled, 4 if there are no commits or fewer than '--min-commits', 5 if the release conflicts with an existing release on the git provider and 6 if the git provider rejects the credentials. Other failures exit with 1
+
+		The changelogs of the components of a monorepo or of several repositories can be generated concurrently via '--component', '--component-repository' and '--component-workers'. The workers share the rate limit of the git provider and the changelogs are merged into a section per component in the order of the flags
+
+		The phases of generating the changelog are traced via OpenTelemetry to the OTLP endpoint of the standard environment variable `+"`$OTEL_EXPORTER_OTLP_ENDPOINT`"+`, if it is set. The trace joins the pipeline trace given by '$TRACEPARENT'
 		
 		To update the release notes on your git provider needs a git API token which is usually provided via the Tekton git authentication mechanism.
 
@@ -161,65 +106,201 @@ e.g. define environment variables GIT_USERNAME and GIT_API_TOKEN
 		# specify the version and a header template
 		jx-changelog create --header-file docs/dev/changelog-header.md --version 1.2.3
 
-`)
+		# generate the changelog of the components of a monorepo 4 at a time
+		jx-changelog create --component api=services/api --component web=services/web,libs/ui --component-workers 4
 
-	GitHubIssueRegex = regexp.MustCompile(`(\#\d+)`)
-	JIRAIssueRegex   = regexp.MustCompile(`[A-Z][A-Z]+-(\d+)`)
+`)
 )
 
+// CheckErr exits with the exit code of the kind of the error so that pipelines can tell why the changelog failed
+func CheckErr(err error) {
+	code := changelog.ExitCode(err)
+	if err == nil || code == changelog.ExitCodeFailure {
+		helper.CheckErr(err)
+		return
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "error: ") {
+		msg = "error: " + msg
+	}
+	helper.Fatal(msg, code)
+}
+
 // NewCmdChangelogCreate creates the command and options
 func NewCmdChangelogCreate() (*cobra.Command, *Options) {
 	o := &Options{}
 	cmd := &cobra.Command{
 		Use:     "create",
 		Short:   "Creates a changelog for a git tag",
-		Aliases: []string{"changelog", "changes", "publish"},
+		Aliases: []string{"changelog", "changes"},
 		Long:    cmdLong,
 		Example: cmdExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := o.Run()
+			err := ApplyConfig(cmd.Flags(), o.ScmFactory.Dir)
 			helper.CheckErr(err)
+			err = o.Run()
+			CheckErr(err)
 		},
 	}
 	o.ScmFactory.DiscoverFromGit = true
 
-	cmd.Flags().StringVarP(&o.PreviousRevision, "previous-rev", "p", "", "the previous tag revision")
-	cmd.Flags().StringVarP(&o.PreviousDate, "previous-date", "", "", "the previous date to find a revision in format 'MonthName dayNumber year'")
-	cmd.Flags().StringVarP(&o.CurrentRevision, "rev", "", "", "the current tag revision")
+	AddCollectFlags(cmd, &o.Generator)
+	cmd.Flags().StringVarP(&o.ConfigRepo, "config-repo", "", "", "The organisation wide configuration repository such as 'myorg/changelog-config' or a git URL. The options of its 'changelog.yaml' file are used unless set locally and relative paths such as the '--header-file' are taken from the repository which is cached for 15 minutes")
 	cmd.Flags().StringVarP(&o.TemplatesDir, "templates-dir", "t", "", "the directory containing the helm chart templates to generate the resources")
 	cmd.Flags().StringVarP(&o.ReleaseYamlFile, "release-yaml-file", "", "release.yaml", "the name of the file to generate the Release YAML")
 	cmd.Flags().StringVarP(&o.CrdYamlFile, "crd-yaml-file", "", "release-crd.yaml", "the name of the file to generate the Release CustomResourceDefinition YAML")
-	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "The version to release")
 	cmd.Flags().StringVarP(&o.Build, "build", "", "", "The Build number which is used to update the PipelineActivity. If not specified its defaulted from  the '$BUILD_NUMBER' environment variable")
+	cmd.Flags().StringArrayVarP(&o.Components, "component", "", nil, "The name and comma separated paths of a component of a monorepo such as 'api=services/api'. The changelog of each component is generated separately via --path and merged into a section per component")
+	cmd.Flags().StringArrayVarP(&o.ComponentRepositories, "component-repository", "", nil, "The name and directory of a git clone of another repository on the same git server such as 'web=../web' whose changelog is generated separately and merged into a section after the --component sections")
+	cmd.Flags().IntVarP(&o.ComponentWorkers, "component-workers", "", 1, "The number of components and repositories whose changelogs are generated concurrently. The sections are merged in the order of the flags whatever the number of workers")
+	cmd.Flags().IntVarP(&o.ComponentMinRateLimit, "component-min-rate-limit", "", 0, "Waits for the rate limit of the git provider to reset once the remaining rate limit drops below this number while generating the changelogs of the components. Zero disables the check")
 	cmd.Flags().StringVarP(&o.OutputMarkdownFile, "output-markdown", "", "", "The file to generate for the changelog output if not updating a Git provider release")
+	cmd.Flags().StringVarP(&o.Format, "format", "", changelog.RendererMarkdown, fmt.Sprintf("The format of the changelog output if not updating a Git provider release. Values: %s", strings.Join(changelog.RendererNames(), ", ")))
+	cmd.Flags().StringToStringVarP(&o.FormatOptions, "format-option", "", nil, "The options of the output format such as 'page=true,title=My Release' for html, 'compact=true' for json or 'channel=releases' for slack")
+	cmd.Flags().StringVarP(&o.ExportEnvFile, "export-env-file", "", "", "The file to generate containing the version, tag, release URL and other details of the changelog as environment variables in dotenv format for later pipeline steps")
+	cmd.Flags().BoolVarP(&o.Sign, "sign", "", false, "Signs the markdown and JSON renderings of the changelog with cosign keyless signing and uploads them along with their signatures, certificates and bundles as assets of the release")
+	cmd.Flags().StringVarP(&o.CosignBinary, "cosign-binary", "", "cosign", "The cosign binary used to sign the changelog via --sign")
+	cmd.Flags().StringVarP(&o.ChecksumsFile, "checksums-file", "", "", "The checksums file of the release artifacts such as 'sha256sums.txt' rendered as a table in the release notes and uploaded as an asset of the release")
+	cmd.Flags().StringVarP(&o.CalendarFile, "calendar-file", "", "", "The iCalendar .ics file to add the event of the release to, replacing any existing event of the same version, so that teams can subscribe to a release calendar")
+	cmd.Flags().StringVarP(&o.SiteDir, "site-dir", "", "", "The directory of the static site to write the data file of the release to such as 'docs/data/releases' for hugo or 'website/blog' for docusaurus")
+	cmd.Flags().StringVarP(&o.SiteFormat, "site-format", "", changelog.SiteFormatHugo, fmt.Sprintf("The format of the data file of the release in the --site-dir. Values: %s for a JSON data file or %s for a blog post with front matter", changelog.SiteFormatHugo, changelog.SiteFormatDocusaurus))
+	cmd.Flags().StringVarP(&o.SiteBranch, "site-branch", "", "", "If specified the data file of the release is committed and pushed to the --site-dir of this branch such as 'gh-pages' rather than written to the working directory")
+	cmd.Flags().BoolVarP(&o.PublishWiki, "wiki", "", false, "Commits the release notes as the Release-vX.Y.Z page of the wiki of the repository on GitHub or Gitea")
+	cmd.Flags().StringVarP(&o.WikiURL, "wiki-url", "", "", "The clone URL of the wiki. Defaults to the .wiki.git URL of the repository")
+	cmd.Flags().StringVarP(&o.WikiUsername, "wiki-username", "", "", "The user name used with the git token to push to the wiki. Defaults to the user of the git token")
+	cmd.Flags().StringVarP(&o.ProjectBoard, "project-board", "", "", "The owner and number of the GitHub project such as myorg/5 whose items of the issues and pull requests of the release are moved to the --project-option of the --project-field")
+	cmd.Flags().StringVarP(&o.ProjectField, "project-field", "", changelog.DefaultProjectField, "The single select field of the --project-board which is set for the released issues and pull requests")
+	cmd.Flags().StringVarP(&o.ProjectOption, "project-option", "", changelog.DefaultProjectOption, "The option of the --project-field set for the released issues and pull requests such as the Released column")
+	cmd.Flags().StringSliceVarP(&o.TranslateLanguages, "translate", "", nil, "The languages such as 'ja' to translate the changelog into via --translate-command or --translate-url")
+	cmd.Flags().StringVarP(&o.TranslateCommand, "translate-command", "", "", "The shell command which translates the markdown on its standard input into the language of the $CHANGELOG_LANGUAGE environment variable writing the translation to its standard output")
+	cmd.Flags().StringVarP(&o.TranslateURL, "translate-url", "", "", "The URL of the endpoint which translates the markdown. It is posted JSON with the language and markdown and replies with JSON containing the translated markdown")
+	cmd.Flags().StringVarP(&o.TranslationOutput, "translation-output", "", changelog.TranslationOutputSection, fmt.Sprintf("How the translations are published. Values: %s to append them as sections of the release notes or %s to write them to the translations directory", changelog.TranslationOutputSection, changelog.TranslationOutputFile))
+	cmd.Flags().StringVarP(&o.TranslationsDir, "translations-dir", "", "translations", "The directory the translations are written to as <language>.md files if the translation output is "+changelog.TranslationOutputFile)
 	cmd.Flags().BoolVarP(&o.OverwriteCRD, "overwrite", "o", false, "overwrites the Release CRD YAML file if it exists")
 	cmd.Flags().BoolVarP(&o.GenerateCRD, "crd", "c", false, "Generate the CRD in the chart")
 	cmd.Flags().BoolVarP(&o.GenerateReleaseYaml, "generate-yaml", "y", true, "Generate the Release YAML in the local helm chart")
 	cmd.Flags().BoolVarP(&o.UpdateRelease, "update-release", "", true, "Should we update the release on the Git repository with the changelog")
+	cmd.Flags().BoolVarP(&o.ApprovalPR, "approval-pr", "", false, "Rather than updating the release on the Git repository the changelog is committed to the --approval-dir on a branch and a pull request is opened on the default branch so that the release notes are reviewed. Once the pull request is merged 'jx-changelog publish --from-file' creates the release")
+	cmd.Flags().StringVarP(&o.ApprovalDir, "approval-dir", "", changelog.DefaultApprovalDir, "The directory of the repository the release notes awaiting approval are committed to as '<tag>.md' files")
+	cmd.Flags().StringArrayVarP(&o.Notify, "notify", "", nil, "The Slack incoming webhook URLs to post the release notes to once the release is published. Not posted to while awaiting approval via --approval-pr")
+	cmd.Flags().StringVarP(&o.Channel, "channel", "", "", fmt.Sprintf("The release channel of the release: %s, %s or %s. The changelog starts from the previous tag of the channel or of a more stable channel, %s and %s releases are marked as pre-releases, %s releases only notify the --channel-notify webhook of the channel and the --output-markdown file keeps a section for the latest release of each channel", changelog.ChannelStable, changelog.ChannelBeta, changelog.ChannelNightly, changelog.ChannelBeta, changelog.ChannelNightly, changelog.ChannelNightly))
+	cmd.Flags().StringVarP(&o.ChannelTagPattern, "channel-tag-pattern", "", "", "The regular expression matching the tags of the --channel replacing the default pattern such as '^v?\\d+\\.\\d+\\.\\d+-(beta|rc)\\.\\d+$' for beta")
+	cmd.Flags().StringToStringVarP(&o.ChannelNotify, "channel-notify", "", nil, "The Slack incoming webhook URLs of the channels to post their releases to such as 'nightly=https://hooks.slack.com/services/...'")
 	cmd.Flags().BoolVarP(&o.NoReleaseInDev, "no-dev-release", "", false, "Disables the generation of Release CRDs in the development namespace to track releases being performed")
-	cmd.Flags().BoolVarP(&o.IncludeMergeCommits, "include-merge-commits", "", false, "Include merge commits when generating the changelog")
-	cmd.Flags().BoolVarP(&o.FailIfFindCommits, "fail-if-no-commits", "", false, "Do we want to fail the build if we don't find any commits to generate the changelog")
-
-	cmd.Flags().StringVarP(&o.Header, "header", "", "", "The changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
-	cmd.Flags().StringVarP(&o.HeaderFile, "header-file", "", "", "The file name of the changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
-	cmd.Flags().StringVarP(&o.Footer, "footer", "", "", "The changelog footer in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
-	cmd.Flags().StringVarP(&o.FooterFile, "footer-file", "", "", "The file name of the changelog footer in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
+	cmd.Flags().StringArrayVarP(&o.ReleaseAssets, "release-asset", "", nil, fmt.Sprintf("The formats of the changelog to upload as assets of the release on the Git repository. Values: %s", strings.Join(changelog.RendererNames(), ", ")))
+	cmd.Flags().BoolVarP(&o.ReleaseMetadata, "release-metadata", "", true, "Embeds the version, revisions and generator version as a hidden comment in the release on the Git repository so that later runs can detect hand edited release notes")
+	cmd.Flags().BoolVarP(&o.Reproducible, "reproducible", "", false, "Sorts the generated Release YAML deterministically and omits volatile fields such as timestamps so that regenerating it does not change the file")
+	cmd.Flags().DurationVarP(&o.Timeout, "timeout", "", 0, "The maximum time to spend generating the changelog such as '10m'. Requests to the git provider and Kubernetes are aborted when it expires. Defaults to no timeout")
+	cmd.Flags().StringVarP(&o.OnReleaseError, "on-release-error", "", changelog.ErrorPolicyWarn, fmt.Sprintf("What to do if the release on the git provider cannot be created or updated. Failing to query the release always fails. Values: %s or %s", changelog.ErrorPolicyFail, changelog.ErrorPolicyWarn))
+	cmd.Flags().StringVarP(&o.OnActivityError, "on-activity-error", "", changelog.ErrorPolicyWarn, fmt.Sprintf("What to do if the PipelineActivity cannot be updated with the details of the changelog. Values: %s or %s", changelog.ErrorPolicyFail, changelog.ErrorPolicyWarn))
+	cmd.Flags().StringVarP(&o.LogFormat, "log-format", "", LogFormatText, fmt.Sprintf("The format of the log output. Values: %s or %s", LogFormatText, LogFormatJSON))
+	cmd.Flags().BoolVarP(&o.Quiet, "quiet", "q", false, "Only logs warnings and errors")
+	cmd.Flags().BoolVarP(&o.Interactive, "interactive", "i", false, "Lets you pick the commits of the changelog and edit their titles and types before it is published. Ignored in batch mode")
+	cmd.Flags().BoolVarP(&o.Profile, "profile", "", false, "Reports how long each phase of generating the changelog took along with the number of git provider API calls")
+	cmd.Flags().StringToStringVarP(&o.PerfBudget, "perf-budget", "", nil, "The maximum durations of the phases of generating the changelog such as 'render=2s,total=1m' beyond which a warning is logged so that regressions in generation time are caught. Phases: git-log, issue-lookup, user-resolution, render, publish, kube-update or total")
+	cmd.Flags().BoolVarP(&o.ContributorAvatars, "contributor-avatars", "", false, "Includes the avatar images of the contributors in the Contributors section")
+	cmd.Flags().StringVarP(&o.Mentions, "mentions", "", changelog.MentionsNone, fmt.Sprintf("How users are rendered in the changelog. Values: %s to link to their profile, %s to @mention them or %s to only @mention members of the organisation of the repository and render others as plain names", changelog.MentionsNone, changelog.MentionsAll, changelog.MentionsOrgMembers))
+	cmd.Flags().IntVarP(&o.Highlights, "highlights", "", 0, "The maximum number of commits to list in a Highlights section at the top of the changelog with the rest folded below. Commits of issues and pull requests with the highlight labels come first followed by breaking changes and the largest pull requests. Zero disables the section")
+	cmd.Flags().BoolVarP(&o.CollapseReverts, "collapse-reverts", "", false, "Collapses the commits which are reverted and reapplied in the release into a single entry of the original commit marked as reworked. Commits whose last revert undoes them are left out")
+	cmd.Flags().StringSliceVarP(&o.HighlightLabels, "highlight-labels", "", []string{changelog.DefaultHighlightLabel}, "The labels of the issues and pull requests whose commits are highlighted")
+	cmd.Flags().BoolVarP(&o.LinkIssues, "link-issues", "", false, "Links the bare issue mentions such as '#123' and 'PROJ-456' in the titles and bodies of the changelog to the issue tracker")
+	cmd.Flags().StringVarP(&o.JiraServer, "jira-server", "", "", "The URL of the Jira server such as 'https://acme.atlassian.net' which looks up the issues referenced as 'PROJ-123' instead of the git provider. The API token is read from $"+issues.JiraAPITokenEnv)
+	cmd.Flags().StringVarP(&o.JiraProject, "jira-project", "", "", "The key of the Jira project such as 'PROJ'")
+	cmd.Flags().StringVarP(&o.JiraUsername, "jira-username", "", "", "The user name or email of the Jira API token. Defaults to $"+issues.JiraUsernameEnv+". If there is none the API token is used as a personal access token")
+	cmd.Flags().BoolVarP(&o.JiraVersion, "jira-version", "", false, "Creates or updates the version of the release in the Jira project with the release date and adds it to the fix versions of the referenced Jira issues")
+	cmd.Flags().BoolVarP(&o.JiraVersionReleased, "jira-version-released", "", false, "Marks the Jira version created via --jira-version as released")
+	cmd.Flags().StringVarP(&o.JiraVersionName, "jira-version-name", "", "", "The name of the Jira version created via --jira-version. Defaults to the version without any 'v' prefix")
+	cmd.Flags().StringToStringVarP(&o.IssueURLTemplates, "issue-url-template", "", nil, "The URL templates of the issue mentions linked via --link-issues indexed by '#' for git provider issues or the project key such as 'PROJ=https://jira.acme.com/browse/{id}' where {id} is replaced by the issue ID. Defaults to the URLs of the issue tracker")
+
+	cmd.Flags().StringVarP(&o.Header, "header", "", "", "The changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object along with the Contributors, Reviewers and commit Trailers and the functions releases, previousRelease, releasesSince, quarterStart, ordinal and add: https://golang.org/pkg/text/template/")
+	cmd.Flags().StringVarP(&o.HeaderFile, "header-file", "", "", "The file name of the changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object along with the Contributors, Reviewers and commit Trailers and the functions releases, previousRelease, releasesSince, quarterStart, ordinal and add: https://golang.org/pkg/text/template/")
+	cmd.Flags().StringVarP(&o.Footer, "footer", "", "", "The changelog footer in markdown for the changelog. Can use go template expressions on the ReleaseSpec object along with the Contributors, Reviewers and commit Trailers and the functions releases, previousRelease, releasesSince, quarterStart, ordinal and add: https://golang.org/pkg/text/template/")
+	cmd.Flags().StringVarP(&o.Template, "template", "", "", "A go template of the whole body of the changelog in markdown replacing the generated changes between the header and footer. It is executed on the ReleaseSpec object along with the Contributors, Reviewers, commit Trailers, the commit Groups of each Conventional Commits type and the CompareURL. Along with the functions of the header only this subset of the sprig functions can be used: upper, lower, title, trim, trimPrefix, trimSuffix, replace, contains, hasPrefix, hasSuffix, repeat, splitList, join, quote, indent, nindent, trunc, default, empty, sub, now, date and toJson")
+	cmd.Flags().StringVarP(&o.TemplateFile, "template-file", "", "", "The file name of the go template of the whole body of the changelog. See --template")
+	cmd.Flags().StringVarP(&o.FooterFile, "footer-file", "", "", "The file name of the changelog footer in markdown for the changelog. Can use go template expressions on the ReleaseSpec object along with the Contributors, Reviewers and commit Trailers and the functions releases, previousRelease, releasesSince, quarterStart, ordinal and add: https://golang.org/pkg/text/template/")
 
 	o.ScmFactory.AddFlags(cmd)
+	o.HTTP.AddFlags(cmd)
 	o.BaseOptions.AddBaseFlags(cmd)
 	return cmd, o
 }
 
+// AddCollectFlags adds the flags of the generator which configure the range of revisions and how the changelog is
+// collected from the git history, issue tracker and git provider
+func AddCollectFlags(cmd *cobra.Command, g *changelog.Generator) {
+	cmd.Flags().StringVarP(&g.PreviousRevision, "previous-rev", "p", "", "the previous tag revision")
+	cmd.Flags().StringVarP(&g.Milestone, "milestone", "", "", "Generates the changelog from the issues closed and the pull requests merged in the milestone such as 'v1.4.0' rather than the commits between git revisions")
+	cmd.Flags().StringVarP(&g.PullRequestsMergedSince, "prs-merged-since", "", "", "Generates the changelog from the pull requests merged on or after the date such as '2020-07-01' or RFC 3339 time via the git provider API rather than the git history")
+	cmd.Flags().StringVarP(&g.PullRequestsMergedUntil, "prs-merged-until", "", "", "Generates the changelog from the pull requests merged on or before the date such as '2020-07-31' or RFC 3339 time via the git provider API rather than the git history")
+	cmd.Flags().StringVarP(&g.BaseBranch, "base-branch", "", "", "The branch the pull requests of --prs-merged-since and --prs-merged-until were merged into. Defaults to the default branch of the repository")
+	cmd.Flags().StringVarP(&g.PreviousDate, "previous-date", "", "", "the previous date to find a revision in format 'MonthName dayNumber year'")
+	cmd.Flags().StringVarP(&g.CurrentRevision, "rev", "", "", "the current tag revision")
+	cmd.Flags().StringVarP(&g.Version, "version", "v", "", "The version to release")
+	cmd.Flags().StringVarP(&g.MailmapFile, "mailmap-file", "", "", "The git mailmap file used to map commit names and emails to contributors. Defaults to the '.mailmap' file in the repository")
+	cmd.Flags().StringVarP(&g.AliasFile, "alias-file", "", "", "An optional YAML file mapping the names and emails of contributors to git provider logins and classifying bots and service accounts which are excluded from the contributor lists")
+	cmd.Flags().BoolVarP(&g.IncludeMergeCommits, "include-merge-commits", "", false, "Include merge commits when generating the changelog")
+	cmd.Flags().StringVarP(&g.MergeCommitPolicy, "merge-commit-policy", "", "", fmt.Sprintf("Which merge commits are included in the changelog. Values: %s, %s or %s to only include merges of pull requests and exclude branch synchronisation merges. Defaults to %s unless --include-merge-commits is specified", changelog.MergeCommitsInclude, changelog.MergeCommitsExclude, changelog.MergeCommitsOnlyPRs, changelog.MergeCommitsExclude))
+	cmd.Flags().StringVarP(&g.GitBackend, "git-backend", "", "", fmt.Sprintf("How the git commits are read. Values: %s to walk the commits in process or %s to run 'git log' which copes better with very large repositories. Defaults to %s unless --path or --component is used which require %s", gits.GitBackendGoGit, gits.GitBackendCLI, gits.GitBackendGoGit, gits.GitBackendCLI))
+	cmd.Flags().StringArrayVarP(&g.Paths, "path", "", nil, "Only includes the commits which change the paths such as 'services/api' which is useful for the changelogs of the components of monorepos. The commits are read via 'git log -- <paths>' so that git skips the commits of other paths which requires the cli --git-backend")
+	cmd.Flags().StringVarP(&g.ChangesetDir, "changesets", "", "", "The directory of changeset fragments such as '.changeset'. Each markdown fragment describes a change and the 'major', 'minor' or 'patch' bump of each package in its front matter. The fragments are added to the changelog, the most severe bump is applied to the previous version if no --version is specified and the fragments are removed once the changelog is published")
+	cmd.Flags().BoolVarP(&g.ChangesetCommit, "changesets-commit", "", false, "Commits the removal of the changesets along with the --output-markdown file as the release commit and pushes it to the release branch")
+	cmd.Flags().BoolVarP(&g.FailIfFindCommits, "fail-if-no-commits", "", false, "Do we want to fail the build if we don't find any commits to generate the changelog")
+	cmd.Flags().BoolVarP(&g.FirstRelease, "first-release", "", false, "If there is no previous tag generate the changelog of the initial release from the full history of the repository")
+	cmd.Flags().IntVarP(&g.FirstReleaseMax, "first-release-max-commits", "", 0, "The maximum number of commits to include in the changelog of the initial release. Defaults to all commits")
+	cmd.Flags().StringVarP(&g.OnIssueLookupError, "on-issue-lookup-error", "", changelog.ErrorPolicyWarn, fmt.Sprintf("What to do if an issue referenced by a commit cannot be looked up in the issue tracker. Values: %s or %s", changelog.ErrorPolicyFail, changelog.ErrorPolicyWarn))
+	cmd.Flags().IntVarP(&g.MinCommits, "min-commits", "", 0, "The minimum number of commits required after filtering to generate the changelog. If there are fewer commits the command fails")
+	cmd.Flags().BoolVarP(&g.NewContributors, "new-contributors", "", false, "Adds a New Contributors section listing the authors whose first contribution is in this release")
+	cmd.Flags().BoolVarP(&g.Contributors, "contributors", "", false, "Adds a Contributors section listing the authors of the commits in this release along with their commit counts")
+	cmd.Flags().BoolVarP(&g.Reviewers, "reviewers", "", false, "Credits the approving reviewers of each pull request and adds a Reviewers section")
+	cmd.Flags().BoolVarP(&g.Deployments, "deployments", "", false, "Adds a Deployed to line listing the environments the release was successfully deployed to via the deployment statuses of the git provider")
+	cmd.Flags().BoolVarP(&g.DependencyReleaseNotes, "dependency-release-notes", "", false, "Includes the release notes of the upstream releases of each dependency update in a collapsible block in the Dependencies section")
+	cmd.Flags().StringArrayVarP(&g.DependencyAnalyzers, "dependency-analyzer", "", nil, fmt.Sprintf("Adds the dependency updates found by diffing the manifest files of the ecosystem between the revisions. Values: %s", strings.Join(deps.AnalyzerNames(), ", ")))
+	cmd.Flags().BoolVarP(&g.ClassifyDependencies, "classify-dependencies", "", false, "Classifies the dependency updates as major, minor or patch updates in the Dependencies section and the annotations of the Release")
+	cmd.Flags().BoolVarP(&g.DependencyAdvisories, "dependency-advisories", "", false, "Classifies the dependency updates which fix security advisories in the OSV database as security updates. Implies --classify-dependencies")
+	cmd.Flags().BoolVarP(&g.DependencyUpdatePaths, "dependency-update-paths", "", false, "Includes the commits and issues of the Release CRs in the charts of the upstream releases of each dependency update and records their dependency updates as the paths of the update")
+	cmd.Flags().BoolVarP(&g.CommitLinks, "commit-links", "", false, "Adds the short SHA of each commit linked to the commit on the git provider to the end of its entry in the changelog")
+	cmd.Flags().BoolVarP(&g.CompareLink, "compare-link", "", false, "Adds a Full Changelog line linking to the comparison of the revisions of the release on the git provider to the end of the changelog")
+	cmd.Flags().BoolVarP(&g.LabelBadges, "label-badges", "", false, "Renders the labels of the issues and pull requests as code spans after their entries in the changelog")
+	cmd.Flags().StringArrayVarP(&g.LabelBadgePatterns, "label-badge-pattern", "", nil, "The glob patterns such as 'kind/*' of the labels rendered by --label-badges. Defaults to all labels")
+	cmd.Flags().StringSliceVarP(&g.ExcludeLabels, "exclude-labels", "", []string{changelog.DefaultExcludeLabel}, "The labels of the pull requests and issues which are left out of the release notes along with their commits. They are still recorded in the Release")
+	cmd.Flags().StringToStringVarP(&g.LabelSections, "label-section", "", nil, "Maps the labels of pull requests and issues to the changelog sections of their commits taking precedence over the Conventional Commits types such as 'kind/bug=Bug Fixes,kind/feature=feat'. The labels of pull requests take precedence over those of issues")
+	cmd.Flags().BoolVarP(&g.CommitPullRequests, "commit-pull-requests", "", false, "Looks up the pull request which merged each commit via the git provider so that squashed and rebased commits are linked to their pull requests and labels. Supported on GitHub and GitLab. Makes an API call per commit")
+	cmd.Flags().IntVarP(&g.MaxAPICalls, "max-api-calls", "", 0, "The maximum number of git provider API calls made enriching the changelog. Once reached issues are no longer looked up and users are resolved from git only so that a large backfill does not use up the rate limit of a shared token. Publishing is not limited. Zero means no limit")
+	cmd.Flags().IntVarP(&g.MinRateLimitRemaining, "min-rate-limit-remaining", "", 0, "Stops enriching the changelog via the git provider API like --max-api-calls once the remaining rate limit reported by the git provider drops below this number. Zero disables the check")
+	cmd.Flags().IntVarP(&g.EnrichWorkers, "enrich-workers", "", 1, "The number of commits whose users, issues and pull requests are looked up on the git provider and issue tracker at once. The commits are still listed in the order of the git history")
+	cmd.Flags().StringVarP(&g.CheckpointFile, "checkpoint-file", "", "", "A file the issues and users resolved so far are saved to periodically and on failure so that a retry of a large backfill resumes where the previous run left off. The file is removed once the changelog has been fully enriched")
+	cmd.Flags().StringVarP(&g.SkipCommitPattern, "skip-commit-pattern", "", changelog.DefaultSkipCommitPattern, "The regular expression matching the messages of release commits which are removed from the changelog. Set to an empty string to include all commits")
+}
+
 func (o *Options) Validate() error {
 	err := o.BaseOptions.Validate()
 	if err != nil {
 		return errors.Wrapf(err, "failed to validate base options")
 	}
 
+	err = o.configureLogging()
+	if err != nil {
+		return err
+	}
+
 	err = o.ScmFactory.Validate()
 	if err != nil {
 		return errors.Wrapf(err, "failed to discover git repository")
 	}
+	o.HTTP.Tune(o.ScmFactory.ScmClient)
+	o.HTTPClient = o.HTTP.Client()
+
+	if o.GeneratorVersion == "" {
+		o.GeneratorVersion = version.GetVersion()
+	}
+	err = o.Generator.Validate()
+	if err != nil {
+		return err
+	}
+	err = changelog.ValidateErrorPolicy("on-activity-error", o.OnActivityError)
+	if err != nil {
+		return err
+	}
 
 	o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
 	if err != nil {
@@ -235,324 +316,74 @@ func (o *Options) Run() error {
 		return errors.Wrapf(err, "failed to validate")
 	}
 
-	// lets enable batch mode if we detect we are inside a pipeline
-	if !o.BatchMode && builds.GetBuildNumber() != "" {
-		log.Logger().Info("Using batch mode as inside a pipeline")
-		o.BatchMode = true
+	ctx := o.GetContext()
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
 	}
-
-	dir := o.ScmFactory.Dir
-
-	previousRev := o.PreviousRevision
-	if previousRev == "" {
-		previousDate := o.PreviousDate
-		if previousDate != "" {
-			previousRev, err = gits.GetRevisionBeforeDateText(o.Git(), dir, previousDate)
-			if err != nil {
-				return errors.Wrapf(err, "failed to find commits before date %s", previousDate)
-			}
-		}
+	ctx, shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Logger().Warnf("failed to setup tracing: %s", err.Error())
 	}
-	if previousRev == "" {
-		previousRev, _, err = gits.GetCommitPointedToByPreviousTag(o.Git(), dir)
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := shutdownTracing(flushCtx)
 		if err != nil {
-			return err
-		}
-		if previousRev == "" {
-			// lets assume we are the first release
-			previousRev, err = gits.GetFirstCommitSha(o.Git(), dir)
-			if err != nil {
-				return errors.Wrap(err, "failed to find first commit after we found no previous releaes")
+			log.Logger().Warnf("failed to flush the trace spans: %s", err.Error())
+		}
+	}()
+	ctx, span := tracing.Start(ctx, "changelog")
+	defer span.End()
+
+	o.State.Context = ctx
+	if o.Profile || len(o.State.PerfBudget) > 0 {
+		o.State.Profile = changelog.NewProfile()
+		o.State.Profile.CountAPICalls(o.ScmFactory.ScmClient)
+		defer func() {
+			if o.Profile {
+				o.State.Profile.Report()
 			}
-			if previousRev == "" {
-				log.Logger().Info("no previous commit version found so change diff unavailable")
-				return nil
-			}
-		}
-	}
-	currentRev := o.CurrentRevision
-	if currentRev == "" {
-		currentRev, _, err = gits.GetCommitPointedToByLatestTag(o.Git(), dir)
-		if err != nil {
-			return err
-		}
+			o.State.Profile.CheckBudget(o.State.PerfBudget)
+		}()
 	}
 
-	templatesDir := o.TemplatesDir
-	dir = o.ScmFactory.Dir
-	if templatesDir == "" {
-		chartFile, err := helmhelpers.FindChart(dir)
-		if err != nil {
-			return errors.Wrap(err, "could not find helm chart")
-		}
-		path, _ := filepath.Split(chartFile)
-		templatesDir = filepath.Join(path, "templates")
-	}
-	err = os.MkdirAll(templatesDir, files.DefaultDirWritePermissions)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create the templates directory %s", templatesDir)
-	}
-
-	log.Logger().Infof("Generating change log from git ref %s => %s", info(previousRev), info(currentRev))
-
-	gitDir, gitConfDir, err := gitclient.FindGitConfigDir(dir)
-	if err != nil {
-		return err
-	}
-	if gitDir == "" || gitConfDir == "" {
-		log.Logger().Warnf("No git directory could be found from dir %s", dir)
-		return nil
+	// lets enable batch mode if we detect we are inside a pipeline
+	if !o.BatchMode && builds.GetBuildNumber() != "" {
+		log.Logger().Info("Using batch mode as inside a pipeline")
+		o.BatchMode = true
 	}
-
-	gitInfo := o.ScmFactory.GitURL
-	if gitInfo == nil {
-		gitInfo, err = giturl.ParseGitURL(o.ScmFactory.SourceURL)
-		if err != nil {
-			return errors.Wrapf(err, "failed to parse git URL %s", o.ScmFactory.SourceURL)
+	if o.Interactive && o.Input == nil {
+		if o.BatchMode {
+			log.Logger().Warnf("ignoring --interactive in batch mode")
+		} else {
+			o.Input = inputfactory.NewInput(&o.BaseOptions)
 		}
 	}
 
-	tracker, err := o.CreateIssueProvider()
-	if err != nil {
+	result, err := o.Generate(ctx)
+	if err != nil || result == nil {
 		return err
 	}
-	o.State.Tracker = tracker
-
-	o.State.FoundIssueNames = map[string]bool{}
-
-	commits, err := chgit.FetchCommits(gitDir, previousRev, currentRev)
-	if err != nil {
-		if o.FailIfFindCommits {
-			return err
-		}
-		log.Logger().Warnf("failed to find git commits between revision %s and %s due to: %s", previousRev, currentRev, err.Error())
-	}
-	if commits != nil {
-		commitSlice := *commits
-		if len(commitSlice) > 0 {
-			if strings.HasPrefix(commitSlice[0].Message, "release ") {
-				// remove the release commit from the log
-				tmp := commitSlice[1:]
-				commits = &tmp
-			}
-		}
-		log.Logger().Debugf("Found commits:")
-		if commits != nil {
-			for _, commit := range *commits {
-				log.Logger().Debugf("  commit %s", commit.Hash)
-				log.Logger().Debugf("  Author: %s <%s>", commit.Author.Name, commit.Author.Email)
-				log.Logger().Debugf("  Date: %s", commit.Committer.When.Format(time.ANSIC))
-				log.Logger().Debugf("      %s\n\n\n", commit.Message)
-			}
-		}
-	}
+	spec := &result.Release.Spec
+	releaseNotesURL := spec.ReleaseNotesURL
+	cleanVersion := spec.Version
 	version := o.Version
 	if version == "" {
-		version = SpecVersion
-	}
-
-	release := &v1.Release{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "Release",
-			APIVersion: jenkinsio.GroupAndVersion,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name: ReleaseName,
-			CreationTimestamp: metav1.Time{
-				Time: time.Now(),
-			},
-			//ResourceVersion:   "1",
-			DeletionTimestamp: &metav1.Time{},
-		},
-		Spec: v1.ReleaseSpec{
-			Name:          SpecName,
-			Version:       version,
-			GitOwner:      gitInfo.Organisation,
-			GitRepository: gitInfo.Name,
-			GitHTTPURL:    gitInfo.HttpsURL(),
-			GitCloneURL:   gitInfo.CloneURL,
-			Commits:       []v1.CommitSummary{},
-			Issues:        []v1.IssueSummary{},
-			PullRequests:  []v1.IssueSummary{},
-		},
-	}
-
-	scmClient := o.ScmFactory.ScmClient
-	resolver := users.GitUserResolver{
-		GitProvider: scmClient,
-	}
-	if commits != nil {
-		for _, commit := range *commits {
-			c := commit
-			if o.IncludeMergeCommits || len(commit.ParentHashes) <= 1 {
-				o.addCommit(&release.Spec, &c, &resolver)
-			}
-		}
-	}
-
-	release.Spec.DependencyUpdates = CollapseDependencyUpdates(release.Spec.DependencyUpdates)
-
-	// lets try to update the release
-	markdown, err := gits.GenerateMarkdown(&release.Spec, gitInfo)
-	if err != nil {
-		return err
-	}
-	header, err := o.getTemplateResult(&release.Spec, "header", o.Header, o.HeaderFile)
-	if err != nil {
-		return err
-	}
-	footer, err := o.getTemplateResult(&release.Spec, "footer", o.Footer, o.FooterFile)
-	if err != nil {
-		return err
-	}
-	markdown = header + markdown + footer
-
-	log.Logger().Debugf("Generated release notes:\n\n%s\n", markdown)
-
-	if version != "" && o.UpdateRelease {
-		tags, err := gits.FilterTags(o.Git(), dir, version)
-		if err != nil {
-			return errors.Wrapf(err, "listing tags with pattern %s in %s", version, dir)
-		}
-		vVersion := fmt.Sprintf("v%s", version)
-		vtags, err := gits.FilterTags(o.Git(), dir, vVersion)
-		if err != nil {
-			return errors.Wrapf(err, "listing tags with pattern %s in %s", vVersion, dir)
-		}
-		foundTag := false
-		foundVTag := false
-
-		for _, t := range tags {
-			if t == version {
-				foundTag = true
-				break
-			}
-		}
-		for _, t := range vtags {
-			if t == vVersion {
-				foundVTag = true
-				break
-			}
-		}
-		tagName := version
-		if foundVTag && !foundTag {
-			tagName = vVersion
-		}
-		releaseInfo := &scm.ReleaseInput{
-			Title:       version,
-			Tag:         tagName,
-			Description: markdown,
-		}
-
-		ctx := context.Background()
-		fullName := scm.Join(o.ScmFactory.Owner, o.ScmFactory.Repository)
-
-		// lets try find a release for the tag
-		rel, _, err := scmClient.Releases.FindByTag(ctx, fullName, tagName)
-
-		if isReleaseNotFound(err, o.ScmFactory.GitKind) {
-			err = nil
-			rel = nil
-		}
-		if err != nil {
-			return errors.Wrapf(err, "failed to query release on repo %s for tag %s", fullName, tagName)
-		}
-
-		if rel == nil {
-			rel, _, err = scmClient.Releases.Create(ctx, fullName, releaseInfo)
-			if err != nil {
-				log.Logger().Warnf("Failed to create the release for %s: %s", fullName, err)
-				return nil
-			}
-		} else {
-			if rel.ID != 0 {
-				rel, _, err = scmClient.Releases.Update(ctx, fullName, rel.ID, releaseInfo)
-			} else {
-				rel, _, err = scmClient.Releases.UpdateByTag(ctx, fullName, rel.Tag, releaseInfo)
-			}
-			if err != nil {
-				id := -1
-				if rel != nil {
-					id = rel.ID
-				}
-				log.Logger().Warnf("Failed to update the release for %s number: %d: %s", fullName, id, err)
-				return nil
-			}
-		}
-
-		url := ""
-		if rel != nil {
-			url = rel.Link
-		}
-		if url == "" {
-			url = stringhelpers.UrlJoin(gitInfo.HttpsURL(), "releases/tag", tagName)
-		}
-		release.Spec.ReleaseNotesURL = url
-		log.Logger().Infof("updated the release information at %s", info(url))
-		log.Logger().Debugf("added description: %s", markdown)
-	} else if o.OutputMarkdownFile != "" {
-		err := ioutil.WriteFile(o.OutputMarkdownFile, []byte(markdown), files.DefaultFileWritePermissions)
-		if err != nil {
-			return err
-		}
-		log.Logger().Infof("\nGenerated Changelog: %s", info(o.OutputMarkdownFile))
-	} else {
-		log.Logger().Infof("\nGenerated Changelog:")
-		log.Logger().Infof("%s\n", markdown)
+		version = changelog.SpecVersion
 	}
-
-	o.State.Release = release
-	// now lets marshal the release YAML
-	data, err := yaml.Marshal(release)
-
-	if err != nil {
-		return errors.Wrap(err, "failed to unmarshal Release")
-	}
-	if data == nil {
-		return fmt.Errorf("could not marshal release to yaml")
-	}
-	releaseFile := filepath.Join(templatesDir, o.ReleaseYamlFile)
-	crdFile := filepath.Join(templatesDir, o.CrdYamlFile)
-	if o.GenerateReleaseYaml {
-		err = ioutil.WriteFile(releaseFile, data, files.DefaultFileWritePermissions)
-		if err != nil {
-			return errors.Wrapf(err, "failed to save Release YAML file %s", releaseFile)
-		}
-		log.Logger().Infof("generated: %s", info(releaseFile))
-	}
-	cleanVersion := strings.TrimPrefix(version, "v")
-	release.Spec.Version = cleanVersion
-	if o.GenerateCRD {
-		exists, err := files.FileExists(crdFile)
-		if err != nil {
-			return errors.Wrapf(err, "failed to check for CRD YAML file %s", crdFile)
-		}
-		if o.OverwriteCRD || !exists {
-			err = ioutil.WriteFile(crdFile, []byte(ReleaseCrdYaml), files.DefaultFileWritePermissions)
-			if err != nil {
-				return errors.Wrapf(err, "failed to save Release CRD YAML file %s", crdFile)
-			}
-			log.Logger().Infof("generated: %s", info(crdFile))
-
-			err = gitclient.Add(o.Git(), templatesDir)
-			if err != nil {
-				return errors.Wrapf(err, "failed to git add in dir %s", templatesDir)
-			}
-		}
-	}
-	appName := ""
-	if gitInfo != nil {
-		appName = gitInfo.Name
-	}
-	if appName == "" {
-		appName = release.Spec.Name
-	}
-	if appName == "" {
-		appName = release.Spec.GitRepository
-	}
-	releaseNotesURL := release.Spec.ReleaseNotesURL
+	span.SetAttributes(
+		attribute.String(changelog.LogKeyPreviousRevision, result.Range.PreviousRev),
+		attribute.String(changelog.LogKeyCurrentRevision, result.Range.CurrentRev),
+		attribute.String(changelog.LogKeyVersion, version),
+		attribute.Int(changelog.LogKeyCommits, len(spec.Commits)),
+		attribute.String(changelog.LogKeyReleaseURL, releaseNotesURL),
+	)
 
 	// lets modify the PipelineActivity
+	stopKubeUpdate := o.StartPhase(changelog.PhaseKubeUpdate)
+	defer stopKubeUpdate()
 	err = o.updatePipelineActivity(func(pa *v1.PipelineActivity) (bool, error) {
 		updated := false
 		ps := &pa.Spec
@@ -565,7 +396,7 @@ func (o *Options) Run() error {
 			return newValue
 		}
 
-		commits := release.Spec.Commits
+		commits := spec.Commits
 		if len(commits) > 0 {
 			lastCommit := commits[len(commits)-1]
 			ps.LastCommitSHA = doUpdate(ps.LastCommitSHA, lastCommit.SHA)
@@ -577,7 +408,7 @@ func (o *Options) Run() error {
 		return updated, nil
 	})
 	if err != nil {
-		return errors.Wrapf(err, "failed to update PipelineActivity")
+		return changelog.HandleError(o.OnActivityError, errors.Wrapf(err, "failed to update PipelineActivity"))
 	}
 	return nil
 }
@@ -589,9 +420,16 @@ func (o *Options) updatePipelineActivity(fn func(activity *v1.PipelineActivity)
 			o.BuildNumber = os.Getenv("BUILD_ID")
 		}
 	}
-	pipeline := fmt.Sprintf("%s/%s/%s", o.ScmFactory.Owner, o.ScmFactory.Repository, o.ScmFactory.Branch)
+	branch := o.ScmFactory.Branch
+	if branch == "" {
+		branch = o.State.Branch
+	}
+	if branch == "" {
+		branch = o.State.DefaultBranch
+	}
+	pipeline := fmt.Sprintf("%s/%s/%s", o.ScmFactory.Owner, o.ScmFactory.Repository, branch)
 
-	ctx := context.Background()
+	ctx := o.State.Context
 	build := o.BuildNumber
 	if pipeline != "" && build != "" {
 		ns := o.Namespace
@@ -636,274 +474,10 @@ func (o *Options) updatePipelineActivity(fn func(activity *v1.PipelineActivity)
 			}
 		}
 		if lastErr != nil {
-			log.Logger().Warnf("failed to update  PipelineActivity %s due to %s", name, lastErr.Error())
+			return errors.Wrapf(lastErr, "failed to update PipelineActivity %s", name)
 		}
 	} else {
 		log.Logger().Warnf("No $BUILD_NUMBER so cannot update PipelineActivities with the details from the changelog")
 	}
 	return nil
 }
-
-// CreateIssueProvider creates the issue provider
-func (o *Options) CreateIssueProvider() (issues.IssueProvider, error) {
-	return issues.CreateGitIssueProvider(o.ScmFactory.ScmClient, o.ScmFactory.Owner, o.ScmFactory.Repository)
-	/*
-		// TODO find kind from a configuration file inside the repository....
-		kind := ""
-		return issues.CreateIssueProvider(kind, serverURL, username, apiToken, project, o.BatchMode)
-	*/
-}
-
-func (o *Options) Git() gitclient.Interface {
-	if o.GitClient == nil {
-		o.GitClient = cli.NewCLIClient("", o.CommandRunner)
-	}
-	return o.GitClient
-}
-
-func (o *Options) addCommit(spec *v1.ReleaseSpec, commit *object.Commit, resolver *users.GitUserResolver) {
-	// TODO
-	url := ""
-	branch := "master"
-
-	var author, committer *v1.UserDetails
-	var err error
-	sha := commit.Hash.String()
-	if commit.Author.Email != "" && commit.Author.Name != "" {
-		author, err = resolver.GitSignatureAsUser(&commit.Author)
-		if err != nil {
-			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git author %s: %v", commit.Author, err)
-		}
-	}
-	if commit.Committer.Email != "" && commit.Committer.Name != "" {
-		committer, err = resolver.GitSignatureAsUser(&commit.Committer)
-		if err != nil {
-			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git committer %s: %v", commit.Committer, err)
-		}
-	}
-	commitSummary := v1.CommitSummary{
-		Message:   commit.Message,
-		URL:       url,
-		SHA:       sha,
-		Author:    author,
-		Branch:    branch,
-		Committer: committer,
-	}
-
-	err = o.addIssuesAndPullRequests(spec, &commitSummary, commit)
-	if err != nil {
-		log.Logger().Warnf("Failed to enrich commit %s with issues: %s", sha, err)
-	}
-	spec.Commits = append(spec.Commits, commitSummary)
-
-}
-
-func (o *Options) addIssuesAndPullRequests(spec *v1.ReleaseSpec, commit *v1.CommitSummary, rawCommit *object.Commit) error {
-	tracker := o.State.Tracker
-
-	regex := GitHubIssueRegex
-	issueKind := issues.GetIssueProvider(tracker)
-	if !o.State.LoggedIssueKind {
-		o.State.LoggedIssueKind = true
-		log.Logger().Infof("Finding issues in commit messages using %s format", issueKind)
-	}
-	if issueKind == issues.Jira {
-		regex = JIRAIssueRegex
-	}
-	message := fullCommitMessageText(rawCommit)
-
-	matches := regex.FindAllStringSubmatch(message, -1)
-
-	resolver := users.GitUserResolver{
-		GitProvider: o.ScmFactory.ScmClient,
-	}
-	for _, match := range matches {
-		for _, result := range match {
-			result = strings.TrimPrefix(result, "#")
-			if _, ok := o.State.FoundIssueNames[result]; !ok {
-				o.State.FoundIssueNames[result] = true
-				issue, err := tracker.GetIssue(result)
-				if err != nil {
-					log.Logger().Warnf("Failed to lookup issue %s in issue tracker %s due to %s", result, tracker.HomeURL(), err)
-					continue
-				}
-				if issue == nil {
-					log.Logger().Warnf("Failed to find issue %s for repository %s", result, tracker.HomeURL())
-					continue
-				}
-
-				user, err := resolver.Resolve(&issue.Author)
-				if err != nil {
-					log.Logger().Warnf("Failed to resolve user %v for issue %s repository %s", issue.Author, result, tracker.HomeURL())
-				}
-
-				var closedBy *v1.UserDetails
-				if issue.ClosedBy == nil {
-					log.Logger().Warnf("Failed to find closedBy user for issue %s repository %s", result, tracker.HomeURL())
-				} else {
-					u, err := resolver.Resolve(issue.ClosedBy)
-					if err != nil {
-						log.Logger().Warnf("Failed to resolve closedBy user %v for issue %s repository %s", issue.Author, result, tracker.HomeURL())
-					} else if u != nil {
-						closedBy = u
-					}
-				}
-
-				var assignees []v1.UserDetails
-				if issue.Assignees == nil {
-					log.Logger().Warnf("Failed to find assignees for issue %s repository %s", result, tracker.HomeURL())
-				} else {
-					u, err := resolver.GitUserSliceAsUserDetailsSlice(issue.Assignees)
-					if err != nil {
-						log.Logger().Warnf("Failed to resolve Assignees %v for issue %s repository %s", issue.Assignees, result, tracker.HomeURL())
-					}
-					assignees = u
-				}
-
-				labels := toV1Labels(issue.Labels)
-				commit.IssueIDs = append(commit.IssueIDs, result)
-				issueSummary := v1.IssueSummary{
-					ID:                result,
-					URL:               issue.Link,
-					Title:             issue.Title,
-					Body:              issue.Body,
-					User:              user,
-					CreationTimestamp: kube.ToMetaTime(&issue.Created),
-					ClosedBy:          closedBy,
-					Assignees:         assignees,
-					Labels:            labels,
-				}
-				state := issue.State
-				if state != "" {
-					issueSummary.State = state
-				}
-				if issue.PullRequest {
-					spec.PullRequests = append(spec.PullRequests, issueSummary)
-				} else {
-					spec.Issues = append(spec.Issues, issueSummary)
-				}
-			}
-		}
-	}
-	return nil
-}
-
-// toV1Labels converts git labels to IssueLabel
-func toV1Labels(labels []string) []v1.IssueLabel {
-	var answer []v1.IssueLabel
-	for _, label := range labels {
-		answer = append(answer, v1.IssueLabel{
-			Name: label,
-		})
-	}
-	return answer
-}
-
-// fullCommitMessageText returns the commit message
-func fullCommitMessageText(commit *object.Commit) string {
-	answer := commit.Message
-	fn := func(parent *object.Commit) error {
-		text := parent.Message
-		if text != "" {
-			sep := "\n"
-			if strings.HasSuffix(answer, "\n") {
-				sep = ""
-			}
-			answer += sep + text
-		}
-		return nil
-	}
-	err := fn(commit) //nolint:errcheck
-	if err != nil {
-		log.Logger().Warnf("failed to create commit message %s", err.Error())
-	}
-	return answer
-
-}
-
-func (o *Options) getTemplateResult(releaseSpec *v1.ReleaseSpec, templateName string, templateText string, templateFile string) (string, error) {
-	if templateText == "" {
-		if templateFile == "" {
-			return "", nil
-		}
-		data, err := ioutil.ReadFile(templateFile)
-		if err != nil {
-			return "", err
-		}
-		templateText = string(data)
-	}
-	if templateText == "" {
-		return "", nil
-	}
-	tmpl, err := template.New(templateName).Parse(templateText)
-	if err != nil {
-		return "", err
-	}
-	var buffer bytes.Buffer
-	writer := bufio.NewWriter(&buffer)
-	err = tmpl.Execute(writer, releaseSpec)
-	writer.Flush()
-	return buffer.String(), err
-}
-
-//CollapseDependencyUpdates takes a raw set of dependencyUpdates, removes duplicates and collapses multiple updates to
-// the same org/repo:components into a sungle update
-func CollapseDependencyUpdates(dependencyUpdates []v1.DependencyUpdate) []v1.DependencyUpdate {
-	// Sort the dependency updates. This makes the outputs more readable, and it also allows us to more easily do duplicate removal and collapsing
-
-	sort.Slice(dependencyUpdates, func(i, j int) bool {
-		if dependencyUpdates[i].Owner == dependencyUpdates[j].Owner {
-			if dependencyUpdates[i].Repo == dependencyUpdates[j].Repo {
-				if dependencyUpdates[i].Component == dependencyUpdates[j].Component {
-					if dependencyUpdates[i].FromVersion == dependencyUpdates[j].FromVersion {
-						return dependencyUpdates[i].ToVersion < dependencyUpdates[j].ToVersion
-					}
-					return dependencyUpdates[i].FromVersion < dependencyUpdates[j].FromVersion
-				}
-				return dependencyUpdates[i].Component < dependencyUpdates[j].Component
-			}
-			return dependencyUpdates[i].Repo < dependencyUpdates[j].Repo
-		}
-		return dependencyUpdates[i].Owner < dependencyUpdates[j].Owner
-	})
-
-	// Collapse  entries
-	collapsed := make([]v1.DependencyUpdate, 0)
-
-	if len(dependencyUpdates) > 0 {
-		start := 0
-		for i := 1; i <= len(dependencyUpdates); i++ {
-			if i == len(dependencyUpdates) || dependencyUpdates[i-1].Owner != dependencyUpdates[i].Owner || dependencyUpdates[i-1].Repo != dependencyUpdates[i].Repo || dependencyUpdates[i-1].Component != dependencyUpdates[i].Component {
-				end := i - 1
-				collapsed = append(collapsed, v1.DependencyUpdate{
-					DependencyUpdateDetails: v1.DependencyUpdateDetails{
-						Owner:              dependencyUpdates[start].Owner,
-						Repo:               dependencyUpdates[start].Repo,
-						Component:          dependencyUpdates[start].Component,
-						URL:                dependencyUpdates[start].URL,
-						Host:               dependencyUpdates[start].Host,
-						FromVersion:        dependencyUpdates[start].FromVersion,
-						FromReleaseHTMLURL: dependencyUpdates[start].FromReleaseHTMLURL,
-						FromReleaseName:    dependencyUpdates[start].FromReleaseName,
-						ToVersion:          dependencyUpdates[end].ToVersion,
-						ToReleaseName:      dependencyUpdates[end].ToReleaseName,
-						ToReleaseHTMLURL:   dependencyUpdates[end].ToReleaseHTMLURL,
-					},
-				})
-				start = i
-			}
-		}
-	}
-	return collapsed
-}
-
-func isReleaseNotFound(err error, gitKind string) bool {
-	if gitKind == "gitlab" {
-		if err == nil {
-			return false
-		}
-		return strings.Contains(err.Error(), scm.ErrForbidden.Error())
-	} else {
-		return scmhelpers.IsScmNotFound(err)
-	}
-}
@@ -76,3 +76,22 @@ func TestCreateChangelog(t *testing.T) {
 	t.Logf("tag: %s\n", release.Tag)
 
 }
+
+func TestJiraVersionFlags(t *testing.T) {
+	cmd, o := create.NewCmdChangelogCreate()
+	err := cmd.Flags().Parse([]string{"--jira-version", "--jira-version-released", "--jira-version-name", "Release 2.0.1"})
+	require.NoError(t, err)
+	assert.True(t, o.JiraVersion)
+	assert.True(t, o.JiraVersionReleased)
+	assert.Equal(t, "Release 2.0.1", o.JiraVersionName)
+}
+
+func TestNoCommitsFlags(t *testing.T) {
+	cmd, o := create.NewCmdChangelogCreate()
+	assert.False(t, o.FailIfFindCommits)
+	assert.Equal(t, 0, o.MinCommits)
+	err := cmd.Flags().Parse([]string{"--fail-if-no-commits", "--min-commits", "3"})
+	require.NoError(t, err)
+	assert.True(t, o.FailIfFindCommits)
+	assert.Equal(t, 3, o.MinCommits)
+}
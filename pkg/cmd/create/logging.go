@@ -0,0 +1,40 @@
+package create
+
+import (
+	"github.com/fatih/color"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LogFormatText logs human readable colored text
+	LogFormatText = "text"
+
+	// LogFormatJSON logs JSON objects for log aggregation
+	LogFormatJSON = "json"
+)
+
+// configureLogging configures the log format and level from the command line flags
+func (o *Options) configureLogging() error {
+	// lets make sure the logger is initialised first so that it does not replace our formatter
+	log.Logger()
+
+	switch o.LogFormat {
+	case "", LogFormatText:
+	case LogFormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		color.NoColor = true
+	default:
+		return options.InvalidOptionf("log-format", o.LogFormat, "should be one of %s or %s", LogFormatText, LogFormatJSON)
+	}
+
+	if o.Quiet {
+		err := log.SetLevel("warn")
+		if err != nil {
+			return errors.Wrapf(err, "failed to set the log level for quiet mode")
+		}
+	}
+	return nil
+}
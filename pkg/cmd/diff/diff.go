@@ -0,0 +1,236 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SourceProvider compares the release notes of the releases on the git provider
+	SourceProvider = "provider"
+
+	// SourceRelease compares the Release resources of a namespace
+	SourceRelease = "release"
+
+	// OutputMarkdown prints the differences as markdown
+	OutputMarkdown = "markdown"
+
+	// OutputJSON prints the differences as JSON
+	OutputJSON = "json"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	ScmFactory scmhelpers.Options
+	JXClient   jxc.Interface
+	Namespace  string
+	Source     string
+	Output     string
+	FailOnDiff bool
+	Args       []string
+	Out        io.Writer
+
+	// Diff the differences found by the last run
+	Diff *changelog.ReleaseDiff
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Compares the commits, issues and pull requests of two releases
+
+		The releases are the release notes published on the git provider for the tags or the Release resources of the versions in a namespace. Either release can also be the path of a Release YAML file such as the one generated by 'jx-changelog create' so that regenerated release notes can be verified against the published ones
+`)
+
+	cmdExample = templates.Examples(`
+		# compares the releases on the git provider of two tags
+		jx-changelog diff v1.2.0 v1.3.0
+
+		# compares the Release resources of two versions
+		jx-changelog diff 1.2.0 1.3.0 --source release --repo myorg/myapp -n jx
+
+		# verifies the regenerated Release YAML matches the published release notes
+		jx-changelog diff v1.3.0 charts/myapp/templates/release.yaml --fail-on-diff
+`)
+)
+
+// NewCmdDiff creates the command and options
+func NewCmdDiff() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "diff <from> <to>",
+		Short:   "Compares the commits, issues and pull requests of two releases",
+		Long:    cmdLong,
+		Example: cmdExample,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = args
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	o.ScmFactory.DiscoverFromGit = true
+
+	cmd.Flags().StringVarP(&o.Source, "source", "", SourceProvider, fmt.Sprintf("Where the releases are found. Values: %s for the releases of the tags on the git provider or %s for the Release resources of the versions", SourceProvider, SourceRelease))
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "The namespace of the Release resources. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputMarkdown, fmt.Sprintf("The format of the report. Values: %s or %s", OutputMarkdown, OutputJSON))
+	cmd.Flags().BoolVarP(&o.FailOnDiff, "fail-on-diff", "", false, "Fails if the releases differ")
+
+	o.ScmFactory.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the clients
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if len(o.Args) != 2 {
+		return errors.Errorf("expected the two releases to compare but got %d arguments", len(o.Args))
+	}
+	switch o.Output {
+	case OutputMarkdown, OutputJSON:
+	default:
+		return options.InvalidOptionf("output", o.Output, "should be %s or %s", OutputMarkdown, OutputJSON)
+	}
+	switch o.Source {
+	case SourceProvider:
+		err = o.ScmFactory.Validate()
+		if err != nil {
+			return errors.Wrapf(err, "failed to discover git repository")
+		}
+	case SourceRelease:
+		o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create jx client")
+		}
+	default:
+		return options.InvalidOptionf("source", o.Source, "should be %s or %s", SourceProvider, SourceRelease)
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	return nil
+}
+
+// Run compares the releases
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	from, err := o.releaseItems(o.Args[0])
+	if err != nil {
+		return err
+	}
+	to, err := o.releaseItems(o.Args[1])
+	if err != nil {
+		return err
+	}
+	o.Diff = changelog.DiffReleases(o.Args[0], from, o.Args[1], to)
+
+	if o.Output == OutputJSON {
+		data, err := json.MarshalIndent(o.Diff, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the differences")
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err = fmt.Fprint(o.Out, changelog.ReleaseDiffMarkdown(o.Diff))
+		if err != nil {
+			return err
+		}
+	}
+	if o.FailOnDiff && !o.Diff.Empty() {
+		return errors.Errorf("%s and %s differ by %d added and %d removed items", o.Args[0], o.Args[1], len(o.Diff.Added), len(o.Diff.Removed))
+	}
+	return nil
+}
+
+// releaseItems returns the items of the Release YAML file or the release of the source
+func (o *Options) releaseItems(name string) ([]changelog.ReleaseItem, error) {
+	exists, err := files.FileExists(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", name)
+	}
+	if exists {
+		release := &v1.Release{}
+		err = yamls.LoadFile(name, release)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the Release file %s", name)
+		}
+		return changelog.ReleaseSpecItems(&release.Spec), nil
+	}
+	if o.Source == SourceRelease {
+		release, err := o.findRelease(name)
+		if err != nil {
+			return nil, err
+		}
+		return changelog.ReleaseSpecItems(&release.Spec), nil
+	}
+
+	fullName := o.ScmFactory.FullRepositoryName
+	rel, _, err := o.ScmFactory.ScmClient.Releases.FindByTag(o.GetContext(), fullName, name)
+	if err != nil {
+		if scmhelpers.IsScmNotFound(err) {
+			return nil, errors.Errorf("there is no release of tag %s in %s", name, fullName)
+		}
+		return nil, errors.Wrapf(err, "failed to find the release of tag %s in %s", name, fullName)
+	}
+	repoURL := ""
+	if o.ScmFactory.GitURL != nil {
+		repoURL = o.ScmFactory.GitURL.HttpsURL()
+	}
+	return changelog.MarkdownReleaseItems(rel.Description, repoURL), nil
+}
+
+// findRelease returns the Release resource of the version in the namespace filtered by the repository if specified
+func (o *Options) findRelease(version string) (*v1.Release, error) {
+	list, err := o.JXClient.JenkinsV1().Releases(o.Namespace).List(o.GetContext(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Releases in namespace %s", o.Namespace)
+	}
+	var answer []*v1.Release
+	for i := range list.Items {
+		r := &list.Items[i]
+		if strings.TrimPrefix(r.Spec.Version, "v") != strings.TrimPrefix(version, "v") {
+			continue
+		}
+		if o.ScmFactory.FullRepositoryName != "" && scm.Join(r.Spec.GitOwner, r.Spec.GitRepository) != o.ScmFactory.FullRepositoryName {
+			continue
+		}
+		answer = append(answer, r)
+	}
+	switch len(answer) {
+	case 0:
+		return nil, errors.Errorf("there is no Release of version %s in namespace %s", version, o.Namespace)
+	case 1:
+		return answer[0], nil
+	default:
+		return nil, errors.Errorf("there are %d Releases of version %s in namespace %s. Specify the repository via --repo", len(answer), version, o.Namespace)
+	}
+}
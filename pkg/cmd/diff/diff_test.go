@@ -0,0 +1,110 @@
+package diff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/diff"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffProviderReleases(t *testing.T) {
+	repoURL := "https://github.com/myorg/myrepo"
+	scmClient, data := scmfake.NewDefault()
+	data.Releases = map[string]map[int]*scm.Release{}
+	data.Releases["myorg/myrepo"] = map[int]*scm.Release{
+		1: {ID: 1, Tag: "v1.2.0", Description: "### Bug Fixes\n\n* a bug ([Jane](https://github.com/jane)) [#12](" + repoURL + "/issues/12) [1111111](" + repoURL + "/commit/1111111aaaa)\n"},
+		2: {ID: 2, Tag: "v1.3.0", Description: "### New Features\n\n* something new [#13](" + repoURL + "/pull/13) [2222222](" + repoURL + "/commit/2222222bbbb)\n\n* upstream [3333333](https://github.com/other/repo/commit/3333333cccc)\n"},
+	}
+
+	newOptions := func(args ...string) (*diff.Options, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		_, o := diff.NewCmdDiff()
+		o.Ctx = context.Background()
+		o.Args = args
+		o.Out = out
+		o.ScmFactory.Dir = t.TempDir()
+		o.ScmFactory.SourceURL = repoURL
+		o.ScmFactory.GitKind = "fake"
+		o.ScmFactory.ScmClient = scmClient
+		return o, out
+	}
+
+	o, out := newOptions("v1.2.0", "v1.3.0")
+	o.Output = diff.OutputJSON
+	require.NoError(t, o.Run())
+	d := &changelog.ReleaseDiff{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), d))
+	require.Len(t, d.Added, 2)
+	assert.Equal(t, changelog.ReleaseItem{Kind: changelog.ReleaseItemPullRequest, ID: "13", Title: "something new #13 2222222"}, d.Added[0])
+	assert.Equal(t, "2222222bbbb", d.Added[1].ID)
+	require.Len(t, d.Removed, 2)
+
+	// lets verify a regenerated Release YAML against the published release
+	release := &v1.Release{
+		Spec: v1.ReleaseSpec{
+			Commits: []v1.CommitSummary{{SHA: "1111111aaaa", Message: "fix: a bug"}},
+			Issues:  []v1.IssueSummary{{ID: "12", Title: "a bug"}},
+		},
+	}
+	releaseFile := filepath.Join(t.TempDir(), "release.yaml")
+	fileData, err := yaml.Marshal(release)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(releaseFile, fileData, 0600))
+
+	o, out = newOptions("v1.2.0", releaseFile)
+	o.FailOnDiff = true
+	require.NoError(t, o.Run())
+	assert.Contains(t, out.String(), "The releases contain the same commits, issues and pull requests")
+
+	o, _ = newOptions("v1.3.0", releaseFile)
+	o.FailOnDiff = true
+	assert.Error(t, o.Run())
+
+	o, _ = newOptions("v1.2.0", "v9.9.9")
+	assert.Error(t, o.Run())
+}
+
+func TestDiffReleaseResources(t *testing.T) {
+	release := func(repo, version string, commits ...string) *v1.Release {
+		r := &v1.Release{
+			ObjectMeta: metav1.ObjectMeta{Name: repo + "-" + version, Namespace: "jx"},
+			Spec:       v1.ReleaseSpec{Version: version, GitOwner: "myorg", GitRepository: repo},
+		}
+		for _, sha := range commits {
+			r.Spec.Commits = append(r.Spec.Commits, v1.CommitSummary{SHA: sha, Message: "fix: " + sha})
+		}
+		return r
+	}
+
+	out := &bytes.Buffer{}
+	_, o := diff.NewCmdDiff()
+	o.Ctx = context.Background()
+	o.Source = diff.SourceRelease
+	o.JXClient = fakejx.NewSimpleClientset(
+		release("app1", "1.2.0", "1111111aaaa"),
+		release("app1", "1.3.0", "1111111aaaa", "2222222bbbb"),
+		release("app2", "1.3.0", "3333333cccc"),
+	)
+	o.Namespace = "jx"
+	o.Out = out
+	o.Args = []string{"v1.2.0", "v1.3.0"}
+	assert.Error(t, o.Run(), "should fail as there are two releases of 1.3.0")
+
+	o.ScmFactory.FullRepositoryName = "myorg/app1"
+	require.NoError(t, o.Run())
+	assert.Contains(t, out.String(), "### Added\n\n* commit 2222222 fix: 2222222bbbb\n")
+	assert.NotContains(t, out.String(), "Removed")
+}
@@ -0,0 +1,283 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SourceReleases digests the Release resources of a namespace
+	SourceReleases = "releases"
+
+	// SourceCommits digests the commits of the git repositories
+	SourceCommits = "commits"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	JXClient     jxc.Interface
+	GitClient    gitclient.Interface
+	Namespace    string
+	Selector     string
+	Period       string
+	Source       string
+	Repositories []string
+	Title        string
+	Format       string
+	OutputFile   string
+	Notify       []string
+	GitUsername  string
+	GitToken     string
+	Workers      int
+	Now          func() time.Time
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Creates a digest of the releases or commits of a period across repositories
+
+		The digest of releases aggregates the changelogs of the Release resources of a namespace created in the period grouped by repository. The digest of commits clones each repository and groups the commits of the period by their Conventional Commits type. The digest can be rendered as markdown, HTML or a Slack message and posted to Slack incoming webhooks so that it can be run on a schedule as a newsletter
+
+		Several repositories can be cloned and digested concurrently via --workers. The repositories are listed in the order of the --repo flags whatever the number of workers
+`)
+
+	cmdExample = templates.Examples(`
+		# the weekly digest of the releases in production
+		jx-changelog digest --period weekly -n jx-production
+
+		# the monthly digest of the commits of some repositories posted to Slack
+		jx-changelog digest --period monthly --source commits --repo https://github.com/myorg/app1 --repo https://github.com/myorg/app2 --notify $SLACK_WEBHOOK_URL
+`)
+)
+
+// NewCmdDigest creates the command and options
+func NewCmdDigest() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "digest",
+		Short:   "Creates a digest of the releases or commits of a period across repositories",
+		Aliases: []string{"newsletter"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Period, "period", "", changelog.DigestPeriodWeekly, fmt.Sprintf("The period of the digest ending now. Values: %s, %s or %s", changelog.DigestPeriodDaily, changelog.DigestPeriodWeekly, changelog.DigestPeriodMonthly))
+	cmd.Flags().StringVarP(&o.Source, "source", "", SourceReleases, fmt.Sprintf("What the digest aggregates. Values: %s for the Release resources of the namespace or %s for the commits of the repositories", SourceReleases, SourceCommits))
+	cmd.Flags().StringArrayVarP(&o.Repositories, "repo", "", nil, "The git URLs or 'owner/name' patterns of the repositories. Required for commits. Defaults to all the repositories of the releases")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "The namespace of the Release resources. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "The label selector of the Release resources")
+	cmd.Flags().StringVarP(&o.Title, "title", "", "", "The title of the digest")
+	cmd.Flags().StringVarP(&o.Format, "format", "", changelog.RendererMarkdown, fmt.Sprintf("The format of the digest. Values: %s, %s or %s", changelog.RendererMarkdown, changelog.RendererHTML, changelog.RendererSlack))
+	cmd.Flags().StringVarP(&o.OutputFile, "output", "", "", "The file to generate for the digest. If not specified and there are no webhooks to notify the digest is logged")
+	cmd.Flags().StringArrayVarP(&o.Notify, "notify", "", nil, "The Slack incoming webhook URLs to post the digest to")
+	cmd.Flags().StringVarP(&o.GitUsername, "git-username", "", "oauth2", "The git user name used with the git token to clone the repositories")
+	cmd.Flags().StringVarP(&o.GitToken, "git-token", "", "", "The git token used to clone the repositories. Defaults to the '$GIT_TOKEN' environment variable")
+	cmd.Flags().IntVarP(&o.Workers, "workers", "", 1, "The number of repositories cloned and digested concurrently for the commits digest")
+
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the clients
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	switch o.Format {
+	case changelog.RendererMarkdown, changelog.RendererHTML, changelog.RendererSlack:
+	default:
+		return options.InvalidOptionf("format", o.Format, "should be %s, %s or %s", changelog.RendererMarkdown, changelog.RendererHTML, changelog.RendererSlack)
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	_, err = changelog.DigestPeriodStart(o.Period, o.Now())
+	if err != nil {
+		return options.InvalidOptionf("period", o.Period, "%s", err.Error())
+	}
+	switch o.Source {
+	case SourceReleases:
+		o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create jx client")
+		}
+	case SourceCommits:
+		if len(o.Repositories) == 0 {
+			return options.MissingOption("repo")
+		}
+		if o.GitToken == "" {
+			o.GitToken = os.Getenv("GIT_TOKEN")
+		}
+		if o.GitClient == nil {
+			o.GitClient = cli.NewCLIClient("", nil)
+		}
+	default:
+		return options.InvalidOptionf("source", o.Source, "should be %s or %s", SourceReleases, SourceCommits)
+	}
+	return nil
+}
+
+// Run generates the digest
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	until := o.Now()
+	since, err := changelog.DigestPeriodStart(o.Period, until)
+	if err != nil {
+		return err
+	}
+	title := o.Title
+	if title == "" {
+		title = strings.Title(o.Period) + " Digest"
+	}
+	var markdown string
+	if o.Source == SourceCommits {
+		markdown, err = o.commitsDigest(title, since, until)
+	} else {
+		markdown, err = o.releasesDigest(title, since, until)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, url := range o.Notify {
+		err = changelog.PostWebhook(o.GetContext(), nil, url, &changelog.SlackMessage{Text: changelog.ToSlackMarkdown(markdown), Mrkdwn: true})
+		if err != nil {
+			return errors.Wrap(err, "failed to post the digest")
+		}
+		log.Logger().Infof("posted the %s to %s", title, termcolor.ColorInfo(redactURL(url)))
+	}
+
+	output := markdown
+	switch o.Format {
+	case changelog.RendererHTML:
+		output = changelog.MarkdownToHTML(markdown, title, "", true)
+	case changelog.RendererSlack:
+		data, err := json.MarshalIndent(&changelog.SlackMessage{Text: changelog.ToSlackMarkdown(markdown), Mrkdwn: true}, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the Slack message")
+		}
+		output = string(data) + "\n"
+	}
+	if o.OutputFile == "" {
+		if len(o.Notify) == 0 {
+			log.Logger().Infof("%s", output)
+		}
+		return nil
+	}
+	err = ioutil.WriteFile(o.OutputFile, []byte(output), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the digest %s", o.OutputFile)
+	}
+	log.Logger().Infof("generated the %s: %s", title, termcolor.ColorInfo(o.OutputFile))
+	return nil
+}
+
+func (o *Options) releasesDigest(title string, since, until time.Time) (string, error) {
+	list, err := o.JXClient.JenkinsV1().Releases(o.Namespace).List(o.GetContext(), metav1.ListOptions{LabelSelector: o.Selector})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the Releases in namespace %s", o.Namespace)
+	}
+	releases, err := changelog.FilterReleases(list.Items, &changelog.ReleaseFilter{Since: since, Until: until})
+	if err != nil {
+		return "", err
+	}
+	if len(o.Repositories) > 0 {
+		var matched []v1.Release
+		for i := range releases {
+			r := &releases[i]
+			if o.matchesRepository(scm.Join(r.Spec.GitOwner, r.Spec.GitRepository)) {
+				matched = append(matched, releases[i])
+			}
+		}
+		releases = matched
+	}
+	return changelog.ReleasesDigestMarkdown(title, since, until, releases)
+}
+
+// matchesRepository returns true if the 'owner/name' matches one of the repositories
+func (o *Options) matchesRepository(fullName string) bool {
+	for _, r := range o.Repositories {
+		pattern := r
+		gitInfo, err := giturl.ParseGitURL(r)
+		if err == nil && strings.Contains(r, "://") {
+			pattern = scm.Join(gitInfo.Organisation, gitInfo.Name)
+		}
+		matched, err := path.Match(pattern, fullName)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) commitsDigest(title string, since, until time.Time) (string, error) {
+	fetcher := &gits.CLICommitFetcher{}
+	repositories := make([]changelog.DigestRepository, len(o.Repositories))
+	err := changelog.RunConcurrently(o.Workers, len(o.Repositories), func(i int) error {
+		r := o.Repositories[i]
+		gitInfo, err := giturl.ParseGitURL(r)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the git URL %s", r)
+		}
+		repo := &repositories[i]
+		repo.Name = scm.Join(gitInfo.Organisation, gitInfo.Name)
+		repo.URL = gitInfo.URLWithoutUser()
+		dir, err := gits.CloneWithCredentials(o.GitClient, r, o.GitUsername, o.GitToken, "")
+		if err != nil {
+			return errors.Wrapf(err, "failed to clone %s", r)
+		}
+		defer os.RemoveAll(dir) //nolint:errcheck
+		repo.Commits, err = changelog.CollectDigestCommits(fetcher, dir, since, until)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return changelog.CommitsDigestMarkdown(title, since, until, repositories), nil
+}
+
+// redactURL removes the path of the webhook URL which usually contains its secret
+func redactURL(url string) string {
+	idx := strings.Index(url, "://")
+	if idx < 0 {
+		return url
+	}
+	host := url[idx+3:]
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	return url[:idx+3] + host
+}
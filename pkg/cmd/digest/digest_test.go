@@ -0,0 +1,74 @@
+package digest_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/digest"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDigestReleases(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	release := func(owner, repo, version string, created time.Time, commit string) *v1.Release {
+		return &v1.Release{
+			ObjectMeta: metav1.ObjectMeta{Name: repo + "-" + version, Namespace: "jx", CreationTimestamp: metav1.NewTime(created)},
+			Spec: v1.ReleaseSpec{
+				Name:          repo,
+				Version:       version,
+				GitOwner:      owner,
+				GitRepository: repo,
+				GitHTTPURL:    "https://github.com/" + owner + "/" + repo,
+				Commits:       []v1.CommitSummary{{Message: commit, SHA: "123"}},
+			},
+		}
+	}
+
+	var posted []changelog.SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := changelog.SlackMessage{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		posted = append(posted, msg)
+	}))
+	defer server.Close()
+
+	_, o := digest.NewCmdDigest()
+	o.JXClient = fakejx.NewSimpleClientset(
+		release("myorg", "app1", "1.0.0", now.Add(-10*24*time.Hour), "feat: too old"),
+		release("myorg", "app1", "1.1.0", now.Add(-48*time.Hour), "feat: something new"),
+		release("myorg", "app2", "2.0.1", now.Add(-time.Hour), "fix: a bug"),
+		release("other", "app3", "3.0.0", now.Add(-time.Hour), "fix: not mine"),
+	)
+	o.Namespace = "jx"
+	o.Now = func() time.Time {
+		return now
+	}
+	o.Repositories = []string{"myorg/*"}
+	o.Notify = []string{server.URL + "/services/secret"}
+	o.OutputFile = filepath.Join(t.TempDir(), "digest.md")
+	require.NoError(t, o.Run())
+
+	data, err := ioutil.ReadFile(o.OutputFile)
+	require.NoError(t, err)
+	markdown := string(data)
+	assert.Contains(t, markdown, "# Weekly Digest\n")
+	assert.Contains(t, markdown, "something new")
+	assert.Contains(t, markdown, "a bug")
+	assert.NotContains(t, markdown, "too old")
+	assert.NotContains(t, markdown, "not mine")
+	require.Len(t, posted, 1)
+	assert.Contains(t, posted[0].Text, "a bug")
+
+	o.Period = "yearly"
+	assert.Error(t, o.Run())
+}
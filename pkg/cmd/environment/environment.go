@@ -0,0 +1,135 @@
+package environment
+
+import (
+	"io/ioutil"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	ScmFactory         scmhelpers.Options
+	GitClient          gitclient.Interface
+	FromRevision       string
+	ToRevision         string
+	OutputMarkdownFile string
+	NoReleaseNotes     bool
+	Workers            int
+	MinRateLimit       int
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Creates a changelog of the applications promoted between two revisions of an environment GitOps repository
+
+		The versions of the applications in the helmfiles of a Jenkins X 3 environment or the 'env/requirements.yaml' file of a Jenkins X 2 environment are compared between the revisions. The release notes of each application whose version changed are taken from the releases of its git repository or the Release CR of the application in the 'config-root' directory and aggregated into one document
+
+		The release notes of several applications can be fetched concurrently via --workers. The workers share the rate limit of the git provider so that once it drops below --min-rate-limit-remaining they wait for it to reset. The applications are listed in the same order whatever the number of workers
+`)
+
+	cmdExample = templates.Examples(`
+		# show what is going to production in the latest promotion
+		jx-changelog environment --from HEAD~1
+
+		# save the changes between two revisions
+		jx-changelog environment --from 1234abc --to 5678def --output-markdown changes.md
+
+		# fetch the release notes of 8 applications at a time
+		jx-changelog environment --from HEAD~10 --workers 8 --min-rate-limit-remaining 100
+`)
+)
+
+// NewCmdEnvironment creates the command and options
+func NewCmdEnvironment() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "environment",
+		Short:   "Creates a changelog of the applications promoted between two revisions of an environment",
+		Aliases: []string{"env", "promotion"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	o.ScmFactory.DiscoverFromGit = true
+
+	cmd.Flags().StringVarP(&o.FromRevision, "from", "", "", "The previous git revision of the environment")
+	cmd.Flags().StringVarP(&o.ToRevision, "to", "", "HEAD", "The current git revision of the environment")
+	cmd.Flags().StringVarP(&o.OutputMarkdownFile, "output-markdown", "", "", "The file to generate for the changelog output. If not specified the changelog is logged")
+	cmd.Flags().BoolVarP(&o.NoReleaseNotes, "no-release-notes", "", false, "Disables fetching the release notes of the applications from the git provider")
+	cmd.Flags().IntVarP(&o.Workers, "workers", "", 1, "The number of applications whose release notes are fetched concurrently")
+	cmd.Flags().IntVarP(&o.MinRateLimit, "min-rate-limit-remaining", "", 0, "Waits for the rate limit of the git provider to reset once the remaining rate limit drops below this number. Zero disables the check")
+
+	o.ScmFactory.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the git provider client
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if o.FromRevision == "" {
+		return options.MissingOption("from")
+	}
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", nil)
+	}
+	if !o.NoReleaseNotes {
+		err = o.ScmFactory.Validate()
+		if err != nil {
+			return errors.Wrapf(err, "failed to discover git repository")
+		}
+		changelog.NewRateLimiter(o.MinRateLimit).Limit(o.ScmFactory.ScmClient)
+	}
+	return nil
+}
+
+// Run generates the changelog of the environment
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	diff := &changelog.EnvironmentDiff{
+		Dir:          o.ScmFactory.Dir,
+		FromRevision: o.FromRevision,
+		ToRevision:   o.ToRevision,
+		GitClient:    o.GitClient,
+		ScmClient:    o.ScmFactory.ScmClient,
+		Context:      o.GetContext(),
+		Workers:      o.Workers,
+	}
+	changes, err := diff.Diff()
+	if err != nil {
+		return errors.Wrapf(err, "failed to diff the environment between %s and %s", o.FromRevision, o.ToRevision)
+	}
+	markdown := changelog.EnvironmentMarkdown(changes, o.FromRevision, o.ToRevision)
+	if o.OutputMarkdownFile == "" {
+		log.Logger().Infof("%s", markdown)
+		return nil
+	}
+	err = ioutil.WriteFile(o.OutputMarkdownFile, []byte(markdown), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the changelog file %s", o.OutputMarkdownFile)
+	}
+	log.Logger().Infof("generated the changelog of %d applications: %s", len(changes), termcolor.ColorInfo(o.OutputMarkdownFile))
+	return nil
+}
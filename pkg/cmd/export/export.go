@@ -0,0 +1,159 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/create"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// FormatJSON exports the model as JSON
+	FormatJSON = "json"
+
+	// FormatYAML exports the model as YAML
+	FormatYAML = "yaml"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+	changelog.Generator
+
+	OutputFormat string
+	OutputFile   string
+	Timeout      time.Duration
+	Out          io.Writer
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Exports the structured model of the changelog without rendering or publishing it
+
+		The commits, issues, pull requests, contributors, reviewers and dependency updates are collected just like 'jx-changelog create' and written as JSON or YAML so that they can be consumed by custom renderers and audits. The collection options can also be specified in the '.jx/changelog.yaml' file in the repository
+`)
+
+	cmdExample = templates.Examples(`
+		# exports the changelog of the latest tag as JSON
+		jx-changelog export --output changelog.json
+
+		# exports the changelog between two tags as YAML including the new contributors
+		jx-changelog export --format yaml --previous-rev v1.0.0 --rev v1.1.0 --new-contributors
+`)
+)
+
+// NewCmdExport creates the command and options
+func NewCmdExport() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Exports the structured model of the changelog without rendering or publishing it",
+		Aliases: []string{"dump"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := create.ApplySharedConfig(cmd.Flags(), o.ScmFactory.Dir)
+			helper.CheckErr(err)
+			err = o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	o.ScmFactory.DiscoverFromGit = true
+
+	create.AddCollectFlags(cmd, &o.Generator)
+	cmd.Flags().StringVarP(&o.OutputFormat, "format", "", FormatJSON, fmt.Sprintf("The format of the exported model. Values: %s or %s", FormatJSON, FormatYAML))
+	cmd.Flags().StringVarP(&o.OutputFile, "output", "o", "", "The file to write the exported model to. Defaults to the standard output")
+	cmd.Flags().DurationVarP(&o.Timeout, "timeout", "", 0, "The maximum time to spend collecting the changelog such as '10m'. Defaults to no timeout")
+
+	o.ScmFactory.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and discovers the git repository
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	switch o.OutputFormat {
+	case FormatJSON, FormatYAML:
+	default:
+		return options.InvalidOptionf("format", o.OutputFormat, "should be %s or %s", FormatJSON, FormatYAML)
+	}
+	err = o.ScmFactory.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to discover git repository")
+	}
+
+	// lets only collect the changelog
+	o.UpdateRelease = false
+	o.GenerateReleaseYaml = false
+	o.GenerateCRD = false
+	err = o.Generator.Validate()
+	if err != nil {
+		return err
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	return nil
+}
+
+// Run collects and exports the changelog
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	ctx := o.GetContext()
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	model, err := o.CollectModel(ctx)
+	if err != nil {
+		return err
+	}
+	if model == nil {
+		log.Logger().Warnf("no previous tag to export the changelog from. Use --previous-rev or --first-release")
+		return nil
+	}
+
+	var data []byte
+	if o.OutputFormat == FormatYAML {
+		data, err = yaml.Marshal(model)
+	} else {
+		data, err = json.MarshalIndent(model, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the changelog to %s", o.OutputFormat)
+	}
+	if o.OutputFile == "" {
+		_, err = o.Out.Write(data)
+		return err
+	}
+	err = ioutil.WriteFile(o.OutputFile, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the exported changelog %s", o.OutputFile)
+	}
+	log.Logger().Infof("exported the changelog to %s", termcolor.ColorInfo(o.OutputFile))
+	return nil
+}
@@ -0,0 +1,91 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/export"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	git := func(args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	commit := func(message string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(message), 0600))
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	commit("feat: initial")
+	git("tag", "v1.0.0")
+	commit("feat(cli)!: something new\n\nBREAKING CHANGE: the old flag is removed")
+	commit("fix: a bug")
+	git("tag", "v1.1.0")
+
+	newOptions := func() *export.Options {
+		scmClient, _ := scmfake.NewDefault()
+		_, o := export.NewCmdExport()
+		o.Ctx = context.Background()
+		o.GitClient = g
+		o.ScmFactory.Dir = dir
+		o.ScmFactory.SourceURL = "https://github.com/myorg/myrepo"
+		o.ScmFactory.GitKind = "fake"
+		o.ScmFactory.ScmClient = scmClient
+		o.ScmFactory.GitClient = g
+		o.PreviousRevision = "v1.0.0"
+		o.CurrentRevision = "v1.1.0"
+		return o
+	}
+
+	out := &bytes.Buffer{}
+	o := newOptions()
+	o.Out = out
+	require.NoError(t, o.Run())
+	model := &changelog.ExportModel{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), model))
+	assert.Equal(t, "myorg/myrepo", model.Repository)
+	assert.Equal(t, "v1.0.0", model.Range.PreviousRev)
+	require.Len(t, model.Changelog.Commits, 2)
+	assert.Equal(t, "fix", model.Changelog.Commits[0].Type)
+	assert.Equal(t, "cli", model.Changelog.Commits[1].Scope)
+	assert.True(t, model.Changelog.Commits[1].Breaking)
+	assert.Equal(t, 1, model.Stats.BreakingChanges)
+	require.Len(t, model.Contributors, 1)
+	assert.Equal(t, 2, model.Contributors[0].Commits)
+
+	_, err := os.Stat(filepath.Join(dir, "templates"))
+	assert.True(t, os.IsNotExist(err), "should not create the templates directory")
+
+	o = newOptions()
+	o.OutputFormat = export.FormatYAML
+	o.OutputFile = filepath.Join(t.TempDir(), "changelog.yaml")
+	require.NoError(t, o.Run())
+	data, err := ioutil.ReadFile(o.OutputFile)
+	require.NoError(t, err)
+	model = &changelog.ExportModel{}
+	require.NoError(t, yaml.Unmarshal(data, model))
+	assert.Len(t, model.Changelog.Commits, 2)
+
+	o = newOptions()
+	o.OutputFormat = "xml"
+	assert.Error(t, o.Run())
+}
@@ -0,0 +1,177 @@
+package helm
+
+import (
+	"io/ioutil"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// clusterState the name of the state of the helm releases of the current cluster
+const clusterState = "cluster"
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	FromFile           string
+	ToFile             string
+	VersionStreamDir   string
+	Sources            map[string]string
+	OutputMarkdownFile string
+	GitServerURL       string
+	GitKind            string
+	GitToken           string
+	NoReleaseNotes     bool
+	ScmClient          *scm.Client
+	CommandRunner      cmdrunner.CommandRunner
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Creates a changelog of the applications upgraded between two states of the deployed helm releases
+
+		Each state is either a helmfile or the output of 'helm list --all-namespaces --output json'. If '--to' is not specified the helm releases of the current cluster are used. The source repository of each chart is found via '--source' or the version stream and the release notes of the upstream releases between the deployed versions are combined into one document
+`)
+
+	cmdExample = templates.Examples(`
+		# save the state of the cluster before an upgrade
+		helm list --all-namespaces --output json > before.json
+
+		# after the upgrade create the changelog of the applications
+		jx-changelog helm --from before.json --version-stream-dir versionStream
+
+		# compare two helmfiles
+		jx-changelog helm --from old/helmfile.yaml --to helmfile.yaml --source jx-preview=https://github.com/jenkins-x/jx-preview
+`)
+)
+
+// NewCmdHelm creates the command and options
+func NewCmdHelm() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "helm",
+		Short:   "Creates a changelog of the applications upgraded between two states of the deployed helm releases",
+		Aliases: []string{"helmfile"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.FromFile, "from", "", "", "The helmfile or 'helm list' JSON file of the previous state")
+	cmd.Flags().StringVarP(&o.ToFile, "to", "", "", "The helmfile or 'helm list' JSON file of the current state. Defaults to the helm releases of the current cluster")
+	cmd.Flags().StringVarP(&o.VersionStreamDir, "version-stream-dir", "", "", "The directory of a Jenkins X version stream used to find the source repositories of the charts")
+	cmd.Flags().StringToStringVarP(&o.Sources, "source", "", nil, "The source repositories of charts such as 'jx-preview=https://github.com/jenkins-x/jx-preview'")
+	cmd.Flags().StringVarP(&o.OutputMarkdownFile, "output-markdown", "", "", "The file to generate for the changelog output. If not specified the changelog is logged")
+	cmd.Flags().StringVarP(&o.GitServerURL, "git-server", "", "https://github.com", "The git server hosting the source repositories of the charts")
+	cmd.Flags().StringVarP(&o.GitKind, "git-kind", "", "", "The kind of git server to connect to")
+	cmd.Flags().StringVarP(&o.GitToken, "git-token", "", "", "The git token used to fetch the releases of the source repositories")
+	cmd.Flags().BoolVarP(&o.NoReleaseNotes, "no-release-notes", "", false, "Disables fetching the release notes of the applications from the git provider")
+
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the git provider client
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if o.FromFile == "" {
+		return options.MissingOption("from")
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.QuietCommandRunner
+	}
+	if o.ScmClient == nil && !o.NoReleaseNotes {
+		o.ScmClient, o.GitToken, err = scmhelpers.NewScmClient(o.GitKind, o.GitServerURL, o.GitToken)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the git provider client for %s: try supply --git-token or --no-release-notes", o.GitServerURL)
+		}
+	}
+	return nil
+}
+
+// Run generates the changelog of the helm releases
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	diff := &changelog.HelmDiff{
+		Sources:          o.Sources,
+		VersionStreamDir: o.VersionStreamDir,
+		Context:          o.GetContext(),
+	}
+	if !o.NoReleaseNotes {
+		diff.ScmClient = o.ScmClient
+	}
+	diff.Previous, err = o.loadState(o.FromFile)
+	if err != nil {
+		return err
+	}
+	diff.Current, err = o.loadState(o.ToFile)
+	if err != nil {
+		return err
+	}
+	changes, err := diff.Diff()
+	if err != nil {
+		return errors.Wrap(err, "failed to diff the helm releases")
+	}
+	to := o.ToFile
+	if to == "" {
+		to = clusterState
+	}
+	markdown := changelog.EnvironmentMarkdown(changes, o.FromFile, to)
+	if o.OutputMarkdownFile == "" {
+		log.Logger().Infof("%s", markdown)
+		return nil
+	}
+	err = ioutil.WriteFile(o.OutputMarkdownFile, []byte(markdown), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the changelog file %s", o.OutputMarkdownFile)
+	}
+	log.Logger().Infof("generated the changelog of %d applications: %s", len(changes), termcolor.ColorInfo(o.OutputMarkdownFile))
+	return nil
+}
+
+// loadState parses the file of the state of the helm releases or lists the helm releases of the cluster if the file
+// is not specified
+func (o *Options) loadState(file string) (map[string]changelog.EnvironmentApp, error) {
+	var text string
+	if file == "" {
+		c := &cmdrunner.Command{
+			Name: "helm",
+			Args: []string{"list", "--all-namespaces", "--output", "json"},
+		}
+		out, err := o.CommandRunner(c)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the helm releases of the cluster")
+		}
+		text = out
+	} else {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", file)
+		}
+		text = string(data)
+	}
+	answer, err := changelog.ParseHelmState(file, text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the helm releases of %s", file)
+	}
+	return answer, nil
+}
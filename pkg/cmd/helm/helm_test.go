@@ -0,0 +1,51 @@
+package helm_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/helm"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmChangelog(t *testing.T) {
+	tmpDir := t.TempDir()
+	from := filepath.Join(tmpDir, "before.json")
+	require.NoError(t, ioutil.WriteFile(from, []byte(`[
+{"name":"jx-preview","namespace":"jx","chart":"jx-preview-0.1.0","app_version":"0.1.0"},
+{"name":"lighthouse","namespace":"jx","chart":"lighthouse-1.1.0-rc.1","app_version":"1.1.0"}
+]`), 0600))
+
+	scmClient, _ := scmfake.NewDefault()
+	ctx := context.Background()
+	for _, tag := range []string{"v0.1.0", "v0.1.1", "v0.2.0"} {
+		_, _, err := scmClient.Releases.Create(ctx, "jenkins-x/jx-preview", &scm.ReleaseInput{Tag: tag, Title: tag, Description: "changes of " + tag})
+		require.NoError(t, err)
+	}
+
+	_, o := helm.NewCmdHelm()
+	o.FromFile = from
+	o.ScmClient = scmClient
+	o.Sources = map[string]string{"jx-preview": "https://github.com/jenkins-x/jx-preview"}
+	o.OutputMarkdownFile = filepath.Join(tmpDir, "changes.md")
+	o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+		assert.Equal(t, "helm list --all-namespaces --output json", cmdrunner.CLI(c))
+		return `[{"name":"jx-preview","namespace":"jx","chart":"jx-preview-0.2.0"},{"name":"lighthouse","namespace":"jx","chart":"lighthouse-1.1.0-rc.1"}]`, nil
+	}
+	require.NoError(t, o.Run())
+
+	data, err := ioutil.ReadFile(o.OutputMarkdownFile)
+	require.NoError(t, err)
+	markdown := string(data)
+	assert.Contains(t, markdown, "| [jx-preview](https://github.com/jenkins-x/jx-preview) | jx | 0.1.0 | 0.2.0 |\n")
+	assert.NotContains(t, markdown, "lighthouse")
+	assert.Contains(t, markdown, "changes of v0.2.0")
+	assert.Contains(t, markdown, "changes of v0.1.1")
+	assert.NotContains(t, markdown, "changes of v0.1.0")
+}
@@ -0,0 +1,232 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// OutputText logs the violations as text
+	OutputText = "text"
+
+	// OutputJSON prints the violations as JSON
+	OutputJSON = "json"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+	changelog.LintRules
+
+	GitClient    gitclient.Interface
+	Dir          string
+	FromRev      string
+	ToRev        string
+	GitBackend   string
+	SkipMerges   bool
+	SkipPatterns []string
+	Output       string
+	Out          io.Writer
+
+	// Violations the violations found by the last run
+	Violations []changelog.LintViolation
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Lints the messages of the commits which are not released yet against the Conventional Commits rules
+
+		The commits since the latest tag are validated against the allowed types and scopes, the maximum length of the subject and the format of breaking change footers. The command fails with a report of the violations so that the quality of the changelog is enforced before the release
+`)
+
+	cmdExample = templates.Examples(`
+		# lints the commits since the latest tag
+		jx-changelog lint
+
+		# lints the commits of a pull request requiring one of the scopes
+		jx-changelog lint --from origin/main --scopes cli,api --require-scope
+`)
+)
+
+// NewCmdLint creates the command and options
+func NewCmdLint() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   "Lints the messages of the commits which are not released yet against the Conventional Commits rules",
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "The directory of the git repository")
+	cmd.Flags().StringVarP(&o.FromRev, "from", "", "", "The revision after which commits are linted. Defaults to the latest tag or the whole history if there are no tags")
+	cmd.Flags().StringVarP(&o.ToRev, "to", "", "HEAD", "The revision up to which commits are linted")
+	cmd.Flags().StringVarP(&o.GitBackend, "git-backend", "", gits.GitBackendGoGit, fmt.Sprintf("The backend used to walk the git history. Values: %s or %s", gits.GitBackendGoGit, gits.GitBackendCLI))
+	cmd.Flags().StringSliceVarP(&o.Types, "types", "", changelog.DefaultLintTypes(), "The allowed Conventional Commits types")
+	cmd.Flags().StringSliceVarP(&o.Scopes, "scopes", "", nil, "The allowed scopes. Any scope is allowed if not specified")
+	cmd.Flags().BoolVarP(&o.RequireScope, "require-scope", "", false, "Fails commits without a scope")
+	cmd.Flags().IntVarP(&o.MaxSubjectLength, "max-subject-length", "", 72, "The maximum length of the first line of the commit message. Zero disables the check")
+	cmd.Flags().BoolVarP(&o.AllowNonConventional, "allow-non-conventional", "", false, "Only lints commits using the Conventional Commits format")
+	cmd.Flags().BoolVarP(&o.SkipMerges, "skip-merges", "", true, "Skips merge commits")
+	cmd.Flags().StringArrayVarP(&o.SkipPatterns, "skip-pattern", "", nil, "The regular expressions of the commit messages to skip such as '^Revert '")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputText, fmt.Sprintf("The format of the report. Values: %s or %s", OutputText, OutputJSON))
+
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	switch o.Output {
+	case OutputText, OutputJSON:
+	default:
+		return options.InvalidOptionf("output", o.Output, "should be %s or %s", OutputText, OutputJSON)
+	}
+	if o.MaxSubjectLength < 0 {
+		return options.InvalidOptionf("max-subject-length", o.MaxSubjectLength, "should not be negative")
+	}
+	for _, p := range o.SkipPatterns {
+		_, err = regexp.Compile(p)
+		if err != nil {
+			return options.InvalidOptionf("skip-pattern", p, "should be a regular expression: %s", err.Error())
+		}
+	}
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", nil)
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	return nil
+}
+
+// Run lints the commits
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	fetcher, err := gits.NewCommitFetcher(o.GitBackend)
+	if err != nil {
+		return options.InvalidOptionf("git-backend", o.GitBackend, "%s", err.Error())
+	}
+	fromRev := o.FromRev
+	if fromRev == "" {
+		_, fromRev, err = gits.GetCommitPointedToByLatestTag(o.GitClient, o.Dir)
+		if err != nil {
+			return err
+		}
+	}
+	var iter gits.CommitIterator
+	if fromRev == "" {
+		iter, err = fetcher.FetchHistory(o.Dir, o.ToRev, 0)
+	} else {
+		iter, err = fetcher.FetchCommits(o.Dir, fromRev, o.ToRev)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch the commits of %s", o.Dir)
+	}
+	defer iter.Close()
+
+	var skips []*regexp.Regexp
+	for _, p := range o.SkipPatterns {
+		skips = append(skips, regexp.MustCompile(p))
+	}
+	o.Violations = nil
+	count := 0
+	for {
+		c, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to walk the commits of %s", o.Dir)
+		}
+		if o.SkipMerges && len(c.ParentHashes) > 1 {
+			continue
+		}
+		if matchesAny(skips, c.Message) {
+			continue
+		}
+		count++
+		o.Violations = append(o.Violations, o.Lint(c.Hash.String(), c.Message)...)
+	}
+
+	err = o.report(count)
+	if err != nil {
+		return err
+	}
+	if len(o.Violations) > 0 {
+		return errors.Errorf("found %d violations of the commit message rules", len(o.Violations))
+	}
+	return nil
+}
+
+func (o *Options) report(count int) error {
+	if o.Output == OutputJSON {
+		violations := o.Violations
+		if violations == nil {
+			violations = []changelog.LintViolation{}
+		}
+		data, err := json.MarshalIndent(violations, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the violations")
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	}
+	if len(o.Violations) == 0 {
+		log.Logger().Infof("linted %d commits with no violations", count)
+		return nil
+	}
+	var buf strings.Builder
+	lastSHA := ""
+	for _, v := range o.Violations {
+		if v.SHA != lastSHA {
+			lastSHA = v.SHA
+			buf.WriteString(fmt.Sprintf("\n%s %s\n", termcolor.ColorInfo(shortSHA(v.SHA)), v.Subject))
+		}
+		buf.WriteString(fmt.Sprintf("  %s: %s\n", termcolor.ColorWarning(v.Rule), v.Message))
+	}
+	log.Logger().Infof("linted %d commits:%s", count, buf.String())
+	return nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, r := range patterns {
+		if r.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,73 @@
+package lint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/lint"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	git := func(args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	commit := func(message string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(message), 0600))
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	commit("initial import")
+	git("tag", "v1.0.0")
+	commit("feat: something new")
+	bad := commit("fixed a bug")
+	commit("Revert \"feat: something new\"")
+
+	_, o := lint.NewCmdLint()
+	o.Dir = dir
+	o.GitClient = g
+	o.SkipPatterns = []string{"^Revert "}
+	err := o.Run()
+	require.Error(t, err)
+	require.Len(t, o.Violations, 1)
+	assert.Equal(t, bad, o.Violations[0].SHA)
+	assert.Equal(t, changelog.LintRuleConventional, o.Violations[0].Rule)
+
+	out := &bytes.Buffer{}
+	_, o = lint.NewCmdLint()
+	o.Dir = dir
+	o.GitClient = g
+	o.FromRev = bad
+	o.Output = lint.OutputJSON
+	o.Out = out
+	require.Error(t, o.Run())
+	var violations []changelog.LintViolation
+	require.NoError(t, json.Unmarshal(out.Bytes(), &violations))
+	require.Len(t, violations, 1)
+	assert.Equal(t, "Revert \"feat: something new\"", violations[0].Subject)
+
+	_, o = lint.NewCmdLint()
+	o.Dir = dir
+	o.GitClient = g
+	o.FromRev = bad
+	o.AllowNonConventional = true
+	require.NoError(t, o.Run())
+
+	o.Output = "yaml"
+	assert.Error(t, o.Run())
+}
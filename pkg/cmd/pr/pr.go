@@ -0,0 +1,154 @@
+package pr
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ModeDescription adds the changelog to the description of the pull request
+	ModeDescription = "description"
+
+	// ModeComment adds the changelog as a comment on the pull request
+	ModeComment = "comment"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	ScmFactory scmhelpers.Options
+	GitClient  gitclient.Interface
+	Number     int
+	Mode       string
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Adds the release notes of the applications promoted by a pull request on an environment repository to the pull request
+
+		The versions of the applications are compared between the base and head commits of the pull request which need to be available in the local clone of the environment repository. The changelog is added to the description of the pull request replacing the changelog of any previous run or added as a comment
+`)
+
+	cmdExample = templates.Examples(`
+		# enrich the description of the pull request of the current pipeline
+		jx-changelog pr
+
+		# comment on a pull request
+		jx-changelog pr --number 123 --mode comment
+`)
+)
+
+// NewCmdPullRequest creates the command and options
+func NewCmdPullRequest() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "pr",
+		Short:   "Adds the release notes of the applications promoted by a pull request on an environment repository to the pull request",
+		Aliases: []string{"pullrequest", "promote-pr"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	o.ScmFactory.DiscoverFromGit = true
+
+	cmd.Flags().IntVarP(&o.Number, "number", "", 0, "The number of the pull request. Defaults to the '$PULL_NUMBER' environment variable")
+	cmd.Flags().StringVarP(&o.Mode, "mode", "", ModeDescription, fmt.Sprintf("How the changelog is added to the pull request. Values: %s or %s", ModeDescription, ModeComment))
+
+	o.ScmFactory.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the git provider client
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if o.Mode != ModeDescription && o.Mode != ModeComment {
+		return options.InvalidOptionf("mode", o.Mode, "should be %s or %s", ModeDescription, ModeComment)
+	}
+	if o.Number <= 0 {
+		text := os.Getenv("PULL_NUMBER")
+		if text == "" {
+			return options.MissingOption("number")
+		}
+		o.Number, err = strconv.Atoi(text)
+		if err != nil {
+			return options.InvalidOptionf("number", text, "the $PULL_NUMBER should be a number")
+		}
+	}
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", nil)
+	}
+	err = o.ScmFactory.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to discover git repository")
+	}
+	return nil
+}
+
+// Run adds the changelog to the pull request
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	ctx := o.GetContext()
+	scmClient := o.ScmFactory.ScmClient
+	fullName := o.ScmFactory.FullRepositoryName
+	pr, _, err := scmClient.PullRequests.Find(ctx, fullName, o.Number)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find pull request %s#%d", fullName, o.Number)
+	}
+	diff := &changelog.EnvironmentDiff{
+		Dir:          o.ScmFactory.Dir,
+		FromRevision: pr.Base.Sha,
+		ToRevision:   pr.Head.Sha,
+		GitClient:    o.GitClient,
+		ScmClient:    scmClient,
+		Context:      ctx,
+	}
+	changes, err := diff.Diff()
+	if err != nil {
+		return errors.Wrapf(err, "failed to diff the environment between %s and %s", pr.Base.Sha, pr.Head.Sha)
+	}
+	if len(changes) == 0 {
+		log.Logger().Infof("pull request %s#%d does not change the versions of any applications", fullName, o.Number)
+		return nil
+	}
+	markdown := changelog.EnvironmentMarkdown(changes, pr.Base.Ref, pr.Head.Ref)
+	if o.Mode == ModeComment {
+		_, _, err = scmClient.PullRequests.CreateComment(ctx, fullName, o.Number, &scm.CommentInput{Body: markdown})
+		if err != nil {
+			return errors.Wrapf(err, "failed to comment on pull request %s#%d", fullName, o.Number)
+		}
+		log.Logger().Infof("commented the changelog of %d applications on pull request %s", len(changes), termcolor.ColorInfo(pr.Link))
+		return nil
+	}
+	body := changelog.UpdatePromotionDescription(pr.Body, markdown)
+	_, _, err = scmClient.PullRequests.Update(ctx, fullName, o.Number, &scm.PullRequestInput{Body: body})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the description of pull request %s#%d", fullName, o.Number)
+	}
+	log.Logger().Infof("added the changelog of %d applications to pull request %s", len(changes), termcolor.ColorInfo(pr.Link))
+	return nil
+}
@@ -0,0 +1,68 @@
+package pr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/pr"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRequestComment(t *testing.T) {
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	git := func(args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	write := func(path, text string) {
+		f := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0755))
+		require.NoError(t, ioutil.WriteFile(f, []byte(text), 0600))
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	write("helmfiles/jx/helmfile.yaml", "releases:\n- chart: jx3/jx-preview\n  version: 0.1.0\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	base := git("rev-parse", "HEAD")
+	write("helmfiles/jx/helmfile.yaml", "releases:\n- chart: jx3/jx-preview\n  version: 0.2.0\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "chore: promote jx-preview to 0.2.0")
+	head := git("rev-parse", "HEAD")
+
+	scmClient, data := scmfake.NewDefault()
+	data.PullRequests[12] = &scm.PullRequest{
+		Number: 12,
+		Title:  "chore: promote jx-preview to 0.2.0",
+		Base:   scm.PullRequestBranch{Ref: "master", Sha: base},
+		Head:   scm.PullRequestBranch{Ref: "promote-jx-preview-0.2.0", Sha: head},
+	}
+
+	_, o := pr.NewCmdPullRequest()
+	o.Number = 12
+	o.Mode = pr.ModeComment
+	o.GitClient = g
+	o.ScmFactory.Dir = dir
+	o.ScmFactory.SourceURL = "https://github.com/myorg/environment-mycluster-dev"
+	o.ScmFactory.GitKind = "fake"
+	o.ScmFactory.ScmClient = scmClient
+	require.NoError(t, o.Run())
+
+	comments := data.PullRequestComments[12]
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].Body, "## Environment Changes from master to promote-jx-preview-0.2.0\n")
+	assert.Contains(t, comments[0].Body, "| jx-preview | jx | 0.1.0 | 0.2.0 |\n")
+
+	o.Mode = "review"
+	assert.Error(t, o.Run())
+}
@@ -0,0 +1,139 @@
+package publish
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// versionTagRegex matches the tags which are versions such as 'v1.2.3' or '1.2.3-rc.1'
+var versionTagRegex = regexp.MustCompile(`^v?\d+(\.\d+)*([-+].*)?$`)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	ScmFactory    scmhelpers.Options
+	GitClient     gitclient.Interface
+	NotesFile     string
+	Tag           string
+	Notify        []string
+	Channel       string
+	ChannelNotify map[string]string
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Creates or updates the release of a tag on the git provider from a release notes file
+
+		This decouples the generation of the release notes, such as via 'jx-changelog create --output-markdown', from their publication in later stages of a pipeline. It also completes the approval of release notes generated via 'jx-changelog create --approval-pr': once the pull request committing the notes to the 'release-notes' directory is merged the release is created from the approved file.
+
+		The tag defaults to the name of the file without its extension if it is a version such as 'v1.2.3.md'
+`)
+
+	cmdExample = templates.Examples(`
+		# publish the release notes generated by an earlier stage
+		jx-changelog publish --from-file notes.md --tag v1.2.3
+
+		# publish the approved release notes of v1.2.3 and post them to Slack
+		jx-changelog publish --from-file release-notes/v1.2.3.md --notify https://hooks.slack.com/services/...
+`)
+)
+
+// NewCmdPublish creates the command and options
+func NewCmdPublish() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "publish",
+		Short:   "Creates or updates the release of a tag on the git provider from a release notes file",
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	o.ScmFactory.DiscoverFromGit = true
+
+	cmd.Flags().StringVarP(&o.NotesFile, "from-file", "f", "", "The markdown file of the release notes such as 'release-notes/v1.2.3.md'")
+	cmd.Flags().StringVarP(&o.NotesFile, "notes-file", "", "", "An alias of --from-file")
+	cmd.Flags().StringVarP(&o.Tag, "tag", "", "", "The tag of the release. Defaults to the name of the file without its extension if it is a version")
+	cmd.Flags().StringArrayVarP(&o.Notify, "notify", "", nil, "The Slack incoming webhook URLs to post the release notes to once the release is published")
+	cmd.Flags().StringVarP(&o.Channel, "channel", "", "", fmt.Sprintf("The release channel of the release: %s, %s or %s. The %s and %s releases are marked as pre-releases and %s releases only notify the --channel-notify webhook of the channel", changelog.ChannelStable, changelog.ChannelBeta, changelog.ChannelNightly, changelog.ChannelBeta, changelog.ChannelNightly, changelog.ChannelNightly))
+	cmd.Flags().StringToStringVarP(&o.ChannelNotify, "channel-notify", "", nil, "The Slack incoming webhook URLs of the channels to post their releases to such as 'nightly=https://hooks.slack.com/services/...'")
+
+	o.ScmFactory.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the git provider client
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if o.NotesFile == "" {
+		return options.MissingOption("from-file")
+	}
+	if o.Tag == "" {
+		o.Tag = changelog.TagOfApprovalFile(o.NotesFile)
+		if !versionTagRegex.MatchString(o.Tag) {
+			return options.MissingOption("tag")
+		}
+	}
+	_, err = changelog.ParseChannel(o.Channel, "")
+	if err != nil {
+		return err
+	}
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", nil)
+	}
+	err = o.ScmFactory.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to discover git repository")
+	}
+	return nil
+}
+
+// Run publishes the release notes
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	data, err := ioutil.ReadFile(o.NotesFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the release notes %s", o.NotesFile)
+	}
+	markdown := string(data)
+	if strings.TrimSpace(markdown) == "" {
+		return errors.Errorf("the release notes %s are empty", o.NotesFile)
+	}
+	g := &changelog.Generator{
+		ScmFactory:    o.ScmFactory,
+		GitClient:     o.GitClient,
+		Notify:        o.Notify,
+		Channel:       o.Channel,
+		ChannelNotify: o.ChannelNotify,
+	}
+	result, err := g.PublishNotes(o.GetContext(), o.Tag, markdown)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("published the release notes of %s: %s", termcolor.ColorInfo(result.Tag), termcolor.ColorInfo(result.Release.Spec.ReleaseNotesURL))
+	return nil
+}
@@ -0,0 +1,106 @@
+package publish_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/publish"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishNotesFile(t *testing.T) {
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	_, err := g.Command(dir, "init", "-q")
+	require.NoError(t, err)
+	notesFile := filepath.Join(dir, "release-notes", "v1.2.3.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(notesFile), 0755))
+	require.NoError(t, ioutil.WriteFile(notesFile, []byte("## Changes\n\n* fix: a bug\n"), 0600))
+
+	scmClient, data := scmfake.NewDefault()
+	o := newOptions(notesFile, g, dir, scmClient)
+	require.NoError(t, o.Run())
+	assert.Equal(t, "v1.2.3", o.Tag, "the tag should default to the name of the file")
+
+	releases := data.Releases["myorg/myrepo"]
+	require.Len(t, releases, 1)
+	for _, r := range releases {
+		assert.Equal(t, "v1.2.3", r.Tag)
+		assert.Equal(t, "1.2.3", r.Title)
+		assert.Equal(t, "## Changes\n\n* fix: a bug\n", r.Description)
+	}
+
+	// lets update the release from the edited notes
+	require.NoError(t, ioutil.WriteFile(notesFile, []byte("## Changes\n\n* fix: a nasty bug\n"), 0600))
+	o = newOptions(notesFile, g, dir, scmClient)
+	require.NoError(t, o.Run())
+	require.Len(t, releases, 1)
+	for _, r := range releases {
+		assert.Equal(t, "## Changes\n\n* fix: a nasty bug\n", r.Description)
+	}
+
+	_, o = publish.NewCmdPublish()
+	o.Ctx = context.Background()
+	assert.Error(t, o.Validate(), "the file should be required")
+}
+
+func TestPublishNotify(t *testing.T) {
+	var messages []changelog.SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := changelog.SlackMessage{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	g := cli.NewCLIClient("", nil)
+	_, err := g.Command(dir, "init", "-q")
+	require.NoError(t, err)
+	notesFile := filepath.Join(dir, "notes.md")
+	require.NoError(t, ioutil.WriteFile(notesFile, []byte("### Bug Fixes\n\n* a bug\n"), 0600))
+
+	scmClient, data := scmfake.NewDefault()
+	o := newOptions(notesFile, g, dir, scmClient)
+	assert.Error(t, o.Validate(), "the tag should be required if the file is not named after it")
+
+	o = newOptions(notesFile, g, dir, scmClient)
+	o.Tag = "v2.0.0"
+	o.Notify = []string{server.URL + "/services/T000/B000/XXXX"}
+	require.NoError(t, o.Run())
+	require.Len(t, data.Releases["myorg/myrepo"], 1)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "*<https://fake.git/myorg/myrepo/releases/release/0|myorg/myrepo v2.0.0>*\n\n*Bug Fixes*\n\n• a bug\n", messages[0].Text)
+}
+
+func TestPublishFromFileAlias(t *testing.T) {
+	for _, flag := range []string{"--from-file", "-f", "--notes-file"} {
+		cmd, o := publish.NewCmdPublish()
+		require.NoError(t, cmd.Flags().Parse([]string{flag, "release-notes/v1.2.3.md"}))
+		assert.Equal(t, "release-notes/v1.2.3.md", o.NotesFile, "the notes file should be set via %s", flag)
+	}
+}
+
+func newOptions(notesFile string, g gitclient.Interface, dir string, scmClient *scm.Client) *publish.Options {
+	_, o := publish.NewCmdPublish()
+	o.Ctx = context.Background()
+	o.NotesFile = notesFile
+	o.GitClient = g
+	o.ScmFactory.Dir = dir
+	o.ScmFactory.SourceURL = "https://github.com/myorg/myrepo"
+	o.ScmFactory.GitKind = "fake"
+	o.ScmFactory.ScmClient = scmClient
+	return o
+}
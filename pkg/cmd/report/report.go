@@ -0,0 +1,136 @@
+package report
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+	changelog.ReleaseFilter
+
+	JXClient   jxc.Interface
+	Namespace  string
+	Selector   string
+	SinceAgo   time.Duration
+	Title      string
+	Format     string
+	OutputFile string
+	Now        func() time.Time
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Creates a report of the Release resources of a namespace
+
+		The changelogs of the Release resources generated by 'jx-changelog create' in the namespace are aggregated into one markdown or HTML document. The releases can be limited to a time window or to the releases created after a previous Release so that the report covers a promotion
+`)
+
+	cmdExample = templates.Examples(`
+		# report the releases of the last week in production
+		jx-changelog report -n jx-production --since 168h
+
+		# report the releases since the previous promotion as HTML
+		jx-changelog report -n jx-production --after-release myapp-1.2.3 --format html --output report.html
+`)
+)
+
+// NewCmdReport creates the command and options
+func NewCmdReport() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "report",
+		Short:   "Creates a report of the Release resources of a namespace",
+		Aliases: []string{"releases"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "The namespace of the Release resources. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "The label selector of the Release resources")
+	cmd.Flags().DurationVarP(&o.SinceAgo, "since", "", 0, "Only includes releases created within the duration such as '24h'")
+	cmd.Flags().StringVarP(&o.AfterRelease, "after-release", "", "", "Only includes releases created after the Release of the name such as the release of a previous promotion")
+	cmd.Flags().StringVarP(&o.Title, "title", "", "", "The title of the report. Defaults to the namespace")
+	cmd.Flags().StringVarP(&o.Format, "format", "", changelog.RendererMarkdown, fmt.Sprintf("The format of the report. Values: %s or %s", changelog.RendererMarkdown, changelog.RendererHTML))
+	cmd.Flags().StringVarP(&o.OutputFile, "output", "", "", "The file to generate for the report. If not specified the report is logged")
+
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the kubernetes client
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if o.Format != changelog.RendererMarkdown && o.Format != changelog.RendererHTML {
+		return options.InvalidOptionf("format", o.Format, "should be %s or %s", changelog.RendererMarkdown, changelog.RendererHTML)
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.SinceAgo > 0 {
+		o.Since = o.Now().Add(-o.SinceAgo)
+	}
+	o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create jx client")
+	}
+	return nil
+}
+
+// Run generates the report
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	list, err := o.JXClient.JenkinsV1().Releases(o.Namespace).List(o.GetContext(), metav1.ListOptions{LabelSelector: o.Selector})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the Releases in namespace %s", o.Namespace)
+	}
+	releases, err := changelog.FilterReleases(list.Items, &o.ReleaseFilter)
+	if err != nil {
+		return err
+	}
+	title := o.Title
+	if title == "" {
+		title = "Releases in " + o.Namespace
+	}
+	output, err := changelog.ReleaseReportMarkdown(title, releases)
+	if err != nil {
+		return err
+	}
+	if o.Format == changelog.RendererHTML {
+		output = changelog.MarkdownToHTML(output, title, "", true)
+	}
+	if o.OutputFile == "" {
+		log.Logger().Infof("%s", output)
+		return nil
+	}
+	err = ioutil.WriteFile(o.OutputFile, []byte(output), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the report %s", o.OutputFile)
+	}
+	log.Logger().Infof("generated the report of %d releases: %s", len(releases), termcolor.ColorInfo(o.OutputFile))
+	return nil
+}
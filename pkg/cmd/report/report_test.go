@@ -0,0 +1,61 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/report"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReport(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	release := func(name, version string, created time.Time, commit string) *v1.Release {
+		return &v1.Release{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx-production", CreationTimestamp: metav1.NewTime(created)},
+			Spec: v1.ReleaseSpec{
+				Name:       "myapp",
+				Version:    version,
+				GitHTTPURL: "https://github.com/jstrachan/myapp",
+				Commits:    []v1.CommitSummary{{Message: commit, SHA: "123"}},
+			},
+		}
+	}
+	_, o := report.NewCmdReport()
+	o.JXClient = fakejx.NewSimpleClientset(
+		release("myapp-1.0.0", "1.0.0", now.Add(-72*time.Hour), "feat: initial"),
+		release("myapp-1.1.0", "1.1.0", now.Add(-48*time.Hour), "feat: something new"),
+		release("myapp-1.1.1", "1.1.1", now.Add(-time.Hour), "fix: a bug"),
+	)
+	o.Namespace = "jx-production"
+	o.Now = func() time.Time {
+		return now
+	}
+	o.AfterRelease = "myapp-1.0.0"
+	o.OutputFile = filepath.Join(t.TempDir(), "report.md")
+	require.NoError(t, o.Run())
+
+	data, err := ioutil.ReadFile(o.OutputFile)
+	require.NoError(t, err)
+	markdown := string(data)
+	assert.Contains(t, markdown, "# Releases in jx-production\n")
+	assert.Contains(t, markdown, "| [myapp](https://github.com/jstrachan/myapp) | 1.1.1 | 2021-03-10T11:00:00Z |\n| [myapp](https://github.com/jstrachan/myapp) | 1.1.0 |")
+	assert.Contains(t, markdown, "\n## [myapp](https://github.com/jstrachan/myapp) 1.1.1\n\n### Changes\n\n#### Bug Fixes\n\n* a bug")
+	assert.NotContains(t, markdown, "initial")
+
+	o.AfterRelease = ""
+	o.SinceAgo = 24 * time.Hour
+	o.Format = "html"
+	require.NoError(t, o.Run())
+	data, err = ioutil.ReadFile(o.OutputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<title>Releases in jx-production</title>")
+	assert.Contains(t, string(data), "a bug")
+	assert.NotContains(t, string(data), "something new")
+}
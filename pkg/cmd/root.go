@@ -1,7 +1,19 @@
 package cmd
 
 import (
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/check"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/controller"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/create"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/diff"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/digest"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/environment"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/export"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/helm"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/lint"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/pr"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/publish"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/report"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/serve"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 
@@ -30,7 +42,19 @@ func Main() *cobra.Command {
 	}
 	o := options.BaseOptions{}
 	o.AddBaseFlags(cmd)
+	cmd.AddCommand(cobras.SplitCommand(check.NewCmdCheck()))
+	cmd.AddCommand(cobras.SplitCommand(controller.NewCmdController()))
 	cmd.AddCommand(cobras.SplitCommand(create.NewCmdChangelogCreate()))
+	cmd.AddCommand(cobras.SplitCommand(diff.NewCmdDiff()))
+	cmd.AddCommand(cobras.SplitCommand(digest.NewCmdDigest()))
+	cmd.AddCommand(cobras.SplitCommand(environment.NewCmdEnvironment()))
+	cmd.AddCommand(cobras.SplitCommand(export.NewCmdExport()))
+	cmd.AddCommand(cobras.SplitCommand(helm.NewCmdHelm()))
+	cmd.AddCommand(cobras.SplitCommand(lint.NewCmdLint()))
+	cmd.AddCommand(cobras.SplitCommand(pr.NewCmdPullRequest()))
+	cmd.AddCommand(cobras.SplitCommand(publish.NewCmdPublish()))
+	cmd.AddCommand(cobras.SplitCommand(report.NewCmdReport()))
+	cmd.AddCommand(cobras.SplitCommand(serve.NewCmdServe()))
 	cmd.AddCommand(cobras.SplitCommand(version.NewCmdVersion()))
 	return cmd
 }
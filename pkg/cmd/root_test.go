@@ -0,0 +1,25 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/publish"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishCommand(t *testing.T) {
+	root := cmd.Main()
+	found, _, err := root.Find([]string{"publish", "--from-file", "release-notes/v1.2.3.md"})
+	require.NoError(t, err)
+	expected, _ := publish.NewCmdPublish()
+	assert.Equal(t, expected.Use, found.Use, "publish should not resolve to an alias of another command")
+	assert.Equal(t, expected.Short, found.Short)
+	assert.NotNil(t, found.Flags().Lookup("from-file"))
+	assert.NotNil(t, found.Flags().Lookup("notes-file"), "the --notes-file alias should be kept")
+
+	found, _, err = root.Find([]string{"changelog"})
+	require.NoError(t, err)
+	assert.Equal(t, "create", found.Name())
+}
@@ -0,0 +1,304 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/metrics"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+const (
+	// ChangelogAPIPath the path of the endpoint returning the changelog of a tag as '/changelog/{owner}/{repo}/{tag}'
+	ChangelogAPIPath = "/changelog/"
+
+	// PreviewAPIPath the path of the endpoint returning the changelog of unreleased commits
+	PreviewAPIPath = "/preview"
+
+	markdownContentType = "text/markdown; charset=utf-8"
+)
+
+// shaRegex matches a full git SHA which unlike a branch always has the same changelog
+var shaRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// ChangelogResponse the changelog returned by the REST API
+type ChangelogResponse struct {
+	// Repository the 'owner/name' of the repository
+	Repository string `json:"repository"`
+
+	// Tag the tag of the release
+	Tag string `json:"tag,omitempty"`
+
+	// PullRequest the number of the previewed pull request
+	PullRequest int `json:"pullRequest,omitempty"`
+
+	// Head the SHA of the previewed commits
+	Head string `json:"head,omitempty"`
+
+	// PreviousTag the tag the changes are since. Empty if the changelog contains the whole history
+	PreviousTag string `json:"previousTag,omitempty"`
+
+	// Markdown the changelog as markdown. Empty if there are no changes
+	Markdown string `json:"markdown"`
+}
+
+// PreviewRequest the body of a preview request. Either the pull request or the head should be specified
+type PreviewRequest struct {
+	// Repository the 'owner/name' of the repository
+	Repository string `json:"repository"`
+
+	// PullRequest the number of the pull request to preview
+	PullRequest int `json:"pullRequest,omitempty"`
+
+	// Head the branch or SHA to preview
+	Head string `json:"head,omitempty"`
+
+	// Base the revision the changes are since. Defaults to the latest tag reachable from the head
+	Base string `json:"base,omitempty"`
+}
+
+// responseCache caches the responses of the REST API for a period so that portals requesting the same release notes
+// do not clone the repository every time
+type responseCache struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	size    int
+	keys    []string
+	entries map[string]cacheEntry
+	shared  cache.Cache
+}
+
+type cacheEntry struct {
+	response *ChangelogResponse
+	expires  time.Time
+}
+
+func newResponseCache(ttl time.Duration, size int) *responseCache {
+	return &responseCache{ttl: ttl, size: size, entries: map[string]cacheEntry{}}
+}
+
+// get returns the cached response falling back to the shared cache of the replicas if there is one
+func (c *responseCache) get(key string) *ChangelogResponse {
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	c.lock.Unlock()
+	if ok && !time.Now().After(e.expires) {
+		return e.response
+	}
+	if c.ttl <= 0 || c.size <= 0 {
+		return nil
+	}
+	response := &ChangelogResponse{}
+	if !cache.GetJSON(c.shared, sharedResponsePrefix+key, response) {
+		return nil
+	}
+	c.add(key, response)
+	return response
+}
+
+// put caches the response and adds it to the shared cache if there is one
+func (c *responseCache) put(key string, response *ChangelogResponse) {
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+	c.add(key, response)
+	cache.SetJSON(c.shared, sharedResponsePrefix+key, response)
+}
+
+// add caches the response evicting the oldest entries beyond the size of the cache
+func (c *responseCache) add(key string, response *ChangelogResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.keys = append(c.keys, key)
+	}
+	c.entries[key] = cacheEntry{response: response, expires: time.Now().Add(c.ttl)}
+	for len(c.keys) > c.size {
+		delete(c.entries, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+}
+
+// authorized returns true if the request has the bearer token of the API or anonymous requests are allowed
+func (o *Options) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if o.APIToken == "" {
+		if !o.APIAnonymous {
+			http.Error(w, "the API requires a token", http.StatusUnauthorized)
+		}
+		return o.APIAnonymous
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(o.APIToken)) != 1 {
+		http.Error(w, "invalid API token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleChangelog returns the changelog of the commits of a tag since the previous tag
+func (o *Options) handleChangelog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "changelogs should be requested with GET", http.StatusMethodNotAllowed)
+		return
+	}
+	if !o.authorized(w, r) {
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, ChangelogAPIPath), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		http.Error(w, "the path should be "+ChangelogAPIPath+"{owner}/{repo}/{tag}", http.StatusNotFound)
+		return
+	}
+	fullName := scm.Join(parts[0], parts[1])
+	tag := parts[2]
+	if !validRevision(tag) {
+		http.Error(w, "invalid tag "+tag, http.StatusBadRequest)
+		return
+	}
+	key := fullName + "@" + tag
+	response := o.cache.get(key)
+	if response == nil {
+		repo, t, ok := o.findRepository(w, fullName)
+		if !ok {
+			return
+		}
+		begin := time.Now()
+		dir, err := o.clone(t, repo)
+		if err != nil {
+			o.apiError(w, fullName, begin, err)
+			return
+		}
+		defer os.RemoveAll(dir)
+		_, err = o.GitClient.Command(dir, "rev-parse", "--verify", tag+"^{commit}")
+		if err != nil {
+			http.Error(w, "tag "+tag+" not found in "+fullName, http.StatusNotFound)
+			return
+		}
+		response = &ChangelogResponse{Repository: fullName, Tag: tag, PreviousTag: o.previousTag(dir, tag+"^")}
+		response.Markdown, err = o.renderMarkdown(t, repo, dir, response.PreviousTag, tag, strings.TrimPrefix(tag, "v"))
+		if err != nil {
+			o.apiError(w, fullName, begin, err)
+			return
+		}
+		metrics.ObserveGeneration(metrics.KindAPI, begin, nil)
+		o.cache.put(key, response)
+	}
+	writeResponse(w, r, response)
+}
+
+// handlePreview returns the changelog of the commits of a pull request or branch since the latest tag
+func (o *Options) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "previews should be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	if !o.authorized(w, r) {
+		return
+	}
+	req := &PreviewRequest{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		http.Error(w, "failed to parse the preview request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Repository == "" || (req.PullRequest <= 0 && req.Head == "") {
+		http.Error(w, "the preview request should specify the repository and the pullRequest or head", http.StatusBadRequest)
+		return
+	}
+	if (req.Head != "" && !validRevision(req.Head)) || (req.Base != "" && !validRevision(req.Base)) {
+		http.Error(w, "the head and base of the preview request should be a SHA or a branch or tag name", http.StatusBadRequest)
+		return
+	}
+	repo, t, ok := o.findRepository(w, req.Repository)
+	if !ok {
+		return
+	}
+	head := req.Head
+	if req.PullRequest > 0 {
+		pr, _, err := t.scmClient.PullRequests.Find(o.GetContext(), repo.FullName, req.PullRequest)
+		if err != nil {
+			http.Error(w, "pull request not found", http.StatusNotFound)
+			return
+		}
+		head = pr.Head.Sha
+	}
+	key := ""
+	if shaRegex.MatchString(head) {
+		key = repo.FullName + "@" + req.Base + ".." + head
+		if response := o.cache.get(key); response != nil {
+			writeResponse(w, r, response)
+			return
+		}
+	}
+	begin := time.Now()
+	dir, err := o.clone(t, repo)
+	if err != nil {
+		o.apiError(w, repo.FullName, begin, err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	sha, err := o.fetchRevision(dir, head)
+	if err != nil {
+		http.Error(w, "head "+head+" not found in "+repo.FullName, http.StatusNotFound)
+		return
+	}
+	response := &ChangelogResponse{Repository: repo.FullName, PullRequest: req.PullRequest, Head: sha, PreviousTag: req.Base}
+	if response.PreviousTag == "" {
+		response.PreviousTag = o.previousTag(dir, sha)
+	}
+	response.Markdown, err = o.renderMarkdown(t, repo, dir, response.PreviousTag, sha, previewVersion)
+	if err != nil {
+		o.apiError(w, repo.FullName, begin, err)
+		return
+	}
+	metrics.ObserveGeneration(metrics.KindAPI, begin, nil)
+	if key != "" {
+		o.cache.put(key, response)
+	}
+	writeResponse(w, r, response)
+}
+
+// findRepository finds the repository of the '--git-server' using the credentials of its owner writing a not found
+// response if it does not exist
+func (o *Options) findRepository(w http.ResponseWriter, fullName string) (*scm.Repository, *tenant, bool) {
+	owner, name := scm.Split(fullName)
+	t, err := o.tenantOf(&scm.Repository{Namespace: owner, Name: name, FullName: fullName, Link: o.GitServerURL}, 0)
+	if err != nil {
+		o.apiError(w, fullName, time.Now(), err)
+		return nil, nil, false
+	}
+	repo, _, err := t.scmClient.Repositories.Find(o.GetContext(), fullName)
+	if err != nil {
+		log.Logger().Debugf("failed to find repository %s: %s", fullName, err.Error())
+		http.Error(w, "repository "+fullName+" not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return repo, t, true
+}
+
+// apiError records the failed generation which began at the given time and writes the error response
+func (o *Options) apiError(w http.ResponseWriter, fullName string, begin time.Time, err error) {
+	metrics.ObserveGeneration(metrics.KindAPI, begin, err)
+	log.Logger().Errorf("failed to generate the changelog of %s: %s", fullName, err.Error())
+	http.Error(w, "failed to generate the changelog of "+fullName, http.StatusInternalServerError)
+}
+
+// writeResponse writes the markdown of the changelog if requested via '?format=markdown' or an 'Accept: text/markdown'
+// header otherwise the JSON response
+func writeResponse(w http.ResponseWriter, r *http.Request, response *ChangelogResponse) {
+	if r.URL.Query().Get("format") == "markdown" || strings.Contains(r.Header.Get("Accept"), "text/markdown") {
+		w.Header().Set("Content-Type", markdownContentType)
+		w.Write([]byte(response.Markdown)) //nolint:errcheck
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response) //nolint:errcheck
+}
@@ -0,0 +1,62 @@
+package serve
+
+import (
+	"os"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SharedCacheNone does not share the users, issues and responses between the replicas of the service
+	SharedCacheNone = "none"
+
+	// SharedCacheRedis shares them via a redis server
+	SharedCacheRedis = "redis"
+
+	// SharedCacheConfigMap shares them via a ConfigMap
+	SharedCacheConfigMap = "configmap"
+
+	// SharedCacheSecret shares them via a Secret which is preferable to a ConfigMap for private repositories
+	SharedCacheSecret = "secret"
+
+	// DefaultSharedCacheName the name of the ConfigMap or Secret of the shared cache
+	DefaultSharedCacheName = "jx-changelog-cache"
+
+	// sharedResponsePrefix the prefix of the keys of the responses of the REST API in the shared cache
+	sharedResponsePrefix = "responses/"
+)
+
+// createSharedCache creates the cache of the '--shared-cache' if one is not specified
+func (o *Options) createSharedCache() error {
+	if o.SharedCache != nil {
+		return nil
+	}
+	var err error
+	switch o.SharedCacheKind {
+	case "", SharedCacheNone:
+	case SharedCacheRedis:
+		if o.RedisAddress == "" {
+			return options.MissingOption("redis-address")
+		}
+		if o.RedisPassword == "" {
+			o.RedisPassword = os.Getenv("REDIS_PASSWORD")
+		}
+		o.SharedCache = cache.NewRedisCache(o.RedisAddress, o.RedisPassword, o.RedisDB, o.SharedCacheTTL)
+	case SharedCacheConfigMap, SharedCacheSecret:
+		o.KubeClient, o.Namespace, err = kube.LazyCreateKubeClientAndNamespace(o.KubeClient, o.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the kube client")
+		}
+		if o.SharedCacheKind == SharedCacheSecret {
+			o.SharedCache = cache.NewSecretCache(o.GetContext(), o.KubeClient, o.Namespace, o.SharedCacheName, o.SharedCacheTTL)
+		} else {
+			o.SharedCache = cache.NewConfigMapCache(o.GetContext(), o.KubeClient, o.Namespace, o.SharedCacheName, o.SharedCacheTTL)
+		}
+	default:
+		return options.InvalidOptionf("shared-cache", o.SharedCacheKind, "should be %s, %s, %s or %s", SharedCacheNone, SharedCacheRedis, SharedCacheConfigMap, SharedCacheSecret)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PreviewMarker marks the comment of the changelog preview so that later previews replace it
+	PreviewMarker = "<!-- jx-changelog:preview -->"
+
+	// previewVersion the version of the previewed changelog
+	previewVersion = "unreleased"
+)
+
+// previewCommandRegex matches the comment requesting a changelog preview
+var previewCommandRegex = regexp.MustCompile(`(?m)^/changelog\s+preview\s*$`)
+
+// PluginHelp describes the service to Lighthouse in the format of external plugins
+type PluginHelp struct {
+	Description string
+	Events      []string
+	Commands    []PluginCommand
+}
+
+// PluginCommand a command of a Lighthouse plugin
+type PluginCommand struct {
+	Usage       string
+	Featured    bool
+	Description string
+	Examples    []string
+	WhoCanUse   string
+}
+
+// handleHelp describes the commands of the service to Lighthouse
+func handleHelp(w http.ResponseWriter, r *http.Request) {
+	help := &PluginHelp{
+		Description: "Generates changelogs of releases and previews the changelogs of pull requests",
+		Events:      []string{"push", "release", "issue_comment"},
+		Commands: []PluginCommand{
+			{
+				Usage:       "/changelog preview",
+				Featured:    true,
+				Description: "Comments the changelog of the commits of the pull request since the latest tag",
+				Examples:    []string{"/changelog preview"},
+				WhoCanUse:   "Anyone",
+			},
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(help) //nolint:errcheck
+}
+
+// preview comments the changelog of the commits of the pull request since the latest tag replacing any previous
+// preview comment
+func (o *Options) preview(event *Event) error {
+	ctx := o.GetContext()
+	repo := &event.Repository
+	t, err := o.tenantOf(repo, event.InstallationID)
+	if err != nil {
+		return err
+	}
+	pr, _, err := t.scmClient.PullRequests.Find(ctx, repo.FullName, event.PullRequest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find pull request %s#%d", repo.FullName, event.PullRequest)
+	}
+	dir, err := o.clone(t, repo)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	head, err := o.fetchRevision(dir, pr.Head.Sha)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch the head of pull request %s#%d", repo.FullName, event.PullRequest)
+	}
+	previousTag := o.previousTag(dir, head)
+	markdown, err := o.renderMarkdown(t, repo, dir, previousTag, head, previewVersion)
+	if err != nil {
+		return err
+	}
+	since := previousTag
+	if since == "" {
+		since = "the first commit"
+	}
+	if markdown == "" {
+		markdown = fmt.Sprintf("No changes since %s", since)
+	}
+	body := fmt.Sprintf("%s\n### Changelog Preview\n\nThe changes of this pull request since %s:\n\n%s\n", PreviewMarker, since, markdown)
+	return o.commentPreview(t.scmClient, repo.FullName, event.PullRequest, body)
+}
+
+// validRevision returns true if the revision is a full SHA or a valid branch or tag name which git cannot mistake for
+// an option or a refspec. It follows the rules of 'git check-ref-format --branch'
+func validRevision(rev string) bool {
+	if shaRegex.MatchString(rev) {
+		return true
+	}
+	if rev == "" || rev == "@" || strings.HasPrefix(rev, "-") || strings.HasSuffix(rev, "/") || strings.HasSuffix(rev, ".") ||
+		strings.Contains(rev, "..") || strings.Contains(rev, "@{") || strings.Contains(rev, "//") {
+		return false
+	}
+	for _, r := range rev {
+		if r < ' ' || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, part := range strings.Split(rev, "/") {
+		if strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchRevision fetches the branch or SHA from the remote of the clone and returns its SHA
+func (o *Options) fetchRevision(dir, rev string) (string, error) {
+	if !validRevision(rev) {
+		return "", errors.Errorf("invalid revision %q", rev)
+	}
+	_, err := o.GitClient.Command(dir, "fetch", "origin", "--", rev)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to fetch %s", rev)
+	}
+	sha, err := o.GitClient.Command(dir, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve the fetched %s", rev)
+	}
+	return strings.TrimSpace(sha), nil
+}
+
+// previousTag returns the latest tag reachable from the revision or an empty string if there is none
+func (o *Options) previousTag(dir, rev string) string {
+	tag, err := o.GitClient.Command(dir, "describe", "--tags", "--abbrev=0", rev)
+	if err != nil {
+		log.Logger().Debugf("no tag found before %s: %s", rev, err.Error())
+		return ""
+	}
+	return strings.TrimSpace(tag)
+}
+
+// renderMarkdown renders the markdown changelog of the commits of the clone between the revisions without publishing
+// it. Returns an empty string if there are no changes
+func (o *Options) renderMarkdown(t *tenant, repo *scm.Repository, dir, previousRev, currentRev, version string) (string, error) {
+	ctx := o.GetContext()
+	g, err := o.newGenerator(t, repo, dir)
+	if err != nil {
+		return "", err
+	}
+	g.PreviousRevision = previousRev
+	g.CurrentRevision = currentRev
+	g.FirstRelease = previousRev == ""
+	g.Version = version
+	g.Format = changelog.RendererMarkdown
+	g.FormatOptions = nil
+	g.UpdateRelease = false
+	g.GenerateReleaseYaml = false
+	g.GenerateCRD = false
+	g.ExportEnvFile = ""
+	g.CalendarFile = ""
+	g.SiteDir = ""
+	g.SiteBranch = ""
+	g.PublishWiki = false
+	g.OutputMarkdownFile = ""
+	err = g.Validate()
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid changelog configuration of %s", repo.FullName)
+	}
+	rng, err := g.ResolveRange(ctx)
+	if err != nil || rng == nil {
+		return "", err
+	}
+	result, err := g.Collect(ctx, rng)
+	if err != nil || result == nil {
+		return "", err
+	}
+	err = g.Render(ctx, result)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Markdown), nil
+}
+
+// commentPreview edits the previous preview comment of the pull request or creates one
+func (o *Options) commentPreview(scmClient *scm.Client, fullName string, number int, body string) error {
+	ctx := o.GetContext()
+	input := &scm.CommentInput{Body: body}
+	comments, _, err := scmClient.PullRequests.ListComments(ctx, fullName, number, scm.ListOptions{Size: 100})
+	if err != nil {
+		log.Logger().Debugf("failed to list the comments of pull request %s#%d: %s", fullName, number, err.Error())
+	}
+	for _, c := range comments {
+		if !strings.Contains(c.Body, PreviewMarker) {
+			continue
+		}
+		_, _, err = scmClient.PullRequests.EditComment(ctx, fullName, number, c.ID, input)
+		if err == nil {
+			log.Logger().Infof("updated the changelog preview of pull request %s#%d", fullName, number)
+			return nil
+		}
+		log.Logger().Debugf("failed to edit comment %d of pull request %s#%d: %s", c.ID, fullName, number, err.Error())
+		break
+	}
+	_, _, err = scmClient.PullRequests.CreateComment(ctx, fullName, number, input)
+	if err != nil {
+		return errors.Wrapf(err, "failed to comment on pull request %s#%d", fullName, number)
+	}
+	log.Logger().Infof("commented the changelog preview on pull request %s#%d", fullName, number)
+	return nil
+}
@@ -0,0 +1,149 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// ReplayPath the path of the admin endpoint listing and replaying the processed events
+const ReplayPath = "/replay"
+
+// ReplayRequest the events to replay. Recorded events are replayed by their delivery IDs or if they failed and
+// missed events by their repository and tag
+type ReplayRequest struct {
+	// IDs the delivery IDs of the recorded events to replay
+	IDs []string `json:"ids,omitempty"`
+
+	// Failed replays the recorded events whose latest generation failed
+	Failed bool `json:"failed,omitempty"`
+
+	// Repository the 'owner/name' of the repository of a missed release
+	Repository string `json:"repository,omitempty"`
+
+	// Tag the tag of the missed release. Defaults to the latest tag
+	Tag string `json:"tag,omitempty"`
+}
+
+// ReplayResponse the replayed events
+type ReplayResponse struct {
+	Queued []string `json:"queued"`
+}
+
+// handleReplay lists the processed events on GET and queues the requested events again on POST. It requires the
+// API token as replayed events are published with the git token
+func (o *Options) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if o.APIToken == "" {
+		http.Error(w, "the replay endpoint requires an --api-token", http.StatusForbidden)
+		return
+	}
+	if !o.authorized(w, r) {
+		return
+	}
+	records, err := o.Store.List()
+	if err != nil {
+		log.Logger().Errorf("failed to list the processed events: %s", err.Error())
+		http.Error(w, "failed to list the processed events", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("failed") == "true" {
+			records = latestFailures(records)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(records) //nolint:errcheck
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "events should be replayed with POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &ReplayRequest{}
+	err = json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		http.Error(w, "failed to parse the replay request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var events []*Event
+	if req.Repository != "" {
+		repo, _, ok := o.findRepository(w, req.Repository)
+		if !ok {
+			return
+		}
+		events = append(events, &Event{Repository: *repo, Tag: req.Tag})
+	}
+	if len(req.IDs) > 0 {
+		ids := map[string]bool{}
+		for _, id := range req.IDs {
+			ids[id] = true
+		}
+		for _, record := range latestRecords(records) {
+			if ids[record.Event.ID] {
+				e := record.Event
+				events = append(events, &e)
+			}
+		}
+	}
+	if req.Failed {
+		for _, record := range latestFailures(records) {
+			e := record.Event
+			events = append(events, &e)
+		}
+	}
+	if len(events) == 0 {
+		http.Error(w, "no events to replay", http.StatusNotFound)
+		return
+	}
+	response := &ReplayResponse{}
+	for _, e := range events {
+		e.Replay = true
+		if !o.enqueue(w, e) {
+			return
+		}
+		log.Logger().Infof("replaying the event of %s", e.String())
+		response.Queued = append(response.Queued, e.String())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response) //nolint:errcheck
+}
+
+// recordKey identifies the recorded events of the same webhook or release
+func recordKey(e *Event) string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return e.String()
+}
+
+// latestRecords returns the latest record of each event in the order they were first processed
+func latestRecords(records []EventRecord) []EventRecord {
+	var keys []string
+	latest := map[string]EventRecord{}
+	for _, record := range records {
+		key := recordKey(&record.Event)
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = record
+	}
+	answer := make([]EventRecord, 0, len(keys))
+	for _, key := range keys {
+		answer = append(answer, latest[key])
+	}
+	return answer
+}
+
+// latestFailures returns the events whose latest generation failed
+func latestFailures(records []EventRecord) []EventRecord {
+	answer := []EventRecord{}
+	for _, record := range latestRecords(records) {
+		if record.Error != "" {
+			answer = append(answer, record)
+		}
+	}
+	return answer
+}
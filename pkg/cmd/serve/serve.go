@@ -0,0 +1,546 @@
+package serve
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/create"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/credentials"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/httpclient"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/metrics"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+const tagRefPrefix = "refs/tags/"
+
+// Event a webhook event requiring the changelog of a release of a repository
+type Event struct {
+	// ID the delivery ID of the webhook used to ignore retried webhooks. Empty if the git provider does not send one
+	ID string `json:"id,omitempty"`
+
+	// Repository the repository of the release
+	Repository scm.Repository `json:"repository"`
+
+	// Tag the tag of the release. Empty if the webhook does not include the tag in which case the latest tag is used
+	Tag string `json:"tag,omitempty"`
+
+	// PullRequest the number of the pull request whose changelog is previewed. Zero for releases
+	PullRequest int `json:"pullRequest,omitempty"`
+
+	// InstallationID the GitHub App installation which sent the webhook if any
+	InstallationID int64 `json:"installationID,omitempty"`
+
+	// Replay true if the event is replayed so that it is generated even if it was processed before
+	Replay bool `json:"replay,omitempty"`
+}
+
+// String describes the release or pull request of the event
+func (e *Event) String() string {
+	if e.PullRequest > 0 {
+		return fmt.Sprintf("%s#%d", e.Repository.FullName, e.PullRequest)
+	}
+	if e.Tag == "" {
+		return e.Repository.FullName
+	}
+	return e.Repository.FullName + " " + e.Tag
+}
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	Address         string
+	Path            string
+	HMACToken       string
+	GitServerURL    string
+	GitKind         string
+	GitToken        string
+	GitUsername     string
+	WorkDir         string
+	QueueSize       int
+	APIToken        string
+	APIAnonymous    bool
+	CacheTTL        time.Duration
+	CacheSize       int
+	CredentialsFile string
+	StoreKind       string
+	StoreFile       string
+	StoreConfigMap  string
+	StoreMax        int
+	SharedCacheKind string
+	SharedCacheName string
+	SharedCacheTTL  time.Duration
+	RedisAddress    string
+	RedisPassword   string
+	RedisDB         int
+	Namespace       string
+	Credentials     *credentials.Config
+	HTTP            httpclient.Options
+	Store           EventStore
+	SharedCache     cache.Cache
+	KubeClient      kubernetes.Interface
+	ScmClient       *scm.Client
+	GitClient       gitclient.Interface
+
+	// NewScmClient creates the git provider clients of the credentials of the repositories
+	NewScmClient func(kind, serverURL, token string) (*scm.Client, error)
+
+	// Generate generates and publishes the changelog of the event. Defaults to cloning the repository and running
+	// the same generation as the create command
+	Generate func(event *Event) error
+
+	queue chan *Event
+	cache *responseCache
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Runs a service which generates and publishes changelogs when it receives the webhook events of the git provider
+
+		When a tag is pushed or a release is published the repository is cloned and the changelog of the release is generated using the configuration of the '.jx/changelog.yaml' file in the repository and the environment variables of the service such as ` + "`$JX_CHANGELOG_SKIP_COMMIT_PATTERN`" + `. The changelogs are published to the releases of the git provider so that one central deployment can replace a changelog step in every pipeline
+
+		Webhooks of releases do not include the tag so the latest tag of the repository is used. Each tag is only generated once by the service so that updating the release does not generate it again
+
+		The processed events are recorded in the '--store' so that retried webhooks are ignored after restarts. The 'file' store persists them to the '--store-file' such as one on a persistent volume and the 'configmap' store to the '--store-configmap' ConfigMap. If there is an '--api-token' then 'GET /replay' lists the processed events and posting '{"ids": ["<delivery id>"]}', '{"failed": true}' or '{"repository": "myorg/myrepo", "tag": "v1.2.0"}' to '/replay' generates failed or missed events again
+
+		Commenting '/changelog preview' on a pull request comments the changelog of the commits of the pull request since the latest tag. The service can also be registered as a Lighthouse external plugin using the webhook endpoint which describes the command on the '/help' path of the endpoint
+
+		The REST API lets portals request release notes on demand. 'GET /changelog/{owner}/{repo}/{tag}' returns the changelog of a tag since the previous tag and 'POST /preview' with a JSON body such as '{"repository": "myorg/myrepo", "pullRequest": 7}' or '{"repository": "myorg/myrepo", "head": "main"}' returns the changelog of the unreleased commits. Responses are JSON unless '?format=markdown' or an 'Accept: text/markdown' header is used and are cached for the '--cache-ttl'. The REST API requires the '--api-token' unless '--api-anonymous' allows anonymous requests
+
+		When the service is scaled horizontally the '--shared-cache' shares the users and issues looked up via the git provider along with the responses of the REST API between the replicas so that they do not repeat the same API requests. The 'redis' cache uses the '--redis-address' server and the 'configmap' and 'secret' caches the '--shared-cache-name' ConfigMap or Secret
+
+		The '--credentials' file maps git hosts and owners to git tokens or GitHub App installations so that one service can generate the changelogs of several organisations and git providers. The first credential matching the host and owner of the repository of a webhook is used falling back to the '--git-token':
+
+		  credentials:
+		  - host: github.com
+		    owner: myorg
+		    githubApp:
+		      appID: 1234
+		      privateKeyFile: /secrets/github-app/private-key.pem
+		  - host: gitlab.mycompany.com
+		    kind: gitlab
+		    tokenFile: /secrets/gitlab/token
+		    hmacTokenEnv: GITLAB_HMAC_TOKEN
+
+		Prometheus metrics of the generations, their durations and the errors and rate limit of the git provider API are exposed on '/metrics'
+`)
+
+	cmdExample = templates.Examples(`
+		# listen for webhooks on port 8080
+		jx-changelog serve --git-token $GIT_TOKEN --hmac-token $HMAC_TOKEN
+
+		# request the release notes of a tag from the REST API
+		curl -H "Authorization: Bearer $API_TOKEN" http://localhost:8080/changelog/myorg/myrepo/v1.2.0?format=markdown
+`)
+)
+
+// NewCmdServe creates the command and options
+func NewCmdServe() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "serve",
+		Short:   "Runs a service which generates and publishes changelogs when it receives the webhook events of the git provider",
+		Aliases: []string{"server", "webhook"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Address, "address", "", ":8080", "The address the service listens on")
+	cmd.Flags().StringVarP(&o.Path, "path", "", "/hook", "The path of the webhook endpoint")
+	cmd.Flags().StringVarP(&o.HMACToken, "hmac-token", "", "", "The secret used to verify the signatures of the webhooks. Defaults to the '$HMAC_TOKEN' environment variable")
+	cmd.Flags().StringVarP(&o.GitServerURL, "git-server", "", "https://github.com", "The git server sending the webhooks")
+	cmd.Flags().StringVarP(&o.GitKind, "git-kind", "", "", "The kind of git server to connect to")
+	cmd.Flags().StringVarP(&o.GitToken, "git-token", "", "", "The git token used to clone the repositories and publish the releases. Defaults to the '$GIT_TOKEN' environment variable")
+	cmd.Flags().StringVarP(&o.GitUsername, "git-username", "", "oauth2", "The git user name used with the git token to clone the repositories")
+	cmd.Flags().StringVarP(&o.WorkDir, "work-dir", "", "", "The directory the repositories are cloned into. Defaults to the temporary directory")
+	cmd.Flags().IntVarP(&o.QueueSize, "queue-size", "", 100, "The maximum number of webhook events waiting to be generated. Further events are rejected until the queue drains")
+	cmd.Flags().StringVarP(&o.StoreKind, "store", "", StoreMemory, fmt.Sprintf("Where the processed events are recorded. Values: %s, %s or %s", StoreMemory, StoreFile, StoreConfigMap))
+	cmd.Flags().StringVarP(&o.StoreFile, "store-file", "", "", "The file the processed events are persisted to for the file store")
+	cmd.Flags().StringVarP(&o.StoreConfigMap, "store-configmap", "", DefaultStoreConfigMapName, "The ConfigMap the processed events are persisted to for the configmap store")
+	cmd.Flags().IntVarP(&o.StoreMax, "store-max", "", 1000, "The maximum number of processed events which are recorded")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "The namespace of the ConfigMap of the configmap store. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&o.CredentialsFile, "credentials", "", "", "The file mapping git hosts and owners to git tokens or GitHub App installations")
+	cmd.Flags().StringVarP(&o.APIToken, "api-token", "", "", "The bearer token required by the REST API. Defaults to the '$API_TOKEN' environment variable")
+	cmd.Flags().BoolVarP(&o.APIAnonymous, "api-anonymous", "", false, "Allows anonymous requests to the REST API when there is no '--api-token'. Otherwise the REST API is disabled without a token")
+	cmd.Flags().DurationVarP(&o.CacheTTL, "cache-ttl", "", time.Hour, "How long the responses of the REST API are cached. Zero disables the cache")
+	cmd.Flags().IntVarP(&o.CacheSize, "cache-size", "", 100, "The maximum number of responses of the REST API which are cached")
+	cmd.Flags().StringVarP(&o.SharedCacheKind, "shared-cache", "", SharedCacheNone, fmt.Sprintf("Where the users and issues looked up via the git provider and the responses of the REST API are shared between the replicas of the service so that they do not repeat the same API requests. Values: %s, %s, %s or %s", SharedCacheNone, SharedCacheRedis, SharedCacheConfigMap, SharedCacheSecret))
+	cmd.Flags().StringVarP(&o.SharedCacheName, "shared-cache-name", "", DefaultSharedCacheName, "The ConfigMap or Secret of the configmap or secret shared cache")
+	cmd.Flags().DurationVarP(&o.SharedCacheTTL, "shared-cache-ttl", "", time.Hour, "How long entries are kept in the shared cache. Zero keeps them until they are evicted")
+	cmd.Flags().StringVarP(&o.RedisAddress, "redis-address", "", "", "The address of the redis server of the redis shared cache such as 'redis:6379'")
+	cmd.Flags().StringVarP(&o.RedisPassword, "redis-password", "", "", "The password of the redis server. Defaults to the '$REDIS_PASSWORD' environment variable")
+	cmd.Flags().IntVarP(&o.RedisDB, "redis-db", "", 0, "The database of the redis server")
+
+	o.HTTP.AddFlags(cmd)
+	o.BaseOptions.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options and creates the git provider client
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate base options")
+	}
+	if o.QueueSize <= 0 {
+		return options.InvalidOptionf("queue-size", o.QueueSize, "should be greater than zero")
+	}
+	if o.HMACToken == "" {
+		o.HMACToken = os.Getenv("HMAC_TOKEN")
+	}
+	if o.HMACToken == "" {
+		log.Logger().Warnf("no --hmac-token specified so the signatures of the webhooks are not verified")
+	}
+	if o.APIToken == "" {
+		o.APIToken = os.Getenv("API_TOKEN")
+	}
+	if o.APIToken == "" {
+		if o.APIAnonymous {
+			log.Logger().Warnf("no --api-token specified so anyone who can reach the REST API can request the changelogs of the repositories of the git token and the %s endpoint is disabled", ReplayPath)
+		} else {
+			log.Logger().Warnf("no --api-token specified so the REST API and the %s endpoint are disabled. Use --api-anonymous to allow anonymous requests to the REST API", ReplayPath)
+		}
+	}
+	if o.GitToken == "" {
+		o.GitToken = os.Getenv("GIT_TOKEN")
+	}
+	if o.ScmClient == nil {
+		o.ScmClient, o.GitToken, err = scmhelpers.NewScmClient(o.GitKind, o.GitServerURL, o.GitToken)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the git provider client for %s: try supply --git-token", o.GitServerURL)
+		}
+		o.HTTP.Tune(o.ScmClient)
+		metrics.InstrumentScmClient(o.ScmClient)
+	}
+	if o.Credentials == nil && o.CredentialsFile != "" {
+		o.Credentials, err = credentials.LoadConfig(o.CredentialsFile)
+		if err != nil {
+			return err
+		}
+	}
+	if o.NewScmClient == nil {
+		o.NewScmClient = newScmClient
+	}
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", nil)
+	}
+	if o.Generate == nil {
+		o.Generate = o.generate
+	}
+	err = o.createStore()
+	if err != nil {
+		return err
+	}
+	err = o.createSharedCache()
+	if err != nil {
+		return err
+	}
+	o.queue = make(chan *Event, o.QueueSize)
+	o.cache = newResponseCache(o.CacheTTL, o.CacheSize)
+	o.cache.shared = o.SharedCache
+
+	// lets create the context before the handlers use it concurrently
+	o.GetContext()
+	return nil
+}
+
+// Run runs the service
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	o.Start()
+	log.Logger().Infof("listening for webhooks on %s%s", termcolor.ColorInfo(o.Address), o.Path)
+	return http.ListenAndServe(o.Address, o.Handler())
+}
+
+// Start starts generating the changelogs of the queued events one at a time so that the releases of a repository are
+// published in the order of the webhooks
+func (o *Options) Start() {
+	go func() {
+		for event := range o.queue {
+			kind := metrics.KindRelease
+			if event.PullRequest > 0 {
+				kind = metrics.KindPreview
+			}
+			begin := time.Now()
+			err := o.Generate(event)
+			metrics.ObserveGeneration(kind, begin, err)
+			record := EventRecord{Event: *event, Time: time.Now()}
+			if err != nil {
+				log.Logger().Errorf("failed to generate the changelog of %s: %s", event.String(), err.Error())
+				record.Error = err.Error()
+			}
+			err = o.Store.Add(record)
+			if err != nil {
+				log.Logger().Errorf("failed to record the event of %s: %s", event.String(), err.Error())
+			}
+		}
+	}()
+}
+
+// Handler returns the handler of the webhook endpoint, the REST API, the metrics and the health checks
+func (o *Options) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(o.Path, o.handleWebhook)
+	mux.HandleFunc(strings.TrimSuffix(o.Path, "/")+"/help", handleHelp)
+	// lets not let anonymous requests clone the repositories of the git token unless explicitly allowed
+	if o.APIToken != "" || o.APIAnonymous {
+		mux.HandleFunc(ChangelogAPIPath, o.handleChangelog)
+		mux.HandleFunc(PreviewAPIPath, o.handlePreview)
+	}
+	// lets not let anonymous requests generate and publish releases with the git token
+	if o.APIToken != "" {
+		mux.HandleFunc(ReplayPath, o.handleReplay)
+	}
+	mux.Handle("/metrics", metrics.Default.Handler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK")) //nolint:errcheck
+	})
+	return mux
+}
+
+func (o *Options) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "webhooks should be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	hook, err := o.webhookService(r).Parse(r, o.hmacToken)
+	if err != nil {
+		log.Logger().Warnf("failed to parse webhook: %s", err.Error())
+		http.Error(w, "failed to parse webhook", http.StatusBadRequest)
+		return
+	}
+	event := ToEvent(hook)
+	if event == nil {
+		w.Write([]byte("ignored")) //nolint:errcheck
+		return
+	}
+	event.ID = deliveryID(r)
+	if o.processed(func(e *Event) bool { return event.ID != "" && e.ID == event.ID }) {
+		log.Logger().Infof("ignored the retried webhook %s of %s", event.ID, event.String())
+		w.Write([]byte("already processed")) //nolint:errcheck
+		return
+	}
+	if !o.enqueue(w, event) {
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("queued")) //nolint:errcheck
+}
+
+// enqueue queues the event writing an unavailable response if the queue is full
+func (o *Options) enqueue(w http.ResponseWriter, event *Event) bool {
+	select {
+	case o.queue <- event:
+		return true
+	default:
+		log.Logger().Warnf("rejected the event of %s as the queue is full", event.String())
+		http.Error(w, "too many events waiting to be generated", http.StatusServiceUnavailable)
+		return false
+	}
+}
+
+// deliveryID returns the ID of the delivery of the webhook which is the same when the git provider retries it
+func deliveryID(r *http.Request) string {
+	for _, header := range []string{"X-GitHub-Delivery", "X-Gitea-Delivery", "X-Gogs-Delivery", "X-Gitlab-Event-UUID", "X-Request-UUID"} {
+		if id := r.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// processed returns true if an event matching the filter was processed successfully
+func (o *Options) processed(filter func(e *Event) bool) bool {
+	records, err := o.Store.List()
+	if err != nil {
+		log.Logger().Warnf("failed to list the processed events: %s", err.Error())
+		return false
+	}
+	for i := range records {
+		if records[i].Error == "" && filter(&records[i].Event) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedTag returns true if the changelog of the tag of the repository was generated
+func (o *Options) generatedTag(fullName, tag string) bool {
+	return o.processed(func(e *Event) bool {
+		return e.PullRequest == 0 && e.Repository.FullName == fullName && e.Tag == tag
+	})
+}
+
+// ToEvent returns the changelog event of a tag push, published release or '/changelog preview' pull request comment
+// webhook or nil if the webhook does not require a changelog
+func ToEvent(hook scm.Webhook) *Event {
+	event := toEvent(hook)
+	if event != nil && hook.GetInstallationRef() != nil {
+		event.InstallationID = hook.GetInstallationRef().ID
+	}
+	return event
+}
+
+func toEvent(hook scm.Webhook) *Event {
+	switch h := hook.(type) {
+	case *scm.PushHook:
+		if h.Deleted || !strings.HasPrefix(h.Ref, tagRefPrefix) {
+			return nil
+		}
+		return &Event{Repository: h.Repo, Tag: strings.TrimPrefix(h.Ref, tagRefPrefix)}
+	case *scm.ReleaseHook:
+		// releases updated or deleted by publishing the changelog should not be generated again
+		if h.Action == scm.ActionUpdate || h.Action == scm.ActionEdited || h.Action == scm.ActionDelete {
+			return nil
+		}
+		return &Event{Repository: h.Repo}
+	case *scm.IssueCommentHook:
+		if h.Action != scm.ActionCreate || !h.Issue.PullRequest || !previewCommandRegex.MatchString(h.Comment.Body) {
+			return nil
+		}
+		return &Event{Repository: h.Repo, PullRequest: h.Issue.Number}
+	case *scm.PullRequestCommentHook:
+		if h.Action != scm.ActionCreate || !previewCommandRegex.MatchString(h.Comment.Body) {
+			return nil
+		}
+		return &Event{Repository: h.Repo, PullRequest: h.PullRequest.Number}
+	default:
+		return nil
+	}
+}
+
+// generate clones the repository and generates the changelog of the tag of the event or previews the changelog of
+// the pull request of the event
+func (o *Options) generate(event *Event) error {
+	if event.PullRequest > 0 {
+		return o.preview(event)
+	}
+	repo := &event.Repository
+	if event.Tag != "" && !event.Replay && o.generatedTag(repo.FullName, event.Tag) {
+		log.Logger().Infof("already generated the changelog of %s %s", repo.FullName, event.Tag)
+		return nil
+	}
+	t, err := o.tenantOf(repo, event.InstallationID)
+	if err != nil {
+		return err
+	}
+	dir, err := o.clone(t, repo)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tag := event.Tag
+	if tag == "" {
+		_, tag, err = gits.GetCommitPointedToByLatestTag(o.GitClient, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the latest tag of %s", repo.FullName)
+		}
+		if tag == "" {
+			log.Logger().Infof("no tags found in %s", repo.FullName)
+			return nil
+		}
+	}
+	if event.Tag == "" {
+		if !event.Replay && o.generatedTag(repo.FullName, tag) {
+			log.Logger().Infof("already generated the changelog of %s %s", repo.FullName, tag)
+			return nil
+		}
+		// lets record the tag of the event so that it is not generated again
+		event.Tag = tag
+	}
+
+	g, err := o.newGenerator(t, repo, dir)
+	if err != nil {
+		return err
+	}
+	g.CurrentRevision = tag
+	g.Version = strings.TrimPrefix(tag, "v")
+
+	// there is no chart to commit the Release YAML to so only the release of the git provider is updated
+	g.GenerateReleaseYaml = false
+	g.UpdateRelease = true
+	err = g.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "invalid changelog configuration of %s", repo.FullName)
+	}
+	result, err := g.Generate(o.GetContext())
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		log.Logger().Infof("no changelog to generate for %s %s", repo.FullName, tag)
+		return nil
+	}
+	log.Logger().Infof("generated the changelog of %s %s: %s", repo.FullName, tag, termcolor.ColorInfo(result.Release.Spec.ReleaseNotesURL))
+	return nil
+}
+
+// clone clones the repository using the credentials of the tenant into a new directory which the caller should remove
+func (o *Options) clone(t *tenant, repo *scm.Repository) (string, error) {
+	dir, err := ioutil.TempDir(o.WorkDir, "jx-changelog-")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the directory to clone the repository into")
+	}
+	_, err = gits.CloneWithCredentials(o.GitClient, repo.Clone, t.username, t.token, dir)
+	if err != nil {
+		os.RemoveAll(dir) //nolint:errcheck
+		return "", errors.Wrapf(err, "failed to clone %s", repo.Clone)
+	}
+	return dir, nil
+}
+
+// newGenerator creates the generator of the clone of the repository using the changelog configuration of the
+// repository, the environment variables of the service and the git provider of the tenant
+func (o *Options) newGenerator(t *tenant, repo *scm.Repository, dir string) (*changelog.Generator, error) {
+	cmd, co := create.NewCmdChangelogCreate()
+	err := create.ApplyConfig(cmd.Flags(), dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the changelog configuration of %s", repo.FullName)
+	}
+	g := &co.Generator
+	g.GitClient = o.GitClient
+	g.HTTPClient = o.HTTP.Client()
+	g.SharedCache = o.SharedCache
+	g.ScmFactory = scmhelpers.Options{
+		Dir:                dir,
+		FullRepositoryName: repo.FullName,
+		Owner:              repo.Namespace,
+		Repository:         repo.Name,
+		Branch:             repo.Branch,
+		ScmClient:          t.scmClient,
+		GitServerURL:       t.serverURL,
+		SourceURL:          repo.Link,
+		GitKind:            t.kind,
+		GitToken:           t.token,
+		GitClient:          o.GitClient,
+	}
+	err = g.ScmFactory.Validate()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover the git repository %s", repo.FullName)
+	}
+	return g, nil
+}
@@ -0,0 +1,494 @@
+package serve_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/serve"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/credentials"
+	"github.com/jenkins-x/go-scm/scm"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const repository = `"repository":{"name":"myrepo","full_name":"myorg/myrepo","owner":{"login":"myorg"},"clone_url":"https://github.com/myorg/myrepo.git","html_url":"https://github.com/myorg/myrepo"}`
+
+func TestServeWebhooks(t *testing.T) {
+	events := make(chan *serve.Event, 10)
+	_, o := serve.NewCmdServe()
+	o.HMACToken = "secret"
+	o.ScmClient = github.NewDefault()
+	o.Generate = func(event *serve.Event) error {
+		events <- event
+		return nil
+	}
+	require.NoError(t, o.Validate())
+	o.Start()
+	handler := o.Handler()
+
+	post := func(event, payload, secret string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-GitHub-Delivery", "1234")
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write([]byte(payload)) //nolint:errcheck
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, post("push", `{"ref":"refs/tags/v1.2.0",`+repository+`}`, "secret"))
+	assert.Equal(t, http.StatusOK, post("push", `{"ref":"refs/heads/master",`+repository+`}`, "secret"))
+	assert.Equal(t, http.StatusOK, post("release", `{"action":"edited",`+repository+`}`, "secret"))
+	assert.Equal(t, http.StatusBadRequest, post("push", `{"ref":"refs/tags/v1.3.0",`+repository+`}`, "wrong"))
+	assert.Equal(t, http.StatusAccepted, post("release", `{"action":"published",`+repository+`}`, "secret"))
+	assert.Equal(t, http.StatusOK, post("issue_comment", `{"action":"created","issue":{"number":7,"pull_request":{}},"comment":{"body":"looks good"},`+repository+`}`, "secret"))
+	assert.Equal(t, http.StatusAccepted, post("issue_comment", `{"action":"created","issue":{"number":7,"pull_request":{}},"comment":{"body":"/changelog preview"},`+repository+`}`, "secret"))
+
+	for _, expected := range []string{"myorg/myrepo v1.2.0", "myorg/myrepo", "myorg/myrepo#7"} {
+		select {
+		case e := <-events:
+			assert.Equal(t, "https://github.com/myorg/myrepo.git", e.Repository.Clone)
+			assert.Equal(t, expected, e.String())
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for the webhook event")
+		}
+	}
+	assert.Empty(t, events)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hook/help", nil))
+	assert.Contains(t, w.Body.String(), `"Usage":"/changelog preview"`)
+}
+
+func TestServePreview(t *testing.T) {
+	tmpDir := t.TempDir()
+	remote := filepath.Join(tmpDir, "myrepo.git")
+	g := cli.NewCLIClient("", nil)
+	git := func(dir string, args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	git(tmpDir, "init", "-q", "--bare", remote)
+	workDir := filepath.Join(tmpDir, "work")
+	git(tmpDir, "clone", "-q", remote, workDir)
+	git(workDir, "config", "user.email", "jane@foo.com")
+	git(workDir, "config", "user.name", "Jane Doe")
+	commit := func(message string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "README.md"), []byte(message), 0600))
+		git(workDir, "add", "README.md")
+		git(workDir, "commit", "-q", "-m", message)
+	}
+	commit("feat: initial")
+	git(workDir, "tag", "v1.0.0")
+	git(workDir, "push", "-q", "origin", "HEAD", "--tags")
+	git(workDir, "checkout", "-q", "-b", "feature")
+	commit("feat: the widget")
+	git(workDir, "push", "-q", "origin", "feature")
+	head := git(workDir, "rev-parse", "HEAD")
+
+	scmClient, data := scmfake.NewDefault()
+	data.PullRequests[7] = &scm.PullRequest{
+		Number: 7,
+		Head:   scm.PullRequestBranch{Ref: "feature", Sha: head},
+	}
+	_, o := serve.NewCmdServe()
+	o.ScmClient = scmClient
+	o.GitKind = "fake"
+	o.GitClient = g
+	o.Ctx = context.Background()
+	require.NoError(t, o.Validate())
+
+	event := &serve.Event{
+		Repository:  scm.Repository{Namespace: "myorg", Name: "myrepo", FullName: "myorg/myrepo", Clone: remote, Link: "https://github.com/myorg/myrepo"},
+		PullRequest: 7,
+	}
+	require.NoError(t, o.Generate(event))
+	comments := data.PullRequestComments[7]
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0].Body, serve.PreviewMarker)
+	assert.Contains(t, comments[0].Body, "since v1.0.0")
+	assert.Contains(t, comments[0].Body, "the widget")
+	assert.NotContains(t, comments[0].Body, "initial")
+}
+
+func TestServeAPI(t *testing.T) {
+	tmpDir := t.TempDir()
+	remote := filepath.Join(tmpDir, "myrepo.git")
+	g := cli.NewCLIClient("", nil)
+	git := func(dir string, args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	git(tmpDir, "init", "-q", "--bare", remote)
+	workDir := filepath.Join(tmpDir, "work")
+	git(tmpDir, "clone", "-q", remote, workDir)
+	git(workDir, "config", "user.email", "jane@foo.com")
+	git(workDir, "config", "user.name", "Jane Doe")
+	commit := func(message string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "README.md"), []byte(message), 0600))
+		git(workDir, "add", "README.md")
+		git(workDir, "commit", "-q", "-m", message)
+	}
+	commit("feat: initial")
+	git(workDir, "tag", "v1.0.0")
+	commit("fix: a bug")
+	git(workDir, "tag", "v1.1.0")
+	commit("feat: the next release")
+	git(workDir, "tag", "v1.2.0")
+	git(workDir, "push", "-q", "origin", "HEAD", "--tags")
+	git(workDir, "checkout", "-q", "-b", "feature")
+	commit("feat: the widget")
+	git(workDir, "push", "-q", "origin", "feature")
+
+	scmClient, data := scmfake.NewDefault()
+	data.Repositories = []*scm.Repository{{Namespace: "myorg", Name: "myrepo", FullName: "myorg/myrepo", Clone: remote, Link: "https://github.com/myorg/myrepo"}}
+	sharedCache := cache.NewConfigMapCache(context.Background(), fake.NewSimpleClientset(), "jx", serve.DefaultSharedCacheName, time.Hour)
+	newHandler := func() http.Handler {
+		_, o := serve.NewCmdServe()
+		o.ScmClient = scmClient
+		o.GitKind = "fake"
+		o.GitClient = g
+		o.APIToken = "secret"
+		o.SharedCache = sharedCache
+		o.Ctx = context.Background()
+		require.NoError(t, o.Validate())
+		return o.Handler()
+	}
+	handler := newHandler()
+
+	request := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/changelog/myorg/myrepo/v1.1.0", "", "secret")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	response := &serve.ChangelogResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+	assert.Equal(t, "myorg/myrepo", response.Repository)
+	assert.Equal(t, "v1.0.0", response.PreviousTag)
+	assert.Contains(t, response.Markdown, "a bug")
+	assert.NotContains(t, response.Markdown, "initial")
+	assert.NotContains(t, response.Markdown, "next release")
+
+	// the cached response is returned once the repository is gone
+	require.NoError(t, os.RemoveAll(remote))
+	w = request(http.MethodGet, "/changelog/myorg/myrepo/v1.1.0?format=markdown", "", "secret")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, response.Markdown, w.Body.String())
+
+	// other replicas of the service use the shared cache
+	handler = newHandler()
+	w = request(http.MethodGet, "/changelog/myorg/myrepo/v1.1.0?format=markdown", "", "secret")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, response.Markdown, w.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/changelog/myorg/myrepo/v1.1.0", "", "wrong").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/changelog/myorg/other/v1.1.0", "", "secret").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/changelog/myorg/myrepo", "", "secret").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/preview", `{"repository":"myorg/myrepo"}`, "secret").Code)
+
+	w = request(http.MethodGet, "/metrics", "", "")
+	assert.Contains(t, w.Body.String(), `jx_changelog_generations_total{kind="api",result="success"}`)
+	assert.Contains(t, w.Body.String(), `jx_changelog_generation_duration_seconds_count{kind="api"}`)
+}
+
+func TestServeAPIPreview(t *testing.T) {
+	tmpDir := t.TempDir()
+	remote := filepath.Join(tmpDir, "myrepo.git")
+	g := cli.NewCLIClient("", nil)
+	git := func(dir string, args ...string) string {
+		out, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(out)
+	}
+	git(tmpDir, "init", "-q", "--bare", remote)
+	workDir := filepath.Join(tmpDir, "work")
+	git(tmpDir, "clone", "-q", remote, workDir)
+	git(workDir, "config", "user.email", "jane@foo.com")
+	git(workDir, "config", "user.name", "Jane Doe")
+	commit := func(message string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "README.md"), []byte(message), 0600))
+		git(workDir, "add", "README.md")
+		git(workDir, "commit", "-q", "-m", message)
+	}
+	commit("feat: initial")
+	git(workDir, "tag", "v1.0.0")
+	git(workDir, "push", "-q", "origin", "HEAD", "--tags")
+	git(workDir, "checkout", "-q", "-b", "feature")
+	commit("feat: the widget")
+	git(workDir, "push", "-q", "origin", "feature")
+	head := git(workDir, "rev-parse", "HEAD")
+
+	scmClient, data := scmfake.NewDefault()
+	data.Repositories = []*scm.Repository{{Namespace: "myorg", Name: "myrepo", FullName: "myorg/myrepo", Clone: remote, Link: "https://github.com/myorg/myrepo"}}
+	data.PullRequests[7] = &scm.PullRequest{Number: 7, Head: scm.PullRequestBranch{Ref: "feature", Sha: head}}
+	_, o := serve.NewCmdServe()
+	o.ScmClient = scmClient
+	o.GitKind = "fake"
+	o.GitClient = g
+	o.Ctx = context.Background()
+	o.APIAnonymous = true
+	require.NoError(t, o.Validate())
+	handler := o.Handler()
+
+	for _, body := range []string{`{"repository":"myorg/myrepo","head":"--upload-pack=touch pwned"}`, `{"repository":"myorg/myrepo","head":"feature:refs/heads/main"}`, `{"repository":"myorg/myrepo","head":"feature","base":"-v1.0.0"}`} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "the preview of %s should be rejected", body)
+	}
+
+	for _, body := range []string{`{"repository":"myorg/myrepo","pullRequest":7}`, `{"repository":"myorg/myrepo","head":"feature"}`} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		response := &serve.ChangelogResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+		assert.Equal(t, head, response.Head)
+		assert.Equal(t, "v1.0.0", response.PreviousTag)
+		assert.Contains(t, response.Markdown, "the widget")
+		assert.NotContains(t, response.Markdown, "initial")
+	}
+}
+
+func TestServeCredentials(t *testing.T) {
+	config, err := credentials.ParseConfig(`
+credentials:
+- host: github.com
+  owner: otherorg
+  token: other-token
+  hmacToken: other-secret
+`)
+	require.NoError(t, err)
+
+	events := make(chan *serve.Event, 10)
+	var tokens []string
+	_, o := serve.NewCmdServe()
+	o.HMACToken = "secret"
+	o.ScmClient = github.NewDefault()
+	o.Credentials = config
+	o.Ctx = context.Background()
+	o.NewScmClient = func(kind, serverURL, token string) (*scm.Client, error) {
+		tokens = append(tokens, kind+" "+serverURL+" "+token)
+		client, _ := scmfake.NewDefault()
+		return client, nil
+	}
+	o.Generate = func(event *serve.Event) error {
+		events <- event
+		return nil
+	}
+	o.APIAnonymous = true
+	require.NoError(t, o.Validate())
+	o.Start()
+	handler := o.Handler()
+
+	otherRepository := strings.ReplaceAll(repository, "myorg", "otherorg")
+	post := func(payload, secret string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-GitHub-Delivery", "1234")
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write([]byte(payload)) //nolint:errcheck
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusBadRequest, post(`{"ref":"refs/tags/v1.0.0",`+otherRepository+`}`, "secret"))
+	assert.Equal(t, http.StatusAccepted, post(`{"ref":"refs/tags/v1.0.0","installation":{"id":99},`+otherRepository+`}`, "other-secret"))
+	assert.Equal(t, http.StatusAccepted, post(`{"ref":"refs/tags/v1.0.0",`+repository+`}`, "secret"))
+
+	for _, expected := range []string{"otherorg/myrepo v1.0.0", "myorg/myrepo v1.0.0"} {
+		select {
+		case e := <-events:
+			assert.Equal(t, expected, e.String())
+			if e.Repository.Namespace == "otherorg" {
+				assert.Equal(t, int64(99), e.InstallationID)
+			}
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for the webhook event")
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/changelog/otherorg/myrepo/v1.0.0", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, []string{"github https://github.com other-token"}, tokens)
+}
+
+func TestServeReplay(t *testing.T) {
+	storeFile := filepath.Join(t.TempDir(), "events.json")
+	events := make(chan *serve.Event, 10)
+	failures := map[string]bool{"myorg/myrepo v2.0.0": true}
+	newHandler := func() http.Handler {
+		_, o := serve.NewCmdServe()
+		o.ScmClient = github.NewDefault()
+		o.Ctx = context.Background()
+		o.StoreKind = serve.StoreFile
+		o.StoreFile = storeFile
+		o.APIToken = "secret"
+		o.Generate = func(event *serve.Event) error {
+			events <- event
+			if failures[event.String()] {
+				delete(failures, event.String())
+				return errors.New("the git provider is down")
+			}
+			return nil
+		}
+		require.NoError(t, o.Validate())
+		o.Start()
+		return o.Handler()
+	}
+	handler := newHandler()
+
+	post := func(delivery, tag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(`{"ref":"refs/tags/`+tag+`",`+repository+`}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-GitHub-Delivery", delivery)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	replay := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/replay?failed=true", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	waitFor := func(expected string) {
+		select {
+		case e := <-events:
+			assert.Equal(t, expected, e.String())
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for the event")
+		}
+		// lets wait for the worker to record the event
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/replay", strings.NewReader(`{"repository":"myorg/myrepo"}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "replaying should require the API token")
+
+	assert.Equal(t, http.StatusAccepted, post("a", "v1.0.0").Code)
+	waitFor("myorg/myrepo v1.0.0")
+	assert.Equal(t, "already processed", post("a", "v1.0.0").Body.String())
+	assert.Equal(t, http.StatusAccepted, post("b", "v2.0.0").Code)
+	waitFor("myorg/myrepo v2.0.0")
+
+	var records []serve.EventRecord
+	require.NoError(t, json.Unmarshal(replay(http.MethodGet, "").Body.Bytes(), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, "b", records[0].Event.ID)
+	assert.Equal(t, "the git provider is down", records[0].Error)
+
+	w = replay(http.MethodPost, `{"failed":true}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	waitFor("myorg/myrepo v2.0.0")
+	records = nil
+	require.NoError(t, json.Unmarshal(replay(http.MethodGet, "").Body.Bytes(), &records))
+	assert.Empty(t, records)
+	assert.Equal(t, http.StatusNotFound, replay(http.MethodPost, `{"failed":true}`).Code)
+
+	// the processed events are loaded from the file after a restart
+	handler = newHandler()
+	assert.Equal(t, "already processed", post("a", "v1.0.0").Body.String())
+	assert.Equal(t, "already processed", post("b", "v2.0.0").Body.String())
+	w = replay(http.MethodPost, `{"ids":["a"]}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	waitFor("myorg/myrepo v1.0.0")
+}
+
+func TestConfigMapStore(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewSimpleClientset()
+	store, err := serve.NewConfigMapStore(ctx, kubeClient, "jx", serve.DefaultStoreConfigMapName, 2)
+	require.NoError(t, err)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Add(serve.EventRecord{Event: serve.Event{ID: id, Tag: "v1.0.0"}}))
+	}
+
+	store, err = serve.NewConfigMapStore(ctx, kubeClient, "jx", serve.DefaultStoreConfigMapName, 2)
+	require.NoError(t, err)
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "b", records[0].Event.ID)
+	assert.Equal(t, "c", records[1].Event.ID)
+
+	// lets check the replicas keep the events of each other
+	now := time.Now()
+	other, err := serve.NewConfigMapStore(ctx, kubeClient, "jx", serve.DefaultStoreConfigMapName, 3)
+	require.NoError(t, err)
+	require.NoError(t, store.Add(serve.EventRecord{Event: serve.Event{ID: "d", Tag: "v1.0.0"}, Time: now}))
+	require.NoError(t, other.Add(serve.EventRecord{Event: serve.Event{ID: "e", Tag: "v1.0.0"}, Time: now.Add(time.Second)}))
+	store, err = serve.NewConfigMapStore(ctx, kubeClient, "jx", serve.DefaultStoreConfigMapName, 3)
+	require.NoError(t, err)
+	records, err = store.List()
+	require.NoError(t, err)
+	var ids []string
+	for _, r := range records {
+		ids = append(ids, r.Event.ID)
+	}
+	assert.Equal(t, []string{"c", "d", "e"}, ids)
+}
+
+func TestServeReplayRequiresAPIToken(t *testing.T) {
+	_, o := serve.NewCmdServe()
+	o.ScmClient = github.NewDefault()
+	o.Ctx = context.Background()
+	o.Generate = func(event *serve.Event) error {
+		require.Fail(t, "anonymous replays should not generate releases")
+		return nil
+	}
+	require.NoError(t, o.Validate())
+	o.APIToken = ""
+	w := httptest.NewRecorder()
+	o.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/replay", strings.NewReader(`{"repository":"myorg/myrepo"}`)))
+	assert.NotEqual(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "OK", w.Body.String(), "the replay endpoint should not be registered without an API token")
+}
+
+func TestServeAPIRequiresAPITokenOrAnonymous(t *testing.T) {
+	_, o := serve.NewCmdServe()
+	o.ScmClient = github.NewDefault()
+	o.Ctx = context.Background()
+	require.NoError(t, o.Validate())
+	o.APIToken = ""
+	handler := o.Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/changelog/myorg/myrepo/v1.0.0", nil))
+	assert.Equal(t, "OK", w.Body.String(), "the changelog endpoint should not be registered without an API token")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(`{"repository":"myorg/myrepo","head":"main"}`)))
+	assert.Equal(t, "OK", w.Body.String(), "the preview endpoint should not be registered without an API token")
+}
@@ -0,0 +1,284 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// StoreMemory keeps the processed events in memory so they are forgotten when the service restarts
+	StoreMemory = "memory"
+
+	// StoreFile persists the processed events to a file such as one on a persistent volume
+	StoreFile = "file"
+
+	// StoreConfigMap persists the processed events to a ConfigMap
+	StoreConfigMap = "configmap"
+
+	// DefaultStoreConfigMapName the name of the ConfigMap of the processed events
+	DefaultStoreConfigMapName = "jx-changelog-events"
+
+	// storeConfigMapKey the key of the processed events in the ConfigMap
+	storeConfigMapKey = "events.json"
+)
+
+// EventRecord an event processed by the service
+type EventRecord struct {
+	// Event the processed event
+	Event Event `json:"event"`
+
+	// Time when the event was processed
+	Time time.Time `json:"time"`
+
+	// Error the error generating the changelog of the event. Empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// EventStore records the processed events so that retried webhooks do not generate changelogs twice and failed or
+// missed events can be replayed
+type EventStore interface {
+	// List returns the processed events oldest first
+	List() ([]EventRecord, error)
+
+	// Add records a processed event
+	Add(record EventRecord) error
+}
+
+// storeBackend loads and saves the JSON of the processed events
+type storeBackend interface {
+	load() ([]byte, error)
+
+	// save saves the JSON returned by the function of the JSON currently saved which may have been changed by
+	// another replica since it was loaded
+	save(merge func(existing []byte) ([]byte, error)) error
+}
+
+// recordStore keeps the latest processed events in memory saving them to the backend on every change
+type recordStore struct {
+	lock    sync.Mutex
+	max     int
+	records []EventRecord
+	backend storeBackend
+}
+
+// NewMemoryStore creates a store keeping the latest processed events in memory
+func NewMemoryStore(max int) EventStore {
+	return &recordStore{max: max}
+}
+
+// NewFileStore creates a store persisting the latest processed events to the JSON file
+func NewFileStore(fileName string, max int) (EventStore, error) {
+	return newRecordStore(&fileBackend{fileName: fileName}, max)
+}
+
+// NewConfigMapStore creates a store persisting the latest processed events to the ConfigMap which is created if
+// it does not exist
+func NewConfigMapStore(ctx context.Context, kubeClient kubernetes.Interface, ns, name string, max int) (EventStore, error) {
+	return newRecordStore(&configMapBackend{ctx: ctx, kubeClient: kubeClient, ns: ns, name: name}, max)
+}
+
+// createStore creates the event store of the '--store' if one is not specified
+func (o *Options) createStore() error {
+	if o.Store != nil {
+		return nil
+	}
+	var err error
+	switch o.StoreKind {
+	case "", StoreMemory:
+		o.Store = NewMemoryStore(o.StoreMax)
+	case StoreFile:
+		if o.StoreFile == "" {
+			return options.MissingOption("store-file")
+		}
+		o.Store, err = NewFileStore(o.StoreFile, o.StoreMax)
+	case StoreConfigMap:
+		o.KubeClient, o.Namespace, err = kube.LazyCreateKubeClientAndNamespace(o.KubeClient, o.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the kube client")
+		}
+		o.Store, err = NewConfigMapStore(o.GetContext(), o.KubeClient, o.Namespace, o.StoreConfigMap, o.StoreMax)
+	default:
+		return options.InvalidOptionf("store", o.StoreKind, "should be %s, %s or %s", StoreMemory, StoreFile, StoreConfigMap)
+	}
+	return err
+}
+
+func newRecordStore(backend storeBackend, max int) (*recordStore, error) {
+	s := &recordStore{max: max, backend: backend}
+	data, err := backend.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &s.records)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the processed events")
+		}
+	}
+	return s, nil
+}
+
+func (s *recordStore) List() ([]EventRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]EventRecord(nil), s.records...), nil
+}
+
+// Add records the event removing the oldest events beyond the maximum. The event is added to the events saved in the
+// backend so that the events added by other replicas are kept
+func (s *recordStore) Add(record EventRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.backend == nil {
+		s.records = appendRecord(s.records, record, s.max)
+		return nil
+	}
+	var records []EventRecord
+	err := s.backend.save(func(existing []byte) ([]byte, error) {
+		records = nil
+		if len(existing) > 0 {
+			err := json.Unmarshal(existing, &records)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to parse the processed events")
+			}
+		}
+		records = appendRecord(records, record, s.max)
+		data, err := json.Marshal(records)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal the processed events")
+		}
+		return data, nil
+	})
+	if err != nil {
+		// lets still ignore the retried webhooks of the event received by this replica
+		s.records = appendRecord(s.records, record, s.max)
+		return err
+	}
+	s.records = records
+	return nil
+}
+
+func appendRecord(records []EventRecord, record EventRecord, max int) []EventRecord {
+	records = append(records, record)
+	if max > 0 && len(records) > max {
+		records = append([]EventRecord(nil), records[len(records)-max:]...)
+	}
+	return records
+}
+
+type fileBackend struct {
+	fileName string
+}
+
+func (b *fileBackend) load() ([]byte, error) {
+	data, err := ioutil.ReadFile(b.fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the processed events from %s", b.fileName)
+	}
+	return data, nil
+}
+
+func (b *fileBackend) save(merge func(existing []byte) ([]byte, error)) error {
+	existing, err := b.load()
+	if err != nil {
+		return err
+	}
+	data, err := merge(existing)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(b.fileName), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the directory of %s", b.fileName)
+	}
+	// lets write a temporary file first so that a crash does not lose the previous events
+	tmpFile := b.fileName + ".tmp"
+	err = ioutil.WriteFile(tmpFile, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the processed events to %s", tmpFile)
+	}
+	return os.Rename(tmpFile, b.fileName)
+}
+
+type configMapBackend struct {
+	ctx        context.Context
+	kubeClient kubernetes.Interface
+	ns         string
+	name       string
+}
+
+func (b *configMapBackend) load() ([]byte, error) {
+	cm, err := b.kubeClient.CoreV1().ConfigMaps(b.ns).Get(b.ctx, b.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", b.name, b.ns)
+	}
+	return []byte(cm.Data[storeConfigMapKey]), nil
+}
+
+// save merges the processed events with those saved by other replicas retrying if the ConfigMap is updated
+// concurrently
+func (b *configMapBackend) save(merge func(existing []byte) ([]byte, error)) error {
+	configMaps := b.kubeClient.CoreV1().ConfigMaps(b.ns)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(b.ctx, b.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			data, err := merge(nil)
+			if err != nil {
+				return err
+			}
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: b.name, Namespace: b.ns},
+				Data:       map[string]string{storeConfigMapKey: string(data)},
+			}
+			_, err = configMaps.Create(b.ctx, cm, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// lets retry as another replica created the ConfigMap first
+				return apierrors.NewConflict(corev1.Resource("ConfigMap"), b.name, err)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to create ConfigMap %s in namespace %s", b.name, b.ns)
+			}
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", b.name, b.ns)
+		}
+		data, err := merge([]byte(cm.Data[storeConfigMapKey]))
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[storeConfigMapKey] = string(data)
+		_, err = configMaps.Update(b.ctx, cm, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			return err
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to update ConfigMap %s in namespace %s", b.name, b.ns)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/credentials"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/metrics"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/pkg/errors"
+)
+
+// webhookKindHeaders the headers identifying the kind of git provider sending a webhook
+var webhookKindHeaders = map[string]string{
+	"X-GitHub-Event": "github",
+	"X-Gitlab-Event": "gitlab",
+	"X-Gitea-Event":  "gitea",
+	"X-Gogs-Event":   "gogs",
+}
+
+// tenant the git provider client and credentials used for the repositories of an owner on a git host
+type tenant struct {
+	scmClient *scm.Client
+	serverURL string
+	kind      string
+	username  string
+	token     string
+}
+
+// newScmClient creates the git provider client of a credential
+func newScmClient(kind, serverURL, token string) (*scm.Client, error) {
+	client, _, err := scmhelpers.NewScmClient(kind, serverURL, token)
+	return client, err
+}
+
+// credentialOf returns the credential of the host and owner of the repository or nil to use the default credentials
+func (o *Options) credentialOf(repo *scm.Repository) *credentials.Credential {
+	gitURL := repo.Clone
+	if gitURL == "" {
+		gitURL = repo.Link
+	}
+	owner := repo.Namespace
+	if owner == "" {
+		owner = strings.SplitN(repo.FullName, "/", 2)[0]
+	}
+	return o.Credentials.FindURL(gitURL, owner)
+}
+
+// tenantOf returns the client and credentials of the repository. The installation is the GitHub App installation of
+// the webhook if known
+func (o *Options) tenantOf(repo *scm.Repository, installationID int64) (*tenant, error) {
+	cred := o.credentialOf(repo)
+	if cred == nil {
+		return &tenant{
+			scmClient: o.ScmClient,
+			serverURL: o.GitServerURL,
+			kind:      o.GitKind,
+			username:  o.GitUsername,
+			token:     o.GitToken,
+		}, nil
+	}
+	token, err := cred.GitToken(o.GetContext(), o.HTTP.Client(), installationID, repo.FullName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the git token of %s", repo.FullName)
+	}
+	client, err := o.NewScmClient(cred.Kind, cred.Server, token)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the git provider client for %s", cred.Server)
+	}
+	// lets share the connections of the clients of every event rather than reconnecting for each one
+	o.HTTP.Tune(client)
+	metrics.InstrumentScmClient(client)
+	return &tenant{
+		scmClient: client,
+		serverURL: cred.Server,
+		kind:      cred.Kind,
+		username:  cred.Username,
+		token:     token,
+	}, nil
+}
+
+// hmacToken returns the secret of the webhooks of the repository of the webhook
+func (o *Options) hmacToken(hook scm.Webhook) (string, error) {
+	repo := hook.Repository()
+	cred := o.credentialOf(&repo)
+	if cred != nil && cred.HMACSecret() != "" {
+		return cred.HMACSecret(), nil
+	}
+	return o.HMACToken, nil
+}
+
+// webhookService returns the service parsing the webhooks of the kind of git provider which sent the request
+func (o *Options) webhookService(r *http.Request) scm.WebhookService {
+	for header, kind := range webhookKindHeaders {
+		if r.Header.Get(header) == "" || kind == o.ScmClient.Driver.String() {
+			continue
+		}
+		service, err := factory.NewWebHookService(kind)
+		if err == nil && service != nil {
+			return service
+		}
+	}
+	return o.ScmClient.Webhooks
+}
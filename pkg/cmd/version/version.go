@@ -1,9 +1,17 @@
 package version
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -23,11 +31,31 @@ const (
 	// version can be found - such as if the version property is not properly
 	// included in the go test flags
 	TestVersion = "1.0.0-SNAPSHOT"
+
+	// OutputText prints the build information as text
+	OutputText = "text"
+
+	// OutputJSON prints the build information as JSON for tools asserting the version
+	OutputJSON = "json"
 )
 
+// Info the build information of the binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	BuildUser string `json:"buildUser,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
 // ShowOptions the options for viewing running PRs
 type Options struct {
 	Verbose bool
+	Output  string
+	Short   bool
+	Out     io.Writer
 }
 
 // NewCmdVersion creates a command object for the "version" command
@@ -42,13 +70,42 @@ func NewCmdVersion() (*cobra.Command, *Options) {
 			helper.CheckErr(err)
 		},
 	}
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputText, fmt.Sprintf("The format of the build information. Values: %s or %s", OutputText, OutputJSON))
+	cmd.Flags().BoolVarP(&o.Short, "short", "", false, "Only prints the version")
 	return cmd, o
 }
 
 // Run implements the command
 func (o *Options) Run() error {
-	v := GetVersion()
-	log.Logger().Infof("version: %s", termcolor.ColorInfo(v))
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	info := GetInfo()
+	switch o.Output {
+	case "", OutputText:
+	case OutputJSON:
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the build information")
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	default:
+		return options.InvalidOptionf("output", o.Output, "should be %s or %s", OutputText, OutputJSON)
+	}
+	if o.Short {
+		_, err := fmt.Fprintln(o.Out, info.Version)
+		return err
+	}
+	log.Logger().Infof("version: %s", termcolor.ColorInfo(info.Version))
+	if info.Commit != "" {
+		log.Logger().Infof("commit: %s", termcolor.ColorInfo(info.Commit))
+	}
+	if info.BuildDate != "" {
+		log.Logger().Infof("build date: %s", termcolor.ColorInfo(info.BuildDate))
+	}
+	log.Logger().Infof("go version: %s", termcolor.ColorInfo(info.GoVersion))
+	log.Logger().Infof("platform: %s", termcolor.ColorInfo(info.Platform))
 	return nil
 }
 
@@ -58,3 +115,18 @@ func GetVersion() string {
 	}
 	return TestVersion
 }
+
+// GetInfo returns the build information. The go version is the runtime the binary was built with rather than the
+// GoVersion of the build which is the minimum version of the module
+func GetInfo() *Info {
+	goVersion := runtime.Version()
+	return &Info{
+		Version:   GetVersion(),
+		Commit:    Revision,
+		Branch:    Branch,
+		BuildUser: BuildUser,
+		BuildDate: BuildDate,
+		GoVersion: goVersion,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
@@ -0,0 +1,43 @@
+package version_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	version.Revision = "abc123"
+	version.BuildDate = "20210310-12:00:00"
+	defer func() {
+		version.Revision = ""
+		version.BuildDate = ""
+	}()
+
+	buf := &bytes.Buffer{}
+	_, o := version.NewCmdVersion()
+	o.Out = buf
+	o.Output = version.OutputJSON
+	require.NoError(t, o.Run())
+
+	info := &version.Info{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), info))
+	assert.Equal(t, version.TestVersion, info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "20210310-12:00:00", info.BuildDate)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+
+	buf.Reset()
+	o.Output = version.OutputText
+	o.Short = true
+	require.NoError(t, o.Run())
+	assert.Equal(t, version.TestVersion+"\n", buf.String())
+
+	o.Output = "yaml"
+	assert.Error(t, o.Run())
+}
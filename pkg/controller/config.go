@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"path"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultConfigMapName the name of the ConfigMap containing the configuration of the controller
+	DefaultConfigMapName = "jx-changelog-controller"
+
+	// ConfigMapKey the key of the configuration in the ConfigMap
+	ConfigMapKey = "config.yaml"
+
+	// DefaultChangelogPath the path of the CHANGELOG file in a GitOps repository
+	DefaultChangelogPath = "CHANGELOG.md"
+)
+
+// Config the configuration of how the controller publishes the Release resources
+type Config struct {
+	// Notifications the webhooks notified of each release such as Slack channels or environment dashboards
+	Notifications []Notification `json:"notifications,omitempty"`
+
+	// Changelogs the GitOps repositories whose CHANGELOG files are updated with each release
+	Changelogs []ChangelogRepository `json:"changelogs,omitempty"`
+}
+
+// Notification a webhook posted the changelog of each release
+type Notification struct {
+	// Name the name of the notification used in logs
+	Name string `json:"name,omitempty"`
+
+	// URL the URL of the webhook
+	URL string `json:"url"`
+
+	// Format the format of the payload. Either slack for a Slack message or json for the Release resource
+	Format string `json:"format,omitempty"`
+
+	// Repositories the 'owner/name' patterns of the repositories whose releases are notified. Defaults to all
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+// ChangelogRepository a GitOps repository whose CHANGELOG file is updated with each release
+type ChangelogRepository struct {
+	// URL the git clone URL of the repository
+	URL string `json:"url"`
+
+	// Branch the branch to commit to. Defaults to the default branch of the repository
+	Branch string `json:"branch,omitempty"`
+
+	// Path the path of the CHANGELOG file. Can use the '{{ .Owner }}' and '{{ .Repository }}' of the release.
+	// Defaults to DefaultChangelogPath
+	Path string `json:"path,omitempty"`
+
+	// Repositories the 'owner/name' patterns of the repositories whose releases are added. Defaults to all
+	Repositories []string `json:"repositories,omitempty"`
+}
+
+// ParseConfig parses and validates the YAML configuration
+func ParseConfig(text string) (*Config, error) {
+	config := &Config{}
+	err := yaml.Unmarshal([]byte(text), config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the controller configuration")
+	}
+	for i := range config.Notifications {
+		n := &config.Notifications[i]
+		if n.URL == "" {
+			return nil, options.MissingOption("notifications.url")
+		}
+		if n.Format == "" {
+			n.Format = changelog.RendererSlack
+		}
+		if n.Format != changelog.RendererSlack && n.Format != changelog.RendererJSON {
+			return nil, options.InvalidOptionf("notifications.format", n.Format, "should be %s or %s", changelog.RendererSlack, changelog.RendererJSON)
+		}
+		if n.Name == "" {
+			n.Name = n.URL
+		}
+	}
+	for i := range config.Changelogs {
+		c := &config.Changelogs[i]
+		if c.URL == "" {
+			return nil, options.MissingOption("changelogs.url")
+		}
+		if c.Path == "" {
+			c.Path = DefaultChangelogPath
+		}
+	}
+	return config, nil
+}
+
+// matchesRepository returns true if there are no patterns or the 'owner/name' of the repository matches one of them
+func matchesRepository(patterns []string, fullName string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		matched, err := path.Match(p, fullName)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
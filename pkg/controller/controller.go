@@ -0,0 +1,338 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/metrics"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// ReconciledAnnotation the annotation recording the version of the Release published by the controller so that
+// releases are only published once even if the controller restarts
+const ReconciledAnnotation = "changelog.jenkins-x.io/reconciled"
+
+const (
+	// DefaultCommitterName the name of the committer of the CHANGELOG updates
+	DefaultCommitterName = "jenkins-x-bot"
+
+	// DefaultCommitterEmail the email of the committer of the CHANGELOG updates
+	DefaultCommitterEmail = "jenkins-x@googlegroups.com"
+)
+
+// Controller publishes the changelogs of the Release resources of a namespace to notification webhooks and the
+// CHANGELOG files of GitOps repositories
+type Controller struct {
+	JXClient    versioned.Interface
+	Namespace   string
+	Config      *Config
+	GitClient   gitclient.Interface
+	GitUsername string
+	GitToken    string
+	HTTPClient  *http.Client
+
+	// CommitterName the name of the committer of the CHANGELOG updates. Defaults to DefaultCommitterName
+	CommitterName string
+
+	// CommitterEmail the email of the committer of the CHANGELOG updates. Defaults to DefaultCommitterEmail
+	CommitterEmail string
+
+	// WorkDir the directory the GitOps repositories are cloned into. Defaults to the temporary directory
+	WorkDir string
+}
+
+// Run watches the Release resources reconciling them until the context is done
+func (c *Controller) Run(ctx context.Context, resync time.Duration) error {
+	factory := externalversions.NewSharedInformerFactoryWithOptions(c.JXClient, resync, externalversions.WithNamespace(c.Namespace))
+	informer := factory.Jenkins().V1().Releases()
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	enqueue := func(obj interface{}) {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err == nil {
+			queue.Add(key)
+		}
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(_, obj interface{}) {
+			enqueue(obj)
+		},
+	})
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return errors.Errorf("failed to sync the Release resources of namespace %s", c.Namespace)
+	}
+	log.Logger().Infof("watching the Release resources of namespace %s", c.Namespace)
+
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+	for {
+		item, shutdown := queue.Get()
+		if shutdown {
+			return nil
+		}
+		key := item.(string)
+		err := c.reconcileKey(ctx, informer.Lister().Releases(c.Namespace).Get, key)
+		if err != nil {
+			log.Logger().Warnf("failed to reconcile Release %s: %s", key, err.Error())
+			queue.AddRateLimited(key)
+		} else {
+			queue.Forget(key)
+		}
+		queue.Done(key)
+	}
+}
+
+func (c *Controller) reconcileKey(ctx context.Context, get func(string) (*v1.Release, error), key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	release, err := get(name)
+	if err != nil {
+		// the release has been deleted
+		return nil
+	}
+	return c.Reconcile(ctx, release.DeepCopy())
+}
+
+// Reconcile publishes the changelog of the release if it has not already been published. Failures to notify webhooks
+// are logged so that notifications are not repeated whereas failures to update CHANGELOG files are returned so that
+// they are retried
+func (c *Controller) Reconcile(ctx context.Context, release *v1.Release) error {
+	spec := &release.Spec
+	version := spec.Version
+	if version == "" || strings.Contains(version, "{{") || release.Annotations[ReconciledAnnotation] == version {
+		return nil
+	}
+	fullName := scm.Join(spec.GitOwner, spec.GitRepository)
+	markdown, err := releaseMarkdown(release)
+	if err != nil {
+		return err
+	}
+	for i := range c.Config.Notifications {
+		n := &c.Config.Notifications[i]
+		if !matchesRepository(n.Repositories, fullName) {
+			continue
+		}
+		begin := time.Now()
+		err = c.notify(ctx, n, release, markdown)
+		metrics.ObserveGeneration(metrics.KindNotification, begin, err)
+		if err != nil {
+			log.Logger().Warnf("failed to notify %s of release %s %s: %s", n.Name, fullName, version, err.Error())
+		}
+	}
+	for i := range c.Config.Changelogs {
+		r := &c.Config.Changelogs[i]
+		if !matchesRepository(r.Repositories, fullName) {
+			continue
+		}
+		begin := time.Now()
+		err = c.updateChangelog(r, release, markdown)
+		metrics.ObserveGeneration(metrics.KindChangelogFile, begin, err)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the CHANGELOG of %s", r.URL)
+		}
+	}
+	return c.markReconciled(ctx, release)
+}
+
+// releaseMarkdown returns the markdown changelog of the release
+func releaseMarkdown(release *v1.Release) (string, error) {
+	spec := &release.Spec
+	gitInfo := &giturl.GitRepository{Organisation: spec.GitOwner, Name: spec.GitRepository}
+	if spec.GitHTTPURL != "" {
+		info, err := giturl.ParseGitURL(spec.GitHTTPURL)
+		if err == nil {
+			gitInfo = info
+		}
+	}
+	markdown, err := gits.GenerateMarkdown(spec, gitInfo)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate the changelog of Release %s", release.Name)
+	}
+	return markdown, nil
+}
+
+// releaseHeading returns the markdown heading of the release linking to its release notes
+func releaseHeading(release *v1.Release) string {
+	spec := &release.Spec
+	title := scm.Join(spec.GitOwner, spec.GitRepository) + " " + spec.Version
+	if spec.ReleaseNotesURL != "" {
+		title = "[" + title + "](" + spec.ReleaseNotesURL + ")"
+	}
+	return "## " + title
+}
+
+func (c *Controller) notify(ctx context.Context, n *Notification, release *v1.Release, markdown string) error {
+	var payload interface{} = release
+	if n.Format == changelog.RendererSlack {
+		payload = &changelog.SlackMessage{
+			Text:   changelog.ToSlackMarkdown(releaseHeading(release) + "\n\n" + markdown),
+			Mrkdwn: true,
+		}
+	}
+	err := changelog.PostWebhook(ctx, c.HTTPClient, n.URL, payload)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("notified %s of release %s %s", n.Name, scm.Join(release.Spec.GitOwner, release.Spec.GitRepository), release.Spec.Version)
+	return nil
+}
+
+// updateChangelog adds the release to the top of the CHANGELOG file of the GitOps repository unless it already
+// contains the release
+func (c *Controller) updateChangelog(r *ChangelogRepository, release *v1.Release, markdown string) error {
+	spec := &release.Spec
+	filePath, err := changelogPath(r.Path, spec)
+	if err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir(c.WorkDir, "jx-changelog-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the directory to clone the repository into")
+	}
+	defer os.RemoveAll(dir)
+	_, err = gits.CloneWithCredentials(c.GitClient, r.URL, c.GitUsername, c.GitToken, dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to clone %s", r.URL)
+	}
+	if r.Branch != "" {
+		err = gitclient.Checkout(c.GitClient, dir, r.Branch)
+		if err != nil {
+			return err
+		}
+	}
+
+	file := filepath.Join(dir, filePath)
+	var text string
+	exists, err := files.FileExists(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file exists %s", file)
+	}
+	if exists {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", file)
+		}
+		text = string(data)
+	}
+	heading := releaseHeading(release)
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == heading {
+			return nil
+		}
+	}
+	text = InsertChangelogEntry(text, heading+"\n\n"+strings.TrimSpace(markdown)+"\n")
+	err = os.MkdirAll(filepath.Dir(file), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the directory of %s", file)
+	}
+	err = ioutil.WriteFile(file, []byte(text), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", file)
+	}
+	err = gitclient.Add(c.GitClient, dir, filePath)
+	if err != nil {
+		return err
+	}
+	name := c.CommitterName
+	if name == "" {
+		name = DefaultCommitterName
+	}
+	email := c.CommitterEmail
+	if email == "" {
+		email = DefaultCommitterEmail
+	}
+	_, err = c.GitClient.Command(dir, "-c", "user.name="+name, "-c", "user.email="+email, "commit", "-m", fmt.Sprintf("chore: changelog of %s %s", scm.Join(spec.GitOwner, spec.GitRepository), spec.Version))
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit %s", filePath)
+	}
+	err = gitclient.Push(c.GitClient, dir, "origin", false, "HEAD")
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("added release %s %s to %s in %s", scm.Join(spec.GitOwner, spec.GitRepository), spec.Version, filePath, r.URL)
+	return nil
+}
+
+// changelogPath evaluates the template of the path of the CHANGELOG file for the release
+func changelogPath(pathTemplate string, spec *v1.ReleaseSpec) (string, error) {
+	tmpl, err := template.New("path").Parse(pathTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the CHANGELOG path %s", pathTemplate)
+	}
+	var buf strings.Builder
+	err = tmpl.Execute(&buf, map[string]string{"Owner": spec.GitOwner, "Repository": spec.GitRepository})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate the CHANGELOG path %s", pathTemplate)
+	}
+	return filepath.Clean(buf.String()), nil
+}
+
+// InsertChangelogEntry adds the entry to the top of the CHANGELOG text keeping any leading title of the document
+func InsertChangelogEntry(text, entry string) string {
+	if strings.HasPrefix(text, "# ") {
+		lines := strings.SplitN(text, "\n", 2)
+		rest := ""
+		if len(lines) > 1 {
+			rest = strings.TrimLeft(lines[1], "\n")
+		}
+		if rest == "" {
+			return lines[0] + "\n\n" + entry
+		}
+		return lines[0] + "\n\n" + entry + "\n" + rest
+	}
+	if strings.TrimSpace(text) == "" {
+		return entry
+	}
+	return entry + "\n" + text
+}
+
+// markReconciled records the version of the release as published retrying if the release was modified concurrently
+func (c *Controller) markReconciled(ctx context.Context, release *v1.Release) error {
+	releases := c.JXClient.JenkinsV1().Releases(release.Namespace)
+	name := release.Name
+	version := release.Spec.Version
+	var err error
+	for i := 0; i < 3; i++ {
+		if release.Annotations == nil {
+			release.Annotations = map[string]string{}
+		}
+		release.Annotations[ReconciledAnnotation] = version
+		_, err = releases.Update(ctx, release, metav1.UpdateOptions{})
+		if err == nil {
+			return nil
+		}
+		latest, getErr := releases.Get(ctx, name, metav1.GetOptions{})
+		if getErr != nil {
+			return errors.Wrapf(getErr, "failed to get Release %s", name)
+		}
+		release = latest
+	}
+	return errors.Wrapf(err, "failed to annotate Release %s", name)
+}
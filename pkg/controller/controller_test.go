@@ -0,0 +1,122 @@
+// +build unit
+
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/controller"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	fakejx "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Parallel()
+	config, err := controller.ParseConfig(`notifications:
+- url: https://hooks.slack.com/services/1234
+changelogs:
+- url: https://github.com/myorg/environment-dev.git
+`)
+	require.NoError(t, err)
+	require.Len(t, config.Notifications, 1)
+	assert.Equal(t, changelog.RendererSlack, config.Notifications[0].Format)
+	assert.Equal(t, controller.DefaultChangelogPath, config.Changelogs[0].Path)
+
+	_, err = controller.ParseConfig("notifications:\n- url: http://foo\n  format: html\n")
+	assert.Error(t, err)
+}
+
+func TestInsertChangelogEntry(t *testing.T) {
+	t.Parallel()
+	entry := "## myorg/myrepo 1.1.0\n\n* fix\n"
+	assert.Equal(t, entry, controller.InsertChangelogEntry("", entry))
+	assert.Equal(t, "# Changelog\n\n"+entry+"\n## myorg/myrepo 1.0.0\n", controller.InsertChangelogEntry("# Changelog\n\n## myorg/myrepo 1.0.0\n", entry))
+	assert.Equal(t, entry+"\n## myorg/myrepo 1.0.0\n", controller.InsertChangelogEntry("## myorg/myrepo 1.0.0\n", entry))
+}
+
+func TestReconcile(t *testing.T) {
+	var messages []changelog.SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := changelog.SlackMessage{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		messages = append(messages, msg)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	remote := filepath.Join(tmpDir, "environment.git")
+	g := cli.NewCLIClient("", nil)
+	git := func(dir string, args ...string) {
+		_, err := g.Command(dir, args...)
+		require.NoError(t, err, "git %v", args)
+	}
+	git(tmpDir, "init", "-q", "--bare", remote)
+	workDir := filepath.Join(tmpDir, "work")
+	git(tmpDir, "clone", "-q", remote, workDir)
+	git(workDir, "config", "user.email", "jane@foo.com")
+	git(workDir, "config", "user.name", "Jane Doe")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "README.md"), []byte("# environment\n"), 0600))
+	git(workDir, "add", "README.md")
+	git(workDir, "commit", "-q", "-m", "initial")
+	git(workDir, "push", "-q", "origin", "HEAD")
+
+	release := &v1.Release{
+		ObjectMeta: metav1.ObjectMeta{Name: "myrepo-1.1.0", Namespace: "jx"},
+		Spec: v1.ReleaseSpec{
+			Name:            "myrepo",
+			Version:         "1.1.0",
+			GitOwner:        "myorg",
+			GitRepository:   "myrepo",
+			GitHTTPURL:      "https://github.com/myorg/myrepo",
+			ReleaseNotesURL: "https://github.com/myorg/myrepo/releases/tag/v1.1.0",
+			Commits: []v1.CommitSummary{
+				{Message: "fix: the widget", SHA: "1234567890"},
+			},
+		},
+	}
+	jxClient := fakejx.NewSimpleClientset(release)
+	c := &controller.Controller{
+		JXClient:  jxClient,
+		Namespace: "jx",
+		GitClient: g,
+		Config: &controller.Config{
+			Notifications: []controller.Notification{
+				{Name: "slack", URL: server.URL, Format: changelog.RendererSlack},
+				{Name: "other", URL: server.URL, Format: changelog.RendererSlack, Repositories: []string{"other/*"}},
+			},
+			Changelogs: []controller.ChangelogRepository{
+				{URL: remote, Path: "changelogs/{{ .Repository }}.md"},
+			},
+		},
+	}
+	ctx := context.Background()
+	require.NoError(t, c.Reconcile(ctx, release.DeepCopy()))
+
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0].Text, "*<https://github.com/myorg/myrepo/releases/tag/v1.1.0|myorg/myrepo 1.1.0>*")
+	assert.Contains(t, messages[0].Text, "the widget")
+
+	git(workDir, "pull", "-q", "origin", "HEAD")
+	data, err := ioutil.ReadFile(filepath.Join(workDir, "changelogs", "myrepo.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "## [myorg/myrepo 1.1.0](https://github.com/myorg/myrepo/releases/tag/v1.1.0)\n\n")
+	assert.Contains(t, string(data), "the widget")
+
+	reconciled, err := jxClient.JenkinsV1().Releases("jx").Get(ctx, release.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", reconciled.Annotations[controller.ReconciledAnnotation])
+
+	require.NoError(t, c.Reconcile(ctx, reconciled))
+	assert.Len(t, messages, 1)
+}
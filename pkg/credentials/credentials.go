@@ -0,0 +1,192 @@
+package credentials
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+// DefaultUsername the git user name used with tokens and GitHub App installation tokens
+const DefaultUsername = "oauth2"
+
+// Config the credentials of the git hosts and owners a service generates changelogs for
+type Config struct {
+	// Credentials the credentials in order of precedence. The first one matching the host and owner of a repository is used
+	Credentials []Credential `json:"credentials,omitempty"`
+}
+
+// Credential the credentials of the repositories of a git host and owner
+type Credential struct {
+	// Host the host of the git server such as 'github.com'
+	Host string `json:"host"`
+
+	// Owner the pattern of the owners of the repositories such as 'myorg' or 'team-*'. Defaults to all the owners of the host
+	Owner string `json:"owner,omitempty"`
+
+	// Kind the kind of git provider. Defaults to the kind of well known hosts
+	Kind string `json:"kind,omitempty"`
+
+	// Server the URL of the git server. Defaults to 'https://' and the host
+	Server string `json:"server,omitempty"`
+
+	// Username the git user name used with the token to clone repositories. Defaults to DefaultUsername
+	Username string `json:"username,omitempty"`
+
+	// Token the git token. Use TokenEnv or TokenFile to avoid storing the token in the configuration
+	Token string `json:"token,omitempty"`
+
+	// TokenEnv the environment variable containing the git token
+	TokenEnv string `json:"tokenEnv,omitempty"`
+
+	// TokenFile the file containing the git token such as a mounted Secret
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	// HMACToken the secret verifying the signatures of the webhooks of the repositories. Defaults to the secret of the service
+	HMACToken string `json:"hmacToken,omitempty"`
+
+	// HMACTokenEnv the environment variable containing the secret of the webhooks
+	HMACTokenEnv string `json:"hmacTokenEnv,omitempty"`
+
+	// GitHubApp the GitHub App whose installation tokens are used instead of a git token
+	GitHubApp *GitHubApp `json:"githubApp,omitempty"`
+
+	tokens *installationTokens
+}
+
+// LoadConfig loads and validates the credentials configuration file
+func LoadConfig(fileName string) (*Config, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the credentials file %s", fileName)
+	}
+	config, err := ParseConfig(string(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials file %s", fileName)
+	}
+	return config, nil
+}
+
+// ParseConfig parses and validates the YAML configuration filling in the defaults of the credentials
+func ParseConfig(text string) (*Config, error) {
+	config := &Config{}
+	err := yaml.Unmarshal([]byte(text), config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the credentials configuration")
+	}
+	for i := range config.Credentials {
+		c := &config.Credentials[i]
+		if c.Host == "" {
+			return nil, options.MissingOption("credentials.host")
+		}
+		if c.Server == "" {
+			c.Server = "https://" + c.Host
+		}
+		if c.Kind == "" {
+			c.Kind, err = factory.NewDriverIdentifier().Identify(c.Host)
+			if err != nil {
+				return nil, options.MissingOption("credentials.kind")
+			}
+		}
+		if c.Username == "" {
+			c.Username = DefaultUsername
+		}
+		if c.Owner != "" {
+			_, err = path.Match(c.Owner, "")
+			if err != nil {
+				return nil, options.InvalidOptionf("credentials.owner", c.Owner, "should be a valid pattern")
+			}
+		}
+		if c.GitHubApp != nil {
+			err = c.GitHubApp.validate()
+			if err != nil {
+				return nil, err
+			}
+			c.tokens = &installationTokens{tokens: map[int64]*installationToken{}}
+		} else if c.Token == "" && c.TokenEnv == "" && c.TokenFile == "" {
+			return nil, options.MissingOption("credentials.token")
+		}
+	}
+	return config, nil
+}
+
+// Find returns the first credential of the host and owner or nil if there is none
+func (c *Config) Find(host, owner string) *Credential {
+	if c == nil {
+		return nil
+	}
+	host = strings.ToLower(host)
+	for i := range c.Credentials {
+		cred := &c.Credentials[i]
+		if strings.ToLower(cred.Host) != host {
+			continue
+		}
+		if cred.Owner == "" {
+			return cred
+		}
+		matched, err := path.Match(cred.Owner, owner)
+		if err == nil && matched {
+			return cred
+		}
+	}
+	return nil
+}
+
+// FindURL returns the first credential of the host of the git URL and the owner or nil if there is none
+func (c *Config) FindURL(gitURL, owner string) *Credential {
+	u, err := url.Parse(gitURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	return c.Find(u.Hostname(), owner)
+}
+
+// HMACSecret returns the secret of the webhooks of the credential or an empty string to use the secret of the service
+func (c *Credential) HMACSecret() string {
+	if c.HMACToken != "" {
+		return c.HMACToken
+	}
+	if c.HMACTokenEnv != "" {
+		return os.Getenv(c.HMACTokenEnv)
+	}
+	return ""
+}
+
+// GitToken returns the git token of the credential. GitHub App installation tokens are created for the installation
+// or if it is zero for the installation of the app on the repository and cached until they are about to expire
+func (c *Credential) GitToken(ctx context.Context, httpClient *http.Client, installationID int64, fullName string) (string, error) {
+	if c.GitHubApp != nil {
+		return c.tokens.get(ctx, httpClient, c, installationID, fullName)
+	}
+	if c.Token != "" {
+		return c.Token, nil
+	}
+	if c.TokenEnv != "" {
+		token := os.Getenv(c.TokenEnv)
+		if token == "" {
+			return "", errors.Errorf("no git token in the $%s environment variable of the credential of %s", c.TokenEnv, c.Host)
+		}
+		return token, nil
+	}
+	data, err := ioutil.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load the git token of %s", c.Host)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// installationTokens the cached GitHub App installation tokens of a credential by installation
+type installationTokens struct {
+	lock          sync.Mutex
+	tokens        map[int64]*installationToken
+	installations map[string]int64
+}
@@ -0,0 +1,131 @@
+// +build unit
+
+package credentials_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+	config, err := credentials.ParseConfig(`
+credentials:
+- host: github.com
+  owner: "team-*"
+  token: team-token
+  hmacToken: team-secret
+- host: github.com
+  tokenFile: ` + tokenFile + `
+- host: git.mycompany.com
+  kind: gitlab
+  token: gitlab-token
+`)
+	require.NoError(t, err)
+	require.Len(t, config.Credentials, 3)
+	assert.Equal(t, "github", config.Credentials[0].Kind)
+	assert.Equal(t, "https://github.com", config.Credentials[0].Server)
+	assert.Equal(t, credentials.DefaultUsername, config.Credentials[0].Username)
+
+	c := config.FindURL("https://github.com/team-a/myrepo.git", "team-a")
+	require.NotNil(t, c)
+	assert.Equal(t, "team-secret", c.HMACSecret())
+	token, err := c.GitToken(ctx, nil, 0, "team-a/myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, "team-token", token)
+
+	c = config.Find("GitHub.com", "myorg")
+	require.NotNil(t, c)
+	assert.Empty(t, c.HMACSecret())
+	token, err = c.GitToken(ctx, nil, 0, "myorg/myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", token)
+
+	assert.Equal(t, "gitlab", config.FindURL("https://git.mycompany.com/myorg/myrepo", "myorg").Kind)
+	assert.Nil(t, config.Find("bitbucket.org", "myorg"))
+
+	for _, text := range []string{
+		"credentials:\n- token: abc\n",
+		"credentials:\n- host: github.com\n",
+		"credentials:\n- host: git.mycompany.com\n  token: abc\n",
+		"credentials:\n- host: github.com\n  githubApp:\n    appID: 1\n",
+	} {
+		_, err = credentials.ParseConfig(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestGitHubAppToken(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		require.Len(t, parts, 3)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		assert.Contains(t, string(payload), `"iss":1234`)
+
+		switch r.URL.Path {
+		case "/repos/myorg/myrepo/installation":
+			w.Write([]byte(`{"id":42}`)) //nolint:errcheck
+		case "/app/installations/42/access_tokens":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"token":"installation-token","expires_at":"2999-01-01T00:00:00Z"}`)) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config, err := credentials.ParseConfig(`
+credentials:
+- host: github.com
+  githubApp:
+    appID: 1234
+    apiURL: ` + server.URL + `
+    privateKey: |
+      ` + strings.ReplaceAll(strings.TrimSpace(string(keyPEM)), "\n", "\n      ") + `
+`)
+	require.NoError(t, err)
+	c := config.Find("github.com", "myorg")
+	require.NotNil(t, c)
+
+	for i := 0; i < 2; i++ {
+		token, err := c.GitToken(ctx, nil, 0, "myorg/myrepo")
+		require.NoError(t, err)
+		assert.Equal(t, "installation-token", token)
+	}
+	assert.Equal(t, []string{"GET /repos/myorg/myrepo/installation", "POST /app/installations/42/access_tokens"}, requests)
+
+	_, err = c.GitToken(ctx, nil, 7, "myorg/myrepo")
+	assert.Error(t, err)
+}
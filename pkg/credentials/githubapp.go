@@ -0,0 +1,193 @@
+package credentials
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+)
+
+const (
+	// gitHubAPIURL the API of github.com
+	gitHubAPIURL = "https://api.github.com"
+
+	// tokenExpiryMargin how long before their expiry installation tokens are renewed so that a changelog being
+	// generated does not use an expired token
+	tokenExpiryMargin = 5 * time.Minute
+)
+
+// GitHubApp a GitHub App whose installation tokens are used to access the repositories it is installed on
+type GitHubApp struct {
+	// AppID the ID of the app
+	AppID int64 `json:"appID"`
+
+	// InstallationID the ID of the installation of the app. Defaults to the installation of the webhook or repository
+	InstallationID int64 `json:"installationID,omitempty"`
+
+	// PrivateKey the PEM encoded private key of the app. Use PrivateKeyFile to avoid storing the key in the configuration
+	PrivateKey string `json:"privateKey,omitempty"`
+
+	// PrivateKeyFile the file containing the PEM encoded private key of the app such as a mounted Secret
+	PrivateKeyFile string `json:"privateKeyFile,omitempty"`
+
+	// APIURL the URL of the GitHub API. Defaults to the API of github.com or the '/api/v3' path of GitHub Enterprise servers
+	APIURL string `json:"apiURL,omitempty"`
+}
+
+type installationToken struct {
+	token   string
+	expires time.Time
+}
+
+func (a *GitHubApp) validate() error {
+	if a.AppID == 0 {
+		return options.MissingOption("credentials.githubApp.appID")
+	}
+	if a.PrivateKey == "" && a.PrivateKeyFile == "" {
+		return options.MissingOption("credentials.githubApp.privateKeyFile")
+	}
+	return nil
+}
+
+// apiURL returns the URL of the GitHub API of the server of the credential
+func (a *GitHubApp) apiURL(server string) string {
+	if a.APIURL != "" {
+		return strings.TrimSuffix(a.APIURL, "/")
+	}
+	server = strings.TrimSuffix(server, "/")
+	if server == "" || server == "https://github.com" {
+		return gitHubAPIURL
+	}
+	return server + "/api/v3"
+}
+
+// JWT returns the JSON Web Token authenticating as the app which is valid for a few minutes
+func (a *GitHubApp) JWT(now time.Time) (string, error) {
+	keyPEM := a.PrivateKey
+	if keyPEM == "" {
+		data, err := ioutil.ReadFile(a.PrivateKeyFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to load the private key of GitHub App %d", a.AppID)
+		}
+		keyPEM = string(data)
+	}
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return "", errors.Errorf("the private key of GitHub App %d is not PEM encoded", a.AppID)
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err2 := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err2 != nil {
+			return "", errors.Wrapf(err, "failed to parse the private key of GitHub App %d", a.AppID)
+		}
+		var ok bool
+		key, ok = parsed.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.Errorf("the private key of GitHub App %d is not an RSA key", a.AppID)
+		}
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	header := encode([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	// lets allow for the clock of GitHub being slightly behind
+	payload := encode([]byte(fmt.Sprintf(`{"iat":%d,"exp":%d,"iss":%d}`, now.Add(-time.Minute).Unix(), now.Add(9*time.Minute).Unix(), a.AppID)))
+	digest := sha256.Sum256([]byte(header + "." + payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to sign the JWT of GitHub App %d", a.AppID)
+	}
+	return header + "." + payload + "." + encode(signature), nil
+}
+
+// get returns the cached installation token of the installation creating one if it is missing or about to expire
+func (t *installationTokens) get(ctx context.Context, httpClient *http.Client, c *Credential, installationID int64, fullName string) (string, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	app := c.GitHubApp
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	jwt := ""
+	var err error
+	if app.InstallationID != 0 {
+		installationID = app.InstallationID
+	}
+	if installationID == 0 {
+		installationID = t.installations[fullName]
+	}
+	if installationID == 0 {
+		if fullName == "" {
+			return "", errors.Errorf("no installation of GitHub App %d specified", app.AppID)
+		}
+		jwt, err = app.JWT(now)
+		if err != nil {
+			return "", err
+		}
+		installation := struct {
+			ID int64 `json:"id"`
+		}{}
+		err = appRequest(ctx, httpClient, http.MethodGet, app.apiURL(c.Server)+"/repos/"+fullName+"/installation", jwt, &installation)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to find the installation of GitHub App %d on %s", app.AppID, fullName)
+		}
+		installationID = installation.ID
+		if t.installations == nil {
+			t.installations = map[string]int64{}
+		}
+		t.installations[fullName] = installationID
+	}
+	cached := t.tokens[installationID]
+	if cached != nil && now.Add(tokenExpiryMargin).Before(cached.expires) {
+		return cached.token, nil
+	}
+	if jwt == "" {
+		jwt, err = app.JWT(now)
+		if err != nil {
+			return "", err
+		}
+	}
+	created := struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	err = appRequest(ctx, httpClient, http.MethodPost, fmt.Sprintf("%s/app/installations/%d/access_tokens", app.apiURL(c.Server), installationID), jwt, &created)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the token of installation %d of GitHub App %d", installationID, app.AppID)
+	}
+	t.tokens[installationID] = &installationToken{token: created.Token, expires: created.ExpiresAt}
+	return created.Token, nil
+}
+
+// appRequest sends a request to the GitHub API authenticated as the app and decodes the JSON response
+func appRequest(ctx context.Context, httpClient *http.Client, method, url, jwt string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the request to %s", url)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send the request to %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("%s returned status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
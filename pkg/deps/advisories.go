@@ -0,0 +1,116 @@
+package deps
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultOSVURL the URL of the query API of the OSV vulnerability database
+const DefaultOSVURL = "https://api.osv.dev/v1/query"
+
+// osvEcosystems the OSV ecosystems of the analyzers whose dependency names match the OSV package names
+var osvEcosystems = map[string]string{
+	AnalyzerGo:     "Go",
+	AnalyzerNpm:    "npm",
+	AnalyzerPython: "PyPI",
+	AnalyzerMaven:  "Maven",
+}
+
+// AdvisoryLookup finds the security advisories fixed by dependency updates
+type AdvisoryLookup interface {
+	// Fixed returns the IDs of the advisories which affect the previous version of the update but not the current one
+	Fixed(u *Update) ([]string, error)
+}
+
+// OSVLookup looks up advisories in the OSV vulnerability database
+type OSVLookup struct {
+	// URL the URL of the query API. Defaults to DefaultOSVURL
+	URL string
+
+	// Client the HTTP client. Defaults to http.DefaultClient
+	Client *http.Client
+}
+
+type osvQuery struct {
+	Version string `json:"version"`
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+}
+
+type osvResponse struct {
+	Vulns []struct {
+		ID string `json:"id"`
+	} `json:"vulns"`
+}
+
+// Fixed returns the advisories fixed by the update. Updates of ecosystems not known to OSV have no advisories
+func (l *OSVLookup) Fixed(u *Update) ([]string, error) {
+	ecosystem := osvEcosystems[u.Ecosystem]
+	if ecosystem == "" || u.From == "" || u.To == "" {
+		return nil, nil
+	}
+	previous, err := l.query(ecosystem, u.Name, u.From)
+	if err != nil {
+		return nil, err
+	}
+	if len(previous) == 0 {
+		return nil, nil
+	}
+	current, err := l.query(ecosystem, u.Name, u.To)
+	if err != nil {
+		return nil, err
+	}
+	remaining := map[string]bool{}
+	for _, id := range current {
+		remaining[id] = true
+	}
+	var answer []string
+	for _, id := range previous {
+		if !remaining[id] {
+			answer = append(answer, id)
+		}
+	}
+	return answer, nil
+}
+
+// query returns the IDs of the advisories affecting the version of the package
+func (l *OSVLookup) query(ecosystem, name, version string) ([]string, error) {
+	q := &osvQuery{Version: version}
+	q.Package.Name = name
+	q.Package.Ecosystem = ecosystem
+	data, err := json.Marshal(q)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the OSV query")
+	}
+	url := l.URL
+	if url == "" {
+		url = DefaultOSVURL
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query the advisories of %s %s", name, version)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to query the advisories of %s %s: status %d", name, version, resp.StatusCode)
+	}
+	result := &osvResponse{}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the advisories of %s %s", name, version)
+	}
+	var answer []string
+	for _, v := range result.Vulns {
+		answer = append(answer, v.ID)
+	}
+	return answer, nil
+}
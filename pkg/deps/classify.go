@@ -0,0 +1,71 @@
+package deps
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// ClassMajor the major version of the dependency changed
+	ClassMajor = "major"
+
+	// ClassMinor the minor version of the dependency changed
+	ClassMinor = "minor"
+
+	// ClassPatch only the patch version or pre-release of the dependency changed
+	ClassPatch = "patch"
+
+	// ClassSecurity the update fixes security advisories of the previous version
+	ClassSecurity = "security"
+)
+
+// Classes the classes of dependency updates in the order of their severity
+var Classes = []string{ClassSecurity, ClassMajor, ClassMinor, ClassPatch}
+
+// Classification the class of a dependency update along with the IDs of the security advisories it fixes
+type Classification struct {
+	Class      string   `json:"class"`
+	Advisories []string `json:"advisories,omitempty"`
+}
+
+// ClassifyVersions returns the semantic version class of the update between the versions ignoring any 'v' prefix.
+// Returns an empty string if the dependency was added or removed or the versions are not semantic versions
+func ClassifyVersions(from, to string) string {
+	f, ok := semverParts(from)
+	if !ok {
+		return ""
+	}
+	t, ok := semverParts(to)
+	if !ok {
+		return ""
+	}
+	switch {
+	case f[0] != t[0]:
+		return ClassMajor
+	case f[1] != t[1]:
+		return ClassMinor
+	default:
+		return ClassPatch
+	}
+}
+
+// semverParts returns the major, minor and patch numbers of the version where the minor and patch are optional
+func semverParts(version string) ([3]int, bool) {
+	var answer [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	parts := strings.Split(version, ".")
+	if version == "" || len(parts) > 3 {
+		return answer, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return answer, false
+		}
+		answer[i] = n
+	}
+	return answer, true
+}
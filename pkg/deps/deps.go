@@ -0,0 +1,195 @@
+package deps
+
+import (
+	"sort"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AnalyzerGo diffs the requirements of go.mod files
+	AnalyzerGo = "go"
+
+	// AnalyzerNpm diffs the dependencies of package.json files
+	AnalyzerNpm = "npm"
+
+	// AnalyzerPython diffs the requirements of requirements.txt files
+	AnalyzerPython = "python"
+
+	// AnalyzerMaven diffs the dependencies of pom.xml files
+	AnalyzerMaven = "maven"
+
+	// AnalyzerHelm diffs the dependencies of helm charts
+	AnalyzerHelm = "helm"
+
+	// AnalyzerImage diffs the base images of Dockerfiles
+	AnalyzerImage = "image"
+
+	// AnalyzerVersionStream diffs the chart and package versions of a Jenkins X version stream
+	AnalyzerVersionStream = "versionstream"
+)
+
+// knownGitHosts the hosts of git providers whose URLs start with the owner and repository
+var knownGitHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// Update a dependency whose version changed between two versions of a manifest file
+type Update struct {
+	// Ecosystem the name of the analyzer such as 'go'
+	Ecosystem string
+
+	// Name the name of the dependency such as the Go module path
+	Name string
+
+	// From the previous version. Empty if the dependency was added
+	From string
+
+	// To the current version. Empty if the dependency was removed
+	To string
+
+	// Indirect true if the dependency is only required by other dependencies
+	Indirect bool
+
+	// Scope the scope of the dependency such as 'indirect', 'dev' or 'test'. Empty for runtime dependencies
+	Scope string
+
+	// URL the URL of the source repository of the dependency if known
+	URL string
+
+	// FromURL the URL of the release of the previous version if known
+	FromURL string
+
+	// ToURL the URL of the release of the current version if known
+	ToURL string
+}
+
+// Analyzer finds the dependency updates between two versions of the manifest files of an ecosystem
+type Analyzer interface {
+	// Title the title of the section of the changelog such as 'Go Module Updates'
+	Title() string
+
+	// Files the paths of the manifest files relative to the root of the repository. Paths may be glob patterns which
+	// are matched against the files of the working directory
+	Files() []string
+
+	// Diff returns the dependency updates between the previous and current contents of the manifest file at the path.
+	// The contents are empty if the file does not exist at the revision
+	Diff(path, previous, current string) ([]Update, error)
+}
+
+var analyzers = map[string]Analyzer{
+	AnalyzerGo:            &GoModAnalyzer{},
+	AnalyzerNpm:           &NpmAnalyzer{},
+	AnalyzerPython:        &PythonAnalyzer{},
+	AnalyzerMaven:         &MavenAnalyzer{},
+	AnalyzerHelm:          &HelmAnalyzer{},
+	AnalyzerImage:         &DockerfileAnalyzer{},
+	AnalyzerVersionStream: &VersionStreamAnalyzer{},
+}
+
+// RegisterAnalyzer registers an analyzer so that it can be enabled by name replacing any existing analyzer of the name
+func RegisterAnalyzer(name string, analyzer Analyzer) {
+	analyzers[name] = analyzer
+}
+
+// AnalyzerNames returns the sorted names of the registered analyzers
+func AnalyzerNames() []string {
+	var answer []string
+	for name := range analyzers {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// NewAnalyzer returns the analyzer of the given name
+func NewAnalyzer(name string) (Analyzer, error) {
+	a := analyzers[name]
+	if a == nil {
+		return nil, errors.Errorf("unknown dependency analyzer %s. Should be one of: %s", name, strings.Join(AnalyzerNames(), ", "))
+	}
+	return a, nil
+}
+
+// Dependency a dependency of a manifest file
+type Dependency struct {
+	Version  string
+	Indirect bool
+	Scope    string
+	URL      string
+}
+
+// DiffDependencies returns the updates of the dependencies whose versions differ sorted by name
+func DiffDependencies(ecosystem string, previous, current map[string]Dependency) []Update {
+	var answer []Update
+	for name, c := range current {
+		p, ok := previous[name]
+		if ok && p.Version == c.Version {
+			continue
+		}
+		answer = append(answer, Update{
+			Ecosystem: ecosystem,
+			Name:      name,
+			From:      p.Version,
+			To:        c.Version,
+			Indirect:  c.Indirect,
+			Scope:     c.Scope,
+			URL:       c.URL,
+		})
+	}
+	for name, p := range previous {
+		if _, ok := current[name]; !ok {
+			answer = append(answer, Update{
+				Ecosystem: ecosystem,
+				Name:      name,
+				From:      p.Version,
+				Indirect:  p.Indirect,
+				Scope:     p.Scope,
+				URL:       p.URL,
+			})
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
+
+// ToDependencyUpdate converts the update to the dependency update of the Release using the URL to find the owner and
+// repository of dependencies hosted on well known git providers
+func ToDependencyUpdate(u *Update) v1.DependencyUpdate {
+	du := v1.DependencyUpdate{
+		DependencyUpdateDetails: v1.DependencyUpdateDetails{
+			Component:          u.Name,
+			URL:                u.URL,
+			FromVersion:        u.From,
+			FromReleaseHTMLURL: u.FromURL,
+			ToVersion:          u.To,
+			ToReleaseHTMLURL:   u.ToURL,
+		},
+	}
+	path := strings.TrimPrefix(strings.TrimPrefix(u.URL, "https://"), "http://")
+	parts := strings.Split(path, "/")
+	if len(parts) >= 3 && isKnownGitHost(parts[0]) {
+		du.Host = parts[0]
+		du.Owner = parts[1]
+		du.Repo = parts[2]
+	}
+	return du
+}
+
+func isKnownGitHost(host string) bool {
+	for _, h := range knownGitHosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// Section the dependency updates of an analyzer. The title groups the updates which are not hosted in a git repository
+type Section struct {
+	Title   string
+	Updates []Update
+}
@@ -0,0 +1,257 @@
+// +build unit
+
+package deps_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoModAnalyzer(t *testing.T) {
+	t.Parallel()
+	previous := `module github.com/jenkins-x-plugins/jx-changelog
+
+go 1.15
+
+require (
+	github.com/pkg/errors v0.8.1
+	github.com/spf13/cobra v1.0.0
+	golang.org/x/text v0.3.2 // indirect
+)
+
+require github.com/stretchr/testify v1.6.1
+`
+	current := `module github.com/jenkins-x-plugins/jx-changelog
+
+go 1.15
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/spf13/cobra v1.0.0
+	golang.org/x/text v0.3.3 // indirect
+	gopkg.in/yaml.v2 v2.3.0
+)
+`
+	analyzer, err := deps.NewAnalyzer(deps.AnalyzerGo)
+	require.NoError(t, err)
+	updates, err := analyzer.Diff("go.mod", previous, current)
+	require.NoError(t, err)
+	require.Len(t, updates, 4)
+
+	assert.Equal(t, deps.Update{Ecosystem: deps.AnalyzerGo, Name: "github.com/pkg/errors", From: "v0.8.1", To: "v0.9.1", URL: "https://github.com/pkg/errors"}, updates[0])
+	assert.Equal(t, "github.com/stretchr/testify", updates[1].Name)
+	assert.Equal(t, "", updates[1].To)
+	assert.Equal(t, "golang.org/x/text", updates[2].Name)
+	assert.True(t, updates[2].Indirect)
+	assert.Equal(t, "", updates[2].URL)
+	assert.Equal(t, "", updates[3].From)
+
+	du := deps.ToDependencyUpdate(&updates[0])
+	assert.Equal(t, "github.com", du.Host)
+	assert.Equal(t, "pkg", du.Owner)
+	assert.Equal(t, "errors", du.Repo)
+	assert.Equal(t, "github.com/pkg/errors", du.Component)
+
+	_, err = deps.NewAnalyzer("cobol")
+	assert.Error(t, err)
+}
+
+func TestNpmAnalyzer(t *testing.T) {
+	t.Parallel()
+	previous := `{"dependencies": {"lodash": "^4.17.15", "react": "16.13.0"}, "devDependencies": {"jest": "25.0.0"}}`
+	current := `{"dependencies": {"lodash": "^4.17.20", "react": "16.13.0"}, "devDependencies": {"jest": "26.0.0"}}`
+	updates, err := (&deps.NpmAnalyzer{}).Diff("package.json", previous, current)
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.Equal(t, deps.Update{Ecosystem: deps.AnalyzerNpm, Name: "jest", From: "25.0.0", To: "26.0.0", Scope: "dev", URL: "https://www.npmjs.com/package/jest"}, updates[0])
+	assert.Equal(t, "^4.17.20", updates[1].To)
+
+	du := deps.ToDependencyUpdate(&updates[0])
+	assert.Equal(t, "", du.Owner)
+	assert.Equal(t, "jest", du.Component)
+
+	_, err = (&deps.NpmAnalyzer{}).Diff("package.json", previous, "{")
+	assert.Error(t, err)
+}
+
+func TestPythonAnalyzer(t *testing.T) {
+	t.Parallel()
+	previous := "# pinned\nrequests==2.24.0\nDjango>=3.0,<3.1\n-r dev.txt\nruamel.yaml==0.16.10 ; python_version > '3'\n"
+	current := "requests[security]==2.25.0\nDjango>=3.1,<3.2\nruamel.yaml==0.16.10\n"
+	updates, err := (&deps.PythonAnalyzer{}).Diff("requirements.txt", previous, current)
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.Equal(t, "django", updates[0].Name)
+	assert.Equal(t, ">=3.0,<3.1", updates[0].From)
+	assert.Equal(t, deps.Update{Ecosystem: deps.AnalyzerPython, Name: "requests", From: "2.24.0", To: "2.25.0", URL: "https://pypi.org/project/requests/"}, updates[1])
+}
+
+func TestMavenAnalyzer(t *testing.T) {
+	t.Parallel()
+	pom := func(jackson, junit string) string {
+		return `<project>
+  <version>1.0.0</version>
+  <properties>
+    <jackson.version>` + jackson + `</jackson.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <version>${jackson.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>` + junit + `</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`
+	}
+	updates, err := (&deps.MavenAnalyzer{}).Diff("pom.xml", pom("2.11.0", "4.12"), pom("2.11.3", "4.13"))
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.Equal(t, deps.Update{Ecosystem: deps.AnalyzerMaven, Name: "com.fasterxml.jackson.core:jackson-databind", From: "2.11.0", To: "2.11.3",
+		URL: "https://search.maven.org/artifact/com.fasterxml.jackson.core/jackson-databind"}, updates[0])
+	assert.Equal(t, "test", updates[1].Scope)
+}
+
+func TestHelmAnalyzer(t *testing.T) {
+	t.Parallel()
+	lock := func(version string) string {
+		return `dependencies:
+- name: postgresql
+  repository: https://charts.bitnami.com/bitnami
+  version: ` + version + `
+- name: common
+  repository: file://../common
+  version: 1.0.0
+`
+	}
+	a := &deps.HelmAnalyzer{}
+	updates, err := a.Diff("charts/myapp/Chart.lock", lock("9.1.0"), lock("9.2.1"))
+	require.NoError(t, err)
+	assert.Equal(t, []deps.Update{{Ecosystem: deps.AnalyzerHelm, Name: "postgresql", From: "9.1.0", To: "9.2.1", URL: "https://charts.bitnami.com/bitnami"}}, updates)
+
+	// version ranges of Chart.yaml files are resolved in the Chart.lock
+	updates, err = a.Diff("charts/myapp/Chart.yaml", "dependencies:\n- name: postgresql\n  version: ~9.1.0\n", "dependencies:\n- name: postgresql\n  version: ~9.2.0\n")
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+}
+
+func TestDockerfileAnalyzer(t *testing.T) {
+	t.Parallel()
+	previous := `ARG GO_VERSION=1.15
+FROM golang:${GO_VERSION} AS builder
+FROM builder AS test
+FROM --platform=linux/amd64 gcr.io/jenkinsxio/jx-boot:3.1.0
+FROM scratch
+`
+	current := `ARG GO_VERSION=1.16
+FROM golang:${GO_VERSION} AS builder
+FROM builder AS test
+FROM --platform=linux/amd64 gcr.io/jenkinsxio/jx-boot@sha256:0123abcd
+FROM bitnami/kubectl
+`
+	updates, err := (&deps.DockerfileAnalyzer{}).Diff("Dockerfile", previous, current)
+	require.NoError(t, err)
+	require.Len(t, updates, 3)
+	assert.Equal(t, deps.Update{Ecosystem: deps.AnalyzerImage, Name: "bitnami/kubectl", To: "latest", URL: "https://hub.docker.com/r/bitnami/kubectl"}, updates[0])
+	assert.Equal(t, "@sha256:0123abcd", updates[1].To)
+	assert.Equal(t, "3.1.0", updates[1].From)
+	assert.Equal(t, "https://gcr.io/jenkinsxio/jx-boot", updates[1].URL)
+	assert.Equal(t, deps.Update{Ecosystem: deps.AnalyzerImage, Name: "golang", From: "1.15", To: "1.16", URL: "https://hub.docker.com/_/golang"}, updates[2])
+}
+
+func TestVersionStreamAnalyzer(t *testing.T) {
+	t.Parallel()
+	a := &deps.VersionStreamAnalyzer{}
+	updates, err := a.Diff("versionStream/charts/jx3/jx-pipelines-visualizer/defaults.yaml",
+		"version: 1.7.1\ngitUrl: https://github.com/jenkins-x/jx-pipelines-visualizer.git\n",
+		"version: 1.7.2\ngitUrl: https://github.com/jenkins-x/jx-pipelines-visualizer.git\n")
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, deps.Update{
+		Ecosystem: deps.AnalyzerVersionStream,
+		Name:      "jx3/jx-pipelines-visualizer",
+		From:      "1.7.1",
+		To:        "1.7.2",
+		Scope:     "chart",
+		URL:       "https://github.com/jenkins-x/jx-pipelines-visualizer",
+		FromURL:   "https://github.com/jenkins-x/jx-pipelines-visualizer/releases/tag/v1.7.1",
+		ToURL:     "https://github.com/jenkins-x/jx-pipelines-visualizer/releases/tag/v1.7.2",
+	}, updates[0])
+
+	updates, err = a.Diff("packages/jx.yml", "", "version: 3.1.0\n")
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "jx", updates[0].Name)
+	assert.Equal(t, "package", updates[0].Scope)
+	assert.Empty(t, updates[0].ToURL)
+
+	updates, err = a.Diff("packages/jx.yml", "version: 3.1.0\n", "version: 3.1.0\ngitUrl: https://github.com/jenkins-x/jx\n")
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+
+	dir := t.TempDir()
+	assert.False(t, deps.IsVersionStream(dir))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "versionStream", "charts"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "versionStream", "packages"), 0755))
+	assert.True(t, deps.IsVersionStream(dir))
+}
+
+func TestClassifyVersions(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		from, to, expected string
+	}{
+		{"v1.2.3", "v2.0.0", deps.ClassMajor},
+		{"1.2.3", "1.3.0", deps.ClassMinor},
+		{"1.2.3", "1.2.4", deps.ClassPatch},
+		{"1.2", "1.2.1-rc.1", deps.ClassPatch},
+		{"", "1.0.0", ""},
+		{"latest", "1.0.0", ""},
+		{"@sha256:0123", "@sha256:4567", ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, deps.ClassifyVersions(tc.from, tc.to), "classify %s to %s", tc.from, tc.to)
+	}
+}
+
+func TestOSVLookup(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch q["version"] {
+		case "4.17.15":
+			w.Write([]byte(`{"vulns":[{"id":"GHSA-p6mc-m468-83gw"},{"id":"GHSA-29mw-wpgm-hmr9"}]}`)) //nolint:errcheck
+		case "4.17.20":
+			w.Write([]byte(`{"vulns":[{"id":"GHSA-29mw-wpgm-hmr9"}]}`)) //nolint:errcheck
+		default:
+			w.Write([]byte(`{}`)) //nolint:errcheck
+		}
+	}))
+	defer server.Close()
+
+	lookup := &deps.OSVLookup{URL: server.URL}
+	fixed, err := lookup.Fixed(&deps.Update{Ecosystem: deps.AnalyzerNpm, Name: "lodash", From: "4.17.15", To: "4.17.20"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GHSA-p6mc-m468-83gw"}, fixed)
+
+	fixed, err = lookup.Fixed(&deps.Update{Ecosystem: deps.AnalyzerImage, Name: "golang", From: "4.17.15", To: "4.17.20"})
+	require.NoError(t, err)
+	assert.Empty(t, fixed)
+}
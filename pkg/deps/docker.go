@@ -0,0 +1,103 @@
+package deps
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// dockerArgRegex matches references to build arguments such as '${GO_VERSION}' or '$GO_VERSION'
+var dockerArgRegex = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+// DockerfileAnalyzer diffs the images of the FROM instructions of Dockerfiles
+type DockerfileAnalyzer struct{}
+
+// Title returns the title of the section
+func (a *DockerfileAnalyzer) Title() string {
+	return "Base Image Updates"
+}
+
+// Files returns the Dockerfiles in the root of the repository and its immediate sub directories
+func (a *DockerfileAnalyzer) Files() []string {
+	return []string{"Dockerfile", "Dockerfile.*", "*/Dockerfile"}
+}
+
+// Diff returns the base images whose tag or digest differ
+func (a *DockerfileAnalyzer) Diff(path, previous, current string) ([]Update, error) {
+	return DiffDependencies(AnalyzerImage, ParseDockerfile(previous), ParseDockerfile(current)), nil
+}
+
+// ParseDockerfile returns the base images of the FROM instructions indexed by image name with the tag and digest as
+// the version. Build stages, scratch and images using undefined build arguments are ignored
+func ParseDockerfile(text string) map[string]Dependency {
+	answer := map[string]Dependency{}
+	args := map[string]string{}
+	stages := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			kv := strings.SplitN(fields[1], "=", 2)
+			if len(kv) == 2 {
+				args[kv[0]] = strings.Trim(kv[1], `"'`)
+			}
+		case "FROM":
+			fields = fields[1:]
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:]
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+				stages[strings.ToLower(fields[2])] = true
+			}
+			image := dockerArgRegex.ReplaceAllStringFunc(fields[0], func(ref string) string {
+				return args[dockerArgRegex.FindStringSubmatch(ref)[1]]
+			})
+			if image == "" || image == "scratch" || stages[strings.ToLower(image)] || strings.Contains(image, "$") {
+				continue
+			}
+			name, version := splitImage(image)
+			if _, ok := answer[name]; !ok {
+				answer[name] = Dependency{Version: version, URL: imageURL(name)}
+			}
+		}
+	}
+	return answer
+}
+
+// splitImage splits the image into its name and its tag and digest defaulting the tag to latest
+func splitImage(image string) (string, string) {
+	name := image
+	digest := ""
+	if idx := strings.Index(name, "@"); idx >= 0 {
+		digest = name[idx:]
+		name = name[:idx]
+	}
+	tag := ""
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		tag = name[idx+1:]
+		name = name[:idx]
+	}
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+	return name, tag + digest
+}
+
+// imageURL returns the web page of the image on Docker Hub or the registry of the image
+func imageURL(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) == 1 {
+		return "https://hub.docker.com/_/" + name
+	}
+	if !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		return "https://hub.docker.com/r/" + name
+	}
+	return "https://" + name
+}
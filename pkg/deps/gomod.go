@@ -0,0 +1,76 @@
+package deps
+
+import (
+	"bufio"
+	"strings"
+)
+
+// GoModAnalyzer diffs the requirements of the go.mod file. The go.sum file is not used as it contains every version
+// of the modules in the build graph rather than the selected versions
+type GoModAnalyzer struct{}
+
+// Title returns the title of the section
+func (a *GoModAnalyzer) Title() string {
+	return "Go Module Updates"
+}
+
+// Files returns the go.mod file
+func (a *GoModAnalyzer) Files() []string {
+	return []string{"go.mod"}
+}
+
+// Diff returns the module requirements whose versions differ
+func (a *GoModAnalyzer) Diff(path, previous, current string) ([]Update, error) {
+	answer := DiffDependencies(AnalyzerGo, ParseGoMod(previous), ParseGoMod(current))
+	for i := range answer {
+		answer[i].URL = goModuleURL(answer[i].Name)
+	}
+	return answer, nil
+}
+
+// ParseGoMod returns the required modules of the go.mod file indexed by module path
+func ParseGoMod(text string) map[string]Dependency {
+	answer := map[string]Dependency{}
+	inRequire := false
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case inRequire && line == ")":
+			inRequire = false
+			continue
+		case inRequire:
+		case line == "require (":
+			inRequire = true
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		default:
+			continue
+		}
+		indirect := false
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			indirect = strings.TrimSpace(line[idx+2:]) == "indirect"
+			line = strings.TrimSpace(line[:idx])
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		d := Dependency{Version: fields[1], Indirect: indirect}
+		if indirect {
+			d.Scope = "indirect"
+		}
+		answer[fields[0]] = d
+	}
+	return answer
+}
+
+// goModuleURL returns the URL of the repository of the module on well known git providers
+func goModuleURL(module string) string {
+	parts := strings.Split(module, "/")
+	if len(parts) < 3 || !isKnownGitHost(parts[0]) {
+		return ""
+	}
+	return "https://" + strings.Join(parts[:3], "/")
+}
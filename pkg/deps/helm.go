@@ -0,0 +1,76 @@
+package deps
+
+import (
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// HelmAnalyzer diffs the sub chart dependencies of the helm charts in the root of the repository or the charts
+// directory. Only pinned versions of Chart.yaml files are reported as they usually contain version ranges which are
+// resolved in the Chart.lock file
+type HelmAnalyzer struct{}
+
+type helmDependencies struct {
+	Dependencies []struct {
+		Name       string `json:"name"`
+		Version    string `json:"version"`
+		Repository string `json:"repository"`
+	} `json:"dependencies"`
+}
+
+// Title returns the title of the section
+func (a *HelmAnalyzer) Title() string {
+	return "Helm Chart Updates"
+}
+
+// Files returns the Chart.lock and Chart.yaml files of the charts along with the requirements.lock files of helm 2 charts
+func (a *HelmAnalyzer) Files() []string {
+	return []string{
+		"Chart.lock", "requirements.lock", "Chart.yaml",
+		"charts/*/Chart.lock", "charts/*/requirements.lock", "charts/*/Chart.yaml",
+	}
+}
+
+// Diff returns the sub charts whose versions differ
+func (a *HelmAnalyzer) Diff(path, previous, current string) ([]Update, error) {
+	pinnedOnly := strings.HasSuffix(path, "Chart.yaml")
+	p, err := ParseHelmDependencies(previous, pinnedOnly)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the previous %s", path)
+	}
+	c, err := ParseHelmDependencies(current, pinnedOnly)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the current %s", path)
+	}
+	return DiffDependencies(AnalyzerHelm, p, c), nil
+}
+
+// ParseHelmDependencies returns the dependencies of the Chart.yaml, Chart.lock or requirements.lock file indexed by
+// name linking to the chart repository. If pinnedOnly is true dependencies using version ranges are ignored
+func ParseHelmDependencies(text string, pinnedOnly bool) (map[string]Dependency, error) {
+	answer := map[string]Dependency{}
+	if strings.TrimSpace(text) == "" {
+		return answer, nil
+	}
+	chart := &helmDependencies{}
+	err := yaml.Unmarshal([]byte(text), chart)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range chart.Dependencies {
+		if d.Name == "" || d.Version == "" {
+			continue
+		}
+		if pinnedOnly && strings.ContainsAny(d.Version, "^~<>=*xX| ") {
+			continue
+		}
+		dep := Dependency{Version: d.Version}
+		if strings.HasPrefix(d.Repository, "https://") || strings.HasPrefix(d.Repository, "http://") {
+			dep.URL = d.Repository
+		}
+		answer[d.Name] = dep
+	}
+	return answer, nil
+}
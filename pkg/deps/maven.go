@@ -0,0 +1,117 @@
+package deps
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// mavenPropertyRegex matches property references such as '${jackson.version}'
+var mavenPropertyRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// MavenAnalyzer diffs the dependencies of the pom.xml file
+type MavenAnalyzer struct{}
+
+type pomXML struct {
+	Version    string          `xml:"version"`
+	Parent     mavenArtifact   `xml:"parent"`
+	Properties mavenProperties `xml:"properties"`
+
+	Dependencies         []mavenArtifact `xml:"dependencies>dependency"`
+	DependencyManagement []mavenArtifact `xml:"dependencyManagement>dependencies>dependency"`
+	Plugins              []mavenArtifact `xml:"build>plugins>plugin"`
+}
+
+type mavenArtifact struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+type mavenProperties struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// Title returns the title of the section
+func (a *MavenAnalyzer) Title() string {
+	return "Maven Dependency Updates"
+}
+
+// Files returns the pom.xml file
+func (a *MavenAnalyzer) Files() []string {
+	return []string{"pom.xml"}
+}
+
+// Diff returns the dependencies and plugins whose versions differ
+func (a *MavenAnalyzer) Diff(path, previous, current string) ([]Update, error) {
+	p, err := ParsePom(previous)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the previous %s", path)
+	}
+	c, err := ParsePom(current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the current %s", path)
+	}
+	answer := DiffDependencies(AnalyzerMaven, p, c)
+	for i := range answer {
+		answer[i].URL = "https://search.maven.org/artifact/" + strings.Replace(answer[i].Name, ":", "/", 1)
+	}
+	return answer, nil
+}
+
+// ParsePom returns the versioned dependencies, managed dependencies, plugins and parent of the pom.xml file indexed by
+// 'groupId:artifactId' resolving property references to the properties of the pom
+func ParsePom(text string) (map[string]Dependency, error) {
+	answer := map[string]Dependency{}
+	if strings.TrimSpace(text) == "" {
+		return answer, nil
+	}
+	pom := &pomXML{}
+	err := xml.Unmarshal([]byte(text), pom)
+	if err != nil {
+		return nil, err
+	}
+	properties := map[string]string{
+		"project.version": pom.Version,
+	}
+	for _, e := range pom.Properties.Entries {
+		properties[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+	resolve := func(value string) string {
+		return mavenPropertyRegex.ReplaceAllStringFunc(strings.TrimSpace(value), func(ref string) string {
+			v, ok := properties[mavenPropertyRegex.FindStringSubmatch(ref)[1]]
+			if !ok {
+				return ref
+			}
+			return v
+		})
+	}
+	add := func(artifacts []mavenArtifact, defaultScope string) {
+		for _, a := range artifacts {
+			version := resolve(a.Version)
+			if a.ArtifactID == "" || version == "" {
+				continue
+			}
+			name := resolve(a.GroupID) + ":" + resolve(a.ArtifactID)
+			if _, ok := answer[name]; ok {
+				continue
+			}
+			scope := a.Scope
+			if scope == "" || scope == "compile" {
+				scope = defaultScope
+			}
+			answer[name] = Dependency{Version: version, Scope: scope}
+		}
+	}
+	add(pom.Dependencies, "")
+	add(pom.DependencyManagement, "managed")
+	add(pom.Plugins, "plugin")
+	add([]mavenArtifact{pom.Parent}, "parent")
+	return answer, nil
+}
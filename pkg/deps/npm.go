@@ -0,0 +1,70 @@
+package deps
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NpmAnalyzer diffs the dependencies of the package.json file
+type NpmAnalyzer struct{}
+
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// Title returns the title of the section
+func (a *NpmAnalyzer) Title() string {
+	return "npm Package Updates"
+}
+
+// Files returns the package.json file
+func (a *NpmAnalyzer) Files() []string {
+	return []string{"package.json"}
+}
+
+// Diff returns the packages whose versions differ
+func (a *NpmAnalyzer) Diff(path, previous, current string) ([]Update, error) {
+	p, err := ParsePackageJSON(previous)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the previous %s", path)
+	}
+	c, err := ParsePackageJSON(current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the current %s", path)
+	}
+	answer := DiffDependencies(AnalyzerNpm, p, c)
+	for i := range answer {
+		answer[i].URL = "https://www.npmjs.com/package/" + answer[i].Name
+	}
+	return answer, nil
+}
+
+// ParsePackageJSON returns the dependencies of the package.json file indexed by package name
+func ParsePackageJSON(text string) (map[string]Dependency, error) {
+	answer := map[string]Dependency{}
+	if strings.TrimSpace(text) == "" {
+		return answer, nil
+	}
+	pkg := &packageJSON{}
+	err := json.Unmarshal([]byte(text), pkg)
+	if err != nil {
+		return nil, err
+	}
+	add := func(dependencies map[string]string, scope string) {
+		for name, version := range dependencies {
+			if _, ok := answer[name]; !ok {
+				answer[name] = Dependency{Version: version, Scope: scope}
+			}
+		}
+	}
+	add(pkg.Dependencies, "")
+	add(pkg.PeerDependencies, "peer")
+	add(pkg.OptionalDependencies, "optional")
+	add(pkg.DevDependencies, "dev")
+	return answer, nil
+}
@@ -0,0 +1,64 @@
+package deps
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// requirementRegex matches the name, optional extras and version specifier of a requirement
+var requirementRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(.*)$`)
+
+// PythonAnalyzer diffs the requirements of the requirements.txt file
+type PythonAnalyzer struct{}
+
+// Title returns the title of the section
+func (a *PythonAnalyzer) Title() string {
+	return "Python Package Updates"
+}
+
+// Files returns the requirements.txt file
+func (a *PythonAnalyzer) Files() []string {
+	return []string{"requirements.txt"}
+}
+
+// Diff returns the requirements whose versions differ
+func (a *PythonAnalyzer) Diff(path, previous, current string) ([]Update, error) {
+	answer := DiffDependencies(AnalyzerPython, ParseRequirements(previous), ParseRequirements(current))
+	for i := range answer {
+		answer[i].URL = "https://pypi.org/project/" + answer[i].Name + "/"
+	}
+	return answer, nil
+}
+
+// ParseRequirements returns the requirements of the requirements.txt file indexed by the normalized package name.
+// Pinned versions such as 'foo==1.2.3' are returned as '1.2.3' and other specifiers as written. Options, includes and
+// URL requirements are ignored
+func ParseRequirements(text string) map[string]Dependency {
+	answer := map[string]Dependency{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		if idx := strings.Index(line, ";"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		m := requirementRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(m[1]))
+		version := strings.ReplaceAll(m[2], " ", "")
+		if strings.HasPrefix(version, "==") && !strings.Contains(version, ",") {
+			version = strings.TrimPrefix(version, "==")
+		}
+		answer[name] = Dependency{Version: version}
+	}
+	return answer
+}
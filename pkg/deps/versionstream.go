@@ -0,0 +1,115 @@
+package deps
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// versionStreamDir the directory of the version stream in a cluster repository
+const versionStreamDir = "versionStream"
+
+// VersionStreamAnalyzer diffs the versions of the charts and packages of a Jenkins X version stream. It supports both
+// version stream repositories and cluster repositories which contain the version stream in the versionStream directory
+type VersionStreamAnalyzer struct{}
+
+// VersionStreamFile the version and source repository of a chart or package of the version stream
+type VersionStreamFile struct {
+	Version string `json:"version"`
+	GitURL  string `json:"gitUrl"`
+}
+
+// IsVersionStream returns true if the directory is a version stream or a cluster repository containing one
+func IsVersionStream(dir string) bool {
+	for _, prefix := range []string{versionStreamDir, ""} {
+		if isDir(filepath.Join(dir, prefix, "charts")) && isDir(filepath.Join(dir, prefix, "packages")) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Title returns the title of the section
+func (a *VersionStreamAnalyzer) Title() string {
+	return "Version Stream Updates"
+}
+
+// Files returns the chart and package version files of the version stream
+func (a *VersionStreamAnalyzer) Files() []string {
+	var answer []string
+	for _, prefix := range []string{versionStreamDir + "/", ""} {
+		answer = append(answer, prefix+"charts/*/*.yml", prefix+"charts/*/*/defaults.yaml", prefix+"packages/*.yml")
+	}
+	return answer
+}
+
+// Diff returns the version change of the chart or package of the file linking to the upstream releases
+func (a *VersionStreamAnalyzer) Diff(filePath, previous, current string) ([]Update, error) {
+	name, scope := versionStreamName(filePath)
+	p, err := ParseVersionStreamFile(previous)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the previous %s", filePath)
+	}
+	c, err := ParseVersionStreamFile(current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the current %s", filePath)
+	}
+	if p.Version == c.Version {
+		return nil, nil
+	}
+	url := strings.TrimSuffix(c.GitURL, ".git")
+	if url == "" {
+		url = strings.TrimSuffix(p.GitURL, ".git")
+	}
+	return []Update{{
+		Ecosystem: AnalyzerVersionStream,
+		Name:      name,
+		From:      p.Version,
+		To:        c.Version,
+		Scope:     scope,
+		URL:       url,
+		FromURL:   releaseURL(url, p.Version),
+		ToURL:     releaseURL(url, c.Version),
+	}}, nil
+}
+
+// versionStreamName returns the name and kind of the chart or package of the version stream file
+func versionStreamName(filePath string) (string, string) {
+	filePath = strings.TrimPrefix(filePath, versionStreamDir+"/")
+	kind := strings.SplitN(filePath, "/", 2)[0]
+	name := strings.TrimPrefix(filePath, kind+"/")
+	if path.Base(name) == "defaults.yaml" {
+		name = path.Dir(name)
+	}
+	return strings.TrimSuffix(name, ".yml"), strings.TrimSuffix(kind, "s")
+}
+
+// ParseVersionStreamFile parses the YAML of the version stream file. Empty text returns an empty file
+func ParseVersionStreamFile(text string) (*VersionStreamFile, error) {
+	answer := &VersionStreamFile{}
+	if strings.TrimSpace(text) == "" {
+		return answer, nil
+	}
+	err := yaml.Unmarshal([]byte(text), answer)
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// releaseURL returns the URL of the GitHub release of the version assuming the tags use a 'v' prefix
+func releaseURL(url, version string) string {
+	if version == "" || !strings.HasPrefix(url, "https://github.com/") {
+		return ""
+	}
+	return url + "/releases/tag/v" + strings.TrimPrefix(version, "v")
+}
@@ -0,0 +1,318 @@
+package gits
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+const (
+	// GitBackendGoGit walks the commits in process using go-git
+	GitBackendGoGit = "go-git"
+
+	// GitBackendCLI streams the output of 'git log' which copes better with very large repositories
+	GitBackendCLI = "cli"
+
+	gitLogFieldSeparator  = "\x1f"
+	gitLogRecordSeparator = "\x1e"
+
+	// gitLogMaxRecordSize the maximum size of a single commit in the git log output
+	gitLogMaxRecordSize = 16 * 1024 * 1024
+
+	// gitLogFormat the hash, parents, author, committer and raw message of each commit
+	gitLogFormat = "--format=%H%x1f%P%x1f%an%x1f%ae%x1f%at%x1f%cn%x1f%ce%x1f%ct%x1f%B%x1e"
+)
+
+// CommitIterator iterates over commits one at a time so that large ranges of commits are not held in memory
+type CommitIterator interface {
+	// Next returns the next commit or io.EOF if there are no more commits
+	Next() (*object.Commit, error)
+
+	// Close releases the resources of the iterator
+	Close()
+}
+
+// CommitFetcher fetches the commits of the changelog from a git repository in reverse chronological order
+type CommitFetcher interface {
+	// FetchCommits iterates over the commits reachable from toRev which are not reachable from fromRev
+	FetchCommits(gitDir, fromRev, toRev string) (CommitIterator, error)
+
+	// FetchHistory iterates over the commits reachable from rev. If maxCommits is greater than zero only the latest commits are returned
+	FetchHistory(gitDir, rev string, maxCommits int) (CommitIterator, error)
+}
+
+// NewCommitFetcher creates the commit fetcher of the given backend. Defaults to GitBackendGoGit
+func NewCommitFetcher(backend string) (CommitFetcher, error) {
+	switch backend {
+	case "", GitBackendGoGit:
+		return &GoGitCommitFetcher{}, nil
+	case GitBackendCLI:
+		return &CLICommitFetcher{}, nil
+	default:
+		return nil, errors.Errorf("unknown git backend %s. Should be one of %s or %s", backend, GitBackendGoGit, GitBackendCLI)
+	}
+}
+
+// GoGitCommitFetcher fetches commits using go-git
+type GoGitCommitFetcher struct{}
+
+// FetchCommits iterates over the commits between the revisions. Missing objects in shallow clones end the iteration
+func (f *GoGitCommitFetcher) FetchCommits(gitDir, fromRev, toRev string) (CommitIterator, error) {
+	repo, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open git repository %s", gitDir)
+	}
+	from, err := resolveCommit(repo, fromRev)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveCommit(repo, toRev)
+	if err != nil {
+		return nil, err
+	}
+
+	// lets only keep the hashes of the commits before the range in memory
+	exclude := map[plumbing.Hash]bool{}
+	iter := object.NewCommitPreorderIter(from, nil, nil)
+	err = iter.ForEach(func(c *object.Commit) error {
+		exclude[c.Hash] = true
+		return nil
+	})
+	iter.Close()
+	if err != nil && err != plumbing.ErrObjectNotFound {
+		return nil, errors.Wrapf(err, "failed to walk the history of %s", fromRev)
+	}
+	return &goGitCommitIterator{iter: object.NewCommitPreorderIter(to, exclude, nil)}, nil
+}
+
+// FetchHistory iterates over the history of the revision
+func (f *GoGitCommitFetcher) FetchHistory(gitDir, rev string, maxCommits int) (CommitIterator, error) {
+	repo, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open git repository %s", gitDir)
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	c, err := resolveCommit(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Log(&git.LogOptions{From: c.Hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to walk the history of %s", rev)
+	}
+	return &goGitCommitIterator{iter: iter, max: maxCommits}, nil
+}
+
+// resolveCommit resolves the branch, tag or SHA to its commit peeling annotated tags
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve revision %s", rev)
+	}
+	tag, err := repo.TagObject(*hash)
+	if err == nil {
+		return tag.Commit()
+	}
+	c, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the commit of revision %s", rev)
+	}
+	return c, nil
+}
+
+type goGitCommitIterator struct {
+	iter  object.CommitIter
+	max   int
+	count int
+}
+
+func (i *goGitCommitIterator) Next() (*object.Commit, error) {
+	if i.max > 0 && i.count >= i.max {
+		return nil, io.EOF
+	}
+	c, err := i.iter.Next()
+	if err == plumbing.ErrObjectNotFound {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	i.count++
+	return c, nil
+}
+
+func (i *goGitCommitIterator) Close() {
+	i.iter.Close()
+}
+
+// CLICommitFetcher fetches commits by streaming the output of 'git log'
+type CLICommitFetcher struct {
+	// Binary the git binary. Defaults to 'git'
+	Binary string
+
+	// Paths if specified only the commits which change these paths are returned. They are passed to git as pathspecs
+	// so that the commits of other paths of large monorepos are skipped by git rather than walked
+	Paths []string
+}
+
+// FetchCommits iterates over the commits between the revisions
+func (f *CLICommitFetcher) FetchCommits(gitDir, fromRev, toRev string) (CommitIterator, error) {
+	if toRev == "" {
+		toRev = "HEAD"
+	}
+	return f.log(gitDir, fromRev+".."+toRev)
+}
+
+// FetchHistory iterates over the history of the revision
+func (f *CLICommitFetcher) FetchHistory(gitDir, rev string, maxCommits int) (CommitIterator, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	args := []string{rev}
+	if maxCommits > 0 {
+		args = append(args, "-n", strconv.Itoa(maxCommits))
+	}
+	return f.log(gitDir, args...)
+}
+
+func (f *CLICommitFetcher) log(gitDir string, args ...string) (CommitIterator, error) {
+	binary := f.Binary
+	if binary == "" {
+		binary = "git"
+	}
+	args = append([]string{"log", gitLogFormat}, args...)
+	if len(f.Paths) > 0 {
+		args = append(append(args, "--"), f.Paths...)
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = gitDir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run git %s", strings.Join(args, " "))
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run git %s", strings.Join(args, " "))
+	}
+	iter := NewGitLogIterator(stdout).(*gitLogIterator)
+	iter.wait = func() error {
+		err := cmd.Wait()
+		if err != nil {
+			return errors.Wrapf(err, "failed to run git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	iter.kill = func() {
+		if cmd.ProcessState == nil && cmd.Process != nil {
+			cmd.Process.Kill() //nolint:errcheck
+			cmd.Wait()         //nolint:errcheck
+		}
+	}
+	return iter, nil
+}
+
+// NewGitLogIterator iterates over the commits in the output of 'git log' using the field and record separated format
+// of the CLICommitFetcher
+func NewGitLogIterator(r io.Reader) CommitIterator {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), gitLogMaxRecordSize)
+	scanner.Split(splitGitLogRecords)
+	return &gitLogIterator{scanner: scanner}
+}
+
+type gitLogIterator struct {
+	scanner *bufio.Scanner
+	wait    func() error
+	kill    func()
+}
+
+func (i *gitLogIterator) Next() (*object.Commit, error) {
+	for i.scanner.Scan() {
+		record := strings.TrimLeft(i.scanner.Text(), "\r\n")
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		return parseGitLogRecord(record)
+	}
+	err := i.scanner.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the git log")
+	}
+	if i.wait != nil {
+		wait := i.wait
+		i.wait = nil
+		err = wait()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, io.EOF
+}
+
+func (i *gitLogIterator) Close() {
+	if i.kill != nil {
+		i.kill()
+	}
+}
+
+// splitGitLogRecords splits the git log output on the record separator
+func splitGitLogRecords(data []byte, atEOF bool) (int, []byte, error) {
+	idx := bytes.Index(data, []byte(gitLogRecordSeparator))
+	if idx >= 0 {
+		return idx + len(gitLogRecordSeparator), data[:idx], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func parseGitLogRecord(record string) (*object.Commit, error) {
+	fields := strings.SplitN(record, gitLogFieldSeparator, 9)
+	if len(fields) != 9 {
+		return nil, errors.Errorf("failed to parse git log record %q", record)
+	}
+	author, err := toSignature(fields[2], fields[3], fields[4])
+	if err != nil {
+		return nil, err
+	}
+	committer, err := toSignature(fields[5], fields[6], fields[7])
+	if err != nil {
+		return nil, err
+	}
+	c := &object.Commit{
+		Hash:      plumbing.NewHash(fields[0]),
+		Author:    author,
+		Committer: committer,
+		Message:   fields[8],
+	}
+	for _, p := range strings.Fields(fields[1]) {
+		c.ParentHashes = append(c.ParentHashes, plumbing.NewHash(p))
+	}
+	return c, nil
+}
+
+func toSignature(name, email, timestamp string) (object.Signature, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return object.Signature{}, errors.Wrapf(err, "failed to parse git log timestamp %s", timestamp)
+	}
+	return object.Signature{
+		Name:  name,
+		Email: email,
+		When:  time.Unix(seconds, 0),
+	}, nil
+}
@@ -0,0 +1,95 @@
+// +build unit
+
+package gits_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLogIterator(t *testing.T) {
+	t.Parallel()
+	record := func(fields ...string) string {
+		return strings.Join(fields, "\x1f") + "\x1e"
+	}
+	output := record("1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222 3333333333333333333333333333333333333333",
+		"James Strachan", "james@foo.com", "1600000000", "GitHub", "noreply@github.com", "1600000100", "Merge pull request #12 from foo/bar\n\nfix: something\n") +
+		"\n" + record("2222222222222222222222222222222222222222", "", "Jane Doe", "jane@foo.com", "1500000000", "Jane Doe", "jane@foo.com", "1500000000", "feat: initial\n") + "\n"
+
+	iter := gits.NewGitLogIterator(strings.NewReader(output))
+	defer iter.Close()
+
+	c, err := iter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "1111111111111111111111111111111111111111", c.Hash.String())
+	assert.Len(t, c.ParentHashes, 2)
+	assert.Equal(t, "James Strachan", c.Author.Name)
+	assert.Equal(t, "james@foo.com", c.Author.Email)
+	assert.Equal(t, int64(1600000100), c.Committer.When.Unix())
+	assert.Equal(t, "Merge pull request #12 from foo/bar\n\nfix: something\n", c.Message)
+
+	c, err = iter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "feat: initial\n", c.Message)
+	assert.Empty(t, c.ParentHashes)
+
+	_, err = iter.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = gits.NewCommitFetcher("svn")
+	assert.Error(t, err)
+}
+
+func TestCLICommitFetcherPaths(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	commit := func(path, message string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, path), []byte(message), 0600))
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	git("init", "-q")
+	git("config", "user.email", "jane@foo.com")
+	git("config", "user.name", "Jane Doe")
+	commit("README.md", "initial import")
+	git("tag", "v1.0.0")
+	commit("services/api/main.go", "feat: api endpoint")
+	commit("services/web/index.html", "feat: web page")
+	commit("services/api/handler.go", "fix: api handler")
+
+	messages := func(iter gits.CommitIterator, err error) []string {
+		require.NoError(t, err)
+		defer iter.Close()
+		var answer []string
+		for {
+			c, err := iter.Next()
+			if err == io.EOF {
+				return answer
+			}
+			require.NoError(t, err)
+			answer = append(answer, strings.TrimSpace(c.Message))
+		}
+	}
+	fetcher := &gits.CLICommitFetcher{Paths: []string{"services/api"}}
+	assert.Equal(t, []string{"fix: api handler", "feat: api endpoint"}, messages(fetcher.FetchCommits(dir, "v1.0.0", "HEAD")))
+	assert.Equal(t, []string{"fix: api handler"}, messages(fetcher.FetchHistory(dir, "HEAD", 1)))
+
+	fetcher.Paths = nil
+	assert.Len(t, messages(fetcher.FetchCommits(dir, "v1.0.0", "HEAD")), 3)
+}
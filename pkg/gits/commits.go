@@ -1,11 +1,16 @@
 package gits
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
@@ -58,7 +63,12 @@ func createCommitGroup(title string) *CommitGroup {
 // ParseCommit parses a conventional commit
 // see: https://conventionalcommits.org/
 func ParseCommit(message string) *CommitInfo {
-	answer := &CommitInfo{
+	answer := parseCommit(message)
+	return &answer
+}
+
+func parseCommit(message string) CommitInfo {
+	answer := CommitInfo{
 		Message: message,
 	}
 
@@ -95,155 +105,636 @@ func (c *CommitInfo) Order() int {
 	return c.Group().Order
 }
 
+// GroupAndCommitInfos the indexes of the commits of a group
 type GroupAndCommitInfos struct {
 	group   *CommitGroup
-	commits []string
+	commits []int
+}
+
+// MarkdownOptions the optional behaviour when generating the markdown document
+type MarkdownOptions struct {
+	// Reviewers the approving reviewers of the pull requests indexed by the pull request ID
+	Reviewers map[string][]v1.UserDetails
+
+	// ContributorAvatars includes the avatar images of the contributors
+	ContributorAvatars bool
+
+	// CompareURL the URL of the page comparing the revisions of the release
+	CompareURL string
+
+	// CompareLink adds a Full Changelog line linking to the CompareURL at the end of the changelog
+	CompareLink bool
+
+	// CommitLinks adds the short SHA of each commit linked to its URL to the end of the entries of the commits
+	CommitLinks bool
+
+	// InitialRelease labels the changelog as the initial release of the repository
+	InitialRelease bool
+
+	// Mentions if specified users are rendered as @login mentions if this function returns true or as plain names otherwise
+	Mentions func(user *v1.UserDetails) bool
+
+	// DependencyReleaseNotes the markdown release notes of the upstream releases of the dependency updates indexed by DependencyKey
+	DependencyReleaseNotes map[string]string
+
+	// DependencySections the dependency updates found by diffing manifest files. They are rendered in the
+	// Dependencies table grouped with the other dependency updates
+	DependencySections []deps.Section
+
+	// DependencyClassifications the classification of the dependency updates indexed by DependencyKey. If specified
+	// the Dependencies table includes the class of each update
+	DependencyClassifications map[string]deps.Classification
+
+	// DependencyChanges the commits and issues of the upstream releases of the dependency updates indexed by DependencyKey
+	DependencyChanges map[string]*UpstreamChanges
+
+	// Highlights the SHAs of the commits listed in a Highlights section at the top of the changelog in order. The
+	// rest of the changelog is folded below them
+	Highlights []string
+
+	// Reworked the SHAs of the commits which were reverted and reapplied in the release. They are marked as reworked
+	Reworked map[string]bool
+
+	// LabelBadges renders the labels of the issues and pull requests as code spans after their entries. The entries
+	// of commits show the labels of the issues they reference
+	LabelBadges bool
+
+	// LabelFilter if specified only the labels it returns true for are rendered as badges
+	LabelFilter func(label string) bool
+
+	// Deployments the environments the release is deployed to which are listed in a Deployed to line
+	Deployments []Deployment
+}
+
+// Deployment an environment the release was successfully deployed to
+type Deployment struct {
+	Environment string    `json:"environment"`
+	URL         string    `json:"url,omitempty"`
+	Deployed    time.Time `json:"deployed"`
+}
+
+// UpstreamChanges the commits, issues and pull requests of the upstream releases of a dependency update
+type UpstreamChanges struct {
+	Commits      []v1.CommitSummary
+	Issues       []v1.IssueSummary
+	PullRequests []v1.IssueSummary
+}
+
+// DependencyKey returns the key of the dependency update of the owner, repository and component
+func DependencyKey(du *v1.DependencyUpdate) string {
+	key := du.Owner + "/" + du.Repo
+	if du.Component != "" {
+		key += ":" + du.Component
+	}
+	return key
 }
 
 // GenerateMarkdown generates the markdown document for the commits
 func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository) (string, error) {
-	var commitInfos []*CommitInfo
+	return GenerateMarkdownWithOptions(releaseSpec, gitInfo, &MarkdownOptions{})
+}
 
-	groupAndCommits := map[int]*GroupAndCommitInfos{}
+// GenerateMarkdownWithOptions generates the markdown document for the commits using the given options
+func GenerateMarkdownWithOptions(releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository, options *MarkdownOptions) (string, error) {
+	var buffer strings.Builder
+	buffer.Grow(estimateMarkdownSize(releaseSpec))
+	err := WriteMarkdown(&buffer, releaseSpec, gitInfo, options)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
 
+// WriteMarkdown streams the markdown document for the commits to the writer using the given options
+func WriteMarkdown(w io.Writer, releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository, options *MarkdownOptions) error {
+	if options == nil {
+		options = &MarkdownOptions{}
+	}
+	m := &markdownWriter{
+		info:      gitInfo,
+		options:   options,
+		issueMap:  make(map[string]*v1.IssueSummary, len(releaseSpec.Issues)),
+		userLinks: map[string]string{},
+	}
 	issues := releaseSpec.Issues
-	issueMap := map[string]*v1.IssueSummary{}
-	for _, issue := range issues {
-		cp := issue
-		issueMap[cp.ID] = &cp
+	for i := range issues {
+		m.issueMap[issues[i].ID] = &issues[i]
 	}
 
-	for _, cs := range releaseSpec.Commits {
-		commits := cs
-		message := commits.Message
-		if message != "" {
-			ci := ParseCommit(message)
-
-			description := "* " + describeCommit(gitInfo, &commits, ci, issueMap) + "\n"
-			group := ci.Group()
-			if group != nil {
-				gac := groupAndCommits[group.Order]
-				if gac == nil {
-					gac = &GroupAndCommitInfos{
-						group:   group,
-						commits: []string{},
-					}
-					groupAndCommits[group.Order] = gac
-				}
-				gac.commits = append(gac.commits, description)
+	commits := releaseSpec.Commits
+	commitInfos := make([]CommitInfo, len(commits))
+	groupAndCommits := map[int]*GroupAndCommitInfos{}
+	count := 0
+	for i := range commits {
+		message := commits[i].Message
+		if message == "" {
+			continue
+		}
+		count++
+		commitInfos[i] = parseCommit(message)
+		group := commitInfos[i].Group()
+		if group != nil {
+			gac := groupAndCommits[group.Order]
+			if gac == nil {
+				gac = &GroupAndCommitInfos{group: group}
+				groupAndCommits[group.Order] = gac
 			}
-			commitInfos = append(commitInfos, ci)
+			gac.commits = append(gac.commits, i)
 		}
 	}
 
 	prs := releaseSpec.PullRequests
-
-	var buffer bytes.Buffer
-	if len(commitInfos) == 0 && len(issues) == 0 && len(prs) == 0 {
-		return "", nil
+	out := bufio.NewWriterSize(w, markdownBufferSize)
+	title := "## Changes\n"
+	if options.InitialRelease {
+		title = "## Initial Release\n"
+	}
+	if count == 0 && len(issues) == 0 && len(prs) == 0 {
+		if options.InitialRelease {
+			out.WriteString(title)
+		}
+		return out.Flush()
 	}
+	out.WriteString(title)
+
+	if len(options.Highlights) == 0 {
+		m.writeBody(out, releaseSpec, commitInfos, groupAndCommits)
+	} else {
+		// lets render the body first as the highlights include its reading time
+		var body strings.Builder
+		body.Grow(estimateMarkdownSize(releaseSpec))
+		m.writeBody(&body, releaseSpec, commitInfos, groupAndCommits)
+
+		shas := make(map[string]int, len(commits))
+		for i := range commits {
+			if commits[i].Message != "" {
+				shas[commits[i].SHA] = i
+			}
+		}
+		out.WriteString("\n### Highlights\n\n")
+		out.WriteString("_" + describeReadingTime(body.String()) + "_\n\n")
+		for _, sha := range options.Highlights {
+			if i, ok := shas[sha]; ok {
+				out.Write(m.commitLine(&commits[i], &commitInfos[i]))
+			}
+		}
+		fmt.Fprintf(out, "\n<details>\n<summary>%s</summary>\n%s\n</details>\n", "All "+plural(count, "change"), body.String())
+	}
+	if len(options.Deployments) > 0 {
+		out.WriteString("\n**Deployed to**: " + describeDeployments(options.Deployments) + "\n")
+	}
+	if options.CompareLink && options.CompareURL != "" {
+		out.WriteString("\n**Full Changelog**: " + options.CompareURL + "\n")
+	}
+	return out.Flush()
+}
+
+// markdownBufferSize the size of the buffer used when streaming the markdown to a writer
+const markdownBufferSize = 64 * 1024
+
+// estimateMarkdownSize returns the approximate size of the markdown of the release so buffers can be preallocated
+func estimateMarkdownSize(releaseSpec *v1.ReleaseSpec) int {
+	return 256 + 160*len(releaseSpec.Commits) + 128*(len(releaseSpec.Issues)+len(releaseSpec.PullRequests)) + 256*len(releaseSpec.DependencyUpdates)
+}
+
+// markdownBuffer the writer the sections of the markdown are written to. Any write errors are reported when the
+// buffer is flushed
+type markdownBuffer interface {
+	io.Writer
+	io.StringWriter
+}
 
-	buffer.WriteString("## Changes\n")
+// markdownWriter writes the markdown lines reusing its buffers to avoid allocating a string per line
+type markdownWriter struct {
+	info     *giturl.GitRepository
+	options  *MarkdownOptions
+	issueMap map[string]*v1.IssueSummary
 
+	userLinks map[string]string
+	line      []byte
+	previous  []byte
+	labels    []v1.IssueLabel
+}
+
+// writeLine writes the current line unless it is the same as the previous line
+func (m *markdownWriter) writeLine(out markdownBuffer) {
+	if !bytes.Equal(m.line, m.previous) {
+		out.Write(m.line)
+		m.line, m.previous = m.previous, m.line
+	}
+}
+
+// writeBody writes the commits grouped by their kind, the issues, pull requests and dependency updates
+func (m *markdownWriter) writeBody(out markdownBuffer, releaseSpec *v1.ReleaseSpec, commitInfos []CommitInfo, groupAndCommits map[int]*GroupAndCommitInfos) {
+	options := m.options
 	hasTitle := false
 	for i := 0; i <= unknownKindOrder; i++ {
 		gac := groupAndCommits[i]
 		if gac != nil && len(gac.commits) > 0 {
 			group := gac.group
 			if group != nil {
+				title := group.Title
 				legend := ""
-				buffer.WriteString("\n")
-				if group.Title == "" && hasTitle {
-					group.Title = "Other Changes"
+				out.WriteString("\n")
+				if title == "" && hasTitle {
+					title = "Other Changes"
 					legend = "These commits did not use [Conventional Commits](https://conventionalcommits.org/) formatted messages:\n\n"
 				}
-				if group.Title != "" {
+				if title != "" {
 					hasTitle = true
-					buffer.WriteString("### " + group.Title + "\n\n" + legend)
+					out.WriteString("### " + title + "\n\n" + legend)
 				}
 			}
-			previous := ""
-			for _, msg := range gac.commits {
-				if msg != previous {
-					buffer.WriteString(msg)
-					previous = msg
-				}
+			m.previous = m.previous[:0]
+			for _, c := range gac.commits {
+				m.commitLine(&releaseSpec.Commits[c], &commitInfos[c])
+				m.writeLine(out)
 			}
 		}
 	}
 
+	issues := releaseSpec.Issues
 	if len(issues) > 0 {
-		buffer.WriteString("\n### Issues\n\n")
-
-		previous := ""
-		for _, issue := range issues {
-			i := issue
-			msg := describeIssue(gitInfo, &i)
-			if msg != previous {
-				buffer.WriteString("* " + msg + "\n")
-				previous = msg
-			}
+		out.WriteString("\n### Issues\n\n")
+
+		m.previous = m.previous[:0]
+		for i := range issues {
+			m.issueLine(&issues[i], nil)
+			m.writeLine(out)
 		}
 	}
+	prs := releaseSpec.PullRequests
 	if len(prs) > 0 {
-		buffer.WriteString("\n### Pull Requests\n\n")
-
-		previous := ""
-		for _, pr := range prs {
-			pullRequest := pr
-			msg := describeIssue(gitInfo, &pullRequest)
-			if msg != previous {
-				buffer.WriteString("* " + msg + "\n")
-				previous = msg
+		out.WriteString("\n### Pull Requests\n\n")
+
+		m.previous = m.previous[:0]
+		for i := range prs {
+			m.issueLine(&prs[i], options.Reviewers[prs[i].ID])
+			m.writeLine(out)
+		}
+	}
+
+	rows := dependencyRows(releaseSpec.DependencyUpdates, options)
+	if len(rows) > 0 {
+		groups := 0
+		for i := range rows {
+			if i == 0 || rows[i].group != rows[i-1].group {
+				groups++
+			}
+		}
+		classified := len(options.DependencyClassifications) > 0
+		out.WriteString("\n### Dependencies\n\n")
+		fmt.Fprintf(out, "<details>\n<summary>%s across %s%s</summary>\n\n", plural(len(rows), "dependency update"), plural(groups, "repository"), describeClassCounts(rows))
+		if classified {
+			out.WriteString("| Repository | Dependency | Old Version | New Version | Scope | Update |\n")
+			out.WriteString("| ---------- | ---------- | ----------- | ----------- | ----- | ------ |\n")
+		} else {
+			out.WriteString("| Repository | Dependency | Old Version | New Version | Scope |\n")
+			out.WriteString("| ---------- | ---------- | ----------- | ----------- | ----- |\n")
+		}
+		for i := range rows {
+			r := &rows[i]
+			group := ""
+			if i == 0 || r.group != rows[i-1].group {
+				group = markdownLink(r.group, r.groupURL)
+			}
+			fmt.Fprintf(out, "| %s | %s | %s | %s | %s |", group, markdownLink(r.name, r.url), markdownLink(versionOrDash(r.from), r.fromURL), markdownLink(versionOrDash(r.to), r.toURL), r.scope)
+			if classified {
+				out.WriteString(" " + describeClass(&r.class) + " |")
+			}
+			out.WriteString("\n")
+		}
+		for i := range rows {
+			r := &rows[i]
+			if r.notes != "" {
+				fmt.Fprintf(out, "\n<details>\n<summary>%s release notes from %s to %s</summary>\n\n%s\n</details>\n", r.notesName, r.from, r.to, r.notes)
+			}
+			if r.changes != nil {
+				fmt.Fprintf(out, "\n<details>\n<summary>%s changes from %s to %s</summary>\n\n%s</details>\n", r.notesName, r.from, r.to, describeUpstreamChanges(r.changes))
 			}
 		}
+		out.WriteString("\n</details>\n")
 	}
+}
+
+// dependencyRow a row of the Dependencies table
+type dependencyRow struct {
+	group, groupURL  string
+	name, url        string
+	from, fromURL    string
+	to, toURL        string
+	scope            string
+	notesName, notes string
+	class            deps.Classification
+	changes          *UpstreamChanges
+}
 
-	if len(releaseSpec.DependencyUpdates) > 0 {
-		buffer.WriteString("\n### Dependency Updates\n\n")
-		var previous v1.DependencyUpdate
-		sequence := make([]v1.DependencyUpdate, 0)
-		buffer.WriteString("| Dependency | Component | New Version | Old Version |\n")
-		buffer.WriteString("| ---------- | --------- | ----------- | ----------- |\n")
-		for i, du := range releaseSpec.DependencyUpdates {
-			sequence = append(sequence, du)
-			// If it's the last element, or if the owner/repo:component changes, then print - this logic relies of the sort
-			// being owner, repo, component, fromVersion, ToVersion, which is done above
-			if i == len(releaseSpec.DependencyUpdates)-1 || du.Owner != previous.Owner || du.Repo != previous.Repo || du.Component != previous.Component {
-				// find the earliest from version
-				fromDu := sequence[0]
-				toDu := sequence[len(sequence)-1]
-				msg := fmt.Sprintf("| [%s/%s](%s) | %s | [%s](%s) | [%s](%s)|\n", toDu.Owner, toDu.Repo, toDu.URL, toDu.Component, toDu.ToVersion, toDu.ToReleaseHTMLURL, fromDu.FromVersion, fromDu.FromReleaseHTMLURL)
-				buffer.WriteString(msg)
-				sequence = make([]v1.DependencyUpdate, 0)
+// dependencyRows returns the rows of the dependency updates and the dependency sections grouped by host/owner/repo.
+// Consecutive updates of the same component are combined from the earliest to the latest version
+func dependencyRows(updates []v1.DependencyUpdate, options *MarkdownOptions) []dependencyRow {
+	var answer []dependencyRow
+	updates = tableDependencyUpdates(updates, options)
+	start := 0
+	for i := range updates {
+		du := &updates[i]
+		if i < len(updates)-1 {
+			next := &updates[i+1]
+			if du.Owner == next.Owner && du.Repo == next.Repo && du.Component == next.Component {
+				continue
 			}
-			previous = du
 		}
+		fromDu := &updates[start]
+		start = i + 1
+		name := du.Component
+		if name == "" {
+			name = du.Repo
+		}
+		group, groupURL := dependencyGroup(du)
+		answer = append(answer, dependencyRow{
+			group:     group,
+			groupURL:  groupURL,
+			name:      name,
+			from:      fromDu.FromVersion,
+			fromURL:   fromDu.FromReleaseHTMLURL,
+			to:        du.ToVersion,
+			toURL:     du.ToReleaseHTMLURL,
+			notesName: du.Owner + "/" + du.Repo,
+			notes:     options.DependencyReleaseNotes[DependencyKey(du)],
+			class:     options.DependencyClassifications[DependencyKey(du)],
+			changes:   options.DependencyChanges[DependencyKey(du)],
+		})
 	}
-	return buffer.String(), nil
+	for _, section := range options.DependencySections {
+		for i := range section.Updates {
+			u := &section.Updates[i]
+			du := deps.ToDependencyUpdate(u)
+			group, groupURL := dependencyGroup(&du)
+			if group == "" {
+				group = section.Title
+			}
+			answer = append(answer, dependencyRow{
+				group:     group,
+				groupURL:  groupURL,
+				name:      u.Name,
+				url:       u.URL,
+				from:      u.From,
+				fromURL:   u.FromURL,
+				to:        u.To,
+				toURL:     u.ToURL,
+				scope:     u.Scope,
+				notesName: u.Name,
+				notes:     options.DependencyReleaseNotes[DependencyKey(&du)],
+				class:     options.DependencyClassifications[DependencyKey(&du)],
+				changes:   options.DependencyChanges[DependencyKey(&du)],
+			})
+		}
+	}
+
+	// lets keep the order of the rows within each group
+	order := map[string]int{}
+	for i := range answer {
+		if _, ok := order[answer[i].group]; !ok {
+			order[answer[i].group] = len(order)
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return order[answer[i].group] < order[answer[j].group]
+	})
+	return answer
+}
+
+// dependencyGroup returns the host/owner/repo of the dependency update and its URL
+func dependencyGroup(du *v1.DependencyUpdate) (string, string) {
+	if du.Owner == "" && du.Repo == "" {
+		return "", ""
+	}
+	group := du.Owner + "/" + du.Repo
+	if du.Host == "" {
+		return group, du.URL
+	}
+	return du.Host + "/" + group, "https://" + du.Host + "/" + group
+}
+
+// tableDependencyUpdates returns the dependency updates which are not rendered in the dependency sections
+func tableDependencyUpdates(updates []v1.DependencyUpdate, options *MarkdownOptions) []v1.DependencyUpdate {
+	if len(options.DependencySections) == 0 {
+		return updates
+	}
+	sectionKeys := map[string]bool{}
+	for _, section := range options.DependencySections {
+		for i := range section.Updates {
+			du := deps.ToDependencyUpdate(&section.Updates[i])
+			sectionKeys[DependencyKey(&du)] = true
+		}
+	}
+	var answer []v1.DependencyUpdate
+	for i := range updates {
+		if !sectionKeys[DependencyKey(&updates[i])] {
+			answer = append(answer, updates[i])
+		}
+	}
+	return answer
+}
+
+// describeUpstreamChanges returns the markdown lists of the issues, pull requests and commits of the upstream releases
+func describeUpstreamChanges(changes *UpstreamChanges) string {
+	var buf strings.Builder
+	list := func(title string, issues []v1.IssueSummary) {
+		if len(issues) == 0 {
+			return
+		}
+		buf.WriteString("#### " + title + "\n\n")
+		for i := range issues {
+			buf.WriteString("* " + describeIssueShort(&issues[i]) + issues[i].Title + "\n")
+		}
+		buf.WriteString("\n")
+	}
+	list("Issues", changes.Issues)
+	list("Pull Requests", changes.PullRequests)
+	if len(changes.Commits) > 0 {
+		buf.WriteString("#### Commits\n\n")
+		for i := range changes.Commits {
+			c := &changes.Commits[i]
+			message := strings.TrimSpace(strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0])
+			sha := c.SHA
+			if len(sha) > 7 {
+				sha = sha[:7]
+			}
+			buf.WriteString("* " + message + " (" + markdownLink(sha, c.URL) + ")\n")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
 }
 
-func describeIssue(info *giturl.GitRepository, issue *v1.IssueSummary) string {
-	return describeIssueShort(issue) + issue.Title + describeUser(info, issue.User)
+// describeClassCounts returns the number of updates of each class in the order of their severity
+func describeClassCounts(rows []dependencyRow) string {
+	counts := map[string]int{}
+	for i := range rows {
+		counts[rows[i].class.Class]++
+	}
+	var parts []string
+	for _, class := range deps.Classes {
+		if counts[class] > 0 {
+			parts = append(parts, strconv.Itoa(counts[class])+" "+class)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// describeClass returns the class of the update linking to any security advisories it fixes
+func describeClass(c *deps.Classification) string {
+	if len(c.Advisories) == 0 {
+		return c.Class
+	}
+	var links []string
+	for _, id := range c.Advisories {
+		links = append(links, markdownLink(id, "https://osv.dev/vulnerability/"+id))
+	}
+	return c.Class + " (" + strings.Join(links, ", ") + ")"
+}
+
+// markdownLink returns the markdown link of the text or the text if there is no URL
+func markdownLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		noun = strings.TrimSuffix(noun, "y") + "ie"
+	}
+	return strconv.Itoa(count) + " " + noun + "s"
+}
+
+// describeReadingTime returns the approximate time to read the markdown at 200 words a minute
+func describeReadingTime(markdown string) string {
+	minutes := (len(strings.Fields(markdown)) + 199) / 200
+	if minutes < 1 {
+		minutes = 1
+	}
+	return "About " + plural(minutes, "minute") + " to read"
+}
+
+func versionOrDash(version string) string {
+	if version == "" {
+		return "-"
+	}
+	return version
+}
+
+// describeDeployments returns the environments linked to their URLs along with the time of the deployments
+func describeDeployments(deployments []Deployment) string {
+	var environments []string
+	for _, d := range deployments {
+		text := d.Environment
+		if d.URL != "" {
+			text = markdownLink(text, d.URL)
+		}
+		if !d.Deployed.IsZero() {
+			text += " (" + d.Deployed.UTC().Format("2006-01-02 15:04 MST") + ")"
+		}
+		environments = append(environments, text)
+	}
+	return strings.Join(environments, ", ")
+}
+
+// appendLabels appends the label badges if they are enabled
+func appendLabels(b []byte, labels []v1.IssueLabel, options *MarkdownOptions) []byte {
+	if options == nil || !options.LabelBadges {
+		return b
+	}
+	for _, label := range labels {
+		if label.Name == "" || (options.LabelFilter != nil && !options.LabelFilter(label.Name)) {
+			continue
+		}
+		b = append(b, " `"...)
+		b = append(b, strings.ReplaceAll(label.Name, "`", "'")...)
+		b = append(b, '`')
+	}
+	return b
+}
+func describeReviewers(info *giturl.GitRepository, reviewers []v1.UserDetails, options *MarkdownOptions) string {
+	var links []string
+	for i := range reviewers {
+		link := userLink(info, &reviewers[i], options)
+		if link != "" {
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		return ""
+	}
+	return " reviewed by " + strings.Join(links, ", ")
 }
 
 func describeIssueShort(issue *v1.IssueSummary) string {
-	prefix := ""
-	id := issue.ID
-	if len(id) > 0 {
-		// lets only add the hash prefix for numeric ids
-		_, err := strconv.Atoi(id)
-		if err == nil {
-			prefix = "#"
+	return string(appendIssueShort(nil, issue))
+}
+
+// appendIssueShort appends the link to the issue adding the hash prefix for numeric ids
+func appendIssueShort(b []byte, issue *v1.IssueSummary) []byte {
+	b = append(b, '[')
+	if isNumeric(issue.ID) {
+		b = append(b, '#')
+	}
+	b = append(b, issue.ID...)
+	b = append(b, "]("...)
+	b = append(b, issue.URL...)
+	return append(b, ") "...)
+}
+
+func isNumeric(id string) bool {
+	if id == "" {
+		return false
+	}
+	_, err := strconv.Atoi(id)
+	return err == nil
+}
+
+// appendUser appends the user in parentheses if there is any user text
+func (m *markdownWriter) appendUser(b []byte, user *v1.UserDetails) []byte {
+	userText := ""
+	if user != nil && user.URL == "" && user.Login != "" && m.options.Mentions == nil {
+		// lets only join the profile URL once per login
+		userText = m.userLinks[user.Login]
+		if userText == "" {
+			userText = userLink(m.info, user, m.options)
+			m.userLinks[user.Login] = userText
 		}
+	} else {
+		userText = userLink(m.info, user, m.options)
+	}
+	if userText == "" {
+		return b
 	}
-	return "[" + prefix + issue.ID + "](" + issue.URL + ") "
+	b = append(b, " ("...)
+	b = append(b, userText...)
+	return append(b, ')')
 }
 
-func describeUser(info *giturl.GitRepository, user *v1.UserDetails) string {
-	answer := ""
+// userLink returns the markdown link to the user or their name if there is no URL.
+// If mentions are enabled the user is either mentioned or rendered as a plain name
+func userLink(info *giturl.GitRepository, user *v1.UserDetails, options *MarkdownOptions) string {
+	userText := ""
+	if user != nil && options != nil && options.Mentions != nil {
+		if user.Login != "" && options.Mentions(user) {
+			return "@" + user.Login
+		}
+		if user.Name != "" {
+			return user.Name
+		}
+		return user.Login
+	}
 	if user != nil {
-		userText := ""
 		login := user.Login
 		url := user.URL
 		label := login
@@ -260,32 +751,84 @@ func describeUser(info *giturl.GitRepository, user *v1.UserDetails) string {
 				userText = "[" + label + "](" + url + ")"
 			}
 		}
-		if userText != "" {
-			answer = " (" + userText + ")"
-		}
 	}
-	return answer
+	return userText
 }
 
-func describeCommit(info *giturl.GitRepository, cs *v1.CommitSummary, ci *CommitInfo, issueMap map[string]*v1.IssueSummary) string {
-	prefix := ""
+// commitLine renders the bullet of the commit into the line buffer and returns it
+func (m *markdownWriter) commitLine(cs *v1.CommitSummary, ci *CommitInfo) []byte {
+	b := append(m.line[:0], "* "...)
 	if ci.Feature != "" {
-		prefix = ci.Feature + ": "
+		b = append(b, ci.Feature...)
+		b = append(b, ": "...)
 	}
 	message := strings.TrimSpace(ci.Message)
-	lines := strings.Split(message, "\n")
+	if idx := strings.IndexByte(message, '\n'); idx >= 0 {
+		message = message[:idx]
+	}
+	b = append(b, message...)
+	if m.options.Reworked[cs.SHA] {
+		b = append(b, " (reworked)"...)
+	}
+
+	// lets badge the labels of the referenced issues once each
+	m.labels = m.labels[:0]
+	if m.options.LabelBadges {
+		for _, issueID := range cs.IssueIDs {
+			if issue := m.issueMap[issueID]; issue != nil {
+				for _, label := range issue.Labels {
+					if !containsLabel(m.labels, label.Name) {
+						m.labels = append(m.labels, label)
+					}
+				}
+			}
+		}
+	}
+	b = appendLabels(b, m.labels, m.options)
 
-	// TODO add link to issue etc...
 	user := cs.Author
 	if user == nil {
 		user = cs.Committer
 	}
-	issueText := ""
-	for _, issueId := range cs.IssueIDs {
-		issue := issueMap[issueId]
-		if issue != nil {
-			issueText += " " + describeIssueShort(issue)
+	b = m.appendUser(b, user)
+	for _, issueID := range cs.IssueIDs {
+		if issue := m.issueMap[issueID]; issue != nil {
+			b = append(b, ' ')
+			b = appendIssueShort(b, issue)
+		}
+	}
+	if m.options.CommitLinks && cs.URL != "" {
+		sha := cs.SHA
+		if len(sha) > 7 {
+			sha = sha[0:7]
+		}
+		b = append(b, " ["...)
+		b = append(b, sha...)
+		b = append(b, "]("...)
+		b = append(b, cs.URL...)
+		b = append(b, ')')
+	}
+	m.line = append(b, '\n')
+	return m.line
+}
+
+// issueLine renders the bullet of the issue or pull request into the line buffer and returns it
+func (m *markdownWriter) issueLine(issue *v1.IssueSummary, reviewers []v1.UserDetails) []byte {
+	b := append(m.line[:0], "* "...)
+	b = appendIssueShort(b, issue)
+	b = append(b, issue.Title...)
+	b = appendLabels(b, issue.Labels, m.options)
+	b = m.appendUser(b, issue.User)
+	b = append(b, describeReviewers(m.info, reviewers, m.options)...)
+	m.line = append(b, '\n')
+	return m.line
+}
+
+func containsLabel(labels []v1.IssueLabel, name string) bool {
+	for i := range labels {
+		if labels[i].Name == name {
+			return true
 		}
 	}
-	return prefix + lines[0] + describeUser(info, user) + issueText
+	return false
 }
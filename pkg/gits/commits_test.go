@@ -3,9 +3,16 @@
 package gits_test
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/deps"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,3 +40,411 @@ func assertParseCommit(t *testing.T, input string, expected *gits.CommitInfo) {
 	assert.Equal(t, expected.Message, info.Message, "Message for Commit %s", info)
 	assert.Equal(t, expected, info, "CommitInfo for Commit %s", info)
 }
+
+func TestGenerateMarkdownWithMentions(t *testing.T) {
+	t.Parallel()
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{
+				Message: "fix: some commit 1",
+				SHA:     "123",
+				Author:  &v1.UserDetails{Name: "James Strachan", Login: "jstrachan"},
+			},
+			{
+				Message: "fix: some commit 2",
+				SHA:     "456",
+				Author:  &v1.UserDetails{Name: "Drive By", Login: "driveby"},
+			},
+		},
+	}
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	options := &gits.MarkdownOptions{
+		Mentions: func(user *v1.UserDetails) bool {
+			return user.Login == "jstrachan"
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+
+	expectedMarkdown := `## Changes
+
+### Bug Fixes
+
+* some commit 1 (@jstrachan)
+* some commit 2 (Drive By)
+`
+	assert.Equal(t, expectedMarkdown, markdown)
+}
+
+func TestGenerateMarkdownInitialRelease(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	options := &gits.MarkdownOptions{InitialRelease: true}
+
+	markdown, err := gits.GenerateMarkdownWithOptions(&v1.ReleaseSpec{}, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "## Initial Release\n", markdown)
+
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{
+				Message: "feat: initial import",
+				SHA:     "123",
+			},
+		},
+	}
+	markdown, err = gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "## Initial Release\n\n### New Features\n\n* initial import\n", markdown)
+}
+
+func TestGenerateMarkdownDependencyReleaseNotes(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	du := v1.DependencyUpdate{
+		DependencyUpdateDetails: v1.DependencyUpdateDetails{
+			Owner:       "jenkins-x",
+			Repo:        "jx-api",
+			FromVersion: "1.0.0",
+			ToVersion:   "1.1.0",
+		},
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits:           []v1.CommitSummary{{Message: "chore(deps): upgrade jx-api", SHA: "123"}},
+		DependencyUpdates: []v1.DependencyUpdate{du},
+	}
+	options := &gits.MarkdownOptions{
+		DependencyReleaseNotes: map[string]string{
+			gits.DependencyKey(&du): "#### v1.1.0\n\n* a new feature\n",
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, "| jenkins-x/jx-api | jx-api | 1.0.0 | 1.1.0 |  |\n")
+	assert.Contains(t, markdown, "\n<details>\n<summary>jenkins-x/jx-api release notes from 1.0.0 to 1.1.0</summary>\n\n#### v1.1.0\n\n* a new feature\n\n</details>\n")
+}
+
+func TestGenerateMarkdownDependencySections(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	u := deps.Update{Ecosystem: deps.AnalyzerGo, Name: "github.com/pkg/errors", From: "v0.8.1", To: "v0.9.1", URL: "https://github.com/pkg/errors"}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits:           []v1.CommitSummary{{Message: "chore: upgrade errors", SHA: "123"}},
+		DependencyUpdates: []v1.DependencyUpdate{deps.ToDependencyUpdate(&u)},
+	}
+	options := &gits.MarkdownOptions{
+		DependencySections: []deps.Section{{Title: "Go Module Updates", Updates: []deps.Update{u, {Name: "golang.org/x/text", From: "v0.3.2", To: "v0.3.3", Indirect: true, Scope: "indirect"}}}},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, "\n### Dependencies\n\n<details>\n<summary>2 dependency updates across 2 repositories</summary>\n\n"+
+		"| Repository | Dependency | Old Version | New Version | Scope |\n| ---------- | ---------- | ----------- | ----------- | ----- |\n"+
+		"| [github.com/pkg/errors](https://github.com/pkg/errors) | [github.com/pkg/errors](https://github.com/pkg/errors) | v0.8.1 | v0.9.1 |  |\n"+
+		"| Go Module Updates | golang.org/x/text | v0.3.2 | v0.3.3 | indirect |\n\n</details>\n")
+}
+
+func TestGenerateMarkdownDependenciesGrouped(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	update := func(component, from, to string) v1.DependencyUpdate {
+		return v1.DependencyUpdate{
+			DependencyUpdateDetails: v1.DependencyUpdateDetails{
+				Host:        "github.com",
+				Owner:       "jenkins-x",
+				Repo:        "jx",
+				Component:   component,
+				FromVersion: from,
+				ToVersion:   to,
+			},
+		}
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits:           []v1.CommitSummary{{Message: "chore(deps): upgrade jx", SHA: "123"}},
+		DependencyUpdates: []v1.DependencyUpdate{update("cli", "1.0.0", "1.1.0"), update("cli", "1.1.0", "1.2.0"), update("docs", "2.0.0", "2.0.1")},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, &gits.MarkdownOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, "<summary>2 dependency updates across 1 repository</summary>")
+	assert.Contains(t, markdown, "| [github.com/jenkins-x/jx](https://github.com/jenkins-x/jx) | cli | 1.0.0 | 1.2.0 |  |\n|  | docs | 2.0.0 | 2.0.1 |  |\n")
+}
+
+func TestGenerateMarkdownDependencyClassifications(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	u := deps.Update{Ecosystem: deps.AnalyzerNpm, Name: "lodash", From: "4.17.15", To: "4.17.20"}
+	du := deps.ToDependencyUpdate(&u)
+	releaseSpec := &v1.ReleaseSpec{
+		Commits:           []v1.CommitSummary{{Message: "chore: upgrade lodash", SHA: "123"}},
+		DependencyUpdates: []v1.DependencyUpdate{du},
+	}
+	options := &gits.MarkdownOptions{
+		DependencySections: []deps.Section{{Title: "npm Package Updates", Updates: []deps.Update{u}}},
+		DependencyClassifications: map[string]deps.Classification{
+			gits.DependencyKey(&du): {Class: deps.ClassSecurity, Advisories: []string{"GHSA-p6mc-m468-83gw"}},
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, "<summary>1 dependency update across 1 repository (1 security)</summary>")
+	assert.Contains(t, markdown, "| Repository | Dependency | Old Version | New Version | Scope | Update |\n")
+	assert.Contains(t, markdown, "| lodash | 4.17.15 | 4.17.20 |  | security ([GHSA-p6mc-m468-83gw](https://osv.dev/vulnerability/GHSA-p6mc-m468-83gw)) |\n")
+}
+
+func TestGenerateMarkdownDependencyChanges(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	du := v1.DependencyUpdate{
+		DependencyUpdateDetails: v1.DependencyUpdateDetails{
+			Owner:       "jenkins-x",
+			Repo:        "jx-api",
+			FromVersion: "1.0.0",
+			ToVersion:   "1.1.0",
+		},
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits:           []v1.CommitSummary{{Message: "chore(deps): upgrade jx-api", SHA: "123"}},
+		DependencyUpdates: []v1.DependencyUpdate{du},
+	}
+	options := &gits.MarkdownOptions{
+		DependencyChanges: map[string]*gits.UpstreamChanges{
+			gits.DependencyKey(&du): {
+				Commits: []v1.CommitSummary{{Message: "fix: something\n\nmore detail", SHA: "1234567890", URL: "https://github.com/jenkins-x/jx-api/commit/1234567890"}},
+				Issues:  []v1.IssueSummary{{ID: "12", Title: "a bug", URL: "https://github.com/jenkins-x/jx-api/issues/12"}},
+			},
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, "\n<details>\n<summary>jenkins-x/jx-api changes from 1.0.0 to 1.1.0</summary>\n\n"+
+		"#### Issues\n\n* [#12](https://github.com/jenkins-x/jx-api/issues/12) a bug\n\n"+
+		"#### Commits\n\n* fix: something ([1234567](https://github.com/jenkins-x/jx-api/commit/1234567890))\n\n</details>\n")
+}
+
+func TestCommitURL(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	assert.Equal(t, "https://github.com/jstrachan/foo/commit/abc", gits.CommitURL(gitInfo, "github", "abc"))
+	assert.Equal(t, "https://github.com/jstrachan/foo/-/commit/abc", gits.CommitURL(gitInfo, "gitlab", "abc"))
+	assert.Equal(t, "https://github.com/jstrachan/foo/commits/abc", gits.CommitURL(gitInfo, "bitbucketserver", "abc"))
+	assert.Equal(t, "", gits.CommitURL(nil, "github", "abc"))
+
+	assert.Equal(t, "https://github.com/jstrachan/foo/compare/v1.0.0...main", gits.CompareURL(gitInfo, "github", "v1.0.0", "main"))
+	assert.Equal(t, "https://github.com/jstrachan/foo/-/compare/v1.0.0...v1.1.0", gits.CompareURL(gitInfo, "gitlab", "v1.0.0", "v1.1.0"))
+	assert.Equal(t, "", gits.CompareURL(gitInfo, "github", "", "main"))
+}
+
+func TestGenerateMarkdownCommitAndCompareLinks(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "fix: a bug", SHA: "1234567890", URL: "https://github.com/jstrachan/foo/commit/1234567890"},
+		},
+	}
+	compareURL := "https://github.com/jstrachan/foo/compare/v1.0.0...v1.1.0"
+
+	// the links are opt in so that the default changelog is unchanged
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, &gits.MarkdownOptions{CompareURL: compareURL})
+	assert.NoError(t, err)
+	assert.Equal(t, "## Changes\n\n### Bug Fixes\n\n* a bug\n", markdown)
+
+	markdown, err = gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, &gits.MarkdownOptions{CompareURL: compareURL, CompareLink: true, CommitLinks: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "## Changes\n\n### Bug Fixes\n\n* a bug [1234567](https://github.com/jstrachan/foo/commit/1234567890)\n"+
+		"\n**Full Changelog**: "+compareURL+"\n", markdown)
+}
+
+func TestGenerateMarkdownHighlights(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "feat: something new", SHA: "111"},
+			{Message: "fix: a bug", SHA: "222"},
+			{Message: "feat(cli): another", SHA: "333"},
+		},
+	}
+	options := &gits.MarkdownOptions{Highlights: []string{"333", "111"}, CompareURL: "https://github.com/jstrachan/foo/compare/v1.0.0...v1.1.0", CompareLink: true}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "## Changes\n\n### Highlights\n\n_About 1 minute to read_\n\n* cli: another\n* something new\n\n"+
+		"<details>\n<summary>All 3 changes</summary>\n\n### New Features\n\n* something new\n* cli: another\n\n### Bug Fixes\n\n* a bug\n"+
+		"\n</details>\n\n**Full Changelog**: https://github.com/jstrachan/foo/compare/v1.0.0...v1.1.0\n", markdown)
+}
+
+func TestGenerateMarkdownReworked(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "feat: caching", SHA: "111"},
+			{Message: "fix: a bug", SHA: "222"},
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, &gits.MarkdownOptions{Reworked: map[string]bool{"111": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, "## Changes\n\n### New Features\n\n* caching (reworked)\n\n### Bug Fixes\n\n* a bug\n", markdown)
+}
+
+func TestGenerateMarkdownLabelBadges(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "fix: a bug", SHA: "111", IssueIDs: []string{"3"}},
+		},
+		Issues: []v1.IssueSummary{
+			{ID: "3", URL: "https://github.com/jstrachan/foo/issues/3", Title: "the crash", Labels: []v1.IssueLabel{{Name: "kind/bug"}, {Name: "lifecycle/stale"}}},
+		},
+	}
+	options := &gits.MarkdownOptions{
+		LabelBadges: true,
+		LabelFilter: func(label string) bool {
+			return strings.HasPrefix(label, "kind/")
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "## Changes\n\n### Bug Fixes\n\n* a bug `kind/bug` [#3](https://github.com/jstrachan/foo/issues/3) \n\n"+
+		"### Issues\n\n* [#3](https://github.com/jstrachan/foo/issues/3) the crash `kind/bug`\n", markdown)
+}
+
+func TestGenerateMarkdownDeployments(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "fix: a bug", SHA: "111"},
+		},
+	}
+	options := &gits.MarkdownOptions{
+		Deployments: []gits.Deployment{
+			{Environment: "staging", URL: "https://staging.example.com", Deployed: time.Date(2020, time.July, 1, 10, 30, 0, 0, time.UTC)},
+			{Environment: "production"},
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "## Changes\n\n### Bug Fixes\n\n* a bug\n\n"+
+		"**Deployed to**: [staging](https://staging.example.com) (2020-07-01 10:30 UTC), production\n", markdown)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "feat: cheese", SHA: "1"},
+			{Message: "wine", SHA: "2"},
+			{Message: "wine", SHA: "3"},
+		},
+	}
+	expected := "## Changes\n\n### New Features\n\n* cheese\n\n### Other Changes\n\nThese commits did not use [Conventional Commits](https://conventionalcommits.org/) formatted messages:\n\n* wine\n"
+
+	// the legend of the other changes is rendered every time
+	for i := 0; i < 2; i++ {
+		var buf strings.Builder
+		err := gits.WriteMarkdown(&buf, releaseSpec, gitInfo, &gits.MarkdownOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func BenchmarkGenerateMarkdown(b *testing.B) {
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	kinds := []string{"feat", "fix(cli)", "chore", "docs", "refactor!"}
+	releaseSpec := &v1.ReleaseSpec{}
+	for i := 0; i < 10000; i++ {
+		id := strconv.Itoa(i)
+		releaseSpec.Commits = append(releaseSpec.Commits, v1.CommitSummary{
+			Message:  kinds[i%len(kinds)] + ": change number " + id + "\n\nThe body of the change\nwith several lines\n",
+			SHA:      fmt.Sprintf("%040d", i),
+			URL:      "https://github.com/jstrachan/foo/commit/" + id,
+			Author:   &v1.UserDetails{Login: "user" + strconv.Itoa(i%50), Name: "User"},
+			IssueIDs: []string{strconv.Itoa(i % 500)},
+		})
+	}
+	for i := 0; i < 500; i++ {
+		id := strconv.Itoa(i)
+		releaseSpec.Issues = append(releaseSpec.Issues, v1.IssueSummary{
+			ID:     id,
+			URL:    "https://github.com/jstrachan/foo/issues/" + id,
+			Title:  "issue " + id,
+			User:   &v1.UserDetails{Login: "user" + strconv.Itoa(i%50)},
+			Labels: []v1.IssueLabel{{Name: "kind/bug"}},
+		})
+	}
+	options := &gits.MarkdownOptions{
+		CompareURL:  "https://github.com/jstrachan/foo/compare/v1.0.0...v1.1.0",
+		LabelBadges: true,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
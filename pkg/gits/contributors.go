@@ -0,0 +1,161 @@
+package gits
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+)
+
+// NewContributor a contributor whose first contribution to the repository is in this release
+type NewContributor struct {
+	User             *v1.UserDetails   `json:"user"`
+	FirstCommit      *v1.CommitSummary `json:"firstCommit,omitempty"`
+	FirstPullRequest *v1.IssueSummary  `json:"firstPullRequest,omitempty"`
+}
+
+// GenerateNewContributorsMarkdown generates the markdown section listing the new contributors
+func GenerateNewContributorsMarkdown(contributors []NewContributor, gitInfo *giturl.GitRepository, options *MarkdownOptions) string {
+	if len(contributors) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	buffer.WriteString("\n### New Contributors\n\n")
+	for i := range contributors {
+		c := &contributors[i]
+		user := userLink(gitInfo, c.User, options)
+		if user == "" {
+			continue
+		}
+		buffer.WriteString("* " + user + " made their first contribution")
+		if c.FirstPullRequest != nil {
+			buffer.WriteString(" in " + strings.TrimSpace(describeIssueShort(c.FirstPullRequest)))
+		} else if c.FirstCommit != nil {
+			buffer.WriteString(" in " + describeCommitShort(c.FirstCommit))
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
+// describeCommitShort returns a short link to the commit
+func describeCommitShort(cs *v1.CommitSummary) string {
+	sha := cs.SHA
+	if len(sha) > 7 {
+		sha = sha[0:7]
+	}
+	if cs.URL == "" {
+		return sha
+	}
+	return "[" + sha + "](" + cs.URL + ")"
+}
+
+// Contributor a contributor to the release along with the number of commits they authored
+type Contributor struct {
+	User    v1.UserDetails `json:"user"`
+	Commits int            `json:"commits"`
+}
+
+// Contributors aggregates the authors of the commits in the release ordered by the number of commits descending.
+// Bots and service accounts are excluded
+func Contributors(releaseSpec *v1.ReleaseSpec) []Contributor {
+	var answer []Contributor
+	indexes := map[string]int{}
+	for i := range releaseSpec.Commits {
+		cs := &releaseSpec.Commits[i]
+		user := cs.Author
+		if user == nil {
+			user = cs.Committer
+		}
+		if user == nil || user.ServiceAccount != "" {
+			continue
+		}
+		key := strings.ToLower(user.Login)
+		if key == "" {
+			key = strings.ToLower(user.Name)
+		}
+		if key == "" {
+			continue
+		}
+		idx, ok := indexes[key]
+		if !ok {
+			idx = len(answer)
+			indexes[key] = idx
+			answer = append(answer, Contributor{User: *user})
+		}
+		answer[idx].Commits++
+		if answer[idx].User.AvatarURL == "" {
+			answer[idx].User.AvatarURL = user.AvatarURL
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		if answer[i].Commits != answer[j].Commits {
+			return answer[i].Commits > answer[j].Commits
+		}
+		return contributorLabel(&answer[i]) < contributorLabel(&answer[j])
+	})
+	return answer
+}
+
+func contributorLabel(c *Contributor) string {
+	if c.User.Login != "" {
+		return strings.ToLower(c.User.Login)
+	}
+	return strings.ToLower(c.User.Name)
+}
+
+// GenerateContributorsMarkdown generates the markdown section listing the contributors and their commit counts
+func GenerateContributorsMarkdown(contributors []Contributor, gitInfo *giturl.GitRepository, options *MarkdownOptions) string {
+	if len(contributors) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	buffer.WriteString("\n### Contributors\n\n")
+	for i := range contributors {
+		c := &contributors[i]
+		user := userLink(gitInfo, &c.User, options)
+		if user == "" {
+			continue
+		}
+		buffer.WriteString("* ")
+		if options != nil && options.ContributorAvatars && c.User.AvatarURL != "" {
+			buffer.WriteString(`<img src="` + c.User.AvatarURL + `" width="20" height="20" alt="` + contributorLabel(c) + `"> `)
+		}
+		commits := "commits"
+		if c.Commits == 1 {
+			commits = "commit"
+		}
+		buffer.WriteString(fmt.Sprintf("%s (%d %s)\n", user, c.Commits, commits))
+	}
+	return buffer.String()
+}
+
+// Reviewer a reviewer who approved pull requests in the release along with the number of pull requests they approved
+type Reviewer struct {
+	User    v1.UserDetails `json:"user"`
+	Reviews int            `json:"reviews"`
+}
+
+// GenerateReviewersMarkdown generates the markdown section listing the reviewers of the pull requests in the release
+func GenerateReviewersMarkdown(reviewers []Reviewer, gitInfo *giturl.GitRepository, options *MarkdownOptions) string {
+	if len(reviewers) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	buffer.WriteString("\n### Reviewers\n\n")
+	for i := range reviewers {
+		r := &reviewers[i]
+		user := userLink(gitInfo, &r.User, options)
+		if user == "" {
+			continue
+		}
+		reviews := "reviews"
+		if r.Reviews == 1 {
+			reviews = "review"
+		}
+		buffer.WriteString(fmt.Sprintf("* %s (%d %s)\n", user, r.Reviews, reviews))
+	}
+	return buffer.String()
+}
@@ -0,0 +1,111 @@
+// +build unit
+
+package gits_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateNewContributorsMarkdown(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	contributors := []gits.NewContributor{
+		{
+			User: &v1.UserDetails{Login: "jstrachan"},
+			FirstPullRequest: &v1.IssueSummary{
+				ID:  "12",
+				URL: "https://github.com/jstrachan/foo/pull/12",
+			},
+		},
+		{
+			User: &v1.UserDetails{Name: "Some One"},
+			FirstCommit: &v1.CommitSummary{
+				SHA: "1234567890",
+				URL: "https://github.com/jstrachan/foo/commit/1234567890",
+			},
+		},
+	}
+	markdown := gits.GenerateNewContributorsMarkdown(contributors, gitInfo, nil)
+
+	expected := `
+### New Contributors
+
+* [jstrachan](https://github.com/jstrachan) made their first contribution in [#12](https://github.com/jstrachan/foo/pull/12)
+* Some One made their first contribution in [1234567](https://github.com/jstrachan/foo/commit/1234567890)
+`
+	assert.Equal(t, expected, markdown)
+	assert.Equal(t, "", gits.GenerateNewContributorsMarkdown(nil, gitInfo, nil))
+}
+
+func TestContributors(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{SHA: "1", Author: &v1.UserDetails{Login: "rawlingsj"}},
+			{SHA: "2", Author: &v1.UserDetails{Login: "jstrachan", AvatarURL: "https://avatars/jstrachan.png"}},
+			{SHA: "3", Author: &v1.UserDetails{Login: "JStrachan"}},
+			{SHA: "4", Committer: &v1.UserDetails{Name: "Some One"}},
+		},
+	}
+	contributors := gits.Contributors(releaseSpec)
+	if assert.Len(t, contributors, 3) {
+		assert.Equal(t, "jstrachan", contributors[0].User.Login)
+		assert.Equal(t, 2, contributors[0].Commits)
+		assert.Equal(t, "rawlingsj", contributors[1].User.Login)
+		assert.Equal(t, "Some One", contributors[2].User.Name)
+	}
+
+	markdown := gits.GenerateContributorsMarkdown(contributors, gitInfo, &gits.MarkdownOptions{ContributorAvatars: true})
+	expected := `
+### Contributors
+
+* <img src="https://avatars/jstrachan.png" width="20" height="20" alt="jstrachan"> [jstrachan](https://github.com/jstrachan) (2 commits)
+* [rawlingsj](https://github.com/rawlingsj) (1 commit)
+* Some One (1 commit)
+`
+	assert.Equal(t, expected, markdown)
+}
+
+func TestReviewersMarkdown(t *testing.T) {
+	t.Parallel()
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	releaseSpec := &v1.ReleaseSpec{
+		PullRequests: []v1.IssueSummary{
+			{
+				ID:    "7",
+				URL:   "https://github.com/jstrachan/foo/pull/7",
+				Title: "some change",
+				User:  &v1.UserDetails{Login: "rawlingsj"},
+			},
+		},
+	}
+	options := &gits.MarkdownOptions{
+		Reviewers: map[string][]v1.UserDetails{
+			"7": {{Login: "jstrachan"}},
+		},
+	}
+	markdown, err := gits.GenerateMarkdownWithOptions(releaseSpec, gitInfo, options)
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, "* [#7](https://github.com/jstrachan/foo/pull/7) some change ([rawlingsj](https://github.com/rawlingsj)) reviewed by [jstrachan](https://github.com/jstrachan)\n")
+
+	markdown = gits.GenerateReviewersMarkdown([]gits.Reviewer{{User: v1.UserDetails{Login: "jstrachan"}, Reviews: 2}}, gitInfo, nil)
+	assert.Equal(t, "\n### Reviewers\n\n* [jstrachan](https://github.com/jstrachan) (2 reviews)\n", markdown)
+}
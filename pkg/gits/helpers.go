@@ -2,6 +2,10 @@ package gits
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
@@ -20,35 +24,21 @@ func GetRevisionBeforeDateText(g gitclient.Interface, dir string, dateText strin
 // GetCommitPointedToByLatestTag return the SHA of the commit pointed to by the latest git tag as well as the tag name
 // for the git repo in dir
 func GetCommitPointedToByLatestTag(g gitclient.Interface, dir string) (string, string, error) {
-	tagSHA, tagName, err := NthTag(g, dir, 1)
+	commitSHA, tagName, err := nthTagCommit(g, dir, 1)
 	if err != nil {
 		return "", "", errors.Wrapf(err, "getting commit pointed to by latest tag in %s", dir)
 	}
-	if tagSHA == "" {
-		return tagSHA, tagName, nil
-	}
-	commitSHA, err := g.Command(dir, "rev-list", "-n", "1", tagSHA)
-	if err != nil {
-		return "", "", errors.Wrapf(err, "running for git rev-list -n 1 %s", tagSHA)
-	}
-	return commitSHA, tagName, err
+	return commitSHA, tagName, nil
 }
 
 // GetCommitPointedToByPreviousTag return the SHA of the commit pointed to by the latest-but-1 git tag as well as the tag
 // name for the git repo in dir
 func GetCommitPointedToByPreviousTag(g gitclient.Interface, dir string) (string, string, error) {
-	tagSHA, tagName, err := NthTag(g, dir, 2)
+	commitSHA, tagName, err := nthTagCommit(g, dir, 2)
 	if err != nil {
 		return "", "", errors.Wrapf(err, "getting commit pointed to by previous tag in %s", dir)
 	}
-	if tagSHA == "" {
-		return tagSHA, tagName, nil
-	}
-	commitSHA, err := g.Command(dir, "rev-list", "-n", "1", tagSHA)
-	if err != nil {
-		return "", "", errors.Wrapf(err, "running for git rev-list -n 1 %s", tagSHA)
-	}
-	return commitSHA, tagName, err
+	return commitSHA, tagName, nil
 }
 
 // NthTag return the SHA and tag name of nth tag in reverse chronological order from the repository at the given directory.
@@ -66,6 +56,10 @@ func NthTag(g gitclient.Interface, dir string, n int) (string, string, error) {
 		return "", "", errors.Wrapf(err, "running git %s", strings.Join(args, " "))
 	}
 
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return "", "", nil
+	}
 	tagList := strings.Split(out, "\n")
 
 	if len(tagList) < n {
@@ -104,3 +98,127 @@ func FilterTags(g gitclient.Interface, dir string, filter string) ([]string, err
 	}
 	return split, nil
 }
+
+// HasCommitsByAuthor returns true if there are any commits by the author with the given email reachable from the revision
+func HasCommitsByAuthor(g gitclient.Interface, dir string, rev string, email string) (bool, error) {
+	text, err := g.Command(dir, "log", "-1", "--fixed-strings", "--format=%H", "--author=<"+email+">", rev)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to find commits by %s before %s", email, rev)
+	}
+	return strings.TrimSpace(text) != "", nil
+}
+
+// GetRemoteDefaultBranch returns the default branch of the origin remote from its HEAD reference
+func GetRemoteDefaultBranch(g gitclient.Interface, dir string) (string, error) {
+	text, err := g.Command(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the HEAD of the origin remote in %s", dir)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(text), "origin/"), nil
+}
+
+// GetFileAtRevision returns the contents of the file at the revision and false if the file does not exist at the revision
+func GetFileAtRevision(g gitclient.Interface, dir, rev, path string) (string, bool, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	object := rev + ":" + path
+	_, err := g.Command(dir, "cat-file", "-e", object)
+	if err != nil {
+		return "", false, nil
+	}
+	text, err := g.Command(dir, "show", object)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to read %s at revision %s", path, rev)
+	}
+	return text, true, nil
+}
+
+// AuthURL adds the git credentials to an HTTP clone URL. Other URLs such as SSH URLs and local paths are unchanged
+func AuthURL(cloneURL, username, token string) (string, error) {
+	if token == "" || !strings.HasPrefix(cloneURL, "http") {
+		return cloneURL, nil
+	}
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the clone URL %s", cloneURL)
+	}
+	u.User = url.UserPassword(username, token)
+	return u.String(), nil
+}
+
+// CloneWithCredentials clones the repository into the directory or a new temporary directory if it is empty. The HTTP
+// credentials are kept in a git credential store in the git directory of the clone rather than in the remote URL so
+// that the token is not in the arguments of the git commands, their errors or the logs. Later fetches and pushes of
+// the clone use the same credentials
+func CloneWithCredentials(g gitclient.Interface, cloneURL, username, token, dir string) (string, error) {
+	args, credentials, cleanup, err := CredentialArgs(cloneURL, username, token)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	if len(args) == 0 {
+		return gitclient.CloneToDir(g, cloneURL, dir)
+	}
+	if dir == "" {
+		dir, err = ioutil.TempDir("", "jx-git-")
+		if err != nil {
+			return "", errors.Wrap(err, "failed to create temporary directory")
+		}
+	}
+	_, err = g.Command(filepath.Dir(dir), append(args, "clone", cloneURL, dir)...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to clone repository %s to directory: %s", cloneURL, dir)
+	}
+
+	file := filepath.Join(dir, ".git", "credentials")
+	err = ioutil.WriteFile(file, []byte(credentials), 0600)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write the git credentials file %s", file)
+	}
+	for _, args := range [][]string{{"config", "credential.helper", ""}, {"config", "--add", "credential.helper", credentialHelper(file)}} {
+		_, err = g.Command(dir, args...)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to configure the git credentials of %s", dir)
+		}
+	}
+	return dir, nil
+}
+
+// CredentialArgs returns the git arguments which make a git command use a temporary credential store of the HTTP
+// credentials so that the token is not in the URL, the arguments of the command, its errors or the logs along with the
+// line of the credential store. The returned function removes the credential store. There are no arguments for URLs
+// which are not HTTP URLs or if there is no token
+func CredentialArgs(gitURL, username, token string) ([]string, string, func(), error) {
+	cleanup := func() {}
+	if token == "" || !strings.HasPrefix(gitURL, "http") {
+		return nil, "", cleanup, nil
+	}
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return nil, "", cleanup, errors.Wrapf(err, "failed to parse the git URL %s", gitURL)
+	}
+	credentials := (&url.URL{Scheme: u.Scheme, Host: u.Host, User: url.UserPassword(username, token)}).String() + "\n"
+	store, err := ioutil.TempFile("", "jx-git-credentials-")
+	if err != nil {
+		return nil, "", cleanup, errors.Wrap(err, "failed to create the git credentials file")
+	}
+	cleanup = func() {
+		os.Remove(store.Name()) //nolint:errcheck
+	}
+	_, err = store.WriteString(credentials)
+	if err == nil {
+		err = store.Close()
+	}
+	if err != nil {
+		cleanup()
+		return nil, "", func() {}, errors.Wrap(err, "failed to write the git credentials file")
+	}
+	// lets ignore any other credential helpers so that git neither prompts nor uses other credentials
+	return []string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper(store.Name())}, credentials, cleanup, nil
+}
+
+// credentialHelper returns the git credential helper of the credential store file
+func credentialHelper(file string) string {
+	return "store --file='" + file + "'"
+}
@@ -0,0 +1,68 @@
+// +build unit
+
+package gits_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneWithCredentials(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	git := func(dir string, args ...string) string {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git(root, "init", "-q", "--bare", "myrepo.git")
+	work := filepath.Join(root, "work")
+	git(root, "clone", "-q", filepath.Join(root, "myrepo.git"), work)
+	git(work, "-c", "user.email=jane@foo.com", "-c", "user.name=Jane Doe", "commit", "-q", "--allow-empty", "-m", "initial import")
+	git(work, "push", "-q", "origin", "HEAD")
+
+	gitPath, err := exec.LookPath("git")
+	require.NoError(t, err)
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=true"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "jane" || password != "mytoken" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	g := cli.NewCLIClient("", nil)
+	dir, err := gits.CloneWithCredentials(g, server.URL+"/myrepo.git", "jane", "mytoken", filepath.Join(root, "clone"))
+	require.NoError(t, err)
+	assert.Equal(t, "initial import", git(dir, "log", "-1", "--format=%s"))
+
+	config, err := ioutil.ReadFile(filepath.Join(dir, ".git", "config"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(config), "mytoken", "the token should not be in the remote URL")
+	git(dir, "fetch", "-q", "origin")
+
+	_, err = gits.CloneWithCredentials(g, server.URL+"/myrepo.git", "jane", "wrongtoken", filepath.Join(root, "failed"))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "wrongtoken", "the token should not be in the error")
+}
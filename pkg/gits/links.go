@@ -0,0 +1,45 @@
+package gits
+
+import (
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+// CommitURL returns the web URL of the commit on the git provider of the given kind
+func CommitURL(gitInfo *giturl.GitRepository, gitKind string, sha string) string {
+	if gitInfo == nil || sha == "" {
+		return ""
+	}
+	httpURL := gitInfo.HttpsURL()
+	if httpURL == "" {
+		return ""
+	}
+	switch gitKind {
+	case "gitlab":
+		return stringhelpers.UrlJoin(httpURL, "-", "commit", sha)
+	case "bitbucket", "bitbucketcloud", "bitbucketserver", "stash":
+		return stringhelpers.UrlJoin(httpURL, "commits", sha)
+	default:
+		return stringhelpers.UrlJoin(httpURL, "commit", sha)
+	}
+}
+
+// CompareURL returns the web URL comparing the two revisions on the git provider of the given kind
+// or an empty string if the provider has no compare page
+func CompareURL(gitInfo *giturl.GitRepository, gitKind string, fromRev string, toRev string) string {
+	if gitInfo == nil || fromRev == "" || toRev == "" {
+		return ""
+	}
+	httpURL := gitInfo.HttpsURL()
+	if httpURL == "" {
+		return ""
+	}
+	switch gitKind {
+	case "gitlab":
+		return stringhelpers.UrlJoin(httpURL, "-", "compare", fromRev+"..."+toRev)
+	case "bitbucket", "bitbucketcloud", "bitbucketserver", "stash":
+		return ""
+	default:
+		return stringhelpers.UrlJoin(httpURL, "compare", fromRev+"..."+toRev)
+	}
+}
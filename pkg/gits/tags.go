@@ -0,0 +1,99 @@
+package gits
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/pkg/errors"
+)
+
+// tagFormat the for-each-ref format of the name, object and peeled commit of the tags along with their commit dates
+const tagFormat = "--format=%(refname)%00%(objectname)%00%(*objectname)%00%(committerdate:iso-strict)%00%(*committerdate:iso-strict)"
+
+// Tag a git tag along with the commit it points to
+type Tag struct {
+	Name string
+	SHA  string
+	Date time.Time
+}
+
+// ListTags returns the tags newest first along with the commits they point to via a single git for-each-ref. If any
+// names are specified only the tags of those names are returned. If count is positive at most count tags are returned
+func ListTags(g gitclient.Interface, dir string, count int, names ...string) ([]Tag, error) {
+	args := []string{"for-each-ref", "--sort=-creatordate", tagFormat}
+	if count > 0 {
+		args = append(args, fmt.Sprintf("--count=%d", count))
+	}
+	if len(names) == 0 {
+		args = append(args, "refs/tags")
+	}
+	for _, name := range names {
+		args = append(args, "refs/tags/"+name)
+	}
+	out, err := g.Command(dir, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "running git %s", strings.Join(args, " "))
+	}
+	return parseTags(out, names)
+}
+
+func parseTags(out string, names []string) ([]Tag, error) {
+	var answer []Tag
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x00")
+		if len(fields) < 2 {
+			return nil, errors.Errorf("unexpected format for returned tag and sha: '%s'", line)
+		}
+		name := strings.TrimPrefix(fields[0], "refs/tags/")
+		// lets ignore tags nested below the names such as 'v1.0.0/foo'
+		if len(names) > 0 && !contains(names, name) {
+			continue
+		}
+		tag := Tag{Name: name, SHA: fields[1]}
+		date := ""
+		if len(fields) > 3 {
+			date = fields[3]
+		}
+		// lets use the commit of annotated tags rather than the tag object
+		if len(fields) > 4 && fields[2] != "" {
+			tag.SHA = fields[2]
+			date = fields[4]
+		}
+		if date != "" {
+			var err error
+			tag.Date, err = time.Parse(time.RFC3339, date)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the date %s of tag %s", date, tag.Name)
+			}
+		}
+		answer = append(answer, tag)
+	}
+	return answer, nil
+}
+
+// nthTagCommit returns the commit SHA and name of the nth tag in reverse chronological order or empty strings if
+// there is no such tag
+func nthTagCommit(g gitclient.Interface, dir string, n int) (string, string, error) {
+	tags, err := ListTags(g, dir, n)
+	if err != nil {
+		return "", "", err
+	}
+	if len(tags) < n {
+		return "", "", nil
+	}
+	return tags[n-1].SHA, tags[n-1].Name, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
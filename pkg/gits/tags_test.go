@@ -0,0 +1,69 @@
+// +build unit
+
+package gits_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTags(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	git := func(date string, args ...string) string {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		c.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		out, err := c.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(date, message string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(message), 0600))
+		git(date, "add", "-A")
+		git(date, "commit", "-q", "-m", message)
+		return git(date, "rev-parse", "HEAD")
+	}
+	git("", "init", "-q")
+	git("", "config", "user.email", "jane@foo.com")
+	git("", "config", "user.name", "Jane Doe")
+	first := commit("2020-05-02T10:00:00Z", "initial import")
+	git("2020-05-02T10:00:00Z", "tag", "v1.0.0")
+	second := commit("2020-07-14T10:00:00Z", "feat: something new")
+	git("2020-07-15T10:00:00Z", "tag", "-a", "-m", "the release", "v1.1.0")
+
+	g := cli.NewCLIClient("", nil)
+	tags, err := gits.ListTags(g, dir, 0)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "v1.1.0", tags[0].Name)
+	assert.Equal(t, second, tags[0].SHA, "should resolve the commit of the annotated tag")
+	assert.True(t, time.Date(2020, time.July, 14, 10, 0, 0, 0, time.UTC).Equal(tags[0].Date))
+	assert.Equal(t, "v1.0.0", tags[1].Name)
+	assert.Equal(t, first, tags[1].SHA)
+
+	tags, err = gits.ListTags(g, dir, 0, "1.0.0", "v1.0.0")
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "v1.0.0", tags[0].Name)
+
+	sha, name, err := gits.GetCommitPointedToByPreviousTag(g, dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, sha)
+	assert.Equal(t, "v1.0.0", name)
+
+	sha, name, err = gits.GetCommitPointedToByLatestTag(g, dir)
+	require.NoError(t, err)
+	assert.Equal(t, second, sha)
+	assert.Equal(t, "v1.1.0", name)
+}
@@ -0,0 +1,39 @@
+package gits
+
+import (
+	"regexp"
+	"strings"
+)
+
+var trailerRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// ParseTrailers parses the trailers of a commit message such as 'Ticket: ABC-123' or 'Signed-off-by: ...'.
+// The trailers are the lines of the last paragraph of the message if they all have the form 'Key: value'.
+// Repeated keys have their values joined with a comma
+func ParseTrailers(message string) map[string]string {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	lines := strings.Split(paragraphs[len(paragraphs)-1], "\n")
+	answer := map[string]string{}
+	lastKey := ""
+	for _, line := range lines {
+		if lastKey != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			// folded continuation of the previous trailer
+			answer[lastKey] += " " + strings.TrimSpace(line)
+			continue
+		}
+		m := trailerRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		key, value := m[1], strings.TrimSpace(m[2])
+		if existing, ok := answer[key]; ok {
+			value = existing + ", " + value
+		}
+		answer[key] = value
+		lastKey = key
+	}
+	return answer
+}
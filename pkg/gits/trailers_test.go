@@ -0,0 +1,42 @@
+// +build unit
+
+package gits_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrailers(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		message  string
+		expected map[string]string
+	}{
+		{
+			message: "fix: something\n\nsome description\n\nTicket: ABC-123\nRisk: low\nReviewed-by: a\nReviewed-by: b\n",
+			expected: map[string]string{
+				"Ticket":      "ABC-123",
+				"Risk":        "low",
+				"Reviewed-by": "a, b",
+			},
+		},
+		{
+			message: "fix: something\n\nRollback: revert the commit\n  and redeploy",
+			expected: map[string]string{
+				"Rollback": "revert the commit and redeploy",
+			},
+		},
+		{
+			message: "Ticket: ABC-123",
+		},
+		{
+			message: "fix: something\n\nsome description\nTicket: ABC-123",
+		},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, gits.ParseTrailers(tc.message), "trailers for message %q", tc.message)
+	}
+}
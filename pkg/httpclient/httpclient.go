@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/transport"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// Options tunes the connection pooling, keep-alives and timeouts of the HTTP clients of the git provider and the
+// issue trackers. A single transport is created and shared by every client so that their connections are reused
+type Options struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	KeepAlive           time.Duration
+	Timeout             time.Duration
+	DisableHTTP2        bool
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+// AddFlags adds the flags of the options
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&o.MaxIdleConns, "http-max-idle-conns", "", 100, "The maximum number of idle connections kept open to the git provider and issue trackers. Zero means no limit")
+	cmd.Flags().IntVarP(&o.MaxIdleConnsPerHost, "http-max-idle-conns-per-host", "", 10, "The maximum number of idle connections kept open to each host. Larger values avoid reconnecting to the git provider when requests overlap")
+	cmd.Flags().IntVarP(&o.MaxConnsPerHost, "http-max-conns-per-host", "", 0, "The maximum number of connections to each host. Zero means no limit")
+	cmd.Flags().DurationVarP(&o.IdleConnTimeout, "http-idle-conn-timeout", "", 90*time.Second, "How long idle connections are kept open")
+	cmd.Flags().DurationVarP(&o.KeepAlive, "http-keep-alive", "", 30*time.Second, "The interval of the TCP keep-alives of the connections. Negative disables them")
+	cmd.Flags().DurationVarP(&o.Timeout, "http-timeout", "", 0, "The maximum time of each request to the git provider and the issue trackers such as '30s'. Zero means no limit")
+	cmd.Flags().BoolVarP(&o.DisableHTTP2, "http-disable-http2", "", false, "Uses HTTP/1.1 rather than HTTP/2 such as for git servers behind proxies which do not support HTTP/2")
+}
+
+// Transport returns the transport shared by the clients creating it on first use
+func (o *Options) Transport() *http.Transport {
+	o.once.Do(func() {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: o.KeepAlive}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.DialContext = dialer.DialContext
+		t.MaxIdleConns = o.MaxIdleConns
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		t.MaxConnsPerHost = o.MaxConnsPerHost
+		t.IdleConnTimeout = o.IdleConnTimeout
+		t.ForceAttemptHTTP2 = !o.DisableHTTP2
+		if o.DisableHTTP2 {
+			// a non nil empty map disables the upgrade to HTTP/2
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		o.transport = t
+	})
+	return o.transport
+}
+
+// Client returns a client of the shared transport
+func (o *Options) Client() *http.Client {
+	return &http.Client{Transport: o.Transport(), Timeout: o.Timeout}
+}
+
+// Tune makes the client of the git provider use the shared transport beneath its authentication
+func (o *Options) Tune(client *scm.Client) {
+	if client == nil {
+		return
+	}
+	httpClient := http.Client{}
+	if client.Client != nil {
+		httpClient = *client.Client
+	}
+	httpClient.Transport = o.base(httpClient.Transport)
+	if o.Timeout > 0 {
+		httpClient.Timeout = o.Timeout
+	}
+	client.Client = &httpClient
+}
+
+// base returns a copy of the authentication transport using the shared transport as its base
+func (o *Options) base(rt http.RoundTripper) http.RoundTripper {
+	switch t := rt.(type) {
+	case nil:
+		return o.Transport()
+	case *http.Transport:
+		if t == http.DefaultTransport {
+			return o.Transport()
+		}
+		return t
+	case *oauth2.Transport:
+		c := *t
+		c.Base = o.base(t.Base)
+		return &c
+	case *transport.Authorization:
+		c := *t
+		c.Base = o.base(t.Base)
+		return &c
+	case *transport.BasicAuth:
+		c := *t
+		c.Base = o.base(t.Base)
+		return &c
+	case *transport.BearerToken:
+		c := *t
+		c.Base = o.base(t.Base)
+		return &c
+	case *transport.Custom:
+		c := *t
+		c.Base = o.base(t.Base)
+		return &c
+	case *transport.PrivateToken:
+		c := *t
+		c.Base = o.base(t.Base)
+		return &c
+	default:
+		log.Logger().Debugf("cannot tune the HTTP transport %T of the git provider", rt)
+		return rt
+	}
+}
@@ -0,0 +1,68 @@
+// +build unit
+
+package httpclient_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/httpclient"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTune(t *testing.T) {
+	lock := sync.Mutex{}
+	connections := 0
+	var authorizations []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"login":"jstrachan"}`) //nolint:errcheck
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			lock.Lock()
+			connections++
+			lock.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	o := &httpclient.Options{MaxIdleConnsPerHost: 2, IdleConnTimeout: time.Minute, Timeout: 10 * time.Second, DisableHTTP2: true}
+	assert.Same(t, o.Transport(), o.Transport(), "the transport should be shared")
+	assert.Empty(t, o.Transport().TLSNextProto, "HTTP/2 should be disabled")
+	assert.Equal(t, 2, o.Transport().MaxIdleConnsPerHost)
+
+	for i := 0; i < 2; i++ {
+		scmClient, err := factory.NewClient("github", server.URL, "mytoken")
+		require.NoError(t, err)
+		o.Tune(scmClient)
+		assert.Equal(t, 10*time.Second, scmClient.Client.Timeout)
+
+		user, _, err := scmClient.Users.Find(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "jstrachan", user.Login)
+	}
+
+	resp, err := o.Client().Get(server.URL)
+	require.NoError(t, err)
+	ioutil.ReadAll(resp.Body) //nolint:errcheck
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"Bearer mytoken", "Bearer mytoken", ""}, authorizations)
+	assert.Equal(t, 1, connections, "the clients should reuse the same connection")
+
+	o.Tune(nil)
+}
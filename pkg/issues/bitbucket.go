@@ -0,0 +1,156 @@
+package issues
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+// BitbucketIssueProvider looks up the issues and pull requests of a Bitbucket Cloud repository. Pull requests are
+// numbered separately from issues so they are referenced with the PullRequestPrefix such as '!12'
+type BitbucketIssueProvider struct {
+	*GitIssueProvider
+
+	lock       sync.Mutex
+	milestones map[string]string
+}
+
+type bitbucketLink struct {
+	Href string `json:"href"`
+}
+
+type bitbucketUser struct {
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+	Links       struct {
+		HTML   bitbucketLink `json:"html"`
+		Avatar bitbucketLink `json:"avatar"`
+	} `json:"links"`
+}
+
+type bitbucketIssue struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Content     struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	State     string         `json:"state"`
+	Reporter  *bitbucketUser `json:"reporter"`
+	Author    *bitbucketUser `json:"author"`
+	Assignee  *bitbucketUser `json:"assignee"`
+	ClosedBy  *bitbucketUser `json:"closed_by"`
+	CreatedOn time.Time      `json:"created_on"`
+	UpdatedOn time.Time      `json:"updated_on"`
+	Milestone *struct {
+		Name string `json:"name"`
+	} `json:"milestone"`
+	Links struct {
+		HTML bitbucketLink `json:"html"`
+	} `json:"links"`
+}
+
+// GetIssue returns the issue or the pull request if the key has the PullRequestPrefix
+func (i *BitbucketIssueProvider) GetIssue(key string) (*scm.Issue, error) {
+	kind := "issues"
+	pullRequest := strings.HasPrefix(key, PullRequestPrefix)
+	if pullRequest {
+		kind = "pullrequests"
+	}
+	n, err := issueKeyToNumber(strings.TrimPrefix(key, PullRequestPrefix))
+	if err != nil {
+		return nil, err
+	}
+	from := &bitbucketIssue{}
+	err = i.getJSON(fmt.Sprintf("2.0/repositories/%s/%s/%d", i.fullName, kind, n), from)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.Milestone != nil {
+		i.lock.Lock()
+		if i.milestones == nil {
+			i.milestones = map[string]string{}
+		}
+		i.milestones[key] = from.Milestone.Name
+		i.lock.Unlock()
+	}
+	body := from.Content.Raw
+	author := from.Reporter
+	if pullRequest {
+		body = from.Description
+		author = from.Author
+	}
+	state := bitbucketState(from.State)
+	answer := &scm.Issue{
+		Number:      from.ID,
+		Title:       from.Title,
+		Body:        body,
+		Link:        from.Links.HTML.Href,
+		State:       state,
+		Closed:      state != "open",
+		PullRequest: pullRequest,
+		Created:     from.CreatedOn,
+		Updated:     from.UpdatedOn,
+		Assignees:   []scm.User{},
+	}
+	if answer.Link == "" {
+		answer.Link = i.IssueURL(key)
+	}
+	if author != nil {
+		answer.Author = *author.toScmUser()
+	}
+	if from.Assignee != nil {
+		answer.Assignees = append(answer.Assignees, *from.Assignee.toScmUser())
+	}
+	if from.ClosedBy != nil {
+		answer.ClosedBy = from.ClosedBy.toScmUser()
+	}
+	return answer, nil
+}
+
+// GetMilestone returns the milestone of the issue which was looked up
+func (i *BitbucketIssueProvider) GetMilestone(key string) (string, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.milestones[key], nil
+}
+
+// IssueURL returns the URL of the issue or the pull request if the key has the PullRequestPrefix
+func (i *BitbucketIssueProvider) IssueURL(key string) string {
+	if strings.HasPrefix(key, PullRequestPrefix) {
+		return stringhelpers.UrlJoin(i.HomeURL(), "pull-requests", strings.TrimPrefix(key, PullRequestPrefix))
+	}
+	return stringhelpers.UrlJoin(i.HomeURL(), "issues", key)
+}
+
+// HomeURL returns the URL of the repository on the website rather than the API
+func (i *BitbucketIssueProvider) HomeURL() string {
+	base := strings.Replace(i.GitProvider.BaseURL.String(), "://api.bitbucket.org", "://bitbucket.org", 1)
+	return stringhelpers.UrlJoin(base, i.Owner, i.Repository)
+}
+
+// bitbucketState converts the state of Bitbucket issues and pull requests to 'open', 'closed' or 'merged'
+func bitbucketState(state string) string {
+	switch strings.ToLower(state) {
+	case "new", "open", "on hold":
+		return "open"
+	case "merged":
+		return "merged"
+	default:
+		return "closed"
+	}
+}
+
+func (u *bitbucketUser) toScmUser() *scm.User {
+	return &scm.User{
+		Login:  u.Nickname,
+		Name:   u.DisplayName,
+		Avatar: u.Links.Avatar.Href,
+		Link:   u.Links.HTML.Href,
+	}
+}
@@ -2,8 +2,12 @@ package issues
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
@@ -15,10 +19,14 @@ type GitIssueProvider struct {
 	GitProvider *scm.Client
 	Owner       string
 	Repository  string
+	Ctx         context.Context
 	fullName    string
 }
 
-func CreateGitIssueProvider(scmClient *scm.Client, owner string, repository string) (IssueProvider, error) {
+// CreateGitIssueProvider creates an issue provider for the repository whose git provider requests use the given context.
+// GitHub uses an adapter which looks up issues in batches via GraphQL. GitLab and Bitbucket Cloud use adapters as their
+// pull requests are numbered separately from their issues
+func CreateGitIssueProvider(ctx context.Context, scmClient *scm.Client, owner string, repository string) (IssueProvider, error) {
 	if owner == "" {
 		return nil, fmt.Errorf("no owner specified")
 	}
@@ -26,16 +34,28 @@ func CreateGitIssueProvider(scmClient *scm.Client, owner string, repository stri
 		return nil, fmt.Errorf("no repository specified")
 	}
 	fullName := scm.Join(owner, repository)
-	return &GitIssueProvider{
+	provider := &GitIssueProvider{
 		GitProvider: scmClient,
 		Owner:       owner,
 		Repository:  repository,
+		Ctx:         ctx,
 		fullName:    fullName,
-	}, nil
+	}
+	if scmClient != nil {
+		switch scmClient.Driver {
+		case scm.DriverGithub:
+			return &GitHubIssueProvider{GitIssueProvider: provider}, nil
+		case scm.DriverGitlab:
+			return &GitLabIssueProvider{GitIssueProvider: provider}, nil
+		case scm.DriverBitbucket:
+			return &BitbucketIssueProvider{GitIssueProvider: provider}, nil
+		}
+	}
+	return provider, nil
 }
 
 func (i *GitIssueProvider) GetIssue(key string) (*scm.Issue, error) {
-	ctx := context.Background()
+	ctx := i.context()
 	n, err := issueKeyToNumber(key)
 	if err != nil {
 		return nil, err
@@ -48,7 +68,7 @@ func (i *GitIssueProvider) GetIssue(key string) (*scm.Issue, error) {
 }
 
 func (i *GitIssueProvider) SearchIssues(query string) ([]*scm.Issue, error) {
-	ctx := context.Background()
+	ctx := i.context()
 	opts := scm.SearchOptions{
 		Query: query,
 	}
@@ -86,7 +106,7 @@ func (i *GitIssueProvider) CreateIssue(_ *scm.Issue) (*scm.Issue, error) {
 }
 
 func (i *GitIssueProvider) CreateIssueComment(key string, comment string) error {
-	ctx := context.Background()
+	ctx := i.context()
 	n, err := issueKeyToNumber(key)
 	if err != nil {
 		return err
@@ -99,6 +119,34 @@ func (i *GitIssueProvider) CreateIssueComment(key string, comment string) error
 	return nil
 }
 
+func (i *GitIssueProvider) context() context.Context {
+	if i.Ctx == nil {
+		return context.Background()
+	}
+	return i.Ctx
+}
+
+// getJSON performs the request to the REST API of the git provider and parses the JSON reply
+func (i *GitIssueProvider) getJSON(path string, out interface{}) error {
+	res, err := i.GitProvider.Do(i.context(), &scm.Request{Method: http.MethodGet, Path: path})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %s", path)
+	}
+	defer res.Body.Close()
+	if res.Status == http.StatusNotFound {
+		return errors.Wrapf(scm.ErrNotFound, "failed to get %s", path)
+	}
+	if res.Status >= 300 {
+		data, _ := ioutil.ReadAll(res.Body)
+		return errors.Errorf("failed to get %s: status %d %s", path, res.Status, strings.TrimSpace(string(data)))
+	}
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return nil
+}
+
 func (i *GitIssueProvider) HomeURL() string {
 	return stringhelpers.UrlJoin(i.GitProvider.BaseURL.String(), i.Owner, i.Repository)
 }
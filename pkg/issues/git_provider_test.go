@@ -0,0 +1,383 @@
+// +build unit
+
+package issues_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/go-scm/scm/driver/bitbucket"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	"github.com/jenkins-x/go-scm/scm/driver/gitlab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveJSON(routes map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body)) //nolint:errcheck
+	}))
+}
+
+func TestGitLabIssueProvider(t *testing.T) {
+	t.Parallel()
+	server := serveJSON(map[string]string{
+		"/api/v4/projects/myorg%2Fmyrepo/issues/3": `{"iid": 3, "title": "a bug", "state": "closed", "web_url": "https://gitlab.com/myorg/myrepo/-/issues/3",
+			"author": {"username": "jdoe"}, "closed_by": {"username": "jroe"}, "milestone": {"title": "v1.2"}}`,
+		"/api/v4/projects/myorg%2Fmyrepo/merge_requests/3": `{"iid": 3, "title": "fix the bug", "state": "merged", "labels": ["bug"],
+			"author": {"username": "jroe"}, "merged_by": {"username": "jdoe"}}`,
+	})
+	defer server.Close()
+	client, err := gitlab.New(server.URL)
+	require.NoError(t, err)
+
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), client, "myorg", "myrepo")
+	require.NoError(t, err)
+	require.IsType(t, &issues.GitLabIssueProvider{}, tracker)
+	assert.True(t, issues.SeparatePullRequests(tracker))
+
+	issue, err := tracker.GetIssue("3")
+	require.NoError(t, err)
+	assert.Equal(t, "a bug", issue.Title)
+	assert.Equal(t, "closed", issue.State)
+	assert.False(t, issue.PullRequest)
+	assert.Equal(t, "jroe", issue.ClosedBy.Login)
+
+	mr, err := tracker.GetIssue("!3")
+	require.NoError(t, err)
+	assert.Equal(t, "fix the bug", mr.Title)
+	assert.Equal(t, "merged", mr.State)
+	assert.True(t, mr.PullRequest)
+	assert.Equal(t, []string{"bug"}, mr.Labels)
+	assert.Equal(t, "jdoe", mr.ClosedBy.Login)
+
+	milestones := tracker.(issues.MilestoneProvider)
+	milestone, err := milestones.GetMilestone("3")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2", milestone)
+	milestone, err = milestones.GetMilestone("!3")
+	require.NoError(t, err)
+	assert.Empty(t, milestone)
+
+	assert.Equal(t, server.URL+"/myorg/myrepo/-/merge_requests/3", tracker.IssueURL("!3"))
+	assert.Equal(t, server.URL+"/myorg/myrepo/-/issues/3", tracker.IssueURL("3"))
+
+	_, err = tracker.GetIssue("4")
+	assert.Error(t, err)
+}
+
+func TestBitbucketIssueProvider(t *testing.T) {
+	t.Parallel()
+	server := serveJSON(map[string]string{
+		"/2.0/repositories/myorg/myrepo/issues/3": `{"id": 3, "title": "a bug", "state": "resolved", "content": {"raw": "it broke"},
+			"reporter": {"nickname": "jdoe"}, "assignee": {"nickname": "jroe"}, "milestone": {"name": "v1.2"},
+			"links": {"html": {"href": "https://bitbucket.org/myorg/myrepo/issues/3"}}}`,
+		"/2.0/repositories/myorg/myrepo/pullrequests/3": `{"id": 3, "title": "fix the bug", "state": "MERGED", "description": "fixes #3",
+			"author": {"nickname": "jroe"}, "closed_by": {"nickname": "jdoe"}}`,
+	})
+	defer server.Close()
+	client, err := bitbucket.New(server.URL)
+	require.NoError(t, err)
+
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), client, "myorg", "myrepo")
+	require.NoError(t, err)
+	require.IsType(t, &issues.BitbucketIssueProvider{}, tracker)
+	assert.True(t, issues.SeparatePullRequests(tracker))
+
+	issue, err := tracker.GetIssue("3")
+	require.NoError(t, err)
+	assert.Equal(t, "it broke", issue.Body)
+	assert.Equal(t, "closed", issue.State)
+	assert.Equal(t, "jdoe", issue.Author.Login)
+	assert.Equal(t, "jroe", issue.Assignees[0].Login)
+	assert.False(t, issue.PullRequest)
+
+	pr, err := tracker.GetIssue("!3")
+	require.NoError(t, err)
+	assert.Equal(t, "fixes #3", pr.Body)
+	assert.Equal(t, "merged", pr.State)
+	assert.True(t, pr.PullRequest)
+	assert.Equal(t, server.URL+"/myorg/myrepo/pull-requests/3", pr.Link)
+
+	milestone, err := tracker.(issues.MilestoneProvider).GetMilestone("3")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2", milestone)
+}
+
+func TestGitHubIssueProvider(t *testing.T) {
+	t.Parallel()
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/graphql":
+			body := map[string]interface{}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			query := body["query"].(string)
+			queries = append(queries, query)
+			assert.Equal(t, map[string]interface{}{"owner": "myorg", "name": "myrepo"}, body["variables"])
+			if strings.Contains(query, "i199:") {
+				w.Write([]byte(`{"data": {"repository": {}}}`)) //nolint:errcheck
+				return
+			}
+			w.Write([]byte(`{"data": {"repository": {
+				"i3": {"__typename": "Issue", "number": 3, "title": "a bug", "state": "CLOSED", "url": "https://github.com/myorg/myrepo/issues/3",
+					"author": {"login": "jdoe", "name": "John Doe"}, "labels": {"nodes": [{"name": "bug"}]}, "assignees": {"nodes": [{"login": "jroe"}]},
+					"milestone": {"title": "v1.2"}, "timelineItems": {"nodes": [{"actor": {"login": "jroe"}}]}},
+				"i4": {"__typename": "PullRequest", "number": 4, "title": "fix the bug", "state": "MERGED",
+					"author": {"login": "jroe"}, "labels": {"nodes": []}, "assignees": {"nodes": []}, "mergedBy": {"login": "jdoe"}},
+				"i5": null}},
+				"errors": [{"type": "NOT_FOUND", "message": "Could not resolve to an issue or pull request with the number of 5."}]}`)) //nolint:errcheck
+		case "/repos/myorg/myrepo/issues/5":
+			w.Write([]byte(`{"number": 5, "title": "via rest", "state": "open", "user": {"login": "jdoe"}}`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, err := github.New(server.URL)
+	require.NoError(t, err)
+
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), client, "myorg", "myrepo")
+	require.NoError(t, err)
+	require.IsType(t, &issues.GitHubIssueProvider{}, tracker)
+	assert.False(t, issues.SeparatePullRequests(tracker))
+
+	keys := []string{"3", "4", "5", "PROJ-1"}
+	for n := 100; n < 200; n++ {
+		keys = append(keys, strconv.Itoa(n))
+	}
+	err = tracker.(issues.BatchIssueProvider).PrefetchIssues(keys)
+	require.NoError(t, err)
+	require.Len(t, queries, 2, "should query the issues in batches of %d", issues.GitHubBatchSize)
+
+	issue, err := tracker.GetIssue("3")
+	require.NoError(t, err)
+	assert.Equal(t, "a bug", issue.Title)
+	assert.Equal(t, "closed", issue.State)
+	assert.False(t, issue.PullRequest)
+	assert.Equal(t, "John Doe", issue.Author.Name)
+	assert.Equal(t, []string{"bug"}, issue.Labels)
+	assert.Equal(t, "jroe", issue.Assignees[0].Login)
+	assert.Equal(t, "jroe", issue.ClosedBy.Login)
+
+	pr, err := tracker.GetIssue("4")
+	require.NoError(t, err)
+	assert.Equal(t, "closed", pr.State)
+	assert.True(t, pr.PullRequest)
+	assert.Equal(t, "jdoe", pr.ClosedBy.Login)
+
+	milestone, err := tracker.(issues.MilestoneProvider).GetMilestone("3")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2", milestone)
+
+	issue, err = tracker.GetIssue("5")
+	require.NoError(t, err)
+	assert.Equal(t, "via rest", issue.Title, "should look up the issues which were not prefetched via REST")
+	assert.Len(t, queries, 2)
+}
+
+func TestCommitPullRequests(t *testing.T) {
+	t.Parallel()
+	server := serveJSON(map[string]string{
+		"/repos/myorg/myrepo/commits/abc123/pulls": `[{"number": 7, "title": "feat: caching", "html_url": "https://github.com/myorg/myrepo/pull/7",
+			"state": "closed", "merged_at": "2020-07-01T10:00:00Z", "labels": [{"name": "enhancement"}]}]`,
+		"/api/v4/projects/myorg%2Fmyrepo/repository/commits/abc123/merge_requests": `[{"iid": 8, "title": "fix: crash", "state": "merged",
+			"web_url": "https://gitlab.com/myorg/myrepo/-/merge_requests/8", "labels": ["bug"]}]`,
+	})
+	defer server.Close()
+
+	githubClient, err := github.New(server.URL)
+	require.NoError(t, err)
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), githubClient, "myorg", "myrepo")
+	require.NoError(t, err)
+	prs, err := tracker.(issues.CommitPullRequestProvider).CommitPullRequests("abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []issues.CommitPullRequest{{Key: "7", Number: 7, Title: "feat: caching", URL: "https://github.com/myorg/myrepo/pull/7",
+		State: "closed", Labels: []string{"enhancement"}, Merged: true}}, prs)
+
+	gitlabClient, err := gitlab.New(server.URL)
+	require.NoError(t, err)
+	tracker, err = issues.CreateGitIssueProvider(context.TODO(), gitlabClient, "myorg", "myrepo")
+	require.NoError(t, err)
+	prs, err = tracker.(issues.CommitPullRequestProvider).CommitPullRequests("abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []issues.CommitPullRequest{{Key: "!8", Number: 8, Title: "fix: crash", URL: "https://gitlab.com/myorg/myrepo/-/merge_requests/8",
+		State: "merged", Labels: []string{"bug"}, Merged: true}}, prs)
+}
+
+func TestMilestoneIssues(t *testing.T) {
+	t.Parallel()
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.EscapedPath() {
+		case "/graphql":
+			body := map[string]interface{}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			variables := body["variables"].(map[string]interface{})
+			searches = append(searches, variables["query"].(string))
+			if variables["after"] == nil {
+				w.Write([]byte(`{"data": {"search": {"pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+					{"__typename": "Issue", "number": 3, "title": "a bug", "state": "CLOSED", "labels": {"nodes": []}, "assignees": {"nodes": []}, "milestone": {"title": "v1.4.0"}},
+					{"__typename": "PullRequest", "number": 4, "title": "rejected", "state": "CLOSED", "labels": {"nodes": []}, "assignees": {"nodes": []}}]}}}`)) //nolint:errcheck
+				return
+			}
+			w.Write([]byte(`{"data": {"search": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"__typename": "PullRequest", "number": 5, "title": "fix the bug", "state": "MERGED", "labels": {"nodes": []}, "assignees": {"nodes": []}, "milestone": {"title": "v1.4.0"}}]}}}`)) //nolint:errcheck
+		case "/api/v4/projects/myorg%2Fmyrepo/issues":
+			assert.Equal(t, "v1.4.0", r.URL.Query().Get("milestone"))
+			assert.Equal(t, "closed", r.URL.Query().Get("state"))
+			w.Write([]byte(`[{"iid": 3, "title": "a bug", "state": "closed"}]`)) //nolint:errcheck
+		case "/api/v4/projects/myorg%2Fmyrepo/merge_requests":
+			assert.Equal(t, "merged", r.URL.Query().Get("state"))
+			w.Write([]byte(`[{"iid": 3, "title": "fix the bug", "state": "merged"}]`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	githubClient, err := github.New(server.URL)
+	require.NoError(t, err)
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), githubClient, "myorg", "myrepo")
+	require.NoError(t, err)
+	keys, err := tracker.(issues.MilestoneIssuesProvider).MilestoneIssues("v1.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "5"}, keys, "should skip the pull requests closed without being merged")
+	assert.Equal(t, []string{`repo:myorg/myrepo milestone:"v1.4.0" is:closed`, `repo:myorg/myrepo milestone:"v1.4.0" is:closed`}, searches)
+	pr, err := tracker.GetIssue("5")
+	require.NoError(t, err)
+	assert.True(t, pr.PullRequest)
+	milestone, err := tracker.(issues.MilestoneProvider).GetMilestone("5")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.4.0", milestone)
+
+	gitlabClient, err := gitlab.New(server.URL)
+	require.NoError(t, err)
+	tracker, err = issues.CreateGitIssueProvider(context.TODO(), gitlabClient, "myorg", "myrepo")
+	require.NoError(t, err)
+	keys, err = tracker.(issues.MilestoneIssuesProvider).MilestoneIssues("v1.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "!3"}, keys)
+	mr, err := tracker.GetIssue("!3")
+	require.NoError(t, err)
+	assert.Equal(t, "fix the bug", mr.Title)
+	assert.Equal(t, "merged", mr.State)
+}
+
+func TestMergedPullRequests(t *testing.T) {
+	t.Parallel()
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.EscapedPath() {
+		case "/graphql":
+			body := map[string]interface{}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			searches = append(searches, body["variables"].(map[string]interface{})["query"].(string))
+			w.Write([]byte(`{"data": {"search": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"__typename": "PullRequest", "number": 5, "title": "fix the bug", "state": "MERGED", "labels": {"nodes": []}, "assignees": {"nodes": []}}]}}}`)) //nolint:errcheck
+		case "/api/v4/projects/myorg%2Fmyrepo/merge_requests":
+			assert.Equal(t, "main", r.URL.Query().Get("target_branch"))
+			assert.Equal(t, "2020-07-01T00:00:00Z", r.URL.Query().Get("updated_after"))
+			w.Write([]byte(`[{"iid": 3, "title": "fix the bug", "state": "merged", "merged_at": "2020-07-02T10:00:00Z"},
+				{"iid": 4, "title": "too late", "state": "merged", "merged_at": "2020-08-02T10:00:00Z"}]`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	since := time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2020, time.August, 1, 0, 0, 0, 0, time.UTC)
+
+	githubClient, err := github.New(server.URL)
+	require.NoError(t, err)
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), githubClient, "myorg", "myrepo")
+	require.NoError(t, err)
+	keys, err := tracker.(issues.MergedPullRequestsProvider).MergedPullRequests("main", since, until)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5"}, keys)
+	_, err = tracker.(issues.MergedPullRequestsProvider).MergedPullRequests("", since, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`repo:myorg/myrepo is:pr is:merged base:"main" merged:2020-07-01T00:00:00Z..2020-08-01T00:00:00Z`,
+		`repo:myorg/myrepo is:pr is:merged merged:>=2020-07-01T00:00:00Z`,
+	}, searches)
+
+	gitlabClient, err := gitlab.New(server.URL)
+	require.NoError(t, err)
+	tracker, err = issues.CreateGitIssueProvider(context.TODO(), gitlabClient, "myorg", "myrepo")
+	require.NoError(t, err)
+	keys, err = tracker.(issues.MergedPullRequestsProvider).MergedPullRequests("main", since, until)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"!3"}, keys, "should filter the merge requests on their merge time")
+	mr, err := tracker.GetIssue("!3")
+	require.NoError(t, err)
+	assert.True(t, mr.PullRequest)
+}
+
+func TestProjectBoard(t *testing.T) {
+	t.Parallel()
+	var mutations []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		query := body["query"].(string)
+		variables := body["variables"].(map[string]interface{})
+		switch {
+		case strings.Contains(query, "repositoryOwner"):
+			assert.Equal(t, map[string]interface{}{"owner": "myorg", "number": float64(5), "field": "Status"}, variables)
+			w.Write([]byte(`{"data": {"repositoryOwner": {"projectV2": {"id": "P1", "field": {"id": "F1",
+				"options": [{"id": "O1", "name": "In Progress"}, {"id": "O2", "name": "Released"}]}}}}}`)) //nolint:errcheck
+		case strings.Contains(query, "issueOrPullRequest"):
+			if variables["number"] == float64(3) {
+				w.Write([]byte(`{"data": {"repository": {"issueOrPullRequest": {"id": "I3", "projectItems": {"nodes": [{"id": "PI3", "project": {"id": "P1"}}]}}}}}`)) //nolint:errcheck
+				return
+			}
+			w.Write([]byte(`{"data": {"repository": {"issueOrPullRequest": {"id": "I4", "projectItems": {"nodes": [{"id": "X", "project": {"id": "P2"}}]}}}}}`)) //nolint:errcheck
+		case strings.Contains(query, "addProjectV2ItemById"):
+			mutations = append(mutations, variables)
+			w.Write([]byte(`{"data": {"addProjectV2ItemById": {"item": {"id": "PI4"}}}}`)) //nolint:errcheck
+		case strings.Contains(query, "updateProjectV2ItemFieldValue"):
+			mutations = append(mutations, variables)
+			w.Write([]byte(`{"data": {"updateProjectV2ItemFieldValue": {"projectV2Item": {"id": "x"}}}}`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, err := github.New(server.URL)
+	require.NoError(t, err)
+	tracker, err := issues.CreateGitIssueProvider(context.TODO(), client, "myorg", "myrepo")
+	require.NoError(t, err)
+	boards := tracker.(issues.ProjectBoardProvider)
+
+	_, err = boards.FindProjectBoard("myorg", 5, "Status", "Done")
+	assert.Error(t, err)
+	board, err := boards.FindProjectBoard("myorg", 5, "Status", "released")
+	require.NoError(t, err)
+	assert.Equal(t, &issues.ProjectBoard{ProjectID: "P1", FieldID: "F1", OptionID: "O2"}, board)
+
+	require.NoError(t, boards.SetProjectStatus(board, "3"))
+	require.NoError(t, boards.SetProjectStatus(board, "4"))
+	assert.Equal(t, []map[string]interface{}{
+		{"project": "P1", "item": "PI3", "field": "F1", "option": "O2"},
+		{"project": "P1", "content": "I4"},
+		{"project": "P1", "item": "PI4", "field": "F1", "option": "O2"},
+	}, mutations)
+}
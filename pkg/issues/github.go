@@ -0,0 +1,354 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// GitHubBatchSize the number of issues and pull requests looked up by each GraphQL query
+const GitHubBatchSize = 100
+
+// githubIssueFields the GraphQL fields of the issues and pull requests
+const githubIssueFields = `number title body url state createdAt updatedAt
+author { login avatarUrl ... on User { name email } }
+labels(first: 100) { nodes { name } }
+assignees(first: 20) { nodes { login avatarUrl name email } }
+milestone { title }`
+
+// githubIssueFragments the GraphQL fragments of the issues and pull requests along with the users who closed them
+var githubIssueFragments = fmt.Sprintf(`... on Issue { %s
+  timelineItems(itemTypes: [CLOSED_EVENT], last: 1) { nodes { ... on ClosedEvent { actor { login avatarUrl } } } } }
+... on PullRequest { %s
+  mergedBy { login avatarUrl } }`, githubIssueFields, githubIssueFields)
+
+// GitHubIssueProvider looks up the issues and pull requests of a GitHub repository in batches via the GraphQL API.
+// Issues which have not been prefetched are looked up via the REST API
+type GitHubIssueProvider struct {
+	*GitIssueProvider
+
+	lock       sync.Mutex
+	issues     map[string]*scm.Issue
+	milestones map[string]string
+}
+
+type githubUser struct {
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatarUrl"`
+}
+
+type githubIssue struct {
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	URL       string      `json:"url"`
+	State     string      `json:"state"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Author    *githubUser `json:"author"`
+	MergedBy  *githubUser `json:"mergedBy"`
+	Labels    struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignees struct {
+		Nodes []githubUser `json:"nodes"`
+	} `json:"assignees"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	TimelineItems *struct {
+		Nodes []struct {
+			Actor *githubUser `json:"actor"`
+		} `json:"nodes"`
+	} `json:"timelineItems"`
+	Typename string `json:"__typename"`
+}
+
+// GetIssue returns the prefetched issue or looks it up via the REST API
+func (i *GitHubIssueProvider) GetIssue(key string) (*scm.Issue, error) {
+	if issue := i.cached(key); issue != nil {
+		return issue, nil
+	}
+	return i.GitIssueProvider.GetIssue(key)
+}
+
+// GetMilestone returns the milestone of the prefetched issue or pull request
+func (i *GitHubIssueProvider) GetMilestone(key string) (string, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.milestones[key], nil
+}
+
+// PrefetchIssues looks up the issues and pull requests of the keys via GraphQL queries of GitHubBatchSize keys.
+// Keys which are not found are left for GetIssue to report
+func (i *GitHubIssueProvider) PrefetchIssues(keys []string) error {
+	var numbers []int
+	for _, key := range keys {
+		n, err := strconv.Atoi(key)
+		if err == nil && i.cached(key) == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	for len(numbers) > 0 {
+		batch := numbers
+		if len(batch) > GitHubBatchSize {
+			batch = batch[:GitHubBatchSize]
+		}
+		numbers = numbers[len(batch):]
+		err := i.prefetchBatch(batch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *GitHubIssueProvider) prefetchBatch(numbers []int) error {
+	var buf strings.Builder
+	buf.WriteString("query($owner: String!, $name: String!) {\n  repository(owner: $owner, name: $name) {\n")
+	for _, n := range numbers {
+		fmt.Fprintf(&buf, "    i%d: issueOrPullRequest(number: %d) { __typename\n%s }\n", n, n, githubIssueFragments)
+	}
+	buf.WriteString("  }\n}\n")
+
+	var reply struct {
+		Data struct {
+			Repository map[string]*githubIssue `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err := i.postGraphQL(buf.String(), map[string]interface{}{"owner": i.Owner, "name": i.Repository}, &reply)
+	if err != nil {
+		return err
+	}
+	for _, e := range reply.Errors {
+		// lets leave the missing issues for GetIssue to report
+		if e.Type != "NOT_FOUND" {
+			return errors.Errorf("failed to query the issues of repository %s: %s", i.fullName, e.Message)
+		}
+	}
+	for _, from := range reply.Data.Repository {
+		if from != nil && from.Number != 0 {
+			i.cache(from)
+		}
+	}
+	return nil
+}
+
+// MilestoneIssues searches for the issues closed and the pull requests merged in the milestone caching them so that
+// GetIssue does not look them up again
+func (i *GitHubIssueProvider) MilestoneIssues(milestone string) ([]string, error) {
+	keys, err := i.search(fmt.Sprintf("repo:%s milestone:%q is:closed", i.fullName, milestone))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to search the issues of milestone %s", milestone)
+	}
+	return keys, nil
+}
+
+// MergedPullRequests searches for the pull requests merged into the base branch between the times caching them so
+// that GetIssue does not look them up again
+func (i *GitHubIssueProvider) MergedPullRequests(base string, since, until time.Time) ([]string, error) {
+	query := fmt.Sprintf("repo:%s is:pr is:merged", i.fullName)
+	if base != "" {
+		query += fmt.Sprintf(" base:%q", base)
+	}
+	switch {
+	case !since.IsZero() && !until.IsZero():
+		query += " merged:" + since.UTC().Format(time.RFC3339) + ".." + until.UTC().Format(time.RFC3339)
+	case !since.IsZero():
+		query += " merged:>=" + since.UTC().Format(time.RFC3339)
+	case !until.IsZero():
+		query += " merged:<=" + until.UTC().Format(time.RFC3339)
+	}
+	keys, err := i.search(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to search the pull requests merged into %s", base)
+	}
+	return keys, nil
+}
+
+// search pages through the issues and pull requests of the GraphQL search query returning their keys. Pull requests
+// which were closed without being merged are ignored
+func (i *GitHubIssueProvider) search(searchQuery string) ([]string, error) {
+	query := "query($query: String!, $after: String) {\n  search(query: $query, type: ISSUE, first: 100, after: $after) {\n" +
+		"    pageInfo { hasNextPage endCursor }\n    nodes { __typename\n" + githubIssueFragments + " }\n  }\n}\n"
+	variables := map[string]interface{}{"query": searchQuery}
+	var keys []string
+	for {
+		var reply struct {
+			Data struct {
+				Search struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []*githubIssue `json:"nodes"`
+				} `json:"search"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		err := i.postGraphQL(query, variables, &reply)
+		if err != nil {
+			return nil, err
+		}
+		if len(reply.Errors) > 0 {
+			return nil, errors.Errorf("failed to search repository %s: %s", i.fullName, reply.Errors[0].Message)
+		}
+		search := reply.Data.Search
+		for _, from := range search.Nodes {
+			if from == nil || from.Number == 0 || (from.Typename == "PullRequest" && from.State != "MERGED") {
+				continue
+			}
+			keys = append(keys, i.cache(from))
+		}
+		if !search.PageInfo.HasNextPage {
+			return keys, nil
+		}
+		variables["after"] = search.PageInfo.EndCursor
+	}
+}
+
+// cached returns the cached issue or pull request of the key
+func (i *GitHubIssueProvider) cached(key string) *scm.Issue {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.issues[key]
+}
+
+// cache caches the issue or pull request returning its key
+func (i *GitHubIssueProvider) cache(from *githubIssue) string {
+	key := strconv.Itoa(from.Number)
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.issues == nil {
+		i.issues = map[string]*scm.Issue{}
+		i.milestones = map[string]string{}
+	}
+	i.issues[key] = from.toIssue()
+	if from.Milestone != nil {
+		i.milestones[key] = from.Milestone.Title
+	}
+	return key
+}
+
+// toIssue converts the GraphQL issue or pull request using the lower case states of the REST API
+func (from *githubIssue) toIssue() *scm.Issue {
+	state := strings.ToLower(from.State)
+	if state == "merged" {
+		state = "closed"
+	}
+	answer := &scm.Issue{
+		Number:      from.Number,
+		Title:       from.Title,
+		Body:        from.Body,
+		Link:        from.URL,
+		State:       state,
+		Closed:      state == "closed",
+		PullRequest: from.Typename == "PullRequest",
+		Created:     from.CreatedAt,
+		Updated:     from.UpdatedAt,
+		Assignees:   []scm.User{},
+	}
+	if from.Author != nil {
+		answer.Author = from.Author.toUser()
+	}
+	for _, l := range from.Labels.Nodes {
+		answer.Labels = append(answer.Labels, l.Name)
+	}
+	for _, u := range from.Assignees.Nodes {
+		answer.Assignees = append(answer.Assignees, u.toUser())
+	}
+	closedBy := from.MergedBy
+	if from.TimelineItems != nil && len(from.TimelineItems.Nodes) > 0 {
+		closedBy = from.TimelineItems.Nodes[0].Actor
+	}
+	if closedBy != nil {
+		u := closedBy.toUser()
+		answer.ClosedBy = &u
+	}
+	return answer
+}
+
+func (u *githubUser) toUser() scm.User {
+	return scm.User{Login: u.Login, Name: u.Name, Email: u.Email, Avatar: u.AvatarURL}
+}
+
+// postGraphQL posts the query to the GraphQL API of the git provider and parses the JSON reply
+func (i *GitHubIssueProvider) postGraphQL(query string, variables map[string]interface{}, out interface{}) error {
+	endpoint := "graphql"
+	if i.GitProvider.GraphQLURL != nil {
+		endpoint = i.GitProvider.GraphQLURL.String()
+	}
+	data, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the GraphQL query")
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	res, err := i.GitProvider.Do(i.context(), &scm.Request{Method: http.MethodPost, Path: endpoint, Header: header, Body: bytes.NewReader(data)})
+	if err != nil {
+		return errors.Wrapf(err, "failed to post to %s", endpoint)
+	}
+	defer res.Body.Close()
+	if res.Status >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return errors.Errorf("failed to post to %s: status %d %s", endpoint, res.Status, strings.TrimSpace(string(body)))
+	}
+	err = json.NewDecoder(res.Body).Decode(out)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the reply of %s", endpoint)
+	}
+	return nil
+}
+
+// CommitPullRequests returns the pull requests associated with the commit
+func (i *GitHubIssueProvider) CommitPullRequests(sha string) ([]CommitPullRequest, error) {
+	var from []struct {
+		Number   int        `json:"number"`
+		Title    string     `json:"title"`
+		HTMLURL  string     `json:"html_url"`
+		State    string     `json:"state"`
+		MergedAt *time.Time `json:"merged_at"`
+		Labels   []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	err := i.getJSON(fmt.Sprintf("repos/%s/commits/%s/pulls", i.fullName, sha), &from)
+	if err != nil {
+		return nil, err
+	}
+	var answer []CommitPullRequest
+	for _, pr := range from {
+		cpr := CommitPullRequest{
+			Key:    strconv.Itoa(pr.Number),
+			Number: pr.Number,
+			Title:  pr.Title,
+			URL:    pr.HTMLURL,
+			State:  pr.State,
+			Merged: pr.MergedAt != nil,
+		}
+		for _, l := range pr.Labels {
+			cpr.Labels = append(cpr.Labels, l.Name)
+		}
+		answer = append(answer, cpr)
+	}
+	return answer, nil
+}
@@ -0,0 +1,250 @@
+package issues
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+// GitLabIssueProvider looks up the issues and merge requests of a GitLab project. Merge requests are numbered
+// separately from issues so they are referenced with the PullRequestPrefix such as '!12'
+type GitLabIssueProvider struct {
+	*GitIssueProvider
+
+	lock       sync.Mutex
+	issues     map[string]*scm.Issue
+	milestones map[string]string
+}
+
+type gitlabUser struct {
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+	WebURL    string `json:"web_url"`
+}
+
+type gitlabIssue struct {
+	IID         int          `json:"iid"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	State       string       `json:"state"`
+	WebURL      string       `json:"web_url"`
+	Labels      []string     `json:"labels"`
+	Author      gitlabUser   `json:"author"`
+	Assignees   []gitlabUser `json:"assignees"`
+	ClosedBy    *gitlabUser  `json:"closed_by"`
+	MergedBy    *gitlabUser  `json:"merged_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	MergedAt    *time.Time   `json:"merged_at"`
+	Milestone   *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+}
+
+// GetIssue returns the issue or the merge request if the key has the PullRequestPrefix
+func (i *GitLabIssueProvider) GetIssue(key string) (*scm.Issue, error) {
+	i.lock.Lock()
+	issue := i.issues[key]
+	i.lock.Unlock()
+	if issue != nil {
+		return issue, nil
+	}
+	kind := "issues"
+	mergeRequest := strings.HasPrefix(key, PullRequestPrefix)
+	if mergeRequest {
+		kind = "merge_requests"
+	}
+	n, err := issueKeyToNumber(strings.TrimPrefix(key, PullRequestPrefix))
+	if err != nil {
+		return nil, err
+	}
+	from := &gitlabIssue{}
+	err = i.getJSON(fmt.Sprintf("api/v4/projects/%s/%s/%d", strings.ReplaceAll(i.fullName, "/", "%2F"), kind, n), from)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.Milestone != nil {
+		i.cache(key, nil, from.Milestone.Title)
+	}
+	return from.toIssue(mergeRequest), nil
+}
+
+// cache caches the issue or merge request if not nil and its milestone if not empty
+func (i *GitLabIssueProvider) cache(key string, issue *scm.Issue, milestone string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.issues == nil {
+		i.issues = map[string]*scm.Issue{}
+		i.milestones = map[string]string{}
+	}
+	if issue != nil {
+		i.issues[key] = issue
+	}
+	if milestone != "" {
+		i.milestones[key] = milestone
+	}
+}
+
+// MilestoneIssues finds the issues closed and the merge requests merged in the milestone caching them so that
+// GetIssue does not look them up again
+func (i *GitLabIssueProvider) MilestoneIssues(milestone string) ([]string, error) {
+	var keys []string
+	for _, kind := range []string{"issues", "merge_requests"} {
+		state := "closed"
+		prefix := ""
+		if kind == "merge_requests" {
+			state = "merged"
+			prefix = PullRequestPrefix
+		}
+		for page := 1; ; page++ {
+			var from []gitlabIssue
+			err := i.getJSON(fmt.Sprintf("api/v4/projects/%s/%s?milestone=%s&state=%s&per_page=100&page=%d",
+				strings.ReplaceAll(i.fullName, "/", "%2F"), kind, url.QueryEscape(milestone), state, page), &from)
+			if err != nil {
+				return nil, err
+			}
+			for k := range from {
+				key := prefix + strconv.Itoa(from[k].IID)
+				i.cache(key, from[k].toIssue(kind == "merge_requests"), milestone)
+				keys = append(keys, key)
+			}
+			if len(from) < 100 {
+				break
+			}
+		}
+	}
+	return keys, nil
+}
+
+// MergedPullRequests finds the merge requests merged into the target branch between the times caching them so that
+// GetIssue does not look them up again
+func (i *GitLabIssueProvider) MergedPullRequests(base string, since, until time.Time) ([]string, error) {
+	path := fmt.Sprintf("api/v4/projects/%s/merge_requests?state=merged&order_by=updated_at&per_page=100", strings.ReplaceAll(i.fullName, "/", "%2F"))
+	if base != "" {
+		path += "&target_branch=" + url.QueryEscape(base)
+	}
+	if !since.IsZero() {
+		// lets narrow down the search as merge requests are updated when merged then filter them on the merge time
+		path += "&updated_after=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	}
+	var keys []string
+	for page := 1; ; page++ {
+		var from []gitlabIssue
+		err := i.getJSON(fmt.Sprintf("%s&page=%d", path, page), &from)
+		if err != nil {
+			return nil, err
+		}
+		for k := range from {
+			mr := &from[k]
+			if mr.MergedAt == nil || mr.MergedAt.Before(since) || (!until.IsZero() && mr.MergedAt.After(until)) {
+				continue
+			}
+			key := PullRequestPrefix + strconv.Itoa(mr.IID)
+			milestone := ""
+			if mr.Milestone != nil {
+				milestone = mr.Milestone.Title
+			}
+			i.cache(key, mr.toIssue(true), milestone)
+			keys = append(keys, key)
+		}
+		if len(from) < 100 {
+			return keys, nil
+		}
+	}
+}
+
+// toIssue converts the GitLab issue or merge request
+func (from *gitlabIssue) toIssue(mergeRequest bool) *scm.Issue {
+	answer := &scm.Issue{
+		Number:      from.IID,
+		Title:       from.Title,
+		Body:        from.Description,
+		Link:        from.WebURL,
+		State:       gitlabState(from.State),
+		Labels:      from.Labels,
+		Closed:      from.State != "opened",
+		Author:      *from.Author.toScmUser(),
+		PullRequest: mergeRequest,
+		Created:     from.CreatedAt,
+		Updated:     from.UpdatedAt,
+		Assignees:   []scm.User{},
+	}
+	for k := range from.Assignees {
+		answer.Assignees = append(answer.Assignees, *from.Assignees[k].toScmUser())
+	}
+	closedBy := from.ClosedBy
+	if from.MergedBy != nil {
+		closedBy = from.MergedBy
+	}
+	if closedBy != nil {
+		answer.ClosedBy = closedBy.toScmUser()
+	}
+	return answer
+}
+
+// GetMilestone returns the milestone of the issue or merge request which was looked up
+func (i *GitLabIssueProvider) GetMilestone(key string) (string, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.milestones[key], nil
+}
+
+// CommitPullRequests returns the merge requests associated with the commit
+func (i *GitLabIssueProvider) CommitPullRequests(sha string) ([]CommitPullRequest, error) {
+	var from []gitlabIssue
+	err := i.getJSON(fmt.Sprintf("api/v4/projects/%s/repository/commits/%s/merge_requests", strings.ReplaceAll(i.fullName, "/", "%2F"), sha), &from)
+	if err != nil {
+		return nil, err
+	}
+	var answer []CommitPullRequest
+	for k := range from {
+		mr := &from[k]
+		answer = append(answer, CommitPullRequest{
+			Key:    PullRequestPrefix + strconv.Itoa(mr.IID),
+			Number: mr.IID,
+			Title:  mr.Title,
+			URL:    mr.WebURL,
+			State:  gitlabState(mr.State),
+			Labels: mr.Labels,
+			Merged: mr.State == "merged",
+		})
+	}
+	return answer, nil
+}
+
+// IssueURL returns the URL of the issue or the merge request if the key has the PullRequestPrefix
+func (i *GitLabIssueProvider) IssueURL(key string) string {
+	if strings.HasPrefix(key, PullRequestPrefix) {
+		return stringhelpers.UrlJoin(i.GitProvider.BaseURL.String(), i.fullName, "-", "merge_requests", strings.TrimPrefix(key, PullRequestPrefix))
+	}
+	return stringhelpers.UrlJoin(i.GitProvider.BaseURL.String(), i.fullName, "-", "issues", key)
+}
+
+// gitlabState converts the state of GitLab issues and merge requests to 'open', 'closed' or 'merged'
+func gitlabState(state string) string {
+	switch state {
+	case "opened", "locked":
+		return "open"
+	case "merged":
+		return "merged"
+	default:
+		return "closed"
+	}
+}
+
+func (u *gitlabUser) toScmUser() *scm.User {
+	return &scm.User{
+		Login:  u.Username,
+		Name:   u.Name,
+		Avatar: u.AvatarURL,
+		Link:   u.WebURL,
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/andygrunwald/go-jira"
@@ -13,23 +16,53 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// JiraAPITokenEnv the environment variable of the API token or personal access token of the Jira server
+	JiraAPITokenEnv = "JIRA_API_TOKEN"
+
+	// JiraUsernameEnv the environment variable of the user name or email of the API token of the Jira server
+	JiraUsernameEnv = "JIRA_USERNAME"
+
+	// JiraBatchSize the maximum number of issues looked up by each search of PrefetchIssues
+	JiraBatchSize = 50
+)
+
 type JiraService struct {
 	JiraClient *jira.Client
 	ServerURL  string
 	Project    string
+
+	lock   sync.Mutex
+	issues map[string]*scm.Issue
 }
 
-func CreateJiraIssueProvider(serverURL, username, apiToken, project string, batchMode bool) (IssueProvider, error) {
+// CreateJiraIssueProvider creates the issue provider of the Jira server. The API token is used with basic
+// authentication if there is a user name, such as the email of a Jira Cloud account, otherwise as the personal
+// access token of a Jira Server or Data Center. The authentication wraps the transport of the HTTP client so that
+// its connections are shared. Defaults to http.DefaultClient if the client is nil
+func CreateJiraIssueProvider(client *http.Client, serverURL, username, apiToken, project string, batchMode bool) (IssueProvider, error) {
 	if serverURL == "" {
 		return nil, fmt.Errorf("no JIRA server URL for server")
 	}
-	var httpClient *http.Client
-	if apiToken != "" {
-		tp := jira.BasicAuthTransport{
-			Username: username,
-			Password: apiToken,
+	httpClient := http.Client{}
+	if client != nil {
+		httpClient = *client
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if apiToken != "" && username == "" {
+		httpClient.Transport = &jiraBearerTransport{token: apiToken, next: next}
+		if batchMode {
+			log.Logger().Infof("Using JIRA server %s with a personal access token", serverURL)
+		}
+	} else if apiToken != "" {
+		httpClient.Transport = &jira.BasicAuthTransport{
+			Username:  username,
+			Password:  apiToken,
+			Transport: next,
 		}
-		httpClient = tp.Client()
 		if batchMode {
 			log.Logger().Infof("Using JIRA server %s user name %s and an API token", serverURL, username)
 		}
@@ -38,7 +71,10 @@ func CreateJiraIssueProvider(serverURL, username, apiToken, project string, batc
 			log.Logger().Warnf("No authentication found for JIRA server %s so using anonymous access", serverURL)
 		}
 	}
-	jiraClient, _ := jira.NewClient(httpClient, serverURL)
+	jiraClient, err := jira.NewClient(&httpClient, serverURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid JIRA server URL %s", serverURL)
+	}
 	return &JiraService{
 		JiraClient: jiraClient,
 		ServerURL:  serverURL,
@@ -46,12 +82,61 @@ func CreateJiraIssueProvider(serverURL, username, apiToken, project string, batc
 	}, nil
 }
 
+// GetIssue returns the prefetched issue or looks it up via the REST API
 func (i *JiraService) GetIssue(key string) (*scm.Issue, error) {
+	if issue := i.cached(key); issue != nil {
+		return issue, nil
+	}
 	issue, _, err := i.JiraClient.Issue.Get(key, nil)
 	if err != nil {
 		return nil, err
 	}
-	return i.jiraToGitIssue(issue), nil
+	return i.cache(issue), nil
+}
+
+// PrefetchIssues looks up the issues of the keys via JQL searches of JiraBatchSize keys
+func (i *JiraService) PrefetchIssues(keys []string) error {
+	var missing []string
+	for _, key := range keys {
+		if strings.Contains(key, "-") && i.cached(key) == nil {
+			missing = append(missing, key)
+		}
+	}
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > JiraBatchSize {
+			batch = batch[:JiraBatchSize]
+		}
+		missing = missing[len(batch):]
+		jql := "key in (" + strings.Join(batch, ", ") + ")"
+		found, _, err := i.JiraClient.Issue.Search(jql, &jira.SearchOptions{MaxResults: len(batch), ValidateQuery: "warn"})
+		if err != nil {
+			return errors.Wrapf(err, "failed to search the issues %s", strings.Join(batch, ", "))
+		}
+		for k := range found {
+			i.cache(&found[k])
+		}
+	}
+	return nil
+}
+
+// cached returns the cached issue of the key
+func (i *JiraService) cached(key string) *scm.Issue {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.issues[key]
+}
+
+// cache converts and caches the issue
+func (i *JiraService) cache(issue *jira.Issue) *scm.Issue {
+	answer := i.jiraToGitIssue(issue)
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.issues == nil {
+		i.issues = map[string]*scm.Issue{}
+	}
+	i.issues[issue.Key] = answer
+	return answer
 }
 
 func (i *JiraService) SearchIssues(query string) ([]*scm.Issue, error) {
@@ -71,9 +156,17 @@ func (i *JiraService) SearchIssues(query string) ([]*scm.Issue, error) {
 	return answer, nil
 }
 
-func (i *JiraService) SearchIssuesClosedSince(_ time.Time) ([]*scm.Issue, error) {
-	log.Logger().Warn("TODO SearchIssuesClosedSince() not yet implemented for JIRA")
-	return nil, nil
+func (i *JiraService) SearchIssuesClosedSince(t time.Time) ([]*scm.Issue, error) {
+	jql := fmt.Sprintf("project = %s AND resolved >= '%s'", i.Project, t.Format("2006/01/02 15:04"))
+	var answer []*scm.Issue
+	issues, _, err := i.JiraClient.Issue.Search(jql, nil)
+	if err != nil {
+		return answer, err
+	}
+	for k := range issues {
+		answer = append(answer, i.jiraToGitIssue(&issues[k]))
+	}
+	return answer, nil
 }
 
 func (i *JiraService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
@@ -106,24 +199,78 @@ func (i *JiraService) CreateIssueComment(_ string, _ string) error {
 	return fmt.Errorf("TODO")
 }
 
+// EnsureVersion creates or updates the version of the project
+func (i *JiraService) EnsureVersion(version *ProjectVersion) (string, error) {
+	project, _, err := i.JiraClient.Project.Get(i.Project)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not find project %s", i.Project)
+	}
+	jv := &jira.Version{
+		Name:        version.Name,
+		Description: version.Description,
+		Released:    version.Released,
+		ReleaseDate: version.ReleaseDate,
+	}
+	for k := range project.Versions {
+		existing := &project.Versions[k]
+		if existing.Name != version.Name {
+			continue
+		}
+		jv.ID = existing.ID
+		updated, _, err := i.JiraClient.Version.Update(jv)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to update version %s of project %s", version.Name, i.Project)
+		}
+		return updated.ID, nil
+	}
+	jv.ProjectID, err = strconv.Atoi(project.ID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the ID %s of project %s", project.ID, i.Project)
+	}
+	created, _, err := i.JiraClient.Version.Create(jv)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create version %s of project %s", version.Name, i.Project)
+	}
+	return created.ID, nil
+}
+
+// AddIssueVersion adds the version to the fix versions of the issue
+func (i *JiraService) AddIssueVersion(key, version string) error {
+	data := map[string]interface{}{
+		"update": map[string]interface{}{
+			"fixVersions": []interface{}{
+				map[string]interface{}{"add": map[string]string{"name": version}},
+			},
+		},
+	}
+	_, err := i.JiraClient.Issue.UpdateIssue(key, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add the fix version %s to issue %s", version, key)
+	}
+	return nil
+}
+
 func (i *JiraService) IssueURL(key string) string {
 	return stringhelpers.UrlJoin(i.ServerURL, "browse", key)
 }
 
 func (i *JiraService) jiraToGitIssue(issue *jira.Issue) *scm.Issue {
-	answer := &scm.Issue{}
-	key := issue.Key
-	// TODO
-	//answer.Key = key
-	answer.Link = i.IssueURL(key)
+	answer := &scm.Issue{
+		Link:      i.IssueURL(issue.Key),
+		Assignees: []scm.User{},
+	}
 	fields := issue.Fields
 	if fields != nil {
 		answer.Title = fields.Summary
 		answer.Body = fields.Description
-		/// TODO
-		//answer.Labels = gits.ToGitLabels(fields.Labels)
-		// TODO
-		//answer.ClosedAt = jiraTimeToTimeP(fields.Resolutiondate)
+		answer.Labels = fields.Labels
+		answer.Created = time.Time(fields.Created)
+		answer.Updated = time.Time(fields.Updated)
+		if fields.Status != nil {
+			// lets use the name of the workflow status such as 'In Progress' or 'Done'
+			answer.State = fields.Status.Name
+			answer.Closed = fields.Status.StatusCategory.Key == jira.StatusCategoryComplete
+		}
 		user := jiraUserToGitUser(fields.Reporter)
 		if user != nil {
 			answer.Author = *user
@@ -140,10 +287,14 @@ func jiraUserToGitUser(user *jira.User) *scm.User {
 	if user == nil {
 		return nil
 	}
+	// the login is left empty as Jira accounts are not accounts of the git provider so should not be looked up there
+	name := user.DisplayName
+	if name == "" {
+		name = user.Name
+	}
 	return &scm.User{
 		Avatar: jiraAvatarUrl(user),
-		Name:   user.Name,
-		Login:  user.Key,
+		Name:   name,
 		Email:  user.EmailAddress,
 	}
 }
@@ -186,5 +337,20 @@ func (i *JiraService) ServerName() string {
 }
 
 func (i *JiraService) HomeURL() string {
+	if i.Project == "" {
+		return i.ServerURL
+	}
 	return stringhelpers.UrlJoin(i.ServerURL, "browse", i.Project)
 }
+
+// jiraBearerTransport authenticates the requests with a personal access token
+type jiraBearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *jiraBearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req2 := req.Clone(req.Context())
+	req2.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req2)
+}
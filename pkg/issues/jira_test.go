@@ -0,0 +1,99 @@
+// +build unit
+
+package issues_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jiraIssueFields = `"fields": {"summary": "the login fails", "description": "it fails", "labels": ["bug"],
+	"created": "2020-09-01T10:00:00.000+0000",
+	"status": {"name": "Done", "statusCategory": {"key": "done"}},
+	"reporter": {"accountId": "5b10a", "displayName": "Jane Doe", "emailAddress": "jane@foo.com"},
+	"assignee": {"accountId": "5b10b", "displayName": "John Roe", "avatarUrls": {"48x48": "https://acme.atlassian.net/avatar.png"}}}`
+
+func TestJiraIssueProvider(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	var auth atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		auth.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/rest/api/2/issue/PROJ-1":
+			w.Write([]byte(`{"key": "PROJ-1", ` + jiraIssueFields + `}`)) //nolint:errcheck
+		case "/rest/api/2/search":
+			assert.Equal(t, "key in (PROJ-2, PROJ-3)", r.URL.Query().Get("jql"))
+			w.Write([]byte(`{"issues": [{"key": "PROJ-2", ` + jiraIssueFields + `}, {"key": "PROJ-3", "fields": {"summary": "search", "status": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}}}]}`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateJiraIssueProvider(nil, server.URL, "", "my-token", "PROJ", false)
+	require.NoError(t, err)
+	assert.Equal(t, issues.Jira, issues.GetIssueProvider(tracker))
+	assert.Equal(t, server.URL+"/browse/PROJ", tracker.HomeURL())
+
+	issue, err := tracker.GetIssue("PROJ-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer my-token", auth.Load(), "the token should be used as a personal access token without a user name")
+	assert.Equal(t, "the login fails", issue.Title)
+	assert.Equal(t, server.URL+"/browse/PROJ-1", issue.Link)
+	assert.Equal(t, "Done", issue.State)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, []string{"bug"}, issue.Labels)
+	assert.Equal(t, 2020, issue.Created.Year())
+	assert.Equal(t, "Jane Doe", issue.Author.Name)
+	assert.Equal(t, "jane@foo.com", issue.Author.Email)
+	assert.Empty(t, issue.Author.Login, "Jira accounts should not be looked up on the git provider")
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "John Roe", issue.Assignees[0].Name)
+	assert.Equal(t, "https://acme.atlassian.net/avatar.png", issue.Assignees[0].Avatar)
+
+	batch, ok := tracker.(issues.BatchIssueProvider)
+	require.True(t, ok, "the Jira issue provider should look up issues in batches")
+	require.NoError(t, batch.PrefetchIssues([]string{"PROJ-1", "PROJ-2", "PROJ-3"}))
+	count := atomic.LoadInt32(&requests)
+	for _, key := range []string{"PROJ-2", "PROJ-3"} {
+		_, err = tracker.GetIssue(key)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, count, atomic.LoadInt32(&requests), "the prefetched issues should not be looked up again")
+	issue, err = tracker.GetIssue("PROJ-3")
+	require.NoError(t, err)
+	assert.Equal(t, "In Progress", issue.State)
+	assert.False(t, issue.Closed)
+	assert.NotNil(t, issue.Assignees, "unassigned issues should have no assignees rather than unknown assignees")
+
+	_, err = tracker.GetIssue("PROJ-4")
+	assert.Error(t, err)
+
+	shared := &countingTransport{next: http.DefaultTransport}
+	tracker, err = issues.CreateJiraIssueProvider(&http.Client{Transport: shared}, server.URL, "jane@foo.com", "my-token", "PROJ", false)
+	require.NoError(t, err)
+	_, err = tracker.GetIssue("PROJ-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Basic amFuZUBmb28uY29tOm15LXRva2Vu", auth.Load(), "the token should be used with basic authentication with a user name")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&shared.requests), "the requests should use the transport of the shared client")
+}
+
+// countingTransport counts the requests made via the transport
+type countingTransport struct {
+	requests int32
+	next     http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,198 @@
+package issues
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ProjectBoard a board of a GitHub project along with the option of its single select field which the issues are
+// moved to
+type ProjectBoard struct {
+	ProjectID string
+	FieldID   string
+	OptionID  string
+}
+
+// ProjectBoardProvider is implemented by the issue providers which can update the project boards tracking the issues
+type ProjectBoardProvider interface {
+	// FindProjectBoard finds the project of the owner along with the option of its single select field such as the
+	// 'Released' option of the 'Status' field
+	FindProjectBoard(owner string, number int, field, option string) (*ProjectBoard, error)
+
+	// SetProjectStatus adds the issue or pull request to the project if required then sets the field of its item to
+	// the option of the board
+	SetProjectStatus(board *ProjectBoard, key string) error
+}
+
+// FindProjectBoard finds the GitHub project (v2) of the organisation or user via GraphQL
+func (i *GitHubIssueProvider) FindProjectBoard(owner string, number int, field, option string) (*ProjectBoard, error) {
+	query := `query($owner: String!, $number: Int!, $field: String!) {
+  repositoryOwner(login: $owner) {
+    ... on Organization { projectV2(number: $number) { ...board } }
+    ... on User { projectV2(number: $number) { ...board } }
+  }
+}
+fragment board on ProjectV2 {
+  id
+  field(name: $field) { ... on ProjectV2SingleSelectField { id options { id name } } }
+}
+`
+	var reply struct {
+		graphQLReply
+		Data struct {
+			RepositoryOwner *struct {
+				ProjectV2 *struct {
+					ID    string `json:"id"`
+					Field *struct {
+						ID      string `json:"id"`
+						Options []struct {
+							ID   string `json:"id"`
+							Name string `json:"name"`
+						} `json:"options"`
+					} `json:"field"`
+				} `json:"projectV2"`
+			} `json:"repositoryOwner"`
+		} `json:"data"`
+	}
+	err := i.postGraphQL(query, map[string]interface{}{"owner": owner, "number": number, "field": field}, &reply)
+	if err != nil {
+		return nil, err
+	}
+	err = reply.err()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find project %d of %s", number, owner)
+	}
+	if reply.Data.RepositoryOwner == nil || reply.Data.RepositoryOwner.ProjectV2 == nil {
+		return nil, errors.Errorf("failed to find project %d of %s", number, owner)
+	}
+	project := reply.Data.RepositoryOwner.ProjectV2
+	if project.Field == nil || project.Field.ID == "" {
+		return nil, errors.Errorf("project %d of %s has no single select field %s", number, owner, field)
+	}
+	for _, o := range project.Field.Options {
+		if strings.EqualFold(o.Name, option) {
+			return &ProjectBoard{ProjectID: project.ID, FieldID: project.Field.ID, OptionID: o.ID}, nil
+		}
+	}
+	return nil, errors.Errorf("the field %s of project %d of %s has no option %s", field, number, owner, option)
+}
+
+// SetProjectStatus adds the issue or pull request to the GitHub project if it is not already an item of the project
+// then sets the single select field of the item
+func (i *GitHubIssueProvider) SetProjectStatus(board *ProjectBoard, key string) error {
+	n, err := strconv.Atoi(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the issue number %s", key)
+	}
+	query := `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    issueOrPullRequest(number: $number) {
+      ... on Issue { ...items }
+      ... on PullRequest { ...items }
+    }
+  }
+}
+fragment items on ProjectV2ItemOwner {
+  id
+  projectItems(first: 50) { nodes { id project { id } } }
+}
+`
+	var reply struct {
+		graphQLReply
+		Data struct {
+			Repository struct {
+				IssueOrPullRequest *struct {
+					ID           string `json:"id"`
+					ProjectItems struct {
+						Nodes []struct {
+							ID      string `json:"id"`
+							Project struct {
+								ID string `json:"id"`
+							} `json:"project"`
+						} `json:"nodes"`
+					} `json:"projectItems"`
+				} `json:"issueOrPullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	err = i.postGraphQL(query, map[string]interface{}{"owner": i.Owner, "name": i.Repository, "number": n}, &reply)
+	if err != nil {
+		return err
+	}
+	err = reply.err()
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the project items of issue %s", key)
+	}
+	issue := reply.Data.Repository.IssueOrPullRequest
+	if issue == nil {
+		return errors.Errorf("failed to find issue %s of repository %s", key, i.fullName)
+	}
+	itemID := ""
+	for _, item := range issue.ProjectItems.Nodes {
+		if item.Project.ID == board.ProjectID {
+			itemID = item.ID
+		}
+	}
+	if itemID == "" {
+		itemID, err = i.addProjectItem(board, issue.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add issue %s to the project", key)
+		}
+	}
+	mutation := `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) {
+    projectV2Item { id }
+  }
+}
+`
+	var updated graphQLReply
+	err = i.postGraphQL(mutation, map[string]interface{}{"project": board.ProjectID, "item": itemID, "field": board.FieldID, "option": board.OptionID}, &updated)
+	if err == nil {
+		err = updated.err()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the project item of issue %s", key)
+	}
+	return nil
+}
+
+func (i *GitHubIssueProvider) addProjectItem(board *ProjectBoard, contentID string) (string, error) {
+	mutation := `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}
+`
+	var reply struct {
+		graphQLReply
+		Data struct {
+			AddProjectV2ItemByID struct {
+				Item struct {
+					ID string `json:"id"`
+				} `json:"item"`
+			} `json:"addProjectV2ItemById"`
+		} `json:"data"`
+	}
+	err := i.postGraphQL(mutation, map[string]interface{}{"project": board.ProjectID, "content": contentID}, &reply)
+	if err == nil {
+		err = reply.err()
+	}
+	if err != nil {
+		return "", err
+	}
+	return reply.Data.AddProjectV2ItemByID.Item.ID, nil
+}
+
+// graphQLReply the errors of a GraphQL reply
+type graphQLReply struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (r *graphQLReply) err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return errors.New(r.Errors[0].Message)
+}
@@ -37,3 +37,83 @@ func GetIssueProvider(tracker IssueProvider) string {
 	}
 	return Git
 }
+
+// ProjectVersion a version of the project in the issue tracker such as a Jira release
+type ProjectVersion struct {
+	Name        string
+	Description string
+
+	// ReleaseDate the date of the release in the form '2006-01-02'
+	ReleaseDate string
+	Released    bool
+}
+
+// VersionProvider is implemented by the issue providers which manage the versions of the project such as Jira
+type VersionProvider interface {
+	// EnsureVersion creates the version of the project or updates the existing version of the same name returning its ID
+	EnsureVersion(version *ProjectVersion) (string, error)
+
+	// AddIssueVersion adds the version to the fix versions of the issue
+	AddIssueVersion(key, version string) error
+}
+
+// PullRequestPrefix the prefix of the keys of the merge requests of GitLab and the pull requests of Bitbucket Cloud
+// which are numbered separately from their issues such as '!12'
+const PullRequestPrefix = "!"
+
+// MilestoneProvider is implemented by the issue providers which find the milestones of the issues they look up
+type MilestoneProvider interface {
+	// GetMilestone returns the title of the milestone of the issue or pull request or empty if it has none
+	GetMilestone(key string) (string, error)
+}
+
+// SeparatePullRequests returns true if the pull requests of the issue provider are numbered separately from its
+// issues so that they are referenced via the PullRequestPrefix
+func SeparatePullRequests(tracker IssueProvider) bool {
+	switch tracker.(type) {
+	case *GitLabIssueProvider, *BitbucketIssueProvider:
+		return true
+	}
+	return false
+}
+
+// BatchIssueProvider is implemented by the issue providers which can look up many issues in one request
+type BatchIssueProvider interface {
+	// PrefetchIssues looks up the issues and pull requests of the keys in batches so that GetIssue does not need to
+	// look them up one at a time
+	PrefetchIssues(keys []string) error
+}
+
+// CommitPullRequest a pull request associated with a commit such as the pull request which merged it
+type CommitPullRequest struct {
+	// Key the key of the pull request such as '123' or '!123' if the pull requests are numbered separately
+	Key    string
+	Number int
+	Title  string
+	URL    string
+	State  string
+	Labels []string
+	Merged bool
+}
+
+// CommitPullRequestProvider is implemented by the issue providers which can find the pull requests associated with a
+// commit even if it was squashed or rebased when merged
+type CommitPullRequestProvider interface {
+	// CommitPullRequests returns the pull requests associated with the commit
+	CommitPullRequests(sha string) ([]CommitPullRequest, error)
+}
+
+// MilestoneIssuesProvider is implemented by the issue providers which can find the issues and pull requests of a
+// milestone
+type MilestoneIssuesProvider interface {
+	// MilestoneIssues returns the keys of the issues closed and the pull requests merged in the milestone of the title
+	MilestoneIssues(milestone string) ([]string, error)
+}
+
+// MergedPullRequestsProvider is implemented by the issue providers which can find the pull requests merged into a
+// branch
+type MergedPullRequestsProvider interface {
+	// MergedPullRequests returns the keys of the pull requests merged into the base branch between the times. Zero
+	// times are ignored
+	MergedPullRequests(base string, since, until time.Time) ([]string, error)
+}
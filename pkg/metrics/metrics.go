@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ResultSuccess the result label of a successful generation
+	ResultSuccess = "success"
+
+	// ResultFailure the result label of a failed generation
+	ResultFailure = "failure"
+
+	// KindRelease the kind of the generations of releases from webhooks
+	KindRelease = "release"
+
+	// KindPreview the kind of the previews of pull requests from comments
+	KindPreview = "preview"
+
+	// KindAPI the kind of the generations requested via the REST API
+	KindAPI = "api"
+
+	// KindNotification the kind of the notifications of the controller
+	KindNotification = "notification"
+
+	// KindChangelogFile the kind of the CHANGELOG file updates of the controller
+	KindChangelogFile = "changelog-file"
+
+	// contentType the content type of the Prometheus text exposition format
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// labelEscaper escapes the label values of the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// DurationBuckets the upper bounds in seconds of the buckets of the generation durations which usually take seconds
+// rather than the milliseconds of typical request latencies
+var DurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+var (
+	// Default the registry exposed on the '/metrics' endpoints of the serve and controller commands
+	Default = NewRegistry()
+
+	// Generations counts the changelogs generated, previewed and published by kind and result
+	Generations = Default.NewCounter("jx_changelog_generations_total", "The number of changelogs generated by kind and result", "kind", "result")
+
+	// GenerationDuration the durations of generating changelogs by kind
+	GenerationDuration = Default.NewHistogram("jx_changelog_generation_duration_seconds", "The duration of generating changelogs in seconds by kind", DurationBuckets, "kind")
+
+	// ProviderAPIErrors counts the failed requests to the API of the git provider by host and status code
+	ProviderAPIErrors = Default.NewCounter("jx_changelog_provider_api_errors_total", "The number of failed git provider API requests by host and status code", "host", "status")
+
+	// ProviderRateLimitRemaining the remaining requests of the rate limit of the git provider by host
+	ProviderRateLimitRemaining = Default.NewGauge("jx_changelog_provider_rate_limit_remaining", "The remaining requests of the git provider API rate limit by host", "host")
+)
+
+// ObserveGeneration records the duration and result of a generation of the kind which began at the given time
+func ObserveGeneration(kind string, begin time.Time, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	Generations.Inc(kind, result)
+	GenerationDuration.Observe(time.Since(begin).Seconds(), kind)
+}
+
+// Registry the metrics exposed in the Prometheus text format
+type Registry struct {
+	lock    sync.Mutex
+	metrics []*metric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+}
+
+// Counter a counter partitioned by label values
+type Counter struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Gauge a gauge partitioned by label values
+type Gauge struct {
+	registry *Registry
+	metric   *metric
+}
+
+// Histogram a histogram partitioned by label values
+type Histogram struct {
+	registry *Registry
+	metric   *metric
+}
+
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *metric {
+	m := &metric{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// NewCounter registers a counter with the label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{registry: r, metric: r.register(name, help, "counter", nil, labels)}
+}
+
+// NewGauge registers a gauge with the label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{registry: r, metric: r.register(name, help, "gauge", nil, labels)}
+}
+
+// NewHistogram registers a histogram of the sorted bucket upper bounds with the label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{registry: r, metric: r.register(name, help, "histogram", buckets, labels)}
+}
+
+// get returns the series of the label values which the caller should only use while holding the lock
+func (m *metric) get(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels but was given %d values", m.name, len(m.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s := m.series[key]
+	if s == nil {
+		s = &series{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(m.buckets))}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Inc increments the counter of the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the value to the counter of the label values
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.registry.lock.Lock()
+	defer c.registry.lock.Unlock()
+	c.metric.get(labelValues).value += value
+}
+
+// Value returns the value of the counter of the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.registry.lock.Lock()
+	defer c.registry.lock.Unlock()
+	return c.metric.get(labelValues).value
+}
+
+// Set sets the gauge of the label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.registry.lock.Lock()
+	defer g.registry.lock.Unlock()
+	g.metric.get(labelValues).value = value
+}
+
+// Observe records the value in the histogram of the label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.registry.lock.Lock()
+	defer h.registry.lock.Unlock()
+	s := h.metric.get(labelValues)
+	for i, upper := range h.metric.buckets {
+		if value <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// Handler returns the handler of the '/metrics' endpoint
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(r.Text())) //nolint:errcheck
+	})
+}
+
+// Text returns the metrics in the Prometheus text exposition format
+func (r *Registry) Text() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var buf strings.Builder
+	for _, m := range r.metrics {
+		buf.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind))
+		var keys []string
+		for key := range m.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := m.series[key]
+			if m.kind != "histogram" {
+				buf.WriteString(fmt.Sprintf("%s%s %s\n", m.name, formatLabels(m.labels, s.labelValues), formatValue(s.value)))
+				continue
+			}
+			bucketLabels := append(append([]string(nil), m.labels...), "le")
+			for i, upper := range m.buckets {
+				buf.WriteString(fmt.Sprintf("%s_bucket%s %d\n", m.name, formatLabels(bucketLabels, append(append([]string(nil), s.labelValues...), formatValue(upper))), s.counts[i]))
+			}
+			buf.WriteString(fmt.Sprintf("%s_bucket%s %d\n", m.name, formatLabels(bucketLabels, append(append([]string(nil), s.labelValues...), "+Inf")), s.count))
+			buf.WriteString(fmt.Sprintf("%s_sum%s %s\n", m.name, formatLabels(m.labels, s.labelValues), formatValue(s.value)))
+			buf.WriteString(fmt.Sprintf("%s_count%s %d\n", m.name, formatLabels(m.labels, s.labelValues), s.count))
+		}
+	}
+	return buf.String()
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(names))
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
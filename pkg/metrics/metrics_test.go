@@ -0,0 +1,73 @@
+// +build unit
+
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/metrics"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryText(t *testing.T) {
+	t.Parallel()
+	r := metrics.NewRegistry()
+	counter := r.NewCounter("things_total", "The things", "kind")
+	gauge := r.NewGauge("remaining", "The remaining")
+	histogram := r.NewHistogram("duration_seconds", "The durations", []float64{1, 5}, "kind")
+
+	counter.Inc("b")
+	counter.Add(2, "a\"quoted\"")
+	gauge.Set(42)
+	histogram.Observe(0.5, "x")
+	histogram.Observe(3, "x")
+	histogram.Observe(10, "x")
+
+	assert.Equal(t, `# HELP things_total The things
+# TYPE things_total counter
+things_total{kind="a\"quoted\""} 2
+things_total{kind="b"} 1
+# HELP remaining The remaining
+# TYPE remaining gauge
+remaining 42
+# HELP duration_seconds The durations
+# TYPE duration_seconds histogram
+duration_seconds_bucket{kind="x",le="1"} 1
+duration_seconds_bucket{kind="x",le="5"} 2
+duration_seconds_bucket{kind="x",le="+Inf"} 3
+duration_seconds_sum{kind="x"} 13.5
+duration_seconds_count{kind="x"} 3
+`, r.Text())
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, w.Body.String(), "remaining 42\n")
+}
+
+func TestInstrumentScmClient(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := &scm.Client{}
+	metrics.InstrumentScmClient(client)
+	require.NotNil(t, client.Client)
+
+	for _, path := range []string{"/found", "/missing"} {
+		resp, err := client.Client.Get(server.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	host := server.Listener.Addr().String()
+	assert.Equal(t, float64(1), metrics.ProviderAPIErrors.Value(host, "404"))
+	assert.Contains(t, metrics.Default.Text(), `jx_changelog_provider_rate_limit_remaining{host="`+host+`"} 4999`)
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// rateLimitRemainingHeaders the headers of the remaining rate limit of GitHub, GitLab and Gitea
+var rateLimitRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+
+// InstrumentScmClient wraps the HTTP client of the git provider to record the failed API requests and the remaining
+// rate limit of the provider
+func InstrumentScmClient(client *scm.Client) {
+	if client == nil {
+		return
+	}
+	httpClient := http.Client{}
+	if client.Client != nil {
+		httpClient = *client.Client
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	httpClient.Transport = &instrumentedTransport{next: next}
+	client.Client = &httpClient
+}
+
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		ProviderAPIErrors.Inc(host, "error")
+		return resp, err
+	}
+	if resp.StatusCode >= 400 {
+		ProviderAPIErrors.Inc(host, strconv.Itoa(resp.StatusCode))
+	}
+	for _, name := range rateLimitRemainingHeaders {
+		value := resp.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		remaining, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			ProviderRateLimitRemaining.Set(remaining, host)
+		}
+		break
+	}
+	return resp, nil
+}
@@ -0,0 +1,293 @@
+package refs
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+var (
+	// GitIssueRegex matches git provider issue references such as '#12'
+	GitIssueRegex = regexp.MustCompile(`\#\d+`)
+
+	// JiraIssueRegex matches Jira issue references such as 'ABC-12'
+	JiraIssueRegex = regexp.MustCompile(`[A-Z][A-Z]+-\d+`)
+
+	// PullRequestRegex matches the references to pull requests numbered separately from issues such as the GitLab
+	// '!12' or the Bitbucket 'pull request #12'
+	PullRequestRegex = regexp.MustCompile(`(?i:pull request #|!)(\d+)`)
+)
+
+// Ref a reference to an issue or pull request in a commit message
+type Ref struct {
+	// ID the ID of the issue such as '12' for git providers or 'ABC-12' for Jira
+	ID string
+}
+
+// IssueSummary an issue or pull request resolved from a Ref
+type IssueSummary struct {
+	Ref
+	URL         string
+	Title       string
+	Body        string
+	State       string
+	User        *v1.UserDetails
+	ClosedBy    *v1.UserDetails
+	Assignees   []v1.UserDetails
+	Labels      []string
+	Milestone   string
+	Created     time.Time
+	PullRequest bool
+}
+
+// Scan returns the git provider issue references in the message in the order they appear without duplicates
+func Scan(message string) []Ref {
+	return ScanKind(issues.Git, message)
+}
+
+// ScanKind returns the issue references in the message using the format of the kind of issue tracker
+func ScanKind(kind, message string) []Ref {
+	regex := GitIssueRegex
+	if kind == issues.Jira {
+		regex = JiraIssueRegex
+	}
+	var answer []Ref
+	found := map[string]bool{}
+	for _, match := range regex.FindAllString(message, -1) {
+		id := strings.TrimPrefix(match, "#")
+		if !found[id] {
+			found[id] = true
+			answer = append(answer, Ref{ID: id})
+		}
+	}
+	return answer
+}
+
+// ScanPullRequests returns the references to pull requests numbered separately from issues in the order they appear
+// with the issues.PullRequestPrefix along with the message without them
+func ScanPullRequests(message string) ([]Ref, string) {
+	var answer []Ref
+	found := map[string]bool{}
+	for _, m := range PullRequestRegex.FindAllStringSubmatch(message, -1) {
+		id := issues.PullRequestPrefix + m[1]
+		if !found[id] {
+			found[id] = true
+			answer = append(answer, Ref{ID: id})
+		}
+	}
+	return answer, PullRequestRegex.ReplaceAllString(message, "")
+}
+
+// Resolver resolves references to the issues and pull requests of an issue tracker. Each reference is only looked up
+// once so that the same issue referenced by several commits is only included once
+type Resolver struct {
+	Tracker issues.IssueProvider
+
+	// Users resolves the authors, closers and assignees of the issues. Optional
+	Users *users.GitUserResolver
+
+	// OnLookupError handles failures looking up an issue. Returning nil skips the reference. Defaults to failing
+	OnLookupError func(err error) error
+
+	// StartLookup optionally starts timing a lookup returning the function to call when it completes
+	StartLookup func() func()
+
+	// StartUserResolution optionally starts timing resolving users returning the function to call when it completes
+	StartUserResolution func() func()
+
+	// Known the issues resolved by a previous run which are used instead of looking them up again such as when
+	// resuming after a failure. Optional
+	Known map[string]*IssueSummary
+
+	// OnResolved is optionally called with each issue looked up in the issue tracker
+	OnResolved func(issue *IssueSummary)
+
+	// SharedCache optionally shares the issues looked up in the issue tracker between processes. Optional
+	SharedCache cache.Cache
+
+	found   map[string]bool
+	lock    sync.Mutex
+	lookups map[string]*lookup
+}
+
+// lookup the memoized result of looking up a reference in the issue tracker
+type lookup struct {
+	once    sync.Once
+	summary *IssueSummary
+	err     error
+}
+
+// Prefetch looks up the references which are not known in the issue tracker remembering the results for Resolve.
+// It can be called by several goroutines at once so that the lookups of different commits overlap while Resolve
+// still decides which commit each issue belongs to in commit order
+func (r *Resolver) Prefetch(refs []Ref) {
+	for _, ref := range refs {
+		if r.Known[ref.ID] == nil {
+			r.lookup(ref)
+		}
+	}
+}
+
+// lookup looks up the reference in the issue tracker once
+func (r *Resolver) lookup(ref Ref) (*IssueSummary, error) {
+	r.lock.Lock()
+	if r.lookups == nil {
+		r.lookups = map[string]*lookup{}
+	}
+	l := r.lookups[ref.ID]
+	if l == nil {
+		l = &lookup{}
+		r.lookups[ref.ID] = l
+	}
+	r.lock.Unlock()
+	l.once.Do(func() {
+		l.summary, l.err = r.resolveShared(ref)
+	})
+	return l.summary, l.err
+}
+
+// resolveShared resolves the reference using the shared cache if there is one
+func (r *Resolver) resolveShared(ref Ref) (*IssueSummary, error) {
+	if r.SharedCache == nil {
+		return r.resolve(ref)
+	}
+	key := "issues/" + r.Tracker.HomeURL() + "/" + ref.ID
+	shared := &IssueSummary{}
+	if cache.GetJSON(r.SharedCache, key, shared) {
+		shared.Ref = ref
+		return shared, nil
+	}
+	summary, err := r.resolve(ref)
+	if err == nil {
+		cache.SetJSON(r.SharedCache, key, summary)
+	}
+	return summary, err
+}
+
+// Resolve looks up the references which have not been resolved before in the issue tracker
+func (r *Resolver) Resolve(refs []Ref) ([]IssueSummary, error) {
+	if r.found == nil {
+		r.found = map[string]bool{}
+	}
+	var answer []IssueSummary
+	for _, ref := range refs {
+		if r.found[ref.ID] {
+			continue
+		}
+		r.found[ref.ID] = true
+		if known := r.Known[ref.ID]; known != nil {
+			answer = append(answer, *known)
+			continue
+		}
+		summary, err := r.lookup(ref)
+		if err != nil {
+			if r.OnLookupError == nil {
+				return answer, err
+			}
+			err = r.OnLookupError(err)
+			if err != nil {
+				return answer, err
+			}
+			continue
+		}
+		if r.OnResolved != nil {
+			r.OnResolved(summary)
+		}
+		answer = append(answer, *summary)
+	}
+	return answer, nil
+}
+
+// MarkResolved records the issue as resolved so that references to it are not looked up. Returns false if it was
+// resolved before
+func (r *Resolver) MarkResolved(id string) bool {
+	if r.found == nil {
+		r.found = map[string]bool{}
+	}
+	if r.found[id] {
+		return false
+	}
+	r.found[id] = true
+	return true
+}
+
+func (r *Resolver) resolve(ref Ref) (*IssueSummary, error) {
+	tracker := r.Tracker
+	stopLookup := start(r.StartLookup)
+	issue, err := tracker.GetIssue(ref.ID)
+	stopLookup()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to lookup issue %s in issue tracker %s", ref.ID, tracker.HomeURL())
+	}
+	if issue == nil {
+		return nil, errors.Errorf("failed to find issue %s for repository %s", ref.ID, tracker.HomeURL())
+	}
+
+	stopUserResolution := start(r.StartUserResolution)
+	defer stopUserResolution()
+	resolver := r.Users
+	user, err := resolver.Resolve(&issue.Author)
+	if err != nil {
+		log.Logger().Warnf("Failed to resolve user %v for issue %s repository %s", issue.Author, ref.ID, tracker.HomeURL())
+	}
+
+	var closedBy *v1.UserDetails
+	if issue.ClosedBy == nil {
+		log.Logger().Warnf("Failed to find closedBy user for issue %s repository %s", ref.ID, tracker.HomeURL())
+	} else {
+		u, err := resolver.Resolve(issue.ClosedBy)
+		if err != nil {
+			log.Logger().Warnf("Failed to resolve closedBy user %v for issue %s repository %s", issue.Author, ref.ID, tracker.HomeURL())
+		} else if u != nil {
+			closedBy = u
+		}
+	}
+
+	var assignees []v1.UserDetails
+	if issue.Assignees == nil {
+		log.Logger().Warnf("Failed to find assignees for issue %s repository %s", ref.ID, tracker.HomeURL())
+	} else {
+		u, err := resolver.GitUserSliceAsUserDetailsSlice(issue.Assignees)
+		if err != nil {
+			log.Logger().Warnf("Failed to resolve Assignees %v for issue %s repository %s", issue.Assignees, ref.ID, tracker.HomeURL())
+		}
+		assignees = u
+	}
+
+	milestone := ""
+	if milestones, ok := tracker.(issues.MilestoneProvider); ok {
+		milestone, err = milestones.GetMilestone(ref.ID)
+		if err != nil {
+			log.Logger().Warnf("Failed to find the milestone of issue %s repository %s: %s", ref.ID, tracker.HomeURL(), err.Error())
+		}
+	}
+	return &IssueSummary{
+		Ref:         ref,
+		URL:         issue.Link,
+		Title:       issue.Title,
+		Body:        issue.Body,
+		State:       issue.State,
+		User:        user,
+		Created:     issue.Created,
+		ClosedBy:    closedBy,
+		Assignees:   assignees,
+		Labels:      issue.Labels,
+		Milestone:   milestone,
+		PullRequest: issue.PullRequest,
+	}, nil
+}
+
+func start(fn func() func()) func() {
+	if fn == nil {
+		return func() {}
+	}
+	return fn()
+}
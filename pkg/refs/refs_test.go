@@ -0,0 +1,151 @@
+// +build unit
+
+package refs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/refs"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTracker struct {
+	issues  map[string]*scm.Issue
+	lookups []string
+}
+
+func (f *fakeTracker) GetIssue(key string) (*scm.Issue, error) {
+	f.lookups = append(f.lookups, key)
+	if key == "500" {
+		return nil, errors.New("server error")
+	}
+	return f.issues[key], nil
+}
+
+func (f *fakeTracker) SearchIssues(string) ([]*scm.Issue, error)               { return nil, nil }
+func (f *fakeTracker) SearchIssuesClosedSince(time.Time) ([]*scm.Issue, error) { return nil, nil }
+func (f *fakeTracker) CreateIssue(issue *scm.Issue) (*scm.Issue, error)        { return issue, nil }
+func (f *fakeTracker) CreateIssueComment(string, string) error                 { return nil }
+func (f *fakeTracker) IssueURL(key string) string {
+	return "https://github.com/myorg/myrepo/issues/" + key
+}
+func (f *fakeTracker) HomeURL() string { return "https://github.com/myorg/myrepo" }
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []refs.Ref{{ID: "12"}, {ID: "3"}}, refs.Scan("fix: something (#12)\n\nfixes #3 and #12"))
+	assert.Empty(t, refs.Scan("chore: no references"))
+	assert.Equal(t, []refs.Ref{{ID: "ABC-12"}}, refs.ScanKind(issues.Jira, "ABC-12 fix something #4"))
+}
+
+func TestScanPullRequests(t *testing.T) {
+	t.Parallel()
+	found, rest := refs.ScanPullRequests("Merged in feature (pull request #7)\n\nfixes #3\n\nSee merge request myorg/myrepo!12 and !7")
+	assert.Equal(t, []refs.Ref{{ID: "!7"}, {ID: "!12"}}, found)
+	assert.Equal(t, []refs.Ref{{ID: "3"}}, refs.Scan(rest))
+
+	found, rest = refs.ScanPullRequests("feat!: breaking change #4")
+	assert.Empty(t, found)
+	assert.Equal(t, "feat!: breaking change #4", rest)
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	tracker := &fakeTracker{
+		issues: map[string]*scm.Issue{
+			"12": {Number: 12, Title: "a bug", Link: "https://github.com/myorg/myrepo/pull/12", Labels: []string{"bug"}, PullRequest: true},
+			"3":  {Number: 3, Title: "an issue"},
+		},
+	}
+	r := &refs.Resolver{Tracker: tracker}
+
+	found, err := r.Resolve(refs.Scan("fix: something (#12) fixes #3"))
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "12", found[0].ID)
+	assert.Equal(t, "a bug", found[0].Title)
+	assert.True(t, found[0].PullRequest)
+	assert.Equal(t, []string{"bug"}, found[0].Labels)
+	assert.False(t, found[1].PullRequest)
+
+	// issues are only resolved once
+	found, err = r.Resolve(refs.Scan("more on #12"))
+	require.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Equal(t, []string{"12", "3"}, tracker.lookups)
+
+	_, err = r.Resolve(refs.Scan("missing #99"))
+	assert.Error(t, err)
+
+	var skipped []error
+	r.OnLookupError = func(err error) error {
+		skipped = append(skipped, err)
+		return nil
+	}
+	found, err = r.Resolve(refs.Scan("broken #500"))
+	require.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Len(t, skipped, 1)
+}
+
+func TestResolveKnown(t *testing.T) {
+	t.Parallel()
+	tracker := &fakeTracker{
+		issues: map[string]*scm.Issue{
+			"3": {Number: 3, Title: "an issue"},
+		},
+	}
+	var resolved []string
+	r := &refs.Resolver{
+		Tracker: tracker,
+		Known: map[string]*refs.IssueSummary{
+			"12": {Ref: refs.Ref{ID: "12"}, Title: "a bug", PullRequest: true},
+		},
+		OnResolved: func(issue *refs.IssueSummary) {
+			resolved = append(resolved, issue.ID)
+		},
+	}
+
+	found, err := r.Resolve(refs.Scan("fix: something (#12) fixes #3"))
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "a bug", found[0].Title)
+	assert.Equal(t, "an issue", found[1].Title)
+	assert.Equal(t, []string{"3"}, tracker.lookups, "should only look up the issues which are not known")
+	assert.Equal(t, []string{"3"}, resolved)
+}
+
+type memoryCache map[string][]byte
+
+func (c memoryCache) Get(key string) ([]byte, error) {
+	return c[key], nil
+}
+
+func (c memoryCache) Set(key string, value []byte) error {
+	c[key] = value
+	return nil
+}
+
+func TestResolveSharedCache(t *testing.T) {
+	t.Parallel()
+	tracker := &fakeTracker{
+		issues: map[string]*scm.Issue{
+			"3": {Number: 3, Title: "an issue"},
+		},
+	}
+	shared := memoryCache{}
+	for i := 0; i < 2; i++ {
+		r := &refs.Resolver{Tracker: tracker, SharedCache: shared}
+		found, err := r.Resolve(refs.Scan("fixes #3"))
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "3", found[0].ID)
+		assert.Equal(t, "an issue", found[0].Title)
+	}
+	assert.Equal(t, []string{"3"}, tracker.lookups, "the second resolver should use the shared cache")
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ServiceName the default name of the service recorded on the spans. Can be overridden via $OTEL_SERVICE_NAME
+	ServiceName = "jx-changelog"
+
+	// TracerName the name of the tracer used to instrument the changelog generation
+	TracerName = "github.com/jenkins-x-plugins/jx-changelog"
+
+	// TraceParentEnv the environment variable containing the W3C trace context of the pipeline to join
+	TraceParentEnv = "TRACEPARENT"
+)
+
+var endpointEnvVars = []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"}
+
+// Enabled returns true if an OTLP endpoint is configured via the standard OpenTelemetry environment variables
+func Enabled() bool {
+	for _, name := range endpointEnvVars {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Setup registers a tracer provider exporting spans via OTLP over HTTP if an endpoint is configured.
+// The returned context joins the trace of the pipeline if $TRACEPARENT is set.
+// The returned function flushes any pending spans and should be called before exiting
+func Setup(ctx context.Context) (context.Context, func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if !Enabled() {
+		return ctx, noop, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return ctx, noop, errors.Wrap(err, "failed to create the OTLP trace exporter")
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String(ServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return ctx, noop, errors.Wrap(err, "failed to create the trace resource")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	propagator := propagation.TraceContext{}
+	otel.SetTextMapPropagator(propagator)
+	if traceParent := os.Getenv(TraceParentEnv); traceParent != "" {
+		carrier := propagation.HeaderCarrier(http.Header{})
+		carrier.Set("traceparent", traceParent)
+		ctx = propagator.Extract(ctx, carrier)
+	}
+	return ctx, provider.Shutdown, nil
+}
+
+// Start starts a span using the global tracer provider which does nothing unless Setup enabled tracing
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, opts...)
+}
@@ -0,0 +1,82 @@
+package users
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/pkg/errors"
+)
+
+// Aliases maps the different names and emails a contributor has used onto a single git provider account
+type Aliases struct {
+	Aliases []Alias `json:"aliases,omitempty"`
+
+	// Identities the rules to classify users as bots or service accounts. They are matched before DefaultIdentityRules
+	Identities []IdentityRule `json:"identities,omitempty"`
+
+	compiled bool
+	rules    []IdentityRule
+}
+
+// Alias the identities of a single contributor
+type Alias struct {
+	// Login the git provider login of the contributor
+	Login string `json:"login,omitempty"`
+
+	// Name the display name to use for the contributor
+	Name string `json:"name,omitempty"`
+
+	// Email the canonical email of the contributor
+	Email string `json:"email,omitempty"`
+
+	// Names the other names used in git commits
+	Names []string `json:"names,omitempty"`
+
+	// Emails the other emails used in git commits
+	Emails []string `json:"emails,omitempty"`
+}
+
+// LoadAliases loads the alias configuration file
+func LoadAliases(path string) (*Aliases, error) {
+	answer := &Aliases{}
+	err := yamls.LoadFile(path, answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load alias file %s", path)
+	}
+	err = answer.Validate()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid alias file %s", path)
+	}
+	return answer, nil
+}
+
+// Find returns the alias matching the given name or email or nil if there is no match
+func (a *Aliases) Find(name, email string) *Alias {
+	if a == nil {
+		return nil
+	}
+	for i := range a.Aliases {
+		alias := &a.Aliases[i]
+		if email != "" {
+			if strings.EqualFold(alias.Email, email) {
+				return alias
+			}
+			for _, e := range alias.Emails {
+				if strings.EqualFold(e, email) {
+					return alias
+				}
+			}
+		}
+		if name != "" {
+			if alias.Name == name {
+				return alias
+			}
+			for _, n := range alias.Names {
+				if n == name {
+					return alias
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package users
+
+import (
+	"regexp"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// IdentityKindBot the kind of identity for bots such as dependabot or renovate
+	IdentityKindBot = "bot"
+
+	// IdentityKindServiceAccount the kind of identity for service accounts such as pipeline users
+	IdentityKindServiceAccount = "service-account"
+)
+
+// IdentityRule matches users via regular expressions on their name, email or login to classify them as a kind of identity.
+// All of the non empty expressions have to match for the rule to match
+type IdentityRule struct {
+	// Kind the kind of identity: bot or service-account
+	Kind string `json:"kind,omitempty"`
+
+	// Name the regular expression to match the name of the user
+	Name string `json:"name,omitempty"`
+
+	// Email the regular expression to match the email of the user
+	Email string `json:"email,omitempty"`
+
+	// Login the regular expression to match the login of the user
+	Login string `json:"login,omitempty"`
+
+	name, email, login *regexp.Regexp
+}
+
+// DefaultIdentityRules the rules used to detect bots after any configured rules
+var DefaultIdentityRules = []IdentityRule{
+	{
+		Kind:  IdentityKindBot,
+		Login: `\[bot\]$`,
+	},
+	{
+		Kind: IdentityKindBot,
+		Name: `\[bot\]$`,
+	},
+}
+
+func (r *IdentityRule) compile() error {
+	var err error
+	r.name, err = compileOptionalRegex(r.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse name regex %s", r.Name)
+	}
+	r.email, err = compileOptionalRegex(r.Email)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse email regex %s", r.Email)
+	}
+	r.login, err = compileOptionalRegex(r.Login)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse login regex %s", r.Login)
+	}
+	return nil
+}
+
+func (r *IdentityRule) matches(user *v1.UserDetails) bool {
+	if r.name == nil && r.email == nil && r.login == nil {
+		return false
+	}
+	if r.name != nil && !r.name.MatchString(user.Name) {
+		return false
+	}
+	if r.email != nil && !r.email.MatchString(user.Email) {
+		return false
+	}
+	if r.login != nil && !r.login.MatchString(user.Login) {
+		return false
+	}
+	return true
+}
+
+func compileOptionalRegex(text string) (*regexp.Regexp, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return regexp.Compile(text)
+}
+
+var compiledDefaultIdentityRules = compileIdentityRules(DefaultIdentityRules)
+
+func compileIdentityRules(rules []IdentityRule) []IdentityRule {
+	answer := make([]IdentityRule, len(rules))
+	copy(answer, rules)
+	for i := range answer {
+		answer[i].compile() //nolint:errcheck
+	}
+	return answer
+}
+
+// identityRules returns the compiled identity rules followed by the default rules so that the configured rules take
+// precedence without losing the detection of bots
+func (a *Aliases) identityRules() []IdentityRule {
+	if a == nil || len(a.Identities) == 0 {
+		return compiledDefaultIdentityRules
+	}
+	if !a.compiled {
+		a.Identities = compileIdentityRules(a.Identities)
+		a.compiled = true
+	}
+	if a.rules == nil {
+		a.rules = append(append([]IdentityRule{}, a.Identities...), compiledDefaultIdentityRules...)
+	}
+	return a.rules
+}
+
+// Validate validates the identity rules are valid regular expressions
+func (a *Aliases) Validate() error {
+	for i := range a.Identities {
+		err := a.Identities[i].compile()
+		if err != nil {
+			return errors.Wrapf(err, "invalid identity rule %d", i)
+		}
+	}
+	a.compiled = true
+	a.rules = nil
+	a.identityRules()
+	return nil
+}
+
+// Classify returns the kind of identity of the user such as bot or service-account or an empty string for regular users
+func (a *Aliases) Classify(user *v1.UserDetails) string {
+	if user == nil {
+		return ""
+	}
+	rules := a.identityRules()
+	for i := range rules {
+		if rules[i].matches(user) {
+			kind := rules[i].Kind
+			if kind == "" {
+				kind = IdentityKindBot
+			}
+			return kind
+		}
+	}
+	return ""
+}
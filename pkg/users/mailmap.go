@@ -0,0 +1,127 @@
+package users
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+)
+
+// MailmapFileName the default name of the git mailmap file in a repository
+const MailmapFileName = ".mailmap"
+
+// Mailmap maps the names and emails recorded in git commits to canonical contributor identities
+// see: https://git-scm.com/docs/gitmailmap
+type Mailmap struct {
+	entries []mailmapEntry
+}
+
+type mailmapEntry struct {
+	properName  string
+	properEmail string
+	commitName  string
+	commitEmail string
+}
+
+// LoadMailmap loads the mailmap file if it exists. If the file does not exist an empty mailmap is returned
+func LoadMailmap(path string) (*Mailmap, error) {
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return &Mailmap{}, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load mailmap file %s", path)
+	}
+	return ParseMailmap(string(data)), nil
+}
+
+// ParseMailmap parses the text of a mailmap file. Lines which cannot be parsed are ignored like git does
+func ParseMailmap(text string) *Mailmap {
+	m := &Mailmap{}
+	for _, line := range strings.Split(text, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[0:idx]
+		}
+		entry, ok := parseMailmapLine(line)
+		if ok {
+			m.entries = append(m.entries, entry)
+		}
+	}
+	return m
+}
+
+// parseMailmapLine parses one of the supported forms: 'Proper Name <commit@email>', '<proper@email> <commit@email>',
+// 'Proper Name <proper@email> <commit@email>' or 'Proper Name <proper@email> Commit Name <commit@email>'
+func parseMailmapLine(line string) (mailmapEntry, bool) {
+	var names, emails []string
+	rest := line
+	for {
+		start := strings.Index(rest, "<")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], ">")
+		if end < 0 {
+			break
+		}
+		names = append(names, strings.TrimSpace(rest[0:start]))
+		emails = append(emails, strings.TrimSpace(rest[start+1:start+end]))
+		rest = rest[start+end+1:]
+	}
+	switch len(emails) {
+	case 1:
+		if names[0] == "" {
+			return mailmapEntry{}, false
+		}
+		return mailmapEntry{properName: names[0], commitEmail: emails[0]}, true
+	case 2:
+		return mailmapEntry{
+			properName:  names[0],
+			properEmail: emails[0],
+			commitName:  names[1],
+			commitEmail: emails[1],
+		}, true
+	default:
+		return mailmapEntry{}, false
+	}
+}
+
+// Resolve returns the canonical name and email for the given commit name and email.
+// Later entries take precedence over earlier ones and entries matching the commit name are preferred
+func (m *Mailmap) Resolve(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+	var match *mailmapEntry
+	for i := range m.entries {
+		e := &m.entries[i]
+		if !strings.EqualFold(e.commitEmail, email) {
+			continue
+		}
+		if e.commitName != "" {
+			if !strings.EqualFold(e.commitName, name) {
+				continue
+			}
+			match = e
+			continue
+		}
+		if match == nil || match.commitName == "" {
+			match = e
+		}
+	}
+	if match == nil {
+		return name, email
+	}
+	if match.properName != "" {
+		name = match.properName
+	}
+	if match.properEmail != "" {
+		email = match.properEmail
+	}
+	return name, email
+}
@@ -0,0 +1,87 @@
+// +build unit
+
+package users_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailmap(t *testing.T) {
+	t.Parallel()
+	m := users.ParseMailmap(`# some comment
+James Strachan <james@old.com>
+<jim@new.com> <jim@old.com>
+Jane Doe <jane@new.com> <jane@old.com>
+Bob Smith <bob@new.com> bob <bob@shared.com>
+invalid line
+`)
+
+	testCases := []struct {
+		name, email             string
+		expectName, expectEmail string
+	}{
+		{"jstrachan", "james@old.com", "James Strachan", "james@old.com"},
+		{"Jim", "JIM@old.com", "Jim", "jim@new.com"},
+		{"jane", "jane@old.com", "Jane Doe", "jane@new.com"},
+		{"bob", "bob@shared.com", "Bob Smith", "bob@new.com"},
+		{"robert", "bob@shared.com", "robert", "bob@shared.com"},
+		{"someone", "someone@else.com", "someone", "someone@else.com"},
+	}
+	for _, tc := range testCases {
+		name, email := m.Resolve(tc.name, tc.email)
+		assert.Equal(t, tc.expectName, name, "name for %s <%s>", tc.name, tc.email)
+		assert.Equal(t, tc.expectEmail, email, "email for %s <%s>", tc.name, tc.email)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	t.Parallel()
+	a := &users.Aliases{
+		Aliases: []users.Alias{
+			{
+				Login:  "jstrachan",
+				Name:   "James Strachan",
+				Emails: []string{"james@old.com"},
+				Names:  []string{"jimmy"},
+			},
+		},
+	}
+	alias := a.Find("", "JAMES@old.com")
+	if assert.NotNil(t, alias) {
+		assert.Equal(t, "jstrachan", alias.Login)
+	}
+	assert.NotNil(t, a.Find("jimmy", ""))
+	assert.Nil(t, a.Find("someone", "someone@else.com"))
+
+	var nilAliases *users.Aliases
+	assert.Nil(t, nilAliases.Find("jimmy", ""))
+}
+
+func TestClassifyIdentities(t *testing.T) {
+	t.Parallel()
+	var defaults *users.Aliases
+	assert.Equal(t, users.IdentityKindBot, defaults.Classify(&v1.UserDetails{Login: "dependabot[bot]"}))
+	assert.Equal(t, "", defaults.Classify(&v1.UserDetails{Login: "jstrachan"}))
+
+	a := &users.Aliases{
+		Identities: []users.IdentityRule{
+			{
+				Kind:  users.IdentityKindServiceAccount,
+				Email: `^jenkins-x@`,
+			},
+		},
+	}
+	require.NoError(t, a.Validate())
+	assert.Equal(t, users.IdentityKindServiceAccount, a.Classify(&v1.UserDetails{Name: "jx", Email: "jenkins-x@googlegroups.com"}))
+	assert.Equal(t, "", a.Classify(&v1.UserDetails{Login: "jstrachan", Email: "james@foo.com"}))
+	assert.Equal(t, users.IdentityKindBot, a.Classify(&v1.UserDetails{Login: "renovate[bot]"}), "the default rules should still apply")
+	assert.Equal(t, users.IdentityKindServiceAccount, a.Classify(&v1.UserDetails{Name: "jx[bot]", Email: "jenkins-x@googlegroups.com"}), "the configured rules should take precedence")
+
+	invalid := &users.Aliases{Identities: []users.IdentityRule{{Login: "["}}}
+	assert.Error(t, invalid.Validate())
+}
@@ -0,0 +1,46 @@
+package users
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// OrgMembers checks whether users are members of an organisation via the git provider caching the results
+type OrgMembers struct {
+	GitProvider *scm.Client
+	Org         string
+	Ctx         context.Context
+	members     map[string]bool
+}
+
+// IsMember returns true if the login is a member of the organisation. Any errors checking membership are logged
+// and the user is assumed to not be a member so that we never mention users we are not sure about
+func (m *OrgMembers) IsMember(login string) bool {
+	if m == nil || login == "" {
+		return false
+	}
+	key := strings.ToLower(login)
+	if strings.EqualFold(key, m.Org) {
+		return true
+	}
+	if m.members == nil {
+		m.members = map[string]bool{}
+	}
+	member, ok := m.members[key]
+	if ok {
+		return member
+	}
+	if m.GitProvider != nil && m.Org != "" {
+		var err error
+		member, _, err = m.GitProvider.Organizations.IsMember(contextOrBackground(m.Ctx), m.Org, login)
+		if err != nil {
+			log.Logger().Debugf("failed to check if %s is a member of %s: %s", login, m.Org, err.Error())
+			member = false
+		}
+	}
+	m.members[key] = member
+	return member
+}
@@ -2,12 +2,18 @@ package users
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
@@ -18,7 +24,186 @@ import (
 // GitUserResolver allows git users to be converted to Jenkins X users
 type GitUserResolver struct {
 	GitProvider *scm.Client
-	cache       UserDetailService
+	Mailmap     *Mailmap
+	Aliases     *Aliases
+	// Repository the full name of the repository used to look up commits on the git provider
+	Repository string
+	// Ctx the context of the git provider requests. Defaults to the background context
+	Ctx context.Context
+	// SharedCache optionally shares the users and commit logins looked up via the git provider between processes
+	SharedCache   cache.Cache
+	lock          sync.Mutex
+	cache         UserDetailService
+	loginsByEmail map[string]string
+}
+
+// Checkpoint the users and commit logins resolved by a GitUserResolver
+type Checkpoint struct {
+	Users         map[string]*jenkinsv1.UserDetails `json:"users,omitempty"`
+	LoginsByEmail map[string]string                 `json:"loginsByEmail,omitempty"`
+}
+
+// CommitAuthorAsUser resolves the author of the given commit to a Jenkins X User. The commit is looked up
+// via the git provider which knows the login associated with the commit even if the email address is private.
+// If the commit cannot be found we fall back to resolving the git signature
+func (r *GitUserResolver) CommitAuthorAsUser(sha string, signature *object.Signature) (*jenkinsv1.UserDetails, error) {
+	if signature.Name == "" && signature.Email == "" {
+		return nil, nil
+	}
+	gitUser := &scm.User{
+		Email: signature.Email,
+		Name:  signature.Name,
+	}
+	r.applyAliases(gitUser)
+	if gitUser.Login == "" {
+		gitUser.Login = r.findCommitLogin(sha, gitUser.Email)
+	}
+	return r.classify(gitUser)
+}
+
+// findCommitLogin finds the login of the commit author via the git provider caching the result by email. If the
+// commit does not know the login we fall back to searching for the user by email
+func (r *GitUserResolver) findCommitLogin(sha, email string) string {
+	provider := r.gitProvider()
+	if provider == nil || r.Repository == "" || sha == "" {
+		return ""
+	}
+	key := strings.ToLower(email)
+	r.lock.Lock()
+	login, ok := r.loginsByEmail[key]
+	r.lock.Unlock()
+	if ok && key != "" {
+		return login
+	}
+	sharedKey := sharedCacheKey(provider, "email", key)
+	if key != "" && cache.GetJSON(r.SharedCache, sharedKey, &login) {
+		r.setLogin(key, login)
+		return login
+	}
+	ctx := contextOrBackground(r.Ctx)
+	commit, _, err := provider.Git.FindCommit(ctx, r.Repository, sha)
+	if err != nil {
+		log.Logger().Debugf("failed to find commit %s in repository %s: %s", sha, r.Repository, err.Error())
+	} else if commit != nil {
+		login = commit.Author.Login
+	}
+	if login == "" && key != "" {
+		login, err = searchLoginByEmail(ctx, provider, email)
+		if err != nil {
+			log.Logger().Debugf("failed to search for the user with email %s: %s", email, err.Error())
+		}
+	}
+	if key != "" && login != "" {
+		r.setLogin(key, login)
+		cache.SetJSON(r.SharedCache, sharedKey, login)
+	}
+	return login
+}
+
+// setLogin caches the login of the lower case email
+func (r *GitUserResolver) setLogin(key, login string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.loginsByEmail == nil {
+		r.loginsByEmail = map[string]string{}
+	}
+	r.loginsByEmail[key] = login
+}
+
+// searchLoginByEmail searches the users of the git provider for the login of the public email. Only GitHub
+// supports searching users by email so other providers return no login
+func searchLoginByEmail(ctx context.Context, provider *scm.Client, email string) (string, error) {
+	if provider.Driver != scm.DriverGithub {
+		return "", nil
+	}
+	path := "search/users?q=" + url.QueryEscape(email+" in:email")
+	res, err := provider.Do(ctx, &scm.Request{Method: http.MethodGet, Path: path})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %s", path)
+	}
+	defer res.Body.Close()
+	if res.Status >= 300 {
+		return "", errors.Errorf("failed to get %s: status %d", path, res.Status)
+	}
+	results := struct {
+		Items []struct {
+			Login string `json:"login"`
+		} `json:"items"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&results)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s", path)
+	}
+	// lets not guess between several users with the same email
+	if len(results.Items) != 1 {
+		return "", nil
+	}
+	return results.Items[0].Login, nil
+}
+
+// gitProvider returns the git provider used to look up users or nil if it has been disabled
+func (r *GitUserResolver) gitProvider() *scm.Client {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.GitProvider
+}
+
+// sharedCacheKey returns the key of the shared cache of the email or login of a user of the git provider
+func sharedCacheKey(provider *scm.Client, kind, value string) string {
+	server := ""
+	if provider != nil && provider.BaseURL != nil {
+		server = provider.BaseURL.Host
+	}
+	return "users/" + server + "/" + kind + "/" + value
+}
+
+// contextOrBackground returns the context defaulting to the background context
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// Checkpoint returns the users and commit logins resolved so far so that they can be restored by a later run
+func (r *GitUserResolver) Checkpoint() *Checkpoint {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	answer := &Checkpoint{
+		Users:         map[string]*jenkinsv1.UserDetails{},
+		LoginsByEmail: map[string]string{},
+	}
+	for k, v := range r.cache.cache {
+		u := *v
+		answer.Users[k] = &u
+	}
+	for k, v := range r.loginsByEmail {
+		answer.LoginsByEmail[k] = v
+	}
+	return answer
+}
+
+// Restore adds the users and commit logins of the checkpoint so that they are not looked up again
+func (r *GitUserResolver) Restore(checkpoint *Checkpoint) {
+	if checkpoint == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.cache.cache == nil {
+		r.cache.cache = map[string]*jenkinsv1.UserDetails{}
+	}
+	for k, v := range checkpoint.Users {
+		if v != nil {
+			r.cache.cache[k] = v
+		}
+	}
+	if r.loginsByEmail == nil {
+		r.loginsByEmail = map[string]string{}
+	}
+	for k, v := range checkpoint.LoginsByEmail {
+		r.loginsByEmail[k] = v
+	}
 }
 
 // GitSignatureAsUser resolves the signature to a Jenkins X User
@@ -31,15 +216,38 @@ func (r *GitUserResolver) GitSignatureAsUser(signature *object.Signature) (*jenk
 		Email: signature.Email,
 		Name:  signature.Name,
 	}
-	return r.Resolve(gitUser)
+	r.applyAliases(gitUser)
+	return r.classify(gitUser)
+}
+
+// applyAliases canonicalises the user via the mailmap and alias configuration so that contributors
+// who have committed with different names or emails are resolved to the same account
+func (r *GitUserResolver) applyAliases(user *scm.User) {
+	user.Name, user.Email = r.Mailmap.Resolve(user.Name, user.Email)
+	alias := r.Aliases.Find(user.Name, user.Email)
+	if alias == nil {
+		return
+	}
+	if alias.Login != "" && user.Login == "" {
+		user.Login = alias.Login
+	}
+	if alias.Name != "" {
+		user.Name = alias.Name
+	}
+	if alias.Email != "" {
+		user.Email = alias.Email
+	}
 }
 
 // GitUserSliceAsUserDetailsSlice resolves a slice of git users to a slice of Jenkins X User Details
 func (r *GitUserResolver) GitUserSliceAsUserDetailsSlice(users []scm.User) ([]jenkinsv1.UserDetails, error) {
+	if r == nil {
+		return nil, nil
+	}
 	var answer []jenkinsv1.UserDetails
 	for _, user := range users {
 		us := user
-		u, err := r.Resolve(&us)
+		u, err := r.classify(&us)
 		if err != nil {
 			return nil, err
 		}
@@ -50,40 +258,91 @@ func (r *GitUserResolver) GitUserSliceAsUserDetailsSlice(users []scm.User) ([]je
 	return answer, nil
 }
 
-// Resolve will convert the GitUser to a Jenkins X user and attempt to complete the user info by:
+// Resolve converts the GitUser to a Jenkins X user and classifies it as a bot or service account. The resolver
+// can be shared by goroutines as the cached users are only locked while they are read or written
+func (r *GitUserResolver) Resolve(user *scm.User) (*jenkinsv1.UserDetails, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return r.classify(user)
+}
+
+// DisableGitProvider stops looking up users via the git provider so they are only resolved from their git signatures
+func (r *GitUserResolver) DisableGitProvider() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.GitProvider = nil
+}
+
+func (r *GitUserResolver) classify(user *scm.User) (*jenkinsv1.UserDetails, error) {
+	u, err := r.resolve(user)
+	if u == nil {
+		return u, err
+	}
+	// the user may be cached so lets lock while classifying it
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if u.ServiceAccount == "" && r.Aliases.Classify(u) != "" {
+		u.ServiceAccount = u.Login
+		if u.ServiceAccount == "" {
+			u.ServiceAccount = u.Name
+		}
+	}
+	return u, err
+}
+
+// IsServiceAccount returns true if the user has been classified as a bot or service account
+func IsServiceAccount(user *jenkinsv1.UserDetails) bool {
+	return user != nil && user.ServiceAccount != ""
+}
+
+// resolve will convert the GitUser to a Jenkins X user and attempt to complete the user info by:
 // * checking the user custom resources to see if the user is present there
 // * making a call to the gitProvider
 // as often user info is not complete in a git response
-func (r *GitUserResolver) Resolve(user *scm.User) (*jenkinsv1.UserDetails, error) {
+func (r *GitUserResolver) resolve(user *scm.User) (*jenkinsv1.UserDetails, error) {
 	if r == nil || user == nil || user.Name == "" {
 		return nil, nil
 	}
 
-	u := r.cache.GetUser(user.Name)
+	u := r.cachedUser(user)
 	if u != nil {
 		return u, nil
 	}
 
-	ctx := context.Background()
+	ctx := contextOrBackground(r.Ctx)
 
-	if user.Login == "" {
+	provider := r.gitProvider()
+	if user.Login == "" || provider == nil {
 		u = r.GitUserToUser(user)
-		err := r.cache.CreateOrUpdateUser(u)
+		err := r.cacheUser(u)
 		if err != nil {
 			return u, errors.Wrapf(err, "failed to cache User")
 		}
 		return u, nil
 	}
 
-	scmUser, _, err := r.GitProvider.Users.FindLogin(ctx, user.Login)
+	sharedKey := sharedCacheKey(provider, "login", user.Login)
+	shared := &jenkinsv1.UserDetails{}
+	if cache.GetJSON(r.SharedCache, sharedKey, shared) {
+		return shared, r.cacheUser(shared)
+	}
+
+	// lets not hold the lock while waiting for the git provider
+	scmUser, _, err := provider.Users.FindLogin(ctx, user.Login)
 	if scmUser == nil || scmhelpers.IsScmNotFound(err) {
-		return nil, nil
+		// the login is not known to the git provider so lets use the details we have
+		u = r.GitUserToUser(user)
+		return u, r.cacheUser(u)
 	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to find user %s", user.Login)
 	}
 
 	u = r.GitUserToUser(scmUser)
+	if u.Email == "" {
+		u.Email = user.Email
+	}
 	login := scmUser.Login
 	if login == "" {
 		login = strings.Replace(scmUser.Name, " ", "-", -1)
@@ -91,13 +350,32 @@ func (r *GitUserResolver) Resolve(user *scm.User) (*jenkinsv1.UserDetails, error
 	}
 	id := naming.ToValidName(login)
 	u.Name = naming.ToValidName(id)
-	err = r.cache.CreateOrUpdateUser(u)
+	err = r.cacheUser(u)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create User")
 	}
+	cache.SetJSON(r.SharedCache, sharedKey, u)
 	return u, nil
 }
 
+// cachedUser returns the user cached by name or login
+func (r *GitUserResolver) cachedUser(user *scm.User) *jenkinsv1.UserDetails {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	u := r.cache.GetUser(user.Name)
+	if u == nil && user.Login != "" {
+		u = r.cache.GetUser(naming.ToValidName(user.Login))
+	}
+	return u
+}
+
+// cacheUser adds the user to the cache or updates the cached user
+func (r *GitUserResolver) cacheUser(u *jenkinsv1.UserDetails) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.cache.CreateOrUpdateUser(u)
+}
+
 /* TODO
 // UpdateUserFromPRAuthor will attempt to use the
 func (r *GitUserResolver) UpdateUserFromPRAuthor(author *jenkinsv1.User, pullRequest *scm.PullRequest,
@@ -132,18 +410,24 @@ func (r *GitUserResolver) UpdateUserFromPRAuthor(author *jenkinsv1.User, pullReq
 // attaching the Git Provider account to Accounts
 func (r *GitUserResolver) GitUserToUser(gitUser *scm.User) *jenkinsv1.UserDetails {
 	return &jenkinsv1.UserDetails{
-		Login: gitUser.Login,
-		Name:  gitUser.Name,
-		Email: gitUser.Email,
+		Login:     gitUser.Login,
+		Name:      gitUser.Name,
+		Email:     gitUser.Email,
+		URL:       gitUser.Link,
+		AvatarURL: gitUser.Avatar,
 	}
 }
 
 // GitProviderKey returns the provider key for this GitUserResolver
 func (r *GitUserResolver) GitProviderKey() string {
-	if r == nil || r.GitProvider == nil {
+	if r == nil {
+		return ""
+	}
+	provider := r.gitProvider()
+	if provider == nil {
 		return ""
 	}
-	return fmt.Sprintf("jenkins.io/git-%s-userid", r.GitProvider.Driver.String())
+	return fmt.Sprintf("jenkins.io/git-%s-userid", provider.Driver.String())
 }
 
 // mergeGitUsers merges user1 into user2, replacing any that do not have empty values on user2 with those from user1
@@ -0,0 +1,124 @@
+// +build unit
+
+package users_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestCommitAuthorAsUser(t *testing.T) {
+	t.Parallel()
+	scmClient, fakeData := scmfake.NewDefault()
+	fakeData.Commits["abc"] = &scm.Commit{
+		Sha: "abc",
+		Author: scm.Signature{
+			Name:  "James Strachan",
+			Email: "james@private.com",
+			Login: "jstrachan",
+		},
+	}
+	fakeData.Users = append(fakeData.Users, &scm.User{
+		Login:  "jstrachan",
+		Name:   "James Strachan",
+		Avatar: "https://avatars/jstrachan.png",
+	})
+
+	resolver := &users.GitUserResolver{
+		GitProvider: scmClient,
+		Repository:  "myorg/myrepo",
+	}
+	u, err := resolver.CommitAuthorAsUser("abc", &object.Signature{Name: "James Strachan", Email: "james@private.com"})
+	require.NoError(t, err)
+	require.NotNil(t, u)
+	assert.Equal(t, "jstrachan", u.Login)
+	assert.Equal(t, "james@private.com", u.Email)
+	assert.Equal(t, "https://avatars/jstrachan.png", u.AvatarURL)
+
+	// unknown commits fall back to the git signature
+	u, err = resolver.CommitAuthorAsUser("unknown", &object.Signature{Name: "Someone", Email: "someone@else.com"})
+	require.NoError(t, err)
+	require.NotNil(t, u)
+	assert.Equal(t, "", u.Login)
+	assert.Equal(t, "Someone", u.Name)
+}
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+	scmClient, fakeData := scmfake.NewDefault()
+	fakeData.Commits["abc"] = &scm.Commit{
+		Sha:    "abc",
+		Author: scm.Signature{Name: "James Strachan", Email: "james@private.com", Login: "jstrachan"},
+	}
+	fakeData.Users = append(fakeData.Users, &scm.User{
+		Login:  "jstrachan",
+		Name:   "James Strachan",
+		Avatar: "https://avatars/jstrachan.png",
+	})
+	resolver := &users.GitUserResolver{
+		GitProvider: scmClient,
+		Repository:  "myorg/myrepo",
+	}
+	_, err := resolver.CommitAuthorAsUser("abc", &object.Signature{Name: "James Strachan", Email: "james@private.com"})
+	require.NoError(t, err)
+
+	// the restored users are not looked up again on the git provider
+	emptyClient, _ := scmfake.NewDefault()
+	restored := &users.GitUserResolver{
+		GitProvider: emptyClient,
+		Repository:  "myorg/myrepo",
+	}
+	restored.Restore(resolver.Checkpoint())
+	u, err := restored.CommitAuthorAsUser("abc", &object.Signature{Name: "James Strachan", Email: "james@private.com"})
+	require.NoError(t, err)
+	require.NotNil(t, u)
+	assert.Equal(t, "jstrachan", u.Login)
+	assert.Equal(t, "https://avatars/jstrachan.png", u.AvatarURL)
+}
+
+func TestCommitAuthorAsUserSearchesByEmail(t *testing.T) {
+	t.Parallel()
+	var searches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/myorg/myrepo/commits/abc":
+			w.Write([]byte(`{"sha": "abc", "commit": {"author": {"name": "James Strachan", "email": "james@public.com"}}}`)) //nolint:errcheck
+		case "/search/users":
+			searches = append(searches, r.URL.Query().Get("q"))
+			w.Write([]byte(`{"total_count": 1, "items": [{"login": "jstrachan"}]}`)) //nolint:errcheck
+		case "/users/jstrachan":
+			w.Write([]byte(`{"login": "jstrachan", "name": "James Strachan", "avatar_url": "https://avatars/jstrachan.png"}`)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, err := github.New(server.URL)
+	require.NoError(t, err)
+
+	resolver := &users.GitUserResolver{
+		GitProvider: client,
+		Repository:  "myorg/myrepo",
+	}
+	u, err := resolver.CommitAuthorAsUser("abc", &object.Signature{Name: "James Strachan", Email: "james@public.com"})
+	require.NoError(t, err)
+	require.NotNil(t, u)
+	assert.Equal(t, "jstrachan", u.Login)
+	assert.Equal(t, "https://avatars/jstrachan.png", u.AvatarURL)
+	assert.Equal(t, []string{"james@public.com in:email"}, searches)
+
+	// the login is cached by email
+	_, err = resolver.CommitAuthorAsUser("abc", &object.Signature{Name: "James Strachan", Email: "james@public.com"})
+	require.NoError(t, err)
+	assert.Len(t, searches, 1)
+}